@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/personal-excalidraw/backend/internal/adapter/repository/postgres"
+	drawingapp "github.com/personal-excalidraw/backend/internal/application/drawing"
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+	"github.com/personal-excalidraw/backend/internal/infrastructure/config"
+	"github.com/personal-excalidraw/backend/internal/infrastructure/database"
+	"github.com/personal-excalidraw/backend/internal/infrastructure/logger"
+	"github.com/personal-excalidraw/backend/internal/infrastructure/sluggen"
+)
+
+// rewrite-scenes stores every drawing saved with an older scene schema at the current one.
+// Drawings are upgraded on read regardless, so running it is optional
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	appLogger := logger.New(&cfg.Logger)
+
+	db, err := database.NewPostgresDB(&cfg.Database, appLogger)
+	if err != nil {
+		log.Fatalf("Database connection failed: %v", err)
+	}
+	defer db.Close()
+
+	slugGenerator, err := sluggen.NewGenerator()
+	if err != nil {
+		log.Fatalf("Slug generator setup failed: %v", err)
+	}
+	revisionPolicy := drawing.RevisionPolicy{
+		CoalesceWindow: time.Duration(cfg.Revision.CoalesceSeconds) * time.Second,
+		KeepAll:        time.Duration(cfg.Revision.KeepAllMinutes) * time.Minute,
+		KeepHourly:     time.Duration(cfg.Revision.KeepHourlyHours) * time.Hour,
+	}
+	drawingService := drawingapp.NewService(
+		postgres.NewDrawingRepository(db.Pool),
+		postgres.NewRevisionRepository(db.Pool),
+		revisionPolicy,
+		slugGenerator,
+		appLogger,
+	)
+
+	count, err := drawingService.RewriteSceneSchemas(context.Background())
+	if err != nil {
+		log.Fatalf("Scene rewrite failed after %d drawings: %v", count, err)
+	}
+
+	log.Printf("Rewrote %d drawings to scene schema %d", count, drawing.CurrentSceneSchema)
+}
@@ -12,12 +12,16 @@ import (
 
 	httpAdapter "github.com/personal-excalidraw/backend/internal/adapter/http"
 	"github.com/personal-excalidraw/backend/internal/adapter/http/handler"
+	"github.com/personal-excalidraw/backend/internal/adapter/render"
 	"github.com/personal-excalidraw/backend/internal/adapter/repository/postgres"
 	drawingapp "github.com/personal-excalidraw/backend/internal/application/drawing"
+	folderapp "github.com/personal-excalidraw/backend/internal/application/folder"
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
 	"github.com/personal-excalidraw/backend/internal/infrastructure/config"
 	"github.com/personal-excalidraw/backend/internal/infrastructure/database"
 	"github.com/personal-excalidraw/backend/internal/infrastructure/logger"
 	"github.com/personal-excalidraw/backend/internal/infrastructure/migration"
+	"github.com/personal-excalidraw/backend/internal/infrastructure/sluggen"
 )
 
 func main() {
@@ -55,17 +59,56 @@ func main() {
 
 	// 5. Initialize repositories
 	drawingRepo := postgres.NewDrawingRepository(db.Pool)
+	revisionRepo := postgres.NewRevisionRepository(db.Pool)
+	thumbnailRepo := postgres.NewThumbnailRepository(db.Pool)
+	folderRepo := postgres.NewFolderRepository(db.Pool)
 
 	// 6. Initialize application services
-	drawingService := drawingapp.NewService(drawingRepo, appLogger)
+	slugGenerator, err := sluggen.NewGenerator()
+	if err != nil {
+		appLogger.Error("Failed to create slug generator", "error", err)
+		log.Fatalf("Slug generator setup failed: %v", err)
+	}
+	revisionPolicy := drawing.RevisionPolicy{
+		CoalesceWindow: time.Duration(cfg.Revision.CoalesceSeconds) * time.Second,
+		KeepAll:        time.Duration(cfg.Revision.KeepAllMinutes) * time.Minute,
+		KeepHourly:     time.Duration(cfg.Revision.KeepHourlyHours) * time.Hour,
+	}
+	renderer := render.NewRenderer()
+	thumbnailWorker := drawingapp.NewThumbnailWorker(drawingRepo, thumbnailRepo, renderer, appLogger)
+	drawingService := drawingapp.NewService(drawingRepo, revisionRepo, revisionPolicy, slugGenerator, appLogger)
+	drawingService.SetThumbnailScheduler(thumbnailWorker)
+	exportService := drawingapp.NewExportService(drawingRepo, renderer, thumbnailWorker, appLogger)
+	folderService := folderapp.NewService(folderRepo, drawingRepo, appLogger)
+
+	// Assign slugs to drawings created before slugs were generated
+	if _, err := drawingService.BackfillSlugs(context.Background()); err != nil {
+		appLogger.Error("Failed to backfill drawing slugs", "error", err)
+		log.Fatalf("Slug backfill failed: %v", err)
+	}
+
+	// Start background revision compaction and thumbnail rendering
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	compactor := drawingapp.NewRevisionCompactor(
+		revisionRepo,
+		revisionPolicy,
+		time.Duration(cfg.Revision.CompactionIntervalMinutes)*time.Minute,
+		appLogger,
+	)
+	go compactor.Run(workerCtx)
+	go thumbnailWorker.Run(workerCtx)
 
 	// 7. Initialize HTTP handlers
 	healthHandler := handler.NewHealthHandler()
 	drawingHandler := handler.NewDrawingHandler(drawingService, appLogger)
+	exportHandler := handler.NewExportHandler(exportService, appLogger)
+	folderHandler := handler.NewFolderHandler(folderService, appLogger)
 	authHandler := handler.NewAuthHandler()
 
 	// 8. Setup router
-	router := httpAdapter.NewRouter(cfg, healthHandler, drawingHandler, authHandler, appLogger)
+	router := httpAdapter.NewRouter(cfg, healthHandler, drawingHandler, exportHandler, folderHandler, authHandler, appLogger)
 
 	// 9. Create HTTP server
 	serverAddr := fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.Server.Port)
@@ -97,6 +140,9 @@ func main() {
 
 	appLogger.Info("Shutting down server...")
 
+	// Stop background workers
+	stopWorkers()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	drawingapp "github.com/personal-excalidraw/backend/internal/application/drawing"
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// cacheControl lets browsers keep drawings but makes them revalidate on every use,
+// so an unchanged drawing costs a 304 instead of its full payload
+const cacheControl = "private, no-cache"
+
+// etagHashLength is the number of hex characters of the content hash kept in an ETag
+const etagHashLength = 16
+
+// respondDrawing sends a single drawing with its ETag and Last-Modified validators
+func respondDrawing(w http.ResponseWriter, r *http.Request, status int, output *drawingapp.DrawingOutput) {
+	respondWithValidators(w, r, status, strconv.FormatInt(output.Version, 10), output.UpdatedAt, toDrawingResponse(output))
+}
+
+// respondWithValidators sends data as JSON with ETag, Last-Modified and Cache-Control headers.
+// The ETag is tagPrefix followed by a hash of the body. A successful GET whose
+// If-None-Match or If-Modified-Since shows the client's copy is current gets 304 with no body
+func respondWithValidators(w http.ResponseWriter, r *http.Request, status int, tagPrefix string, lastModified time.Time, data interface{}) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	// Match the trailing newline written by util.RespondJSON
+	body = append(body, '\n')
+
+	etag := contentETag(tagPrefix, body)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", cacheControl)
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if status == http.StatusOK && (r.Method == http.MethodGet || r.Method == http.MethodHead) && notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// contentETag formats a strong entity tag from a prefix and a hash of the body
+func contentETag(prefix string, body []byte) string {
+	sum := sha256.Sum256(body)
+	hash := hex.EncodeToString(sum[:])[:etagHashLength]
+	if prefix == "" {
+		return `"` + hash + `"`
+	}
+	return `"` + prefix + "-" + hash + `"`
+}
+
+// notModified evaluates If-None-Match, falling back to If-Modified-Since when it is absent (RFC 9110 section 13.2.2)
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		return etagListMatches(header, etag)
+	}
+
+	header := r.Header.Get("If-Modified-Since")
+	if header == "" || lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(header)
+	if err != nil {
+		return false
+	}
+
+	// HTTP dates have second precision
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// etagListMatches reports whether a comma-separated If-None-Match list contains etag, using weak comparison
+func etagListMatches(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIfMatch returns the drawing versions a client accepts from the If-Match header
+// A missing header or "*" yields no versions, meaning the write is unconditional.
+// Tags that cannot name a drawing version never match (RFC 9110 section 13.1.1); a header
+// with no other tags yields drawing.ErrVersionConflict
+func parseIfMatch(r *http.Request) ([]int64, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	var versions []int64
+	for _, tag := range strings.Split(header, ",") {
+		if version, ok := tagVersion(strings.TrimSpace(tag)); ok {
+			versions = append(versions, version)
+		}
+	}
+
+	if len(versions) == 0 {
+		return nil, drawing.ErrVersionConflict
+	}
+	return versions, nil
+}
+
+// tagVersion returns the drawing version named by a strong entity tag
+func tagVersion(tag string) (int64, bool) {
+	// If-Match uses strong comparison, so weak tags never match
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+
+	// Drawing tags are "<version>-<content hash>"; only the version takes part in the check
+	tag = tag[1 : len(tag)-1]
+	if i := strings.IndexByte(tag, '-'); i >= 0 {
+		tag = tag[:i]
+	}
+
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil || version < 1 {
+		return 0, false
+	}
+
+	return version, true
+}
@@ -1,14 +1,41 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"mime"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	drawingapp "github.com/personal-excalidraw/backend/internal/application/drawing"
 	"github.com/personal-excalidraw/backend/internal/adapter/http/util"
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
 )
 
+// clientIDHeader identifies the editor session sending a save, used to coalesce autosave revisions
+const clientIDHeader = "X-Client-ID"
+
+// maxClientIDLength is the longest client ID accepted, the size of the revision client_id column
+const maxClientIDLength = 64
+
+// apiPrefix is the path the API is served under; the reverse proxy strips it before requests reach the router
+const apiPrefix = "/api"
+
+// Media types accepted by PATCH /api/drawings/{id}
+const (
+	jsonPatchMediaType  = "application/json-patch+json"
+	mergePatchMediaType = "application/merge-patch+json"
+)
+
+// maxPatchBytes limits the size of a PATCH request body
+const maxPatchBytes = 64 << 20
+
 // DrawingHandler handles drawing HTTP requests
 type DrawingHandler struct {
 	service *drawingapp.Service
@@ -32,24 +59,57 @@ type CreateDrawingRequest struct {
 // UpdateDrawingRequest represents the HTTP request for updating a drawing
 type UpdateDrawingRequest struct {
 	Name string                 `json:"name"`
+	Slug string                 `json:"slug,omitempty"`
 	Data map[string]interface{} `json:"data"`
 }
 
 // DrawingResponse represents the HTTP response for a drawing
 type DrawingResponse struct {
 	ID        string                 `json:"id"`
+	Slug      string                 `json:"slug"`
 	Name      string                 `json:"name"`
 	Data      map[string]interface{} `json:"data"`
+	Version   int64                  `json:"version"`
+	CreatedAt string                 `json:"created_at"`
+	UpdatedAt string                 `json:"updated_at"`
+
+	// FolderID is the folder holding the drawing; null outside any folder
+	FolderID *uuid.UUID `json:"folder_id"`
+}
+
+// DrawingSummaryResponse represents a drawing in a list, without its scene unless asked for with fields
+type DrawingSummaryResponse struct {
+	ID           string `json:"id"`
+	Slug         string `json:"slug"`
+	Name         string `json:"name"`
+	Version      int64  `json:"version"`
+	ElementCount int    `json:"element_count"`
+	ByteSize     int64  `json:"byte_size"`
+
+	// FolderID is the folder holding the drawing; null outside any folder
+	FolderID *uuid.UUID `json:"folder_id"`
+
+	// ThumbnailURL changes with each save, so browsers can cache it; the thumbnail is rendered on demand
+	ThumbnailURL string `json:"thumbnail_url"`
+
+	// ThumbnailVersion is the version the stored thumbnail shows, omitted if none is stored yet
+	ThumbnailVersion int64 `json:"thumbnail_version,omitempty"`
+
+	Data      map[string]interface{} `json:"data,omitempty"`
 	CreatedAt string                 `json:"created_at"`
 	UpdatedAt string                 `json:"updated_at"`
 }
 
 // DrawingListResponse represents a paginated list response
 type DrawingListResponse struct {
-	Drawings []*DrawingResponse `json:"drawings"`
-	Total    int64              `json:"total"`
-	Limit    int                `json:"limit"`
-	Offset   int                `json:"offset"`
+	Drawings []*DrawingSummaryResponse `json:"drawings"`
+	Total    int64                     `json:"total"`
+	Limit    int                       `json:"limit"`
+	Offset   int                       `json:"offset"`
+
+	// NextCursor and PrevCursor select the pages around this one with the cursor parameter
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
 }
 
 // CreateDrawing handles POST /api/drawings
@@ -82,15 +142,7 @@ func (h *DrawingHandler) CreateDrawing(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Convert to HTTP response
-	response := DrawingResponse{
-		ID:        output.ID.String(),
-		Name:      output.Name,
-		Data:      output.Data,
-		CreatedAt: output.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt: output.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
-
-	util.RespondJSON(w, http.StatusCreated, response)
+	respondDrawing(w, r, http.StatusCreated, output)
 }
 
 // GetDrawing handles GET /api/drawings/{id}
@@ -117,43 +169,105 @@ func (h *DrawingHandler) GetDrawing(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Convert to HTTP response
-	response := DrawingResponse{
-		ID:        output.ID.String(),
-		Name:      output.Name,
-		Data:      output.Data,
-		CreatedAt: output.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt: output.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	respondDrawing(w, r, http.StatusOK, output)
+}
+
+// GetDrawingBySlug handles GET /api/drawings/by-slug/{slug}
+// Previous slugs of renamed drawings answer with 301 Moved Permanently to the current slug
+func (h *DrawingHandler) GetDrawingBySlug(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("handling get drawing by slug request")
+
+	// Extract slug from path
+	slug := r.PathValue("slug")
+	if slug == "" {
+		h.logger.Error("missing drawing slug in path")
+		response := ErrorResponse{
+			Error:   "invalid_request",
+			Message: "missing drawing slug",
+		}
+		util.RespondJSON(w, http.StatusBadRequest, response)
+		return
+	}
+
+	// Call service
+	output, err := h.service.GetDrawingBySlug(r.Context(), slug)
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	// Redirect previous slugs of renamed drawings to the current slug.
+	// The Location is relative so it resolves correctly behind the /api prefix.
+	if output.Slug != slug {
+		location := url.PathEscape(output.Slug)
+		if r.URL.RawQuery != "" {
+			location += "?" + r.URL.RawQuery
+		}
+		w.Header().Set("Location", location)
+		w.WriteHeader(http.StatusMovedPermanently)
+		return
 	}
 
-	util.RespondJSON(w, http.StatusOK, response)
+	// Convert to HTTP response
+	respondDrawing(w, r, http.StatusOK, output)
 }
 
 // ListDrawings handles GET /api/drawings
+// Drawings are listed as summaries without their scene; fields=data, or any of elements, appState
+// and files separated by commas, adds those fields back. Pages are selected by offset, or by the
+// next_cursor or prev_cursor of a previous page passed as cursor. sort and order pick the order,
+// and the filters of parseListFilter narrow the list and its total
 func (h *DrawingHandler) ListDrawings(w http.ResponseWriter, r *http.Request) {
 	h.logger.Info("handling list drawings request")
 
 	// Parse query parameters
-	limitStr := r.URL.Query().Get("limit")
-	offsetStr := r.URL.Query().Get("offset")
+	limit, offset := parsePagination(r)
 
-	limit := 10 // default
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
+	cursor := r.URL.Query().Get("cursor")
+	if cursor != "" && offset > 0 {
+		h.logger.Error("both cursor and offset parameters")
+		response := ErrorResponse{
+			Error:   "invalid_request",
+			Message: "cursor and offset cannot be combined",
 		}
+		util.RespondJSON(w, http.StatusBadRequest, response)
+		return
 	}
 
-	offset := 0 // default
-	if offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
-			offset = o
+	var fields []drawing.SummaryField
+	if fieldsStr := r.URL.Query().Get("fields"); fieldsStr != "" {
+		for _, name := range strings.Split(fieldsStr, ",") {
+			field := drawing.SummaryField(strings.TrimSpace(name))
+			if !field.Valid() {
+				h.logger.Error("invalid fields parameter", "fields", fieldsStr)
+				response := ErrorResponse{
+					Error:   "invalid_request",
+					Message: "fields must be data, elements, appState or files, separated by commas",
+				}
+				util.RespondJSON(w, http.StatusBadRequest, response)
+				return
+			}
+			fields = append(fields, field)
 		}
 	}
 
+	order, ok := h.parseListOrder(w, r)
+	if !ok {
+		return
+	}
+	filter, ok := h.parseListFilter(w, r)
+	if !ok {
+		return
+	}
+
 	// Call service
 	input := drawingapp.ListDrawingsInput{
 		Limit:  limit,
 		Offset: offset,
+		Cursor: cursor,
+		Order:  order,
+		Filter: filter,
+		Fields: fields,
 	}
 
 	output, err := h.service.ListDrawings(r.Context(), input)
@@ -164,23 +278,23 @@ func (h *DrawingHandler) ListDrawings(w http.ResponseWriter, r *http.Request) {
 
 	// Convert to HTTP response
 	response := DrawingListResponse{
-		Drawings: make([]*DrawingResponse, len(output.Drawings)),
+		Drawings: make([]*DrawingSummaryResponse, len(output.Drawings)),
 		Total:    output.Total,
 		Limit:    output.Limit,
 		Offset:   output.Offset,
+
+		NextCursor: output.NextCursor,
+		PrevCursor: output.PrevCursor,
 	}
 
 	for i, d := range output.Drawings {
-		response.Drawings[i] = &DrawingResponse{
-			ID:        d.ID.String(),
-			Name:      d.Name,
-			Data:      d.Data,
-			CreatedAt: d.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt: d.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		}
+		response.Drawings[i] = toDrawingSummaryResponse(d)
 	}
 
-	util.RespondJSON(w, http.StatusOK, response)
+	// No Last-Modified: a deleted drawing changes the list without moving any update time,
+	// and two changes within one second share an HTTP date, so only the content ETag
+	// tells whether the client's copy is current
+	respondWithValidators(w, r, http.StatusOK, "", time.Time{}, response)
 }
 
 // UpdateDrawing handles PUT /api/drawings/{id}
@@ -199,6 +313,28 @@ func (h *DrawingHandler) UpdateDrawing(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Read the version the client expects to overwrite
+	expectedVersions, err := parseIfMatch(r)
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	// merge=true reconciles the scene with the stored one instead of replacing it
+	merge := false
+	if mergeStr := r.URL.Query().Get("merge"); mergeStr != "" {
+		merge, err = strconv.ParseBool(mergeStr)
+		if err != nil {
+			h.logger.Error("invalid merge parameter", "merge", mergeStr)
+			response := ErrorResponse{
+				Error:   "invalid_request",
+				Message: "merge must be true or false",
+			}
+			util.RespondJSON(w, http.StatusBadRequest, response)
+			return
+		}
+	}
+
 	// Parse request body
 	var req UpdateDrawingRequest
 	if err := parseJSON(r, &req); err != nil {
@@ -212,10 +348,20 @@ func (h *DrawingHandler) UpdateDrawing(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	clientID, ok := h.parseClientID(w, r)
+	if !ok {
+		return
+	}
+
 	// Call service
 	input := drawingapp.UpdateDrawingInput{
-		Name: req.Name,
-		Data: req.Data,
+		Name:     req.Name,
+		Slug:     req.Slug,
+		Data:     req.Data,
+		Merge:    merge,
+		ClientID: clientID,
+
+		ExpectedVersions: expectedVersions,
 	}
 
 	output, err := h.service.UpdateDrawing(r.Context(), id, input)
@@ -225,15 +371,97 @@ func (h *DrawingHandler) UpdateDrawing(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Convert to HTTP response
-	response := DrawingResponse{
-		ID:        output.ID.String(),
-		Name:      output.Name,
-		Data:      output.Data,
-		CreatedAt: output.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt: output.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	respondDrawing(w, r, http.StatusOK, output)
+}
+
+// PatchDrawing handles PATCH /api/drawings/{id}
+// The body is a JSON Patch or Merge Patch document against the drawing data, chosen by Content-Type
+func (h *DrawingHandler) PatchDrawing(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("handling patch drawing request")
+
+	// Extract ID from path
+	id := r.PathValue("id")
+	if id == "" {
+		h.logger.Error("missing drawing ID in path")
+		response := ErrorResponse{
+			Error:   "invalid_request",
+			Message: "missing drawing ID",
+		}
+		util.RespondJSON(w, http.StatusBadRequest, response)
+		return
+	}
+
+	// Pick the patch format from the Content-Type
+	var format drawingapp.PatchFormat
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case jsonPatchMediaType:
+		format = drawingapp.PatchFormatJSONPatch
+	case mergePatchMediaType:
+		format = drawingapp.PatchFormatMergePatch
+	default:
+		h.logger.Error("unsupported patch media type", "content_type", r.Header.Get("Content-Type"))
+		w.Header().Set("Accept-Patch", jsonPatchMediaType+", "+mergePatchMediaType)
+		response := ErrorResponse{
+			Error:   "unsupported_media_type",
+			Message: "Content-Type must be " + jsonPatchMediaType + " or " + mergePatchMediaType,
+		}
+		util.RespondJSON(w, http.StatusUnsupportedMediaType, response)
+		return
+	}
+
+	// Read the version the client expects to patch
+	expectedVersions, err := parseIfMatch(r)
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	clientID, ok := h.parseClientID(w, r)
+	if !ok {
+		return
+	}
+
+	// Read the raw patch document
+	if r.Body == nil {
+		respondError(w, errors.New("request body is empty"), h.logger)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxPatchBytes)
+	defer r.Body.Close()
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.logger.Error("failed to read patch document", "error", err)
+		status := http.StatusBadRequest
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		response := ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		}
+		util.RespondJSON(w, status, response)
+		return
+	}
+
+	// Call service
+	input := drawingapp.PatchDrawingInput{
+		Format:   format,
+		Patch:    patch,
+		ClientID: clientID,
+
+		ExpectedVersions: expectedVersions,
+	}
+
+	output, err := h.service.PatchDrawing(r.Context(), id, input)
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
 	}
 
-	util.RespondJSON(w, http.StatusOK, response)
+	// Convert to HTTP response
+	respondDrawing(w, r, http.StatusOK, output)
 }
 
 // DeleteDrawing handles DELETE /api/drawings/{id}
@@ -252,8 +480,15 @@ func (h *DrawingHandler) DeleteDrawing(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Read the version the client expects to delete
+	expectedVersions, err := parseIfMatch(r)
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
 	// Call service
-	err := h.service.DeleteDrawing(r.Context(), id)
+	err = h.service.DeleteDrawing(r.Context(), id, expectedVersions)
 	if err != nil {
 		respondError(w, err, h.logger)
 		return
@@ -262,3 +497,77 @@ func (h *DrawingHandler) DeleteDrawing(w http.ResponseWriter, r *http.Request) {
 	// Return 204 No Content
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// toDrawingResponse converts a service output into the HTTP response shape
+func toDrawingResponse(output *drawingapp.DrawingOutput) *DrawingResponse {
+	return &DrawingResponse{
+		ID:        output.ID.String(),
+		Slug:      output.Slug,
+		Name:      output.Name,
+		Data:      output.Data,
+		Version:   output.Version,
+		CreatedAt: output.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: output.UpdatedAt.Format(time.RFC3339),
+		FolderID:  output.FolderID,
+	}
+}
+
+// toDrawingSummaryResponse converts a DrawingSummaryOutput to a DrawingSummaryResponse
+func toDrawingSummaryResponse(output *drawingapp.DrawingSummaryOutput) *DrawingSummaryResponse {
+	return &DrawingSummaryResponse{
+		ID:               output.ID.String(),
+		Slug:             output.Slug,
+		Name:             output.Name,
+		Version:          output.Version,
+		ElementCount:     output.ElementCount,
+		ByteSize:         output.ByteSize,
+		FolderID:         output.FolderID,
+		ThumbnailURL:     thumbnailURL(output),
+		ThumbnailVersion: output.ThumbnailVersion,
+		Data:             output.Data,
+		CreatedAt:        output.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:        output.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// thumbnailURL returns the URL of a drawing's thumbnail as seen by clients, behind the /api prefix
+func thumbnailURL(output *drawingapp.DrawingSummaryOutput) string {
+	return fmt.Sprintf("%s/drawings/%s/thumbnail.png?v=%d", apiPrefix, output.ID, output.Version)
+}
+
+// parsePagination reads the limit and offset query parameters, ignoring invalid values
+func parsePagination(r *http.Request) (limit, offset int) {
+	limitStr := r.URL.Query().Get("limit")
+	offsetStr := r.URL.Query().Get("offset")
+
+	limit = 10 // default
+	if limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	offset = 0 // default
+	if offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	return limit, offset
+}
+
+// parseClientID reads the X-Client-ID header, answering 400 if it is longer than a revision can record
+func (h *DrawingHandler) parseClientID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	clientID := r.Header.Get(clientIDHeader)
+	if len(clientID) > maxClientIDLength {
+		h.logger.Error("invalid client ID header", "length", len(clientID))
+		response := ErrorResponse{
+			Error:   "invalid_request",
+			Message: clientIDHeader + " must be at most 64 bytes",
+		}
+		util.RespondJSON(w, http.StatusBadRequest, response)
+		return "", false
+	}
+	return clientID, true
+}
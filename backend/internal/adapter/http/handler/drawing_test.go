@@ -9,7 +9,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	drawingapp "github.com/personal-excalidraw/backend/internal/application/drawing"
@@ -18,32 +21,61 @@ import (
 
 // mockDrawingRepository is a mock implementation for testing
 type mockDrawingRepository struct {
-	createFunc     func(ctx context.Context, d *drawing.Drawing) error
-	findAllFunc    func(ctx context.Context, limit, offset int) ([]*drawing.Drawing, error)
-	countFunc      func(ctx context.Context) (int64, error)
-	findByIDFunc   func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error)
-	findBySlugFunc func(ctx context.Context, slug string) (*drawing.Drawing, error)
-	updateFunc     func(ctx context.Context, d *drawing.Drawing) error
-	deleteFunc     func(ctx context.Context, id uuid.UUID) error
+	createFunc        func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error
+	findSummariesFunc func(ctx context.Context, query drawing.SummaryQuery, limit, offset int) ([]*drawing.DrawingSummary, error)
+	findByCursorFunc  func(ctx context.Context, query drawing.SummaryQuery, cursor *drawing.Cursor, limit int) ([]*drawing.DrawingSummary, error)
+	countFunc         func(ctx context.Context, filter drawing.DrawingFilter) (int64, error)
+	searchFunc        func(ctx context.Context, query string, limit, offset int) ([]*drawing.SearchResult, error)
+	countSearchFunc   func(ctx context.Context, query string) (int64, error)
+	findByIDFunc      func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error)
+	findBySlugFunc    func(ctx context.Context, slug string) (*drawing.Drawing, error)
+	updateFunc        func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error
+	deleteFunc        func(ctx context.Context, id uuid.UUID, expectedVersions []int64) error
+	moveToFolderFunc  func(ctx context.Context, id uuid.UUID, folderID *uuid.UUID) error
+
+	findBySlugAliasFunc func(ctx context.Context, slug string) (*drawing.Drawing, error)
+	findWithoutSlugFunc func(ctx context.Context, limit int) ([]*drawing.Drawing, error)
+	updateSlugFunc      func(ctx context.Context, id uuid.UUID, slug string) error
 }
 
-func (m *mockDrawingRepository) Create(ctx context.Context, d *drawing.Drawing) error {
+func (m *mockDrawingRepository) Create(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
 	if m.createFunc != nil {
-		return m.createFunc(ctx, d)
+		return m.createFunc(ctx, d, rev)
 	}
 	return errors.New("not implemented")
 }
 
-func (m *mockDrawingRepository) FindAll(ctx context.Context, limit, offset int) ([]*drawing.Drawing, error) {
-	if m.findAllFunc != nil {
-		return m.findAllFunc(ctx, limit, offset)
+func (m *mockDrawingRepository) FindSummaries(ctx context.Context, query drawing.SummaryQuery, limit, offset int) ([]*drawing.DrawingSummary, error) {
+	if m.findSummariesFunc != nil {
+		return m.findSummariesFunc(ctx, query, limit, offset)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockDrawingRepository) FindSummariesByCursor(ctx context.Context, query drawing.SummaryQuery, cursor *drawing.Cursor, limit int) ([]*drawing.DrawingSummary, error) {
+	if m.findByCursorFunc != nil {
+		return m.findByCursorFunc(ctx, query, cursor, limit)
 	}
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockDrawingRepository) Count(ctx context.Context) (int64, error) {
+func (m *mockDrawingRepository) Count(ctx context.Context, filter drawing.DrawingFilter) (int64, error) {
 	if m.countFunc != nil {
-		return m.countFunc(ctx)
+		return m.countFunc(ctx, filter)
+	}
+	return 0, errors.New("not implemented")
+}
+
+func (m *mockDrawingRepository) Search(ctx context.Context, query string, limit, offset int) ([]*drawing.SearchResult, error) {
+	if m.searchFunc != nil {
+		return m.searchFunc(ctx, query, limit, offset)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockDrawingRepository) CountSearch(ctx context.Context, query string) (int64, error) {
+	if m.countSearchFunc != nil {
+		return m.countSearchFunc(ctx, query)
 	}
 	return 0, errors.New("not implemented")
 }
@@ -62,20 +94,120 @@ func (m *mockDrawingRepository) FindBySlug(ctx context.Context, slug string) (*d
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockDrawingRepository) Update(ctx context.Context, d *drawing.Drawing) error {
+func (m *mockDrawingRepository) Update(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
 	if m.updateFunc != nil {
-		return m.updateFunc(ctx, d)
+		return m.updateFunc(ctx, d, rev)
 	}
 	return errors.New("not implemented")
 }
 
-func (m *mockDrawingRepository) Delete(ctx context.Context, id uuid.UUID) error {
+func (m *mockDrawingRepository) Delete(ctx context.Context, id uuid.UUID, expectedVersions []int64) error {
 	if m.deleteFunc != nil {
-		return m.deleteFunc(ctx, id)
+		return m.deleteFunc(ctx, id, expectedVersions)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *mockDrawingRepository) MoveToFolder(ctx context.Context, id uuid.UUID, folderID *uuid.UUID) error {
+	if m.moveToFolderFunc != nil {
+		return m.moveToFolderFunc(ctx, id, folderID)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *mockDrawingRepository) FindBySlugAlias(ctx context.Context, slug string) (*drawing.Drawing, error) {
+	if m.findBySlugAliasFunc != nil {
+		return m.findBySlugAliasFunc(ctx, slug)
+	}
+	return nil, drawing.ErrDrawingNotFound
+}
+
+func (m *mockDrawingRepository) FindWithoutSlug(ctx context.Context, limit int) ([]*drawing.Drawing, error) {
+	if m.findWithoutSlugFunc != nil {
+		return m.findWithoutSlugFunc(ctx, limit)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockDrawingRepository) UpdateSlug(ctx context.Context, id uuid.UUID, slug string) error {
+	if m.updateSlugFunc != nil {
+		return m.updateSlugFunc(ctx, id, slug)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *mockDrawingRepository) FindWithOutdatedSchema(ctx context.Context, limit int) ([]*drawing.Drawing, error) {
+	return nil, nil
+}
+
+func (m *mockDrawingRepository) RewriteData(ctx context.Context, d *drawing.Drawing) (bool, error) {
+	return false, errors.New("not implemented")
+}
+
+// mockRevisionRepository is a mock implementation of the revision repository
+type mockRevisionRepository struct {
+	findByDrawingIDFunc  func(ctx context.Context, drawingID uuid.UUID, limit, offset int) ([]*drawing.Revision, error)
+	findByNumberFunc     func(ctx context.Context, drawingID uuid.UUID, number int) (*drawing.Revision, error)
+	countByDrawingIDFunc func(ctx context.Context, drawingID uuid.UUID) (int64, error)
+	findLatestMetaFunc   func(ctx context.Context, drawingID uuid.UUID) (*drawing.RevisionMeta, error)
+}
+
+func (m *mockRevisionRepository) FindByDrawingID(ctx context.Context, drawingID uuid.UUID, limit, offset int) ([]*drawing.Revision, error) {
+	if m.findByDrawingIDFunc != nil {
+		return m.findByDrawingIDFunc(ctx, drawingID, limit, offset)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockRevisionRepository) FindByNumber(ctx context.Context, drawingID uuid.UUID, number int) (*drawing.Revision, error) {
+	if m.findByNumberFunc != nil {
+		return m.findByNumberFunc(ctx, drawingID, number)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockRevisionRepository) CountByDrawingID(ctx context.Context, drawingID uuid.UUID) (int64, error) {
+	if m.countByDrawingIDFunc != nil {
+		return m.countByDrawingIDFunc(ctx, drawingID)
+	}
+	return 0, errors.New("not implemented")
+}
+
+func (m *mockRevisionRepository) FindLatestMeta(ctx context.Context, drawingID uuid.UUID) (*drawing.RevisionMeta, error) {
+	if m.findLatestMetaFunc != nil {
+		return m.findLatestMetaFunc(ctx, drawingID)
 	}
+	return nil, drawing.ErrRevisionNotFound
+}
+
+func (m *mockRevisionRepository) FindCompactionCandidates(ctx context.Context, policy drawing.RevisionPolicy, now time.Time) ([]uuid.UUID, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockRevisionRepository) MarkCompacted(ctx context.Context, drawingID uuid.UUID, at time.Time) error {
+	return errors.New("not implemented")
+}
+
+func (m *mockRevisionRepository) FindMetaByDrawingID(ctx context.Context, drawingID uuid.UUID) ([]drawing.RevisionMeta, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockRevisionRepository) DeleteByIDs(ctx context.Context, ids []uuid.UUID) error {
 	return errors.New("not implemented")
 }
 
+// mockSlugGenerator is a mock implementation of the slug generator
+type mockSlugGenerator struct {
+	generateFunc func() (string, error)
+}
+
+func (m *mockSlugGenerator) Generate() (string, error) {
+	if m.generateFunc != nil {
+		return m.generateFunc()
+	}
+	return "Xk9pQ2mR", nil
+}
+
 func TestCreateDrawing(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 
@@ -96,7 +228,7 @@ func TestCreateDrawing(t *testing.T) {
 				},
 			},
 			mockRepo: &mockDrawingRepository{
-				createFunc: func(ctx context.Context, d *drawing.Drawing) error {
+				createFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
 					return nil
 				},
 			},
@@ -163,7 +295,7 @@ func TestCreateDrawing(t *testing.T) {
 				Data: map[string]interface{}{"elements": []interface{}{}},
 			},
 			mockRepo: &mockDrawingRepository{
-				createFunc: func(ctx context.Context, d *drawing.Drawing) error {
+				createFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
 					return errors.New("database connection failed")
 				},
 			},
@@ -178,11 +310,32 @@ func TestCreateDrawing(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "data that is not a scene",
+			requestBody: CreateDrawingRequest{
+				Name: "Not a scene",
+				Data: map[string]interface{}{"foo": 1},
+			},
+			mockRepo:       &mockDrawingRepository{},
+			expectedStatus: http.StatusBadRequest,
+			validateResp: func(t *testing.T, body []byte) {
+				var resp ErrorResponse
+				if err := json.Unmarshal(body, &resp); err != nil {
+					t.Fatalf("failed to unmarshal error response: %v", err)
+				}
+				if resp.Error != "invalid_data" {
+					t.Errorf("expected error type 'invalid_data', got '%s'", resp.Error)
+				}
+				if resp.Details["data.elements"] != "is required" {
+					t.Errorf("expected field error for data.elements, got %v", resp.Details)
+				}
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			service := drawingapp.NewService(tt.mockRepo, logger)
+			service := drawingapp.NewService(tt.mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
 			handler := NewDrawingHandler(service, logger)
 
 			var body []byte
@@ -225,12 +378,12 @@ func TestListDrawings(t *testing.T) {
 			name:        "successful list with defaults",
 			queryParams: "",
 			mockRepo: &mockDrawingRepository{
-				findAllFunc: func(ctx context.Context, limit, offset int) ([]*drawing.Drawing, error) {
-					d1, _ := drawing.NewDrawing("Drawing 1", map[string]interface{}{"elements": []interface{}{}})
-					d2, _ := drawing.NewDrawing("Drawing 2", map[string]interface{}{"elements": []interface{}{}})
-					return []*drawing.Drawing{d1, d2}, nil
+				findSummariesFunc: func(ctx context.Context, query drawing.SummaryQuery, limit, offset int) ([]*drawing.DrawingSummary, error) {
+					d1 := &drawing.DrawingSummary{ID: uuid.New(), Name: "Drawing 1"}
+					d2 := &drawing.DrawingSummary{ID: uuid.New(), Name: "Drawing 2"}
+					return []*drawing.DrawingSummary{d1, d2}, nil
 				},
-				countFunc: func(ctx context.Context) (int64, error) {
+				countFunc: func(ctx context.Context, filter drawing.DrawingFilter) (int64, error) {
 					return 2, nil
 				},
 			},
@@ -258,16 +411,16 @@ func TestListDrawings(t *testing.T) {
 			name:        "successful list with custom pagination",
 			queryParams: "?limit=5&offset=10",
 			mockRepo: &mockDrawingRepository{
-				findAllFunc: func(ctx context.Context, limit, offset int) ([]*drawing.Drawing, error) {
+				findSummariesFunc: func(ctx context.Context, query drawing.SummaryQuery, limit, offset int) ([]*drawing.DrawingSummary, error) {
 					if limit != 5 {
 						t.Errorf("expected limit 5, got %d", limit)
 					}
 					if offset != 10 {
 						t.Errorf("expected offset 10, got %d", offset)
 					}
-					return []*drawing.Drawing{}, nil
+					return []*drawing.DrawingSummary{}, nil
 				},
-				countFunc: func(ctx context.Context) (int64, error) {
+				countFunc: func(ctx context.Context, filter drawing.DrawingFilter) (int64, error) {
 					return 100, nil
 				},
 			},
@@ -289,10 +442,10 @@ func TestListDrawings(t *testing.T) {
 			name:        "empty list",
 			queryParams: "",
 			mockRepo: &mockDrawingRepository{
-				findAllFunc: func(ctx context.Context, limit, offset int) ([]*drawing.Drawing, error) {
-					return []*drawing.Drawing{}, nil
+				findSummariesFunc: func(ctx context.Context, query drawing.SummaryQuery, limit, offset int) ([]*drawing.DrawingSummary, error) {
+					return []*drawing.DrawingSummary{}, nil
 				},
-				countFunc: func(ctx context.Context) (int64, error) {
+				countFunc: func(ctx context.Context, filter drawing.DrawingFilter) (int64, error) {
 					return 0, nil
 				},
 			},
@@ -314,10 +467,10 @@ func TestListDrawings(t *testing.T) {
 			name:        "invalid pagination parameters - negative values ignored",
 			queryParams: "?limit=-5&offset=-10",
 			mockRepo: &mockDrawingRepository{
-				findAllFunc: func(ctx context.Context, limit, offset int) ([]*drawing.Drawing, error) {
-					return []*drawing.Drawing{}, nil
+				findSummariesFunc: func(ctx context.Context, query drawing.SummaryQuery, limit, offset int) ([]*drawing.DrawingSummary, error) {
+					return []*drawing.DrawingSummary{}, nil
 				},
-				countFunc: func(ctx context.Context) (int64, error) {
+				countFunc: func(ctx context.Context, filter drawing.DrawingFilter) (int64, error) {
 					return 0, nil
 				},
 			},
@@ -325,10 +478,140 @@ func TestListDrawings(t *testing.T) {
 			validateResp:   func(t *testing.T, body []byte) {},
 		},
 		{
-			name:        "repository error on FindAll",
+			name:        "summaries leave the scene out",
+			queryParams: "",
+			mockRepo: &mockDrawingRepository{
+				findSummariesFunc: func(ctx context.Context, query drawing.SummaryQuery, limit, offset int) ([]*drawing.DrawingSummary, error) {
+					if len(query.Fields) != 0 {
+						t.Errorf("expected no fields, got %v", query.Fields)
+					}
+					return []*drawing.DrawingSummary{{ID: uuid.New(), Name: "Drawing 1", Version: 4, ElementCount: 12, ByteSize: 2048, ThumbnailVersion: 3}}, nil
+				},
+				countFunc: func(ctx context.Context, filter drawing.DrawingFilter) (int64, error) {
+					return 1, nil
+				},
+			},
+			expectedStatus: http.StatusOK,
+			validateResp: func(t *testing.T, body []byte) {
+				var resp struct {
+					Drawings []map[string]interface{} `json:"drawings"`
+				}
+				if err := json.Unmarshal(body, &resp); err != nil {
+					t.Fatalf("failed to unmarshal response: %v", err)
+				}
+				d := resp.Drawings[0]
+				if _, ok := d["data"]; ok {
+					t.Error("expected no data in the summary")
+				}
+				if d["element_count"] != 12.0 || d["byte_size"] != 2048.0 || d["thumbnail_version"] != 3.0 {
+					t.Errorf("expected the summary fields, got %v", d)
+				}
+				if url, _ := d["thumbnail_url"].(string); !strings.HasSuffix(url, "/thumbnail.png?v=4") {
+					t.Errorf("expected the thumbnail URL of version 4, got %q", url)
+				}
+			},
+		},
+		{
+			name:        "fields add scene members back",
+			queryParams: "?fields=elements,%20appState",
+			mockRepo: &mockDrawingRepository{
+				findSummariesFunc: func(ctx context.Context, query drawing.SummaryQuery, limit, offset int) ([]*drawing.DrawingSummary, error) {
+					if fields := query.Fields; len(fields) != 2 || fields[0] != drawing.SummaryFieldElements || fields[1] != drawing.SummaryFieldAppState {
+						t.Errorf("expected elements and appState, got %v", fields)
+					}
+					data := drawing.DrawingData{"elements": []interface{}{}, "appState": map[string]interface{}{}}
+					return []*drawing.DrawingSummary{{ID: uuid.New(), Name: "Drawing 1", Data: data}}, nil
+				},
+				countFunc: func(ctx context.Context, filter drawing.DrawingFilter) (int64, error) {
+					return 1, nil
+				},
+			},
+			expectedStatus: http.StatusOK,
+			validateResp: func(t *testing.T, body []byte) {
+				var resp DrawingListResponse
+				if err := json.Unmarshal(body, &resp); err != nil {
+					t.Fatalf("failed to unmarshal response: %v", err)
+				}
+				if _, ok := resp.Drawings[0].Data["elements"]; !ok {
+					t.Error("expected the elements in the summary")
+				}
+			},
+		},
+		{
+			name:           "unknown field",
+			queryParams:    "?fields=elements,thumbnail",
+			mockRepo:       &mockDrawingRepository{},
+			expectedStatus: http.StatusBadRequest,
+			validateResp: func(t *testing.T, body []byte) {
+				var resp ErrorResponse
+				if err := json.Unmarshal(body, &resp); err != nil {
+					t.Fatalf("failed to unmarshal error response: %v", err)
+				}
+				if resp.Error != "invalid_request" {
+					t.Errorf("expected error type 'invalid_request', got '%s'", resp.Error)
+				}
+			},
+		},
+		{
+			name:           "cursor with offset",
+			queryParams:    "?cursor=abc&offset=10",
+			mockRepo:       &mockDrawingRepository{},
+			expectedStatus: http.StatusBadRequest,
+			validateResp: func(t *testing.T, body []byte) {
+				var resp ErrorResponse
+				if err := json.Unmarshal(body, &resp); err != nil {
+					t.Fatalf("failed to unmarshal error response: %v", err)
+				}
+				if resp.Error != "invalid_request" {
+					t.Errorf("expected error type 'invalid_request', got '%s'", resp.Error)
+				}
+			},
+		},
+		{
+			name:           "invalid cursor",
+			queryParams:    "?cursor=not-a-cursor",
+			mockRepo:       &mockDrawingRepository{},
+			expectedStatus: http.StatusBadRequest,
+			validateResp: func(t *testing.T, body []byte) {
+				var resp ErrorResponse
+				if err := json.Unmarshal(body, &resp); err != nil {
+					t.Fatalf("failed to unmarshal error response: %v", err)
+				}
+				if resp.Error != "invalid_cursor" {
+					t.Errorf("expected error type 'invalid_cursor', got '%s'", resp.Error)
+				}
+			},
+		},
+		{
+			name:        "cursor page",
+			queryParams: "?limit=1&cursor=" + drawing.Cursor{Key: "2025-12-05 10:30:00", ID: uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")}.Encode(),
+			mockRepo: &mockDrawingRepository{
+				findByCursorFunc: func(ctx context.Context, query drawing.SummaryQuery, cursor *drawing.Cursor, limit int) ([]*drawing.DrawingSummary, error) {
+					if cursor.Key != "2025-12-05 10:30:00" || cursor.Before || limit != 2 {
+						t.Errorf("expected the cursor and one more drawing than the page, got %+v %d", cursor, limit)
+					}
+					return []*drawing.DrawingSummary{{ID: uuid.New(), Name: "Drawing 1"}, {ID: uuid.New(), Name: "Drawing 2"}}, nil
+				},
+				countFunc: func(ctx context.Context, filter drawing.DrawingFilter) (int64, error) {
+					return 5, nil
+				},
+			},
+			expectedStatus: http.StatusOK,
+			validateResp: func(t *testing.T, body []byte) {
+				var resp DrawingListResponse
+				if err := json.Unmarshal(body, &resp); err != nil {
+					t.Fatalf("failed to unmarshal response: %v", err)
+				}
+				if len(resp.Drawings) != 1 || resp.NextCursor == "" || resp.PrevCursor == "" {
+					t.Errorf("expected one drawing with both cursors, got %d %q %q", len(resp.Drawings), resp.NextCursor, resp.PrevCursor)
+				}
+			},
+		},
+		{
+			name:        "repository error on FindSummaries",
 			queryParams: "",
 			mockRepo: &mockDrawingRepository{
-				findAllFunc: func(ctx context.Context, limit, offset int) ([]*drawing.Drawing, error) {
+				findSummariesFunc: func(ctx context.Context, query drawing.SummaryQuery, limit, offset int) ([]*drawing.DrawingSummary, error) {
 					return nil, errors.New("database connection failed")
 				},
 			},
@@ -347,10 +630,10 @@ func TestListDrawings(t *testing.T) {
 			name:        "repository error on Count",
 			queryParams: "",
 			mockRepo: &mockDrawingRepository{
-				findAllFunc: func(ctx context.Context, limit, offset int) ([]*drawing.Drawing, error) {
-					return []*drawing.Drawing{}, nil
+				findSummariesFunc: func(ctx context.Context, query drawing.SummaryQuery, limit, offset int) ([]*drawing.DrawingSummary, error) {
+					return []*drawing.DrawingSummary{}, nil
 				},
-				countFunc: func(ctx context.Context) (int64, error) {
+				countFunc: func(ctx context.Context, filter drawing.DrawingFilter) (int64, error) {
 					return 0, errors.New("database connection failed")
 				},
 			},
@@ -369,7 +652,7 @@ func TestListDrawings(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			service := drawingapp.NewService(tt.mockRepo, logger)
+			service := drawingapp.NewService(tt.mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
 			handler := NewDrawingHandler(service, logger)
 
 			req := httptest.NewRequest(http.MethodGet, "/drawings"+tt.queryParams, nil)
@@ -508,7 +791,7 @@ func TestGetDrawing(t *testing.T) {
 				findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
 					d, _ := drawing.NewDrawing("Complete Drawing", map[string]interface{}{
 						"elements": []interface{}{
-							map[string]interface{}{"type": "rectangle"},
+							map[string]interface{}{"id": "rect-1", "type": "rectangle"},
 						},
 						"appState": map[string]interface{}{"zoom": 1.0},
 						"files":    map[string]interface{}{},
@@ -534,7 +817,7 @@ func TestGetDrawing(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			service := drawingapp.NewService(tt.mockRepo, logger)
+			service := drawingapp.NewService(tt.mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
 			handler := NewDrawingHandler(service, logger)
 
 			req := httptest.NewRequest(http.MethodGet, "/drawings/"+tt.drawingID, nil)
@@ -554,12 +837,128 @@ func TestGetDrawing(t *testing.T) {
 	}
 }
 
+func TestGetDrawingBySlug(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	tests := []struct {
+		name           string
+		slug           string
+		mockRepo       *mockDrawingRepository
+		expectedStatus int
+		validateResp   func(t *testing.T, body []byte)
+	}{
+		{
+			name: "successful get by slug",
+			slug: "Xk9pQ2mR",
+			mockRepo: &mockDrawingRepository{
+				findBySlugFunc: func(ctx context.Context, slug string) (*drawing.Drawing, error) {
+					d, _ := drawing.NewDrawing("Test Drawing", map[string]interface{}{"elements": []interface{}{}})
+					d.SetSlug(slug)
+					return d, nil
+				},
+			},
+			expectedStatus: http.StatusOK,
+			validateResp: func(t *testing.T, body []byte) {
+				var resp DrawingResponse
+				if err := json.Unmarshal(body, &resp); err != nil {
+					t.Fatalf("failed to unmarshal response: %v", err)
+				}
+				if resp.Slug != "Xk9pQ2mR" {
+					t.Errorf("expected slug 'Xk9pQ2mR', got '%s'", resp.Slug)
+				}
+				if resp.Name != "Test Drawing" {
+					t.Errorf("expected name 'Test Drawing', got '%s'", resp.Name)
+				}
+			},
+		},
+		{
+			name: "slug not found",
+			slug: "missing1",
+			mockRepo: &mockDrawingRepository{
+				findBySlugFunc: func(ctx context.Context, slug string) (*drawing.Drawing, error) {
+					return nil, drawing.ErrDrawingNotFound
+				},
+			},
+			expectedStatus: http.StatusNotFound,
+			validateResp: func(t *testing.T, body []byte) {
+				var resp ErrorResponse
+				if err := json.Unmarshal(body, &resp); err != nil {
+					t.Fatalf("failed to unmarshal error response: %v", err)
+				}
+				if resp.Error != "not_found" {
+					t.Errorf("expected error type 'not_found', got '%s'", resp.Error)
+				}
+			},
+		},
+		{
+			name:           "empty slug",
+			slug:           "",
+			mockRepo:       &mockDrawingRepository{},
+			expectedStatus: http.StatusBadRequest,
+			validateResp:   func(t *testing.T, body []byte) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := drawingapp.NewService(tt.mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+			handler := NewDrawingHandler(service, logger)
+
+			req := httptest.NewRequest(http.MethodGet, "/drawings/by-slug/"+tt.slug, nil)
+			req.SetPathValue("slug", tt.slug)
+			w := httptest.NewRecorder()
+
+			handler.GetDrawingBySlug(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			if tt.validateResp != nil {
+				tt.validateResp(t, w.Body.Bytes())
+			}
+		})
+	}
+}
+
+func TestGetDrawingBySlugRedirect(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	mockRepo := &mockDrawingRepository{
+		findBySlugFunc: func(ctx context.Context, slug string) (*drawing.Drawing, error) {
+			return nil, drawing.ErrDrawingNotFound
+		},
+		findBySlugAliasFunc: func(ctx context.Context, slug string) (*drawing.Drawing, error) {
+			d, _ := drawing.NewDrawing("Renamed Drawing", map[string]interface{}{"elements": []interface{}{}})
+			d.SetSlug("payments-architecture")
+			return d, nil
+		},
+	}
+
+	service := drawingapp.NewService(mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+	handler := NewDrawingHandler(service, logger)
+
+	req := httptest.NewRequest(http.MethodGet, "/drawings/by-slug/Xk9pQ2mR?view=1", nil)
+	req.SetPathValue("slug", "Xk9pQ2mR")
+	w := httptest.NewRecorder()
+
+	handler.GetDrawingBySlug(w, req)
+
+	if w.Code != http.StatusMovedPermanently {
+		t.Errorf("expected status %d, got %d", http.StatusMovedPermanently, w.Code)
+	}
+	if location := w.Header().Get("Location"); location != "payments-architecture?view=1" {
+		t.Errorf("expected Location 'payments-architecture?view=1', got '%s'", location)
+	}
+}
+
 func TestUpdateDrawing(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 
 	tests := []struct {
 		name           string
 		drawingID      string
+		query          string
 		requestBody    interface{}
 		mockRepo       *mockDrawingRepository
 		expectedStatus int
@@ -580,7 +979,7 @@ func TestUpdateDrawing(t *testing.T) {
 					d, _ := drawing.NewDrawing("Original", map[string]interface{}{"elements": []interface{}{}})
 					return d, nil
 				},
-				updateFunc: func(ctx context.Context, d *drawing.Drawing) error {
+				updateFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
 					return nil
 				},
 			},
@@ -749,7 +1148,7 @@ func TestUpdateDrawing(t *testing.T) {
 				Name: "Complex Updated Drawing",
 				Data: map[string]interface{}{
 					"elements": []interface{}{
-						map[string]interface{}{"type": "rectangle"},
+						map[string]interface{}{"id": "rect-1", "type": "rectangle"},
 					},
 					"appState": map[string]interface{}{"zoom": 1.5},
 					"files":    map[string]interface{}{},
@@ -760,7 +1159,7 @@ func TestUpdateDrawing(t *testing.T) {
 					d, _ := drawing.NewDrawing("Original", map[string]interface{}{"elements": []interface{}{}})
 					return d, nil
 				},
-				updateFunc: func(ctx context.Context, d *drawing.Drawing) error {
+				updateFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
 					return nil
 				},
 			},
@@ -794,7 +1193,7 @@ func TestUpdateDrawing(t *testing.T) {
 					d, _ := drawing.NewDrawing("Original", map[string]interface{}{"elements": []interface{}{}})
 					return d, nil
 				},
-				updateFunc: func(ctx context.Context, d *drawing.Drawing) error {
+				updateFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
 					return nil
 				},
 			},
@@ -821,23 +1220,171 @@ func TestUpdateDrawing(t *testing.T) {
 				}
 			},
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			service := drawingapp.NewService(tt.mockRepo, logger)
-			handler := NewDrawingHandler(service, logger)
-
-			var body []byte
-			var err error
-			if tt.requestBody != nil {
-				body, err = json.Marshal(tt.requestBody)
-				if err != nil {
-					t.Fatalf("failed to marshal request body: %v", err)
+		{
+			name:      "custom slug",
+			drawingID: "123e4567-e89b-12d3-a456-426614174000",
+			requestBody: UpdateDrawingRequest{
+				Slug: "payments-architecture",
+			},
+			mockRepo: &mockDrawingRepository{
+				findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
+					d, _ := drawing.NewDrawing("Original", map[string]interface{}{"elements": []interface{}{}})
+					d.SetSlug("Xk9pQ2mR")
+					return d, nil
+				},
+				updateFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+					return nil
+				},
+			},
+			expectedStatus: http.StatusOK,
+			validateResp: func(t *testing.T, body []byte) {
+				var resp DrawingResponse
+				if err := json.Unmarshal(body, &resp); err != nil {
+					t.Fatalf("failed to unmarshal response: %v", err)
+				}
+				if resp.Slug != "payments-architecture" {
+					t.Errorf("expected slug 'payments-architecture', got '%s'", resp.Slug)
+				}
+			},
+		},
+		{
+			name:      "invalid custom slug",
+			drawingID: "123e4567-e89b-12d3-a456-426614174000",
+			requestBody: UpdateDrawingRequest{
+				Slug: "Payments Architecture",
+			},
+			mockRepo: &mockDrawingRepository{
+				findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
+					d, _ := drawing.NewDrawing("Original", map[string]interface{}{"elements": []interface{}{}})
+					return d, nil
+				},
+			},
+			expectedStatus: http.StatusBadRequest,
+			validateResp: func(t *testing.T, body []byte) {
+				var resp ErrorResponse
+				if err := json.Unmarshal(body, &resp); err != nil {
+					t.Fatalf("failed to unmarshal error response: %v", err)
+				}
+				if resp.Error != "invalid_slug" {
+					t.Errorf("expected error type 'invalid_slug', got '%s'", resp.Error)
+				}
+			},
+		},
+		{
+			name:      "reserved custom slug",
+			drawingID: "123e4567-e89b-12d3-a456-426614174000",
+			requestBody: UpdateDrawingRequest{
+				Slug: "new",
+			},
+			mockRepo: &mockDrawingRepository{
+				findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
+					d, _ := drawing.NewDrawing("Original", map[string]interface{}{"elements": []interface{}{}})
+					return d, nil
+				},
+			},
+			expectedStatus: http.StatusBadRequest,
+			validateResp: func(t *testing.T, body []byte) {
+				var resp ErrorResponse
+				if err := json.Unmarshal(body, &resp); err != nil {
+					t.Fatalf("failed to unmarshal error response: %v", err)
+				}
+				if resp.Error != "reserved_slug" {
+					t.Errorf("expected error type 'reserved_slug', got '%s'", resp.Error)
+				}
+			},
+		},
+		{
+			name:      "custom slug already in use",
+			drawingID: "123e4567-e89b-12d3-a456-426614174000",
+			requestBody: UpdateDrawingRequest{
+				Slug: "payments-architecture",
+			},
+			mockRepo: &mockDrawingRepository{
+				findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
+					d, _ := drawing.NewDrawing("Original", map[string]interface{}{"elements": []interface{}{}})
+					return d, nil
+				},
+				updateFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+					return drawing.ErrSlugConflict
+				},
+			},
+			expectedStatus: http.StatusConflict,
+			validateResp: func(t *testing.T, body []byte) {
+				var resp ErrorResponse
+				if err := json.Unmarshal(body, &resp); err != nil {
+					t.Fatalf("failed to unmarshal error response: %v", err)
+				}
+				if resp.Error != "slug_conflict" {
+					t.Errorf("expected error type 'slug_conflict', got '%s'", resp.Error)
+				}
+			},
+		},
+		{
+			name:      "merge keeps elements saved by another client",
+			drawingID: "123e4567-e89b-12d3-a456-426614174000",
+			query:     "?merge=true",
+			requestBody: UpdateDrawingRequest{
+				Data: map[string]interface{}{
+					"elements": []interface{}{map[string]interface{}{"id": "b", "type": "rectangle", "version": 1}},
+				},
+			},
+			mockRepo: &mockDrawingRepository{
+				findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
+					return drawing.NewDrawing("Test Drawing", map[string]interface{}{
+						"elements": []interface{}{map[string]interface{}{"id": "a", "type": "rectangle", "version": 1}},
+					})
+				},
+				updateFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+					return nil
+				},
+			},
+			expectedStatus: http.StatusOK,
+			validateResp: func(t *testing.T, body []byte) {
+				var resp DrawingResponse
+				if err := json.Unmarshal(body, &resp); err != nil {
+					t.Fatalf("failed to unmarshal response: %v", err)
+				}
+				if elements, ok := resp.Data["elements"].([]interface{}); !ok || len(elements) != 2 {
+					t.Errorf("expected both elements after merge, got %v", resp.Data["elements"])
+				}
+			},
+		},
+		{
+			name:      "invalid merge parameter",
+			drawingID: "123e4567-e89b-12d3-a456-426614174000",
+			query:     "?merge=maybe",
+			requestBody: UpdateDrawingRequest{
+				Name: "Updated Name",
+			},
+			mockRepo:       &mockDrawingRepository{},
+			expectedStatus: http.StatusBadRequest,
+			validateResp: func(t *testing.T, body []byte) {
+				var resp ErrorResponse
+				if err := json.Unmarshal(body, &resp); err != nil {
+					t.Fatalf("failed to unmarshal error response: %v", err)
+				}
+				if resp.Error != "invalid_request" {
+					t.Errorf("expected error type 'invalid_request', got '%s'", resp.Error)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := drawingapp.NewService(tt.mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+			handler := NewDrawingHandler(service, logger)
+
+			var body []byte
+			var err error
+			if tt.requestBody != nil {
+				body, err = json.Marshal(tt.requestBody)
+				if err != nil {
+					t.Fatalf("failed to marshal request body: %v", err)
 				}
 			}
 
-			req := httptest.NewRequest(http.MethodPut, "/drawings/"+tt.drawingID, bytes.NewBuffer(body))
+			req := httptest.NewRequest(http.MethodPut, "/drawings/"+tt.drawingID+tt.query, bytes.NewBuffer(body))
 			req.Header.Set("Content-Type", "application/json")
 			req.SetPathValue("id", tt.drawingID)
 			w := httptest.NewRecorder()
@@ -855,6 +1402,130 @@ func TestUpdateDrawing(t *testing.T) {
 	}
 }
 
+func TestPatchDrawing(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	tests := []struct {
+		name           string
+		contentType    string
+		ifMatch        string
+		clientID       string
+		body           string
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "json patch",
+			contentType:    "application/json-patch+json",
+			body:           `[{"op":"add","path":"/elements/-","value":{"id":"a","type":"rectangle"}}]`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "merge patch with charset",
+			contentType:    "application/merge-patch+json; charset=utf-8",
+			ifMatch:        `"3"`,
+			body:           `{"appState":{"theme":"dark"}}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "plain json is rejected",
+			contentType:    "application/json",
+			body:           `{"appState":{}}`,
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedError:  "unsupported_media_type",
+		},
+		{
+			name:           "malformed json patch",
+			contentType:    "application/json-patch+json",
+			body:           `[{"op":"add","path":"elements"}]`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_patch",
+		},
+		{
+			name:           "failed test operation",
+			contentType:    "application/json-patch+json",
+			body:           `[{"op":"test","path":"/elements","value":[1]}]`,
+			expectedStatus: http.StatusConflict,
+			expectedError:  "patch_conflict",
+		},
+		{
+			name:           "stale If-Match",
+			contentType:    "application/merge-patch+json",
+			ifMatch:        `"2"`,
+			body:           `{}`,
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedError:  "version_conflict",
+		},
+		{
+			name:           "longest client ID",
+			contentType:    "application/json-patch+json",
+			clientID:       strings.Repeat("a", maxClientIDLength),
+			body:           `[{"op":"add","path":"/elements/-","value":{"id":"a","type":"rectangle"}}]`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "client ID too long",
+			contentType:    "application/json-patch+json",
+			clientID:       strings.Repeat("a", maxClientIDLength+1),
+			body:           `[{"op":"add","path":"/elements/-","value":{"id":"a","type":"rectangle"}}]`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_request",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &mockDrawingRepository{
+				findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
+					return drawing.Reconstitute(id, "my-drawing", "My Drawing", map[string]interface{}{"elements": []interface{}{}}, 3, time.Now().UTC(), time.Now().UTC())
+				},
+				updateFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+					return nil
+				},
+			}
+			service := drawingapp.NewService(mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+			handler := NewDrawingHandler(service, logger)
+
+			drawingID := "123e4567-e89b-12d3-a456-426614174000"
+			req := httptest.NewRequest(http.MethodPatch, "/drawings/"+drawingID, strings.NewReader(tt.body))
+			req.SetPathValue("id", drawingID)
+			req.Header.Set("Content-Type", tt.contentType)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			if tt.clientID != "" {
+				req.Header.Set(clientIDHeader, tt.clientID)
+			}
+			w := httptest.NewRecorder()
+
+			handler.PatchDrawing(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+
+			if tt.expectedError != "" {
+				var resp ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("failed to unmarshal error response: %v", err)
+				}
+				if resp.Error != tt.expectedError {
+					t.Errorf("expected error type '%s', got '%s'", tt.expectedError, resp.Error)
+				}
+				return
+			}
+
+			var resp DrawingResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if resp.Version != 4 {
+				t.Errorf("expected version 4, got %d", resp.Version)
+			}
+		})
+	}
+}
+
 func TestDeleteDrawing(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 
@@ -869,13 +1540,7 @@ func TestDeleteDrawing(t *testing.T) {
 			name:      "successful delete",
 			drawingID: "123e4567-e89b-12d3-a456-426614174000",
 			mockRepo: &mockDrawingRepository{
-				findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
-					d, _ := drawing.NewDrawing("Test Drawing", map[string]interface{}{
-						"elements": []interface{}{},
-					})
-					return d, nil
-				},
-				deleteFunc: func(ctx context.Context, id uuid.UUID) error {
+				deleteFunc: func(ctx context.Context, id uuid.UUID, expectedVersions []int64) error {
 					return nil
 				},
 			},
@@ -890,8 +1555,8 @@ func TestDeleteDrawing(t *testing.T) {
 			name:      "drawing not found",
 			drawingID: "123e4567-e89b-12d3-a456-426614174000",
 			mockRepo: &mockDrawingRepository{
-				findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
-					return nil, drawing.ErrDrawingNotFound
+				deleteFunc: func(ctx context.Context, id uuid.UUID, expectedVersions []int64) error {
+					return drawing.ErrDrawingNotFound
 				},
 			},
 			expectedStatus: http.StatusNotFound,
@@ -958,7 +1623,7 @@ func TestDeleteDrawing(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			service := drawingapp.NewService(tt.mockRepo, logger)
+			service := drawingapp.NewService(tt.mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
 			handler := NewDrawingHandler(service, logger)
 
 			req := httptest.NewRequest(http.MethodDelete, "/drawings/"+tt.drawingID, nil)
@@ -977,3 +1642,427 @@ func TestDeleteDrawing(t *testing.T) {
 		})
 	}
 }
+
+func TestDrawingIfMatch(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	tests := []struct {
+		name           string
+		method         string
+		ifMatch        string
+		saveConflict   bool
+		expectedStatus int
+		expectedError  string
+		expectedETag   string
+	}{
+		{
+			name:           "update without If-Match",
+			method:         http.MethodPut,
+			expectedStatus: http.StatusOK,
+			expectedETag:   `"4-`,
+		},
+		{
+			name:           "update with matching If-Match",
+			method:         http.MethodPut,
+			ifMatch:        `"3"`,
+			expectedStatus: http.StatusOK,
+			expectedETag:   `"4-`,
+		},
+		{
+			name:           "update with wildcard If-Match",
+			method:         http.MethodPut,
+			ifMatch:        "*",
+			expectedStatus: http.StatusOK,
+			expectedETag:   `"4-`,
+		},
+		{
+			name:           "update with stale If-Match",
+			method:         http.MethodPut,
+			ifMatch:        `"2"`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "update with full ETag in If-Match",
+			method:         http.MethodPut,
+			ifMatch:        `"3-0123456789abcdef"`,
+			expectedStatus: http.StatusOK,
+			expectedETag:   `"4-`,
+		},
+		{
+			name:           "update with weak If-Match",
+			method:         http.MethodPut,
+			ifMatch:        `W/"3"`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "update with the current version later in an If-Match list",
+			method:         http.MethodPut,
+			ifMatch:        `"2-0123456789abcdef", "3-fedcba9876543210"`,
+			expectedStatus: http.StatusOK,
+			expectedETag:   `"4-`,
+		},
+		{
+			name:           "update with a stale If-Match list",
+			method:         http.MethodPut,
+			ifMatch:        `"1-0123456789abcdef", "2-fedcba9876543210"`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "update with weak and strong tags in an If-Match list",
+			method:         http.MethodPut,
+			ifMatch:        `W/"3", "3"`,
+			expectedStatus: http.StatusOK,
+			expectedETag:   `"4-`,
+		},
+		{
+			name:           "update with only weak tags in an If-Match list",
+			method:         http.MethodPut,
+			ifMatch:        `W/"2", W/"3"`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "update losing a race without If-Match",
+			method:         http.MethodPut,
+			saveConflict:   true,
+			expectedStatus: http.StatusConflict,
+			expectedError:  "concurrent_update",
+		},
+		{
+			name:           "update losing a race with If-Match",
+			method:         http.MethodPut,
+			ifMatch:        `"3"`,
+			saveConflict:   true,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "delete with matching If-Match",
+			method:         http.MethodDelete,
+			ifMatch:        `"3"`,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "delete with stale If-Match",
+			method:         http.MethodDelete,
+			ifMatch:        `"2"`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "delete with the current version in an If-Match list",
+			method:         http.MethodDelete,
+			ifMatch:        `"2", "3"`,
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &mockDrawingRepository{
+				findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
+					return drawing.Reconstitute(id, "my-drawing", "Old", map[string]interface{}{"elements": []interface{}{}}, 3, time.Now().UTC(), time.Now().UTC())
+				},
+				updateFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+					// Another client saves between the load and the save
+					if tt.saveConflict {
+						return drawing.ErrConcurrentUpdate
+					}
+					return nil
+				},
+				deleteFunc: func(ctx context.Context, id uuid.UUID, expectedVersions []int64) error {
+					if len(expectedVersions) != 0 && !slices.Contains(expectedVersions, 3) {
+						return drawing.ErrVersionConflict
+					}
+					return nil
+				},
+			}
+			service := drawingapp.NewService(mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+			handler := NewDrawingHandler(service, logger)
+
+			drawingID := "123e4567-e89b-12d3-a456-426614174000"
+			var req *http.Request
+			if tt.method == http.MethodPut {
+				req = httptest.NewRequest(tt.method, "/drawings/"+drawingID, bytes.NewReader([]byte(`{"name":"New"}`)))
+			} else {
+				req = httptest.NewRequest(tt.method, "/drawings/"+drawingID, nil)
+			}
+			req.SetPathValue("id", drawingID)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+
+			if tt.method == http.MethodPut {
+				handler.UpdateDrawing(w, req)
+			} else {
+				handler.DeleteDrawing(w, req)
+			}
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+
+			expectedError := tt.expectedError
+			if tt.expectedStatus == http.StatusPreconditionFailed {
+				expectedError = "version_conflict"
+			}
+			if expectedError != "" {
+				var resp ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("failed to unmarshal error response: %v", err)
+				}
+				if resp.Error != expectedError {
+					t.Errorf("expected error type '%s', got '%s'", expectedError, resp.Error)
+				}
+			}
+
+			if etag := w.Header().Get("ETag"); !strings.HasPrefix(etag, tt.expectedETag) || (tt.expectedETag == "" && etag != "") {
+				t.Errorf("expected ETag starting with %q, got %q", tt.expectedETag, etag)
+			}
+		})
+	}
+}
+
+func TestConditionalGet(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	updatedAt := time.Date(2025, 12, 5, 10, 30, 0, 0, time.UTC)
+	mockRepo := &mockDrawingRepository{
+		findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
+			return drawing.Reconstitute(id, "my-drawing", "My Drawing", map[string]interface{}{"elements": []interface{}{}}, 3, updatedAt, updatedAt)
+		},
+		findSummariesFunc: func(ctx context.Context, query drawing.SummaryQuery, limit, offset int) ([]*drawing.DrawingSummary, error) {
+			return []*drawing.DrawingSummary{{
+				ID: uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"), Slug: "my-drawing", Name: "My Drawing",
+				Version: 3, CreatedAt: updatedAt, UpdatedAt: updatedAt,
+			}}, nil
+		},
+		countFunc: func(ctx context.Context, filter drawing.DrawingFilter) (int64, error) {
+			return 1, nil
+		},
+	}
+	service := drawingapp.NewService(mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+	handler := NewDrawingHandler(service, logger)
+
+	drawingID := "123e4567-e89b-12d3-a456-426614174000"
+	getDrawing := func(header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/drawings/"+drawingID, nil)
+		req.SetPathValue("id", drawingID)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		handler.GetDrawing(w, req)
+		return w
+	}
+	listDrawings := func(header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/drawings", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		handler.ListDrawings(w, req)
+		return w
+	}
+
+	first := getDrawing("", "")
+	if first.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", first.Code)
+	}
+	etag := first.Header().Get("ETag")
+	if !strings.HasPrefix(etag, `"3-`) {
+		t.Errorf("expected ETag starting with the version, got %q", etag)
+	}
+	if got := first.Header().Get("Last-Modified"); got != "Fri, 05 Dec 2025 10:30:00 GMT" {
+		t.Errorf("expected Last-Modified of updated_at, got %q", got)
+	}
+	if got := first.Header().Get("Cache-Control"); got != "private, no-cache" {
+		t.Errorf("expected Cache-Control 'private, no-cache', got %q", got)
+	}
+
+	list := listDrawings("", "")
+	listETag := list.Header().Get("ETag")
+	if listETag == "" {
+		t.Fatal("expected ETag on list response")
+	}
+	if got := list.Header().Get("Last-Modified"); got != "" {
+		t.Errorf("expected no Last-Modified on list response, got %q", got)
+	}
+
+	tests := []struct {
+		name           string
+		list           bool
+		header         string
+		value          string
+		expectedStatus int
+	}{
+		{name: "matching If-None-Match", header: "If-None-Match", value: etag, expectedStatus: http.StatusNotModified},
+		{name: "weak matching If-None-Match", header: "If-None-Match", value: "W/" + etag, expectedStatus: http.StatusNotModified},
+		{name: "If-None-Match list", header: "If-None-Match", value: `"2-abc", ` + etag, expectedStatus: http.StatusNotModified},
+		{name: "stale If-None-Match", header: "If-None-Match", value: `"2-0123456789abcdef"`, expectedStatus: http.StatusOK},
+		{name: "If-Modified-Since at updated_at", header: "If-Modified-Since", value: "Fri, 05 Dec 2025 10:30:00 GMT", expectedStatus: http.StatusNotModified},
+		{name: "If-Modified-Since before updated_at", header: "If-Modified-Since", value: "Fri, 05 Dec 2025 10:29:59 GMT", expectedStatus: http.StatusOK},
+		{name: "invalid If-Modified-Since", header: "If-Modified-Since", value: "yesterday", expectedStatus: http.StatusOK},
+		{name: "list matching If-None-Match", list: true, header: "If-None-Match", value: listETag, expectedStatus: http.StatusNotModified},
+		{name: "list stale If-None-Match", list: true, header: "If-None-Match", value: etag, expectedStatus: http.StatusOK},
+		{name: "list ignores If-Modified-Since", list: true, header: "If-Modified-Since", value: "Sat, 06 Dec 2025 00:00:00 GMT", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var w *httptest.ResponseRecorder
+			if tt.list {
+				w = listDrawings(tt.header, tt.value)
+			} else {
+				w = getDrawing(tt.header, tt.value)
+			}
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus == http.StatusNotModified {
+				if w.Body.Len() != 0 {
+					t.Errorf("expected empty body for 304, got %s", w.Body.String())
+				}
+				if w.Header().Get("ETag") == "" {
+					t.Error("expected ETag on 304 response")
+				}
+			}
+		})
+	}
+}
+
+func TestListRevisions(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	mockRepo := &mockDrawingRepository{
+		findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
+			d, _ := drawing.NewDrawing("Test Drawing", map[string]interface{}{"elements": []interface{}{}})
+			return d, nil
+		},
+	}
+	revisionRepo := &mockRevisionRepository{
+		findByDrawingIDFunc: func(ctx context.Context, drawingID uuid.UUID, limit, offset int) ([]*drawing.Revision, error) {
+			data := drawing.DrawingData{"elements": []interface{}{}}
+			return []*drawing.Revision{
+				drawing.ReconstituteRevision(uuid.New(), drawingID, 2, "Test Drawing", data, 1, "", time.Now()),
+				drawing.ReconstituteRevision(uuid.New(), drawingID, 1, "Test Drawing", data, 0, "", time.Now()),
+			}, nil
+		},
+		countByDrawingIDFunc: func(ctx context.Context, drawingID uuid.UUID) (int64, error) {
+			return 2, nil
+		},
+	}
+
+	service := drawingapp.NewService(mockRepo, revisionRepo, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+	handler := NewDrawingHandler(service, logger)
+
+	id := "123e4567-e89b-12d3-a456-426614174000"
+	req := httptest.NewRequest(http.MethodGet, "/drawings/"+id+"/revisions", nil)
+	req.SetPathValue("id", id)
+	w := httptest.NewRecorder()
+
+	handler.ListRevisions(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var resp RevisionListResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(resp.Revisions) != 2 || resp.Total != 2 {
+		t.Errorf("expected 2 revisions, got %d (total %d)", len(resp.Revisions), resp.Total)
+	}
+	if resp.Revisions[0].Revision != 2 || resp.Revisions[0].RestoredFrom != 1 {
+		t.Errorf("unexpected first revision: %+v", resp.Revisions[0])
+	}
+}
+
+func TestRestoreRevision(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	tests := []struct {
+		name           string
+		revision       string
+		ifMatch        string
+		revisionRepo   *mockRevisionRepository
+		expectedStatus int
+	}{
+		{
+			name:     "successful restore",
+			revision: "1",
+			revisionRepo: &mockRevisionRepository{
+				findByNumberFunc: func(ctx context.Context, drawingID uuid.UUID, number int) (*drawing.Revision, error) {
+					return drawing.ReconstituteRevision(uuid.New(), drawingID, number, "Old Name", drawing.DrawingData{"elements": []interface{}{}}, 0, "", time.Now()), nil
+				},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:     "restore with matching If-Match",
+			revision: "1",
+			ifMatch:  `"1"`,
+			revisionRepo: &mockRevisionRepository{
+				findByNumberFunc: func(ctx context.Context, drawingID uuid.UUID, number int) (*drawing.Revision, error) {
+					return drawing.ReconstituteRevision(uuid.New(), drawingID, number, "Old Name", drawing.DrawingData{"elements": []interface{}{}}, 0, "", time.Now()), nil
+				},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "restore with stale If-Match",
+			revision:       "1",
+			ifMatch:        `"5"`,
+			revisionRepo:   &mockRevisionRepository{},
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:     "revision not found",
+			revision: "7",
+			revisionRepo: &mockRevisionRepository{
+				findByNumberFunc: func(ctx context.Context, drawingID uuid.UUID, number int) (*drawing.Revision, error) {
+					return nil, drawing.ErrRevisionNotFound
+				},
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid revision number",
+			revision:       "abc",
+			revisionRepo:   &mockRevisionRepository{},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &mockDrawingRepository{
+				findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
+					d, _ := drawing.NewDrawing("Current Name", map[string]interface{}{"elements": []interface{}{}})
+					return d, nil
+				},
+				updateFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+					return nil
+				},
+			}
+
+			service := drawingapp.NewService(mockRepo, tt.revisionRepo, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+			handler := NewDrawingHandler(service, logger)
+
+			id := "123e4567-e89b-12d3-a456-426614174000"
+			req := httptest.NewRequest(http.MethodPost, "/drawings/"+id+"/revisions/"+tt.revision+"/restore", nil)
+			req.SetPathValue("id", id)
+			req.SetPathValue("rev", tt.revision)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+
+			handler.RestoreRevision(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package handler
+
+import (
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/personal-excalidraw/backend/internal/adapter/http/util"
+	drawingapp "github.com/personal-excalidraw/backend/internal/application/drawing"
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// ExportHandler handles drawing export HTTP requests
+type ExportHandler struct {
+	service *drawingapp.ExportService
+	logger  *slog.Logger
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler(service *drawingapp.ExportService, logger *slog.Logger) *ExportHandler {
+	return &ExportHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ExportSVG handles GET /api/drawings/{id}/export.svg
+func (h *ExportHandler) ExportSVG(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("handling export drawing as SVG request")
+
+	id := r.PathValue("id")
+	if id == "" {
+		h.logger.Error("missing drawing ID in path")
+		response := ErrorResponse{
+			Error:   "invalid_request",
+			Message: "missing drawing ID",
+		}
+		util.RespondJSON(w, http.StatusBadRequest, response)
+		return
+	}
+
+	input, ok := h.parseExportInput(w, r)
+	if !ok {
+		return
+	}
+
+	output, err := h.service.ExportSVG(r.Context(), id, input)
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	respondExport(w, r, "image/svg+xml", imageExtension(".svg", input), output)
+}
+
+// ExportPNG handles GET /api/drawings/{id}/export.png
+func (h *ExportHandler) ExportPNG(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("handling export drawing as PNG request")
+
+	id := r.PathValue("id")
+	if id == "" {
+		h.logger.Error("missing drawing ID in path")
+		response := ErrorResponse{
+			Error:   "invalid_request",
+			Message: "missing drawing ID",
+		}
+		util.RespondJSON(w, http.StatusBadRequest, response)
+		return
+	}
+
+	input, ok := h.parseExportInput(w, r)
+	if !ok {
+		return
+	}
+
+	output, err := h.service.ExportPNG(r.Context(), id, input)
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	respondExport(w, r, "image/png", imageExtension(".png", input), output)
+}
+
+// ExportPDF handles GET /api/drawings/{id}/export.pdf
+func (h *ExportHandler) ExportPDF(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("handling export drawing as PDF request")
+
+	id := r.PathValue("id")
+	if id == "" {
+		h.logger.Error("missing drawing ID in path")
+		response := ErrorResponse{
+			Error:   "invalid_request",
+			Message: "missing drawing ID",
+		}
+		util.RespondJSON(w, http.StatusBadRequest, response)
+		return
+	}
+
+	input, ok := h.parseExportInput(w, r)
+	if !ok {
+		return
+	}
+
+	output, err := h.service.ExportPDF(r.Context(), id, input)
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	respondExport(w, r, "application/pdf", ".pdf", output)
+}
+
+// ExportExcalidraw handles GET /api/drawings/{id}/export.excalidraw
+func (h *ExportHandler) ExportExcalidraw(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("handling export drawing as excalidraw file request")
+
+	id := r.PathValue("id")
+	if id == "" {
+		h.logger.Error("missing drawing ID in path")
+		response := ErrorResponse{
+			Error:   "invalid_request",
+			Message: "missing drawing ID",
+		}
+		util.RespondJSON(w, http.StatusBadRequest, response)
+		return
+	}
+
+	output, err := h.service.ExportExcalidraw(r.Context(), id)
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	respondFile(w, r, "attachment", excalidrawMediaType, drawing.ExcalidrawFileExtension, output)
+}
+
+// GetThumbnail handles GET /api/drawings/{id}/thumbnail.png
+func (h *ExportHandler) GetThumbnail(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("handling get drawing thumbnail request")
+
+	id := r.PathValue("id")
+	if id == "" {
+		h.logger.Error("missing drawing ID in path")
+		response := ErrorResponse{
+			Error:   "invalid_request",
+			Message: "missing drawing ID",
+		}
+		util.RespondJSON(w, http.StatusBadRequest, response)
+		return
+	}
+
+	output, err := h.service.GetThumbnail(r.Context(), id)
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	respondExport(w, r, "image/png", ".png", output)
+}
+
+// parseExportInput reads the padding, darkMode, scale and embedScene query parameters shared by the export formats
+// On invalid parameters it responds with 400 and returns false
+func (h *ExportHandler) parseExportInput(w http.ResponseWriter, r *http.Request) (drawingapp.ExportInput, bool) {
+	var input drawingapp.ExportInput
+	query := r.URL.Query()
+
+	if paddingStr := query.Get("padding"); paddingStr != "" {
+		padding, err := strconv.ParseFloat(paddingStr, 64)
+		if err != nil {
+			h.logger.Error("invalid padding parameter", "padding", paddingStr)
+			response := ErrorResponse{
+				Error:   "invalid_request",
+				Message: "padding must be a number",
+			}
+			util.RespondJSON(w, http.StatusBadRequest, response)
+			return input, false
+		}
+		input.Padding = &padding
+	}
+
+	if darkModeStr := query.Get("darkMode"); darkModeStr != "" {
+		darkMode, err := strconv.ParseBool(darkModeStr)
+		if err != nil {
+			h.logger.Error("invalid darkMode parameter", "darkMode", darkModeStr)
+			response := ErrorResponse{
+				Error:   "invalid_request",
+				Message: "darkMode must be true or false",
+			}
+			util.RespondJSON(w, http.StatusBadRequest, response)
+			return input, false
+		}
+		input.DarkMode = darkMode
+	}
+
+	if scaleStr := query.Get("scale"); scaleStr != "" {
+		scale, err := strconv.ParseFloat(scaleStr, 64)
+		if err != nil || scale <= 0 {
+			h.logger.Error("invalid scale parameter", "scale", scaleStr)
+			response := ErrorResponse{
+				Error:   "invalid_request",
+				Message: "scale must be a positive number",
+			}
+			util.RespondJSON(w, http.StatusBadRequest, response)
+			return input, false
+		}
+		input.Scale = &scale
+	}
+
+	if embedSceneStr := query.Get("embedScene"); embedSceneStr != "" {
+		embedScene, err := strconv.ParseBool(embedSceneStr)
+		if err != nil {
+			h.logger.Error("invalid embedScene parameter", "embedScene", embedSceneStr)
+			response := ErrorResponse{
+				Error:   "invalid_request",
+				Message: "embedScene must be true or false",
+			}
+			util.RespondJSON(w, http.StatusBadRequest, response)
+			return input, false
+		}
+		input.EmbedScene = embedScene
+	}
+
+	return input, true
+}
+
+// imageExtension returns the file name extension of an exported image
+// Images with an embedded scene are named like Excalidraw names them, e.g. "flow.excalidraw.png"
+func imageExtension(extension string, input drawingapp.ExportInput) string {
+	if input.EmbedScene {
+		return drawing.ExcalidrawFileExtension + extension
+	}
+	return extension
+}
+
+// respondExport sends an exported drawing inline, named after the drawing, with cache validators
+func respondExport(w http.ResponseWriter, r *http.Request, contentType, extension string, output *drawingapp.ExportOutput) {
+	respondFile(w, r, "inline", contentType, extension, output)
+}
+
+// respondFile sends an exported drawing named after the drawing, with cache validators and the given
+// content disposition: "inline" to display it or "attachment" to download it
+func respondFile(w http.ResponseWriter, r *http.Request, disposition, contentType, extension string, output *drawingapp.ExportOutput) {
+	etag := contentETag(strconv.FormatInt(output.Version, 10), output.Content)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("Last-Modified", output.UpdatedAt.UTC().Format(http.TimeFormat))
+
+	if notModified(r, etag, output.UpdatedAt) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{
+		"filename": exportFilename(output.Name) + extension,
+	}))
+	w.Header().Set("Content-Length", strconv.Itoa(len(output.Content)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(output.Content)
+}
+
+// exportFilename turns a drawing name into a file name without path separators or control characters
+func exportFilename(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		return "drawing"
+	}
+	return name
+}
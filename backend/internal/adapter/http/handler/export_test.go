@@ -0,0 +1,329 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image/png"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/personal-excalidraw/backend/internal/adapter/render"
+	drawingapp "github.com/personal-excalidraw/backend/internal/application/drawing"
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// mockThumbnailRepository is a mock implementation of drawing.ThumbnailRepository
+type mockThumbnailRepository struct {
+	thumbnail *drawing.Thumbnail
+	saved     int
+}
+
+func (m *mockThumbnailRepository) Save(ctx context.Context, t *drawing.Thumbnail) error {
+	m.thumbnail = t
+	m.saved++
+	return nil
+}
+
+func (m *mockThumbnailRepository) FindByDrawingID(ctx context.Context, id uuid.UUID) (*drawing.Thumbnail, error) {
+	if m.thumbnail == nil {
+		return nil, drawing.ErrThumbnailNotFound
+	}
+	return m.thumbnail, nil
+}
+
+// newTestExportHandler creates an export handler rendering with the real renderer
+func newTestExportHandler(repo drawing.Repository, thumbnails drawing.ThumbnailRepository, logger *slog.Logger) *ExportHandler {
+	renderer := render.NewRenderer()
+	worker := drawingapp.NewThumbnailWorker(repo, thumbnails, renderer, logger)
+	return NewExportHandler(drawingapp.NewExportService(repo, renderer, worker, logger), logger)
+}
+
+// exportTestDrawing returns a drawing with a 100x50 rectangle and a text element
+func exportTestDrawing(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
+	d, _ := drawing.NewDrawing("Architecture / Overview", map[string]interface{}{
+		"elements": []interface{}{
+			map[string]interface{}{"id": "rect-1", "type": "rectangle", "x": 0.0, "y": 0.0, "width": 100.0, "height": 50.0},
+			map[string]interface{}{"id": "text-1", "type": "text", "x": 10.0, "y": 10.0, "width": 80.0, "height": 25.0, "text": "API"},
+		},
+		"appState": map[string]interface{}{"viewBackgroundColor": "#ffffff"},
+	})
+	return d, nil
+}
+
+func TestExportSVG(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	findDrawing := exportTestDrawing
+
+	tests := []struct {
+		name           string
+		query          string
+		mockRepo       *mockDrawingRepository
+		expectedStatus int
+		validateResp   func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			name:           "successful export",
+			mockRepo:       &mockDrawingRepository{findByIDFunc: findDrawing},
+			expectedStatus: http.StatusOK,
+			validateResp: func(t *testing.T, w *httptest.ResponseRecorder) {
+				if ct := w.Header().Get("Content-Type"); ct != "image/svg+xml" {
+					t.Errorf("expected Content-Type image/svg+xml, got %q", ct)
+				}
+				if cd := w.Header().Get("Content-Disposition"); cd != `inline; filename="Architecture _ Overview.svg"` {
+					t.Errorf("unexpected Content-Disposition %q", cd)
+				}
+				if w.Header().Get("ETag") == "" {
+					t.Error("expected ETag header")
+				}
+				body := w.Body.String()
+				// 100x50 rectangle with the default padding of 10
+				if !strings.Contains(body, `width="120" height="70"`) {
+					t.Errorf("expected 120x70 image, got %s", body)
+				}
+				if !strings.Contains(body, ">API</text>") {
+					t.Error("expected text element")
+				}
+			},
+		},
+		{
+			name:           "padding and dark mode",
+			query:          "?padding=0&darkMode=true",
+			mockRepo:       &mockDrawingRepository{findByIDFunc: findDrawing},
+			expectedStatus: http.StatusOK,
+			validateResp: func(t *testing.T, w *httptest.ResponseRecorder) {
+				body := w.Body.String()
+				if !strings.Contains(body, `width="100" height="50"`) {
+					t.Errorf("expected 100x50 image, got %s", body)
+				}
+				if !strings.Contains(body, `fill="#121212"`) {
+					t.Error("expected dark background")
+				}
+			},
+		},
+		{
+			name:           "invalid padding",
+			query:          "?padding=wide",
+			mockRepo:       &mockDrawingRepository{findByIDFunc: findDrawing},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "padding out of range",
+			query:          "?padding=-5",
+			mockRepo:       &mockDrawingRepository{findByIDFunc: findDrawing},
+			expectedStatus: http.StatusBadRequest,
+			validateResp: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var resp ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("failed to unmarshal error response: %v", err)
+				}
+				if resp.Error != "invalid_export_options" {
+					t.Errorf("expected error type 'invalid_export_options', got '%s'", resp.Error)
+				}
+			},
+		},
+		{
+			name:           "invalid dark mode",
+			query:          "?darkMode=maybe",
+			mockRepo:       &mockDrawingRepository{findByIDFunc: findDrawing},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "drawing not found",
+			mockRepo: &mockDrawingRepository{
+				findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
+					return nil, drawing.ErrDrawingNotFound
+				},
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newTestExportHandler(tt.mockRepo, &mockThumbnailRepository{}, logger)
+
+			id := "123e4567-e89b-12d3-a456-426614174000"
+			req := httptest.NewRequest(http.MethodGet, "/drawings/"+id+"/export.svg"+tt.query, nil)
+			req.SetPathValue("id", id)
+			w := httptest.NewRecorder()
+
+			handler.ExportSVG(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+
+			if tt.validateResp != nil {
+				tt.validateResp(t, w)
+			}
+		})
+	}
+}
+
+func TestExportPNG(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedWidth  int
+		expectedHeight int
+	}{
+		{
+			name:           "default scale",
+			expectedStatus: http.StatusOK,
+			expectedWidth:  120,
+			expectedHeight: 70,
+		},
+		{
+			name:           "double scale",
+			query:          "?scale=2&padding=0",
+			expectedStatus: http.StatusOK,
+			expectedWidth:  200,
+			expectedHeight: 100,
+		},
+		{
+			name:           "invalid scale",
+			query:          "?scale=big",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "zero scale",
+			query:          "?scale=0",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "scale out of range",
+			query:          "?scale=5",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newTestExportHandler(&mockDrawingRepository{findByIDFunc: exportTestDrawing}, &mockThumbnailRepository{}, logger)
+
+			id := "123e4567-e89b-12d3-a456-426614174000"
+			req := httptest.NewRequest(http.MethodGet, "/drawings/"+id+"/export.png"+tt.query, nil)
+			req.SetPathValue("id", id)
+			w := httptest.NewRecorder()
+
+			handler.ExportPNG(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			if ct := w.Header().Get("Content-Type"); ct != "image/png" {
+				t.Errorf("expected Content-Type image/png, got %q", ct)
+			}
+			img, err := png.Decode(bytes.NewReader(w.Body.Bytes()))
+			if err != nil {
+				t.Fatalf("failed to decode PNG: %v", err)
+			}
+			if size := img.Bounds().Size(); size.X != tt.expectedWidth || size.Y != tt.expectedHeight {
+				t.Errorf("expected %dx%d image, got %dx%d", tt.expectedWidth, tt.expectedHeight, size.X, size.Y)
+			}
+		})
+	}
+}
+
+func TestExportPDF(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+	}{
+		{
+			name:           "default options",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "dark mode",
+			query:          "?darkMode=true",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid padding",
+			query:          "?padding=-1",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newTestExportHandler(&mockDrawingRepository{findByIDFunc: exportTestDrawing}, &mockThumbnailRepository{}, logger)
+
+			id := "123e4567-e89b-12d3-a456-426614174000"
+			req := httptest.NewRequest(http.MethodGet, "/drawings/"+id+"/export.pdf"+tt.query, nil)
+			req.SetPathValue("id", id)
+			w := httptest.NewRecorder()
+
+			handler.ExportPDF(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			if ct := w.Header().Get("Content-Type"); ct != "application/pdf" {
+				t.Errorf("expected Content-Type application/pdf, got %q", ct)
+			}
+			if !bytes.HasPrefix(w.Body.Bytes(), []byte("%PDF-")) {
+				t.Errorf("expected a PDF document")
+			}
+		})
+	}
+}
+
+func TestGetThumbnail(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	id := "123e4567-e89b-12d3-a456-426614174000"
+	thumbnails := &mockThumbnailRepository{}
+	handler := newTestExportHandler(&mockDrawingRepository{findByIDFunc: exportTestDrawing}, thumbnails, logger)
+
+	get := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/drawings/"+id+"/thumbnail.png", nil)
+		req.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		handler.GetThumbnail(w, req)
+		return w
+	}
+
+	// The first request renders and stores the missing thumbnail
+	w := get()
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := png.Decode(bytes.NewReader(w.Body.Bytes())); err != nil {
+		t.Fatalf("failed to decode PNG: %v", err)
+	}
+	if thumbnails.saved != 1 {
+		t.Fatalf("expected thumbnail to be saved once, got %d", thumbnails.saved)
+	}
+
+	// Later requests serve the stored thumbnail of the current version
+	if w := get(); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if thumbnails.saved != 1 {
+		t.Errorf("expected stored thumbnail to be reused, got %d saves", thumbnails.saved)
+	}
+}
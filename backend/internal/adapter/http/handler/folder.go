@@ -0,0 +1,280 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/personal-excalidraw/backend/internal/adapter/http/util"
+	folderapp "github.com/personal-excalidraw/backend/internal/application/folder"
+	"github.com/personal-excalidraw/backend/internal/domain/folder"
+)
+
+// FolderHandler handles HTTP requests for folders
+type FolderHandler struct {
+	service *folderapp.Service
+	logger  *slog.Logger
+}
+
+// NewFolderHandler creates a new FolderHandler
+func NewFolderHandler(service *folderapp.Service, logger *slog.Logger) *FolderHandler {
+	return &FolderHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreateFolderRequest represents the request body for creating a folder
+type CreateFolderRequest struct {
+	Name string `json:"name"`
+
+	// ParentID is the folder to create the folder in; null or absent creates a top-level folder
+	ParentID string `json:"parent_id"`
+}
+
+// RenameFolderRequest represents the request body for renaming a folder
+type RenameFolderRequest struct {
+	Name string `json:"name"`
+}
+
+// MoveFolderRequest represents the request body for moving a folder
+type MoveFolderRequest struct {
+	// ParentID is the folder to move the folder into; null or absent moves it to the top level
+	ParentID string `json:"parent_id"`
+}
+
+// MoveDrawingRequest represents the request body for moving a drawing between folders
+type MoveDrawingRequest struct {
+	// FolderID is the folder to move the drawing into; null or absent takes it out of any folder
+	FolderID string `json:"folder_id"`
+}
+
+// FolderResponse represents a folder in HTTP responses
+type FolderResponse struct {
+	ID string `json:"id"`
+
+	// ParentID is the folder holding the folder; null at the top level
+	ParentID  *uuid.UUID `json:"parent_id"`
+	Name      string     `json:"name"`
+	CreatedAt string     `json:"created_at"`
+	UpdatedAt string     `json:"updated_at"`
+}
+
+// ListFoldersResponse represents the list of all folders in HTTP responses
+type ListFoldersResponse struct {
+	Folders []*FolderResponse `json:"folders"`
+}
+
+// CreateFolder handles POST /api/folders
+func (h *FolderHandler) CreateFolder(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("handling create folder request")
+
+	// Parse request body
+	var req CreateFolderRequest
+	if !h.parseBody(w, r, &req) {
+		return
+	}
+
+	// Call service
+	output, err := h.service.CreateFolder(r.Context(), folderapp.CreateFolderInput{
+		Name:     req.Name,
+		ParentID: req.ParentID,
+	})
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	util.RespondJSON(w, http.StatusCreated, toFolderResponse(output))
+}
+
+// ListFolders handles GET /api/folders
+// All folders are listed at once, ordered by name; clients assemble the tree from parent_id
+func (h *FolderHandler) ListFolders(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("handling list folders request")
+
+	// Call service
+	outputs, err := h.service.ListFolders(r.Context())
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	// Convert to HTTP response
+	response := ListFoldersResponse{
+		Folders: make([]*FolderResponse, len(outputs)),
+	}
+	for i, output := range outputs {
+		response.Folders[i] = toFolderResponse(output)
+	}
+
+	util.RespondJSON(w, http.StatusOK, response)
+}
+
+// GetFolder handles GET /api/folders/{id}
+func (h *FolderHandler) GetFolder(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("handling get folder request")
+
+	id, ok := h.pathFolderID(w, r)
+	if !ok {
+		return
+	}
+
+	// Call service
+	output, err := h.service.GetFolder(r.Context(), id)
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, toFolderResponse(output))
+}
+
+// RenameFolder handles PUT /api/folders/{id}
+func (h *FolderHandler) RenameFolder(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("handling rename folder request")
+
+	id, ok := h.pathFolderID(w, r)
+	if !ok {
+		return
+	}
+
+	// Parse request body
+	var req RenameFolderRequest
+	if !h.parseBody(w, r, &req) {
+		return
+	}
+
+	// Call service
+	output, err := h.service.RenameFolder(r.Context(), id, folderapp.RenameFolderInput{Name: req.Name})
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, toFolderResponse(output))
+}
+
+// MoveFolder handles POST /api/folders/{id}/move
+// The folder moves with its subfolders and drawings; moving it below itself answers 409
+func (h *FolderHandler) MoveFolder(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("handling move folder request")
+
+	id, ok := h.pathFolderID(w, r)
+	if !ok {
+		return
+	}
+
+	// Parse request body
+	var req MoveFolderRequest
+	if !h.parseBody(w, r, &req) {
+		return
+	}
+
+	// Call service
+	output, err := h.service.MoveFolder(r.Context(), id, folderapp.MoveFolderInput{ParentID: req.ParentID})
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, toFolderResponse(output))
+}
+
+// DeleteFolder handles DELETE /api/folders/{id}
+// mode decides what happens to the contents: restrict (the default) refuses a non-empty folder,
+// reparent moves its subfolders and drawings to its parent and cascade deletes them along with it
+func (h *FolderHandler) DeleteFolder(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("handling delete folder request")
+
+	id, ok := h.pathFolderID(w, r)
+	if !ok {
+		return
+	}
+
+	// Call service
+	mode := folder.DeleteMode(strings.ToLower(r.URL.Query().Get("mode")))
+	if err := h.service.DeleteFolder(r.Context(), id, mode); err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	// Return 204 No Content
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MoveDrawing handles POST /api/drawings/{id}/move
+// Moving a drawing leaves its version alone, so it needs no If-Match
+func (h *FolderHandler) MoveDrawing(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("handling move drawing request")
+
+	// Extract ID from path
+	id := r.PathValue("id")
+	if id == "" {
+		h.logger.Error("missing drawing ID in path")
+		response := ErrorResponse{
+			Error:   "invalid_request",
+			Message: "missing drawing ID",
+		}
+		util.RespondJSON(w, http.StatusBadRequest, response)
+		return
+	}
+
+	// Parse request body
+	var req MoveDrawingRequest
+	if !h.parseBody(w, r, &req) {
+		return
+	}
+
+	// Call service
+	if err := h.service.MoveDrawing(r.Context(), id, folderapp.MoveDrawingInput{FolderID: req.FolderID}); err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	// Return 204 No Content
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pathFolderID extracts the folder ID from the path, answering 400 if it is missing
+func (h *FolderHandler) pathFolderID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id := r.PathValue("id")
+	if id == "" {
+		h.logger.Error("missing folder ID in path")
+		response := ErrorResponse{
+			Error:   "invalid_request",
+			Message: "missing folder ID",
+		}
+		util.RespondJSON(w, http.StatusBadRequest, response)
+		return "", false
+	}
+	return id, true
+}
+
+// parseBody parses the JSON request body into v, answering 400 if it is missing or malformed
+func (h *FolderHandler) parseBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := parseJSON(r, v); err != nil {
+		h.logger.Error("invalid request body", "error", err)
+		response := ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		}
+		util.RespondJSON(w, http.StatusBadRequest, response)
+		return false
+	}
+	return true
+}
+
+// toFolderResponse converts a service output into the HTTP response shape
+func toFolderResponse(output *folderapp.FolderOutput) *FolderResponse {
+	return &FolderResponse{
+		ID:        output.ID.String(),
+		ParentID:  output.ParentID,
+		Name:      output.Name,
+		CreatedAt: output.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: output.UpdatedAt.Format(time.RFC3339),
+	}
+}
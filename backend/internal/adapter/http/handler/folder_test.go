@@ -0,0 +1,382 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	folderapp "github.com/personal-excalidraw/backend/internal/application/folder"
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+	"github.com/personal-excalidraw/backend/internal/domain/folder"
+)
+
+// mockFolderRepository is a mock implementation of the folder repository
+type mockFolderRepository struct {
+	createFunc          func(ctx context.Context, f *folder.Folder) error
+	findByIDFunc        func(ctx context.Context, id uuid.UUID) (*folder.Folder, error)
+	findAllFunc         func(ctx context.Context) ([]*folder.Folder, error)
+	findAncestorIDsFunc func(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error)
+	renameFunc          func(ctx context.Context, f *folder.Folder) error
+	moveFunc            func(ctx context.Context, f *folder.Folder) error
+	deleteFunc          func(ctx context.Context, id uuid.UUID, mode folder.DeleteMode) error
+}
+
+func (m *mockFolderRepository) Create(ctx context.Context, f *folder.Folder) error {
+	if m.createFunc != nil {
+		return m.createFunc(ctx, f)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *mockFolderRepository) FindByID(ctx context.Context, id uuid.UUID) (*folder.Folder, error) {
+	if m.findByIDFunc != nil {
+		return m.findByIDFunc(ctx, id)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockFolderRepository) FindAll(ctx context.Context) ([]*folder.Folder, error) {
+	if m.findAllFunc != nil {
+		return m.findAllFunc(ctx)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockFolderRepository) FindAncestorIDs(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error) {
+	if m.findAncestorIDsFunc != nil {
+		return m.findAncestorIDsFunc(ctx, id)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockFolderRepository) Rename(ctx context.Context, f *folder.Folder) error {
+	if m.renameFunc != nil {
+		return m.renameFunc(ctx, f)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *mockFolderRepository) Move(ctx context.Context, f *folder.Folder) error {
+	if m.moveFunc != nil {
+		return m.moveFunc(ctx, f)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *mockFolderRepository) Delete(ctx context.Context, id uuid.UUID, mode folder.DeleteMode) error {
+	if m.deleteFunc != nil {
+		return m.deleteFunc(ctx, id, mode)
+	}
+	return errors.New("not implemented")
+}
+
+func TestCreateFolder(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	parentID := uuid.New()
+
+	tests := []struct {
+		name           string
+		body           string
+		createErr      error
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "top-level folder",
+			body:           `{"name": "Projects", "parent_id": null}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "subfolder",
+			body:           `{"name": "Projects", "parent_id": "` + parentID.String() + `"}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "malformed body",
+			body:           `{"name": `,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_request",
+		},
+		{
+			name:           "empty name",
+			body:           `{"name": " "}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "empty_name",
+		},
+		{
+			name:           "invalid parent ID",
+			body:           `{"name": "Projects", "parent_id": "inbox"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_request",
+		},
+		{
+			name:           "missing parent",
+			body:           `{"name": "Projects", "parent_id": "` + parentID.String() + `"}`,
+			createErr:      folder.ErrFolderNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "not_found",
+		},
+		{
+			name:           "sibling with the same name",
+			body:           `{"name": "Projects"}`,
+			createErr:      folder.ErrNameConflict,
+			expectedStatus: http.StatusConflict,
+			expectedError:  "folder_name_conflict",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockFolderRepository{
+				createFunc: func(ctx context.Context, f *folder.Folder) error {
+					return tt.createErr
+				},
+			}
+			service := folderapp.NewService(repo, &mockDrawingRepository{}, logger)
+
+			req := httptest.NewRequest(http.MethodPost, "/folders", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			NewFolderHandler(service, logger).CreateFolder(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedError != "" {
+				var resp ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("failed to unmarshal response: %v", err)
+				}
+				if resp.Error != tt.expectedError {
+					t.Errorf("expected error %q, got %q", tt.expectedError, resp.Error)
+				}
+				return
+			}
+
+			var resp FolderResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if resp.Name != "Projects" {
+				t.Errorf("expected name %q, got %q", "Projects", resp.Name)
+			}
+			if strings.Contains(tt.body, parentID.String()) != (resp.ParentID != nil) {
+				t.Errorf("unexpected parent %v", resp.ParentID)
+			}
+		})
+	}
+}
+
+func TestMoveFolderIntoSubfolder(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	folderID := uuid.New()
+	subfolderID := uuid.New()
+
+	// The new parent lies below the folder being moved
+	repo := &mockFolderRepository{
+		findByIDFunc: func(ctx context.Context, id uuid.UUID) (*folder.Folder, error) {
+			return folder.Reconstitute(id, nil, "Folder", time.Now(), time.Now()), nil
+		},
+		findAncestorIDsFunc: func(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error) {
+			return []uuid.UUID{folderID}, nil
+		},
+	}
+	service := folderapp.NewService(repo, &mockDrawingRepository{}, logger)
+
+	req := httptest.NewRequest(http.MethodPost, "/folders/"+folderID.String()+"/move",
+		strings.NewReader(`{"parent_id": "`+subfolderID.String()+`"}`))
+	req.SetPathValue("id", folderID.String())
+	w := httptest.NewRecorder()
+
+	NewFolderHandler(service, logger).MoveFolder(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusConflict, w.Code, w.Body.String())
+	}
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if resp.Error != "folder_cycle" {
+		t.Errorf("expected error %q, got %q", "folder_cycle", resp.Error)
+	}
+}
+
+func TestDeleteFolder(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	tests := []struct {
+		name           string
+		query          string
+		deleteErr      error
+		expectedMode   folder.DeleteMode
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "empty folder",
+			expectedMode:   folder.DeleteRestrict,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "non-empty folder",
+			deleteErr:      folder.ErrFolderNotEmpty,
+			expectedMode:   folder.DeleteRestrict,
+			expectedStatus: http.StatusConflict,
+			expectedError:  "folder_not_empty",
+		},
+		{
+			name:           "reparent",
+			query:          "?mode=reparent",
+			expectedMode:   folder.DeleteReparent,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "cascade",
+			query:          "?mode=CASCADE",
+			expectedMode:   folder.DeleteCascade,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "unknown mode",
+			query:          "?mode=archive",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_request",
+		},
+		{
+			name:           "folder not found",
+			deleteErr:      folder.ErrFolderNotFound,
+			expectedMode:   folder.DeleteRestrict,
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "not_found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deletedMode folder.DeleteMode
+			repo := &mockFolderRepository{
+				deleteFunc: func(ctx context.Context, id uuid.UUID, mode folder.DeleteMode) error {
+					deletedMode = mode
+					return tt.deleteErr
+				},
+			}
+			service := folderapp.NewService(repo, &mockDrawingRepository{}, logger)
+
+			id := uuid.New().String()
+			req := httptest.NewRequest(http.MethodDelete, "/folders/"+id+tt.query, nil)
+			req.SetPathValue("id", id)
+			w := httptest.NewRecorder()
+
+			NewFolderHandler(service, logger).DeleteFolder(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if deletedMode != tt.expectedMode {
+				t.Errorf("expected delete mode %q, got %q", tt.expectedMode, deletedMode)
+			}
+			if tt.expectedError != "" {
+				var resp ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("failed to unmarshal response: %v", err)
+				}
+				if resp.Error != tt.expectedError {
+					t.Errorf("expected error %q, got %q", tt.expectedError, resp.Error)
+				}
+			}
+		})
+	}
+}
+
+func TestMoveDrawing(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	folderID := uuid.New()
+
+	tests := []struct {
+		name           string
+		body           string
+		moveErr        error
+		expectedFolder *uuid.UUID
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "into a folder",
+			body:           `{"folder_id": "` + folderID.String() + `"}`,
+			expectedFolder: &folderID,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "out of any folder",
+			body:           `{"folder_id": null}`,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "missing folder",
+			body:           `{"folder_id": "` + folderID.String() + `"}`,
+			moveErr:        folder.ErrFolderNotFound,
+			expectedFolder: &folderID,
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "not_found",
+		},
+		{
+			name:           "missing drawing",
+			body:           `{}`,
+			moveErr:        drawing.ErrDrawingNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "not_found",
+		},
+		{
+			name:           "invalid folder ID",
+			body:           `{"folder_id": "inbox"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_request",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var movedTo *uuid.UUID
+			drawings := &mockDrawingRepository{
+				moveToFolderFunc: func(ctx context.Context, id uuid.UUID, folderID *uuid.UUID) error {
+					movedTo = folderID
+					return tt.moveErr
+				},
+			}
+			service := folderapp.NewService(&mockFolderRepository{}, drawings, logger)
+
+			id := uuid.New().String()
+			req := httptest.NewRequest(http.MethodPost, "/drawings/"+id+"/move", strings.NewReader(tt.body))
+			req.SetPathValue("id", id)
+			w := httptest.NewRecorder()
+
+			NewFolderHandler(service, logger).MoveDrawing(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if (movedTo == nil) != (tt.expectedFolder == nil) || (movedTo != nil && *movedTo != *tt.expectedFolder) {
+				t.Errorf("expected folder %v, got %v", tt.expectedFolder, movedTo)
+			}
+			if tt.expectedError != "" {
+				var resp ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("failed to unmarshal response: %v", err)
+				}
+				if resp.Error != tt.expectedError {
+					t.Errorf("expected error %q, got %q", tt.expectedError, resp.Error)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/personal-excalidraw/backend/internal/adapter/http/util"
+	drawingapp "github.com/personal-excalidraw/backend/internal/application/drawing"
+)
+
+// GenerateDrawingRequest represents the HTTP request for generating a drawing from diagram DSL source
+type GenerateDrawingRequest struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	Slug   string `json:"slug,omitempty"`
+	Upsert bool   `json:"upsert,omitempty"`
+}
+
+// GenerateDrawing handles POST /api/drawings/generate
+// The body is either a JSON GenerateDrawingRequest, or the DSL source as text with the name, slug
+// and upsert query parameters. A created drawing is answered with 201, an upserted one with 200
+func (h *DrawingHandler) GenerateDrawing(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("handling generate drawing request")
+
+	req, err := readGenerateDrawingRequest(w, r)
+	if err != nil {
+		h.logger.Error("invalid generate drawing request", "error", err)
+		status := http.StatusBadRequest
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		response := ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		}
+		util.RespondJSON(w, status, response)
+		return
+	}
+
+	// Read the version the client expects to overwrite in upsert mode
+	expectedVersions, err := parseIfMatch(r)
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	clientID, ok := h.parseClientID(w, r)
+	if !ok {
+		return
+	}
+
+	output, err := h.service.GenerateDrawing(r.Context(), drawingapp.GenerateDrawingInput{
+		Name:     req.Name,
+		Source:   req.Source,
+		Slug:     req.Slug,
+		Upsert:   req.Upsert,
+		ClientID: clientID,
+
+		ExpectedVersions: expectedVersions,
+	})
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	status := http.StatusOK
+	if output.Created {
+		status = http.StatusCreated
+	}
+	respondDrawing(w, r, status, output.Drawing)
+}
+
+// readGenerateDrawingRequest reads the DSL source and options of a generate request
+func readGenerateDrawingRequest(w http.ResponseWriter, r *http.Request) (*GenerateDrawingRequest, error) {
+	if r.Body == nil {
+		return nil, errors.New("request body is empty")
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxDiagramSourceBytes)
+	defer r.Body.Close()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var req *GenerateDrawingRequest
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+		req = &GenerateDrawingRequest{}
+		if err := util.DecodeJSON(bytes.NewReader(body), req); err != nil {
+			return nil, errors.New("invalid JSON format")
+		}
+	} else {
+		query := r.URL.Query()
+		req = &GenerateDrawingRequest{Name: query.Get("name"), Slug: query.Get("slug"), Source: string(body)}
+		if upsert := query.Get("upsert"); upsert != "" {
+			if req.Upsert, err = strconv.ParseBool(upsert); err != nil {
+				return nil, errors.New("upsert must be true or false")
+			}
+		}
+	}
+
+	if strings.TrimSpace(req.Source) == "" {
+		return nil, errors.New("source is required")
+	}
+	return req, nil
+}
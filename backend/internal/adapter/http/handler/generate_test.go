@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	drawingapp "github.com/personal-excalidraw/backend/internal/application/drawing"
+	"github.com/personal-excalidraw/backend/internal/domain/diagram"
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+const generateTestSource = `title "Checkout"
+service api "API"
+database db "Orders"
+api -> db "SQL"`
+
+func TestGenerateDrawing(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	// existing is the drawing stored under the "checkout" slug, generated earlier and then edited
+	existing := func(t *testing.T) *drawing.Drawing {
+		result, err := diagram.CompileDSL(context.Background(), generateTestSource, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range result.Data["elements"].([]interface{}) {
+			if el := item.(map[string]interface{}); el["id"] == diagram.NodeElementID("api") {
+				el["x"], el["y"] = 500.0, 400.0
+			}
+		}
+		d, err := drawing.NewDrawing("Checkout (edited)", result.Data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.SetSlug("checkout")
+		return d
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		contentType    string
+		body           string
+		stored         bool
+		expectedStatus int
+		expectedError  string
+		expectedName   string
+		expectedSlug   string
+	}{
+		{
+			name:           "JSON body",
+			path:           "/drawings/generate",
+			contentType:    "application/json",
+			body:           `{"source": "node a\na -> b"}`,
+			expectedStatus: http.StatusCreated,
+			expectedName:   "Untitled",
+			expectedSlug:   "Xk9pQ2mR",
+		},
+		{
+			name:           "text body with a custom slug",
+			path:           "/drawings/generate?slug=checkout&name=Platform",
+			contentType:    "text/plain",
+			body:           generateTestSource,
+			expectedStatus: http.StatusCreated,
+			expectedName:   "Platform",
+			expectedSlug:   "checkout",
+		},
+		{
+			name:           "long title is truncated",
+			path:           "/drawings/generate",
+			contentType:    "text/plain",
+			body:           `title "` + strings.Repeat("x", drawing.MaxNameLength+10) + `"` + "\nnode a",
+			expectedStatus: http.StatusCreated,
+			expectedName:   strings.Repeat("x", drawing.MaxNameLength),
+			expectedSlug:   "Xk9pQ2mR",
+		},
+		{
+			name:           "upsert creates a missing drawing",
+			path:           "/drawings/generate?upsert=true&slug=checkout",
+			contentType:    "text/plain",
+			body:           generateTestSource,
+			expectedStatus: http.StatusCreated,
+			expectedName:   "Checkout",
+			expectedSlug:   "checkout",
+		},
+		{
+			name:           "upsert updates the drawing",
+			path:           "/drawings/generate",
+			contentType:    "application/json",
+			body:           `{"source": "service api \"API\"\ndatabase db \"Orders\"\nqueue events\napi -> db\napi -> events", "slug": "checkout", "upsert": true}`,
+			stored:         true,
+			expectedStatus: http.StatusOK,
+			expectedName:   "Checkout (edited)",
+			expectedSlug:   "checkout",
+		},
+		{
+			name:           "upsert without a slug",
+			path:           "/drawings/generate?upsert=true",
+			contentType:    "text/plain",
+			body:           generateTestSource,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_slug",
+		},
+		{
+			name:           "invalid source",
+			path:           "/drawings/generate",
+			contentType:    "text/plain",
+			body:           "node a size=3",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_diagram",
+		},
+		{
+			name:           "invalid upsert parameter",
+			path:           "/drawings/generate?upsert=maybe",
+			contentType:    "text/plain",
+			body:           generateTestSource,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_request",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stored *drawing.Drawing
+			if tt.stored {
+				stored = existing(t)
+			}
+			var (
+				saved    *drawing.Drawing
+				savedRev *drawing.Revision
+			)
+			repo := &mockDrawingRepository{
+				createFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+					saved = d
+					return nil
+				},
+				updateFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+					saved, savedRev = d, rev
+					return nil
+				},
+				findBySlugFunc: func(ctx context.Context, slug string) (*drawing.Drawing, error) {
+					if stored != nil && slug == stored.Slug() {
+						return stored, nil
+					}
+					return nil, drawing.ErrDrawingNotFound
+				},
+				findBySlugAliasFunc: func(ctx context.Context, slug string) (*drawing.Drawing, error) {
+					return nil, drawing.ErrDrawingNotFound
+				},
+			}
+			// The latest revision is a fresh autosave of the same client, which a regeneration must not fold into
+			revisions := &mockRevisionRepository{
+				findLatestMetaFunc: func(ctx context.Context, drawingID uuid.UUID) (*drawing.RevisionMeta, error) {
+					return &drawing.RevisionMeta{ID: uuid.New(), Number: 1, ClientID: "tab-1", CreatedAt: time.Now().UTC()}, nil
+				},
+			}
+			policy := drawing.RevisionPolicy{CoalesceWindow: time.Minute}
+			service := drawingapp.NewService(repo, revisions, policy, &mockSlugGenerator{}, logger)
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			req.Header.Set(clientIDHeader, "tab-1")
+			w := httptest.NewRecorder()
+
+			NewDrawingHandler(service, logger).GenerateDrawing(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedError != "" {
+				var resp ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("failed to unmarshal response: %v", err)
+				}
+				if resp.Error != tt.expectedError {
+					t.Errorf("expected error %q, got %q", tt.expectedError, resp.Error)
+				}
+				return
+			}
+
+			if saved.Name() != tt.expectedName || saved.Slug() != tt.expectedSlug {
+				t.Errorf("expected %q at slug %q, got %q at %q", tt.expectedName, tt.expectedSlug, saved.Name(), saved.Slug())
+			}
+
+			byID := make(map[string]map[string]interface{})
+			for _, item := range saved.Data()["elements"].([]interface{}) {
+				el := item.(map[string]interface{})
+				byID[el["id"].(string)] = el
+			}
+			if tt.stored {
+				if saved.Version() != 2 {
+					t.Errorf("expected the drawing saved as version 2, got %d", saved.Version())
+				}
+				if savedRev.Number() != 0 {
+					t.Errorf("expected a new revision, got revision %d overwritten", savedRev.Number())
+				}
+				if api := byID["node:api"]; api["x"] != 500.0 || api["y"] != 400.0 {
+					t.Errorf("expected the moved node to keep its position, got (%v, %v)", api["x"], api["y"])
+				}
+				if byID["node:events"] == nil {
+					t.Errorf("expected the new node in the drawing")
+				}
+			}
+		})
+	}
+}
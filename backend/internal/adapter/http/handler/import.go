@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/personal-excalidraw/backend/internal/adapter/http/util"
+	drawingapp "github.com/personal-excalidraw/backend/internal/application/drawing"
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// excalidrawMediaType is the media type of .excalidraw files
+const excalidrawMediaType = "application/vnd.excalidraw+json"
+
+const (
+	// maxImportFiles limits the number of files in an import request
+	maxImportFiles = 500
+
+	// maxDiagramSourceBytes limits the size of diagram source text
+	maxDiagramSourceBytes = 1 << 20
+)
+
+// ImportResponse represents the HTTP response for an import
+type ImportResponse struct {
+	Drawings []*DrawingResponse `json:"drawings"`
+	Failed   []*ImportFailure   `json:"failed"`
+}
+
+// ImportFailure describes an uploaded file that could not be imported
+type ImportFailure struct {
+	Filename string            `json:"filename"`
+	Error    string            `json:"error"`
+	Message  string            `json:"message"`
+	Details  map[string]string `json:"details,omitempty"`
+}
+
+// ImportMermaidRequest represents the HTTP request for importing a Mermaid diagram
+type ImportMermaidRequest struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+}
+
+// ImportDrawings handles POST /api/drawings/import
+// Every file part of the multipart/form-data body is imported as a drawing named after its file name
+// The drawioPages query parameter imports the pages of draw.io files as separate "drawings" (default) or as "frames" of one drawing
+func (h *DrawingHandler) ImportDrawings(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("handling import drawings request")
+
+	pages := drawingapp.DrawioPages(r.URL.Query().Get("drawioPages"))
+	if pages != "" && pages != drawingapp.DrawioPagesDrawings && pages != drawingapp.DrawioPagesFrames {
+		h.logger.Error("invalid drawioPages parameter", "drawioPages", pages)
+		response := ErrorResponse{
+			Error:   "invalid_request",
+			Message: "drawioPages must be drawings or frames",
+		}
+		util.RespondJSON(w, http.StatusBadRequest, response)
+		return
+	}
+
+	files, err := readImportFiles(w, r)
+	if err != nil {
+		h.logger.Error("invalid import request", "error", err)
+		status := http.StatusBadRequest
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		response := ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		}
+		util.RespondJSON(w, status, response)
+		return
+	}
+
+	output, err := h.service.ImportDrawings(r.Context(), drawingapp.ImportInput{Files: files, DrawioPages: pages})
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	response := ImportResponse{
+		Drawings: make([]*DrawingResponse, len(output.Drawings)),
+		Failed:   make([]*ImportFailure, len(output.Failures)),
+	}
+	for i, d := range output.Drawings {
+		response.Drawings[i] = toDrawingResponse(d)
+	}
+	// The request only fails as a whole when no file could be imported, with the most severe
+	// status of its failures, so a repository error is not reported as a bad upload
+	failedStatus := http.StatusBadRequest
+	for i, f := range output.Failures {
+		status, errorType, message := mapErrorToHTTP(f.Err)
+		response.Failed[i] = &ImportFailure{
+			Filename: f.Filename,
+			Error:    errorType,
+			Message:  message,
+			Details:  errorDetails(f.Err),
+		}
+		if status > failedStatus {
+			failedStatus = status
+		}
+	}
+
+	status := http.StatusCreated
+	if len(output.Drawings) == 0 {
+		status = failedStatus
+	}
+	util.RespondJSON(w, status, response)
+}
+
+// readImportFiles reads the file parts of a multipart/form-data import request, in upload order
+func readImportFiles(w http.ResponseWriter, r *http.Request) ([]drawingapp.ImportFile, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, drawing.MaxImportBytes)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, errors.New("request must be multipart/form-data")
+	}
+
+	var files []drawingapp.ImportFile
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("malformed multipart body: %w", err)
+		}
+		if part.FileName() == "" {
+			// Plain form fields carry nothing to import
+			part.Close()
+			continue
+		}
+		if len(files) == maxImportFiles {
+			return nil, fmt.Errorf("too many files, at most %d can be imported at once", maxImportFiles)
+		}
+
+		content, err := io.ReadAll(part)
+		part.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", part.FileName(), err)
+		}
+		files = append(files, drawingapp.ImportFile{Filename: part.FileName(), Content: content})
+	}
+
+	if len(files) == 0 {
+		return nil, errors.New("no files uploaded")
+	}
+	return files, nil
+}
+
+// ImportMermaid handles POST /api/drawings/import/mermaid
+// The body is either a JSON ImportMermaidRequest, or the Mermaid source as text with the name in the name query parameter
+func (h *DrawingHandler) ImportMermaid(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("handling import mermaid request")
+
+	req, err := readImportMermaidRequest(w, r)
+	if err != nil {
+		h.logger.Error("invalid import mermaid request", "error", err)
+		status := http.StatusBadRequest
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		response := ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		}
+		util.RespondJSON(w, status, response)
+		return
+	}
+
+	output, err := h.service.ImportMermaid(r.Context(), drawingapp.ImportMermaidInput{
+		Name:   req.Name,
+		Source: req.Source,
+	})
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	respondDrawing(w, r, http.StatusCreated, output)
+}
+
+// readImportMermaidRequest reads the diagram source and name of a Mermaid import request
+func readImportMermaidRequest(w http.ResponseWriter, r *http.Request) (*ImportMermaidRequest, error) {
+	if r.Body == nil {
+		return nil, errors.New("request body is empty")
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxDiagramSourceBytes)
+	defer r.Body.Close()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &ImportMermaidRequest{Name: r.URL.Query().Get("name"), Source: string(body)}
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+		req = &ImportMermaidRequest{}
+		if err := util.DecodeJSON(bytes.NewReader(body), req); err != nil {
+			return nil, errors.New("invalid JSON format")
+		}
+	}
+
+	if strings.TrimSpace(req.Source) == "" {
+		return nil, errors.New("source is required")
+	}
+	return req, nil
+}
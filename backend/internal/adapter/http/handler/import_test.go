@@ -0,0 +1,546 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"image/png"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	drawingapp "github.com/personal-excalidraw/backend/internal/application/drawing"
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// importTestFile is a .excalidraw file as saved by the editor, with a legacy boundElementIds field
+const importTestFile = `{
+	"type": "excalidraw",
+	"version": 2,
+	"source": "https://excalidraw.com",
+	"elements": [
+		{"id": "rect-1", "type": "rectangle", "x": 0, "y": 0, "width": 100, "height": 50, "boundElementIds": ["arrow-1"]}
+	],
+	"appState": {"viewBackgroundColor": "#ffffff", "gridSize": null},
+	"files": {}
+}`
+
+// multipartBody encodes files, keyed by file name, as a multipart/form-data body
+func multipartBody(t *testing.T, files [][2]string) (*bytes.Buffer, string) {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, f := range files {
+		part, err := mw.CreateFormFile("files", f[0])
+		if err != nil {
+			t.Fatalf("failed to create form file: %v", err)
+		}
+		part.Write([]byte(f[1]))
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatalf("failed to close multipart writer: %v", err)
+	}
+	return &body, mw.FormDataContentType()
+}
+
+func TestImportDrawings(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	tests := []struct {
+		name           string
+		files          [][2]string
+		contentType    string
+		expectedStatus int
+		createErr      error
+		expectedNames  []string
+		expectedFailed []string
+	}{
+		{
+			name:           "single file",
+			files:          [][2]string{{"architecture.excalidraw", importTestFile}},
+			expectedStatus: http.StatusCreated,
+			expectedNames:  []string{"architecture"},
+		},
+		{
+			name: "many files in upload order",
+			files: [][2]string{
+				{"docs/b.excalidraw", importTestFile},
+				{"a.excalidraw.json", importTestFile},
+				{".excalidraw", importTestFile},
+			},
+			expectedStatus: http.StatusCreated,
+			expectedNames:  []string{"b", "a", "Untitled"},
+		},
+		{
+			name: "partial failure",
+			files: [][2]string{
+				{"good.excalidraw", importTestFile},
+				{"library.excalidrawlib", `{"type": "excalidrawlib", "libraryItems": []}`},
+				{"broken.excalidraw", `{"type": "excalidraw", "elements": [{"type": "rectangle"}]}`},
+			},
+			expectedStatus: http.StatusCreated,
+			expectedNames:  []string{"good"},
+			expectedFailed: []string{"invalid_file", "invalid_data"},
+		},
+		{
+			name:           "no valid file",
+			files:          [][2]string{{"notes.txt", "hello"}},
+			expectedStatus: http.StatusBadRequest,
+			expectedFailed: []string{"invalid_file"},
+		},
+		{
+			name: "no file stored",
+			files: [][2]string{
+				{"notes.txt", "hello"},
+				{"good.excalidraw", importTestFile},
+			},
+			createErr:      errors.New("connection refused"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedFailed: []string{"invalid_file", "internal_error"},
+		},
+		{
+			name:           "no files",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "not multipart",
+			contentType:    "application/json",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created []*drawing.Drawing
+			repo := &mockDrawingRepository{
+				createFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+					if tt.createErr != nil {
+						return tt.createErr
+					}
+					created = append(created, d)
+					return nil
+				},
+			}
+			service := drawingapp.NewService(repo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+			handler := NewDrawingHandler(service, logger)
+
+			body, contentType := multipartBody(t, tt.files)
+			if tt.contentType != "" {
+				contentType = tt.contentType
+			}
+			req := httptest.NewRequest(http.MethodPost, "/drawings/import", body)
+			req.Header.Set("Content-Type", contentType)
+			w := httptest.NewRecorder()
+
+			handler.ImportDrawings(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedNames == nil && tt.expectedFailed == nil {
+				return
+			}
+
+			var resp ImportResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if len(resp.Drawings) != len(tt.expectedNames) {
+				t.Fatalf("expected %d drawings, got %d", len(tt.expectedNames), len(resp.Drawings))
+			}
+			for i, name := range tt.expectedNames {
+				if resp.Drawings[i].Name != name {
+					t.Errorf("drawing %d: expected name %q, got %q", i, name, resp.Drawings[i].Name)
+				}
+			}
+			if len(resp.Failed) != len(tt.expectedFailed) {
+				t.Fatalf("expected %d failures, got %+v", len(tt.expectedFailed), resp.Failed)
+			}
+			for i, errorType := range tt.expectedFailed {
+				if resp.Failed[i].Error != errorType {
+					t.Errorf("failure %d: expected error %q, got %q", i, errorType, resp.Failed[i].Error)
+				}
+			}
+
+			if len(created) > 0 {
+				data := created[0].Data()
+				el := data["elements"].([]interface{})[0].(map[string]interface{})
+				if _, ok := el["boundElementIds"]; ok {
+					t.Errorf("expected legacy boundElementIds to be upgraded")
+				}
+				for _, key := range []string{"type", "version", "source"} {
+					if _, ok := data[key]; ok {
+						t.Errorf("expected file header %q not to be stored", key)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestExportExcalidraw(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newTestExportHandler(&mockDrawingRepository{findByIDFunc: exportTestDrawing}, &mockThumbnailRepository{}, logger)
+
+	id := uuid.New().String()
+	req := httptest.NewRequest(http.MethodGet, "/drawings/"+id+"/export.excalidraw", nil)
+	req.SetPathValue("id", id)
+	w := httptest.NewRecorder()
+
+	handler.ExportExcalidraw(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != excalidrawMediaType {
+		t.Errorf("expected Content-Type %s, got %q", excalidrawMediaType, ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename="Architecture _ Overview.excalidraw"` {
+		t.Errorf("unexpected Content-Disposition %q", cd)
+	}
+
+	var file map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &file); err != nil {
+		t.Fatalf("failed to unmarshal file: %v", err)
+	}
+	if file["type"] != "excalidraw" || file["version"] != 2.0 || file["source"] != drawing.ExcalidrawFileSource {
+		t.Errorf("unexpected file header: type %v, version %v, source %v", file["type"], file["version"], file["source"])
+	}
+	if elements, ok := file["elements"].([]interface{}); !ok || len(elements) != 2 {
+		t.Errorf("expected 2 elements, got %v", file["elements"])
+	}
+	if files, ok := file["files"].(map[string]interface{}); !ok || len(files) != 0 {
+		t.Errorf("expected an empty files object, got %v", file["files"])
+	}
+
+	// The exported file imports back into an equivalent scene
+	data, err := drawing.ParseExcalidrawFile(w.Body.Bytes())
+	if err != nil {
+		t.Fatalf("exported file does not import: %v", err)
+	}
+	if len(data["elements"].([]interface{})) != 2 {
+		t.Errorf("expected 2 elements after import, got %v", data["elements"])
+	}
+}
+
+func TestEmbeddedSceneRoundTrip(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	tests := []struct {
+		name     string
+		path     string
+		export   func(h *ExportHandler) http.HandlerFunc
+		filename string
+	}{
+		{
+			name:     "PNG",
+			path:     "/export.png?embedScene=true",
+			export:   func(h *ExportHandler) http.HandlerFunc { return h.ExportPNG },
+			filename: "Architecture _ Overview.excalidraw.png",
+		},
+		{
+			name:     "SVG",
+			path:     "/export.svg?embedScene=true",
+			export:   func(h *ExportHandler) http.HandlerFunc { return h.ExportSVG },
+			filename: "Architecture _ Overview.excalidraw.svg",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exportHandler := newTestExportHandler(&mockDrawingRepository{findByIDFunc: exportTestDrawing}, &mockThumbnailRepository{}, logger)
+
+			id := uuid.New().String()
+			req := httptest.NewRequest(http.MethodGet, "/drawings/"+id+tt.path, nil)
+			req.SetPathValue("id", id)
+			w := httptest.NewRecorder()
+			tt.export(exportHandler)(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			if cd := w.Header().Get("Content-Disposition"); cd != `inline; filename="`+tt.filename+`"` {
+				t.Errorf("unexpected Content-Disposition %q", cd)
+			}
+			if tt.name == "PNG" {
+				if _, err := png.Decode(bytes.NewReader(w.Body.Bytes())); err != nil {
+					t.Fatalf("image with an embedded scene is not a valid PNG: %v", err)
+				}
+			}
+
+			var created *drawing.Drawing
+			repo := &mockDrawingRepository{
+				createFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+					created = d
+					return nil
+				},
+			}
+			service := drawingapp.NewService(repo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+			drawingHandler := NewDrawingHandler(service, logger)
+
+			body, contentType := multipartBody(t, [][2]string{{tt.filename, w.Body.String()}})
+			req = httptest.NewRequest(http.MethodPost, "/drawings/import", body)
+			req.Header.Set("Content-Type", contentType)
+			w = httptest.NewRecorder()
+			drawingHandler.ImportDrawings(w, req)
+
+			if w.Code != http.StatusCreated {
+				t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+			}
+			if created.Name() != "Architecture _ Overview" {
+				t.Errorf("expected name from the file name, got %q", created.Name())
+			}
+
+			original, _ := exportTestDrawing(context.Background(), uuid.New())
+			for _, key := range []string{"elements", "appState"} {
+				want, _ := json.Marshal(original.Data()[key])
+				got, _ := json.Marshal(created.Data()[key])
+				if !bytes.Equal(want, got) {
+					t.Errorf("imported %s differ from the exported ones:\nwant %s\ngot  %s", key, want, got)
+				}
+			}
+		})
+	}
+
+	t.Run("image without a scene", func(t *testing.T) {
+		exportHandler := newTestExportHandler(&mockDrawingRepository{findByIDFunc: exportTestDrawing}, &mockThumbnailRepository{}, logger)
+
+		id := uuid.New().String()
+		req := httptest.NewRequest(http.MethodGet, "/drawings/"+id+"/export.png", nil)
+		req.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		exportHandler.ExportPNG(w, req)
+
+		service := drawingapp.NewService(&mockDrawingRepository{}, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+		body, contentType := multipartBody(t, [][2]string{{"plain.png", w.Body.String()}})
+		req = httptest.NewRequest(http.MethodPost, "/drawings/import", body)
+		req.Header.Set("Content-Type", contentType)
+		w = httptest.NewRecorder()
+		NewDrawingHandler(service, logger).ImportDrawings(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected status 400, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
+
+func TestImportMermaid(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	tests := []struct {
+		name           string
+		path           string
+		contentType    string
+		body           string
+		expectedStatus int
+		expectedError  string
+		expectedName   string
+	}{
+		{
+			name:           "JSON body",
+			path:           "/drawings/import/mermaid",
+			contentType:    "application/json",
+			body:           `{"name": "Flow", "source": "flowchart LR\n  A --> B"}`,
+			expectedStatus: http.StatusCreated,
+			expectedName:   "Flow",
+		},
+		{
+			name:           "text body named by query",
+			path:           "/drawings/import/mermaid?name=Login",
+			contentType:    "text/plain; charset=utf-8",
+			body:           "sequenceDiagram\n  A->>B: hi",
+			expectedStatus: http.StatusCreated,
+			expectedName:   "Login",
+		},
+		{
+			name:           "named by title",
+			path:           "/drawings/import/mermaid",
+			contentType:    "text/plain",
+			body:           "---\ntitle: Pipeline\n---\ngraph TD\n  A --> B",
+			expectedStatus: http.StatusCreated,
+			expectedName:   "Pipeline",
+		},
+		{
+			name:           "long title is truncated",
+			path:           "/drawings/import/mermaid",
+			body:           "---\ntitle: " + strings.Repeat("é", drawing.MaxNameLength) + "\n---\ngraph TD\n  A --> B",
+			expectedStatus: http.StatusCreated,
+			expectedName:   strings.Repeat("é", drawing.MaxNameLength/2),
+		},
+		{
+			name:           "default name",
+			path:           "/drawings/import/mermaid",
+			body:           "graph TD\n  A",
+			expectedStatus: http.StatusCreated,
+			expectedName:   drawing.DefaultImportName,
+		},
+		{
+			name:           "invalid diagram",
+			path:           "/drawings/import/mermaid",
+			body:           "graph TD\n  A[oops --> B",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_diagram",
+		},
+		{
+			name:           "unsupported diagram",
+			path:           "/drawings/import/mermaid",
+			body:           "classDiagram\n  Animal <|-- Duck",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "unsupported_diagram",
+		},
+		{
+			name:           "missing source",
+			path:           "/drawings/import/mermaid",
+			contentType:    "application/json",
+			body:           `{"name": "Empty"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_request",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created *drawing.Drawing
+			repo := &mockDrawingRepository{
+				createFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+					created = d
+					return nil
+				},
+			}
+			service := drawingapp.NewService(repo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+			handler := NewDrawingHandler(service, logger)
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, bytes.NewBufferString(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ImportMermaid(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedError != "" {
+				var resp ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("failed to unmarshal response: %v", err)
+				}
+				if resp.Error != tt.expectedError {
+					t.Errorf("expected error %q, got %q: %s", tt.expectedError, resp.Error, resp.Message)
+				}
+				return
+			}
+
+			if created == nil || created.Name() != tt.expectedName {
+				t.Fatalf("expected a drawing named %q, got %v", tt.expectedName, created)
+			}
+			elements, _ := created.Data()["elements"].([]interface{})
+			if len(elements) == 0 {
+				t.Errorf("expected the diagram's elements to be stored")
+			}
+		})
+	}
+}
+
+func TestImportDrawio(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	page := func(name, label string) string {
+		return `<diagram name="` + name + `"><mxGraphModel><root><mxCell id="0"/><mxCell id="1" parent="0"/>` +
+			`<mxCell id="a" value="` + label + `" vertex="1" parent="1"><mxGeometry x="0" y="0" width="120" height="60" as="geometry"/></mxCell>` +
+			`</root></mxGraphModel></diagram>`
+	}
+	twoPages := `<mxfile>` + page("Context", "User") + page("Containers", "API") + `</mxfile>`
+
+	tests := []struct {
+		name           string
+		query          string
+		files          [][2]string
+		expectedStatus int
+		expectedNames  []string
+		expectedFrames int
+	}{
+		{
+			name:           "drawing per page",
+			files:          [][2]string{{"system.drawio", twoPages}},
+			expectedStatus: http.StatusCreated,
+			expectedNames:  []string{"system - Context", "system - Containers"},
+		},
+		{
+			name:           "single page named after the file",
+			files:          [][2]string{{"flow.drawio", `<mxfile>` + page("Page-1", "Start") + `</mxfile>`}},
+			expectedStatus: http.StatusCreated,
+			expectedNames:  []string{"flow"},
+		},
+		{
+			name:           "frames in one drawing",
+			query:          "?drawioPages=frames",
+			files:          [][2]string{{"system.drawio.xml", twoPages}},
+			expectedStatus: http.StatusCreated,
+			expectedNames:  []string{"system"},
+			expectedFrames: 2,
+		},
+		{
+			name:           "invalid page mode",
+			query:          "?drawioPages=tabs",
+			files:          [][2]string{{"system.drawio", twoPages}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "malformed file",
+			files:          [][2]string{{"broken.drawio", `<mxfile><diagram>`}},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created []*drawing.Drawing
+			repo := &mockDrawingRepository{
+				createFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+					created = append(created, d)
+					return nil
+				},
+			}
+			service := drawingapp.NewService(repo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+
+			body, contentType := multipartBody(t, tt.files)
+			req := httptest.NewRequest(http.MethodPost, "/drawings/import"+tt.query, body)
+			req.Header.Set("Content-Type", contentType)
+			w := httptest.NewRecorder()
+
+			NewDrawingHandler(service, logger).ImportDrawings(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if len(created) != len(tt.expectedNames) {
+				t.Fatalf("expected %d drawings, got %d", len(tt.expectedNames), len(created))
+			}
+			for i, name := range tt.expectedNames {
+				if created[i].Name() != name {
+					t.Errorf("drawing %d: expected name %q, got %q", i, name, created[i].Name())
+				}
+			}
+
+			if tt.expectedFrames > 0 {
+				frames := 0
+				for _, item := range created[0].Data()["elements"].([]interface{}) {
+					if item.(map[string]interface{})["type"] == "frame" {
+						frames++
+					}
+				}
+				if frames != tt.expectedFrames {
+					t.Errorf("expected %d frames, got %d", tt.expectedFrames, frames)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/personal-excalidraw/backend/internal/adapter/http/util"
+	drawingapp "github.com/personal-excalidraw/backend/internal/application/drawing"
+	"github.com/personal-excalidraw/backend/internal/domain/diagram"
+)
+
+// LayoutDrawing handles POST /api/drawings/{id}/layout
+// The algorithm (layered or force), direction (TB, BT, LR or RL) and dryRun query parameters
+// configure the layout; a dry run answers with the laid out drawing without saving it
+func (h *DrawingHandler) LayoutDrawing(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("handling layout drawing request")
+
+	// Extract ID from path
+	id := r.PathValue("id")
+	if id == "" {
+		h.logger.Error("missing drawing ID in path")
+		response := ErrorResponse{
+			Error:   "invalid_request",
+			Message: "missing drawing ID",
+		}
+		util.RespondJSON(w, http.StatusBadRequest, response)
+		return
+	}
+
+	// Read the version the client expects to overwrite
+	expectedVersions, err := parseIfMatch(r)
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	input, ok := h.parseLayoutInput(w, r)
+	if !ok {
+		return
+	}
+	if input.ClientID, ok = h.parseClientID(w, r); !ok {
+		return
+	}
+	input.ExpectedVersions = expectedVersions
+
+	output, err := h.service.LayoutDrawing(r.Context(), id, input)
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	respondDrawing(w, r, http.StatusOK, output)
+}
+
+// parseLayoutInput reads the layout options from the query string, answering 400 if one is invalid
+func (h *DrawingHandler) parseLayoutInput(w http.ResponseWriter, r *http.Request) (drawingapp.LayoutDrawingInput, bool) {
+	query := r.URL.Query()
+	var input drawingapp.LayoutDrawingInput
+
+	switch algorithm := diagram.LayoutAlgorithm(strings.ToLower(query.Get("algorithm"))); algorithm {
+	case "", diagram.LayoutLayered, diagram.LayoutForce:
+		input.Algorithm = algorithm
+	default:
+		h.logger.Error("invalid algorithm parameter", "algorithm", query.Get("algorithm"))
+		response := ErrorResponse{
+			Error:   "invalid_request",
+			Message: "algorithm must be layered or force",
+		}
+		util.RespondJSON(w, http.StatusBadRequest, response)
+		return input, false
+	}
+
+	switch direction := diagram.Direction(strings.ToUpper(query.Get("direction"))); direction {
+	case "", diagram.TopToBottom, diagram.BottomToTop, diagram.LeftToRight, diagram.RightToLeft:
+		input.Direction = direction
+	default:
+		h.logger.Error("invalid direction parameter", "direction", query.Get("direction"))
+		response := ErrorResponse{
+			Error:   "invalid_request",
+			Message: "direction must be TB, BT, LR or RL",
+		}
+		util.RespondJSON(w, http.StatusBadRequest, response)
+		return input, false
+	}
+
+	if dryRunStr := query.Get("dryRun"); dryRunStr != "" {
+		dryRun, err := strconv.ParseBool(dryRunStr)
+		if err != nil {
+			h.logger.Error("invalid dryRun parameter", "dryRun", dryRunStr)
+			response := ErrorResponse{
+				Error:   "invalid_request",
+				Message: "dryRun must be true or false",
+			}
+			util.RespondJSON(w, http.StatusBadRequest, response)
+			return input, false
+		}
+		input.DryRun = dryRun
+	}
+
+	return input, true
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	drawingapp "github.com/personal-excalidraw/backend/internal/application/drawing"
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+func TestLayoutDrawing(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	// stored is a drawing with two boxes drawn side by side and an arrow bound between them
+	stored := func(t *testing.T, elements ...interface{}) *drawing.Drawing {
+		if elements == nil {
+			elements = []interface{}{
+				map[string]interface{}{"id": "api", "type": "rectangle", "x": 0.0, "y": 0.0, "width": 120.0, "height": 60.0, "version": 1.0},
+				map[string]interface{}{"id": "db", "type": "ellipse", "x": 300.0, "y": 0.0, "width": 120.0, "height": 60.0, "version": 1.0},
+				map[string]interface{}{
+					"id": "query", "type": "arrow", "x": 120.0, "y": 30.0, "width": 180.0, "height": 0.0, "version": 1.0,
+					"points":       []interface{}{[]interface{}{0.0, 0.0}, []interface{}{180.0, 0.0}},
+					"startBinding": map[string]interface{}{"elementId": "api", "focus": 0.0, "gap": 4.0},
+					"endBinding":   map[string]interface{}{"elementId": "db", "focus": 0.0, "gap": 4.0},
+				},
+			}
+		}
+		d, err := drawing.NewDrawing("Architecture", map[string]interface{}{"elements": elements})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		ifMatch        string
+		elements       []interface{}
+		expectedStatus int
+		expectedError  string
+		expectSaved    bool
+	}{
+		{
+			name:           "layered layout is saved",
+			query:          "?direction=TB",
+			expectedStatus: http.StatusOK,
+			expectSaved:    true,
+		},
+		{
+			name:           "force layout is saved",
+			query:          "?algorithm=force",
+			ifMatch:        `"1"`,
+			expectedStatus: http.StatusOK,
+			expectSaved:    true,
+		},
+		{
+			name:           "dry run",
+			query:          "?direction=tb&dryRun=true",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "stale version",
+			ifMatch:        `"2"`,
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedError:  "version_conflict",
+		},
+		{
+			name:           "no shapes",
+			elements:       []interface{}{map[string]interface{}{"id": "note", "type": "text", "text": "Hello", "x": 0.0, "y": 0.0, "width": 40.0, "height": 20.0}},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_diagram",
+		},
+		{
+			name:           "unknown algorithm",
+			query:          "?algorithm=circular",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_request",
+		},
+		{
+			name:           "unknown direction",
+			query:          "?direction=up",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_request",
+		},
+		{
+			name:           "invalid dryRun parameter",
+			query:          "?dryRun=maybe",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_request",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := stored(t, tt.elements...)
+			var (
+				saved    *drawing.Drawing
+				savedRev *drawing.Revision
+			)
+			repo := &mockDrawingRepository{
+				findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
+					return d, nil
+				},
+				updateFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+					saved, savedRev = d, rev
+					return nil
+				},
+			}
+			// The latest revision is a fresh autosave of the same client, which a layout must not fold into
+			revisions := &mockRevisionRepository{
+				findLatestMetaFunc: func(ctx context.Context, drawingID uuid.UUID) (*drawing.RevisionMeta, error) {
+					return &drawing.RevisionMeta{ID: uuid.New(), Number: 1, ClientID: "tab-1", CreatedAt: time.Now().UTC()}, nil
+				},
+			}
+			policy := drawing.RevisionPolicy{CoalesceWindow: time.Minute}
+			service := drawingapp.NewService(repo, revisions, policy, &mockSlugGenerator{}, logger)
+
+			id := d.ID().String()
+			req := httptest.NewRequest(http.MethodPost, "/drawings/"+id+"/layout"+tt.query, nil)
+			req.SetPathValue("id", id)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			req.Header.Set(clientIDHeader, "tab-1")
+			w := httptest.NewRecorder()
+
+			NewDrawingHandler(service, logger).LayoutDrawing(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedError != "" {
+				var resp ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("failed to unmarshal response: %v", err)
+				}
+				if resp.Error != tt.expectedError {
+					t.Errorf("expected error %q, got %q", tt.expectedError, resp.Error)
+				}
+				return
+			}
+
+			var resp DrawingResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			byID := make(map[string]map[string]interface{})
+			for _, item := range resp.Data["elements"].([]interface{}) {
+				el := item.(map[string]interface{})
+				byID[el["id"].(string)] = el
+			}
+			if byID["api"]["x"] == 0.0 && byID["api"]["y"] == 0.0 && byID["db"]["x"] == 300.0 && byID["db"]["y"] == 0.0 {
+				t.Errorf("expected the shapes to be repositioned")
+			}
+
+			if !tt.expectSaved {
+				if saved != nil || resp.Version != 1 {
+					t.Errorf("expected a dry run to leave the drawing unsaved at version 1, got version %d", resp.Version)
+				}
+				return
+			}
+			if saved == nil || resp.Version != 2 {
+				t.Fatalf("expected the drawing saved as version 2, got version %d", resp.Version)
+			}
+			if savedRev.Number() != 0 {
+				t.Errorf("expected a new revision, got revision %d overwritten", savedRev.Number())
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/personal-excalidraw/backend/internal/adapter/http/util"
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// maxNameFilterLength is the longest name filter accepted, the length of the longest name
+const maxNameFilterLength = 255
+
+// rootFolderParam is the folder_id value listing the drawings outside any folder
+const rootFolderParam = "root"
+
+// parseListOrder reads the sort and order query parameters, answering 400 if one is invalid
+// The order defaults to the natural direction of the sort field
+func (h *DrawingHandler) parseListOrder(w http.ResponseWriter, r *http.Request) (drawing.SortOrder, bool) {
+	query := r.URL.Query()
+	order := drawing.DefaultSortOrder
+
+	if sortStr := query.Get("sort"); sortStr != "" {
+		field := drawing.SortField(strings.ToLower(sortStr))
+		if !field.Valid() {
+			h.logger.Error("invalid sort parameter", "sort", sortStr)
+			response := ErrorResponse{
+				Error:   "invalid_request",
+				Message: "sort must be name, created_at, updated_at or size",
+			}
+			util.RespondJSON(w, http.StatusBadRequest, response)
+			return order, false
+		}
+		order = drawing.SortOrder{Field: field, Descending: field.DefaultDescending()}
+	}
+
+	switch orderStr := strings.ToLower(query.Get("order")); orderStr {
+	case "":
+	case "asc":
+		order.Descending = false
+	case "desc":
+		order.Descending = true
+	default:
+		h.logger.Error("invalid order parameter", "order", query.Get("order"))
+		response := ErrorResponse{
+			Error:   "invalid_request",
+			Message: "order must be asc or desc",
+		}
+		util.RespondJSON(w, http.StatusBadRequest, response)
+		return order, false
+	}
+
+	return order, true
+}
+
+// parseListFilter reads the filter query parameters of the drawing list, answering 400 if one is invalid
+// Dates are RFC 3339 timestamps or YYYY-MM-DD days in UTC; the after bounds are inclusive and the
+// before bounds exclusive, while the element count bounds are both inclusive
+func (h *DrawingHandler) parseListFilter(w http.ResponseWriter, r *http.Request) (drawing.DrawingFilter, bool) {
+	query := r.URL.Query()
+	var filter drawing.DrawingFilter
+
+	reject := func(param, message string) (drawing.DrawingFilter, bool) {
+		h.logger.Error("invalid "+param+" parameter", param, query.Get(param))
+		response := ErrorResponse{
+			Error:   "invalid_request",
+			Message: message,
+		}
+		util.RespondJSON(w, http.StatusBadRequest, response)
+		return filter, false
+	}
+
+	for _, p := range []struct {
+		param string
+		dst   *string
+	}{
+		{"name_prefix", &filter.NamePrefix},
+		{"name_contains", &filter.NameContains},
+	} {
+		value := query.Get(p.param)
+		if utf8.RuneCountInString(value) > maxNameFilterLength {
+			return reject(p.param, p.param+" must be at most 255 characters")
+		}
+		*p.dst = value
+	}
+
+	for _, p := range []struct {
+		param string
+		dst   **time.Time
+	}{
+		{"created_after", &filter.CreatedAfter},
+		{"created_before", &filter.CreatedBefore},
+		{"updated_after", &filter.UpdatedAfter},
+		{"updated_before", &filter.UpdatedBefore},
+	} {
+		value := query.Get(p.param)
+		if value == "" {
+			continue
+		}
+		t, err := parseFilterTime(value)
+		if err != nil {
+			return reject(p.param, p.param+" must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+		}
+		*p.dst = &t
+	}
+
+	for _, p := range []struct {
+		param string
+		dst   **int
+	}{
+		{"min_elements", &filter.MinElements},
+		{"max_elements", &filter.MaxElements},
+	} {
+		value := query.Get(p.param)
+		if value == "" {
+			continue
+		}
+		// Counts are compared with a 32-bit integer column, so larger values are rejected too
+		parsed, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || parsed < 0 {
+			return reject(p.param, p.param+" must be a non-negative integer of at most 2147483647")
+		}
+		n := int(parsed)
+		*p.dst = &n
+	}
+
+	// folder_id lists a folder, or with root the drawings outside any folder
+	switch folderStr := query.Get("folder_id"); folderStr {
+	case "":
+	case rootFolderParam:
+		filter.Unfiled = true
+	default:
+		folderID, err := uuid.Parse(folderStr)
+		if err != nil {
+			return reject("folder_id", "folder_id must be a folder ID or root")
+		}
+		filter.FolderID = &folderID
+	}
+
+	// Ranges that cannot hold a drawing are mistakes rather than empty lists
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil && !filter.CreatedAfter.Before(*filter.CreatedBefore) {
+		return reject("created_after", "created_after must be before created_before")
+	}
+	if filter.UpdatedAfter != nil && filter.UpdatedBefore != nil && !filter.UpdatedAfter.Before(*filter.UpdatedBefore) {
+		return reject("updated_after", "updated_after must be before updated_before")
+	}
+	if filter.MinElements != nil && filter.MaxElements != nil && *filter.MinElements > *filter.MaxElements {
+		return reject("min_elements", "min_elements must not exceed max_elements")
+	}
+
+	return filter, true
+}
+
+// parseFilterTime parses an RFC 3339 timestamp, or a YYYY-MM-DD date as the start of the day in UTC
+func parseFilterTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.UTC(), nil
+}
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	drawingapp "github.com/personal-excalidraw/backend/internal/application/drawing"
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+func TestListDrawingsSortAndFilter(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	date := func(s string) *time.Time {
+		t, _ := time.Parse(time.RFC3339, s)
+		return &t
+	}
+	count := func(n int) *int { return &n }
+	folderID := uuid.New()
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedError  string
+		expectedOrder  drawing.SortOrder
+		expectedFilter drawing.DrawingFilter
+	}{
+		{
+			name:           "defaults to the newest first",
+			expectedStatus: http.StatusOK,
+			expectedOrder:  drawing.DefaultSortOrder,
+		},
+		{
+			name:           "names sort A to Z by default",
+			query:          "?sort=name",
+			expectedStatus: http.StatusOK,
+			expectedOrder:  drawing.SortOrder{Field: drawing.SortByName},
+		},
+		{
+			name:           "explicit order",
+			query:          "?sort=SIZE&order=asc",
+			expectedStatus: http.StatusOK,
+			expectedOrder:  drawing.SortOrder{Field: drawing.SortBySize},
+		},
+		{
+			name: "filters",
+			query: "?name_prefix=Arch&name_contains=50%25_off&created_after=2026-01-01&created_before=2026-02-01T12:00:00%2B02:00" +
+				"&updated_after=2026-03-01T00:00:00Z&min_elements=0&max_elements=10",
+			expectedStatus: http.StatusOK,
+			expectedOrder:  drawing.DefaultSortOrder,
+			expectedFilter: drawing.DrawingFilter{
+				NamePrefix:    "Arch",
+				NameContains:  "50%_off",
+				CreatedAfter:  date("2026-01-01T00:00:00Z"),
+				CreatedBefore: date("2026-02-01T10:00:00Z"),
+				UpdatedAfter:  date("2026-03-01T00:00:00Z"),
+				MinElements:   count(0),
+				MaxElements:   count(10),
+			},
+		},
+		{
+			name:           "folder",
+			query:          "?folder_id=" + folderID.String(),
+			expectedStatus: http.StatusOK,
+			expectedOrder:  drawing.DefaultSortOrder,
+			expectedFilter: drawing.DrawingFilter{FolderID: &folderID},
+		},
+		{
+			name:           "outside any folder",
+			query:          "?folder_id=root",
+			expectedStatus: http.StatusOK,
+			expectedOrder:  drawing.DefaultSortOrder,
+			expectedFilter: drawing.DrawingFilter{Unfiled: true},
+		},
+		{
+			name:           "malformed folder",
+			query:          "?folder_id=inbox",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_request",
+		},
+		{
+			name:           "unknown sort",
+			query:          "?sort=owner",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_request",
+		},
+		{
+			name:           "unknown order",
+			query:          "?order=up",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_request",
+		},
+		{
+			name:           "malformed date",
+			query:          "?updated_before=yesterday",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_request",
+		},
+		{
+			name:           "empty date range",
+			query:          "?created_after=2026-02-01&created_before=2026-01-01",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_request",
+		},
+		{
+			name:           "negative element count",
+			query:          "?min_elements=-1",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_request",
+		},
+		{
+			name:           "element count out of range",
+			query:          "?max_elements=2147483648",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_request",
+		},
+		{
+			name:           "empty element range",
+			query:          "?min_elements=5&max_elements=2",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_request",
+		},
+		{
+			name: "cursor from another order",
+			query: "?sort=name&cursor=" + drawing.CursorAfter(&drawing.DrawingSummary{ID: uuid.New(), Name: "Drawing"},
+				drawing.SortOrder{Field: drawing.SortByName, Descending: true}).Encode(),
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_cursor",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				listed  drawing.SummaryQuery
+				counted drawing.DrawingFilter
+			)
+			repo := &mockDrawingRepository{
+				findSummariesFunc: func(ctx context.Context, query drawing.SummaryQuery, limit, offset int) ([]*drawing.DrawingSummary, error) {
+					listed = query
+					return []*drawing.DrawingSummary{}, nil
+				},
+				countFunc: func(ctx context.Context, filter drawing.DrawingFilter) (int64, error) {
+					counted = filter
+					return 0, nil
+				},
+			}
+			service := drawingapp.NewService(repo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+
+			req := httptest.NewRequest(http.MethodGet, "/drawings"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			NewDrawingHandler(service, logger).ListDrawings(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedError != "" {
+				var resp ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("failed to unmarshal response: %v", err)
+				}
+				if resp.Error != tt.expectedError {
+					t.Errorf("expected error %q, got %q", tt.expectedError, resp.Error)
+				}
+				return
+			}
+
+			if listed.Order != tt.expectedOrder {
+				t.Errorf("expected order %+v, got %+v", tt.expectedOrder, listed.Order)
+			}
+			if !equalFilters(listed.Filter, tt.expectedFilter) || !equalFilters(counted, tt.expectedFilter) {
+				t.Errorf("expected filter %s, listed with %s and counted with %s",
+					formatFilter(tt.expectedFilter), formatFilter(listed.Filter), formatFilter(counted))
+			}
+		})
+	}
+}
+
+// equalFilters reports whether two drawing filters have the same criteria
+func equalFilters(a, b drawing.DrawingFilter) bool {
+	return formatFilter(a) == formatFilter(b)
+}
+
+// formatFilter renders the criteria of a drawing filter for comparison and messages
+func formatFilter(f drawing.DrawingFilter) string {
+	data, _ := json.Marshal(f)
+	return string(data)
+}
@@ -7,7 +7,9 @@ import (
 	"strings"
 
 	"github.com/personal-excalidraw/backend/internal/adapter/http/util"
+	"github.com/personal-excalidraw/backend/internal/domain/diagram"
 	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+	"github.com/personal-excalidraw/backend/internal/domain/folder"
 )
 
 // ErrorResponse represents an error response
@@ -40,11 +42,30 @@ func respondError(w http.ResponseWriter, err error, logger *slog.Logger) {
 	response := ErrorResponse{
 		Error:   errorType,
 		Message: message,
+		Details: errorDetails(err),
 	}
 
 	util.RespondJSON(w, status, response)
 }
 
+// errorDetails lists the invalid fields of a malformed scene, keyed by their path in the request (e.g. "data.elements[0].id")
+func errorDetails(err error) map[string]string {
+	var sceneErr *drawing.SceneError
+	if !errors.As(err, &sceneErr) {
+		return nil
+	}
+
+	details := make(map[string]string, len(sceneErr.Fields))
+	for _, f := range sceneErr.Fields {
+		field := "data"
+		if f.Field != "" {
+			field += "." + f.Field
+		}
+		details[field] = f.Message
+	}
+	return details
+}
+
 // respondValidationError sends a validation error response
 func respondValidationError(w http.ResponseWriter, validationErrors []ValidationError) {
 	details := make(map[string]string)
@@ -84,9 +105,65 @@ func mapErrorToHTTP(err error) (status int, errorType, message string) {
 		return http.StatusBadRequest, "empty_name", "Drawing name cannot be empty"
 	case errors.Is(err, drawing.ErrNameTooLong):
 		return http.StatusBadRequest, "name_too_long", "Drawing name exceeds maximum length"
+	case errors.Is(err, drawing.ErrInvalidCursor):
+		return http.StatusBadRequest, "invalid_cursor", "Invalid pagination cursor"
+	case errors.Is(err, drawing.ErrVersionConflict):
+		return http.StatusPreconditionFailed, "version_conflict", "Drawing was modified by another client"
+	case errors.Is(err, drawing.ErrConcurrentUpdate):
+		return http.StatusConflict, "concurrent_update", "Drawing was saved by another client at the same time"
+	case errors.Is(err, drawing.ErrInvalidPatch):
+		return http.StatusBadRequest, "invalid_patch", unwrapDomainMessage(err, drawing.ErrInvalidPatch)
+	case errors.Is(err, drawing.ErrPatchConflict):
+		return http.StatusConflict, "patch_conflict", unwrapDomainMessage(err, drawing.ErrPatchConflict)
+	case errors.Is(err, drawing.ErrInvalidExcalidrawFile):
+		return http.StatusBadRequest, "invalid_file", unwrapDomainMessage(err, drawing.ErrInvalidExcalidrawFile)
+	case errors.Is(err, diagram.ErrInvalidDiagram):
+		return http.StatusBadRequest, "invalid_diagram", unwrapDomainMessage(err, diagram.ErrInvalidDiagram)
+	case errors.Is(err, diagram.ErrUnsupportedDiagram):
+		return http.StatusBadRequest, "unsupported_diagram", unwrapDomainMessage(err, diagram.ErrUnsupportedDiagram)
+	case errors.Is(err, diagram.ErrDiagramTooLarge):
+		return http.StatusRequestEntityTooLarge, "diagram_too_large", unwrapDomainMessage(err, diagram.ErrDiagramTooLarge)
+	case errors.Is(err, drawing.ErrInvalidExportOptions):
+		return http.StatusBadRequest, "invalid_export_options", unwrapDomainMessage(err, drawing.ErrInvalidExportOptions)
+	case errors.Is(err, drawing.ErrRevisionNotFound):
+		return http.StatusNotFound, "not_found", "Drawing revision not found"
+	case errors.Is(err, drawing.ErrInvalidRevisionNumber):
+		return http.StatusBadRequest, "invalid_request", "Invalid revision number"
+	case errors.Is(err, drawing.ErrSlugConflict):
+		return http.StatusConflict, "slug_conflict", "Drawing slug is already in use"
+	case errors.Is(err, drawing.ErrReservedSlug):
+		return http.StatusBadRequest, "reserved_slug", "Drawing slug is reserved"
+	case errors.Is(err, drawing.ErrInvalidSlug):
+		return http.StatusBadRequest, "invalid_slug", unwrapDomainMessage(err, drawing.ErrInvalidSlug)
+	case errors.Is(err, folder.ErrFolderNotFound):
+		return http.StatusNotFound, "not_found", "Folder not found"
+	case errors.Is(err, folder.ErrEmptyName):
+		return http.StatusBadRequest, "empty_name", "Folder name cannot be empty"
+	case errors.Is(err, folder.ErrNameTooLong):
+		return http.StatusBadRequest, "name_too_long", "Folder name exceeds maximum length"
+	case errors.Is(err, folder.ErrNameConflict):
+		return http.StatusConflict, "folder_name_conflict", "A folder with this name already exists in the parent folder"
+	case errors.Is(err, folder.ErrFolderCycle):
+		return http.StatusConflict, "folder_cycle", "A folder cannot be moved into itself or one of its subfolders"
+	case errors.Is(err, folder.ErrFolderNotEmpty):
+		return http.StatusConflict, "folder_not_empty", "Folder is not empty"
+	case errors.Is(err, folder.ErrInvalidDeleteMode):
+		return http.StatusBadRequest, "invalid_request", "mode must be restrict, reparent or cascade"
 	case err != nil && strings.Contains(err.Error(), "invalid drawing ID"):
 		return http.StatusBadRequest, "invalid_request", err.Error()
+	case err != nil && strings.Contains(err.Error(), "invalid folder ID"):
+		return http.StatusBadRequest, "invalid_request", err.Error()
 	default:
 		return http.StatusInternalServerError, "internal_error", "Internal server error"
 	}
 }
+
+// unwrapDomainMessage returns the detail a domain error was wrapped with (e.g. "invalid drawing slug: must be ...")
+// so clients see why validation failed, falling back to the sentinel message
+func unwrapDomainMessage(err, sentinel error) string {
+	msg := err.Error()
+	if i := strings.Index(msg, sentinel.Error()); i >= 0 {
+		msg = msg[i:]
+	}
+	return msg
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/personal-excalidraw/backend/internal/adapter/http/util"
+	drawingapp "github.com/personal-excalidraw/backend/internal/application/drawing"
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// RevisionSummaryResponse represents a revision in a list, without its drawing data
+type RevisionSummaryResponse struct {
+	Revision     int    `json:"revision"`
+	Name         string `json:"name"`
+	RestoredFrom int    `json:"restored_from,omitempty"`
+	CreatedAt    string `json:"created_at"`
+}
+
+// RevisionResponse represents the HTTP response for a single revision
+type RevisionResponse struct {
+	Revision     int                    `json:"revision"`
+	DrawingID    string                 `json:"drawing_id"`
+	Name         string                 `json:"name"`
+	Data         map[string]interface{} `json:"data"`
+	RestoredFrom int                    `json:"restored_from,omitempty"`
+	CreatedAt    string                 `json:"created_at"`
+}
+
+// RevisionListResponse represents a paginated list of revisions
+type RevisionListResponse struct {
+	Revisions []*RevisionSummaryResponse `json:"revisions"`
+	Total     int64                      `json:"total"`
+	Limit     int                        `json:"limit"`
+	Offset    int                        `json:"offset"`
+}
+
+// ListRevisions handles GET /api/drawings/{id}/revisions
+func (h *DrawingHandler) ListRevisions(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("handling list revisions request")
+
+	// Extract ID from path
+	id := r.PathValue("id")
+	if id == "" {
+		h.logger.Error("missing drawing ID in path")
+		response := ErrorResponse{
+			Error:   "invalid_request",
+			Message: "missing drawing ID",
+		}
+		util.RespondJSON(w, http.StatusBadRequest, response)
+		return
+	}
+
+	// Parse query parameters
+	limit, offset := parsePagination(r)
+
+	// Call service
+	input := drawingapp.ListRevisionsInput{
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	output, err := h.service.ListRevisions(r.Context(), id, input)
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	// Convert to HTTP response
+	response := RevisionListResponse{
+		Revisions: make([]*RevisionSummaryResponse, len(output.Revisions)),
+		Total:     output.Total,
+		Limit:     output.Limit,
+		Offset:    output.Offset,
+	}
+
+	for i, rev := range output.Revisions {
+		response.Revisions[i] = &RevisionSummaryResponse{
+			Revision:     rev.Number,
+			Name:         rev.Name,
+			RestoredFrom: rev.RestoredFrom,
+			CreatedAt:    rev.CreatedAt.Format(time.RFC3339),
+		}
+	}
+
+	util.RespondJSON(w, http.StatusOK, response)
+}
+
+// GetRevision handles GET /api/drawings/{id}/revisions/{rev}
+func (h *DrawingHandler) GetRevision(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("handling get revision request")
+
+	// Extract ID and revision number from path
+	id := r.PathValue("id")
+	if id == "" {
+		h.logger.Error("missing drawing ID in path")
+		response := ErrorResponse{
+			Error:   "invalid_request",
+			Message: "missing drawing ID",
+		}
+		util.RespondJSON(w, http.StatusBadRequest, response)
+		return
+	}
+
+	number, err := parseRevisionNumber(r.PathValue("rev"))
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	// Call service
+	output, err := h.service.GetRevision(r.Context(), id, number)
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	// Convert to HTTP response
+	response := RevisionResponse{
+		Revision:     output.Number,
+		DrawingID:    output.DrawingID.String(),
+		Name:         output.Name,
+		Data:         output.Data,
+		RestoredFrom: output.RestoredFrom,
+		CreatedAt:    output.CreatedAt.Format(time.RFC3339),
+	}
+
+	util.RespondJSON(w, http.StatusOK, response)
+}
+
+// RestoreRevision handles POST /api/drawings/{id}/revisions/{rev}/restore
+func (h *DrawingHandler) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("handling restore revision request")
+
+	// Extract ID and revision number from path
+	id := r.PathValue("id")
+	if id == "" {
+		h.logger.Error("missing drawing ID in path")
+		response := ErrorResponse{
+			Error:   "invalid_request",
+			Message: "missing drawing ID",
+		}
+		util.RespondJSON(w, http.StatusBadRequest, response)
+		return
+	}
+
+	number, err := parseRevisionNumber(r.PathValue("rev"))
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	// Read the version the client expects to overwrite
+	expectedVersions, err := parseIfMatch(r)
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	// Call service
+	output, err := h.service.RestoreRevision(r.Context(), id, number, expectedVersions)
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	// Convert to HTTP response
+	respondDrawing(w, r, http.StatusOK, output)
+}
+
+// parseRevisionNumber parses a revision number path segment
+func parseRevisionNumber(s string) (int, error) {
+	number, err := strconv.Atoi(s)
+	if err != nil || number < 1 {
+		return 0, drawing.ErrInvalidRevisionNumber
+	}
+	return number, nil
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"html"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/personal-excalidraw/backend/internal/adapter/http/util"
+	drawingapp "github.com/personal-excalidraw/backend/internal/application/drawing"
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// SearchResultResponse represents a drawing matching a search in HTTP responses
+type SearchResultResponse struct {
+	ID      string  `json:"id"`
+	Slug    string  `json:"slug"`
+	Name    string  `json:"name"`
+	Version int64   `json:"version"`
+	Rank    float64 `json:"rank"`
+
+	// Snippet is HTML-escaped text with the matches wrapped in <mark> elements
+	Snippet    string   `json:"snippet"`
+	ElementIDs []string `json:"element_ids"`
+	UpdatedAt  string   `json:"updated_at"`
+}
+
+// SearchResponse represents a paginated list of search results in HTTP responses
+type SearchResponse struct {
+	Results []*SearchResultResponse `json:"results"`
+	Total   int64                   `json:"total"`
+	Limit   int                     `json:"limit"`
+	Offset  int                     `json:"offset"`
+}
+
+// SearchDrawings handles GET /api/drawings/search
+// q searches the names of drawings and the text of their text elements and frames, in web search
+// syntax; results are ranked best first and paginated by limit and offset
+func (h *DrawingHandler) SearchDrawings(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("handling search drawings request")
+
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" || utf8.RuneCountInString(q) > drawing.MaxSearchQueryLength {
+		h.logger.Error("invalid q parameter", "q", q)
+		response := ErrorResponse{
+			Error:   "invalid_request",
+			Message: "q must be between 1 and 200 characters",
+		}
+		util.RespondJSON(w, http.StatusBadRequest, response)
+		return
+	}
+
+	limit, offset := parsePagination(r)
+
+	// Call service
+	output, err := h.service.SearchDrawings(r.Context(), drawingapp.SearchDrawingsInput{
+		Query:  q,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		respondError(w, err, h.logger)
+		return
+	}
+
+	// Convert to HTTP response
+	response := SearchResponse{
+		Results: make([]*SearchResultResponse, len(output.Results)),
+		Total:   output.Total,
+		Limit:   output.Limit,
+		Offset:  output.Offset,
+	}
+	for i, res := range output.Results {
+		response.Results[i] = toSearchResultResponse(res)
+	}
+
+	util.RespondJSON(w, http.StatusOK, response)
+}
+
+// toSearchResultResponse converts a SearchResultOutput to a SearchResultResponse
+func toSearchResultResponse(output *drawingapp.SearchResultOutput) *SearchResultResponse {
+	elementIDs := output.ElementIDs
+	if elementIDs == nil {
+		elementIDs = []string{}
+	}
+	return &SearchResultResponse{
+		ID:         output.ID.String(),
+		Slug:       output.Slug,
+		Name:       output.Name,
+		Version:    output.Version,
+		Rank:       output.Rank,
+		Snippet:    snippetHTML(output.Snippet),
+		ElementIDs: elementIDs,
+		UpdatedAt:  output.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// snippetHTML renders a snippet as HTML, escaping the scene text so it can be inserted as is
+func snippetHTML(fragments []drawing.SnippetFragment) string {
+	var b strings.Builder
+	for _, f := range fragments {
+		if f.Match {
+			b.WriteString("<mark>" + html.EscapeString(f.Text) + "</mark>")
+		} else {
+			b.WriteString(html.EscapeString(f.Text))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	drawingapp "github.com/personal-excalidraw/backend/internal/application/drawing"
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+func TestSearchDrawings(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	found := &drawing.SearchResult{
+		ID:      uuid.New(),
+		Slug:    "Xk9pQ2mR",
+		Name:    "Event pipeline",
+		Version: 3,
+		Rank:    0.6,
+		Snippet: []drawing.SnippetFragment{
+			{Text: "Event pipeline\n<producer> → "},
+			{Text: "Kafka", Match: true},
+			{Text: " topic"},
+		},
+		ElementIDs: []string{"label-1", "frame-2"},
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		searchErr      error
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "ranked results",
+			query:          "?q=%20kafka%20&limit=5",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing query",
+			query:          "?q=%20",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_request",
+		},
+		{
+			name:           "query too long",
+			query:          "?q=" + strings.Repeat("a", drawing.MaxSearchQueryLength+1),
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_request",
+		},
+		{
+			name:           "repository error",
+			query:          "?q=kafka",
+			searchErr:      errors.New("database connection failed"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedError:  "internal_error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockDrawingRepository{
+				searchFunc: func(ctx context.Context, query string, limit, offset int) ([]*drawing.SearchResult, error) {
+					if tt.searchErr != nil {
+						return nil, tt.searchErr
+					}
+					if query != "kafka" || limit != 5 {
+						t.Errorf("expected the trimmed query and the page size, got %q %d", query, limit)
+					}
+					return []*drawing.SearchResult{found}, nil
+				},
+				countSearchFunc: func(ctx context.Context, query string) (int64, error) {
+					return 1, nil
+				},
+			}
+			service := drawingapp.NewService(repo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+
+			req := httptest.NewRequest(http.MethodGet, "/drawings/search"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			NewDrawingHandler(service, logger).SearchDrawings(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedError != "" {
+				var resp ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("failed to unmarshal response: %v", err)
+				}
+				if resp.Error != tt.expectedError {
+					t.Errorf("expected error %q, got %q", tt.expectedError, resp.Error)
+				}
+				return
+			}
+
+			var resp SearchResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if resp.Total != 1 || len(resp.Results) != 1 {
+				t.Fatalf("expected one result, got %d of %d", len(resp.Results), resp.Total)
+			}
+			res := resp.Results[0]
+			if res.ID != found.ID.String() || res.Rank != 0.6 || len(res.ElementIDs) != 2 || res.ElementIDs[1] != "frame-2" {
+				t.Errorf("expected the result with its rank and matching elements, got %+v", res)
+			}
+			if want := "Event pipeline\n&lt;producer&gt; → <mark>Kafka</mark> topic"; res.Snippet != want {
+				t.Errorf("expected snippet %q, got %q", want, res.Snippet)
+			}
+		})
+	}
+}
@@ -29,6 +29,7 @@ func CORS(cfg *config.Config) func(http.Handler) http.Handler {
 			// Set CORS headers
 			w.Header().Set("Access-Control-Allow-Methods", strings.Join(cfg.CORS.AllowedMethods, ", "))
 			w.Header().Set("Access-Control-Allow-Headers", strings.Join(cfg.CORS.AllowedHeaders, ", "))
+			w.Header().Set("Access-Control-Expose-Headers", strings.Join(cfg.CORS.ExposedHeaders, ", "))
 			w.Header().Set("Access-Control-Max-Age", "3600")
 
 			// Handle preflight OPTIONS requests
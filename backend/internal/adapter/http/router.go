@@ -14,6 +14,8 @@ func NewRouter(
 	cfg *config.Config,
 	healthHandler *handler.HealthHandler,
 	drawingHandler *handler.DrawingHandler,
+	exportHandler *handler.ExportHandler,
+	folderHandler *handler.FolderHandler,
 	authHandler *handler.AuthHandler,
 	logger *slog.Logger,
 ) http.Handler {
@@ -28,10 +30,38 @@ func NewRouter(
 
 	// Drawing API endpoints (nginx strips /api prefix)
 	mux.HandleFunc("POST /drawings", drawingHandler.CreateDrawing)
+	mux.HandleFunc("POST /drawings/import", drawingHandler.ImportDrawings)
+	mux.HandleFunc("POST /drawings/import/mermaid", drawingHandler.ImportMermaid)
+	mux.HandleFunc("POST /drawings/generate", drawingHandler.GenerateDrawing)
 	mux.HandleFunc("GET /drawings/{id}", drawingHandler.GetDrawing)
+	mux.HandleFunc("GET /drawings/by-slug/{slug}", drawingHandler.GetDrawingBySlug)
 	mux.HandleFunc("GET /drawings", drawingHandler.ListDrawings)
+	mux.HandleFunc("GET /drawings/search", drawingHandler.SearchDrawings)
 	mux.HandleFunc("PUT /drawings/{id}", drawingHandler.UpdateDrawing)
+	mux.HandleFunc("PATCH /drawings/{id}", drawingHandler.PatchDrawing)
 	mux.HandleFunc("DELETE /drawings/{id}", drawingHandler.DeleteDrawing)
+	mux.HandleFunc("POST /drawings/{id}/layout", drawingHandler.LayoutDrawing)
+
+	// Drawing revision history endpoints
+	mux.HandleFunc("GET /drawings/{id}/revisions", drawingHandler.ListRevisions)
+	mux.HandleFunc("GET /drawings/{id}/revisions/{rev}", drawingHandler.GetRevision)
+	mux.HandleFunc("POST /drawings/{id}/revisions/{rev}/restore", drawingHandler.RestoreRevision)
+
+	// Folder endpoints
+	mux.HandleFunc("POST /folders", folderHandler.CreateFolder)
+	mux.HandleFunc("GET /folders", folderHandler.ListFolders)
+	mux.HandleFunc("GET /folders/{id}", folderHandler.GetFolder)
+	mux.HandleFunc("PUT /folders/{id}", folderHandler.RenameFolder)
+	mux.HandleFunc("DELETE /folders/{id}", folderHandler.DeleteFolder)
+	mux.HandleFunc("POST /folders/{id}/move", folderHandler.MoveFolder)
+	mux.HandleFunc("POST /drawings/{id}/move", folderHandler.MoveDrawing)
+
+	// Drawing export endpoints
+	mux.HandleFunc("GET /drawings/{id}/export.svg", exportHandler.ExportSVG)
+	mux.HandleFunc("GET /drawings/{id}/export.png", exportHandler.ExportPNG)
+	mux.HandleFunc("GET /drawings/{id}/export.pdf", exportHandler.ExportPDF)
+	mux.HandleFunc("GET /drawings/{id}/export.excalidraw", exportHandler.ExportExcalidraw)
+	mux.HandleFunc("GET /drawings/{id}/thumbnail.png", exportHandler.GetThumbnail)
 
 	// Apply middleware stack (in reverse order - outermost first)
 	var handler http.Handler = mux
@@ -0,0 +1,154 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Color is a non-premultiplied RGBA color
+type Color struct {
+	R, G, B, A uint8
+}
+
+// Transparent is the color of nothing
+var Transparent = Color{}
+
+// Black is the default stroke color
+var Black = Color{0, 0, 0, 255}
+
+// White is the default background color
+var White = Color{255, 255, 255, 255}
+
+// Visible reports whether anything is drawn with the color
+func (c Color) Visible() bool {
+	return c.A > 0
+}
+
+// Hex returns the color as #rrggbb, ignoring alpha
+func (c Color) Hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// Alpha returns the opacity of the color between 0 and 1
+func (c Color) Alpha() float64 {
+	return float64(c.A) / 255
+}
+
+// namedColors are the CSS color keywords Excalidraw scenes are likely to contain
+var namedColors = map[string]Color{
+	"transparent": Transparent,
+	"black":       Black,
+	"white":       White,
+	"red":         {255, 0, 0, 255},
+	"green":       {0, 128, 0, 255},
+	"blue":        {0, 0, 255, 255},
+	"yellow":      {255, 255, 0, 255},
+	"orange":      {255, 165, 0, 255},
+	"purple":      {128, 0, 128, 255},
+	"pink":        {255, 192, 203, 255},
+	"gray":        {128, 128, 128, 255},
+	"grey":        {128, 128, 128, 255},
+	"brown":       {165, 42, 42, 255},
+	"cyan":        {0, 255, 255, 255},
+	"magenta":     {255, 0, 255, 255},
+	"lime":        {0, 255, 0, 255},
+	"navy":        {0, 0, 128, 255},
+	"teal":        {0, 128, 128, 255},
+	"silver":      {192, 192, 192, 255},
+	"maroon":      {128, 0, 0, 255},
+	"olive":       {128, 128, 0, 255},
+}
+
+// ParseColor parses a CSS color in hex (#rgb, #rgba, #rrggbb, #rrggbbaa), rgb()/rgba() or keyword form
+func ParseColor(s string) (Color, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if c, ok := namedColors[s]; ok {
+		return c, true
+	}
+
+	if hex, ok := strings.CutPrefix(s, "#"); ok {
+		return parseHexColor(hex)
+	}
+
+	for _, prefix := range []string{"rgba(", "rgb("} {
+		if args, ok := strings.CutPrefix(s, prefix); ok {
+			return parseRGBColor(strings.TrimSuffix(args, ")"))
+		}
+	}
+
+	return Color{}, false
+}
+
+// parseHexColor parses the digits of a hex color
+func parseHexColor(hex string) (Color, bool) {
+	if len(hex) == 3 || len(hex) == 4 {
+		expanded := make([]byte, 0, 2*len(hex))
+		for i := 0; i < len(hex); i++ {
+			expanded = append(expanded, hex[i], hex[i])
+		}
+		hex = string(expanded)
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return Color{}, false
+	}
+
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return Color{}, false
+	}
+	return Color{uint8(v >> 24), uint8(v >> 16), uint8(v >> 8), uint8(v)}, true
+}
+
+// parseRGBColor parses the comma-separated arguments of rgb() or rgba()
+func parseRGBColor(args string) (Color, bool) {
+	parts := strings.Split(args, ",")
+	if len(parts) != 3 && len(parts) != 4 {
+		return Color{}, false
+	}
+
+	var values [4]float64
+	values[3] = 1
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return Color{}, false
+		}
+		values[i] = v
+	}
+
+	return Color{
+		R: clampByte(values[0]),
+		G: clampByte(values[1]),
+		B: clampByte(values[2]),
+		A: clampByte(values[3] * 255),
+	}, true
+}
+
+// clampByte rounds v to the nearest byte value
+func clampByte(v float64) uint8 {
+	return uint8(math.Round(math.Max(0, math.Min(255, v))))
+}
+
+// darkModeInvert is how much colors are inverted in dark mode, as Excalidraw's invert(93%) filter
+const darkModeInvert = 0.93
+
+// Dark returns the color as shown in Excalidraw's dark theme: inverted by 93% and rotated 180° in hue
+func (c Color) Dark() Color {
+	invert := func(v uint8) float64 {
+		return darkModeInvert + float64(v)/255*(1-2*darkModeInvert)
+	}
+	r, g, b := invert(c.R), invert(c.G), invert(c.B)
+
+	// hue-rotate(180deg) color matrix from the CSS filter effects specification
+	return Color{
+		R: clampByte(255 * (-0.574*r + 1.430*g + 0.144*b)),
+		G: clampByte(255 * (0.426*r + 0.430*g + 0.144*b)),
+		B: clampByte(255 * (0.426*r + 1.430*g - 0.856*b)),
+		A: c.A,
+	}
+}
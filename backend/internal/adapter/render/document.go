@@ -0,0 +1,227 @@
+package render
+
+import "math"
+
+// Point is a position in document coordinates
+type Point struct {
+	X, Y float64
+}
+
+// Add returns p translated by q
+func (p Point) Add(q Point) Point {
+	return Point{p.X + q.X, p.Y + q.Y}
+}
+
+// Sub returns the vector from q to p
+func (p Point) Sub(q Point) Point {
+	return Point{p.X - q.X, p.Y - q.Y}
+}
+
+// Mul returns p scaled by f
+func (p Point) Mul(f float64) Point {
+	return Point{p.X * f, p.Y * f}
+}
+
+// Len returns the length of p as a vector
+func (p Point) Len() float64 {
+	return math.Hypot(p.X, p.Y)
+}
+
+// Rect is an axis-aligned rectangle
+type Rect struct {
+	Min, Max Point
+}
+
+// Empty reports whether the rectangle contains no points
+func (r Rect) Empty() bool {
+	return r.Min.X > r.Max.X || r.Min.Y > r.Max.Y
+}
+
+// Union returns the smallest rectangle containing r and s
+func (r Rect) Union(s Rect) Rect {
+	if r.Empty() {
+		return s
+	}
+	if s.Empty() {
+		return r
+	}
+	return Rect{
+		Min: Point{math.Min(r.Min.X, s.Min.X), math.Min(r.Min.Y, s.Min.Y)},
+		Max: Point{math.Max(r.Max.X, s.Max.X), math.Max(r.Max.Y, s.Max.Y)},
+	}
+}
+
+// emptyRect is the identity of Rect.Union
+var emptyRect = Rect{Min: Point{math.Inf(1), math.Inf(1)}, Max: Point{math.Inf(-1), math.Inf(-1)}}
+
+// Matrix is an affine transform [a b c d e f], mapping (x, y) to (ax+cy+e, bx+dy+f) as in SVG
+type Matrix [6]float64
+
+// Identity is the transform that leaves points unchanged
+var Identity = Matrix{1, 0, 0, 1, 0, 0}
+
+// Translate returns a translation by (x, y)
+func Translate(x, y float64) Matrix {
+	return Matrix{1, 0, 0, 1, x, y}
+}
+
+// Scale returns a scaling by (sx, sy)
+func Scale(sx, sy float64) Matrix {
+	return Matrix{sx, 0, 0, sy, 0, 0}
+}
+
+// RotateAround returns a clockwise rotation by angle radians around c
+func RotateAround(angle float64, c Point) Matrix {
+	sin, cos := math.Sincos(angle)
+	return Matrix{cos, sin, -sin, cos, c.X - cos*c.X + sin*c.Y, c.Y - sin*c.X - cos*c.Y}
+}
+
+// Mul returns the transform applying n first, then m
+func (m Matrix) Mul(n Matrix) Matrix {
+	return Matrix{
+		m[0]*n[0] + m[2]*n[1],
+		m[1]*n[0] + m[3]*n[1],
+		m[0]*n[2] + m[2]*n[3],
+		m[1]*n[2] + m[3]*n[3],
+		m[0]*n[4] + m[2]*n[5] + m[4],
+		m[1]*n[4] + m[3]*n[5] + m[5],
+	}
+}
+
+// Apply transforms p
+func (m Matrix) Apply(p Point) Point {
+	return Point{m[0]*p.X + m[2]*p.Y + m[4], m[1]*p.X + m[3]*p.Y + m[5]}
+}
+
+// Invert returns the inverse transform, and false when m is not invertible
+func (m Matrix) Invert() (Matrix, bool) {
+	det := m[0]*m[3] - m[1]*m[2]
+	if det == 0 {
+		return Matrix{}, false
+	}
+	return Matrix{
+		m[3] / det,
+		-m[1] / det,
+		-m[2] / det,
+		m[0] / det,
+		(m[2]*m[5] - m[3]*m[4]) / det,
+		(m[1]*m[4] - m[0]*m[5]) / det,
+	}, true
+}
+
+// Document is a scene laid out as drawing primitives, ready to be written in an image format
+// Coordinates are in pixels from the top left corner of the image
+type Document struct {
+	Width  float64
+	Height float64
+
+	// Background fills the whole image; a transparent color leaves it empty
+	Background Color
+
+	Items []Item
+
+	// Fonts lists the font families used by text items, in order of first use
+	Fonts []Font
+
+	// Metadata is written into SVG images as is, such as an embedded scene
+	Metadata string
+}
+
+// Item is a drawing primitive of a document: a *Shape, *Text or *Image
+type Item interface {
+	// Bounds returns the area covered by the item in document coordinates
+	Bounds() Rect
+}
+
+// Shape is a path filled and/or stroked with a solid color
+type Shape struct {
+	Path      Path
+	Transform Matrix
+	Opacity   float64
+
+	// Fill is the fill color; transparent for none
+	Fill Color
+
+	// Stroke is the stroke color; transparent for none
+	Stroke      Color
+	StrokeWidth float64
+
+	// Dash alternates the lengths of dashes and gaps along the stroke; nil for a solid line
+	Dash []float64
+}
+
+// Bounds returns the transformed bounds of the path
+func (s *Shape) Bounds() Rect {
+	return s.Path.Bounds(s.Transform)
+}
+
+// TextAlign is the horizontal alignment of text lines
+type TextAlign int
+
+const (
+	AlignLeft TextAlign = iota
+	AlignCenter
+	AlignRight
+)
+
+// Text is a block of lines set in one font, positioned by its top left corner
+type Text struct {
+	Lines      []string
+	Font       Font
+	FontSize   float64
+	LineHeight float64
+	Align      TextAlign
+	Color      Color
+	Width      float64
+	Height     float64
+	Transform  Matrix
+	Opacity    float64
+}
+
+// Bounds returns the transformed box of the text block
+func (t *Text) Bounds() Rect {
+	return boxBounds(t.Width, t.Height, t.Transform)
+}
+
+// Baseline returns the offset of the baseline of line i from the top of the block
+func (t *Text) Baseline(i int) float64 {
+	// Center the glyph box (ascent + descent) in the line box
+	return float64(i)*t.LineHeight + t.LineHeight/2 + t.FontSize*(t.Font.Ascent-t.Font.Descent)/2
+}
+
+// Anchor returns the horizontal position lines are aligned to
+func (t *Text) Anchor() float64 {
+	switch t.Align {
+	case AlignCenter:
+		return t.Width / 2
+	case AlignRight:
+		return t.Width
+	default:
+		return 0
+	}
+}
+
+// Image is an embedded bitmap or vector image stretched over a box
+type Image struct {
+	MimeType  string
+	DataURL   string
+	Width     float64
+	Height    float64
+	Transform Matrix
+	Opacity   float64
+}
+
+// Bounds returns the transformed box of the image
+func (i *Image) Bounds() Rect {
+	return boxBounds(i.Width, i.Height, i.Transform)
+}
+
+// boxBounds returns the bounds of the box (0, 0)-(w, h) under m
+func boxBounds(w, h float64, m Matrix) Rect {
+	r := emptyRect
+	for _, p := range []Point{{0, 0}, {w, 0}, {w, h}, {0, h}} {
+		q := m.Apply(p)
+		r = r.Union(Rect{Min: q, Max: q})
+	}
+	return r
+}
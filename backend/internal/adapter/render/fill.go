@@ -0,0 +1,87 @@
+package render
+
+import (
+	"math"
+	"sort"
+)
+
+// Fill styles of Excalidraw elements
+const (
+	FillSolid      = "solid"
+	FillHachure    = "hachure"
+	FillCrossHatch = "cross-hatch"
+	FillZigzag     = "zigzag"
+)
+
+// maxHachureLines bounds the lines of one hachure pass; the gap widens for polygons that would need more
+const maxHachureLines = 1000
+
+// hachureLines returns parallel lines gap apart covering the polygon, running at angle degrees
+// from the x axis (negative angles rise to the right, as the y axis points down)
+func hachureLines(polygon []Point, angle, gap float64) [][2]Point {
+	if len(polygon) < 3 || gap <= 0 {
+		return nil
+	}
+
+	// Rotate the polygon so the lines are horizontal, scan it, then rotate the lines back
+	rotate := RotateAround(-angle*math.Pi/180, Point{})
+	back := RotateAround(angle*math.Pi/180, Point{})
+
+	pts := make([]Point, len(polygon))
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for i, p := range polygon {
+		pts[i] = rotate.Apply(p)
+		minY, maxY = math.Min(minY, pts[i].Y), math.Max(maxY, pts[i].Y)
+	}
+	gap = math.Max(gap, (maxY-minY)/maxHachureLines)
+
+	var lines [][2]Point
+	for y := minY + gap/2; y < maxY; y += gap {
+		// Pair up the crossings of the scanline with the polygon's edges (even-odd rule)
+		var xs []float64
+		for i, p := range pts {
+			q := pts[(i+1)%len(pts)]
+			if (p.Y <= y) == (q.Y <= y) {
+				continue
+			}
+			xs = append(xs, p.X+(y-p.Y)*(q.X-p.X)/(q.Y-p.Y))
+		}
+		sort.Float64s(xs)
+		for i := 0; i+1 < len(xs); i += 2 {
+			lines = append(lines, [2]Point{back.Apply(Point{xs[i], y}), back.Apply(Point{xs[i+1], y})})
+		}
+	}
+	return lines
+}
+
+// patternFill returns the lines of a hachure, cross-hatch or zigzag fill of the polygon
+func (s *sketcher) patternFill(polygon []Point, style string, gap float64) Path {
+	gap = math.Max(math.Round(gap), 1)
+
+	// rough.js scans the polygon turned by the hachure angle plus 90 degrees
+	angle := -(roughHachureAngle + 90.0)
+
+	var lines [][2]Point
+	switch style {
+	case FillCrossHatch:
+		lines = append(hachureLines(polygon, angle, gap), hachureLines(polygon, angle-90, gap)...)
+	case FillZigzag:
+		// Each hachure line becomes a narrow V, so neighbouring lines join into a zigzag
+		a := roughHachureAngle * math.Pi / 180
+		d := Point{gap * 0.5 * math.Cos(a), -gap * 0.5 * math.Sin(a)}
+		for _, l := range hachureLines(polygon, angle, gap) {
+			if l[0] == l[1] {
+				continue
+			}
+			lines = append(lines, [2]Point{l[0].Sub(d), l[1]}, [2]Point{l[0].Add(d), l[1]})
+		}
+	default:
+		lines = hachureLines(polygon, angle, gap)
+	}
+
+	var path Path
+	for _, l := range lines {
+		s.doubleLine(&path, l[0], l[1])
+	}
+	return path
+}
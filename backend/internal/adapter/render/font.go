@@ -0,0 +1,33 @@
+package render
+
+// Font is a font family text can be set in
+// Ascent and Descent are relative to the font size and position the baseline within a line
+type Font struct {
+	Family  string
+	URL     string
+	Generic string
+	Ascent  float64
+	Descent float64
+}
+
+// Excalidraw font families, keyed by the fontFamily number stored on text elements
+var fonts = map[int]Font{
+	1: {Family: "Virgil", URL: "https://excalidraw.com/Virgil.woff2", Generic: "cursive", Ascent: 0.886, Descent: 0.374},
+	2: {Family: "Helvetica", Generic: "sans-serif", Ascent: 0.77, Descent: 0.23},
+	3: {Family: "Cascadia", URL: "https://excalidraw.com/Cascadia.woff2", Generic: "monospace", Ascent: 0.952, Descent: 0.248},
+	5: {Family: "Excalifont", URL: "https://excalidraw.com/Excalifont-Regular.woff2", Generic: "cursive", Ascent: 0.886, Descent: 0.374},
+	6: {Family: "Nunito", URL: "https://excalidraw.com/Nunito-Regular.woff2", Generic: "sans-serif", Ascent: 1.011, Descent: 0.353},
+	7: {Family: "Lilita One", URL: "https://excalidraw.com/LilitaOne-Regular.woff2", Generic: "sans-serif", Ascent: 0.923, Descent: 0.220},
+	8: {Family: "Comic Shanns", URL: "https://excalidraw.com/ComicShanns-Regular.woff2", Generic: "cursive", Ascent: 0.750, Descent: 0.250},
+}
+
+// defaultFontFamily is the font of text elements without a known fontFamily
+const defaultFontFamily = 1
+
+// fontFor returns the font of a fontFamily number
+func fontFor(family int) Font {
+	if f, ok := fonts[family]; ok {
+		return f
+	}
+	return fonts[defaultFontFamily]
+}
@@ -0,0 +1,600 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// Element defaults, as Excalidraw applies them to members missing from a scene
+const (
+	defaultFontSize    = 20
+	defaultLineHeight  = 1.25
+	defaultStrokeWidth = 1
+
+	// freedrawWidthScale widens freedraw strokes, which Excalidraw outlines wider than their strokeWidth
+	freedrawWidthScale = 2.5
+
+	// nonSolidWidthIncrement widens dashed and dotted outlines
+	nonSolidWidthIncrement = 0.5
+
+	// hachureGapScale is the gap between fill lines relative to the stroke width
+	hachureGapScale = 4
+
+	// loopThreshold is how close the ends of a line must be for it to be a closed, fillable shape
+	loopThreshold = 8
+)
+
+// Layout lays out the visible elements of a scene as a document
+// Elements keep their scene order, so later elements are drawn on top
+func Layout(ctx context.Context, scene *drawing.Scene, opts drawing.ExportOptions) (*Document, error) {
+	l, err := newLayout(ctx, scene, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := l.bounds
+	if bounds.Empty() {
+		bounds = Rect{}
+	}
+	pad := Point{opts.Padding, opts.Padding}
+	return l.document(Rect{Min: bounds.Min.Sub(pad), Max: bounds.Max.Add(pad)}), nil
+}
+
+// Page is a document laid out as one page of a multi-page export
+type Page struct {
+	*Document
+
+	// Title names the page, such as the name of its frame
+	Title string
+}
+
+// Pages lays out one page per frame of the scene, in scene order, each showing the elements of its
+// frame cropped to the frame. A scene without frames is laid out as a single page, as by Layout
+func Pages(ctx context.Context, scene *drawing.Scene, opts drawing.ExportOptions) ([]Page, error) {
+	// Text bound to a container belongs to the frame of its container
+	frameOf := make(map[string]string)
+	var frames []*drawing.Element
+	for _, el := range scene.Elements {
+		if el.Deleted() {
+			continue
+		}
+		if el.FrameID != nil {
+			frameOf[el.ID] = *el.FrameID
+		}
+		if el.Type == "frame" || el.Type == "magicframe" {
+			frames = append(frames, el)
+		}
+	}
+
+	if len(frames) == 0 {
+		doc, err := Layout(ctx, scene, opts)
+		if err != nil {
+			return nil, err
+		}
+		return []Page{{Document: doc}}, nil
+	}
+
+	pages := make([]Page, len(frames))
+	for i, frame := range frames {
+		l, err := newLayout(ctx, scene, opts, func(el *drawing.Element) bool {
+			if el.ContainerID != nil && frameOf[*el.ContainerID] == frame.ID {
+				return true
+			}
+			return frameOf[el.ID] == frame.ID
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		x, y := valueOr(frame.X, 0), valueOr(frame.Y, 0)
+		w, h := size(frame)
+		pages[i] = Page{
+			Document: l.document(Rect{Min: Point{x, y}, Max: Point{x + w, y + h}}),
+			Title:    frameName(frame, i),
+		}
+	}
+	return pages, nil
+}
+
+// frameName returns the name of a frame, or its position among the frames when it has none
+func frameName(frame *drawing.Element, i int) string {
+	if frame.Name != nil && strings.TrimSpace(*frame.Name) != "" {
+		return *frame.Name
+	}
+	return fmt.Sprintf("Frame %d", i+1)
+}
+
+// newLayout lays out the visible elements of a scene accepted by include; a nil include accepts all
+// Laying out stops between elements once ctx is done
+func newLayout(ctx context.Context, scene *drawing.Scene, opts drawing.ExportOptions, include func(*drawing.Element) bool) (*layout, error) {
+	l := &layout{scene: scene, dark: opts.DarkMode, bounds: emptyRect}
+	for _, el := range scene.Elements {
+		if el.Deleted() || (include != nil && !include(el)) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		l.element(el)
+	}
+	return l, nil
+}
+
+// document returns the laid out items as a document showing area, with the top left corner of area at (0, 0)
+func (l *layout) document(area Rect) *Document {
+	offset := Translate(-area.Min.X, -area.Min.Y)
+	for _, item := range l.items {
+		switch it := item.(type) {
+		case *Shape:
+			it.Transform = offset.Mul(it.Transform)
+		case *Text:
+			it.Transform = offset.Mul(it.Transform)
+		case *Image:
+			it.Transform = offset.Mul(it.Transform)
+		}
+	}
+
+	return &Document{
+		Width:      math.Max(1, area.Max.X-area.Min.X),
+		Height:     math.Max(1, area.Max.Y-area.Min.Y),
+		Background: l.background(),
+		Items:      l.items,
+		Fonts:      l.fonts,
+	}
+}
+
+// layout collects the document items of a scene's elements
+type layout struct {
+	scene *drawing.Scene
+	dark  bool
+	items []Item
+	fonts []Font
+
+	// bounds covers the exact geometry of the elements, leaving out the strokes of sketched lines
+	bounds Rect
+}
+
+// background returns the view background color of the scene
+func (l *layout) background() Color {
+	var bg *string
+	if l.scene.AppState != nil {
+		bg = l.scene.AppState.ViewBackgroundColor
+	}
+	return l.color(bg, White)
+}
+
+// color parses a scene color, falling back to def, in the theme of the export
+func (l *layout) color(s *string, def Color) Color {
+	c := def
+	if s != nil {
+		if parsed, ok := ParseColor(*s); ok {
+			c = parsed
+		}
+	}
+	if l.dark {
+		return c.Dark()
+	}
+	return c
+}
+
+// element adds the items that draw el
+func (l *layout) element(el *drawing.Element) {
+	switch el.Type {
+	case "rectangle", "embeddable", "iframe":
+		w, h := size(el)
+		corners := []Point{{0, 0}, {w, 0}, {w, h}, {0, h}}
+		path := polygonPath(corners, cornerRadius(el, math.Min(w, h)))
+		sk := newSketcher(el)
+		l.shape(el, sk, path, sk.path(path), true)
+	case "diamond":
+		w, h := size(el)
+		corners := []Point{{w / 2, 0}, {w, h / 2}, {w / 2, h}, {0, h / 2}}
+		path := polygonPath(corners, cornerRadius(el, w/2))
+		sk := newSketcher(el)
+		l.shape(el, sk, path, sk.path(path), true)
+	case "ellipse":
+		w, h := size(el)
+		sk := newSketcher(el)
+		l.shape(el, sk, ellipsePath(w, h), sk.ellipse(Point{w / 2, h / 2}, w/2, h/2), true)
+	case "line", "arrow":
+		l.linear(el)
+	case "freedraw":
+		l.freedraw(el)
+	case "text":
+		l.text(el)
+	case "image":
+		l.image(el)
+	}
+}
+
+// shape adds the items drawing an element: its fill, if filled, then its outline
+// The exact path places the element and bounds it; outline is the path as the sketcher draws it
+func (l *layout) shape(el *drawing.Element, sk *sketcher, path, outline Path, filled bool) *Shape {
+	m := transform(el, path.Bounds(Identity))
+	bounds := path.Bounds(m)
+
+	if fill := l.color(el.BackgroundColor, Transparent); filled && fill.Visible() {
+		l.add(l.fill(el, sk, path, fill, m), bounds)
+	}
+
+	s := &Shape{
+		Path:        outline,
+		Transform:   m,
+		Opacity:     opacity(el),
+		Stroke:      l.color(el.StrokeColor, Black),
+		StrokeWidth: strokeWidth(el),
+		Dash:        dashPattern(el),
+	}
+	if s.Dash != nil {
+		// Dashed lines are drawn once rather than twice, so they are widened to look as heavy
+		s.StrokeWidth += nonSolidWidthIncrement
+	}
+	l.add(s, bounds)
+	return s
+}
+
+// fill returns the fill of a closed path in the element's fill style: a solid area or
+// lines in the fill color
+func (l *layout) fill(el *drawing.Element, sk *sketcher, path Path, c Color, m Matrix) *Shape {
+	style := FillSolid
+	if el.FillStyle != nil {
+		style = *el.FillStyle
+	}
+
+	if style == FillSolid {
+		return &Shape{Path: sk.fillPath(path), Transform: m, Opacity: opacity(el), Fill: c}
+	}
+
+	var region []Point
+	if lines := flatten(path, Identity); len(lines) > 0 {
+		region = lines[0].points
+	}
+	width := strokeWidth(el)
+	return &Shape{
+		Path:        sk.patternFill(region, style, width*hachureGapScale),
+		Transform:   m,
+		Opacity:     opacity(el),
+		Stroke:      c,
+		StrokeWidth: width / 2,
+	}
+}
+
+// add adds an item covering bounds to the document
+func (l *layout) add(item Item, bounds Rect) {
+	l.items = append(l.items, item)
+	l.bounds = l.bounds.Union(bounds)
+}
+
+// linear adds a line or arrow with its arrowheads
+func (l *layout) linear(el *drawing.Element) {
+	pts := points(el)
+	if len(pts) < 2 {
+		return
+	}
+
+	closed := el.Type == "line" && len(pts) > 2 && pts[0].Sub(pts[len(pts)-1]).Len() <= loopThreshold
+
+	sk := newSketcher(el)
+	var path, outline Path
+	if el.Roundness != nil {
+		path, outline = curvePath(pts), sk.curve(pts)
+	} else {
+		path, outline = polylinePath(pts), sk.polyline(pts, false)
+	}
+	if closed {
+		path.Close()
+	}
+	body := l.shape(el, sk, path, outline, closed)
+
+	// Arrowheads are never dashed, and only dotted lines give them a dotted outline
+	head := *sk
+	head.roughness = math.Min(1, head.roughness)
+	var dash []float64
+	if strokeStyle(el) == "dotted" {
+		dash = []float64{1.5, 4 + strokeWidth(el)}
+	}
+
+	start, end := el.Arrowheads()
+	if start != "" {
+		l.arrowhead(body, &head, dash, start, pts[0], path[1].Points[0], pts[1])
+	}
+	if end != "" {
+		last := path[len(path)-1]
+		if last.Op == ClosePath {
+			last = path[len(path)-2]
+		}
+		from := pts[len(pts)-2]
+		control := from
+		if last.Op == CubicTo {
+			control = last.Points[1]
+		}
+		l.arrowhead(body, &head, dash, end, pts[len(pts)-1], control, from)
+	}
+}
+
+// arrowheadSizes is the largest size of each arrowhead, as in Excalidraw
+var arrowheadSizes = map[string]float64{
+	"arrow":            30,
+	"bar":              15,
+	"dot":              15,
+	"circle":           15,
+	"circle_outline":   15,
+	"triangle":         15,
+	"triangle_outline": 15,
+	"diamond":          12,
+	"diamond_outline":  12,
+}
+
+// arrowhead adds an arrowhead of kind pointing at tip, coming from the direction of control
+// prev is the previous point of the line, which bounds the size of the arrowhead
+func (l *layout) arrowhead(body *Shape, sk *sketcher, dash []float64, kind string, tip, control, prev Point) {
+	size, ok := arrowheadSizes[kind]
+	if !ok {
+		return
+	}
+
+	dir := tip.Sub(control)
+	if dir.Len() == 0 {
+		dir = tip.Sub(prev)
+	}
+	if dir.Len() == 0 {
+		return
+	}
+	dir = dir.Mul(1 / dir.Len())
+
+	lengthFactor := 0.5
+	if strings.HasPrefix(kind, "diamond") {
+		lengthFactor = 0.25
+	}
+	size = math.Min(size, tip.Sub(prev).Len()*lengthFactor)
+	normal := Point{-dir.Y, dir.X}
+
+	var path, outline Path
+	filled := !strings.HasSuffix(kind, "_outline")
+	switch kind {
+	case "arrow":
+		back := tip.Sub(dir.Mul(size))
+		spread := normal.Mul(size * math.Tan(20*math.Pi/180))
+		path.MoveTo(back.Add(spread))
+		path.LineTo(tip)
+		path.LineTo(back.Sub(spread))
+		filled = false
+	case "bar":
+		half := normal.Mul(size / 2)
+		path.MoveTo(tip.Add(half))
+		path.LineTo(tip.Sub(half))
+		filled = false
+	case "dot", "circle", "circle_outline":
+		r := (size + body.StrokeWidth - 2) / 2
+		path, outline = circlePath(tip, r), sk.ellipse(tip, r, r)
+	case "triangle", "triangle_outline":
+		back := tip.Sub(dir.Mul(size))
+		spread := normal.Mul(size * math.Tan(25*math.Pi/180))
+		path = polygonPath([]Point{tip, back.Add(spread), back.Sub(spread)}, 0)
+	case "diamond", "diamond_outline":
+		back := tip.Sub(dir.Mul(2 * size))
+		mid := tip.Sub(dir.Mul(size))
+		spread := normal.Mul(size / 2)
+		path = polygonPath([]Point{tip, mid.Add(spread), back, mid.Sub(spread)}, 0)
+	}
+
+	if outline == nil {
+		outline = sk.path(path)
+	}
+	bounds := path.Bounds(body.Transform)
+
+	if filled {
+		l.add(&Shape{
+			Path:      sk.fillPath(path),
+			Transform: body.Transform,
+			Opacity:   body.Opacity,
+			Fill:      body.Stroke,
+		}, bounds)
+	}
+	l.add(&Shape{
+		Path:        outline,
+		Transform:   body.Transform,
+		Opacity:     body.Opacity,
+		Stroke:      body.Stroke,
+		StrokeWidth: body.StrokeWidth,
+		Dash:        dash,
+	}, bounds)
+}
+
+// freedraw adds a pen stroke
+func (l *layout) freedraw(el *drawing.Element) {
+	pts := points(el)
+	if len(pts) == 0 {
+		return
+	}
+
+	// Pen strokes are smooth whatever the element's roughness and stroke style
+	s := &Shape{
+		Opacity:     opacity(el),
+		Stroke:      l.color(el.StrokeColor, Black),
+		StrokeWidth: strokeWidth(el) * freedrawWidthScale,
+	}
+	if len(pts) == 1 {
+		// A single tap draws a dot
+		s.Path = circlePath(pts[0], s.StrokeWidth/2)
+		s.Fill, s.Stroke = s.Stroke, Transparent
+	} else {
+		s.Path = freehandPath(pts)
+	}
+	s.Transform = transform(el, s.Path.Bounds(Identity))
+	l.add(s, s.Path.Bounds(s.Transform))
+}
+
+// text adds a text element, one line per newline of its wrapped text
+func (l *layout) text(el *drawing.Element) {
+	if el.Text == nil || *el.Text == "" {
+		return
+	}
+
+	family := defaultFontFamily
+	if el.FontFamily != nil {
+		family = *el.FontFamily
+	}
+	font := fontFor(family)
+	l.useFont(font)
+
+	fontSize := valueOr(el.FontSize, defaultFontSize)
+	lineHeight := valueOr(el.LineHeight, defaultLineHeight)
+
+	align := AlignLeft
+	if el.TextAlign != nil {
+		switch *el.TextAlign {
+		case "center":
+			align = AlignCenter
+		case "right":
+			align = AlignRight
+		}
+	}
+
+	lines := strings.Split(strings.ReplaceAll(*el.Text, "\r\n", "\n"), "\n")
+	w, h := size(el)
+	if h == 0 {
+		h = float64(len(lines)) * fontSize * lineHeight
+	}
+
+	l.addBoxed(&Text{
+		Lines:      lines,
+		Font:       font,
+		FontSize:   fontSize,
+		LineHeight: fontSize * lineHeight,
+		Align:      align,
+		Color:      l.color(el.StrokeColor, Black),
+		Width:      w,
+		Height:     h,
+		Transform:  transform(el, Rect{Max: Point{w, h}}),
+		Opacity:    opacity(el),
+	})
+}
+
+// addBoxed adds a text or image item, which covers its box
+func (l *layout) addBoxed(item Item) {
+	l.add(item, item.Bounds())
+}
+
+// useFont records that font is used by the document
+func (l *layout) useFont(font Font) {
+	for _, f := range l.fonts {
+		if f.Family == font.Family {
+			return
+		}
+	}
+	l.fonts = append(l.fonts, font)
+}
+
+// image adds an image element whose file is embedded in the scene
+func (l *layout) image(el *drawing.Element) {
+	if el.FileID == nil {
+		return
+	}
+	file, ok := l.scene.Files[*el.FileID]
+	if !ok || file == nil || file.DataURL == nil || !strings.HasPrefix(*file.DataURL, "data:") {
+		return
+	}
+
+	mimeType := ""
+	if file.MimeType != nil {
+		mimeType = *file.MimeType
+	}
+
+	w, h := size(el)
+	l.addBoxed(&Image{
+		MimeType:  mimeType,
+		DataURL:   *file.DataURL,
+		Width:     w,
+		Height:    h,
+		Transform: transform(el, Rect{Max: Point{w, h}}),
+		Opacity:   opacity(el),
+	})
+}
+
+// transform returns the transform from element coordinates to scene coordinates:
+// a translation to the element position, rotated around the center of its local bounds
+func transform(el *drawing.Element, local Rect) Matrix {
+	x, y := valueOr(el.X, 0), valueOr(el.Y, 0)
+	m := Translate(x, y)
+
+	angle := valueOr(el.Angle, 0)
+	if angle == 0 || local.Empty() {
+		return m
+	}
+	center := Point{x + (local.Min.X+local.Max.X)/2, y + (local.Min.Y+local.Max.Y)/2}
+	return RotateAround(angle, center).Mul(m)
+}
+
+// cornerRadius returns the corner radius of a rounded shape whose smaller side is x, as Excalidraw computes it
+func cornerRadius(el *drawing.Element, x float64) float64 {
+	if el.Roundness == nil {
+		return 0
+	}
+	if el.Roundness.Type == drawing.RoundnessAdaptiveRadius {
+		fixed := valueOr(el.Roundness.Value, 32)
+		if x <= fixed/0.25 {
+			return x * 0.25
+		}
+		return fixed
+	}
+	return x * 0.25
+}
+
+// size returns the width and height of an element
+func size(el *drawing.Element) (float64, float64) {
+	return math.Abs(valueOr(el.Width, 0)), math.Abs(valueOr(el.Height, 0))
+}
+
+// points returns the points of a linear element, relative to its position
+func points(el *drawing.Element) []Point {
+	pts := make([]Point, 0, len(el.Points))
+	for _, p := range el.Points {
+		if len(p) == 2 {
+			pts = append(pts, Point{p[0], p[1]})
+		}
+	}
+	return pts
+}
+
+// strokeStyle returns the stroke style of an element: solid, dashed or dotted
+func strokeStyle(el *drawing.Element) string {
+	if el.StrokeStyle == nil {
+		return "solid"
+	}
+	return *el.StrokeStyle
+}
+
+// dashPattern returns the dash pattern of an element's outline as Excalidraw draws it, nil for solid lines
+func dashPattern(el *drawing.Element) []float64 {
+	switch strokeStyle(el) {
+	case "dashed":
+		return []float64{8, 8 + strokeWidth(el)}
+	case "dotted":
+		return []float64{1.5, 6 + strokeWidth(el)}
+	default:
+		return nil
+	}
+}
+
+// opacity returns the opacity of an element between 0 and 1
+func opacity(el *drawing.Element) float64 {
+	return valueOr(el.Opacity, 100) / 100
+}
+
+// strokeWidth returns the stroke width of an element
+func strokeWidth(el *drawing.Element) float64 {
+	return valueOr(el.StrokeWidth, defaultStrokeWidth)
+}
+
+// valueOr dereferences v, falling back to def when it is not set
+func valueOr(v *float64, def float64) float64 {
+	if v == nil {
+		return def
+	}
+	return *v
+}
@@ -0,0 +1,188 @@
+package render
+
+import "math"
+
+// SegmentOp is the kind of a path segment
+type SegmentOp int
+
+const (
+	MoveTo SegmentOp = iota
+	LineTo
+	CubicTo
+	ClosePath
+)
+
+// Segment is a path command; CubicTo uses all three points (two controls and the end), MoveTo and LineTo the first
+type Segment struct {
+	Op     SegmentOp
+	Points [3]Point
+}
+
+// Path is a sequence of subpaths made of lines and cubic Bézier curves
+type Path []Segment
+
+// MoveTo starts a new subpath at p
+func (p *Path) MoveTo(pt Point) {
+	*p = append(*p, Segment{Op: MoveTo, Points: [3]Point{pt}})
+}
+
+// LineTo draws a straight line to pt
+func (p *Path) LineTo(pt Point) {
+	*p = append(*p, Segment{Op: LineTo, Points: [3]Point{pt}})
+}
+
+// CubicTo draws a cubic Bézier curve to end
+func (p *Path) CubicTo(c1, c2, end Point) {
+	*p = append(*p, Segment{Op: CubicTo, Points: [3]Point{c1, c2, end}})
+}
+
+// Close closes the current subpath
+func (p *Path) Close() {
+	*p = append(*p, Segment{Op: ClosePath})
+}
+
+// Bounds returns the bounds of the path's points (including control points) under m
+func (p Path) Bounds(m Matrix) Rect {
+	r := emptyRect
+	for _, s := range p {
+		n := 0
+		switch s.Op {
+		case MoveTo, LineTo:
+			n = 1
+		case CubicTo:
+			n = 3
+		}
+		for _, pt := range s.Points[:n] {
+			q := m.Apply(pt)
+			r = r.Union(Rect{Min: q, Max: q})
+		}
+	}
+	return r
+}
+
+// Translate moves all points of the path by d, in place, and returns it
+func (p Path) Translate(d Point) Path {
+	for i := range p {
+		for j := range p[i].Points {
+			p[i].Points[j] = p[i].Points[j].Add(d)
+		}
+	}
+	return p
+}
+
+// kappa places the control points of a cubic Bézier approximating a quarter circle
+const kappa = 0.5522847498
+
+// ellipsePath returns an ellipse inscribed in the box (0, 0)-(w, h)
+func ellipsePath(w, h float64) Path {
+	rx, ry := w/2, h/2
+	cx, cy := rx, ry
+	kx, ky := rx*kappa, ry*kappa
+
+	var p Path
+	p.MoveTo(Point{cx + rx, cy})
+	p.CubicTo(Point{cx + rx, cy + ky}, Point{cx + kx, cy + ry}, Point{cx, cy + ry})
+	p.CubicTo(Point{cx - kx, cy + ry}, Point{cx - rx, cy + ky}, Point{cx - rx, cy})
+	p.CubicTo(Point{cx - rx, cy - ky}, Point{cx - kx, cy - ry}, Point{cx, cy - ry})
+	p.CubicTo(Point{cx + kx, cy - ry}, Point{cx + rx, cy - ky}, Point{cx + rx, cy})
+	p.Close()
+	return p
+}
+
+// circlePath returns a circle of radius r around c
+func circlePath(c Point, r float64) Path {
+	return ellipsePath(2*r, 2*r).Translate(c.Sub(Point{r, r}))
+}
+
+// polygonPath returns a closed polygon through pts whose corners are rounded with radius r
+func polygonPath(pts []Point, r float64) Path {
+	var p Path
+	if len(pts) == 0 {
+		return p
+	}
+	if r <= 0 {
+		p.MoveTo(pts[0])
+		for _, pt := range pts[1:] {
+			p.LineTo(pt)
+		}
+		p.Close()
+		return p
+	}
+
+	n := len(pts)
+	for i := 0; i < n; i++ {
+		prev, corner, next := pts[(i+n-1)%n], pts[i], pts[(i+1)%n]
+		in := cornerPoint(corner, prev, r)
+		out := cornerPoint(corner, next, r)
+		if i == 0 {
+			p.MoveTo(in)
+		} else {
+			p.LineTo(in)
+		}
+		p.CubicTo(in.Add(corner.Sub(in).Mul(kappa)), out.Add(corner.Sub(out).Mul(kappa)), out)
+	}
+	p.Close()
+	return p
+}
+
+// cornerPoint returns the point at distance r from corner towards to, at most halfway along the edge
+func cornerPoint(corner, to Point, r float64) Point {
+	edge := to.Sub(corner)
+	length := edge.Len()
+	if length == 0 {
+		return corner
+	}
+	return corner.Add(edge.Mul(math.Min(r, length/2) / length))
+}
+
+// polylinePath returns an open path through pts
+func polylinePath(pts []Point) Path {
+	var p Path
+	for i, pt := range pts {
+		if i == 0 {
+			p.MoveTo(pt)
+		} else {
+			p.LineTo(pt)
+		}
+	}
+	return p
+}
+
+// curvePath returns a smooth open curve through pts (a Catmull-Rom spline, as Excalidraw draws round lines)
+func curvePath(pts []Point) Path {
+	if len(pts) < 3 {
+		return polylinePath(pts)
+	}
+
+	var p Path
+	p.MoveTo(pts[0])
+	for i := 0; i < len(pts)-1; i++ {
+		p0 := pts[max(i-1, 0)]
+		p1, p2 := pts[i], pts[i+1]
+		p3 := pts[min(i+2, len(pts)-1)]
+		c1 := p1.Add(p2.Sub(p0).Mul(1.0 / 6))
+		c2 := p2.Sub(p3.Sub(p1).Mul(1.0 / 6))
+		p.CubicTo(c1, c2, p2)
+	}
+	return p
+}
+
+// freehandPath returns a smooth curve through the midpoints of pts, as pens draw strokes
+func freehandPath(pts []Point) Path {
+	if len(pts) < 3 {
+		return polylinePath(pts)
+	}
+
+	var p Path
+	p.MoveTo(pts[0])
+	prev := pts[0]
+	for i := 1; i < len(pts)-1; i++ {
+		mid := pts[i].Add(pts[i+1]).Mul(0.5)
+		// Quadratic curve prev -> mid controlled by pts[i], raised to a cubic
+		c := pts[i]
+		p.CubicTo(prev.Add(c.Sub(prev).Mul(2.0/3)), mid.Add(c.Sub(mid).Mul(2.0/3)), mid)
+		prev = mid
+	}
+	p.LineTo(pts[len(pts)-1])
+	return p
+}
@@ -0,0 +1,433 @@
+package render
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// pdfMaxPageSize is the largest width or height of a PDF page in points; larger pages are scaled down
+const pdfMaxPageSize = 14400
+
+// PDF resource names of the standard fonts text is set in
+const (
+	pdfSansFont = "F1"
+	pdfMonoFont = "F2"
+)
+
+// RenderPDF renders the scene as a vector PDF with one page per frame
+func (r *Renderer) RenderPDF(ctx context.Context, scene *drawing.Scene, opts drawing.ExportOptions) ([]byte, error) {
+	pages, err := Pages(ctx, scene, opts)
+	if err != nil {
+		return nil, err
+	}
+	return writePDF(pages)
+}
+
+// pdfWriter writes the numbered objects of a PDF file and their cross-reference table
+type pdfWriter struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+// reserve allocates the number of an object written later
+func (w *pdfWriter) reserve() int {
+	w.offsets = append(w.offsets, 0)
+	return len(w.offsets)
+}
+
+// object writes an object with the given number
+func (w *pdfWriter) object(id int, body string) {
+	w.offsets[id-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+// stream writes a Flate compressed stream object; dict holds the stream's own entries
+func (w *pdfWriter) stream(id int, dict string, data []byte) error {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(data); err != nil {
+		return fmt.Errorf("failed to compress PDF stream: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress PDF stream: %w", err)
+	}
+
+	w.offsets[id-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n<< %s /Length %d /Filter /FlateDecode >>\nstream\n", id, dict, compressed.Len())
+	w.buf.Write(compressed.Bytes())
+	w.buf.WriteString("\nendstream\nendobj\n")
+	return nil
+}
+
+// finish writes the cross-reference table and trailer and returns the file
+func (w *pdfWriter) finish(root int) []byte {
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for _, offset := range w.offsets {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(w.offsets)+1, root, xref)
+	return w.buf.Bytes()
+}
+
+// writePDF writes pages as a PDF file, with a bookmark per titled page
+func writePDF(pages []Page) ([]byte, error) {
+	w := &pdfWriter{}
+	w.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	catalog, tree, sans, mono := w.reserve(), w.reserve(), w.reserve(), w.reserve()
+	w.object(sans, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	w.object(mono, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	fonts := fmt.Sprintf("/Font << /%s %d 0 R /%s %d 0 R >>", pdfSansFont, sans, pdfMonoFont, mono)
+
+	pageIDs := make([]int, len(pages))
+	for i, page := range pages {
+		id, err := writePDFPage(w, page, tree, fonts)
+		if err != nil {
+			return nil, err
+		}
+		pageIDs[i] = id
+	}
+
+	kids := make([]string, len(pageIDs))
+	for i, id := range pageIDs {
+		kids[i] = fmt.Sprintf("%d 0 R", id)
+	}
+	w.object(tree, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pageIDs)))
+
+	outlines := ""
+	if id := writePDFOutlines(w, pages, pageIDs); id != 0 {
+		outlines = fmt.Sprintf(" /Outlines %d 0 R /PageMode /UseOutlines", id)
+	}
+	w.object(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R%s >>", tree, outlines))
+
+	return w.finish(catalog), nil
+}
+
+// writePDFOutlines writes a bookmark per titled page and returns the number of the outline root, or 0 without titles
+func writePDFOutlines(w *pdfWriter, pages []Page, pageIDs []int) int {
+	var titled []int
+	for i, page := range pages {
+		if page.Title != "" {
+			titled = append(titled, i)
+		}
+	}
+	if len(titled) == 0 {
+		return 0
+	}
+
+	root := w.reserve()
+	ids := make([]int, len(titled))
+	for i := range ids {
+		ids[i] = w.reserve()
+	}
+	for i, page := range titled {
+		entry := fmt.Sprintf("<< /Title %s /Parent %d 0 R /Dest [%d 0 R /Fit]", pdfText(pages[page].Title), root, pageIDs[page])
+		if i > 0 {
+			entry += fmt.Sprintf(" /Prev %d 0 R", ids[i-1])
+		}
+		if i < len(ids)-1 {
+			entry += fmt.Sprintf(" /Next %d 0 R", ids[i+1])
+		}
+		w.object(ids[i], entry+" >>")
+	}
+	w.object(root, fmt.Sprintf("<< /Type /Outlines /First %d 0 R /Last %d 0 R /Count %d >>", ids[0], ids[len(ids)-1], len(ids)))
+	return root
+}
+
+// writePDFPage writes a page with its content and images, and returns the number of the page object
+func writePDFPage(w *pdfWriter, page Page, tree int, fonts string) (int, error) {
+	doc := page.Document
+	scale := math.Min(1, pdfMaxPageSize/math.Max(doc.Width, doc.Height))
+	width, height := doc.Width*scale, doc.Height*scale
+
+	c := &pdfContent{states: make(map[[2]float64]string)}
+	// Flip the y axis so document coordinates, which point down, can be used as they are
+	fmt.Fprintf(&c.buf, "%s 0 0 %s 0 %s cm\n", pdfNum(scale), pdfNum(-scale), pdfNum(height))
+	if doc.Background.Visible() {
+		c.fillRect(doc.Width, doc.Height, Identity, doc.Background, 1)
+	}
+
+	for _, item := range doc.Items {
+		switch it := item.(type) {
+		case *Shape:
+			c.shape(it)
+		case *Text:
+			c.text(it)
+		case *Image:
+			if err := c.image(w, it); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	content := w.reserve()
+	if err := w.stream(content, "", c.buf.Bytes()); err != nil {
+		return 0, err
+	}
+
+	id := w.reserve()
+	w.object(id, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources << %s%s >> /Contents %d 0 R >>",
+		tree, pdfNum(width), pdfNum(height), fonts, c.resources(), content))
+	return id, nil
+}
+
+// pdfContent builds the content stream of a page and the resources it uses
+type pdfContent struct {
+	buf bytes.Buffer
+
+	// states names the graphics states setting each pair of fill and stroke opacities
+	states map[[2]float64]string
+
+	// images lists the resource entries of the image XObjects drawn on the page
+	images []string
+}
+
+// resources returns the graphics state and image entries of the page's resource dictionary
+func (c *pdfContent) resources() string {
+	var b strings.Builder
+	if len(c.states) > 0 {
+		names := make([]string, 0, len(c.states))
+		byName := make(map[string][2]float64, len(c.states))
+		for alpha, name := range c.states {
+			names = append(names, name)
+			byName[name] = alpha
+		}
+		sort.Strings(names)
+
+		b.WriteString(" /ExtGState <<")
+		for _, name := range names {
+			alpha := byName[name]
+			fmt.Fprintf(&b, " /%s << /ca %s /CA %s >>", name, pdfNum(alpha[0]), pdfNum(alpha[1]))
+		}
+		b.WriteString(" >>")
+	}
+	if len(c.images) > 0 {
+		fmt.Fprintf(&b, " /XObject << %s >>", strings.Join(c.images, " "))
+	}
+	return b.String()
+}
+
+// opacity sets the fill and stroke opacities, unless both are opaque
+func (c *pdfContent) opacity(fill, stroke float64) {
+	if fill >= 1 && stroke >= 1 {
+		return
+	}
+	key := [2]float64{math.Round(fill*1000) / 1000, math.Round(stroke*1000) / 1000}
+	name, ok := c.states[key]
+	if !ok {
+		name = "GS" + strconv.Itoa(len(c.states))
+		c.states[key] = name
+	}
+	fmt.Fprintf(&c.buf, "/%s gs\n", name)
+}
+
+// transform concatenates m to the current transform
+func (c *pdfContent) transform(m Matrix) {
+	if m != Identity {
+		fmt.Fprintf(&c.buf, "%s %s %s %s %s %s cm\n", pdfNum(m[0]), pdfNum(m[1]), pdfNum(m[2]), pdfNum(m[3]), pdfNum(m[4]), pdfNum(m[5]))
+	}
+}
+
+// shape paints a shape's fill and stroke
+func (c *pdfContent) shape(s *Shape) {
+	fill := s.Fill.Visible()
+	stroke := s.Stroke.Visible() && s.StrokeWidth > 0
+	if (!fill && !stroke) || len(s.Path) == 0 {
+		return
+	}
+
+	// Opacities only apply to the parts that are painted, so unpainted ones are left opaque
+	fillAlpha, strokeAlpha := 1.0, 1.0
+	if fill {
+		fillAlpha = s.Fill.Alpha() * s.Opacity
+	}
+	if stroke {
+		strokeAlpha = s.Stroke.Alpha() * s.Opacity
+	}
+
+	c.buf.WriteString("q\n")
+	c.transform(s.Transform)
+	c.opacity(fillAlpha, strokeAlpha)
+	if fill {
+		fmt.Fprintf(&c.buf, "%s rg\n", pdfColor(s.Fill))
+	}
+	if stroke {
+		fmt.Fprintf(&c.buf, "%s RG %s w 1 J 1 j\n", pdfColor(s.Stroke), pdfNum(s.StrokeWidth))
+		if len(s.Dash) > 0 {
+			dash := make([]string, len(s.Dash))
+			for i, d := range s.Dash {
+				dash[i] = pdfNum(d)
+			}
+			fmt.Fprintf(&c.buf, "[%s] 0 d\n", strings.Join(dash, " "))
+		}
+	}
+
+	c.path(s.Path)
+	switch {
+	case fill && stroke:
+		c.buf.WriteString("B\n")
+	case fill:
+		c.buf.WriteString("f\n")
+	default:
+		c.buf.WriteString("S\n")
+	}
+	c.buf.WriteString("Q\n")
+}
+
+// path appends the construction operators of a path
+func (c *pdfContent) path(p Path) {
+	for _, s := range p {
+		switch s.Op {
+		case MoveTo:
+			fmt.Fprintf(&c.buf, "%s %s m\n", pdfNum(s.Points[0].X), pdfNum(s.Points[0].Y))
+		case LineTo:
+			fmt.Fprintf(&c.buf, "%s %s l\n", pdfNum(s.Points[0].X), pdfNum(s.Points[0].Y))
+		case CubicTo:
+			fmt.Fprintf(&c.buf, "%s %s %s %s %s %s c\n",
+				pdfNum(s.Points[0].X), pdfNum(s.Points[0].Y),
+				pdfNum(s.Points[1].X), pdfNum(s.Points[1].Y),
+				pdfNum(s.Points[2].X), pdfNum(s.Points[2].Y))
+		case ClosePath:
+			c.buf.WriteString("h\n")
+		}
+	}
+}
+
+// fillRect fills the box (0, 0)-(w, h) under m
+func (c *pdfContent) fillRect(w, h float64, m Matrix, color Color, opacity float64) {
+	c.buf.WriteString("q\n")
+	c.transform(m)
+	c.opacity(color.Alpha()*opacity, 1)
+	fmt.Fprintf(&c.buf, "%s rg 0 0 %s %s re f\nQ\n", pdfColor(color), pdfNum(w), pdfNum(h))
+}
+
+// text sets a text block in the standard font closest to its family
+// Each line is flipped back upright, as the page's y axis points down
+func (c *pdfContent) text(t *Text) {
+	font, widths := pdfSansFont, &helveticaWidths
+	if t.Font.Generic == "monospace" {
+		font, widths = pdfMonoFont, nil
+	}
+
+	c.buf.WriteString("q\n")
+	c.transform(t.Transform)
+	c.opacity(t.Color.Alpha()*t.Opacity, 1)
+	fmt.Fprintf(&c.buf, "%s rg\nBT\n/%s %s Tf\n", pdfColor(t.Color), font, pdfNum(t.FontSize))
+	for i, line := range t.Lines {
+		encoded := winAnsi(line)
+		width := textWidth(encoded, widths) * t.FontSize
+
+		x := t.Anchor()
+		switch t.Align {
+		case AlignCenter:
+			x -= width / 2
+		case AlignRight:
+			x -= width
+		}
+		fmt.Fprintf(&c.buf, "1 0 0 -1 %s %s Tm %s Tj\n", pdfNum(x), pdfNum(t.Baseline(i)), pdfString(encoded))
+	}
+	c.buf.WriteString("ET\nQ\n")
+}
+
+// image draws an embedded image, or a placeholder when it cannot be decoded
+func (c *pdfContent) image(w *pdfWriter, i *Image) error {
+	src, err := decodeDataURL(i.DataURL)
+	if err != nil {
+		c.fillRect(i.Width, i.Height, i.Transform, placeholderColor, i.Opacity)
+		return nil
+	}
+
+	size := src.Bounds().Size()
+	rgb := make([]byte, 0, size.X*size.Y*3)
+	alpha := make([]byte, 0, size.X*size.Y)
+	opaque := true
+	for p := 0; p < len(src.Pix); p += 4 {
+		rgb = append(rgb, src.Pix[p], src.Pix[p+1], src.Pix[p+2])
+		alpha = append(alpha, src.Pix[p+3])
+		opaque = opaque && src.Pix[p+3] == 255
+	}
+
+	dict := fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /BitsPerComponent 8", size.X, size.Y)
+	smask := ""
+	if !opaque {
+		mask := w.reserve()
+		if err := w.stream(mask, dict+" /ColorSpace /DeviceGray", alpha); err != nil {
+			return err
+		}
+		smask = fmt.Sprintf(" /SMask %d 0 R", mask)
+	}
+	id := w.reserve()
+	if err := w.stream(id, dict+" /ColorSpace /DeviceRGB"+smask, rgb); err != nil {
+		return err
+	}
+
+	name := "Im" + strconv.Itoa(len(c.images))
+	c.images = append(c.images, fmt.Sprintf("/%s %d 0 R", name, id))
+
+	// Images fill the unit square with their first row at the top, which the flipped y axis puts at the bottom
+	c.buf.WriteString("q\n")
+	c.transform(i.Transform.Mul(Matrix{i.Width, 0, 0, -i.Height, 0, i.Height}))
+	c.opacity(i.Opacity, 1)
+	fmt.Fprintf(&c.buf, "/%s Do\nQ\n", name)
+	return nil
+}
+
+// pdfNum formats a number with at most three decimals
+func pdfNum(v float64) string {
+	v = math.Round(v*1000) / 1000
+	if v == 0 {
+		v = 0 // drop the sign of negative zero
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// pdfColor formats the RGB components of a color
+func pdfColor(c Color) string {
+	return fmt.Sprintf("%s %s %s", pdfNum(float64(c.R)/255), pdfNum(float64(c.G)/255), pdfNum(float64(c.B)/255))
+}
+
+// pdfString encodes bytes as a PDF literal string
+func pdfString(s []byte) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, c := range s {
+		switch c {
+		case '(', ')', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\n':
+			b.WriteString(`\n`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// pdfText encodes text for display outside page content, such as bookmark titles, in UTF-16
+func pdfText(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, r := range s {
+		if r > 0xffff {
+			r1, r2 := 0xd800+((r-0x10000)>>10), 0xdc00+((r-0x10000)&0x3ff)
+			fmt.Fprintf(&b, "%04X%04X", r1, r2)
+			continue
+		}
+		fmt.Fprintf(&b, "%04X", r)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
@@ -0,0 +1,138 @@
+package render
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+var (
+	pdfMediaBox = regexp.MustCompile(`/MediaBox \[0 0 ([\d.]+) ([\d.]+)\]`)
+	pdfStream   = regexp.MustCompile(`(?s)/Length (\d+) /Filter /FlateDecode >>\nstream\n`)
+)
+
+// pdfStreams returns the inflated content of every stream in a rendered PDF
+func pdfStreams(t *testing.T, data []byte) []string {
+	t.Helper()
+	var streams []string
+	for _, m := range pdfStream.FindAllSubmatchIndex(data, -1) {
+		var length int
+		for _, c := range data[m[2]:m[3]] {
+			length = length*10 + int(c-'0')
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(data[m[1] : m[1]+length]))
+		if err != nil {
+			t.Fatalf("failed to inflate stream: %v", err)
+		}
+		content, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatalf("failed to inflate stream: %v", err)
+		}
+		streams = append(streams, string(content))
+	}
+	return streams
+}
+
+func TestRenderPDF(t *testing.T) {
+	r := NewRenderer()
+
+	t.Run("one page per frame in scene order", func(t *testing.T) {
+		scene := parseScene(t, `{
+			"elements": [
+				{"id": "f2", "type": "frame", "x": 300, "y": 0, "width": 200, "height": 100, "name": "Second"},
+				{"id": "f1", "type": "frame", "x": 0, "y": 0, "width": 100, "height": 50, "name": "First"},
+				{"id": "a", "type": "text", "x": 10, "y": 10, "width": 40, "height": 25, "text": "alpha",
+				 "fontSize": 20, "frameId": "f1"},
+				{"id": "b", "type": "text", "x": 310, "y": 10, "width": 40, "height": 25, "text": "beta",
+				 "fontSize": 20, "frameId": "f2"}
+			]
+		}`)
+
+		data, err := r.RenderPDF(context.Background(), scene, drawing.ExportOptions{Padding: 10})
+		if err != nil {
+			t.Fatalf("RenderPDF: %v", err)
+		}
+		if !bytes.HasPrefix(data, []byte("%PDF-")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+			t.Fatalf("output is not a PDF file")
+		}
+
+		boxes := pdfMediaBox.FindAllStringSubmatch(string(data), -1)
+		if len(boxes) != 2 {
+			t.Fatalf("expected 2 pages, got %d", len(boxes))
+		}
+		// Frame pages cover the frame exactly, without padding
+		if boxes[0][1] != "200" || boxes[0][2] != "100" || boxes[1][1] != "100" || boxes[1][2] != "50" {
+			t.Errorf("unexpected page sizes %v", boxes)
+		}
+
+		second, first := strings.Index(string(data), pdfText("Second")), strings.Index(string(data), pdfText("First"))
+		if second < 0 || first < 0 || second > first {
+			t.Errorf("expected bookmarks Second then First")
+		}
+
+		streams := pdfStreams(t, data)
+		if len(streams) != 2 {
+			t.Fatalf("expected 2 content streams, got %d", len(streams))
+		}
+		if !strings.Contains(streams[0], "(beta) Tj") || strings.Contains(streams[0], "(alpha)") {
+			t.Errorf("first page should only hold the second frame's text:\n%s", streams[0])
+		}
+		if !strings.Contains(streams[1], "(alpha) Tj") || strings.Contains(streams[1], "(beta)") {
+			t.Errorf("second page should only hold the first frame's text:\n%s", streams[1])
+		}
+	})
+
+	t.Run("whole canvas without frames", func(t *testing.T) {
+		scene := parseScene(t, `{
+			"elements": [
+				{"id": "r", "type": "rectangle", "x": 0, "y": 0, "width": 100, "height": 50,
+				 "strokeColor": "#1e1e1e", "backgroundColor": "#ff0000", "fillStyle": "solid", "opacity": 50}
+			],
+			"appState": {"viewBackgroundColor": "#ffffff"}
+		}`)
+
+		data, err := r.RenderPDF(context.Background(), scene, drawing.ExportOptions{Padding: 10})
+		if err != nil {
+			t.Fatalf("RenderPDF: %v", err)
+		}
+
+		boxes := pdfMediaBox.FindAllStringSubmatch(string(data), -1)
+		if len(boxes) != 1 || boxes[0][1] != "120" || boxes[0][2] != "70" {
+			t.Fatalf("expected a single 120x70 page, got %v", boxes)
+		}
+		if bytes.Contains(data, []byte("/Outlines")) {
+			t.Errorf("expected no bookmarks without frames")
+		}
+		if !bytes.Contains(data, []byte("/ca 0.5 /CA 1")) {
+			t.Errorf("expected a graphics state for the element's opacity")
+		}
+
+		content := pdfStreams(t, data)[0]
+		for _, want := range []string{"1 1 1 rg 0 0 120 70 re f", "1 0 0 rg", "h\nf\n", "h\nS\n"} {
+			if !strings.Contains(content, want) {
+				t.Errorf("content stream missing %q:\n%s", want, content)
+			}
+		}
+	})
+}
+
+func TestPDFEncoding(t *testing.T) {
+	if got := pdfString(winAnsi("a (b) \\ café – €")); got != "(a \\(b\\) \\\\ caf\xe9 \x96 \x80)" {
+		t.Errorf("unexpected string encoding %q", got)
+	}
+	if got := pdfText("Frame é"); got != "<FEFF004600720061006D0065002000E9>" {
+		t.Errorf("unexpected text encoding %q", got)
+	}
+	if w := textWidth([]byte("Hi"), &helveticaWidths); w != 0.944 {
+		t.Errorf("expected Helvetica width 0.944, got %v", w)
+	}
+	if w := textWidth([]byte("Hi"), nil); w != 1.2 {
+		t.Errorf("expected Courier width 1.2, got %v", w)
+	}
+}
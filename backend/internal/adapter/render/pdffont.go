@@ -0,0 +1,67 @@
+package render
+
+// helveticaWidths are the advances of the printable ASCII characters (32-126) in Helvetica,
+// in thousandths of the font size, from the font's metrics
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space - /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, // 0 - 9
+	278, 278, 584, 584, 584, 556, 1015, // : - @
+	667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, // A - M
+	722, 778, 667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, // N - Z
+	278, 278, 278, 469, 556, 333, // [ - `
+	556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, // a - m
+	556, 556, 556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, // n - z
+	334, 260, 334, 584, // { - ~
+}
+
+// Advances used for characters without an entry in a width table
+const (
+	helveticaDefaultWidth = 556
+	courierWidth          = 600
+)
+
+// winAnsiSpecials maps the characters WinAnsiEncoding places in 0x80-0x9F to their codes
+var winAnsiSpecials = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b,
+	'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// winAnsi encodes a line in WinAnsiEncoding, the encoding of the standard fonts
+// Characters the encoding lacks are replaced by a question mark
+func winAnsi(line string) []byte {
+	out := make([]byte, 0, len(line))
+	for _, r := range line {
+		switch {
+		case r == '\t':
+			out = append(out, ' ')
+		case r >= 0x20 && r < 0x7f, r >= 0xa0 && r <= 0xff:
+			out = append(out, byte(r))
+		case winAnsiSpecials[r] != 0:
+			out = append(out, winAnsiSpecials[r])
+		case r < 0x20:
+			// Control characters are not drawn
+		default:
+			out = append(out, '?')
+		}
+	}
+	return out
+}
+
+// textWidth returns the advance of encoded text relative to the font size
+// A nil table measures the fixed-width Courier
+func textWidth(encoded []byte, widths *[95]int) float64 {
+	total := 0
+	for _, c := range encoded {
+		switch {
+		case widths == nil:
+			total += courierWidth
+		case c >= 32 && c <= 126:
+			total += widths[c-32]
+		default:
+			total += helveticaDefaultWidth
+		}
+	}
+	return float64(total) / 1000
+}
@@ -0,0 +1,262 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"image/png"
+	"math"
+	"net/url"
+	"strings"
+
+	// Image formats embedded scenes may contain
+	_ "image/gif"
+	_ "image/jpeg"
+
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// placeholderColor fills images that cannot be decoded, such as SVG files
+var placeholderColor = Color{0xe9, 0xec, 0xef, 255}
+
+// RenderPNG renders the scene as a PNG image
+func (r *Renderer) RenderPNG(ctx context.Context, scene *drawing.Scene, opts drawing.ExportOptions) ([]byte, error) {
+	doc, err := Layout(ctx, scene, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	scale := opts.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	if opts.MaxDimension > 0 {
+		scale = math.Min(scale, float64(opts.MaxDimension)/math.Max(doc.Width, doc.Height))
+	}
+
+	w, h := pixelSize(doc.Width, scale), pixelSize(doc.Height, scale)
+	if exceedsPixelLimit(w, h) {
+		return nil, fmt.Errorf("%w: a %dx%d image exceeds the limit of %d pixels", drawing.ErrInvalidExportOptions, w, h, drawing.MaxExportPixels)
+	}
+
+	img, err := doc.Rasterize(ctx, w, h, scale)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode PNG: %w", err)
+	}
+	if opts.EmbeddedScene != nil {
+		return insertPNGText(buf.Bytes(), drawing.EmbeddedSceneMimeType, opts.EmbeddedScene), nil
+	}
+	return buf.Bytes(), nil
+}
+
+// insertPNGText adds a tEXt chunk to an encoded PNG image, just before its final IEND chunk
+func insertPNGText(img []byte, keyword string, text []byte) []byte {
+	data := append(append([]byte(keyword), 0), text...)
+
+	chunk := make([]byte, 0, len(data)+12)
+	chunk = binary.BigEndian.AppendUint32(chunk, uint32(len(data)))
+	chunk = append(chunk, "tEXt"...)
+	chunk = append(chunk, data...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+
+	// The IEND chunk is always the last 12 bytes of an image written by image/png
+	end := len(img) - 12
+	out := make([]byte, 0, len(img)+len(chunk))
+	out = append(out, img[:end]...)
+	out = append(out, chunk...)
+	return append(out, img[end:]...)
+}
+
+// pixelSize returns the number of pixels a length covers at scale, at least one
+// Lengths past the pixel limit are clamped just above it, so they convert to an int safely
+func pixelSize(length, scale float64) int {
+	return max(1, int(math.Min(math.Ceil(length*scale), drawing.MaxExportPixels+1)))
+}
+
+// exceedsPixelLimit reports whether a w x h image has more than drawing.MaxExportPixels pixels
+// The sides are compared one at a time, as their product can overflow
+func exceedsPixelLimit(w, h int) bool {
+	return w > 0 && h > 0 && w > drawing.MaxExportPixels/h
+}
+
+// Rasterize draws the document on a w x h image, scaling document coordinates by scale
+// Drawing stops between items once ctx is done
+func (d *Document) Rasterize(ctx context.Context, w, h int, scale float64) (*image.RGBA, error) {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	if d.Background.Visible() {
+		fillPolygons(img, [][]Point{{{0, 0}, {float64(w), 0}, {float64(w), float64(h)}, {0, float64(h)}}}, d.Background, 1)
+	}
+
+	device := Scale(scale, scale)
+	for _, item := range d.Items {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		switch it := item.(type) {
+		case *Shape:
+			rasterizeShape(img, it, device.Mul(it.Transform))
+		case *Text:
+			rasterizeText(img, it, device.Mul(it.Transform))
+		case *Image:
+			rasterizeImage(img, it, device.Mul(it.Transform))
+		}
+	}
+	return img, nil
+}
+
+// rasterizeShape fills and strokes a shape
+func rasterizeShape(img *image.RGBA, s *Shape, m Matrix) {
+	lines := flatten(s.Path, m)
+
+	if s.Fill.Visible() {
+		var polys [][]Point
+		for _, l := range lines {
+			if len(l.points) > 2 {
+				polys = append(polys, l.points)
+			}
+		}
+		fillPolygons(img, polys, s.Fill, s.Opacity)
+	}
+
+	if s.Stroke.Visible() && s.StrokeWidth > 0 {
+		scale := matrixScale(m)
+		if len(s.Dash) > 0 {
+			lines = dashLines(lines, s.Dash, scale)
+		}
+		fillPolygons(img, strokePolygons(lines, s.StrokeWidth*scale), s.Stroke, s.Opacity)
+	}
+}
+
+// rasterizeText draws a text block with the stroke font
+func rasterizeText(img *image.RGBA, t *Text, m Matrix) {
+	lines, weight := textStrokes(t)
+	for i := range lines {
+		for j, p := range lines[i].points {
+			lines[i].points[j] = m.Apply(p)
+		}
+	}
+	fillPolygons(img, strokePolygons(lines, weight*matrixScale(m)), t.Color, t.Opacity)
+}
+
+// rasterizeImage draws an embedded image stretched over its box, or a placeholder when it cannot be decoded
+func rasterizeImage(img *image.RGBA, i *Image, m Matrix) {
+	src, err := decodeDataURL(i.DataURL)
+	if err != nil {
+		box := []Point{m.Apply(Point{0, 0}), m.Apply(Point{i.Width, 0}), m.Apply(Point{i.Width, i.Height}), m.Apply(Point{0, i.Height})}
+		fillPolygons(img, [][]Point{box}, placeholderColor, i.Opacity)
+		return
+	}
+
+	inv, ok := m.Invert()
+	if !ok || i.Width <= 0 || i.Height <= 0 {
+		return
+	}
+
+	srcSize := src.Bounds().Size()
+	sx, sy := float64(srcSize.X)/i.Width, float64(srcSize.Y)/i.Height
+
+	b := boxBounds(i.Width, i.Height, m)
+	size := img.Bounds().Size()
+	for y := max(0, int(b.Min.Y)); y < min(size.Y, int(math.Ceil(b.Max.Y))); y++ {
+		for x := max(0, int(b.Min.X)); x < min(size.X, int(math.Ceil(b.Max.X))); x++ {
+			// Sample the source at the center of the destination pixel
+			p := inv.Apply(Point{float64(x) + 0.5, float64(y) + 0.5})
+			if p.X < 0 || p.Y < 0 || p.X >= i.Width || p.Y >= i.Height {
+				continue
+			}
+			c := sampleBilinear(src, p.X*sx-0.5, p.Y*sy-0.5)
+			if c.Visible() {
+				blend(img, x, y, Color{c.R, c.G, c.B, 255}, c.Alpha()*i.Opacity)
+			}
+		}
+	}
+}
+
+// decodeDataURL decodes a base64 data URL holding a PNG, JPEG or GIF image
+// Images of more than drawing.MaxExportPixels pixels are rejected
+func decodeDataURL(dataURL string) (*image.NRGBA, error) {
+	meta, data, ok := strings.Cut(strings.TrimPrefix(dataURL, "data:"), ",")
+	if !ok {
+		return nil, fmt.Errorf("malformed data URL")
+	}
+
+	var raw []byte
+	if strings.HasSuffix(meta, ";base64") {
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 data: %w", err)
+		}
+		raw = decoded
+	} else {
+		unescaped, err := url.PathUnescape(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL encoded data: %w", err)
+		}
+		raw = []byte(unescaped)
+	}
+
+	// Read the dimensions first, so a small file claiming a huge image is not decoded into memory
+	config, _, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	if exceedsPixelLimit(config.Width, config.Height) {
+		return nil, fmt.Errorf("a %dx%d image exceeds the limit of %d pixels", config.Width, config.Height, drawing.MaxExportPixels)
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	nrgba := image.NewNRGBA(decoded.Bounds().Sub(decoded.Bounds().Min))
+	draw.Draw(nrgba, nrgba.Bounds(), decoded, decoded.Bounds().Min, draw.Src)
+	return nrgba, nil
+}
+
+// sampleBilinear interpolates the four source pixels around (x, y), clamping at the edges
+func sampleBilinear(src *image.NRGBA, x, y float64) Color {
+	size := src.Bounds().Size()
+	x = math.Max(0, math.Min(float64(size.X-1), x))
+	y = math.Max(0, math.Min(float64(size.Y-1), y))
+	x0, y0 := int(x), int(y)
+	x1, y1 := min(x0+1, size.X-1), min(y0+1, size.Y-1)
+	fx, fy := x-float64(x0), y-float64(y0)
+
+	at := func(px, py int) [4]float64 {
+		i := src.PixOffset(px, py)
+		p := src.Pix[i : i+4 : i+4]
+		a := float64(p[3]) / 255
+		// Interpolate premultiplied values so transparent pixels do not bleed their color
+		return [4]float64{float64(p[0]) * a, float64(p[1]) * a, float64(p[2]) * a, float64(p[3])}
+	}
+	c00, c10, c01, c11 := at(x0, y0), at(x1, y0), at(x0, y1), at(x1, y1)
+
+	var v [4]float64
+	for k := range v {
+		top := c00[k]*(1-fx) + c10[k]*fx
+		bottom := c01[k]*(1-fx) + c11[k]*fx
+		v[k] = top*(1-fy) + bottom*fy
+	}
+	if v[3] == 0 {
+		return Transparent
+	}
+	a := v[3] / 255
+	return Color{clampByte(v[0] / a), clampByte(v[1] / a), clampByte(v[2] / a), clampByte(v[3])}
+}
+
+// matrixScale returns how much m scales lengths on average
+func matrixScale(m Matrix) float64 {
+	return math.Sqrt(math.Abs(m[0]*m[3] - m[1]*m[2]))
+}
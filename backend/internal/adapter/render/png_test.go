@@ -0,0 +1,193 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// decodePNG decodes a rendered PNG, failing the test when it is invalid
+func decodePNG(t *testing.T, data []byte) image.Image {
+	t.Helper()
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to decode PNG: %v", err)
+	}
+	return img
+}
+
+// rgbAt returns the color of a pixel as 8-bit RGB
+func rgbAt(img image.Image, x, y int) [3]uint8 {
+	r, g, b, _ := img.At(x, y).RGBA()
+	return [3]uint8{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8)}
+}
+
+func TestRenderPNG(t *testing.T) {
+	scene := parseScene(t, `{
+		"elements": [
+			{"id": "r", "type": "rectangle", "x": 0, "y": 0, "width": 100, "height": 50,
+			 "strokeColor": "#1e1e1e", "backgroundColor": "#ff0000", "fillStyle": "solid", "strokeWidth": 2},
+			{"id": "l", "type": "line", "x": 0, "y": 80, "width": 100, "height": 0, "points": [[0, 0], [100, 0]],
+			 "strokeColor": "#0000ff", "strokeWidth": 4}
+		],
+		"appState": {"viewBackgroundColor": "#ffffff"}
+	}`)
+
+	tests := []struct {
+		name      string
+		opts      drawing.ExportOptions
+		width     int
+		height    int
+		pixels    map[image.Point][3]uint8
+		wantErrIs error
+	}{
+		{
+			name:   "scale 1",
+			opts:   drawing.ExportOptions{Padding: 10},
+			width:  120,
+			height: 100,
+			pixels: map[image.Point][3]uint8{
+				{2, 2}:   {255, 255, 255}, // background
+				{60, 35}: {255, 0, 0},     // rectangle fill
+				{60, 90}: {0, 0, 255},     // line stroke
+			},
+		},
+		{
+			name:   "scale 2",
+			opts:   drawing.ExportOptions{Padding: 10, Scale: 2},
+			width:  240,
+			height: 200,
+			pixels: map[image.Point][3]uint8{
+				{120, 70}:  {255, 0, 0},
+				{120, 180}: {0, 0, 255},
+			},
+		},
+		{
+			name:   "dark mode",
+			opts:   drawing.ExportOptions{Padding: 10, DarkMode: true},
+			width:  120,
+			height: 100,
+			pixels: map[image.Point][3]uint8{
+				{2, 2}: {0x12, 0x12, 0x12},
+			},
+		},
+		{
+			name:   "max dimension",
+			opts:   drawing.ExportOptions{Padding: 10, Scale: 4, MaxDimension: 61},
+			width:  61,
+			height: 51,
+		},
+		{
+			name:      "too many pixels",
+			opts:      drawing.ExportOptions{Padding: drawing.MaxExportPadding, Scale: drawing.MaxExportScale},
+			wantErrIs: drawing.ErrInvalidExportOptions,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := NewRenderer().RenderPNG(context.Background(), scene, tt.opts)
+			if tt.wantErrIs != nil {
+				if !errors.Is(err, tt.wantErrIs) {
+					t.Fatalf("expected %v, got %v", tt.wantErrIs, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			img := decodePNG(t, data)
+			if size := img.Bounds().Size(); size.X != tt.width || size.Y != tt.height {
+				t.Fatalf("expected %dx%d image, got %dx%d", tt.width, tt.height, size.X, size.Y)
+			}
+			for p, want := range tt.pixels {
+				if got := rgbAt(img, p.X, p.Y); got != want {
+					t.Errorf("pixel %v: expected %v, got %v", p, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderPNGHugeScene(t *testing.T) {
+	// Elements 2^32 units apart in both directions give an image whose pixel count overflows an int
+	scene := parseScene(t, `{
+		"elements": [
+			{"id": "a", "type": "rectangle", "x": 0, "y": 0, "width": 10, "height": 10},
+			{"id": "b", "type": "rectangle", "x": 4294967286, "y": 4294967286, "width": 10, "height": 10}
+		]
+	}`)
+
+	if _, err := NewRenderer().RenderPNG(context.Background(), scene, drawing.ExportOptions{}); !errors.Is(err, drawing.ErrInvalidExportOptions) {
+		t.Fatalf("expected %v, got %v", drawing.ErrInvalidExportOptions, err)
+	}
+}
+
+func TestRasterizeTransparentBackground(t *testing.T) {
+	scene := parseScene(t, `{
+		"elements": [{"id": "e", "type": "ellipse", "x": 0, "y": 0, "width": 40, "height": 40, "strokeColor": "#000000"}],
+		"appState": {"viewBackgroundColor": "transparent"}
+	}`)
+
+	doc, err := Layout(context.Background(), scene, drawing.ExportOptions{Padding: 0})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	img, err := doc.Rasterize(context.Background(), 40, 40, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The inside of an unfilled ellipse stays transparent, its outline is drawn
+	if a := img.RGBAAt(20, 20).A; a != 0 {
+		t.Errorf("expected transparent center, got alpha %d", a)
+	}
+	if a := img.RGBAAt(20, 0).A; a == 0 {
+		t.Error("expected outline at the top of the ellipse")
+	}
+}
+
+func TestDecodeDataURL(t *testing.T) {
+	var small bytes.Buffer
+	if err := png.Encode(&small, image.NewNRGBA(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatalf("failed to encode PNG: %v", err)
+	}
+
+	// A GIF header claiming a 65535x65535 screen, with no image data behind it
+	huge := []byte{'G', 'I', 'F', '8', '9', 'a', 0xff, 0xff, 0xff, 0xff, 0, 0, 0, ';'}
+
+	tests := []struct {
+		name        string
+		data        []byte
+		expectError bool
+	}{
+		{name: "small image", data: small.Bytes()},
+		{name: "image over the pixel limit", data: huge, expectError: true},
+		{name: "not an image", data: []byte("<svg></svg>"), expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := decodeDataURL("data:image/png;base64," + base64.StdEncoding.EncodeToString(tt.data))
+			if tt.expectError {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if size := img.Bounds().Size(); size.X != 3 || size.Y != 2 {
+				t.Errorf("expected 3x2 image, got %dx%d", size.X, size.Y)
+			}
+		})
+	}
+}
@@ -0,0 +1,338 @@
+package render
+
+import (
+	"image"
+	"math"
+)
+
+// flattenTolerance is the largest distance in pixels between a curve and the lines approximating it
+const flattenTolerance = 0.25
+
+// maxCurveSegments bounds the number of lines a single Bézier curve is flattened to
+const maxCurveSegments = 64
+
+// maxDashes bounds the dashes of one stroke; longer strokes, whose dashes would mostly be too small
+// to see, are drawn solid
+const maxDashes = 10000
+
+// polyline is a flattened subpath in device coordinates
+type polyline struct {
+	points []Point
+	closed bool
+}
+
+// flatten transforms a path with m and approximates its curves with lines
+func flatten(p Path, m Matrix) []polyline {
+	var lines []polyline
+	var current *polyline
+	var last Point
+
+	for _, s := range p {
+		switch s.Op {
+		case MoveTo:
+			last = m.Apply(s.Points[0])
+			lines = append(lines, polyline{points: []Point{last}})
+			current = &lines[len(lines)-1]
+		case LineTo:
+			if current == nil {
+				continue
+			}
+			last = m.Apply(s.Points[0])
+			current.points = append(current.points, last)
+		case CubicTo:
+			if current == nil {
+				continue
+			}
+			c1, c2, end := m.Apply(s.Points[0]), m.Apply(s.Points[1]), m.Apply(s.Points[2])
+			n := curveSegments(last, c1, c2, end)
+			for i := 1; i <= n; i++ {
+				current.points = append(current.points, cubicPoint(last, c1, c2, end, float64(i)/float64(n)))
+			}
+			last = end
+		case ClosePath:
+			if current != nil {
+				current.closed = true
+				last = current.points[0]
+			}
+		}
+	}
+
+	return lines
+}
+
+// curveSegments returns how many lines approximate a cubic Bézier within flattenTolerance
+func curveSegments(p0, p1, p2, p3 Point) int {
+	// The second differences of the control points bound the deviation of the curve from its chords
+	dd := math.Max(p0.Sub(p1.Mul(2)).Add(p2).Len(), p1.Sub(p2.Mul(2)).Add(p3).Len())
+	n := int(math.Ceil(math.Sqrt(0.75 * dd / flattenTolerance)))
+	return max(1, min(n, maxCurveSegments))
+}
+
+// cubicPoint evaluates a cubic Bézier at t
+func cubicPoint(p0, p1, p2, p3 Point, t float64) Point {
+	u := 1 - t
+	return p0.Mul(u * u * u).Add(p1.Mul(3 * u * u * t)).Add(p2.Mul(3 * u * t * t)).Add(p3.Mul(t * t * t))
+}
+
+// dashLines splits polylines into their dashes, with the pattern lengths scaled by scale
+// The pattern restarts at each subpath, as in SVG
+func dashLines(lines []polyline, pattern []float64, scale float64) []polyline {
+	total := 0.0
+	for _, d := range pattern {
+		total += d
+	}
+	if total <= 0 {
+		return lines
+	}
+
+	length := 0.0
+	for _, l := range lines {
+		for j := 1; j < len(l.points); j++ {
+			length += l.points[j].Sub(l.points[j-1]).Len()
+		}
+		if l.closed && len(l.points) > 1 {
+			length += l.points[0].Sub(l.points[len(l.points)-1]).Len()
+		}
+	}
+	if length/(total*scale)*float64(len(pattern)) > maxDashes {
+		return lines
+	}
+
+	var dashes []polyline
+	for _, l := range lines {
+		pts := l.points
+		if l.closed && len(pts) > 1 {
+			pts = append(pts[:len(pts):len(pts)], pts[0])
+		}
+
+		i := 0
+		left := pattern[0] * scale
+		on := true
+		var current []Point
+		if len(pts) > 0 {
+			current = []Point{pts[0]}
+		}
+		for j := 1; j < len(pts); j++ {
+			from, to := pts[j-1], pts[j]
+			segment := to.Sub(from)
+			length := segment.Len()
+			pos := 0.0
+			for length-pos > left {
+				pos += left
+				p := from.Add(segment.Mul(pos / length))
+				if on {
+					dashes = append(dashes, polyline{points: append(current, p)})
+					current = nil
+				} else {
+					current = []Point{p}
+				}
+				on = !on
+				i = (i + 1) % len(pattern)
+				left = pattern[i] * scale
+			}
+			left -= length - pos
+			if on {
+				current = append(current, to)
+			}
+		}
+		if on && len(current) > 1 {
+			dashes = append(dashes, polyline{points: current})
+		}
+	}
+	return dashes
+}
+
+// strokePolygons outlines polylines stroked with width, with round joins and caps
+// Every polygon winds the same way, so overlapping outlines add up instead of cancelling
+func strokePolygons(lines []polyline, width float64) [][]Point {
+	r := width / 2
+	var polys [][]Point
+	for _, l := range lines {
+		pts := l.points
+		if l.closed && len(pts) > 1 {
+			pts = append(pts[:len(pts):len(pts)], pts[0])
+		}
+		for i, p := range pts {
+			polys = append(polys, circlePolygon(p, r))
+			if i == 0 {
+				continue
+			}
+			q := pts[i-1]
+			d := p.Sub(q)
+			length := d.Len()
+			if length == 0 {
+				continue
+			}
+			n := Point{-d.Y, d.X}.Mul(r / length)
+			polys = append(polys, positive([]Point{q.Add(n), p.Add(n), p.Sub(n), q.Sub(n)}))
+		}
+	}
+	return polys
+}
+
+// circlePolygon approximates a circle with a polygon fine enough for its size
+func circlePolygon(c Point, r float64) []Point {
+	n := max(8, min(64, int(math.Ceil(r*2))))
+	pts := make([]Point, n)
+	for i := range pts {
+		sin, cos := math.Sincos(2 * math.Pi * float64(i) / float64(n))
+		pts[i] = Point{c.X + r*cos, c.Y + r*sin}
+	}
+	return pts
+}
+
+// positive returns the polygon wound with a positive signed area
+func positive(pts []Point) []Point {
+	area := 0.0
+	for i, p := range pts {
+		q := pts[(i+1)%len(pts)]
+		area += p.X*q.Y - q.X*p.Y
+	}
+	if area < 0 {
+		for i, j := 0, len(pts)-1; i < j; i, j = i+1, j-1 {
+			pts[i], pts[j] = pts[j], pts[i]
+		}
+	}
+	return pts
+}
+
+// fillPolygons paints the union of polygons onto img with anti-aliased edges
+// Coverage is accumulated from signed edge areas, as in font rasterizers, over the bounding box of the polygons only
+func fillPolygons(img *image.RGBA, polys [][]Point, c Color, opacity float64) {
+	alpha := c.Alpha() * opacity
+	if alpha <= 0 || len(polys) == 0 {
+		return
+	}
+
+	bounds := emptyRect
+	for _, poly := range polys {
+		for _, p := range poly {
+			bounds = bounds.Union(Rect{Min: p, Max: p})
+		}
+	}
+	size := img.Bounds().Size()
+	x0 := max(0, int(math.Floor(bounds.Min.X)))
+	y0 := max(0, int(math.Floor(bounds.Min.Y)))
+	x1 := min(size.X, int(math.Ceil(bounds.Max.X))+1)
+	y1 := min(size.Y, int(math.Ceil(bounds.Max.Y))+1)
+	if bounds.Empty() || x0 >= x1 || y0 >= y1 {
+		return
+	}
+
+	a := newAccumulator(x1-x0, y1-y0)
+	offset := Point{float64(x0), float64(y0)}
+	for _, poly := range polys {
+		for i, p := range poly {
+			a.line(p.Sub(offset), poly[(i+1)%len(poly)].Sub(offset))
+		}
+	}
+
+	for y := 0; y < a.h; y++ {
+		row := a.cells[y*a.stride : (y+1)*a.stride]
+		sum := float32(0)
+		for x := 0; x < a.w; x++ {
+			sum += row[x]
+			coverage := math.Min(1, math.Abs(float64(sum)))
+			if coverage > 0 {
+				blend(img, x0+x, y0+y, c, coverage*alpha)
+			}
+		}
+	}
+}
+
+// blend composites color c with the given alpha over the premultiplied pixel at (x, y)
+func blend(img *image.RGBA, x, y int, c Color, alpha float64) {
+	i := img.PixOffset(x, y)
+	px := img.Pix[i : i+4 : i+4]
+	inv := 1 - alpha
+	px[0] = uint8(float64(c.R)*alpha + float64(px[0])*inv + 0.5)
+	px[1] = uint8(float64(c.G)*alpha + float64(px[1])*inv + 0.5)
+	px[2] = uint8(float64(c.B)*alpha + float64(px[2])*inv + 0.5)
+	px[3] = uint8(255*alpha + float64(px[3])*inv + 0.5)
+}
+
+// accumulator collects the signed area each edge covers in each pixel cell
+type accumulator struct {
+	w, h   int
+	stride int
+	cells  []float32
+}
+
+// newAccumulator creates an accumulator for a w x h pixel region
+func newAccumulator(w, h int) *accumulator {
+	// Two spare cells per row take the area of edges touching the right border
+	stride := w + 2
+	return &accumulator{w: w, h: h, stride: stride, cells: make([]float32, stride*h)}
+}
+
+// line accumulates the area to the right of the edge p0-p1
+func (a *accumulator) line(p0, p1 Point) {
+	if p0.Y == p1.Y {
+		return
+	}
+	dir := 1.0
+	if p0.Y > p1.Y {
+		dir = -1
+		p0, p1 = p1, p0
+	}
+	if p1.Y <= 0 || p0.Y >= float64(a.h) {
+		return
+	}
+
+	dxdy := (p1.X - p0.X) / (p1.Y - p0.Y)
+	x := p0.X
+	top := p0.Y
+	if top < 0 {
+		x -= top * dxdy
+		top = 0
+	}
+	bottom := math.Min(p1.Y, float64(a.h))
+
+	for y := int(top); float64(y) < bottom; y++ {
+		row := a.cells[y*a.stride : (y+1)*a.stride]
+		dy := math.Min(float64(y+1), bottom) - math.Max(float64(y), top)
+		xNext := x + dxdy*dy
+		d := dy * dir
+
+		// Area left of the region still covers the pixels to its right, so clamp into it
+		xa := a.clampX(math.Min(x, xNext))
+		xb := a.clampX(math.Max(x, xNext))
+		xaFloor := math.Floor(xa)
+		xai := int(xaFloor)
+		xbCeil := math.Ceil(xb)
+		xbi := int(xbCeil)
+
+		if xbi <= xai+1 {
+			// The edge stays within one pixel in this row
+			mid := 0.5*(a.clampX(x)+a.clampX(xNext)) - xaFloor
+			row[xai] += float32(d - d*mid)
+			row[xai+1] += float32(d * mid)
+		} else {
+			s := 1 / (xb - xa)
+			xaFrac := xa - xaFloor
+			a0 := 0.5 * s * (1 - xaFrac) * (1 - xaFrac)
+			xbFrac := xb - xbCeil + 1
+			am := 0.5 * s * xbFrac * xbFrac
+			row[xai] += float32(d * a0)
+			if xbi == xai+2 {
+				row[xai+1] += float32(d * (1 - a0 - am))
+			} else {
+				a1 := s * (1.5 - xaFrac)
+				row[xai+1] += float32(d * (a1 - a0))
+				for xi := xai + 2; xi < xbi-1; xi++ {
+					row[xi] += float32(d * s)
+				}
+				a2 := a1 + float64(xbi-xai-3)*s
+				row[xbi-1] += float32(d * (1 - a2 - am))
+			}
+			row[xbi] += float32(d * am)
+		}
+
+		x = xNext
+	}
+}
+
+// clampX limits x to the accumulated region
+func (a *accumulator) clampX(x float64) float64 {
+	return math.Max(0, math.Min(float64(a.w), x))
+}
@@ -0,0 +1,345 @@
+package render
+
+import (
+	"hash/fnv"
+	"math"
+
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// Parameters of the hand-drawn style, as rough.js defaults them and Excalidraw sets them
+const (
+	roughMaxOffset      = 2
+	roughBowing         = 1
+	roughCurveStepCount = 9
+	roughHachureAngle   = -41
+
+	// roughCurveFitting is how closely sketched ellipses follow their radii; 1 is exact
+	roughCurveFitting = 0.95
+
+	// roughFillRoughnessGain roughens the outline of solid fills beyond the element's roughness
+	roughFillRoughnessGain = 0.8
+
+	// roughCartoonist is the roughness from which sketched lines also move their end points
+	roughCartoonist = 2
+)
+
+// rng is the pseudo-random generator of rough.js (Park-Miller), seeded per element so
+// the same element is always sketched the same way
+type rng struct {
+	seed int32
+}
+
+// newRNG creates a generator for an element, seeded by its seed or, without one, by its ID
+func newRNG(el *drawing.Element) *rng {
+	if el.Seed != nil && int32(*el.Seed) != 0 {
+		return &rng{seed: int32(*el.Seed)}
+	}
+	h := fnv.New32a()
+	h.Write([]byte(el.ID))
+	return &rng{seed: int32(h.Sum32()&math.MaxInt32) | 1}
+}
+
+// next returns a number in [0, 1)
+func (r *rng) next() float64 {
+	r.seed = int32(uint32(48271) * uint32(r.seed))
+	return float64(r.seed&math.MaxInt32) / (1 << 31)
+}
+
+// sketcher draws geometry in the hand-drawn style of rough.js, which Excalidraw renders with
+// A roughness of 0 draws clean geometry
+type sketcher struct {
+	rng              *rng
+	roughness        float64
+	singleStroke     bool
+	preserveVertices bool
+	curveFitting     float64
+}
+
+// newSketcher creates a sketcher with the roughness and stroke style of an element
+func newSketcher(el *drawing.Element) *sketcher {
+	roughness := roughnessOf(el)
+	return &sketcher{
+		rng:              newRNG(el),
+		roughness:        roughness,
+		singleStroke:     strokeStyle(el) != "solid",
+		preserveVertices: roughness < roughCartoonist,
+		curveFitting:     roughCurveFitting,
+	}
+}
+
+// roughnessOf returns the roughness of an element, reduced for small shapes as Excalidraw does
+// Elements without a roughness are drawn clean
+func roughnessOf(el *drawing.Element) float64 {
+	roughness := valueOr(el.Roughness, 0)
+	if roughness <= 0 {
+		return 0
+	}
+
+	w, h := size(el)
+	maxSize, minSize := math.Max(w, h), math.Min(w, h)
+	switch {
+	case el.Type == "line" || el.Type == "arrow",
+		minSize >= 20 && maxSize >= 50,
+		minSize >= 15,
+		el.Roundness != nil && minSize >= maxSize*0.25:
+		return roughness
+	case maxSize < 10:
+		return math.Min(roughness/3, 2.5)
+	default:
+		return math.Min(roughness/2, 2.5)
+	}
+}
+
+// offset returns a random offset in [min, max), scaled by the roughness and gain
+func (s *sketcher) offset(min, max, gain float64) float64 {
+	return s.roughness * gain * (s.rng.next()*(max-min) + min)
+}
+
+// jitter returns a random offset in [-x, x), scaled by the roughness
+func (s *sketcher) jitter(x float64) float64 {
+	return s.offset(-x, x, 1)
+}
+
+// jitterPoint moves p by a random offset of up to x in each direction
+func (s *sketcher) jitterPoint(p Point, x float64) Point {
+	return Point{p.X + s.jitter(x), p.Y + s.jitter(x)}
+}
+
+// line adds a sketched line from p0 to p1 to path: a cubic curve bowing away from the straight line
+// The overlay pass stays closer to the line than the first one
+func (s *sketcher) line(path *Path, p0, p1 Point, overlay bool) {
+	if s.roughness == 0 {
+		path.MoveTo(p0)
+		path.LineTo(p1)
+		return
+	}
+
+	length := p1.Sub(p0).Len()
+	gain := 1.0
+	switch {
+	case length > 500:
+		gain = 0.4
+	case length >= 200:
+		gain = -0.0016668*length + 1.233334
+	}
+
+	offset := float64(roughMaxOffset)
+	if offset*offset*100 > length*length {
+		offset = length / 10
+	}
+	if overlay {
+		offset /= 2
+	}
+	random := func() float64 { return s.offset(-offset, offset, gain) }
+
+	divergePoint := 0.2 + s.rng.next()*0.2
+	bow := Point{
+		s.offset(-1, 1, gain) * roughBowing * roughMaxOffset * (p1.Y - p0.Y) / 200,
+		s.offset(-1, 1, gain) * roughBowing * roughMaxOffset * (p0.X - p1.X) / 200,
+	}
+	d := p1.Sub(p0)
+
+	start, end := p0, p1
+	if !s.preserveVertices {
+		start = Point{p0.X + random(), p0.Y + random()}
+		end = Point{p1.X + random(), p1.Y + random()}
+	}
+	path.MoveTo(start)
+	path.CubicTo(
+		Point{bow.X + p0.X + d.X*divergePoint + random(), bow.Y + p0.Y + d.Y*divergePoint + random()},
+		Point{bow.X + p0.X + 2*d.X*divergePoint + random(), bow.Y + p0.Y + 2*d.Y*divergePoint + random()},
+		end,
+	)
+}
+
+// doubleLine adds a line drawn twice, as a pen retracing it, or once in the single stroke mode
+func (s *sketcher) doubleLine(path *Path, p0, p1 Point) {
+	s.line(path, p0, p1, false)
+	if !s.singleStroke && s.roughness > 0 {
+		s.line(path, p0, p1, true)
+	}
+}
+
+// polyline returns the sketch of the lines through pts, closed back to the first point when closed
+func (s *sketcher) polyline(pts []Point, closed bool) Path {
+	if s.roughness == 0 {
+		path := polylinePath(pts)
+		if closed && len(pts) > 2 {
+			path.Close()
+		}
+		return path
+	}
+
+	var path Path
+	for i := 0; i+1 < len(pts); i++ {
+		s.doubleLine(&path, pts[i], pts[i+1])
+	}
+	if closed && len(pts) > 2 {
+		s.doubleLine(&path, pts[len(pts)-1], pts[0])
+	}
+	return path
+}
+
+// curve returns the sketch of a smooth curve through pts
+func (s *sketcher) curve(pts []Point) Path {
+	if s.roughness == 0 {
+		return curvePath(pts)
+	}
+
+	path := s.curveWithOffset(pts, 1+s.roughness*0.2)
+	if !s.singleStroke {
+		path = append(path, s.curveWithOffset(pts, 1.5*(1+s.roughness*0.22))...)
+	}
+	return path
+}
+
+// curveWithOffset returns a curve through pts moved by random offsets of up to offset
+func (s *sketcher) curveWithOffset(pts []Point, offset float64) Path {
+	if len(pts) == 0 {
+		return nil
+	}
+
+	// The first and last points are repeated, so the spline passes through them
+	moved := []Point{s.jitterPoint(pts[0], offset), s.jitterPoint(pts[0], offset)}
+	for i := 1; i < len(pts); i++ {
+		moved = append(moved, s.jitterPoint(pts[i], offset))
+		if i == len(pts)-1 {
+			moved = append(moved, s.jitterPoint(pts[i], offset))
+		}
+	}
+	return s.spline(moved)
+}
+
+// spline returns a Catmull-Rom spline through pts[1:len-1], using the outer points as tangents
+func (s *sketcher) spline(pts []Point) Path {
+	var path Path
+	switch {
+	case len(pts) > 3:
+		path.MoveTo(pts[1])
+		for i := 1; i+2 < len(pts); i++ {
+			c1 := pts[i].Add(pts[i+1].Sub(pts[i-1]).Mul(1.0 / 6))
+			c2 := pts[i+1].Add(pts[i].Sub(pts[i+2]).Mul(1.0 / 6))
+			path.CubicTo(c1, c2, pts[i+1])
+		}
+	case len(pts) == 3:
+		path.MoveTo(pts[1])
+		path.CubicTo(pts[1], pts[2], pts[2])
+	case len(pts) == 2:
+		s.doubleLine(&path, pts[0], pts[1])
+	}
+	return path
+}
+
+// ellipse returns the sketch of an ellipse centered on c
+func (s *sketcher) ellipse(c Point, rx, ry float64) Path {
+	if s.roughness == 0 {
+		return ellipsePath(2*rx, 2*ry).Translate(c.Sub(Point{rx, ry}))
+	}
+
+	perimeter := math.Sqrt(2 * math.Pi * math.Sqrt((rx*rx+ry*ry)/2))
+	steps := math.Ceil(math.Max(roughCurveStepCount, roughCurveStepCount/math.Sqrt(200)*perimeter))
+	increment := 2 * math.Pi / steps
+
+	fit := 1 - s.curveFitting
+	rx += s.jitter(rx * fit)
+	ry += s.jitter(ry * fit)
+
+	overlap := increment * s.offset(0.1, s.offset(0.4, 1, 1), 1)
+	path := s.spline(s.ellipsePoints(c, rx, ry, increment, 1, overlap))
+	if !s.singleStroke {
+		path = append(path, s.spline(s.ellipsePoints(c, rx, ry, increment, 1.5, 0))...)
+	}
+	return path
+}
+
+// ellipsePoints returns points around an ellipse moved by random offsets, overlapping the start
+// by overlap so the pen visibly closes the loop
+func (s *sketcher) ellipsePoints(c Point, rx, ry, increment, offset, overlap float64) []Point {
+	at := func(angle, f float64) Point {
+		return Point{s.jitter(offset) + c.X + f*rx*math.Cos(angle), s.jitter(offset) + c.Y + f*ry*math.Sin(angle)}
+	}
+
+	start := s.jitter(0.5) - math.Pi/2
+	pts := []Point{at(start-increment, 0.9)}
+	for angle := start; angle < 2*math.Pi+start-0.01; angle += increment {
+		pts = append(pts, at(angle, 1))
+	}
+	return append(pts,
+		at(start+2*math.Pi+overlap*0.5, 1),
+		at(start+overlap, 0.98),
+		at(start+overlap*0.5, 0.9),
+	)
+}
+
+// path returns the sketch of a path: lines are sketched as lines and curves are redrawn with moved controls
+func (s *sketcher) path(p Path) Path {
+	if s.roughness == 0 {
+		return p
+	}
+
+	var out Path
+	var start, current Point
+	for _, seg := range p {
+		switch seg.Op {
+		case MoveTo:
+			start, current = seg.Points[0], seg.Points[0]
+		case LineTo:
+			s.doubleLine(&out, current, seg.Points[0])
+			current = seg.Points[0]
+		case CubicTo:
+			s.bezier(&out, current, seg.Points[0], seg.Points[1], seg.Points[2])
+			current = seg.Points[2]
+		case ClosePath:
+			if current != start {
+				s.doubleLine(&out, current, start)
+			}
+			current = start
+		}
+	}
+	return out
+}
+
+// bezier adds a sketched cubic curve from p0 to end, drawn twice unless in the single stroke mode
+func (s *sketcher) bezier(path *Path, p0, c1, c2, end Point) {
+	offsets := []float64{roughMaxOffset, roughMaxOffset + 0.3}
+	passes := 2
+	if s.singleStroke {
+		passes = 1
+	}
+
+	for i := 0; i < passes; i++ {
+		if i == 0 || s.preserveVertices {
+			path.MoveTo(p0)
+		} else {
+			path.MoveTo(s.jitterPoint(p0, offsets[0]))
+		}
+		to := end
+		if !s.preserveVertices {
+			to = s.jitterPoint(end, offsets[i])
+		}
+		path.CubicTo(s.jitterPoint(c1, offsets[i]), s.jitterPoint(c2, offsets[i]), to)
+	}
+}
+
+// fillPath returns the area of a closed path for a solid fill: the path sketched once, as a single contour
+func (s *sketcher) fillPath(p Path) Path {
+	if s.roughness == 0 {
+		return p
+	}
+
+	fill := *s
+	fill.singleStroke = true
+	fill.roughness += roughFillRoughnessGain
+
+	var out Path
+	for _, seg := range fill.path(p) {
+		// Each sketched segment starts where the previous one ended, so the moves can be dropped
+		if seg.Op == MoveTo && len(out) > 0 {
+			continue
+		}
+		out = append(out, seg)
+	}
+	out.Close()
+	return out
+}
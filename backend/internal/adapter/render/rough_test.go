@@ -0,0 +1,186 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+func TestRoughRendering(t *testing.T) {
+	render := func(t *testing.T, elements string) string {
+		t.Helper()
+		out, err := NewRenderer().RenderSVG(context.Background(), parseScene(t, `{"elements": [`+elements+`]}`), drawing.ExportOptions{Padding: 10})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return string(out)
+	}
+
+	rect := func(extra string) string {
+		return `{"id": "r", "type": "rectangle", "x": 0, "y": 0, "width": 100, "height": 60, "strokeWidth": 2, "backgroundColor": "#a5d8ff"` + extra + `}`
+	}
+
+	t.Run("same seed renders identically", func(t *testing.T) {
+		first := render(t, rect(`, "roughness": 1, "seed": 42, "fillStyle": "hachure"`))
+		second := render(t, rect(`, "roughness": 1, "seed": 42, "fillStyle": "hachure"`))
+		if first != second {
+			t.Error("expected identical output for the same seed")
+		}
+		if other := render(t, rect(`, "roughness": 1, "seed": 43, "fillStyle": "hachure"`)); other == first {
+			t.Error("expected a different seed to change the sketch")
+		}
+	})
+
+	t.Run("sketched lines bow away from the exact outline", func(t *testing.T) {
+		svg := render(t, rect(`, "roughness": 1, "seed": 42`))
+		if strings.Contains(svg, `d="M0 0 L100 0 L100 60 L0 60 Z"`) {
+			t.Error("expected a sketched outline")
+		}
+		if !strings.Contains(svg, " C") {
+			t.Error("expected curves in the sketched outline")
+		}
+		// The export is sized by the exact geometry, not by the sketch
+		if !strings.Contains(svg, `width="120" height="80"`) {
+			t.Error("expected a 120x80 image")
+		}
+	})
+
+	t.Run("roughness 0 keeps the exact outline", func(t *testing.T) {
+		svg := render(t, rect(`, "roughness": 0, "seed": 42`))
+		if !strings.Contains(svg, `d="M0 0 L100 0 L100 60 L0 60 Z"`) {
+			t.Errorf("expected the exact outline, got:\n%s", svg)
+		}
+	})
+
+	t.Run("hachure fill strokes lines in the background color", func(t *testing.T) {
+		svg := render(t, rect(`, "roughness": 0, "fillStyle": "hachure"`))
+		if !strings.Contains(svg, `fill="none" stroke="#a5d8ff" stroke-width="1"`) {
+			t.Errorf("expected fill lines of half the stroke width, got:\n%s", svg)
+		}
+		if strings.Contains(svg, `fill="#a5d8ff"`) {
+			t.Error("expected no solid fill")
+		}
+	})
+
+	t.Run("cross-hatch has more lines than hachure", func(t *testing.T) {
+		hachure := strings.Count(render(t, rect(`, "roughness": 0, "fillStyle": "hachure"`)), "M")
+		crossHatch := strings.Count(render(t, rect(`, "roughness": 0, "fillStyle": "cross-hatch"`)), "M")
+		zigzag := strings.Count(render(t, rect(`, "roughness": 0, "fillStyle": "zigzag"`)), "M")
+		if crossHatch <= hachure || zigzag <= hachure {
+			t.Errorf("expected cross-hatch (%d) and zigzag (%d) to draw more lines than hachure (%d)", crossHatch, zigzag, hachure)
+		}
+	})
+
+	t.Run("solid fill", func(t *testing.T) {
+		svg := render(t, rect(`, "roughness": 1, "seed": 42, "fillStyle": "solid"`))
+		if !strings.Contains(svg, `fill="#a5d8ff"`) {
+			t.Error("expected a solid fill")
+		}
+	})
+
+	t.Run("dashed and dotted strokes", func(t *testing.T) {
+		dashed := render(t, rect(`, "roughness": 1, "seed": 42, "strokeStyle": "dashed"`))
+		if !strings.Contains(dashed, `stroke-width="2.5" stroke-linecap="round" stroke-linejoin="round" stroke-dasharray="8 10"`) {
+			t.Errorf("expected a widened dashed stroke, got:\n%s", dashed)
+		}
+		dotted := render(t, rect(`, "strokeStyle": "dotted"`))
+		if !strings.Contains(dotted, `stroke-dasharray="1.5 8"`) {
+			t.Errorf("expected a dotted stroke, got:\n%s", dotted)
+		}
+	})
+
+	t.Run("arrowheads are not dashed", func(t *testing.T) {
+		svg := render(t, `{"id": "a", "type": "arrow", "x": 0, "y": 0, "points": [[0, 0], [100, 0]], "strokeStyle": "dashed", "endArrowhead": "triangle"}`)
+		if strings.Count(svg, "stroke-dasharray") != 1 {
+			t.Errorf("expected only the arrow body to be dashed, got:\n%s", svg)
+		}
+	})
+}
+
+func TestDashedRaster(t *testing.T) {
+	scene := parseScene(t, `{"elements": [
+		{"id": "l", "type": "line", "x": 0, "y": 0, "points": [[0, 0], [100, 0]], "strokeWidth": 2, "strokeStyle": "dashed"}
+	], "appState": {"viewBackgroundColor": "#ffffff"}}`)
+
+	data, err := NewRenderer().RenderPNG(context.Background(), scene, drawing.ExportOptions{Padding: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	img := decodePNG(t, data)
+
+	// Dashes of 8 alternate with gaps of 10 from the start of the line at x 10
+	if got := rgbAt(img, 14, 10); got == [3]uint8{255, 255, 255} {
+		t.Error("expected a dash at the start of the line")
+	}
+	if got := rgbAt(img, 23, 10); got != [3]uint8{255, 255, 255} {
+		t.Errorf("expected a gap after the first dash, got %v", got)
+	}
+}
+
+func TestHugeElementWork(t *testing.T) {
+	square := []Point{{0, 0}, {1e6, 0}, {1e6, 1e6}, {0, 1e6}}
+
+	// The gap widens so a huge polygon keeps to the line budget
+	if n := len(hachureLines(square, -41, 4)); n == 0 || n > maxHachureLines {
+		t.Errorf("expected between 1 and %d hachure lines, got %d", maxHachureLines, n)
+	}
+
+	// A stroke that would break into too many dashes is drawn solid
+	line := []polyline{{points: []Point{{0, 0}, {1e6, 0}}}}
+	if dashes := dashLines(line, []float64{8, 10}, 1); len(dashes) != 1 {
+		t.Errorf("expected the line to stay solid, got %d dashes", len(dashes))
+	}
+
+	scene := parseScene(t, `{"elements": [
+		{"id": "r", "type": "rectangle", "x": 0, "y": 0, "width": 1000000, "height": 1000000,
+		 "backgroundColor": "#ff0000", "fillStyle": "cross-hatch", "strokeStyle": "dashed"}
+	]}`)
+	if _, err := NewRenderer().RenderPNG(context.Background(), scene, drawing.ThumbnailOptions()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRenderCanceled(t *testing.T) {
+	scene := parseScene(t, `{"elements": [{"id": "r", "type": "rectangle", "x": 0, "y": 0, "width": 100, "height": 50}]}`)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r := NewRenderer()
+	if _, err := r.RenderPNG(ctx, scene, drawing.ExportOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("PNG: expected %v, got %v", context.Canceled, err)
+	}
+	if _, err := r.RenderSVG(ctx, scene, drawing.ExportOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("SVG: expected %v, got %v", context.Canceled, err)
+	}
+	if _, err := r.RenderPDF(ctx, scene, drawing.ExportOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("PDF: expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestRNGDeterministic(t *testing.T) {
+	seed := int64(12345)
+	el := &drawing.Element{ID: "a", Seed: &seed}
+
+	var first, second bytes.Buffer
+	a, b := newRNG(el), newRNG(el)
+	for i := 0; i < 100; i++ {
+		x, y := a.next(), b.next()
+		if x < 0 || x >= 1 {
+			t.Fatalf("expected a number in [0, 1), got %v", x)
+		}
+		first.WriteByte(byte(x * 256))
+		second.WriteByte(byte(y * 256))
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Error("expected the same sequence for the same seed")
+	}
+
+	// Elements without a seed are seeded by their ID
+	if newRNG(&drawing.Element{ID: "a"}).next() == newRNG(&drawing.Element{ID: "b"}).next() {
+		t.Error("expected different IDs to seed different sequences")
+	}
+}
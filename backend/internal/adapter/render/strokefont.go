@@ -0,0 +1,253 @@
+package render
+
+import (
+	"math"
+	"strings"
+	"sync"
+)
+
+// The stroke font draws glyphs as pen strokes on a grid, so raster exports can set text
+// without shipping font files. Glyphs are 4 units wide (m and w are 6), capitals span
+// y 0-6 with the baseline at 6, lowercase letters start at y 2 and descenders reach y 8
+const (
+	strokeFontCapHeight = 6
+	strokeFontBaseline  = 6
+
+	// strokeFontSpacing is the gap between glyphs, in grid units
+	strokeFontSpacing = 1.6
+
+	// strokeFontSpaceWidth is the advance of a space, in grid units
+	strokeFontSpaceWidth = 3
+
+	// strokeFontCapRatio is the cap height relative to the font size
+	strokeFontCapRatio = 0.65
+
+	// strokeFontWeight is the pen width relative to the font size
+	strokeFontWeight = 0.07
+)
+
+// strokeGlyphs encodes each glyph as strokes separated by spaces; a stroke is a sequence of
+// "xy" digit pairs joined by lines, and a single pair draws a dot
+var strokeGlyphs = map[rune]string{
+	'A': "062046 1333",
+	'B': "0006 003041423303 3344453606",
+	'C': "4130100105163645",
+	'D': "0006 003041453606",
+	'E': "40000646 0333",
+	'F': "400006 0333",
+	'G': "41301001051636454323",
+	'H': "0006 4046 0343",
+	'I': "1030 2026 1636",
+	'J': "4045361605",
+	'K': "0006 400346",
+	'L': "000646",
+	'M': "0600234046",
+	'N': "06004640",
+	'O': "103041453616050110",
+	'P': "06003041423303",
+	'Q': "103041453616050110 3446",
+	'R': "06003041423303 3346",
+	'S': "413010010213334445361605",
+	'T': "0040 2026",
+	'U': "000516364540",
+	'V': "002640",
+	'W': "0016233640",
+	'X': "0046 4006",
+	'Y': "002340 2326",
+	'Z': "00400646",
+
+	'a': "12324346 441405163645",
+	'b': "0006 0312324345361605",
+	'c': "4332120305163645",
+	'd': "4046 4332120305163645",
+	'e': "04444332120305163645",
+	'f': "41302126 0232",
+	'g': "4247381807 4332120304153544",
+	'h': "0006 0312324346",
+	'i': "2226 20",
+	'j': "3237281807 30",
+	'k': "0006 320436",
+	'l': "101526",
+	'm': "0206 0312223336 3342526366",
+	'n': "0206 0312324346",
+	'o': "123243453616050312",
+	'p': "0208 0312324345361605",
+	'q': "4248 4332120305163645",
+	'r': "0206 04132232",
+	's': "43321203143445361605",
+	't': "10152636 0232",
+	'u': "0205163645 4246",
+	'v': "022642",
+	'w': "0216233642",
+	'x': "0246 4206",
+	'y': "0225 4208",
+	'z': "02420646",
+
+	'0': "103041453616050110",
+	'1': "112026 1636",
+	'2': "01103041420646",
+	'3': "0110304142334445361605 1333",
+	'4': "300444 3036",
+	'5': "400003334445361605",
+	'6': "413010010516364544331304",
+	'7': "004016",
+	'8': "130201103041423313 1304051636454433",
+	'9': "423313020110304145361605",
+
+	'!':  "2024 26",
+	'"':  "1011 3031",
+	'#':  "1016 3036 0242 0444",
+	'$':  "413010010213334445361605 2027",
+	'%':  "00 46 4006",
+	'&':  "4602011020210405162644",
+	'\'': "2021",
+	'(':  "30111536",
+	')':  "10313516",
+	'*':  "2024 0143 0341",
+	'+':  "2125 0343",
+	',':  "2617",
+	'-':  "0343",
+	'.':  "26",
+	'/':  "4006",
+	':':  "23 26",
+	';':  "23 2617",
+	'<':  "410345",
+	'=':  "0242 0444",
+	'>':  "014305",
+	'?':  "0110304142332324 26",
+	'[':  "30101636",
+	'\\': "0046",
+	']':  "10303616",
+	'^':  "022042",
+	'_':  "0747",
+	'`':  "1021",
+	'{':  "30212213242536",
+	'|':  "2028",
+	'}':  "10212233242516",
+	'~':  "0413233241",
+}
+
+// strokeGlyph is a parsed glyph: its strokes in grid units and its advance
+type strokeGlyph struct {
+	strokes [][]Point
+	advance float64
+}
+
+var (
+	parsedGlyphs     map[rune]strokeGlyph
+	parsedGlyphsOnce sync.Once
+)
+
+// glyph returns the parsed glyph of r, and false when the font has none
+func glyph(r rune) (strokeGlyph, bool) {
+	parsedGlyphsOnce.Do(func() {
+		parsedGlyphs = make(map[rune]strokeGlyph, len(strokeGlyphs))
+		for r, spec := range strokeGlyphs {
+			parsedGlyphs[r] = parseGlyph(spec)
+		}
+	})
+	g, ok := parsedGlyphs[r]
+	return g, ok
+}
+
+// parseGlyph decodes a glyph specification, moving its leftmost point to x 0
+func parseGlyph(spec string) strokeGlyph {
+	var g strokeGlyph
+	left, right := math.Inf(1), 0.0
+	for _, stroke := range strings.Fields(spec) {
+		var pts []Point
+		for i := 0; i+1 < len(stroke); i += 2 {
+			p := Point{float64(stroke[i] - '0'), float64(stroke[i+1] - '0')}
+			left, right = math.Min(left, p.X), math.Max(right, p.X)
+			pts = append(pts, p)
+		}
+		g.strokes = append(g.strokes, pts)
+	}
+
+	for _, stroke := range g.strokes {
+		for i := range stroke {
+			stroke[i].X -= left
+		}
+	}
+	g.advance = right - left + strokeFontSpacing
+	return g
+}
+
+// missingGlyph is drawn for characters the font has no glyph for
+var missingGlyph = strokeGlyph{
+	strokes: [][]Point{{{0, 1}, {3, 1}, {3, 6}, {0, 6}, {0, 1}}},
+	advance: 3 + strokeFontSpacing,
+}
+
+// strokeTextLine returns the strokes of a line of text in grid units, starting at x 0, and its width
+func strokeTextLine(line string) ([][]Point, float64) {
+	var strokes [][]Point
+	x := 0.0
+	for _, r := range line {
+		switch {
+		case r == ' ' || r == '\t':
+			x += strokeFontSpaceWidth
+			continue
+		case r < ' ':
+			continue
+		}
+
+		g, ok := glyph(r)
+		if !ok {
+			g = missingGlyph
+		}
+		for _, stroke := range g.strokes {
+			moved := make([]Point, len(stroke))
+			for i, p := range stroke {
+				moved[i] = Point{p.X + x, p.Y}
+			}
+			strokes = append(strokes, moved)
+		}
+		x += g.advance
+	}
+
+	// The spacing after the last glyph is not part of the line
+	if x > 0 {
+		x -= strokeFontSpacing
+	}
+	return strokes, x
+}
+
+// textStrokes lays out a text block with the stroke font, returning its strokes in the
+// block's coordinates and the pen width. Lines wider than the block are narrowed to fit
+func textStrokes(t *Text) ([]polyline, float64) {
+	unit := t.FontSize * strokeFontCapRatio / strokeFontCapHeight
+
+	var lines []polyline
+	for i, line := range t.Lines {
+		strokes, width := strokeTextLine(line)
+		if len(strokes) == 0 {
+			continue
+		}
+
+		sx := unit
+		if width*unit > t.Width && t.Width > 0 {
+			sx = t.Width / width
+		}
+		lineWidth := width * sx
+
+		left := 0.0
+		switch t.Align {
+		case AlignCenter:
+			left = t.Anchor() - lineWidth/2
+		case AlignRight:
+			left = t.Anchor() - lineWidth
+		}
+		baseline := t.Baseline(i)
+
+		for _, stroke := range strokes {
+			pts := make([]Point, len(stroke))
+			for j, p := range stroke {
+				pts[j] = Point{left + p.X*sx, baseline + (p.Y-strokeFontBaseline)*unit}
+			}
+			lines = append(lines, polyline{points: pts})
+		}
+	}
+
+	return lines, t.FontSize * strokeFontWeight
+}
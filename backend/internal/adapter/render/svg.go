@@ -0,0 +1,187 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// Renderer renders scenes in pure Go, without a browser
+type Renderer struct{}
+
+// NewRenderer creates a new scene renderer
+func NewRenderer() *Renderer {
+	return &Renderer{}
+}
+
+// RenderSVG renders the scene as an SVG document
+func (r *Renderer) RenderSVG(ctx context.Context, scene *drawing.Scene, opts drawing.ExportOptions) ([]byte, error) {
+	doc, err := Layout(ctx, scene, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.EmbeddedScene != nil {
+		doc.Metadata = drawing.EmbedSceneSVG(opts.EmbeddedScene)
+	}
+	return doc.SVG(), nil
+}
+
+// SVG writes the document as a standalone SVG image
+func (d *Document) SVG() []byte {
+	var b bytes.Buffer
+	w, h := num(d.Width), num(d.Height)
+
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" version="1.1" width="%s" height="%s" viewBox="0 0 %s %s">`+"\n", w, h, w, h)
+	b.WriteString("<!-- svg-source:excalidraw -->\n")
+	if d.Metadata != "" {
+		b.WriteString(d.Metadata + "\n")
+	}
+
+	if len(d.Fonts) > 0 {
+		b.WriteString("<defs><style>")
+		for _, f := range d.Fonts {
+			if f.URL != "" {
+				fmt.Fprintf(&b, `@font-face { font-family: "%s"; src: url("%s"); }`, f.Family, f.URL)
+			}
+		}
+		b.WriteString("</style></defs>\n")
+	}
+
+	if d.Background.Visible() {
+		fmt.Fprintf(&b, `<rect x="0" y="0" width="%s" height="%s"%s/>`+"\n", w, h, paint("fill", d.Background))
+	}
+
+	for _, item := range d.Items {
+		switch it := item.(type) {
+		case *Shape:
+			writeSVGShape(&b, it)
+		case *Text:
+			writeSVGText(&b, it)
+		case *Image:
+			writeSVGImage(&b, it)
+		}
+	}
+
+	b.WriteString("</svg>\n")
+	return b.Bytes()
+}
+
+// writeSVGShape writes a shape as a path element
+func writeSVGShape(b *bytes.Buffer, s *Shape) {
+	if !s.Fill.Visible() && !s.Stroke.Visible() {
+		return
+	}
+
+	fmt.Fprintf(b, `<path d="%s"%s%s`, pathData(s.Path), transformAttr(s.Transform), opacityAttr(s.Opacity))
+	if s.Fill.Visible() {
+		b.WriteString(paint("fill", s.Fill))
+	} else {
+		b.WriteString(` fill="none"`)
+	}
+	if s.Stroke.Visible() && s.StrokeWidth > 0 {
+		fmt.Fprintf(b, `%s stroke-width="%s" stroke-linecap="round" stroke-linejoin="round"`, paint("stroke", s.Stroke), num(s.StrokeWidth))
+		if len(s.Dash) > 0 {
+			dash := make([]string, len(s.Dash))
+			for i, d := range s.Dash {
+				dash[i] = num(d)
+			}
+			fmt.Fprintf(b, ` stroke-dasharray="%s"`, strings.Join(dash, " "))
+		}
+	}
+	b.WriteString("/>\n")
+}
+
+// writeSVGText writes a text block as a group of text elements, one per line
+func writeSVGText(b *bytes.Buffer, t *Text) {
+	anchor := map[TextAlign]string{AlignLeft: "start", AlignCenter: "middle", AlignRight: "end"}[t.Align]
+	family := fmt.Sprintf("%s, Segoe UI Emoji, %s", t.Font.Family, t.Font.Generic)
+
+	fmt.Fprintf(b, `<g%s%s>`+"\n", transformAttr(t.Transform), opacityAttr(t.Opacity))
+	for i, line := range t.Lines {
+		fmt.Fprintf(b, `<text x="%s" y="%s" font-family="%s" font-size="%spx"%s text-anchor="%s" style="white-space: pre;" xml:space="preserve" direction="ltr">`,
+			num(t.Anchor()), num(t.Baseline(i)), escape(family), num(t.FontSize), paint("fill", t.Color), anchor)
+		b.WriteString(escape(line))
+		b.WriteString("</text>\n")
+	}
+	b.WriteString("</g>\n")
+}
+
+// writeSVGImage writes an embedded image
+func writeSVGImage(b *bytes.Buffer, i *Image) {
+	fmt.Fprintf(b, `<image width="%s" height="%s" preserveAspectRatio="none" xlink:href="%s"%s%s/>`+"\n",
+		num(i.Width), num(i.Height), escape(i.DataURL), transformAttr(i.Transform), opacityAttr(i.Opacity))
+}
+
+// pathData encodes a path as the d attribute of an SVG path
+func pathData(p Path) string {
+	var b strings.Builder
+	for _, s := range p {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		switch s.Op {
+		case MoveTo:
+			fmt.Fprintf(&b, "M%s %s", num(s.Points[0].X), num(s.Points[0].Y))
+		case LineTo:
+			fmt.Fprintf(&b, "L%s %s", num(s.Points[0].X), num(s.Points[0].Y))
+		case CubicTo:
+			fmt.Fprintf(&b, "C%s %s %s %s %s %s",
+				num(s.Points[0].X), num(s.Points[0].Y),
+				num(s.Points[1].X), num(s.Points[1].Y),
+				num(s.Points[2].X), num(s.Points[2].Y))
+		case ClosePath:
+			b.WriteByte('Z')
+		}
+	}
+	return b.String()
+}
+
+// transformAttr encodes a transform attribute, as a translation when the transform is one
+func transformAttr(m Matrix) string {
+	if m == Identity {
+		return ""
+	}
+	if m[0] == 1 && m[1] == 0 && m[2] == 0 && m[3] == 1 {
+		return fmt.Sprintf(` transform="translate(%s %s)"`, num(m[4]), num(m[5]))
+	}
+	return fmt.Sprintf(` transform="matrix(%s %s %s %s %s %s)"`, num(m[0]), num(m[1]), num(m[2]), num(m[3]), num(m[4]), num(m[5]))
+}
+
+// opacityAttr encodes an opacity attribute, omitted when fully opaque
+func opacityAttr(opacity float64) string {
+	if opacity >= 1 {
+		return ""
+	}
+	return fmt.Sprintf(` opacity="%s"`, num(opacity))
+}
+
+// paint encodes a fill or stroke color attribute, with its opacity when translucent
+func paint(attr string, c Color) string {
+	if c.A == 255 {
+		return fmt.Sprintf(` %s="%s"`, attr, c.Hex())
+	}
+	return fmt.Sprintf(` %s="%s" %s-opacity="%s"`, attr, c.Hex(), attr, num(c.Alpha()))
+}
+
+// num formats a coordinate with at most two decimals
+func num(v float64) string {
+	v = math.Round(v*100) / 100
+	if v == 0 {
+		v = 0 // drop the sign of negative zero
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// escape escapes text for use in XML content and attribute values
+func escape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,187 @@
+package render
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// parseScene parses a scene fixture, failing the test when it is invalid
+func parseScene(t *testing.T, raw string) *drawing.Scene {
+	t.Helper()
+	scene, err := drawing.ParseScene([]byte(raw))
+	if err != nil {
+		t.Fatalf("invalid scene fixture: %v", err)
+	}
+	return scene
+}
+
+func TestRenderSVG(t *testing.T) {
+	tests := []struct {
+		name     string
+		scene    string
+		opts     drawing.ExportOptions
+		contains []string
+		excludes []string
+	}{
+		{
+			name: "shapes with fill and rounded corners",
+			scene: `{"elements": [
+				{"id": "r", "type": "rectangle", "x": 0, "y": 0, "width": 100, "height": 40, "backgroundColor": "#ffc9c9", "roundness": {"type": 3}},
+				{"id": "e", "type": "ellipse", "x": 120, "y": 0, "width": 40, "height": 40},
+				{"id": "d", "type": "diamond", "x": 180, "y": 0, "width": 40, "height": 40}
+			]}`,
+			opts: drawing.ExportOptions{Padding: 10},
+			contains: []string{
+				`width="240" height="60"`,
+				`<rect x="0" y="0" width="240" height="60" fill="#ffffff"/>`,
+				`d="M0 10 C0 4.48 4.48 0 10 0 L90 0`,
+				`fill="#ffc9c9"`,
+				`d="M20 0 L40 20 L20 40 L0 20 Z" transform="translate(190 10)"`,
+			},
+		},
+		{
+			name: "arrow with arrowheads",
+			scene: `{"elements": [
+				{"id": "a", "type": "arrow", "x": 0, "y": 0, "points": [[0, 0], [100, 0]], "startArrowhead": "bar", "endArrowhead": "arrow"}
+			]}`,
+			contains: []string{
+				`d="M0 0 L100 0"`,
+				`d="M0 -7.5 L0 7.5"`,
+				`d="M70 10.92 L100 0 L70 -10.92"`,
+			},
+		},
+		{
+			name: "legacy arrow without endArrowhead",
+			scene: `{"elements": [
+				{"id": "a", "type": "arrow", "x": 0, "y": 0, "points": [[0, 0], [100, 0]]}
+			]}`,
+			contains: []string{`d="M70 10.92 L100 0 L70 -10.92"`},
+		},
+		{
+			name: "line without arrowheads",
+			scene: `{"elements": [
+				{"id": "l", "type": "line", "x": 0, "y": 0, "points": [[0, 0], [100, 0]], "endArrowhead": null}
+			]}`,
+			contains: []string{`d="M0 0 L100 0"`},
+			excludes: []string{`L70`},
+		},
+		{
+			name: "closed line is filled",
+			scene: `{"elements": [
+				{"id": "l", "type": "line", "x": 0, "y": 0, "points": [[0, 0], [50, 0], [50, 50], [0, 0]], "backgroundColor": "#a5d8ff"}
+			]}`,
+			contains: []string{`L0 0 Z"`, `fill="#a5d8ff"`},
+		},
+		{
+			name: "freedraw",
+			scene: `{"elements": [
+				{"id": "f", "type": "freedraw", "x": 0, "y": 0, "points": [[0, 0], [10, 10], [20, 0]], "strokeWidth": 2}
+			]}`,
+			contains: []string{`fill="none" stroke="#000000" stroke-width="5"`},
+		},
+		{
+			name: "text with font family and alignment",
+			scene: `{"elements": [
+				{"id": "t", "type": "text", "x": 0, "y": 0, "width": 100, "height": 50, "text": "a < b\nc", "fontSize": 20, "fontFamily": 3, "textAlign": "right", "strokeColor": "#1971c2"}
+			]}`,
+			contains: []string{
+				`@font-face { font-family: "Cascadia"`,
+				`font-family="Cascadia, Segoe UI Emoji, monospace"`,
+				`text-anchor="end"`,
+				`fill="#1971c2"`,
+				`>a &lt; b</text>`,
+				`>c</text>`,
+			},
+		},
+		{
+			name: "embedded image",
+			scene: `{"elements": [
+				{"id": "i", "type": "image", "x": 0, "y": 0, "width": 20, "height": 10, "fileId": "f1"}
+			], "files": {"f1": {"id": "f1", "mimeType": "image/png", "dataURL": "data:image/png;base64,AAAA"}}}`,
+			contains: []string{`<image width="20" height="10" preserveAspectRatio="none" xlink:href="data:image/png;base64,AAAA"`},
+		},
+		{
+			name: "deleted elements and missing files are skipped",
+			scene: `{"elements": [
+				{"id": "r", "type": "rectangle", "x": 0, "y": 0, "width": 10, "height": 10, "isDeleted": true},
+				{"id": "i", "type": "image", "x": 0, "y": 0, "width": 20, "height": 10, "fileId": "missing"}
+			]}`,
+			excludes: []string{`<path`, `<image`},
+		},
+		{
+			name: "view background and opacity",
+			scene: `{"elements": [
+				{"id": "r", "type": "rectangle", "x": 0, "y": 0, "width": 10, "height": 10, "opacity": 50}
+			], "appState": {"viewBackgroundColor": "#fff9db"}}`,
+			contains: []string{`fill="#fff9db"`, `opacity="0.5"`},
+		},
+		{
+			name:     "transparent background",
+			scene:    `{"elements": [], "appState": {"viewBackgroundColor": "transparent"}}`,
+			excludes: []string{`<rect`},
+		},
+		{
+			name: "dark mode",
+			scene: `{"elements": [
+				{"id": "r", "type": "rectangle", "x": 0, "y": 0, "width": 10, "height": 10}
+			], "appState": {"viewBackgroundColor": "#ffffff"}}`,
+			opts:     drawing.ExportOptions{DarkMode: true},
+			contains: []string{`fill="#121212"`, `stroke="#ededed"`},
+		},
+		{
+			name: "rotated element",
+			scene: `{"elements": [
+				{"id": "r", "type": "rectangle", "x": 0, "y": 0, "width": 10, "height": 10, "angle": 1.5707963267948966}
+			]}`,
+			contains: []string{`transform="matrix(0 1 -1 0 10 0)"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := NewRenderer().RenderSVG(context.Background(), parseScene(t, tt.scene), tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			svg := string(out)
+			for _, s := range tt.contains {
+				if !strings.Contains(svg, s) {
+					t.Errorf("expected SVG to contain %q, got:\n%s", s, svg)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(svg, s) {
+					t.Errorf("expected SVG not to contain %q, got:\n%s", s, svg)
+				}
+			}
+		})
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Color
+		ok    bool
+	}{
+		{"#1e1e1e", Color{0x1e, 0x1e, 0x1e, 255}, true},
+		{"#FFF", White, true},
+		{"#ff000080", Color{255, 0, 0, 128}, true},
+		{"transparent", Transparent, true},
+		{"Red", Color{255, 0, 0, 255}, true},
+		{"rgba(0, 128, 255, 0.5)", Color{0, 128, 255, 128}, true},
+		{"#12345", Color{}, false},
+		{"hsl(0, 0%, 0%)", Color{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseColor(tt.input)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseColor(%q) = %v, %v; want %v, %v", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -4,13 +4,23 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+	"github.com/personal-excalidraw/backend/internal/domain/folder"
+)
+
+const (
+	// pgUniqueViolation is the PostgreSQL error code for unique constraint violations
+	pgUniqueViolation = "23505"
+	// slugIndexName is the unique index guarding drawing slugs
+	slugIndexName = "idx_drawings_slug"
 )
 
 // DrawingRepository implements the drawing.Repository interface using PostgreSQL
@@ -25,51 +35,60 @@ func NewDrawingRepository(pool *pgxpool.Pool) *DrawingRepository {
 	}
 }
 
-// Create stores a new drawing in the database
-func (r *DrawingRepository) Create(ctx context.Context, d *drawing.Drawing) error {
+// Create stores a new drawing in the database along with its first revision
+func (r *DrawingRepository) Create(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
 	// Convert drawing data to JSON bytes
 	dataJSON, err := d.Data().ToJSON()
 	if err != nil {
 		return fmt.Errorf("failed to marshal drawing data: %w", err)
 	}
 
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	// Execute insert query
-	_, err = r.pool.Exec(
+	result, err := tx.Exec(
 		ctx,
 		queryCreateDrawing,
 		d.ID(),
 		d.Slug(),
 		d.Name(),
 		dataJSON,
+		d.Version(),
 		d.CreatedAt(),
 		d.UpdatedAt(),
+		drawing.CurrentSceneSchema,
 	)
 	if err != nil {
+		if isSlugConflict(err) {
+			return drawing.ErrSlugConflict
+		}
 		return fmt.Errorf("failed to create drawing: %w", err)
 	}
 
+	// No row is inserted when the slug is an alias of another drawing
+	if result.RowsAffected() == 0 {
+		return drawing.ErrSlugConflict
+	}
+
+	if err := writeRevision(ctx, tx, rev); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit drawing: %w", err)
+	}
+
 	return nil
 }
 
 // FindByID retrieves a drawing by its ID
 func (r *DrawingRepository) FindByID(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
-	var (
-		drawingID uuid.UUID
-		slug      string
-		name      string
-		dataJSON  []byte
-		createdAt, updatedAt time.Time
-	)
-
 	// Execute select query
-	err := r.pool.QueryRow(ctx, queryFindDrawingByID, id).Scan(
-		&drawingID,
-		&slug,
-		&name,
-		&dataJSON,
-		&createdAt,
-		&updatedAt,
-	)
+	d, err := scanDrawing(r.pool.QueryRow(ctx, queryFindDrawingByID, id))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, drawing.ErrDrawingNotFound
@@ -77,126 +96,185 @@ func (r *DrawingRepository) FindByID(ctx context.Context, id uuid.UUID) (*drawin
 		return nil, fmt.Errorf("failed to find drawing: %w", err)
 	}
 
-	// Parse drawing data from JSON
-	data, err := drawing.FromJSON(dataJSON)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal drawing data: %w", err)
-	}
+	return d, nil
+}
 
-	// Reconstitute the drawing entity
-	d, err := drawing.Reconstitute(drawingID, slug, name, data, createdAt, updatedAt)
+// FindBySlug retrieves a drawing by its slug
+func (r *DrawingRepository) FindBySlug(ctx context.Context, slug string) (*drawing.Drawing, error) {
+	// Execute select query
+	d, err := scanDrawing(r.pool.QueryRow(ctx, queryFindDrawingBySlug, slug))
 	if err != nil {
-		return nil, fmt.Errorf("failed to reconstitute drawing: %w", err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, drawing.ErrDrawingNotFound
+		}
+		return nil, fmt.Errorf("failed to find drawing by slug: %w", err)
 	}
 
 	return d, nil
 }
 
-// FindBySlug retrieves a drawing by its slug
-func (r *DrawingRepository) FindBySlug(ctx context.Context, slugParam string) (*drawing.Drawing, error) {
-	var (
-		drawingID uuid.UUID
-		slug      string
-		name      string
-		dataJSON  []byte
-		createdAt, updatedAt time.Time
-	)
-
+// FindBySlugAlias retrieves a drawing by one of its previous slugs
+func (r *DrawingRepository) FindBySlugAlias(ctx context.Context, alias string) (*drawing.Drawing, error) {
 	// Execute select query
-	err := r.pool.QueryRow(ctx, queryFindDrawingBySlug, slugParam).Scan(
-		&drawingID,
-		&slug,
-		&name,
-		&dataJSON,
-		&createdAt,
-		&updatedAt,
-	)
+	d, err := scanDrawing(r.pool.QueryRow(ctx, queryFindDrawingBySlugAlias, alias))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, drawing.ErrDrawingNotFound
 		}
-		return nil, fmt.Errorf("failed to find drawing by slug: %w", err)
+		return nil, fmt.Errorf("failed to find drawing by slug alias: %w", err)
 	}
 
-	// Parse drawing data from JSON
-	data, err := drawing.FromJSON(dataJSON)
+	return d, nil
+}
+
+// FindSummaries retrieves summaries of the drawings matching a query with pagination, in query order
+// Only the scene fields asked for are read from the database
+func (r *DrawingRepository) FindSummaries(ctx context.Context, query drawing.SummaryQuery, limit, offset int) ([]*drawing.DrawingSummary, error) {
+	args := append(filterArgs(query.Filter), summaryFieldKeys(query.Fields), limit, offset)
+
+	// Execute select query
+	rows, err := r.pool.Query(ctx, drawingSummariesQuery(query.Order, false, false), args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal drawing data: %w", err)
+		return nil, fmt.Errorf("failed to find drawing summaries: %w", err)
+	}
+	defer rows.Close()
+
+	return collectDrawingSummaries(rows)
+}
+
+// FindSummariesByCursor retrieves up to limit summaries of the drawings matching a query next to
+// a cursor, in query order. Drawings before the cursor are read nearest first, so the index is
+// used either way, and reversed
+func (r *DrawingRepository) FindSummariesByCursor(ctx context.Context, query drawing.SummaryQuery, cursor *drawing.Cursor, limit int) ([]*drawing.DrawingSummary, error) {
+	var key *string
+	var id *uuid.UUID
+	before := false
+	if cursor != nil {
+		key, id, before = &cursor.Key, &cursor.ID, cursor.Before
 	}
+	args := append(filterArgs(query.Filter), summaryFieldKeys(query.Fields), limit, key, id)
 
-	// Reconstitute the drawing entity
-	d, err := drawing.Reconstitute(drawingID, slug, name, data, createdAt, updatedAt)
+	// Execute select query
+	rows, err := r.pool.Query(ctx, drawingSummariesQuery(query.Order, true, before), args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to reconstitute drawing: %w", err)
+		return nil, fmt.Errorf("failed to find drawing summaries: %w", err)
+	}
+	defer rows.Close()
+
+	summaries, err := collectDrawingSummaries(rows)
+	if err != nil {
+		return nil, err
 	}
 
-	return d, nil
+	if before {
+		for i, j := 0, len(summaries)-1; i < j; i, j = i+1, j-1 {
+			summaries[i], summaries[j] = summaries[j], summaries[i]
+		}
+	}
+
+	return summaries, nil
 }
 
-// FindAll retrieves all drawings with pagination
-func (r *DrawingRepository) FindAll(ctx context.Context, limit, offset int) ([]*drawing.Drawing, error) {
-	// Execute select query
-	rows, err := r.pool.Query(ctx, queryFindAllDrawings, limit, offset)
+// Search retrieves the drawings whose name or scene text matches a full-text search query,
+// best match first, with pagination. The query is read as web search syntax: quoted phrases,
+// OR and -excluded words
+func (r *DrawingRepository) Search(ctx context.Context, query string, limit, offset int) ([]*drawing.SearchResult, error) {
+	// Execute search query
+	rows, err := r.pool.Query(ctx, querySearchDrawings, query, limit, offset, headlineOptions)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find all drawings: %w", err)
+		return nil, fmt.Errorf("failed to search drawings: %w", err)
 	}
 	defer rows.Close()
 
-	// Collect drawings
-	var drawings []*drawing.Drawing
+	var results []*drawing.SearchResult
 	for rows.Next() {
 		var (
-			drawingID uuid.UUID
-			slug      string
-			name      string
-			dataJSON  []byte
-			createdAt, updatedAt time.Time
+			res     drawing.SearchResult
+			snippet string
 		)
-
-		if err := rows.Scan(&drawingID, &slug, &name, &dataJSON, &createdAt, &updatedAt); err != nil {
-			return nil, fmt.Errorf("failed to scan drawing row: %w", err)
-		}
-
-		// Parse drawing data from JSON
-		data, err := drawing.FromJSON(dataJSON)
-		if err != nil {
-			return nil, fmt.Errorf("failed to unmarshal drawing data: %w", err)
+		if err := rows.Scan(&res.ID, &res.Slug, &res.Name, &res.Version, &res.UpdatedAt,
+			&res.Rank, &snippet, &res.ElementIDs); err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
 		}
+		res.Snippet = parseHeadline(snippet)
+		results = append(results, &res)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating search results: %w", err)
+	}
 
-		// Reconstitute the drawing entity
-		d, err := drawing.Reconstitute(drawingID, slug, name, data, createdAt, updatedAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to reconstitute drawing: %w", err)
-		}
+	return results, nil
+}
 
-		drawings = append(drawings, d)
-	}
+// CountSearch returns the number of drawings matching a full-text search query
+func (r *DrawingRepository) CountSearch(ctx context.Context, query string) (int64, error) {
+	var count int64
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating drawing rows: %w", err)
+	// Execute count query
+	err := r.pool.QueryRow(ctx, queryCountSearchDrawings, query).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count search results: %w", err)
 	}
 
-	return drawings, nil
+	return count, nil
 }
 
-// Update updates an existing drawing in the database
-func (r *DrawingRepository) Update(ctx context.Context, d *drawing.Drawing) error {
+// Update updates an existing drawing in the database and records it in its revision history
+// The stored version must be the one preceding the drawing's version, otherwise
+// another writer saved in between and drawing.ErrConcurrentUpdate is returned.
+// When the slug changes, the previous slug is kept as an alias so old links keep resolving
+func (r *DrawingRepository) Update(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
 	// Convert drawing data to JSON bytes
 	dataJSON, err := d.Data().ToJSON()
 	if err != nil {
 		return fmt.Errorf("failed to marshal drawing data: %w", err)
 	}
 
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the row and read the slug and version currently stored
+	var (
+		currentSlug    string
+		currentVersion int64
+	)
+	if err := tx.QueryRow(ctx, queryLockDrawingForUpdate, d.ID()).Scan(&currentSlug, &currentVersion); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return drawing.ErrDrawingNotFound
+		}
+		return fmt.Errorf("failed to lock drawing: %w", err)
+	}
+
+	// The drawing must be saved on top of the version it was loaded at
+	if currentVersion != d.Version()-1 {
+		return drawing.ErrConcurrentUpdate
+	}
+
+	if currentSlug != d.Slug() {
+		if err := r.moveSlug(ctx, tx, d.ID(), currentSlug, d.Slug(), d.UpdatedAt()); err != nil {
+			return err
+		}
+	}
+
 	// Execute update query
-	result, err := r.pool.Exec(
+	result, err := tx.Exec(
 		ctx,
 		queryUpdateDrawing,
 		d.Name(),
+		d.Slug(),
 		dataJSON,
+		d.Version(),
 		d.UpdatedAt(),
 		d.ID(),
+		drawing.CurrentSceneSchema,
 	)
 	if err != nil {
+		if isSlugConflict(err) {
+			return drawing.ErrSlugConflict
+		}
 		return fmt.Errorf("failed to update drawing: %w", err)
 	}
 
@@ -205,17 +283,87 @@ func (r *DrawingRepository) Update(ctx context.Context, d *drawing.Drawing) erro
 		return drawing.ErrDrawingNotFound
 	}
 
+	if err := writeRevision(ctx, tx, rev); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit drawing update: %w", err)
+	}
+
+	return nil
+}
+
+// moveSlug records oldSlug as an alias of the drawing and releases newSlug from the alias table
+func (r *DrawingRepository) moveSlug(ctx context.Context, tx pgx.Tx, id uuid.UUID, oldSlug, newSlug string, at time.Time) error {
+	// The new slug may only be an alias of this same drawing (e.g. renaming back)
+	var owner uuid.UUID
+	err := tx.QueryRow(ctx, queryFindSlugAliasOwner, newSlug).Scan(&owner)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+	case err != nil:
+		return fmt.Errorf("failed to check slug alias: %w", err)
+	case owner != id:
+		return drawing.ErrSlugConflict
+	default:
+		if _, err := tx.Exec(ctx, queryDeleteSlugAlias, newSlug); err != nil {
+			return fmt.Errorf("failed to release slug alias: %w", err)
+		}
+	}
+
+	if oldSlug == "" {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, queryUpsertSlugAlias, oldSlug, id, at); err != nil {
+		return fmt.Errorf("failed to record slug alias: %w", err)
+	}
+
 	return nil
 }
 
 // Delete removes a drawing from the database
-func (r *DrawingRepository) Delete(ctx context.Context, id uuid.UUID) error {
+func (r *DrawingRepository) Delete(ctx context.Context, id uuid.UUID, expectedVersions []int64) error {
 	// Execute delete query
-	result, err := r.pool.Exec(ctx, queryDeleteDrawing, id)
+	result, err := r.pool.Exec(ctx, queryDeleteDrawing, id, expectedVersions)
 	if err != nil {
 		return fmt.Errorf("failed to delete drawing: %w", err)
 	}
 
+	// Check if any rows were affected
+	if result.RowsAffected() == 0 {
+		if len(expectedVersions) == 0 {
+			return drawing.ErrDrawingNotFound
+		}
+
+		// The drawing is either gone or at another version
+		var exists bool
+		if err := r.pool.QueryRow(ctx, queryDrawingExists, id).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to check drawing existence: %w", err)
+		}
+		if exists {
+			return drawing.ErrVersionConflict
+		}
+		return drawing.ErrDrawingNotFound
+	}
+
+	return nil
+}
+
+// MoveToFolder places a drawing in a folder, or outside any folder when folderID is nil
+// A folder that does not exist is reported as folder.ErrFolderNotFound
+func (r *DrawingRepository) MoveToFolder(ctx context.Context, id uuid.UUID, folderID *uuid.UUID) error {
+	// Execute update query
+	result, err := r.pool.Exec(ctx, queryMoveDrawingToFolder, id, folderID, time.Now().UTC())
+	if err != nil {
+		// The folder does not exist, or was deleted in between
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation {
+			return folder.ErrFolderNotFound
+		}
+		return fmt.Errorf("failed to move drawing: %w", err)
+	}
+
 	// Check if any rows were affected
 	if result.RowsAffected() == 0 {
 		return drawing.ErrDrawingNotFound
@@ -224,15 +372,274 @@ func (r *DrawingRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-// Count returns the total number of drawings in the database
-func (r *DrawingRepository) Count(ctx context.Context) (int64, error) {
+// Count returns the number of drawings in the database matching a filter
+func (r *DrawingRepository) Count(ctx context.Context, filter drawing.DrawingFilter) (int64, error) {
 	var count int64
 
 	// Execute count query
-	err := r.pool.QueryRow(ctx, queryCountDrawings).Scan(&count)
+	err := r.pool.QueryRow(ctx, queryCountDrawings, filterArgs(filter)...).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count drawings: %w", err)
 	}
 
 	return count, nil
 }
+
+// FindWithoutSlug retrieves up to limit drawings that have no slug yet
+func (r *DrawingRepository) FindWithoutSlug(ctx context.Context, limit int) ([]*drawing.Drawing, error) {
+	// Execute select query
+	rows, err := r.pool.Query(ctx, queryFindDrawingsWithoutSlug, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find drawings without slug: %w", err)
+	}
+	defer rows.Close()
+
+	return collectDrawings(rows)
+}
+
+// UpdateSlug sets the slug of an existing drawing
+func (r *DrawingRepository) UpdateSlug(ctx context.Context, id uuid.UUID, slug string) error {
+	// Execute update query
+	result, err := r.pool.Exec(ctx, queryUpdateDrawingSlug, slug, id)
+	if err != nil {
+		if isSlugConflict(err) {
+			return drawing.ErrSlugConflict
+		}
+		return fmt.Errorf("failed to update drawing slug: %w", err)
+	}
+
+	// Check if any rows were affected
+	if result.RowsAffected() == 0 {
+		return drawing.ErrDrawingNotFound
+	}
+
+	return nil
+}
+
+// FindWithOutdatedSchema retrieves up to limit drawings stored with an older scene schema
+// The returned drawings are already upgraded; save them with RewriteData
+func (r *DrawingRepository) FindWithOutdatedSchema(ctx context.Context, limit int) ([]*drawing.Drawing, error) {
+	// Execute select query
+	rows, err := r.pool.Query(ctx, queryFindDrawingsWithOutdatedSchema, drawing.CurrentSceneSchema, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find drawings with outdated schema: %w", err)
+	}
+	defer rows.Close()
+
+	return collectDrawings(rows)
+}
+
+// RewriteData stores the data of a drawing at the current scene schema
+// The version and timestamps are left untouched, since clients already see the upgraded data.
+// A drawing saved or deleted since it was read is left alone, and false is returned
+func (r *DrawingRepository) RewriteData(ctx context.Context, d *drawing.Drawing) (bool, error) {
+	// Convert drawing data to JSON bytes
+	dataJSON, err := d.Data().ToJSON()
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal drawing data: %w", err)
+	}
+
+	// Execute update query
+	result, err := r.pool.Exec(ctx, queryRewriteDrawingData, dataJSON, drawing.CurrentSceneSchema, d.ID(), d.Version())
+	if err != nil {
+		return false, fmt.Errorf("failed to rewrite drawing data: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// collectDrawings scans all rows into drawing entities
+func collectDrawings(rows pgx.Rows) ([]*drawing.Drawing, error) {
+	var drawings []*drawing.Drawing
+	for rows.Next() {
+		d, err := scanDrawing(rows)
+		if err != nil {
+			return nil, err
+		}
+		drawings = append(drawings, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating drawing rows: %w", err)
+	}
+
+	return drawings, nil
+}
+
+// scanDrawing scans a single drawing row into a drawing entity
+func scanDrawing(row pgx.Row) (*drawing.Drawing, error) {
+	var (
+		drawingID            uuid.UUID
+		slug                 string
+		name                 string
+		dataJSON             []byte
+		schemaVersion        int
+		version              int64
+		createdAt, updatedAt time.Time
+		folderID             *uuid.UUID
+	)
+
+	if err := row.Scan(&drawingID, &slug, &name, &dataJSON, &schemaVersion, &version, &createdAt, &updatedAt, &folderID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan drawing row: %w", err)
+	}
+
+	// Parse drawing data from JSON
+	data, err := drawing.FromJSON(dataJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal drawing data: %w", err)
+	}
+
+	// Upgrade drawings saved with an older scene schema so clients always get the current one
+	if err := drawing.UpgradeScene(data, schemaVersion); err != nil {
+		return nil, fmt.Errorf("failed to upgrade drawing data: %w", err)
+	}
+
+	// Reconstitute the drawing entity
+	d, err := drawing.Reconstitute(drawingID, slug, name, data, version, createdAt, updatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconstitute drawing: %w", err)
+	}
+	d.SetFolder(folderID)
+
+	return d, nil
+}
+
+// summaryFieldKeys returns the names of summary fields as query parameters
+func summaryFieldKeys(fields []drawing.SummaryField) []string {
+	keys := make([]string, len(fields))
+	for i, f := range fields {
+		keys[i] = string(f)
+	}
+	return keys
+}
+
+// filterArgs returns a drawing filter as the parameters of filterDrawings, NULL where it is unset
+// The name is matched with ILIKE, so its wildcards are escaped to match literally
+func filterArgs(f drawing.DrawingFilter) []any {
+	var prefix, contains *string
+	if f.NamePrefix != "" {
+		p := likeEscaper.Replace(f.NamePrefix) + "%"
+		prefix = &p
+	}
+	if f.NameContains != "" {
+		c := "%" + likeEscaper.Replace(f.NameContains) + "%"
+		contains = &c
+	}
+	return []any{
+		prefix, contains,
+		utcTime(f.CreatedAfter), utcTime(f.CreatedBefore),
+		utcTime(f.UpdatedAfter), utcTime(f.UpdatedBefore),
+		f.MinElements, f.MaxElements,
+		f.FolderID, f.Unfiled,
+	}
+}
+
+// likeEscaper escapes the wildcards of LIKE patterns and the escape character itself
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// utcTime returns a time in UTC, the zone timestamps are stored in; nil stays nil
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
+// Matches in snippets are delimited by control characters, which cannot occur in scene text as
+// typed, and turned into snippet fragments
+const (
+	headlineStart = "\x02"
+	headlineStop  = "\x03"
+
+	// headlineOptions configures ts_headline to excerpt up to three fragments around the matches
+	headlineOptions = `StartSel="` + headlineStart + `", StopSel="` + headlineStop + `", ` +
+		`MaxFragments=3, MaxWords=20, MinWords=6, FragmentDelimiter=" … "`
+)
+
+// parseHeadline splits a ts_headline excerpt into the matches and the text between them
+func parseHeadline(headline string) []drawing.SnippetFragment {
+	var fragments []drawing.SnippetFragment
+	for {
+		start := strings.Index(headline, headlineStart)
+		if start < 0 {
+			break
+		}
+		stop := strings.Index(headline[start:], headlineStop)
+		if stop < 0 {
+			break
+		}
+		stop += start
+		if start > 0 {
+			fragments = append(fragments, drawing.SnippetFragment{Text: headline[:start]})
+		}
+		fragments = append(fragments, drawing.SnippetFragment{Text: headline[start+len(headlineStart) : stop], Match: true})
+		headline = headline[stop+len(headlineStop):]
+	}
+	if headline != "" {
+		fragments = append(fragments, drawing.SnippetFragment{Text: headline})
+	}
+	return fragments
+}
+
+// collectDrawingSummaries scans all rows into drawing summaries
+func collectDrawingSummaries(rows pgx.Rows) ([]*drawing.DrawingSummary, error) {
+	var summaries []*drawing.DrawingSummary
+	for rows.Next() {
+		s, err := scanDrawingSummary(rows)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating drawing summary rows: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// scanDrawingSummary scans a single drawing summary row
+func scanDrawingSummary(row pgx.Row) (*drawing.DrawingSummary, error) {
+	var (
+		s             drawing.DrawingSummary
+		schemaVersion int
+		elementCount  int64
+		dataJSON      []byte
+	)
+
+	if err := row.Scan(&s.ID, &s.Slug, &s.Name, &schemaVersion, &s.Version, &s.CreatedAt, &s.UpdatedAt, &s.FolderID,
+		&elementCount, &s.ByteSize, &s.ThumbnailVersion, &dataJSON); err != nil {
+		return nil, fmt.Errorf("failed to scan drawing summary row: %w", err)
+	}
+	s.ElementCount = int(elementCount)
+
+	// The data column is NULL unless scene fields were asked for
+	if dataJSON != nil {
+		data, err := drawing.FromJSON(dataJSON)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal drawing data: %w", err)
+		}
+
+		// Upgrade the selected members as whole drawings are, so clients always get the current schema
+		if err := drawing.UpgradeScene(data, schemaVersion); err != nil {
+			return nil, fmt.Errorf("failed to upgrade drawing data: %w", err)
+		}
+		s.Data = data
+	}
+
+	return &s, nil
+}
+
+// isSlugConflict reports whether err is a unique violation on the slug index
+func isSlugConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == pgUniqueViolation && pgErr.ConstraintName == slugIndexName
+	}
+	return false
+}
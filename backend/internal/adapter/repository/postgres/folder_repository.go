@@ -0,0 +1,268 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/personal-excalidraw/backend/internal/domain/folder"
+)
+
+// Unique constraints keeping sibling folder names distinct, below a folder and at the top level
+const (
+	folderNameConstraint         = "folders_parent_id_name_key"
+	topLevelFolderNameConstraint = "idx_folders_top_level_name"
+)
+
+// folderMoveLockKey is the advisory lock key taken by folder moves
+const folderMoveLockKey int64 = 0x666f6c646572
+
+// FolderRepository implements the folder.Repository interface using PostgreSQL
+type FolderRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewFolderRepository creates a new FolderRepository
+func NewFolderRepository(pool *pgxpool.Pool) *FolderRepository {
+	return &FolderRepository{
+		pool: pool,
+	}
+}
+
+// Create stores a new folder in the database
+func (r *FolderRepository) Create(ctx context.Context, f *folder.Folder) error {
+	// Execute insert query
+	_, err := r.pool.Exec(ctx, queryCreateFolder, f.ID(), f.ParentID(), f.Name(), f.CreatedAt(), f.UpdatedAt())
+	if err != nil {
+		return folderWriteError(err, "failed to create folder")
+	}
+
+	return nil
+}
+
+// FindByID retrieves a folder by its ID
+func (r *FolderRepository) FindByID(ctx context.Context, id uuid.UUID) (*folder.Folder, error) {
+	f, err := scanFolder(r.pool.QueryRow(ctx, queryFindFolderByID, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, folder.ErrFolderNotFound
+		}
+		return nil, fmt.Errorf("failed to find folder: %w", err)
+	}
+
+	return f, nil
+}
+
+// FindAll retrieves all folders ordered by name
+func (r *FolderRepository) FindAll(ctx context.Context) ([]*folder.Folder, error) {
+	// Execute select query
+	rows, err := r.pool.Query(ctx, queryFindAllFolders)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find folders: %w", err)
+	}
+	defer rows.Close()
+
+	var folders []*folder.Folder
+	for rows.Next() {
+		f, err := scanFolder(rows)
+		if err != nil {
+			return nil, err
+		}
+		folders = append(folders, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating folders: %w", err)
+	}
+
+	return folders, nil
+}
+
+// FindAncestorIDs retrieves the IDs of the folders above a folder, nearest first
+func (r *FolderRepository) FindAncestorIDs(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error) {
+	// Execute select query
+	rows, err := r.pool.Query(ctx, queryFindFolderAncestorIDs, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find folder ancestors: %w", err)
+	}
+	defer rows.Close()
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan folder ancestors: %w", err)
+	}
+
+	return ids, nil
+}
+
+// Rename stores the name of an existing folder
+// The parent is left as stored, so a rename racing a move cannot undo it
+func (r *FolderRepository) Rename(ctx context.Context, f *folder.Folder) error {
+	// Execute update query
+	result, err := r.pool.Exec(ctx, queryRenameFolder, f.ID(), f.Name(), f.UpdatedAt())
+	if err != nil {
+		return folderWriteError(err, "failed to rename folder")
+	}
+
+	// Check if any rows were affected
+	if result.RowsAffected() == 0 {
+		return folder.ErrFolderNotFound
+	}
+
+	return nil
+}
+
+// Move stores the new parent of a folder
+// The ancestors of the new parent are checked and the folder updated in one transaction that locks
+// both rows, so a concurrent move cannot slip the folder into one of its own subfolders
+func (r *FolderRepository) Move(ctx context.Context, f *folder.Folder) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, queryLockFolderMoves, folderMoveLockKey); err != nil {
+		return fmt.Errorf("failed to lock folder moves: %w", err)
+	}
+
+	// Lock the new parent before the folder, in the same order as Delete locks a folder before its subfolders
+	var ancestors []uuid.UUID
+	if parentID := f.ParentID(); parentID != nil {
+		var grandparentID *uuid.UUID
+		if err := tx.QueryRow(ctx, queryLockFolder, *parentID).Scan(&grandparentID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return folder.ErrFolderNotFound
+			}
+			return fmt.Errorf("failed to lock parent folder: %w", err)
+		}
+
+		rows, err := tx.Query(ctx, queryFindFolderAncestorIDs, *parentID)
+		if err != nil {
+			return fmt.Errorf("failed to find folder ancestors: %w", err)
+		}
+		if ancestors, err = pgx.CollectRows(rows, pgx.RowTo[uuid.UUID]); err != nil {
+			return fmt.Errorf("failed to scan folder ancestors: %w", err)
+		}
+		ancestors = append(ancestors, *parentID)
+	}
+
+	var oldParentID *uuid.UUID
+	if err := tx.QueryRow(ctx, queryLockFolder, f.ID()).Scan(&oldParentID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return folder.ErrFolderNotFound
+		}
+		return fmt.Errorf("failed to lock folder: %w", err)
+	}
+
+	for _, id := range ancestors {
+		if id == f.ID() {
+			return folder.ErrFolderCycle
+		}
+	}
+
+	// Only the parent is written, so a rename landing since the folder was read is kept
+	if _, err := tx.Exec(ctx, queryMoveFolder, f.ID(), f.ParentID(), f.UpdatedAt()); err != nil {
+		return folderWriteError(err, "failed to move folder")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit folder move: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a folder, handling its subfolders and drawings according to mode
+// Reparenting and cascading run in a transaction, so a failure leaves the folder untouched
+func (r *FolderRepository) Delete(ctx context.Context, id uuid.UUID, mode folder.DeleteMode) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the folder so nothing moves into it while it is emptied
+	var parentID *uuid.UUID
+	if err := tx.QueryRow(ctx, queryLockFolder, id).Scan(&parentID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return folder.ErrFolderNotFound
+		}
+		return fmt.Errorf("failed to lock folder: %w", err)
+	}
+
+	switch mode {
+	case folder.DeleteRestrict:
+		_, err = tx.Exec(ctx, queryDeleteFolder, id)
+	case folder.DeleteReparent:
+		now := time.Now().UTC()
+		if _, err := tx.Exec(ctx, queryReparentSubfolders, id, parentID, now); err != nil {
+			return folderWriteError(err, "failed to move subfolders")
+		}
+		if _, err := tx.Exec(ctx, queryReparentFolderDrawings, id, parentID, now); err != nil {
+			return fmt.Errorf("failed to move folder drawings: %w", err)
+		}
+		_, err = tx.Exec(ctx, queryDeleteFolder, id)
+	case folder.DeleteCascade:
+		if _, err := tx.Exec(ctx, queryDeleteFolderTreeDrawings, id); err != nil {
+			return fmt.Errorf("failed to delete folder drawings: %w", err)
+		}
+		_, err = tx.Exec(ctx, queryDeleteFolderTree, id)
+	default:
+		return folder.ErrInvalidDeleteMode
+	}
+	if err != nil {
+		// Subfolders or drawings still reference the folder
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation {
+			return folder.ErrFolderNotEmpty
+		}
+		return fmt.Errorf("failed to delete folder: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit folder delete: %w", err)
+	}
+
+	return nil
+}
+
+// scanFolder scans a single folder row
+func scanFolder(row pgx.Row) (*folder.Folder, error) {
+	var (
+		id                   uuid.UUID
+		parentID             *uuid.UUID
+		name                 string
+		createdAt, updatedAt time.Time
+	)
+
+	if err := row.Scan(&id, &parentID, &name, &createdAt, &updatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan folder row: %w", err)
+	}
+
+	return folder.Reconstitute(id, parentID, name, createdAt, updatedAt), nil
+}
+
+// folderWriteError maps the constraint violations of folder writes to domain errors
+// A missing parent violates the parent foreign key; a sibling with the same name the unique constraint
+func folderWriteError(err error, message string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == pgUniqueViolation &&
+			(pgErr.ConstraintName == folderNameConstraint || pgErr.ConstraintName == topLevelFolderNameConstraint):
+			return folder.ErrNameConflict
+		case pgErr.Code == pgForeignKeyViolation:
+			return folder.ErrFolderNotFound
+		}
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
@@ -1,50 +1,460 @@
 package postgres
 
+import (
+	"fmt"
+
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
 const (
 	// queryCreateDrawing inserts a new drawing into the database
+	// The insert is skipped when the slug is still reserved as an alias of another drawing
 	queryCreateDrawing = `
-		INSERT INTO drawings (id, slug, name, data, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO drawings (id, slug, name, data, schema_version, version, created_at, updated_at)
+		SELECT $1::uuid, $2::varchar, $3::varchar, $4::jsonb, $8::integer, $5::bigint, $6::timestamp, $7::timestamp
+		WHERE NOT EXISTS (
+			SELECT 1 FROM drawing_slug_aliases WHERE slug = $2::varchar
+		)
 	`
 
 	// queryFindDrawingByID retrieves a drawing by its ID
 	queryFindDrawingByID = `
-		SELECT id, slug, name, data, created_at, updated_at
+		SELECT id, slug, name, data, schema_version, version, created_at, updated_at, folder_id
 		FROM drawings
 		WHERE id = $1
 	`
 
 	// queryFindDrawingBySlug retrieves a drawing by its slug
 	queryFindDrawingBySlug = `
-		SELECT id, slug, name, data, created_at, updated_at
+		SELECT id, slug, name, data, schema_version, version, created_at, updated_at, folder_id
 		FROM drawings
 		WHERE slug = $1
 	`
 
-	// queryFindAllDrawings retrieves all drawings with pagination
-	queryFindAllDrawings = `
-		SELECT id, slug, name, data, created_at, updated_at
+	// queryFindDrawingBySlugAlias retrieves a drawing by one of its previous slugs
+	queryFindDrawingBySlugAlias = `
+		SELECT d.id, d.slug, d.name, d.data, d.schema_version, d.version, d.created_at, d.updated_at, d.folder_id
+		FROM drawing_slug_aliases a
+		JOIN drawings d ON d.id = a.drawing_id
+		WHERE a.slug = $1
+	`
+
+	// selectDrawingSummaries selects drawing summaries
+	// The scene is only selected whole when $11 lists 'data', otherwise only its members listed in $11;
+	// the element count and size of the scene are kept in generated columns, so it is never transferred
+	selectDrawingSummaries = `
+		SELECT d.id, d.slug, d.name, d.schema_version, d.version, d.created_at, d.updated_at, d.folder_id,
+			d.element_count, d.byte_size,
+			COALESCE(t.version, 0) AS thumbnail_version,
+			CASE WHEN 'data' = ANY($11::text[]) THEN d.data ELSE (
+				SELECT jsonb_object_agg(m.key, m.value)
+				FROM jsonb_each(d.data) m
+				WHERE m.key = ANY($11::text[])
+			) END AS data
+		FROM drawings d
+		LEFT JOIN drawing_thumbnails t ON t.drawing_id = d.id
+	`
+
+	// filterDrawings keeps the drawings matching the filter in $1 to $10, each criterion skipped when NULL
+	// $1 and $2 are ILIKE patterns for the name; dates are bounded from inclusively and to exclusively;
+	// $9 is a folder to list and $10 lists the drawings outside any folder instead
+	filterDrawings = `
+		WHERE ($1::text IS NULL OR d.name ILIKE $1::text)
+			AND ($2::text IS NULL OR d.name ILIKE $2::text)
+			AND ($3::timestamp IS NULL OR d.created_at >= $3::timestamp)
+			AND ($4::timestamp IS NULL OR d.created_at < $4::timestamp)
+			AND ($5::timestamp IS NULL OR d.updated_at >= $5::timestamp)
+			AND ($6::timestamp IS NULL OR d.updated_at < $6::timestamp)
+			AND ($7::integer IS NULL OR d.element_count >= $7::integer)
+			AND ($8::integer IS NULL OR d.element_count <= $8::integer)
+			AND ($9::uuid IS NULL OR d.folder_id = $9::uuid)
+			AND (NOT $10::boolean OR d.folder_id IS NULL)
+	`
+
+	// queryFindDrawingSummaries retrieves the filtered drawing summaries with pagination
+	// The order is appended by drawingSummariesQuery
+	queryFindDrawingSummaries = selectDrawingSummaries + filterDrawings
+
+	// queryFindDrawingSummariesByCursor retrieves the filtered drawing summaries next to the cursor
+	// with sort key $13 and ID $14; without a cursor ($13 NULL) it starts at the first drawing
+	// The comparison and the order are filled in by drawingSummariesQuery
+	queryFindDrawingSummariesByCursor = selectDrawingSummaries + filterDrawings + `
+			AND ($13::text IS NULL OR (%[1]s, d.id) %[2]s ($13::text::%[3]s, $14::uuid))
+	`
+
+	// querySearchDrawings retrieves the drawings matching the web search query $1, best match first,
+	// with pagination ($2, $3). Snippets and matching elements are only computed for the page;
+	// $4 holds the ts_headline options
+	querySearchDrawings = `
+		SELECT d.id, d.slug, d.name, d.version, d.updated_at, m.rank,
+			ts_headline('english', d.name || E'\n' || drawing_search_text(d.data), m.query, $4::text) AS snippet,
+			ARRAY(
+				SELECT e.value->>'id'
+				FROM jsonb_array_elements(
+					CASE WHEN jsonb_typeof(d.data->'elements') = 'array' THEN d.data->'elements' ELSE '[]'::jsonb END
+				) WITH ORDINALITY e(value, position)
+				WHERE COALESCE(e.value->>'isDeleted', 'false') <> 'true'
+					AND e.value->>'type' IN ('text', 'frame', 'magicframe')
+					AND to_tsvector('english', COALESCE(
+						CASE WHEN e.value->>'type' = 'text' THEN e.value->>'text' ELSE e.value->>'name' END, ''
+					)) @@ m.query
+				ORDER BY e.position
+			) AS element_ids
+		FROM (
+			SELECT d.id, q.query, ts_rank_cd(d.search_vector, q.query) AS rank
+			FROM drawings d, websearch_to_tsquery('english', $1::text) q(query)
+			WHERE d.search_vector @@ q.query
+			ORDER BY rank DESC, d.updated_at DESC, d.id
+			LIMIT $2 OFFSET $3
+		) m
+		JOIN drawings d ON d.id = m.id
+		ORDER BY m.rank DESC, d.updated_at DESC, d.id
+	`
+
+	// queryCountSearchDrawings returns the number of drawings matching the web search query $1
+	queryCountSearchDrawings = `
+		SELECT COUNT(*)
 		FROM drawings
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
+		WHERE search_vector @@ websearch_to_tsquery('english', $1::text)
 	`
 
 	// queryUpdateDrawing updates an existing drawing
 	queryUpdateDrawing = `
 		UPDATE drawings
-		SET name = $1, data = $2, updated_at = $3
-		WHERE id = $4
+		SET name = $1, slug = $2, data = $3, version = $4, updated_at = $5, schema_version = $7
+		WHERE id = $6
+	`
+
+	// queryLockDrawingForUpdate retrieves the current slug and version of a drawing and locks its row
+	queryLockDrawingForUpdate = `
+		SELECT slug, version
+		FROM drawings
+		WHERE id = $1
+		FOR UPDATE
 	`
 
 	// queryDeleteDrawing deletes a drawing by ID
+	// A non-empty $2 only deletes the drawing while it is at one of those versions
 	queryDeleteDrawing = `
 		DELETE FROM drawings
+		WHERE id = $1 AND (COALESCE(cardinality($2::bigint[]), 0) = 0 OR version = ANY($2))
+	`
+
+	// queryDrawingExists reports whether a drawing with the ID exists
+	queryDrawingExists = `
+		SELECT EXISTS (SELECT 1 FROM drawings WHERE id = $1)
+	`
+
+	// queryMoveDrawingToFolder places a drawing in a folder, or outside any folder when $2 is NULL
+	// The update time moves on, so lists validated by it are not served stale
+	queryMoveDrawingToFolder = `
+		UPDATE drawings
+		SET folder_id = $2, updated_at = $3
 		WHERE id = $1
 	`
 
-	// queryCountDrawings returns the total number of drawings
+	// queryCountDrawings returns the number of drawings matching the filter in $1 to $10
 	queryCountDrawings = `
 		SELECT COUNT(*)
+		FROM drawings d
+	` + filterDrawings
+
+	// queryFindDrawingsWithoutSlug retrieves drawings that have not been assigned a slug
+	queryFindDrawingsWithoutSlug = `
+		SELECT id, slug, name, data, schema_version, version, created_at, updated_at, folder_id
+		FROM drawings
+		WHERE slug = ''
+		ORDER BY created_at ASC
+		LIMIT $1
+	`
+
+	// queryFindDrawingsWithOutdatedSchema retrieves drawings stored with an older scene schema
+	queryFindDrawingsWithOutdatedSchema = `
+		SELECT id, slug, name, data, schema_version, version, created_at, updated_at, folder_id
 		FROM drawings
+		WHERE schema_version < $1
+		ORDER BY created_at ASC
+		LIMIT $2
+	`
+
+	// queryRewriteDrawingData replaces the data of a drawing without touching its version or timestamps
+	// Nothing is written if the drawing was saved since it was read, at $4, or is already at the schema
+	queryRewriteDrawingData = `
+		UPDATE drawings
+		SET data = $1, schema_version = $2
+		WHERE id = $3 AND version = $4 AND schema_version < $2
+	`
+
+	// queryFindSlugAliasOwner retrieves the drawing that owns a slug alias
+	queryFindSlugAliasOwner = `
+		SELECT drawing_id
+		FROM drawing_slug_aliases
+		WHERE slug = $1
+	`
+
+	// queryUpsertSlugAlias records a previous slug of a drawing
+	queryUpsertSlugAlias = `
+		INSERT INTO drawing_slug_aliases (slug, drawing_id, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (slug) DO UPDATE SET drawing_id = EXCLUDED.drawing_id, created_at = EXCLUDED.created_at
+	`
+
+	// queryDeleteSlugAlias removes a slug alias
+	queryDeleteSlugAlias = `
+		DELETE FROM drawing_slug_aliases
+		WHERE slug = $1
+	`
+
+	// queryUpdateDrawingSlug sets the slug of a drawing
+	queryUpdateDrawingSlug = `
+		UPDATE drawings
+		SET slug = $1
+		WHERE id = $2
+	`
+
+	// queryCreateRevision inserts a revision with the next revision number of its drawing
+	queryCreateRevision = `
+		INSERT INTO drawing_revisions (id, drawing_id, revision, name, data, schema_version, restored_from, client_id, created_at)
+		SELECT $1::uuid, $2::uuid, COALESCE(MAX(revision), 0) + 1, $3::varchar, $4::jsonb, $8::integer, $5::integer, $6::varchar, $7::timestamp
+		FROM drawing_revisions
+		WHERE drawing_id = $2::uuid
+		RETURNING revision
+	`
+
+	// queryFindRevisionsByDrawingID retrieves the revisions of a drawing, newest first
+	queryFindRevisionsByDrawingID = `
+		SELECT id, drawing_id, revision, name, data, schema_version, restored_from, client_id, created_at
+		FROM drawing_revisions
+		WHERE drawing_id = $1
+		ORDER BY revision DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	// queryFindRevisionByNumber retrieves a single revision of a drawing
+	queryFindRevisionByNumber = `
+		SELECT id, drawing_id, revision, name, data, schema_version, restored_from, client_id, created_at
+		FROM drawing_revisions
+		WHERE drawing_id = $1 AND revision = $2
+	`
+
+	// queryCountRevisionsByDrawingID returns the number of revisions of a drawing
+	queryCountRevisionsByDrawingID = `
+		SELECT COUNT(*)
+		FROM drawing_revisions
+		WHERE drawing_id = $1
+	`
+
+	// queryFindLatestRevisionMeta retrieves the metadata of the most recent revision of a drawing without the data
+	queryFindLatestRevisionMeta = `
+		SELECT id, revision, restored_from, client_id, created_at
+		FROM drawing_revisions
+		WHERE drawing_id = $1
+		ORDER BY revision DESC
+		LIMIT 1
+	`
+
+	// queryOverwriteRevision replaces the name and data of a revision of a drawing, selected by number
+	queryOverwriteRevision = `
+		UPDATE drawing_revisions
+		SET name = $1, data = $2, schema_version = $5
+		WHERE drawing_id = $3 AND revision = $4
+	`
+
+	// queryFindCompactionCandidates retrieves drawings with more than one revision older than a cutoff
+	// ($1) where, since the drawing was last compacted, a revision has aged past the keep-all window
+	// ($3, in microseconds) or one older than a second cutoff ($2) has aged past the hourly window ($4)
+	queryFindCompactionCandidates = `
+		SELECT r.drawing_id
+		FROM drawing_revisions r
+		JOIN drawings d ON d.id = r.drawing_id
+		WHERE r.created_at < $1
+		GROUP BY r.drawing_id, d.revisions_compacted_at
+		HAVING COUNT(*) > 1 AND (
+			d.revisions_compacted_at IS NULL
+			OR MAX(r.created_at) >= d.revisions_compacted_at - $3::bigint * interval '1 microsecond'
+			OR BOOL_OR(r.created_at < $2 AND r.created_at >= d.revisions_compacted_at - $4::bigint * interval '1 microsecond')
+		)
+	`
+
+	// queryMarkRevisionsCompacted records when the revisions of a drawing were last compacted
+	queryMarkRevisionsCompacted = `
+		UPDATE drawings
+		SET revisions_compacted_at = $2
+		WHERE id = $1
+	`
+
+	// queryFindRevisionMetaByDrawingID retrieves revision metadata of a drawing without the data
+	queryFindRevisionMetaByDrawingID = `
+		SELECT id, revision, restored_from, client_id, created_at
+		FROM drawing_revisions
+		WHERE drawing_id = $1
+		ORDER BY revision DESC
+	`
+
+	// queryDeleteRevisionsByIDs deletes revisions by ID
+	queryDeleteRevisionsByIDs = `
+		DELETE FROM drawing_revisions
+		WHERE id = ANY($1)
+	`
+
+	// querySaveThumbnail stores the thumbnail of a drawing, keeping a stored thumbnail of a newer version
+	querySaveThumbnail = `
+		INSERT INTO drawing_thumbnails (drawing_id, image, version, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (drawing_id) DO UPDATE
+		SET image = EXCLUDED.image, version = EXCLUDED.version, created_at = EXCLUDED.created_at
+		WHERE drawing_thumbnails.version <= EXCLUDED.version
+	`
+
+	// queryFindThumbnailByDrawingID retrieves the thumbnail of a drawing
+	queryFindThumbnailByDrawingID = `
+		SELECT drawing_id, image, version, created_at
+		FROM drawing_thumbnails
+		WHERE drawing_id = $1
+	`
+
+	// queryCreateFolder inserts a new folder
+	queryCreateFolder = `
+		INSERT INTO folders (id, parent_id, name, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	// queryFindFolderByID retrieves a folder by its ID
+	queryFindFolderByID = `
+		SELECT id, parent_id, name, created_at, updated_at
+		FROM folders
+		WHERE id = $1
+	`
+
+	// queryFindAllFolders retrieves all folders ordered by name
+	queryFindAllFolders = `
+		SELECT id, parent_id, name, created_at, updated_at
+		FROM folders
+		ORDER BY name ASC, id ASC
+	`
+
+	// queryFindFolderAncestorIDs retrieves the IDs of the folders above a folder, nearest first
+	queryFindFolderAncestorIDs = `
+		WITH RECURSIVE ancestors AS (
+			SELECT parent_id, 1 AS depth
+			FROM folders
+			WHERE id = $1
+			UNION ALL
+			SELECT f.parent_id, a.depth + 1
+			FROM folders f
+			JOIN ancestors a ON f.id = a.parent_id
+		) CYCLE parent_id SET is_cycle USING path
+		SELECT parent_id
+		FROM ancestors
+		WHERE parent_id IS NOT NULL AND NOT is_cycle
+		ORDER BY depth
+	`
+
+	// queryRenameFolder updates the name of an existing folder
+	queryRenameFolder = `
+		UPDATE folders
+		SET name = $2, updated_at = $3
+		WHERE id = $1
+	`
+
+	// queryMoveFolder updates the parent of an existing folder
+	queryMoveFolder = `
+		UPDATE folders
+		SET parent_id = $2, updated_at = $3
+		WHERE id = $1
+	`
+
+	// queryLockFolder retrieves the parent of a folder and locks its row
+	queryLockFolder = `
+		SELECT parent_id
+		FROM folders
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	// queryLockFolderMoves serializes folder moves until the end of the transaction
+	// Row locks alone cannot stop two moves of unrelated folders from closing a loop together
+	queryLockFolderMoves = `
+		SELECT pg_advisory_xact_lock($1)
+	`
+
+	// queryReparentSubfolders moves the subfolders of folder $1 into folder $2
+	queryReparentSubfolders = `
+		UPDATE folders
+		SET parent_id = $2, updated_at = $3
+		WHERE parent_id = $1
+	`
+
+	// queryReparentFolderDrawings moves the drawings of folder $1 into folder $2
+	queryReparentFolderDrawings = `
+		UPDATE drawings
+		SET folder_id = $2, updated_at = $3
+		WHERE folder_id = $1
+	`
+
+	// queryDeleteFolderTreeDrawings deletes the drawings of a folder and of all folders below it
+	queryDeleteFolderTreeDrawings = `
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM folders WHERE id = $1
+			UNION
+			SELECT f.id FROM folders f JOIN subtree s ON f.parent_id = s.id
+		)
+		DELETE FROM drawings
+		WHERE folder_id IN (SELECT id FROM subtree)
+	`
+
+	// queryDeleteFolderTree deletes a folder and all folders below it
+	queryDeleteFolderTree = `
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM folders WHERE id = $1
+			UNION
+			SELECT f.id FROM folders f JOIN subtree s ON f.parent_id = s.id
+		)
+		DELETE FROM folders
+		WHERE id IN (SELECT id FROM subtree)
+	`
+
+	// queryDeleteFolder deletes a folder by ID
+	// The foreign keys of its subfolders and drawings reject the delete if it is not empty
+	queryDeleteFolder = `
+		DELETE FROM folders
+		WHERE id = $1
 	`
 )
+
+// sortColumn is a column drawings can be listed by, with the type its cursor keys are cast to
+type sortColumn struct {
+	name string
+	typ  string
+}
+
+// sortColumns maps the sort fields to their columns; only these names are ever spliced into a query
+var sortColumns = map[drawing.SortField]sortColumn{
+	drawing.SortByName:      {name: "d.name", typ: "varchar"},
+	drawing.SortByCreatedAt: {name: "d.created_at", typ: "timestamp"},
+	drawing.SortByUpdatedAt: {name: "d.updated_at", typ: "timestamp"},
+	drawing.SortBySize:      {name: "d.byte_size", typ: "bigint"},
+}
+
+// drawingSummariesQuery returns the query retrieving drawing summaries in the given order
+// With keyset, the drawings are selected next to a cursor instead of by offset; before selects those
+// preceding it, nearest first. Every value is passed as a parameter
+func drawingSummariesQuery(order drawing.SortOrder, keyset, before bool) string {
+	column, ok := sortColumns[order.Field]
+	if !ok {
+		column = sortColumns[drawing.DefaultSortOrder.Field]
+	}
+
+	// Walking back through the list reverses its order
+	descending := order.Descending != before
+	direction, comparison := "ASC", ">"
+	if descending {
+		direction, comparison = "DESC", "<"
+	}
+	orderBy := fmt.Sprintf("\t\tORDER BY %[1]s %[2]s, d.id %[2]s\n", column.name, direction)
+
+	if !keyset {
+		return queryFindDrawingSummaries + orderBy + "\t\tLIMIT $12 OFFSET $13\n"
+	}
+	return fmt.Sprintf(queryFindDrawingSummariesByCursor, column.name, comparison, column.typ) + orderBy + "\t\tLIMIT $12\n"
+}
@@ -0,0 +1,248 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// RevisionRepository implements the drawing.RevisionRepository interface using PostgreSQL
+type RevisionRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRevisionRepository creates a new RevisionRepository
+func NewRevisionRepository(pool *pgxpool.Pool) *RevisionRepository {
+	return &RevisionRepository{
+		pool: pool,
+	}
+}
+
+// FindByDrawingID retrieves the revisions of a drawing, newest first, with pagination
+func (r *RevisionRepository) FindByDrawingID(ctx context.Context, drawingID uuid.UUID, limit, offset int) ([]*drawing.Revision, error) {
+	// Execute select query
+	rows, err := r.pool.Query(ctx, queryFindRevisionsByDrawingID, drawingID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find revisions: %w", err)
+	}
+	defer rows.Close()
+
+	// Collect revisions
+	var revisions []*drawing.Revision
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating revision rows: %w", err)
+	}
+
+	return revisions, nil
+}
+
+// FindByNumber retrieves a single revision of a drawing
+func (r *RevisionRepository) FindByNumber(ctx context.Context, drawingID uuid.UUID, number int) (*drawing.Revision, error) {
+	// Execute select query
+	rev, err := scanRevision(r.pool.QueryRow(ctx, queryFindRevisionByNumber, drawingID, number))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, drawing.ErrRevisionNotFound
+		}
+		return nil, err
+	}
+
+	return rev, nil
+}
+
+// CountByDrawingID returns the number of revisions of a drawing
+func (r *RevisionRepository) CountByDrawingID(ctx context.Context, drawingID uuid.UUID) (int64, error) {
+	var count int64
+
+	// Execute count query
+	err := r.pool.QueryRow(ctx, queryCountRevisionsByDrawingID, drawingID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count revisions: %w", err)
+	}
+
+	return count, nil
+}
+
+// FindLatestMeta retrieves the metadata of the most recent revision of a drawing, without its data
+func (r *RevisionRepository) FindLatestMeta(ctx context.Context, drawingID uuid.UUID) (*drawing.RevisionMeta, error) {
+	// Execute select query
+	var meta drawing.RevisionMeta
+	err := r.pool.QueryRow(ctx, queryFindLatestRevisionMeta, drawingID).
+		Scan(&meta.ID, &meta.Number, &meta.RestoredFrom, &meta.ClientID, &meta.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, drawing.ErrRevisionNotFound
+		}
+		return nil, fmt.Errorf("failed to find latest revision: %w", err)
+	}
+
+	return &meta, nil
+}
+
+// FindCompactionCandidates retrieves IDs of drawings whose revisions may have aged into a coarser
+// retention bucket of the policy since the drawings were last compacted
+func (r *RevisionRepository) FindCompactionCandidates(ctx context.Context, policy drawing.RevisionPolicy, now time.Time) ([]uuid.UUID, error) {
+	// Execute select query
+	rows, err := r.pool.Query(ctx, queryFindCompactionCandidates,
+		now.Add(-policy.KeepAll), now.Add(-policy.KeepHourly),
+		policy.KeepAll.Microseconds(), policy.KeepHourly.Microseconds(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find compaction candidates: %w", err)
+	}
+	defer rows.Close()
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan compaction candidates: %w", err)
+	}
+
+	return ids, nil
+}
+
+// MarkCompacted records that the revisions of a drawing were compacted at the given time
+func (r *RevisionRepository) MarkCompacted(ctx context.Context, drawingID uuid.UUID, at time.Time) error {
+	// Execute update query
+	if _, err := r.pool.Exec(ctx, queryMarkRevisionsCompacted, drawingID, at); err != nil {
+		return fmt.Errorf("failed to mark revisions compacted: %w", err)
+	}
+
+	return nil
+}
+
+// FindMetaByDrawingID retrieves the lightweight metadata of all revisions of a drawing
+func (r *RevisionRepository) FindMetaByDrawingID(ctx context.Context, drawingID uuid.UUID) ([]drawing.RevisionMeta, error) {
+	// Execute select query
+	rows, err := r.pool.Query(ctx, queryFindRevisionMetaByDrawingID, drawingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find revision metadata: %w", err)
+	}
+	defer rows.Close()
+
+	// Collect revision metadata
+	var metas []drawing.RevisionMeta
+	for rows.Next() {
+		var meta drawing.RevisionMeta
+		if err := rows.Scan(&meta.ID, &meta.Number, &meta.RestoredFrom, &meta.ClientID, &meta.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan revision metadata row: %w", err)
+		}
+		metas = append(metas, meta)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating revision metadata rows: %w", err)
+	}
+
+	return metas, nil
+}
+
+// DeleteByIDs removes revisions by ID
+func (r *RevisionRepository) DeleteByIDs(ctx context.Context, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	// Execute delete query
+	if _, err := r.pool.Exec(ctx, queryDeleteRevisionsByIDs, ids); err != nil {
+		return fmt.Errorf("failed to delete revisions: %w", err)
+	}
+
+	return nil
+}
+
+// writeRevision records a saved drawing in its revision history within the transaction saving it
+// A revision without a number is inserted with the next number of its drawing; a numbered one overwrites
+// the stored revision it was coalesced into
+func writeRevision(ctx context.Context, tx pgx.Tx, rev *drawing.Revision) error {
+	// Convert revision data to JSON bytes
+	dataJSON, err := rev.Data().ToJSON()
+	if err != nil {
+		return fmt.Errorf("failed to marshal revision data: %w", err)
+	}
+
+	if rev.Number() > 0 {
+		// Execute update query
+		result, err := tx.Exec(ctx, queryOverwriteRevision, rev.Name(), dataJSON, rev.DrawingID(), rev.Number(), drawing.CurrentSceneSchema)
+		if err != nil {
+			return fmt.Errorf("failed to overwrite revision: %w", err)
+		}
+
+		// Check if any rows were affected
+		if result.RowsAffected() == 0 {
+			return drawing.ErrRevisionNotFound
+		}
+
+		return nil
+	}
+
+	// Execute insert query; the drawing row is locked or new, so numbers are assigned sequentially
+	var number int
+	err = tx.QueryRow(
+		ctx,
+		queryCreateRevision,
+		rev.ID(),
+		rev.DrawingID(),
+		rev.Name(),
+		dataJSON,
+		rev.RestoredFrom(),
+		rev.ClientID(),
+		rev.CreatedAt(),
+		drawing.CurrentSceneSchema,
+	).Scan(&number)
+	if err != nil {
+		return fmt.Errorf("failed to create revision: %w", err)
+	}
+
+	rev.SetNumber(number)
+
+	return nil
+}
+
+// scanRevision scans a single revision row into a revision entity
+func scanRevision(row pgx.Row) (*drawing.Revision, error) {
+	var (
+		id, drawingID uuid.UUID
+		number        int
+		name          string
+		dataJSON      []byte
+		schemaVersion int
+		restoredFrom  int
+		clientID      string
+		createdAt     time.Time
+	)
+
+	if err := row.Scan(&id, &drawingID, &number, &name, &dataJSON, &schemaVersion, &restoredFrom, &clientID, &createdAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan revision row: %w", err)
+	}
+
+	// Parse revision data from JSON
+	data, err := drawing.FromJSON(dataJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal revision data: %w", err)
+	}
+
+	// Upgrade revisions saved with an older scene schema
+	if err := drawing.UpgradeScene(data, schemaVersion); err != nil {
+		return nil, fmt.Errorf("failed to upgrade revision data: %w", err)
+	}
+
+	return drawing.ReconstituteRevision(id, drawingID, number, name, data, restoredFrom, clientID, createdAt), nil
+}
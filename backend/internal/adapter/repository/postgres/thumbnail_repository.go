@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// pgForeignKeyViolation is the PostgreSQL error code for foreign key violations
+const pgForeignKeyViolation = "23503"
+
+// ThumbnailRepository implements the drawing.ThumbnailRepository interface using PostgreSQL
+type ThumbnailRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewThumbnailRepository creates a new ThumbnailRepository
+func NewThumbnailRepository(pool *pgxpool.Pool) *ThumbnailRepository {
+	return &ThumbnailRepository{
+		pool: pool,
+	}
+}
+
+// Save stores the thumbnail of a drawing, unless a thumbnail of a newer version is already stored
+func (r *ThumbnailRepository) Save(ctx context.Context, t *drawing.Thumbnail) error {
+	_, err := r.pool.Exec(ctx, querySaveThumbnail, t.DrawingID(), t.Image(), t.Version(), t.CreatedAt())
+	if err != nil {
+		// The drawing was deleted while its thumbnail was rendered
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation {
+			return drawing.ErrDrawingNotFound
+		}
+		return fmt.Errorf("failed to save thumbnail: %w", err)
+	}
+
+	return nil
+}
+
+// FindByDrawingID retrieves the thumbnail of a drawing
+func (r *ThumbnailRepository) FindByDrawingID(ctx context.Context, drawingID uuid.UUID) (*drawing.Thumbnail, error) {
+	var (
+		id        uuid.UUID
+		image     []byte
+		version   int64
+		createdAt time.Time
+	)
+
+	// Execute select query
+	err := r.pool.QueryRow(ctx, queryFindThumbnailByDrawingID, drawingID).Scan(&id, &image, &version, &createdAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, drawing.ErrThumbnailNotFound
+		}
+		return nil, fmt.Errorf("failed to find thumbnail: %w", err)
+	}
+
+	return drawing.ReconstituteThumbnail(id, image, version, createdAt), nil
+}
@@ -0,0 +1,97 @@
+package drawing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// RevisionCompactor periodically thins out revision history according to the retention policy
+type RevisionCompactor struct {
+	revisions drawing.RevisionRepository
+	policy    drawing.RevisionPolicy
+	interval  time.Duration
+	logger    *slog.Logger
+}
+
+// NewRevisionCompactor creates a new revision compactor
+func NewRevisionCompactor(revisions drawing.RevisionRepository, policy drawing.RevisionPolicy, interval time.Duration, logger *slog.Logger) *RevisionCompactor {
+	return &RevisionCompactor{
+		revisions: revisions,
+		policy:    policy,
+		interval:  interval,
+		logger:    logger,
+	}
+}
+
+// Run compacts revisions every interval until the context is cancelled
+func (c *RevisionCompactor) Run(ctx context.Context) {
+	if c.interval <= 0 {
+		c.logger.Info("revision compactor disabled")
+		return
+	}
+
+	c.logger.Info("revision compactor started", "interval", c.interval)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := c.Compact(ctx); err != nil && ctx.Err() == nil {
+			c.logger.Error("revision compaction failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			c.logger.Info("revision compactor stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Compact deletes the revisions the retention policy discards and returns how many were deleted
+func (c *RevisionCompactor) Compact(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+
+	drawingIDs, err := c.revisions.FindCompactionCandidates(ctx, c.policy, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find compaction candidates: %w", err)
+	}
+
+	deleted := 0
+	for _, drawingID := range drawingIDs {
+		metas, err := c.revisions.FindMetaByDrawingID(ctx, drawingID)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to load revisions of drawing %s: %w", drawingID, err)
+		}
+
+		if pruned := c.policy.Prune(metas, now); len(pruned) > 0 {
+			ids := make([]uuid.UUID, len(pruned))
+			for i, meta := range pruned {
+				ids[i] = meta.ID
+			}
+
+			if err := c.revisions.DeleteByIDs(ctx, ids); err != nil {
+				return deleted, fmt.Errorf("failed to delete revisions of drawing %s: %w", drawingID, err)
+			}
+
+			deleted += len(ids)
+		}
+
+		// Skip the drawing on later runs until one of its revisions ages into another bucket
+		if err := c.revisions.MarkCompacted(ctx, drawingID, now); err != nil {
+			return deleted, fmt.Errorf("failed to mark revisions of drawing %s compacted: %w", drawingID, err)
+		}
+	}
+
+	if deleted > 0 {
+		c.logger.Info("revisions compacted", "drawings", len(drawingIDs), "deleted", deleted)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,121 @@
+package drawing
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+func TestRevisionCompactorCompact(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	policy := drawing.RevisionPolicy{
+		KeepAll:    time.Hour,
+		KeepHourly: 24 * time.Hour,
+	}
+
+	now := time.Now().UTC()
+	hour := now.Add(-5 * time.Hour).Truncate(time.Hour)
+	day := now.Add(-72 * time.Hour).Truncate(24 * time.Hour)
+
+	meta := func(number int, createdAt time.Time) drawing.RevisionMeta {
+		return drawing.RevisionMeta{ID: uuid.New(), Number: number, CreatedAt: createdAt}
+	}
+
+	revisions := []drawing.RevisionMeta{
+		meta(9, now.Add(-time.Minute)),            // kept: newest
+		meta(8, now.Add(-10*time.Minute)),         // kept: within keep-all window
+		meta(7, hour.Add(40*time.Minute)),         // kept: newest of its hour
+		meta(6, hour.Add(20*time.Minute)),         // pruned: same hour as 7
+		meta(5, hour.Add(5*time.Minute)),          // pruned: same hour as 7
+		meta(4, day.Add(20*time.Hour)),            // kept: newest of its day
+		meta(3, day.Add(2*time.Hour)),             // pruned: same day as 4
+		meta(2, day.Add(-22*time.Hour)),           // kept: only one of its day
+		meta(1, day.Add(-48*time.Hour+time.Hour)), // kept: only one of its day
+	}
+
+	drawingID, untouchedID := uuid.New(), uuid.New()
+	var deleted, marked []uuid.UUID
+	revisionRepo := &mockRevisionRepository{
+		findCompactionCandidatesFunc: func(ctx context.Context, p drawing.RevisionPolicy, at time.Time) ([]uuid.UUID, error) {
+			if p != policy {
+				t.Errorf("expected policy %+v, got %+v", policy, p)
+			}
+			if at.Sub(now) > time.Second || at.Before(now) {
+				t.Errorf("expected candidates as of now, got %s", at)
+			}
+			return []uuid.UUID{drawingID, untouchedID}, nil
+		},
+		findMetaByDrawingIDFunc: func(ctx context.Context, id uuid.UUID) ([]drawing.RevisionMeta, error) {
+			if id == untouchedID {
+				return revisions[:2], nil
+			}
+			return revisions, nil
+		},
+		deleteByIDsFunc: func(ctx context.Context, ids []uuid.UUID) error {
+			deleted = append(deleted, ids...)
+			return nil
+		},
+		markCompactedFunc: func(ctx context.Context, id uuid.UUID, at time.Time) error {
+			marked = append(marked, id)
+			return nil
+		},
+	}
+
+	compactor := NewRevisionCompactor(revisionRepo, policy, time.Minute, logger)
+
+	count, err := compactor.Compact(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("expected 3 revisions deleted, got %d", count)
+	}
+
+	expected := map[uuid.UUID]bool{revisions[3].ID: true, revisions[4].ID: true, revisions[6].ID: true}
+	for _, id := range deleted {
+		if !expected[id] {
+			t.Errorf("unexpected revision deleted: %s", id)
+		}
+	}
+
+	// Drawings with nothing to prune are marked too, so later runs skip them
+	if len(marked) != 2 || marked[0] != drawingID || marked[1] != untouchedID {
+		t.Errorf("expected both drawings marked compacted, got %v", marked)
+	}
+}
+
+func TestRevisionCompactorRunStopsOnCancel(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	runs := make(chan struct{}, 10)
+	revisionRepo := &mockRevisionRepository{
+		findCompactionCandidatesFunc: func(ctx context.Context, policy drawing.RevisionPolicy, now time.Time) ([]uuid.UUID, error) {
+			runs <- struct{}{}
+			return nil, nil
+		},
+	}
+
+	compactor := NewRevisionCompactor(revisionRepo, drawing.RevisionPolicy{}, time.Hour, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		compactor.Run(ctx)
+		close(done)
+	}()
+
+	<-runs
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("compactor did not stop after cancel")
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/personal-excalidraw/backend/internal/domain/diagram"
 	"github.com/personal-excalidraw/backend/internal/domain/drawing"
 )
 
@@ -12,45 +13,283 @@ import (
 type CreateDrawingInput struct {
 	Name string
 	Data map[string]interface{}
+
+	// Slug is a custom slug for the drawing; empty generates one
+	Slug string
 }
 
 // UpdateDrawingInput represents input for updating a drawing
 type UpdateDrawingInput struct {
 	Name string
+	Slug string
 	Data map[string]interface{}
+
+	// Merge reconciles Data with the stored scene element by element instead of replacing it
+	Merge bool
+
+	// ClientID identifies the editor session (e.g. browser tab) that saved the drawing
+	ClientID string
+
+	// ExpectedVersions are the versions the client accepts the drawing at; empty skips the check
+	ExpectedVersions []int64
+}
+
+// PatchFormat identifies the patch document format of a PatchDrawingInput
+type PatchFormat string
+
+const (
+	// PatchFormatJSONPatch is an RFC 6902 JSON Patch document
+	PatchFormatJSONPatch PatchFormat = "json-patch"
+
+	// PatchFormatMergePatch is an RFC 7396 JSON Merge Patch document
+	PatchFormatMergePatch PatchFormat = "merge-patch"
+)
+
+// PatchDrawingInput represents input for patching the data of a drawing
+type PatchDrawingInput struct {
+	Format PatchFormat
+	Patch  []byte
+
+	// ClientID identifies the editor session (e.g. browser tab) that saved the drawing
+	ClientID string
+
+	// ExpectedVersions are the versions the client accepts the drawing at; empty skips the check
+	ExpectedVersions []int64
+}
+
+// ImportFile is an uploaded file to import as a drawing
+type ImportFile struct {
+	Filename string
+	Content  []byte
+}
+
+// DrawioPages selects how the pages of a draw.io file are imported
+type DrawioPages string
+
+const (
+	// DrawioPagesDrawings creates a drawing per page
+	DrawioPagesDrawings DrawioPages = "drawings"
+
+	// DrawioPagesFrames creates a single drawing with a frame per page
+	DrawioPagesFrames DrawioPages = "frames"
+)
+
+// ImportInput represents input for importing drawings from files
+type ImportInput struct {
+	Files []ImportFile
+
+	// DrawioPages selects how multi-page draw.io files are imported; empty creates a drawing per page
+	DrawioPages DrawioPages
+}
+
+// ImportFailure describes a file that could not be imported
+type ImportFailure struct {
+	Filename string
+	Err      error
+}
+
+// ImportOutput represents the drawings created by an import and the files that failed
+type ImportOutput struct {
+	Drawings []*DrawingOutput
+	Failures []*ImportFailure
+}
+
+// ImportMermaidInput represents input for creating a drawing from Mermaid diagram source
+type ImportMermaidInput struct {
+	// Name names the drawing; empty uses the title declared by the source, or a default
+	Name   string
+	Source string
+}
+
+// GenerateDrawingInput represents input for generating a drawing from diagram DSL source
+type GenerateDrawingInput struct {
+	// Name names the drawing; empty uses the title declared by the source, or a default
+	Name   string
+	Source string
+
+	// Slug is the custom slug of the drawing; with Upsert, it selects the drawing to update
+	Slug string
+
+	// Upsert updates the drawing with Slug if there is one, keeping the nodes the user moved in place
+	Upsert bool
+
+	// ClientID identifies the session that saved the drawing
+	ClientID string
+
+	// ExpectedVersions are the versions the client accepts the drawing at; empty skips the check
+	ExpectedVersions []int64
+}
+
+// GenerateDrawingOutput represents a generated drawing and whether it was created
+type GenerateDrawingOutput struct {
+	Drawing *DrawingOutput
+	Created bool
+}
+
+// LayoutDrawingInput represents input for laying out the shapes of a drawing
+type LayoutDrawingInput struct {
+	// Algorithm is the layout to run; empty runs the layered layout
+	Algorithm diagram.LayoutAlgorithm
+
+	// Direction is the direction of the layered layout; empty lays out top to bottom
+	Direction diagram.Direction
+
+	// DryRun returns the laid out drawing without saving it
+	DryRun bool
+
+	// ClientID identifies the session that saved the drawing
+	ClientID string
+
+	// ExpectedVersions are the versions the client accepts the drawing at; empty skips the check
+	ExpectedVersions []int64
+}
+
+// ExportInput represents input for exporting a drawing as an image
+type ExportInput struct {
+	// Padding is the space around the elements; nil uses drawing.DefaultExportPadding
+	Padding *float64
+
+	// DarkMode renders the drawing in the colors of the editor's dark theme
+	DarkMode bool
+
+	// Scale is the number of pixels per scene unit of raster exports; nil means 1
+	Scale *float64
+
+	// EmbedScene stores the scene in PNG and SVG exports, so they can be imported as editable drawings
+	EmbedScene bool
+}
+
+// ExportOutput represents an exported drawing
+type ExportOutput struct {
+	Name      string
+	Content   []byte
+	Version   int64
+	UpdatedAt time.Time
 }
 
 // ListDrawingsInput represents input for listing drawings
 type ListDrawingsInput struct {
 	Limit  int
 	Offset int
+
+	// Cursor is a token from a previous page selecting the drawings next to it; Offset is ignored with it
+	// It must come from a list in the same Order
+	Cursor string
+
+	// Order is the order of the list; the zero value lists the newest drawings first
+	Order drawing.SortOrder
+
+	// Filter narrows the list; Total counts the drawings matching it
+	Filter drawing.DrawingFilter
+
+	// Fields lists the heavy fields of the drawings to include; the summaries leave them out by default
+	Fields []drawing.SummaryField
 }
 
 // DrawingOutput represents a drawing response
 type DrawingOutput struct {
 	ID        uuid.UUID
+	Slug      string
 	Name      string
 	Data      map[string]interface{}
+	Version   int64
 	CreatedAt time.Time
 	UpdatedAt time.Time
+
+	// FolderID is the folder holding the drawing; nil outside any folder
+	FolderID *uuid.UUID
+}
+
+// DrawingSummaryOutput represents a drawing in a list, without its scene unless asked for
+type DrawingSummaryOutput struct {
+	ID               uuid.UUID
+	Slug             string
+	Name             string
+	Version          int64
+	FolderID         *uuid.UUID
+	ElementCount     int
+	ByteSize         int64
+	ThumbnailVersion int64
+	Data             map[string]interface{}
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
 }
 
 // DrawingListOutput represents a paginated list of drawings
 type DrawingListOutput struct {
-	Drawings []*DrawingOutput
+	Drawings []*DrawingSummaryOutput
 	Total    int64
 	Limit    int
 	Offset   int
+
+	// NextCursor and PrevCursor select the following and preceding pages; empty at either end
+	NextCursor string
+	PrevCursor string
+}
+
+// SearchDrawingsInput represents input for a full-text search of drawings
+type SearchDrawingsInput struct {
+	// Query is in web search syntax: words, "quoted phrases", OR and -excluded words
+	Query  string
+	Limit  int
+	Offset int
+}
+
+// SearchResultOutput represents a drawing matching a search
+type SearchResultOutput struct {
+	ID         uuid.UUID
+	Slug       string
+	Name       string
+	Version    int64
+	Rank       float64
+	Snippet    []drawing.SnippetFragment
+	ElementIDs []string
+	UpdatedAt  time.Time
+}
+
+// SearchListOutput represents a paginated list of search results, best match first
+type SearchListOutput struct {
+	Results []*SearchResultOutput
+	Total   int64
+	Limit   int
+	Offset  int
+}
+
+// ListRevisionsInput represents input for listing the revisions of a drawing
+type ListRevisionsInput struct {
+	Limit  int
+	Offset int
+}
+
+// RevisionOutput represents a drawing revision response
+type RevisionOutput struct {
+	Number       int
+	DrawingID    uuid.UUID
+	Name         string
+	Data         map[string]interface{}
+	RestoredFrom int
+	CreatedAt    time.Time
+}
+
+// RevisionListOutput represents a paginated list of revisions
+type RevisionListOutput struct {
+	Revisions []*RevisionOutput
+	Total     int64
+	Limit     int
+	Offset    int
 }
 
 // ToOutput converts a domain drawing to a DrawingOutput DTO
 func ToOutput(d *drawing.Drawing) *DrawingOutput {
 	return &DrawingOutput{
 		ID:        d.ID(),
+		Slug:      d.Slug(),
 		Name:      d.Name(),
 		Data:      d.Data(),
+		Version:   d.Version(),
 		CreatedAt: d.CreatedAt(),
 		UpdatedAt: d.UpdatedAt(),
+		FolderID:  d.FolderID(),
 	}
 }
 
@@ -62,3 +301,63 @@ func ToOutputList(drawings []*drawing.Drawing) []*DrawingOutput {
 	}
 	return outputs
 }
+
+// ToSummaryOutputList converts a list of drawing summaries to DrawingSummaryOutput DTOs
+func ToSummaryOutputList(summaries []*drawing.DrawingSummary) []*DrawingSummaryOutput {
+	outputs := make([]*DrawingSummaryOutput, len(summaries))
+	for i, s := range summaries {
+		outputs[i] = &DrawingSummaryOutput{
+			ID:               s.ID,
+			Slug:             s.Slug,
+			Name:             s.Name,
+			Version:          s.Version,
+			FolderID:         s.FolderID,
+			ElementCount:     s.ElementCount,
+			ByteSize:         s.ByteSize,
+			ThumbnailVersion: s.ThumbnailVersion,
+			Data:             s.Data,
+			CreatedAt:        s.CreatedAt,
+			UpdatedAt:        s.UpdatedAt,
+		}
+	}
+	return outputs
+}
+
+// ToSearchResultOutputList converts a list of search results to SearchResultOutput DTOs
+func ToSearchResultOutputList(results []*drawing.SearchResult) []*SearchResultOutput {
+	outputs := make([]*SearchResultOutput, len(results))
+	for i, r := range results {
+		outputs[i] = &SearchResultOutput{
+			ID:         r.ID,
+			Slug:       r.Slug,
+			Name:       r.Name,
+			Version:    r.Version,
+			Rank:       r.Rank,
+			Snippet:    r.Snippet,
+			ElementIDs: r.ElementIDs,
+			UpdatedAt:  r.UpdatedAt,
+		}
+	}
+	return outputs
+}
+
+// ToRevisionOutput converts a domain revision to a RevisionOutput DTO
+func ToRevisionOutput(r *drawing.Revision) *RevisionOutput {
+	return &RevisionOutput{
+		Number:       r.Number(),
+		DrawingID:    r.DrawingID(),
+		Name:         r.Name(),
+		Data:         r.Data(),
+		RestoredFrom: r.RestoredFrom(),
+		CreatedAt:    r.CreatedAt(),
+	}
+}
+
+// ToRevisionOutputList converts a list of domain revisions to RevisionOutput DTOs
+func ToRevisionOutputList(revisions []*drawing.Revision) []*RevisionOutput {
+	outputs := make([]*RevisionOutput, len(revisions))
+	for i, r := range revisions {
+		outputs[i] = ToRevisionOutput(r)
+	}
+	return outputs
+}
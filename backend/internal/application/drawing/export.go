@@ -0,0 +1,233 @@
+package drawing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// ExportService renders stored drawings to image formats
+type ExportService struct {
+	repo       drawing.Repository
+	renderer   drawing.Renderer
+	thumbnails *ThumbnailWorker
+	logger     *slog.Logger
+}
+
+// NewExportService creates a new export service
+func NewExportService(repo drawing.Repository, renderer drawing.Renderer, thumbnails *ThumbnailWorker, logger *slog.Logger) *ExportService {
+	return &ExportService{
+		repo:       repo,
+		renderer:   renderer,
+		thumbnails: thumbnails,
+		logger:     logger,
+	}
+}
+
+// ExportSVG renders a drawing as an SVG image
+func (s *ExportService) ExportSVG(ctx context.Context, id string, input ExportInput) (*ExportOutput, error) {
+	s.logger.Info("exporting drawing as SVG", "id", id)
+
+	opts := exportOptions(input)
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	d, scene, err := s.loadScene(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.EmbedScene {
+		if opts.EmbeddedScene, err = embeddedScene(d); err != nil {
+			s.logger.Error("failed to embed scene", "id", d.ID(), "error", err)
+			return nil, err
+		}
+	}
+
+	content, err := s.renderer.RenderSVG(ctx, scene, opts)
+	if err != nil {
+		s.logger.Error("failed to render drawing", "id", d.ID(), "error", err)
+		return nil, fmt.Errorf("failed to render drawing: %w", err)
+	}
+
+	s.logger.Info("drawing exported successfully", "id", d.ID(), "format", "svg", "bytes", len(content))
+
+	return exportOutput(d, content), nil
+}
+
+// ExportPNG renders a drawing as a PNG image
+func (s *ExportService) ExportPNG(ctx context.Context, id string, input ExportInput) (*ExportOutput, error) {
+	s.logger.Info("exporting drawing as PNG", "id", id)
+
+	opts := exportOptions(input)
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	d, scene, err := s.loadScene(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.EmbedScene {
+		if opts.EmbeddedScene, err = embeddedScene(d); err != nil {
+			s.logger.Error("failed to embed scene", "id", d.ID(), "error", err)
+			return nil, err
+		}
+	}
+
+	content, err := s.renderer.RenderPNG(ctx, scene, opts)
+	if err != nil {
+		s.logger.Error("failed to render drawing", "id", d.ID(), "error", err)
+		return nil, fmt.Errorf("failed to render drawing: %w", err)
+	}
+
+	s.logger.Info("drawing exported successfully", "id", d.ID(), "format", "png", "bytes", len(content))
+
+	return exportOutput(d, content), nil
+}
+
+// ExportPDF renders a drawing as a PDF document with a page per frame
+func (s *ExportService) ExportPDF(ctx context.Context, id string, input ExportInput) (*ExportOutput, error) {
+	s.logger.Info("exporting drawing as PDF", "id", id)
+
+	opts := exportOptions(input)
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	d, scene, err := s.loadScene(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := s.renderer.RenderPDF(ctx, scene, opts)
+	if err != nil {
+		s.logger.Error("failed to render drawing", "id", d.ID(), "error", err)
+		return nil, fmt.Errorf("failed to render drawing: %w", err)
+	}
+
+	s.logger.Info("drawing exported successfully", "id", d.ID(), "format", "pdf", "bytes", len(content))
+
+	return exportOutput(d, content), nil
+}
+
+// ExportExcalidraw returns a drawing as a .excalidraw file
+func (s *ExportService) ExportExcalidraw(ctx context.Context, id string) (*ExportOutput, error) {
+	s.logger.Info("exporting drawing as excalidraw file", "id", id)
+
+	drawingID, err := uuid.Parse(id)
+	if err != nil {
+		s.logger.Error("invalid drawing ID format", "id", id, "error", err)
+		return nil, fmt.Errorf("invalid drawing ID: %w", err)
+	}
+
+	d, err := s.repo.FindByID(ctx, drawingID)
+	if err != nil {
+		s.logger.Error("failed to get drawing", "id", drawingID, "error", err)
+		return nil, err
+	}
+
+	content, err := drawing.NewExcalidrawFile(d.Data())
+	if err != nil {
+		s.logger.Error("failed to write excalidraw file", "id", d.ID(), "error", err)
+		return nil, fmt.Errorf("failed to write excalidraw file: %w", err)
+	}
+
+	s.logger.Info("drawing exported successfully", "id", d.ID(), "format", "excalidraw", "bytes", len(content))
+
+	return exportOutput(d, content), nil
+}
+
+// GetThumbnail returns the PNG thumbnail of a drawing, rendering it first if it is missing or outdated
+func (s *ExportService) GetThumbnail(ctx context.Context, id string) (*ExportOutput, error) {
+	drawingID, err := uuid.Parse(id)
+	if err != nil {
+		s.logger.Error("invalid drawing ID format", "id", id, "error", err)
+		return nil, fmt.Errorf("invalid drawing ID: %w", err)
+	}
+
+	d, err := s.repo.FindByID(ctx, drawingID)
+	if err != nil {
+		s.logger.Error("failed to get drawing", "id", drawingID, "error", err)
+		return nil, err
+	}
+
+	t, err := s.thumbnails.Thumbnail(ctx, d)
+	if err != nil {
+		s.logger.Error("failed to get thumbnail", "id", drawingID, "error", err)
+		return nil, err
+	}
+
+	return &ExportOutput{
+		Name:      d.Name(),
+		Content:   t.Image(),
+		Version:   t.Version(),
+		UpdatedAt: t.CreatedAt(),
+	}, nil
+}
+
+// loadScene retrieves a drawing and parses its scene
+func (s *ExportService) loadScene(ctx context.Context, id string) (*drawing.Drawing, *drawing.Scene, error) {
+	drawingID, err := uuid.Parse(id)
+	if err != nil {
+		s.logger.Error("invalid drawing ID format", "id", id, "error", err)
+		return nil, nil, fmt.Errorf("invalid drawing ID: %w", err)
+	}
+
+	d, err := s.repo.FindByID(ctx, drawingID)
+	if err != nil {
+		s.logger.Error("failed to get drawing", "id", drawingID, "error", err)
+		return nil, nil, err
+	}
+
+	scene, err := d.Data().Scene()
+	if err != nil {
+		s.logger.Error("failed to parse drawing scene", "id", drawingID, "error", err)
+		return nil, nil, err
+	}
+
+	return d, scene, nil
+}
+
+// exportOptions converts export input to domain export options
+func exportOptions(input ExportInput) drawing.ExportOptions {
+	opts := drawing.ExportOptions{
+		Padding:  drawing.DefaultExportPadding,
+		DarkMode: input.DarkMode,
+	}
+	if input.Padding != nil {
+		opts.Padding = *input.Padding
+	}
+	if input.Scale != nil {
+		opts.Scale = *input.Scale
+	}
+	return opts
+}
+
+// embeddedScene encodes a drawing as the scene envelope embedded in exported images
+func embeddedScene(d *drawing.Drawing) ([]byte, error) {
+	file, err := drawing.NewExcalidrawFile(d.Data())
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed scene: %w", err)
+	}
+	envelope, err := drawing.EncodeEmbeddedScene(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed scene: %w", err)
+	}
+	return envelope, nil
+}
+
+// exportOutput wraps rendered content with the drawing it was rendered from
+func exportOutput(d *drawing.Drawing, content []byte) *ExportOutput {
+	return &ExportOutput{
+		Name:      d.Name(),
+		Content:   content,
+		Version:   d.Version(),
+		UpdatedAt: d.UpdatedAt(),
+	}
+}
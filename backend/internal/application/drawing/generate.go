@@ -0,0 +1,101 @@
+package drawing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/personal-excalidraw/backend/internal/domain/diagram"
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// GenerateDrawing compiles diagram DSL source into a laid out drawing
+// In upsert mode the drawing with the given slug is regenerated in place if it exists, keeping the
+// nodes the user moved and the elements they added; otherwise a new drawing is created
+func (s *Service) GenerateDrawing(ctx context.Context, input GenerateDrawingInput) (*GenerateDrawingOutput, error) {
+	s.logger.Info("generating drawing", "name", input.Name, "slug", input.Slug, "upsert", input.Upsert, "size", len(input.Source))
+
+	if input.Upsert {
+		if input.Slug == "" {
+			return nil, fmt.Errorf("%w: upsert needs the slug of the drawing to update", drawing.ErrInvalidSlug)
+		}
+
+		// Retrieve from repository, falling back to previous slugs of renamed drawings
+		d, err := s.repo.FindBySlug(ctx, input.Slug)
+		if errors.Is(err, drawing.ErrDrawingNotFound) {
+			d, err = s.repo.FindBySlugAlias(ctx, input.Slug)
+		}
+		switch {
+		case err == nil:
+			return s.regenerate(ctx, d, input)
+		case !errors.Is(err, drawing.ErrDrawingNotFound):
+			s.logger.Error("failed to get drawing by slug", "slug", input.Slug, "error", err)
+			return nil, err
+		}
+	}
+
+	result, err := diagram.CompileDSL(ctx, input.Source, nil)
+	if err != nil {
+		s.logger.Error("failed to compile diagram", "error", err)
+		return nil, err
+	}
+
+	// A name given by the client must be valid, while a title from the source is cut to fit
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		name = truncateName(strings.TrimSpace(result.Title))
+	}
+	if name == "" {
+		name = drawing.DefaultImportName
+	}
+
+	created, err := s.CreateDrawing(ctx, CreateDrawingInput{
+		Name: name,
+		Data: result.Data,
+		Slug: input.Slug,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &GenerateDrawingOutput{Drawing: created, Created: true}, nil
+}
+
+// regenerate compiles the source over the scene of an existing drawing and saves it as a new revision
+func (s *Service) regenerate(ctx context.Context, d *drawing.Drawing, input GenerateDrawingInput) (*GenerateDrawingOutput, error) {
+	// Reject the update if the drawing changed since the client loaded it
+	if err := d.CheckVersion(input.ExpectedVersions...); err != nil {
+		s.logger.Info("drawing version conflict", "id", d.ID(), "expected", input.ExpectedVersions, "current", d.Version())
+		return nil, err
+	}
+
+	result, err := diagram.CompileDSL(ctx, input.Source, d.Data())
+	if err != nil {
+		s.logger.Error("failed to compile diagram", "id", d.ID(), "error", err)
+		return nil, err
+	}
+
+	// If name is not provided, keep the existing name
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		name = d.Name()
+	}
+
+	if err := d.Update(name, result.Data); err != nil {
+		s.logger.Error("failed to update drawing domain object", "error", err)
+		return nil, fmt.Errorf("failed to update drawing: %w", err)
+	}
+
+	// Persist to repository along with a revision of its own, never folded into an autosave burst,
+	// so the scene from before the regeneration can be restored
+	if err := s.repo.Update(ctx, d, drawing.NewRevision(d, input.ClientID)); err != nil {
+		s.logger.Error("failed to persist regenerated drawing", "error", err)
+		return nil, fmt.Errorf("failed to save drawing: %w", versionConflict(err, input.ExpectedVersions))
+	}
+
+	s.logger.Info("drawing regenerated successfully", "id", d.ID())
+
+	s.scheduleThumbnail(d.ID())
+
+	return &GenerateDrawingOutput{Drawing: ToOutput(d)}, nil
+}
@@ -0,0 +1,137 @@
+package drawing
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/personal-excalidraw/backend/internal/domain/diagram"
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// ImportDrawings creates a drawing from each uploaded .excalidraw file or image with an embedded scene, named after the file
+// draw.io files become a drawing per page, or a single drawing with a frame per page
+// A file that fails to import is reported in the output without stopping the others
+func (s *Service) ImportDrawings(ctx context.Context, input ImportInput) (*ImportOutput, error) {
+	s.logger.Info("importing drawings", "files", len(input.Files), "drawio_pages", input.DrawioPages)
+
+	output := &ImportOutput{}
+	for _, file := range input.Files {
+		created, err := s.importFile(ctx, file, input.DrawioPages)
+		output.Drawings = append(output.Drawings, created...)
+		if err != nil {
+			s.logger.Error("failed to import drawing", "filename", file.Filename, "error", err)
+			output.Failures = append(output.Failures, &ImportFailure{Filename: file.Filename, Err: err})
+		}
+	}
+
+	s.logger.Info("drawings imported", "imported", len(output.Drawings), "failed", len(output.Failures))
+
+	return output, nil
+}
+
+// importFile creates the drawings of a single uploaded file
+// The drawings created before an error are returned with it
+func (s *Service) importFile(ctx context.Context, file ImportFile, pages DrawioPages) ([]*DrawingOutput, error) {
+	if diagram.IsDrawio(file.Content) || strings.EqualFold(path.Ext(file.Filename), diagram.DrawioFileExtension) {
+		return s.importDrawio(ctx, file, pages)
+	}
+
+	data, err := drawing.ParseSceneFile(file.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.CreateDrawing(ctx, CreateDrawingInput{
+		Name: drawing.ImportName(file.Filename),
+		Data: data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []*DrawingOutput{created}, nil
+}
+
+// importDrawio creates the drawings of a draw.io file: one per page named "<file> - <page>",
+// or one named after the file with a frame per page
+func (s *Service) importDrawio(ctx context.Context, file ImportFile, pages DrawioPages) ([]*DrawingOutput, error) {
+	name := strings.TrimSuffix(drawing.ImportName(file.Filename), diagram.DrawioFileExtension)
+	if name == "" {
+		name = drawing.DefaultImportName
+	}
+
+	if pages == DrawioPagesFrames {
+		result, err := diagram.ConvertDrawioFrames(file.Content)
+		if err != nil {
+			return nil, err
+		}
+		created, err := s.CreateDrawing(ctx, CreateDrawingInput{Name: name, Data: result.Data})
+		if err != nil {
+			return nil, err
+		}
+		return []*DrawingOutput{created}, nil
+	}
+
+	results, err := diagram.ConvertDrawio(file.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	var created []*DrawingOutput
+	for i, result := range results {
+		pageName := name
+		if len(results) > 1 {
+			title := strings.TrimSpace(result.Title)
+			if title == "" {
+				title = fmt.Sprintf("Page %d", i+1)
+			}
+			pageName = truncateName(name + " - " + title)
+		}
+
+		output, err := s.CreateDrawing(ctx, CreateDrawingInput{Name: pageName, Data: result.Data})
+		if err != nil {
+			return created, fmt.Errorf("page %q: %w", result.Title, err)
+		}
+		created = append(created, output)
+	}
+	return created, nil
+}
+
+// truncateName cuts a name to the maximum length of drawing names at a character boundary
+func truncateName(name string) string {
+	if len(name) <= drawing.MaxNameLength {
+		return name
+	}
+	name = name[:drawing.MaxNameLength]
+	for !utf8.ValidString(name) {
+		name = name[:len(name)-1]
+	}
+	return name
+}
+
+// ImportMermaid creates a drawing from a Mermaid flowchart or sequence diagram, laid out as Excalidraw shapes and arrows
+func (s *Service) ImportMermaid(ctx context.Context, input ImportMermaidInput) (*DrawingOutput, error) {
+	s.logger.Info("importing mermaid diagram", "name", input.Name, "size", len(input.Source))
+
+	result, err := diagram.ConvertMermaid(ctx, input.Source)
+	if err != nil {
+		s.logger.Error("failed to convert mermaid diagram", "error", err)
+		return nil, err
+	}
+
+	// A name given by the client must be valid, while a title from the source is cut to fit
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		name = truncateName(strings.TrimSpace(result.Title))
+	}
+	if name == "" {
+		name = drawing.DefaultImportName
+	}
+
+	return s.CreateDrawing(ctx, CreateDrawingInput{
+		Name: name,
+		Data: result.Data,
+	})
+}
@@ -0,0 +1,68 @@
+package drawing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/personal-excalidraw/backend/internal/domain/diagram"
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// LayoutDrawing repositions the shapes of a drawing and the arrows bound between them
+// The laid out scene is saved as a new revision, unless the input asks for a dry run, in which
+// case it is only returned
+func (s *Service) LayoutDrawing(ctx context.Context, id string, input LayoutDrawingInput) (*DrawingOutput, error) {
+	s.logger.Info("laying out drawing", "id", id, "algorithm", input.Algorithm, "direction", input.Direction, "dryRun", input.DryRun)
+
+	// Parse UUID from string
+	drawingID, err := uuid.Parse(id)
+	if err != nil {
+		s.logger.Error("invalid drawing ID format", "id", id, "error", err)
+		return nil, fmt.Errorf("invalid drawing ID: %w", err)
+	}
+
+	// Retrieve from repository
+	d, err := s.repo.FindByID(ctx, drawingID)
+	if err != nil {
+		s.logger.Error("failed to get drawing", "id", drawingID, "error", err)
+		return nil, err
+	}
+
+	// Reject the update if the drawing changed since the client loaded it
+	if err := d.CheckVersion(input.ExpectedVersions...); err != nil {
+		s.logger.Info("drawing version conflict", "id", drawingID, "expected", input.ExpectedVersions, "current", d.Version())
+		return nil, err
+	}
+
+	data, err := diagram.LayoutScene(ctx, d.Data(), diagram.SceneLayoutOptions{Algorithm: input.Algorithm, Direction: input.Direction})
+	if err != nil {
+		s.logger.Error("failed to lay out drawing", "id", drawingID, "error", err)
+		return nil, err
+	}
+
+	if input.DryRun {
+		output := ToOutput(d)
+		output.Data = data
+		return output, nil
+	}
+
+	if err := d.Update(d.Name(), data); err != nil {
+		s.logger.Error("failed to update drawing domain object", "error", err)
+		return nil, fmt.Errorf("failed to update drawing: %w", err)
+	}
+
+	// Persist to repository along with a revision of its own, never folded into an autosave burst,
+	// so the scene from before the layout can be restored
+	if err := s.repo.Update(ctx, d, drawing.NewRevision(d, input.ClientID)); err != nil {
+		s.logger.Error("failed to persist laid out drawing", "error", err)
+		return nil, fmt.Errorf("failed to save drawing: %w", versionConflict(err, input.ExpectedVersions))
+	}
+
+	s.logger.Info("drawing laid out successfully", "id", drawingID)
+
+	s.scheduleThumbnail(d.ID())
+
+	return ToOutput(d), nil
+}
@@ -0,0 +1,45 @@
+package drawing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SearchDrawings finds the drawings whose name or scene text matches a full-text search, best
+// match first, with snippets around the matches and the IDs of the matching elements
+func (s *Service) SearchDrawings(ctx context.Context, input SearchDrawingsInput) (*SearchListOutput, error) {
+	s.logger.Info("searching drawings", "query", input.Query, "limit", input.Limit, "offset", input.Offset)
+
+	// Set default limit if not provided
+	if input.Limit <= 0 {
+		input.Limit = 10
+	}
+
+	// Ensure offset is not negative
+	if input.Offset < 0 {
+		input.Offset = 0
+	}
+
+	query := strings.TrimSpace(input.Query)
+	results, err := s.repo.Search(ctx, query, input.Limit, input.Offset)
+	if err != nil {
+		s.logger.Error("failed to search drawings", "query", query, "error", err)
+		return nil, fmt.Errorf("failed to search drawings: %w", err)
+	}
+
+	total, err := s.repo.CountSearch(ctx, query)
+	if err != nil {
+		s.logger.Error("failed to count search results", "query", query, "error", err)
+		return nil, fmt.Errorf("failed to count search results: %w", err)
+	}
+
+	s.logger.Info("drawings searched successfully", "count", len(results), "total", total)
+
+	return &SearchListOutput{
+		Results: ToSearchResultOutputList(results),
+		Total:   total,
+		Limit:   input.Limit,
+		Offset:  input.Offset,
+	}, nil
+}
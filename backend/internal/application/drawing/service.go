@@ -2,6 +2,7 @@ package drawing
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 
@@ -9,20 +10,54 @@ import (
 	"github.com/personal-excalidraw/backend/internal/domain/drawing"
 )
 
+const (
+	// maxSlugAttempts is how many times slug generation is retried on collision
+	maxSlugAttempts = 5
+
+	// maxMergeAttempts is how many times a merge without an expected version is reconciled
+	// again when another save lands between loading and storing the drawing
+	maxMergeAttempts = 3
+
+	// slugBackfillBatchSize is how many drawings are processed per backfill batch
+	slugBackfillBatchSize = 100
+
+	// schemaRewriteBatchSize is how many drawings are rewritten per scene schema batch
+	schemaRewriteBatchSize = 50
+)
+
+// SlugGenerator generates random URL-safe slugs for drawings
+type SlugGenerator interface {
+	Generate() (string, error)
+}
+
 // Service handles drawing use cases
 type Service struct {
-	repo   drawing.Repository
-	logger *slog.Logger
+	repo      drawing.Repository
+	revisions drawing.RevisionRepository
+	policy    drawing.RevisionPolicy
+	slugs     SlugGenerator
+	logger    *slog.Logger
+
+	// thumbnails refreshes drawing thumbnails after changes; nil disables thumbnails
+	thumbnails ThumbnailScheduler
 }
 
 // NewService creates a new drawing service
-func NewService(repo drawing.Repository, logger *slog.Logger) *Service {
+func NewService(repo drawing.Repository, revisions drawing.RevisionRepository, policy drawing.RevisionPolicy, slugs SlugGenerator, logger *slog.Logger) *Service {
 	return &Service{
-		repo:   repo,
-		logger: logger,
+		repo:      repo,
+		revisions: revisions,
+		policy:    policy,
+		slugs:     slugs,
+		logger:    logger,
 	}
 }
 
+// SetThumbnailScheduler makes the service queue a thumbnail refresh after each change to a drawing
+func (s *Service) SetThumbnailScheduler(thumbnails ThumbnailScheduler) {
+	s.thumbnails = thumbnails
+}
+
 // CreateDrawing creates a new drawing
 func (s *Service) CreateDrawing(ctx context.Context, input CreateDrawingInput) (*DrawingOutput, error) {
 	s.logger.Info("creating drawing", "name", input.Name)
@@ -34,13 +69,26 @@ func (s *Service) CreateDrawing(ctx context.Context, input CreateDrawingInput) (
 		return nil, fmt.Errorf("failed to create drawing: %w", err)
 	}
 
-	// Persist to repository
-	if err := s.repo.Create(ctx, d); err != nil {
+	// Persist to repository with the custom slug, or regenerating the slug on collision,
+	// recording the initial state as the first revision
+	rev := drawing.NewRevision(d, "")
+	if input.Slug != "" {
+		if err := d.ChangeSlug(input.Slug); err != nil {
+			s.logger.Error("invalid custom slug", "slug", input.Slug, "error", err)
+			return nil, fmt.Errorf("failed to create drawing: %w", err)
+		}
+		err = s.repo.Create(ctx, d, rev)
+	} else {
+		err = s.createWithSlug(ctx, d, rev)
+	}
+	if err != nil {
 		s.logger.Error("failed to persist drawing", "error", err)
 		return nil, fmt.Errorf("failed to save drawing: %w", err)
 	}
 
-	s.logger.Info("drawing created successfully", "id", d.ID())
+	s.logger.Info("drawing created successfully", "id", d.ID(), "slug", d.Slug())
+
+	s.scheduleThumbnail(d.ID())
 
 	return ToOutput(d), nil
 }
@@ -68,9 +116,36 @@ func (s *Service) GetDrawing(ctx context.Context, id string) (*DrawingOutput, er
 	return ToOutput(d), nil
 }
 
+// GetDrawingBySlug retrieves a single drawing by slug
+// A previous slug of a renamed drawing resolves to the drawing, whose output carries the current slug
+func (s *Service) GetDrawingBySlug(ctx context.Context, slug string) (*DrawingOutput, error) {
+	s.logger.Info("getting drawing by slug", "slug", slug)
+
+	if slug == "" {
+		return nil, drawing.ErrDrawingNotFound
+	}
+
+	// Retrieve from repository, falling back to previous slugs of renamed drawings
+	d, err := s.repo.FindBySlug(ctx, slug)
+	if errors.Is(err, drawing.ErrDrawingNotFound) {
+		d, err = s.repo.FindBySlugAlias(ctx, slug)
+	}
+	if err != nil {
+		s.logger.Error("failed to get drawing by slug", "slug", slug, "error", err)
+		return nil, err
+	}
+
+	s.logger.Info("drawing retrieved successfully", "id", d.ID(), "slug", slug)
+
+	return ToOutput(d), nil
+}
+
 // ListDrawings retrieves all drawings with pagination
+// Pages are selected by offset, or by a cursor from a previous page, which keeps its place when
+// drawings are created or deleted in between. Both modes return the cursors of the pages around
 func (s *Service) ListDrawings(ctx context.Context, input ListDrawingsInput) (*DrawingListOutput, error) {
-	s.logger.Info("listing drawings", "limit", input.Limit, "offset", input.Offset)
+	s.logger.Info("listing drawings", "limit", input.Limit, "offset", input.Offset, "cursor", input.Cursor,
+		"sort", input.Order.Field, "descending", input.Order.Descending, "fields", input.Fields)
 
 	// Set default limit if not provided
 	if input.Limit <= 0 {
@@ -82,28 +157,110 @@ func (s *Service) ListDrawings(ctx context.Context, input ListDrawingsInput) (*D
 		input.Offset = 0
 	}
 
-	// Find all drawings with pagination
-	drawings, err := s.repo.FindAll(ctx, input.Limit, input.Offset)
+	if input.Order.Field == "" {
+		input.Order = drawing.DefaultSortOrder
+	}
+	query := drawing.SummaryQuery{Filter: input.Filter, Order: input.Order, Fields: input.Fields}
+
+	var (
+		drawings   []*drawing.DrawingSummary
+		next, prev *drawing.Cursor
+		err        error
+	)
+	if input.Cursor != "" {
+		input.Offset = 0
+		drawings, next, prev, err = s.listDrawingsByCursor(ctx, query, input.Cursor, input.Limit)
+	} else {
+		// Find the summaries of the matching drawings with pagination
+		drawings, err = s.repo.FindSummaries(ctx, query, input.Limit, input.Offset)
+	}
 	if err != nil {
+		if errors.Is(err, drawing.ErrInvalidCursor) {
+			s.logger.Info("invalid cursor", "cursor", input.Cursor, "error", err)
+			return nil, err
+		}
 		s.logger.Error("failed to list drawings", "error", err)
 		return nil, fmt.Errorf("failed to retrieve drawings: %w", err)
 	}
 
-	// Get total count
-	total, err := s.repo.Count(ctx)
+	// Get total count of the matching drawings
+	total, err := s.repo.Count(ctx, input.Filter)
 	if err != nil {
 		s.logger.Error("failed to count drawings", "error", err)
 		return nil, fmt.Errorf("failed to count drawings: %w", err)
 	}
 
+	// Offset pages hand out cursors too, so clients can switch to cursor paging
+	if input.Cursor == "" && len(drawings) > 0 {
+		if int64(input.Offset+len(drawings)) < total {
+			c := drawing.CursorAfter(drawings[len(drawings)-1], input.Order)
+			next = &c
+		}
+		if input.Offset > 0 {
+			c := drawing.CursorBefore(drawings[0], input.Order)
+			prev = &c
+		}
+	}
+
 	s.logger.Info("drawings listed successfully", "count", len(drawings), "total", total)
 
-	return &DrawingListOutput{
-		Drawings: ToOutputList(drawings),
+	output := &DrawingListOutput{
+		Drawings: ToSummaryOutputList(drawings),
 		Total:    total,
 		Limit:    input.Limit,
 		Offset:   input.Offset,
-	}, nil
+	}
+	if next != nil {
+		output.NextCursor = next.Encode()
+	}
+	if prev != nil {
+		output.PrevCursor = prev.Encode()
+	}
+	return output, nil
+}
+
+// listDrawingsByCursor retrieves the page of drawings matching a query next to a cursor token,
+// with the cursors of the following and preceding pages, nil where the list ends
+func (s *Service) listDrawingsByCursor(ctx context.Context, query drawing.SummaryQuery, token string, limit int) ([]*drawing.DrawingSummary, *drawing.Cursor, *drawing.Cursor, error) {
+	cursor, err := drawing.DecodeCursor(token)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// A sort key only has a place in the order it was taken from
+	if cursor.Order() != query.Order {
+		return nil, nil, nil, fmt.Errorf("%w: taken from a list in another order", drawing.ErrInvalidCursor)
+	}
+
+	// One more drawing than the page holds tells whether the list goes on
+	drawings, err := s.repo.FindSummariesByCursor(ctx, query, &cursor, limit+1)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	more := len(drawings) > limit
+	if more && cursor.Before {
+		drawings = drawings[1:]
+	} else if more {
+		drawings = drawings[:limit]
+	}
+
+	// An empty page still leads back to where the client came from
+	if len(drawings) == 0 {
+		back := cursor.Reverse()
+		if cursor.Before {
+			return drawings, &back, nil, nil
+		}
+		return drawings, nil, &back, nil
+	}
+
+	first, last := drawing.CursorBefore(drawings[0], query.Order), drawing.CursorAfter(drawings[len(drawings)-1], query.Order)
+	next, prev := &last, &first
+	if cursor.Before && !more {
+		prev = nil
+	}
+	if !cursor.Before && !more {
+		next = nil
+	}
+	return drawings, next, prev, nil
 }
 
 // UpdateDrawing updates an existing drawing
@@ -117,6 +274,34 @@ func (s *Service) UpdateDrawing(ctx context.Context, id string, input UpdateDraw
 		return nil, fmt.Errorf("invalid drawing ID: %w", err)
 	}
 
+	// A merge without an expected version reconciles with whatever is stored, so a save
+	// landing in between only means reconciling again on top of it
+	attempts := 1
+	if input.Merge && len(input.ExpectedVersions) == 0 {
+		attempts = maxMergeAttempts
+	}
+
+	var d *drawing.Drawing
+	for attempt := 1; ; attempt++ {
+		d, err = s.applyUpdate(ctx, drawingID, input)
+		if !errors.Is(err, drawing.ErrConcurrentUpdate) || attempt == attempts {
+			break
+		}
+		s.logger.Warn("drawing changed during merge, retrying", "id", drawingID, "attempt", attempt)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("drawing updated successfully", "id", drawingID)
+
+	s.scheduleThumbnail(d.ID())
+
+	return ToOutput(d), nil
+}
+
+// applyUpdate loads a drawing, applies an update to it and saves it along with a revision
+func (s *Service) applyUpdate(ctx context.Context, drawingID uuid.UUID, input UpdateDrawingInput) (*drawing.Drawing, error) {
 	// Retrieve from repository
 	d, err := s.repo.FindByID(ctx, drawingID)
 	if err != nil {
@@ -124,6 +309,12 @@ func (s *Service) UpdateDrawing(ctx context.Context, id string, input UpdateDraw
 		return nil, err
 	}
 
+	// Reject the update if the drawing changed since the client loaded it
+	if err := d.CheckVersion(input.ExpectedVersions...); err != nil {
+		s.logger.Info("drawing version conflict", "id", drawingID, "expected", input.ExpectedVersions, "current", d.Version())
+		return nil, err
+	}
+
 	// Update the domain entity
 	// If name is not provided (empty), keep the existing name
 	nameToUpdate := input.Name
@@ -131,30 +322,126 @@ func (s *Service) UpdateDrawing(ctx context.Context, id string, input UpdateDraw
 		nameToUpdate = d.Name()
 	}
 
-	// If data is not provided (nil), keep the existing data
-	dataToUpdate := input.Data
-	if dataToUpdate == nil {
-		dataToUpdate = d.Data()
+	// If data is not provided (nil), keep the existing data without re-validating it,
+	// so drawings stored before scene validation can still be renamed
+	if input.Data == nil {
+		if err := d.Rename(nameToUpdate); err != nil {
+			s.logger.Error("failed to rename drawing domain object", "error", err)
+			return nil, fmt.Errorf("failed to update drawing: %w", err)
+		}
+	} else {
+		dataToUpdate := drawing.DrawingData(input.Data)
+		if input.Merge {
+			// Merge with changes other clients saved since this one loaded the drawing
+			dataToUpdate, err = d.Data().Reconcile(dataToUpdate)
+			if err != nil {
+				s.logger.Error("failed to reconcile drawing data", "id", drawingID, "error", err)
+				return nil, fmt.Errorf("failed to update drawing: %w", err)
+			}
+		}
+
+		if err := d.Update(nameToUpdate, dataToUpdate); err != nil {
+			s.logger.Error("failed to update drawing domain object", "error", err)
+			return nil, fmt.Errorf("failed to update drawing: %w", err)
+		}
 	}
 
-	if err := d.Update(nameToUpdate, dataToUpdate); err != nil {
-		s.logger.Error("failed to update drawing domain object", "error", err)
-		return nil, fmt.Errorf("failed to update drawing: %w", err)
+	// If a custom slug is provided, replace the current one
+	if input.Slug != "" && input.Slug != d.Slug() {
+		if err := d.ChangeSlug(input.Slug); err != nil {
+			s.logger.Error("failed to change drawing slug", "slug", input.Slug, "error", err)
+			return nil, fmt.Errorf("failed to update drawing: %w", err)
+		}
 	}
 
-	// Persist to repository
-	if err := s.repo.Update(ctx, d); err != nil {
+	// Record the new state as a revision, folding autosave bursts into one
+	rev, err := s.saveRevision(ctx, d, input.ClientID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Persist to repository along with the revision
+	if err := s.repo.Update(ctx, d, rev); err != nil {
 		s.logger.Error("failed to persist updated drawing", "error", err)
-		return nil, fmt.Errorf("failed to save drawing: %w", err)
+		return nil, fmt.Errorf("failed to save drawing: %w", versionConflict(err, input.ExpectedVersions))
 	}
 
-	s.logger.Info("drawing updated successfully", "id", drawingID)
+	return d, nil
+}
+
+// PatchDrawing applies a JSON Patch or Merge Patch document to the data of an existing drawing
+func (s *Service) PatchDrawing(ctx context.Context, id string, input PatchDrawingInput) (*DrawingOutput, error) {
+	s.logger.Info("patching drawing", "id", id, "format", input.Format)
+
+	// Parse UUID from string
+	drawingID, err := uuid.Parse(id)
+	if err != nil {
+		s.logger.Error("invalid drawing ID format", "id", id, "error", err)
+		return nil, fmt.Errorf("invalid drawing ID: %w", err)
+	}
+
+	// Retrieve from repository
+	d, err := s.repo.FindByID(ctx, drawingID)
+	if err != nil {
+		s.logger.Error("failed to get drawing", "id", drawingID, "error", err)
+		return nil, err
+	}
+
+	// Reject the patch if the drawing changed since the client loaded it
+	if err := d.CheckVersion(input.ExpectedVersions...); err != nil {
+		s.logger.Info("drawing version conflict", "id", drawingID, "expected", input.ExpectedVersions, "current", d.Version())
+		return nil, err
+	}
+
+	// Apply the patch to a copy of the current data
+	var patched drawing.DrawingData
+	switch input.Format {
+	case PatchFormatJSONPatch:
+		patched, err = d.Data().ApplyJSONPatch(input.Patch)
+	case PatchFormatMergePatch:
+		patched, err = d.Data().ApplyMergePatch(input.Patch)
+	default:
+		err = fmt.Errorf("%w: unsupported format %q", drawing.ErrInvalidPatch, input.Format)
+	}
+	if err != nil {
+		s.logger.Error("failed to apply patch", "id", drawingID, "error", err)
+		return nil, fmt.Errorf("failed to patch drawing: %w", err)
+	}
+
+	// A patch that changes nothing is not an edit: no new version, no revision
+	if patched.Equal(d.Data()) {
+		s.logger.Info("patch left drawing unchanged", "id", drawingID)
+		return ToOutput(d), nil
+	}
+
+	// Update re-runs the aggregate validation on the patched data
+	if err := d.Update(d.Name(), patched); err != nil {
+		s.logger.Error("failed to update drawing domain object", "error", err)
+		return nil, fmt.Errorf("failed to patch drawing: %w", err)
+	}
+
+	// Record the new state as a revision, folding autosave bursts into one
+	rev, err := s.saveRevision(ctx, d, input.ClientID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Persist to repository along with the revision
+	if err := s.repo.Update(ctx, d, rev); err != nil {
+		s.logger.Error("failed to persist patched drawing", "error", err)
+		return nil, fmt.Errorf("failed to save drawing: %w", versionConflict(err, input.ExpectedVersions))
+	}
+
+	s.logger.Info("drawing patched successfully", "id", drawingID)
+
+	s.scheduleThumbnail(d.ID())
 
 	return ToOutput(d), nil
 }
 
 // DeleteDrawing deletes an existing drawing
-func (s *Service) DeleteDrawing(ctx context.Context, id string) error {
+// The stored version must be one of expectedVersions, if any
+func (s *Service) DeleteDrawing(ctx context.Context, id string, expectedVersions []int64) error {
 	s.logger.Info("deleting drawing", "id", id)
 
 	// Parse UUID from string
@@ -164,20 +451,289 @@ func (s *Service) DeleteDrawing(ctx context.Context, id string) error {
 		return fmt.Errorf("invalid drawing ID: %w", err)
 	}
 
+	// Delete from repository, which rejects the delete if the drawing changed since the client loaded it
+	if err := s.repo.Delete(ctx, drawingID, expectedVersions); err != nil {
+		if errors.Is(err, drawing.ErrVersionConflict) {
+			s.logger.Info("drawing version conflict", "id", drawingID, "expected", expectedVersions)
+			return err
+		}
+		s.logger.Error("failed to delete drawing", "id", drawingID, "error", err)
+		return fmt.Errorf("failed to delete drawing: %w", err)
+	}
+
+	s.logger.Info("drawing deleted successfully", "id", drawingID)
+
+	return nil
+}
+
+// ListRevisions retrieves the revisions of a drawing, newest first, with pagination
+func (s *Service) ListRevisions(ctx context.Context, id string, input ListRevisionsInput) (*RevisionListOutput, error) {
+	s.logger.Info("listing drawing revisions", "id", id, "limit", input.Limit, "offset", input.Offset)
+
+	// Parse UUID from string
+	drawingID, err := uuid.Parse(id)
+	if err != nil {
+		s.logger.Error("invalid drawing ID format", "id", id, "error", err)
+		return nil, fmt.Errorf("invalid drawing ID: %w", err)
+	}
+
+	// Set default limit if not provided
+	if input.Limit <= 0 {
+		input.Limit = 10
+	}
+
+	// Ensure offset is not negative
+	if input.Offset < 0 {
+		input.Offset = 0
+	}
+
 	// Check if drawing exists
-	_, err = s.repo.FindByID(ctx, drawingID)
+	if _, err := s.repo.FindByID(ctx, drawingID); err != nil {
+		s.logger.Error("failed to get drawing", "id", drawingID, "error", err)
+		return nil, err
+	}
+
+	revisions, err := s.revisions.FindByDrawingID(ctx, drawingID, input.Limit, input.Offset)
+	if err != nil {
+		s.logger.Error("failed to list revisions", "id", drawingID, "error", err)
+		return nil, fmt.Errorf("failed to retrieve revisions: %w", err)
+	}
+
+	total, err := s.revisions.CountByDrawingID(ctx, drawingID)
+	if err != nil {
+		s.logger.Error("failed to count revisions", "id", drawingID, "error", err)
+		return nil, fmt.Errorf("failed to count revisions: %w", err)
+	}
+
+	s.logger.Info("drawing revisions listed successfully", "id", drawingID, "count", len(revisions), "total", total)
+
+	return &RevisionListOutput{
+		Revisions: ToRevisionOutputList(revisions),
+		Total:     total,
+		Limit:     input.Limit,
+		Offset:    input.Offset,
+	}, nil
+}
+
+// GetRevision retrieves a single revision of a drawing
+func (s *Service) GetRevision(ctx context.Context, id string, number int) (*RevisionOutput, error) {
+	s.logger.Info("getting drawing revision", "id", id, "revision", number)
+
+	// Parse UUID from string
+	drawingID, err := uuid.Parse(id)
+	if err != nil {
+		s.logger.Error("invalid drawing ID format", "id", id, "error", err)
+		return nil, fmt.Errorf("invalid drawing ID: %w", err)
+	}
+
+	if number < 1 {
+		return nil, drawing.ErrInvalidRevisionNumber
+	}
+
+	rev, err := s.revisions.FindByNumber(ctx, drawingID, number)
+	if err != nil {
+		s.logger.Error("failed to get revision", "id", drawingID, "revision", number, "error", err)
+		return nil, err
+	}
+
+	s.logger.Info("drawing revision retrieved successfully", "id", drawingID, "revision", number)
+
+	return ToRevisionOutput(rev), nil
+}
+
+// RestoreRevision replaces the drawing's name and data with those of an earlier revision
+// The restore is itself recorded as a new revision, so it can be undone
+// The stored version must be one of expectedVersions, if any
+func (s *Service) RestoreRevision(ctx context.Context, id string, number int, expectedVersions []int64) (*DrawingOutput, error) {
+	s.logger.Info("restoring drawing revision", "id", id, "revision", number)
+
+	// Parse UUID from string
+	drawingID, err := uuid.Parse(id)
+	if err != nil {
+		s.logger.Error("invalid drawing ID format", "id", id, "error", err)
+		return nil, fmt.Errorf("invalid drawing ID: %w", err)
+	}
+
+	if number < 1 {
+		return nil, drawing.ErrInvalidRevisionNumber
+	}
+
+	// Retrieve from repository
+	d, err := s.repo.FindByID(ctx, drawingID)
 	if err != nil {
 		s.logger.Error("failed to get drawing", "id", drawingID, "error", err)
-		return err
+		return nil, err
 	}
 
-	// Delete from repository
-	if err := s.repo.Delete(ctx, drawingID); err != nil {
-		s.logger.Error("failed to delete drawing", "id", drawingID, "error", err)
-		return fmt.Errorf("failed to delete drawing: %w", err)
+	// Reject the restore if the drawing changed since the client loaded it
+	if err := d.CheckVersion(expectedVersions...); err != nil {
+		s.logger.Info("drawing version conflict", "id", drawingID, "expected", expectedVersions, "current", d.Version())
+		return nil, err
 	}
 
-	s.logger.Info("drawing deleted successfully", "id", drawingID)
+	rev, err := s.revisions.FindByNumber(ctx, drawingID, number)
+	if err != nil {
+		s.logger.Error("failed to get revision", "id", drawingID, "revision", number, "error", err)
+		return nil, err
+	}
 
-	return nil
+	if err := d.Restore(rev); err != nil {
+		s.logger.Error("failed to restore drawing domain object", "error", err)
+		return nil, fmt.Errorf("failed to restore drawing: %w", err)
+	}
+
+	// Persist to repository along with the restore as a new revision
+	if err := s.repo.Update(ctx, d, drawing.NewRestoredRevision(d, number)); err != nil {
+		s.logger.Error("failed to persist restored drawing", "error", err)
+		return nil, fmt.Errorf("failed to save drawing: %w", versionConflict(err, expectedVersions))
+	}
+
+	s.logger.Info("drawing revision restored successfully", "id", drawingID, "revision", number)
+
+	s.scheduleThumbnail(d.ID())
+
+	return ToOutput(d), nil
+}
+
+// BackfillSlugs assigns slugs to drawings created before slugs were generated
+// It returns the number of drawings that received a slug
+func (s *Service) BackfillSlugs(ctx context.Context) (int, error) {
+	s.logger.Info("backfilling drawing slugs")
+
+	total := 0
+	for {
+		drawings, err := s.repo.FindWithoutSlug(ctx, slugBackfillBatchSize)
+		if err != nil {
+			s.logger.Error("failed to find drawings without slug", "error", err)
+			return total, fmt.Errorf("failed to find drawings without slug: %w", err)
+		}
+
+		if len(drawings) == 0 {
+			break
+		}
+
+		for _, d := range drawings {
+			if err := s.assignSlug(ctx, d.ID()); err != nil {
+				s.logger.Error("failed to backfill drawing slug", "id", d.ID(), "error", err)
+				return total, fmt.Errorf("failed to backfill slug: %w", err)
+			}
+			total++
+		}
+	}
+
+	s.logger.Info("drawing slugs backfilled", "count", total)
+
+	return total, nil
+}
+
+// RewriteSceneSchemas stores every drawing saved with an older scene schema at the current one
+// Drawings are upgraded on read anyway; rewriting saves doing it on every read
+// It returns the number of drawings rewritten
+func (s *Service) RewriteSceneSchemas(ctx context.Context) (int, error) {
+	s.logger.Info("rewriting drawing scene schemas", "schema", drawing.CurrentSceneSchema)
+
+	total := 0
+	for {
+		drawings, err := s.repo.FindWithOutdatedSchema(ctx, schemaRewriteBatchSize)
+		if err != nil {
+			s.logger.Error("failed to find drawings with outdated schema", "error", err)
+			return total, fmt.Errorf("failed to find drawings with outdated schema: %w", err)
+		}
+
+		if len(drawings) == 0 {
+			break
+		}
+
+		for _, d := range drawings {
+			rewritten, err := s.repo.RewriteData(ctx, d)
+			if err != nil {
+				s.logger.Error("failed to rewrite drawing scene", "id", d.ID(), "error", err)
+				return total, fmt.Errorf("failed to rewrite drawing scene: %w", err)
+			}
+			if !rewritten {
+				// Saved or deleted in the meantime; a save stores the current schema already
+				s.logger.Info("drawing changed since read, skipping scene rewrite", "id", d.ID())
+				continue
+			}
+			total++
+		}
+	}
+
+	s.logger.Info("drawing scene schemas rewritten", "count", total)
+
+	return total, nil
+}
+
+// createWithSlug generates a slug for d and persists it with its first revision, retrying on slug collisions
+func (s *Service) createWithSlug(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+	for attempt := 1; attempt <= maxSlugAttempts; attempt++ {
+		slug, err := s.slugs.Generate()
+		if err != nil {
+			return fmt.Errorf("failed to generate slug: %w", err)
+		}
+		d.SetSlug(slug)
+
+		err = s.repo.Create(ctx, d, rev)
+		if !errors.Is(err, drawing.ErrSlugConflict) {
+			return err
+		}
+
+		s.logger.Warn("slug collision, retrying", "slug", slug, "attempt", attempt)
+	}
+
+	return drawing.ErrSlugConflict
+}
+
+// assignSlug generates and stores a slug for an existing drawing, retrying on slug collisions
+func (s *Service) assignSlug(ctx context.Context, id uuid.UUID) error {
+	for attempt := 1; attempt <= maxSlugAttempts; attempt++ {
+		slug, err := s.slugs.Generate()
+		if err != nil {
+			return fmt.Errorf("failed to generate slug: %w", err)
+		}
+
+		err = s.repo.UpdateSlug(ctx, id, slug)
+		if !errors.Is(err, drawing.ErrSlugConflict) {
+			return err
+		}
+
+		s.logger.Warn("slug collision, retrying", "slug", slug, "attempt", attempt)
+	}
+
+	return drawing.ErrSlugConflict
+}
+
+// versionConflict reports a save lost to a concurrent one as a version conflict when the client
+// named the versions it expected, as the version it was checked against is no longer the stored one
+func versionConflict(err error, expectedVersions []int64) error {
+	if len(expectedVersions) != 0 && errors.Is(err, drawing.ErrConcurrentUpdate) {
+		return drawing.ErrVersionConflict
+	}
+	return err
+}
+
+// scheduleThumbnail queues a thumbnail refresh of a drawing, if thumbnails are enabled
+func (s *Service) scheduleThumbnail(id uuid.UUID) {
+	if s.thumbnails != nil {
+		s.thumbnails.Schedule(id)
+	}
+}
+
+// saveRevision returns the revision recording a save of a drawing, to be stored along with it
+// Saves by the same client within the coalesce window are folded into the latest revision,
+// which keeps its number so the repository overwrites it
+func (s *Service) saveRevision(ctx context.Context, d *drawing.Drawing, clientID string) (*drawing.Revision, error) {
+	latest, err := s.revisions.FindLatestMeta(ctx, d.ID())
+	if err != nil && !errors.Is(err, drawing.ErrRevisionNotFound) {
+		s.logger.Error("failed to get latest revision", "id", d.ID(), "error", err)
+		return nil, fmt.Errorf("failed to record revision: %w", err)
+	}
+
+	if !s.policy.CanCoalesce(latest, clientID, d.UpdatedAt()) {
+		return drawing.NewRevision(d, clientID), nil
+	}
+
+	s.logger.Info("coalescing revision", "id", d.ID(), "revision", latest.Number, "client_id", clientID)
+
+	return drawing.NewCoalescedRevision(d, *latest), nil
 }
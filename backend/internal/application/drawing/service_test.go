@@ -2,9 +2,13 @@ package drawing
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"log/slog"
 	"os"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -14,32 +18,63 @@ import (
 
 // mockDrawingRepository is a mock implementation of the drawing repository
 type mockDrawingRepository struct {
-	createFunc     func(ctx context.Context, d *drawing.Drawing) error
-	findAllFunc    func(ctx context.Context, limit, offset int) ([]*drawing.Drawing, error)
-	countFunc      func(ctx context.Context) (int64, error)
-	findByIDFunc   func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error)
-	findBySlugFunc func(ctx context.Context, slug string) (*drawing.Drawing, error)
-	updateFunc     func(ctx context.Context, d *drawing.Drawing) error
-	deleteFunc     func(ctx context.Context, id uuid.UUID) error
+	createFunc        func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error
+	findSummariesFunc func(ctx context.Context, query drawing.SummaryQuery, limit, offset int) ([]*drawing.DrawingSummary, error)
+	findByCursorFunc  func(ctx context.Context, query drawing.SummaryQuery, cursor *drawing.Cursor, limit int) ([]*drawing.DrawingSummary, error)
+	countFunc         func(ctx context.Context, filter drawing.DrawingFilter) (int64, error)
+	searchFunc        func(ctx context.Context, query string, limit, offset int) ([]*drawing.SearchResult, error)
+	countSearchFunc   func(ctx context.Context, query string) (int64, error)
+	findByIDFunc      func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error)
+	findBySlugFunc    func(ctx context.Context, slug string) (*drawing.Drawing, error)
+	updateFunc        func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error
+	deleteFunc        func(ctx context.Context, id uuid.UUID, expectedVersions []int64) error
+	moveToFolderFunc  func(ctx context.Context, id uuid.UUID, folderID *uuid.UUID) error
+
+	findBySlugAliasFunc func(ctx context.Context, slug string) (*drawing.Drawing, error)
+	findWithoutSlugFunc func(ctx context.Context, limit int) ([]*drawing.Drawing, error)
+	updateSlugFunc      func(ctx context.Context, id uuid.UUID, slug string) error
+	findOutdatedFunc    func(ctx context.Context, limit int) ([]*drawing.Drawing, error)
+	rewriteDataFunc     func(ctx context.Context, d *drawing.Drawing) (bool, error)
 }
 
-func (m *mockDrawingRepository) Create(ctx context.Context, d *drawing.Drawing) error {
+func (m *mockDrawingRepository) Create(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
 	if m.createFunc != nil {
-		return m.createFunc(ctx, d)
+		return m.createFunc(ctx, d, rev)
 	}
 	return errors.New("not implemented")
 }
 
-func (m *mockDrawingRepository) FindAll(ctx context.Context, limit, offset int) ([]*drawing.Drawing, error) {
-	if m.findAllFunc != nil {
-		return m.findAllFunc(ctx, limit, offset)
+func (m *mockDrawingRepository) FindSummaries(ctx context.Context, query drawing.SummaryQuery, limit, offset int) ([]*drawing.DrawingSummary, error) {
+	if m.findSummariesFunc != nil {
+		return m.findSummariesFunc(ctx, query, limit, offset)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockDrawingRepository) FindSummariesByCursor(ctx context.Context, query drawing.SummaryQuery, cursor *drawing.Cursor, limit int) ([]*drawing.DrawingSummary, error) {
+	if m.findByCursorFunc != nil {
+		return m.findByCursorFunc(ctx, query, cursor, limit)
 	}
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockDrawingRepository) Count(ctx context.Context) (int64, error) {
+func (m *mockDrawingRepository) Count(ctx context.Context, filter drawing.DrawingFilter) (int64, error) {
 	if m.countFunc != nil {
-		return m.countFunc(ctx)
+		return m.countFunc(ctx, filter)
+	}
+	return 0, errors.New("not implemented")
+}
+
+func (m *mockDrawingRepository) Search(ctx context.Context, query string, limit, offset int) ([]*drawing.SearchResult, error) {
+	if m.searchFunc != nil {
+		return m.searchFunc(ctx, query, limit, offset)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockDrawingRepository) CountSearch(ctx context.Context, query string) (int64, error) {
+	if m.countSearchFunc != nil {
+		return m.countSearchFunc(ctx, query)
 	}
 	return 0, errors.New("not implemented")
 }
@@ -58,20 +93,143 @@ func (m *mockDrawingRepository) FindBySlug(ctx context.Context, slug string) (*d
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockDrawingRepository) Update(ctx context.Context, d *drawing.Drawing) error {
+func (m *mockDrawingRepository) Update(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
 	if m.updateFunc != nil {
-		return m.updateFunc(ctx, d)
+		return m.updateFunc(ctx, d, rev)
 	}
 	return errors.New("not implemented")
 }
 
-func (m *mockDrawingRepository) Delete(ctx context.Context, id uuid.UUID) error {
+func (m *mockDrawingRepository) Delete(ctx context.Context, id uuid.UUID, expectedVersions []int64) error {
 	if m.deleteFunc != nil {
-		return m.deleteFunc(ctx, id)
+		return m.deleteFunc(ctx, id, expectedVersions)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *mockDrawingRepository) MoveToFolder(ctx context.Context, id uuid.UUID, folderID *uuid.UUID) error {
+	if m.moveToFolderFunc != nil {
+		return m.moveToFolderFunc(ctx, id, folderID)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *mockDrawingRepository) FindBySlugAlias(ctx context.Context, slug string) (*drawing.Drawing, error) {
+	if m.findBySlugAliasFunc != nil {
+		return m.findBySlugAliasFunc(ctx, slug)
+	}
+	return nil, drawing.ErrDrawingNotFound
+}
+
+func (m *mockDrawingRepository) FindWithoutSlug(ctx context.Context, limit int) ([]*drawing.Drawing, error) {
+	if m.findWithoutSlugFunc != nil {
+		return m.findWithoutSlugFunc(ctx, limit)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockDrawingRepository) UpdateSlug(ctx context.Context, id uuid.UUID, slug string) error {
+	if m.updateSlugFunc != nil {
+		return m.updateSlugFunc(ctx, id, slug)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *mockDrawingRepository) FindWithOutdatedSchema(ctx context.Context, limit int) ([]*drawing.Drawing, error) {
+	if m.findOutdatedFunc != nil {
+		return m.findOutdatedFunc(ctx, limit)
+	}
+	return nil, nil
+}
+
+func (m *mockDrawingRepository) RewriteData(ctx context.Context, d *drawing.Drawing) (bool, error) {
+	if m.rewriteDataFunc != nil {
+		return m.rewriteDataFunc(ctx, d)
+	}
+	return false, errors.New("not implemented")
+}
+
+// mockRevisionRepository is a mock implementation of the revision repository
+type mockRevisionRepository struct {
+	findByDrawingIDFunc  func(ctx context.Context, drawingID uuid.UUID, limit, offset int) ([]*drawing.Revision, error)
+	findByNumberFunc     func(ctx context.Context, drawingID uuid.UUID, number int) (*drawing.Revision, error)
+	countByDrawingIDFunc func(ctx context.Context, drawingID uuid.UUID) (int64, error)
+	findLatestMetaFunc   func(ctx context.Context, drawingID uuid.UUID) (*drawing.RevisionMeta, error)
+
+	findCompactionCandidatesFunc func(ctx context.Context, policy drawing.RevisionPolicy, now time.Time) ([]uuid.UUID, error)
+	markCompactedFunc            func(ctx context.Context, drawingID uuid.UUID, at time.Time) error
+	findMetaByDrawingIDFunc      func(ctx context.Context, drawingID uuid.UUID) ([]drawing.RevisionMeta, error)
+	deleteByIDsFunc              func(ctx context.Context, ids []uuid.UUID) error
+}
+
+func (m *mockRevisionRepository) FindByDrawingID(ctx context.Context, drawingID uuid.UUID, limit, offset int) ([]*drawing.Revision, error) {
+	if m.findByDrawingIDFunc != nil {
+		return m.findByDrawingIDFunc(ctx, drawingID, limit, offset)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockRevisionRepository) FindByNumber(ctx context.Context, drawingID uuid.UUID, number int) (*drawing.Revision, error) {
+	if m.findByNumberFunc != nil {
+		return m.findByNumberFunc(ctx, drawingID, number)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockRevisionRepository) CountByDrawingID(ctx context.Context, drawingID uuid.UUID) (int64, error) {
+	if m.countByDrawingIDFunc != nil {
+		return m.countByDrawingIDFunc(ctx, drawingID)
+	}
+	return 0, errors.New("not implemented")
+}
+
+func (m *mockRevisionRepository) FindLatestMeta(ctx context.Context, drawingID uuid.UUID) (*drawing.RevisionMeta, error) {
+	if m.findLatestMetaFunc != nil {
+		return m.findLatestMetaFunc(ctx, drawingID)
+	}
+	return nil, drawing.ErrRevisionNotFound
+}
+
+func (m *mockRevisionRepository) FindCompactionCandidates(ctx context.Context, policy drawing.RevisionPolicy, now time.Time) ([]uuid.UUID, error) {
+	if m.findCompactionCandidatesFunc != nil {
+		return m.findCompactionCandidatesFunc(ctx, policy, now)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockRevisionRepository) MarkCompacted(ctx context.Context, drawingID uuid.UUID, at time.Time) error {
+	if m.markCompactedFunc != nil {
+		return m.markCompactedFunc(ctx, drawingID, at)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *mockRevisionRepository) FindMetaByDrawingID(ctx context.Context, drawingID uuid.UUID) ([]drawing.RevisionMeta, error) {
+	if m.findMetaByDrawingIDFunc != nil {
+		return m.findMetaByDrawingIDFunc(ctx, drawingID)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockRevisionRepository) DeleteByIDs(ctx context.Context, ids []uuid.UUID) error {
+	if m.deleteByIDsFunc != nil {
+		return m.deleteByIDsFunc(ctx, ids)
 	}
 	return errors.New("not implemented")
 }
 
+// mockSlugGenerator is a mock implementation of the slug generator
+type mockSlugGenerator struct {
+	generateFunc func() (string, error)
+}
+
+func (m *mockSlugGenerator) Generate() (string, error) {
+	if m.generateFunc != nil {
+		return m.generateFunc()
+	}
+	return "Xk9pQ2mR", nil
+}
+
 func TestCreateDrawing(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 
@@ -92,7 +250,7 @@ func TestCreateDrawing(t *testing.T) {
 				},
 			},
 			mockRepo: &mockDrawingRepository{
-				createFunc: func(ctx context.Context, d *drawing.Drawing) error {
+				createFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
 					return nil
 				},
 			},
@@ -167,7 +325,7 @@ func TestCreateDrawing(t *testing.T) {
 				Data: map[string]interface{}{"elements": []interface{}{}},
 			},
 			mockRepo: &mockDrawingRepository{
-				createFunc: func(ctx context.Context, d *drawing.Drawing) error {
+				createFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
 					return errors.New("database connection failed")
 				},
 			},
@@ -185,6 +343,7 @@ func TestCreateDrawing(t *testing.T) {
 				Data: map[string]interface{}{
 					"elements": []interface{}{
 						map[string]interface{}{
+							"id":   "rect-1",
 							"type": "rectangle",
 							"x":    100,
 							"y":    200,
@@ -197,7 +356,7 @@ func TestCreateDrawing(t *testing.T) {
 				},
 			},
 			mockRepo: &mockDrawingRepository{
-				createFunc: func(ctx context.Context, d *drawing.Drawing) error {
+				createFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
 					return nil
 				},
 			},
@@ -219,7 +378,7 @@ func TestCreateDrawing(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			service := NewService(tt.mockRepo, logger)
+			service := NewService(tt.mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
 			ctx := context.Background()
 
 			output, err := service.CreateDrawing(ctx, tt.input)
@@ -255,15 +414,15 @@ func TestListDrawings(t *testing.T) {
 				Offset: 0,
 			},
 			mockRepo: &mockDrawingRepository{
-				findAllFunc: func(ctx context.Context, limit, offset int) ([]*drawing.Drawing, error) {
+				findSummariesFunc: func(ctx context.Context, query drawing.SummaryQuery, limit, offset int) ([]*drawing.DrawingSummary, error) {
 					if limit != 10 {
 						t.Errorf("expected limit 10, got %d", limit)
 					}
-					d1, _ := drawing.NewDrawing("Drawing 1", map[string]interface{}{"elements": []interface{}{}})
-					d2, _ := drawing.NewDrawing("Drawing 2", map[string]interface{}{"elements": []interface{}{}})
-					return []*drawing.Drawing{d1, d2}, nil
+					d1 := &drawing.DrawingSummary{ID: uuid.New(), Name: "Drawing 1"}
+					d2 := &drawing.DrawingSummary{ID: uuid.New(), Name: "Drawing 2"}
+					return []*drawing.DrawingSummary{d1, d2}, nil
 				},
-				countFunc: func(ctx context.Context) (int64, error) {
+				countFunc: func(ctx context.Context, filter drawing.DrawingFilter) (int64, error) {
 					return 2, nil
 				},
 			},
@@ -290,16 +449,16 @@ func TestListDrawings(t *testing.T) {
 				Offset: 10,
 			},
 			mockRepo: &mockDrawingRepository{
-				findAllFunc: func(ctx context.Context, limit, offset int) ([]*drawing.Drawing, error) {
+				findSummariesFunc: func(ctx context.Context, query drawing.SummaryQuery, limit, offset int) ([]*drawing.DrawingSummary, error) {
 					if limit != 5 {
 						t.Errorf("expected limit 5, got %d", limit)
 					}
 					if offset != 10 {
 						t.Errorf("expected offset 10, got %d", offset)
 					}
-					return []*drawing.Drawing{}, nil
+					return []*drawing.DrawingSummary{}, nil
 				},
-				countFunc: func(ctx context.Context) (int64, error) {
+				countFunc: func(ctx context.Context, filter drawing.DrawingFilter) (int64, error) {
 					return 100, nil
 				},
 			},
@@ -326,10 +485,10 @@ func TestListDrawings(t *testing.T) {
 				Offset: 0,
 			},
 			mockRepo: &mockDrawingRepository{
-				findAllFunc: func(ctx context.Context, limit, offset int) ([]*drawing.Drawing, error) {
-					return []*drawing.Drawing{}, nil
+				findSummariesFunc: func(ctx context.Context, query drawing.SummaryQuery, limit, offset int) ([]*drawing.DrawingSummary, error) {
+					return []*drawing.DrawingSummary{}, nil
 				},
-				countFunc: func(ctx context.Context) (int64, error) {
+				countFunc: func(ctx context.Context, filter drawing.DrawingFilter) (int64, error) {
 					return 0, nil
 				},
 			},
@@ -353,13 +512,13 @@ func TestListDrawings(t *testing.T) {
 				Offset: -5,
 			},
 			mockRepo: &mockDrawingRepository{
-				findAllFunc: func(ctx context.Context, limit, offset int) ([]*drawing.Drawing, error) {
+				findSummariesFunc: func(ctx context.Context, query drawing.SummaryQuery, limit, offset int) ([]*drawing.DrawingSummary, error) {
 					if offset != 0 {
 						t.Errorf("expected offset 0, got %d", offset)
 					}
-					return []*drawing.Drawing{}, nil
+					return []*drawing.DrawingSummary{}, nil
 				},
-				countFunc: func(ctx context.Context) (int64, error) {
+				countFunc: func(ctx context.Context, filter drawing.DrawingFilter) (int64, error) {
 					return 0, nil
 				},
 			},
@@ -371,13 +530,13 @@ func TestListDrawings(t *testing.T) {
 			},
 		},
 		{
-			name: "repository error on FindAll",
+			name: "repository error on FindSummaries",
 			input: ListDrawingsInput{
 				Limit:  10,
 				Offset: 0,
 			},
 			mockRepo: &mockDrawingRepository{
-				findAllFunc: func(ctx context.Context, limit, offset int) ([]*drawing.Drawing, error) {
+				findSummariesFunc: func(ctx context.Context, query drawing.SummaryQuery, limit, offset int) ([]*drawing.DrawingSummary, error) {
 					return nil, errors.New("database connection failed")
 				},
 			},
@@ -395,10 +554,10 @@ func TestListDrawings(t *testing.T) {
 				Offset: 0,
 			},
 			mockRepo: &mockDrawingRepository{
-				findAllFunc: func(ctx context.Context, limit, offset int) ([]*drawing.Drawing, error) {
-					return []*drawing.Drawing{}, nil
+				findSummariesFunc: func(ctx context.Context, query drawing.SummaryQuery, limit, offset int) ([]*drawing.DrawingSummary, error) {
+					return []*drawing.DrawingSummary{}, nil
 				},
-				countFunc: func(ctx context.Context) (int64, error) {
+				countFunc: func(ctx context.Context, filter drawing.DrawingFilter) (int64, error) {
 					return 0, errors.New("database connection failed")
 				},
 			},
@@ -416,15 +575,14 @@ func TestListDrawings(t *testing.T) {
 				Offset: 500,
 			},
 			mockRepo: &mockDrawingRepository{
-				findAllFunc: func(ctx context.Context, limit, offset int) ([]*drawing.Drawing, error) {
-					drawings := make([]*drawing.Drawing, 100)
+				findSummariesFunc: func(ctx context.Context, query drawing.SummaryQuery, limit, offset int) ([]*drawing.DrawingSummary, error) {
+					drawings := make([]*drawing.DrawingSummary, 100)
 					for i := 0; i < 100; i++ {
-						d, _ := drawing.NewDrawing("Drawing", map[string]interface{}{"elements": []interface{}{}})
-						drawings[i] = d
+						drawings[i] = &drawing.DrawingSummary{ID: uuid.New(), Name: "Drawing"}
 					}
 					return drawings, nil
 				},
-				countFunc: func(ctx context.Context) (int64, error) {
+				countFunc: func(ctx context.Context, filter drawing.DrawingFilter) (int64, error) {
 					return 10000, nil
 				},
 			},
@@ -445,7 +603,7 @@ func TestListDrawings(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			service := NewService(tt.mockRepo, logger)
+			service := NewService(tt.mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
 			ctx := context.Background()
 
 			output, err := service.ListDrawings(ctx, tt.input)
@@ -464,6 +622,118 @@ func TestListDrawings(t *testing.T) {
 	}
 }
 
+func TestListDrawingsCursor(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	// Five drawings, newest first
+	created := time.Date(2025, 12, 5, 10, 0, 0, 0, time.UTC)
+	list := make([]*drawing.DrawingSummary, 5)
+	for i := range list {
+		list[i] = &drawing.DrawingSummary{ID: uuid.New(), Name: string(rune('A' + i)), CreatedAt: created.Add(-time.Duration(i) * time.Minute)}
+	}
+
+	repo := &mockDrawingRepository{
+		findSummariesFunc: func(ctx context.Context, query drawing.SummaryQuery, limit, offset int) ([]*drawing.DrawingSummary, error) {
+			return list[offset:min(offset+limit, len(list))], nil
+		},
+		findByCursorFunc: func(ctx context.Context, query drawing.SummaryQuery, cursor *drawing.Cursor, limit int) ([]*drawing.DrawingSummary, error) {
+			at := -1
+			for i, d := range list {
+				if d.ID == cursor.ID {
+					at = i
+				}
+			}
+			if cursor.Before {
+				return list[max(at-limit, 0):at], nil
+			}
+			return list[at+1 : min(at+1+limit, len(list))], nil
+		},
+		countFunc: func(ctx context.Context, filter drawing.DrawingFilter) (int64, error) {
+			return int64(len(list)), nil
+		},
+	}
+	service := NewService(repo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+	ctx := context.Background()
+
+	names := func(out *DrawingListOutput) string {
+		var b strings.Builder
+		for _, d := range out.Drawings {
+			b.WriteString(d.Name)
+		}
+		return b.String()
+	}
+
+	first, err := service.ListDrawings(ctx, ListDrawingsInput{Limit: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names(first) != "AB" || first.NextCursor == "" || first.PrevCursor != "" {
+		t.Fatalf("expected the first page with a next cursor only, got %q %q %q", names(first), first.NextCursor, first.PrevCursor)
+	}
+
+	second, err := service.ListDrawings(ctx, ListDrawingsInput{Limit: 2, Cursor: first.NextCursor})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names(second) != "CD" || second.NextCursor == "" || second.PrevCursor == "" {
+		t.Fatalf("expected the second page with both cursors, got %q", names(second))
+	}
+
+	last, err := service.ListDrawings(ctx, ListDrawingsInput{Limit: 2, Cursor: second.NextCursor})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names(last) != "E" || last.NextCursor != "" || last.PrevCursor == "" {
+		t.Fatalf("expected the last page without a next cursor, got %q %q", names(last), last.NextCursor)
+	}
+
+	back, err := service.ListDrawings(ctx, ListDrawingsInput{Limit: 2, Cursor: last.PrevCursor})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names(back) != "CD" || back.NextCursor == "" || back.PrevCursor == "" {
+		t.Fatalf("expected to page back to the second page, got %q", names(back))
+	}
+
+	start, err := service.ListDrawings(ctx, ListDrawingsInput{Limit: 2, Cursor: back.PrevCursor})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names(start) != "AB" || start.PrevCursor != "" {
+		t.Fatalf("expected to page back to the first page without a previous cursor, got %q %q", names(start), start.PrevCursor)
+	}
+
+	// Offset pages hand out cursors around them
+	middle, err := service.ListDrawings(ctx, ListDrawingsInput{Limit: 2, Offset: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if middle.NextCursor != second.NextCursor || middle.PrevCursor != second.PrevCursor {
+		t.Errorf("expected an offset page to have the cursors of the same cursor page")
+	}
+
+	if _, err := service.ListDrawings(ctx, ListDrawingsInput{Cursor: "not-a-cursor"}); !errors.Is(err, drawing.ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+
+	// Cursors of sorted lists hold the sort key and only fit the order they were taken from
+	byName := drawing.SortOrder{Field: drawing.SortByName}
+	sorted, err := service.ListDrawings(ctx, ListDrawingsInput{Limit: 2, Order: byName})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cursor, err := drawing.DecodeCursor(sorted.NextCursor)
+	if err != nil || cursor.Key != "B" || cursor.Order() != byName {
+		t.Fatalf("expected a cursor at name B in name order, got %+v %v", cursor, err)
+	}
+	if _, err := service.ListDrawings(ctx, ListDrawingsInput{Limit: 2, Order: byName, Cursor: sorted.NextCursor}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := service.ListDrawings(ctx, ListDrawingsInput{Limit: 2, Cursor: sorted.NextCursor}); !errors.Is(err, drawing.ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor for a cursor from another order, got %v", err)
+	}
+}
+
 func TestGetDrawing(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 
@@ -558,7 +828,7 @@ func TestGetDrawing(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			service := NewService(tt.mockRepo, logger)
+			service := NewService(tt.mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
 			ctx := context.Background()
 
 			output, err := service.GetDrawing(ctx, tt.drawingID)
@@ -648,7 +918,7 @@ func TestUpdateDrawing(t *testing.T) {
 					})
 					return d, nil
 				},
-				updateFunc: func(ctx context.Context, d *drawing.Drawing) error {
+				updateFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
 					return nil
 				},
 			},
@@ -785,6 +1055,7 @@ func TestUpdateDrawing(t *testing.T) {
 				Data: map[string]interface{}{
 					"elements": []interface{}{
 						map[string]interface{}{
+							"id":   "rect-1",
 							"type": "rectangle",
 							"x":    100,
 							"y":    200,
@@ -801,7 +1072,7 @@ func TestUpdateDrawing(t *testing.T) {
 					d, _ := drawing.NewDrawing("Original", map[string]interface{}{"elements": []interface{}{}})
 					return d, nil
 				},
-				updateFunc: func(ctx context.Context, d *drawing.Drawing) error {
+				updateFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
 					return nil
 				},
 			},
@@ -834,7 +1105,7 @@ func TestUpdateDrawing(t *testing.T) {
 					d, _ := drawing.NewDrawing("Original", map[string]interface{}{"elements": []interface{}{}})
 					return d, nil
 				},
-				updateFunc: func(ctx context.Context, d *drawing.Drawing) error {
+				updateFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
 					return errors.New("database connection failed")
 				},
 			},
@@ -879,12 +1150,13 @@ func TestUpdateDrawing(t *testing.T) {
 						"original",
 						"Original",
 						map[string]interface{}{"elements": []interface{}{}},
+						1,
 						time.Now().Add(-24*time.Hour),
 						time.Now().Add(-24*time.Hour),
 					)
 					return d, nil
 				},
-				updateFunc: func(ctx context.Context, d *drawing.Drawing) error {
+				updateFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
 					return nil
 				},
 			},
@@ -907,7 +1179,7 @@ func TestUpdateDrawing(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			service := NewService(tt.mockRepo, logger)
+			service := NewService(tt.mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
 			ctx := context.Background()
 
 			output, err := service.UpdateDrawing(ctx, tt.drawingID, tt.input)
@@ -939,13 +1211,7 @@ func TestDeleteDrawing(t *testing.T) {
 			name:      "successful delete",
 			drawingID: "123e4567-e89b-12d3-a456-426614174000",
 			mockRepo: &mockDrawingRepository{
-				findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
-					d, _ := drawing.NewDrawing("Test Drawing", map[string]interface{}{
-						"elements": []interface{}{},
-					})
-					return d, nil
-				},
-				deleteFunc: func(ctx context.Context, id uuid.UUID) error {
+				deleteFunc: func(ctx context.Context, id uuid.UUID, expectedVersions []int64) error {
 					return nil
 				},
 			},
@@ -955,8 +1221,8 @@ func TestDeleteDrawing(t *testing.T) {
 			name:      "drawing not found",
 			drawingID: "123e4567-e89b-12d3-a456-426614174000",
 			mockRepo: &mockDrawingRepository{
-				findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
-					return nil, drawing.ErrDrawingNotFound
+				deleteFunc: func(ctx context.Context, id uuid.UUID, expectedVersions []int64) error {
+					return drawing.ErrDrawingNotFound
 				},
 			},
 			expectError: true,
@@ -977,13 +1243,7 @@ func TestDeleteDrawing(t *testing.T) {
 			name:      "repository delete error",
 			drawingID: "123e4567-e89b-12d3-a456-426614174000",
 			mockRepo: &mockDrawingRepository{
-				findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
-					d, _ := drawing.NewDrawing("Test Drawing", map[string]interface{}{
-						"elements": []interface{}{},
-					})
-					return d, nil
-				},
-				deleteFunc: func(ctx context.Context, id uuid.UUID) error {
+				deleteFunc: func(ctx context.Context, id uuid.UUID, expectedVersions []int64) error {
 					return errors.New("database connection failed")
 				},
 			},
@@ -993,10 +1253,10 @@ func TestDeleteDrawing(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			service := NewService(tt.mockRepo, logger)
+			service := NewService(tt.mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
 			ctx := context.Background()
 
-			err := service.DeleteDrawing(ctx, tt.drawingID)
+			err := service.DeleteDrawing(ctx, tt.drawingID, nil)
 
 			if tt.expectError && err == nil {
 				t.Error("expected error but got none")
@@ -1007,3 +1267,1194 @@ func TestDeleteDrawing(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateDrawingSlug(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	tests := []struct {
+		name         string
+		slugs        []string
+		conflicts    int
+		expectError  bool
+		expectedSlug string
+	}{
+		{
+			name:         "slug assigned on first attempt",
+			slugs:        []string{"first111"},
+			conflicts:    0,
+			expectError:  false,
+			expectedSlug: "first111",
+		},
+		{
+			name:         "slug regenerated after collision",
+			slugs:        []string{"taken111", "free2222"},
+			conflicts:    1,
+			expectError:  false,
+			expectedSlug: "free2222",
+		},
+		{
+			name:        "gives up after repeated collisions",
+			slugs:       []string{"a", "b", "c", "d", "e"},
+			conflicts:   maxSlugAttempts,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generated := 0
+			slugGen := &mockSlugGenerator{
+				generateFunc: func() (string, error) {
+					slug := tt.slugs[generated]
+					generated++
+					return slug, nil
+				},
+			}
+
+			calls := 0
+			mockRepo := &mockDrawingRepository{
+				createFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+					calls++
+					if calls <= tt.conflicts {
+						return drawing.ErrSlugConflict
+					}
+					return nil
+				},
+			}
+
+			service := NewService(mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, slugGen, logger)
+			output, err := service.CreateDrawing(context.Background(), CreateDrawingInput{
+				Name: "Test Drawing",
+				Data: map[string]interface{}{"elements": []interface{}{}},
+			})
+
+			if tt.expectError {
+				if !errors.Is(err, drawing.ErrSlugConflict) {
+					t.Errorf("expected ErrSlugConflict, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output.Slug != tt.expectedSlug {
+				t.Errorf("expected slug '%s', got '%s'", tt.expectedSlug, output.Slug)
+			}
+		})
+	}
+}
+
+func TestGetDrawingBySlug(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	tests := []struct {
+		name        string
+		slug        string
+		mockRepo    *mockDrawingRepository
+		expectedErr error
+	}{
+		{
+			name: "successful get by slug",
+			slug: "Xk9pQ2mR",
+			mockRepo: &mockDrawingRepository{
+				findBySlugFunc: func(ctx context.Context, slug string) (*drawing.Drawing, error) {
+					d, _ := drawing.NewDrawing("Test Drawing", map[string]interface{}{"elements": []interface{}{}})
+					d.SetSlug(slug)
+					return d, nil
+				},
+			},
+			expectedErr: nil,
+		},
+		{
+			name: "slug not found",
+			slug: "missing1",
+			mockRepo: &mockDrawingRepository{
+				findBySlugFunc: func(ctx context.Context, slug string) (*drawing.Drawing, error) {
+					return nil, drawing.ErrDrawingNotFound
+				},
+			},
+			expectedErr: drawing.ErrDrawingNotFound,
+		},
+		{
+			name: "previous slug resolves through alias",
+			slug: "Xk9pQ2mR",
+			mockRepo: &mockDrawingRepository{
+				findBySlugFunc: func(ctx context.Context, slug string) (*drawing.Drawing, error) {
+					return nil, drawing.ErrDrawingNotFound
+				},
+				findBySlugAliasFunc: func(ctx context.Context, slug string) (*drawing.Drawing, error) {
+					d, _ := drawing.NewDrawing("Test Drawing", map[string]interface{}{"elements": []interface{}{}})
+					d.SetSlug("Xk9pQ2mR")
+					return d, nil
+				},
+			},
+			expectedErr: nil,
+		},
+		{
+			name:        "empty slug",
+			slug:        "",
+			mockRepo:    &mockDrawingRepository{},
+			expectedErr: drawing.ErrDrawingNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewService(tt.mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+
+			output, err := service.GetDrawingBySlug(context.Background(), tt.slug)
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output.Slug != tt.slug {
+				t.Errorf("expected slug '%s', got '%s'", tt.slug, output.Slug)
+			}
+		})
+	}
+}
+
+func TestBackfillSlugs(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	d1, _ := drawing.NewDrawing("Drawing 1", map[string]interface{}{"elements": []interface{}{}})
+	d2, _ := drawing.NewDrawing("Drawing 2", map[string]interface{}{"elements": []interface{}{}})
+	pending := []*drawing.Drawing{d1, d2}
+
+	assigned := make(map[uuid.UUID]string)
+	conflicted := false
+	mockRepo := &mockDrawingRepository{
+		findWithoutSlugFunc: func(ctx context.Context, limit int) ([]*drawing.Drawing, error) {
+			var remaining []*drawing.Drawing
+			for _, d := range pending {
+				if _, ok := assigned[d.ID()]; !ok {
+					remaining = append(remaining, d)
+				}
+			}
+			return remaining, nil
+		},
+		updateSlugFunc: func(ctx context.Context, id uuid.UUID, slug string) error {
+			// Simulate one collision to exercise the retry path
+			if !conflicted {
+				conflicted = true
+				return drawing.ErrSlugConflict
+			}
+			assigned[id] = slug
+			return nil
+		},
+	}
+
+	service := NewService(mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+
+	count, err := service.BackfillSlugs(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 backfilled drawings, got %d", count)
+	}
+	if len(assigned) != 2 {
+		t.Errorf("expected 2 slugs assigned, got %d", len(assigned))
+	}
+}
+
+func TestUpdateDrawingSlug(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	tests := []struct {
+		name         string
+		slug         string
+		updateErr    error
+		expectedErr  error
+		expectedSlug string
+	}{
+		{name: "valid custom slug", slug: "payments-architecture", expectedSlug: "payments-architecture"},
+		{name: "empty slug keeps current", slug: "", expectedSlug: "Xk9pQ2mR"},
+		{name: "too short", slug: "ab", expectedErr: drawing.ErrInvalidSlug},
+		{name: "too long", slug: strings.Repeat("a", drawing.MaxSlugLength+1), expectedErr: drawing.ErrInvalidSlug},
+		{name: "uppercase characters", slug: "Payments", expectedErr: drawing.ErrInvalidSlug},
+		{name: "leading hyphen", slug: "-payments", expectedErr: drawing.ErrInvalidSlug},
+		{name: "consecutive hyphens", slug: "payments--architecture", expectedErr: drawing.ErrInvalidSlug},
+		{name: "reserved word", slug: "search", expectedErr: drawing.ErrReservedSlug},
+		{name: "slug in use", slug: "taken-slug", updateErr: drawing.ErrSlugConflict, expectedErr: drawing.ErrSlugConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &mockDrawingRepository{
+				findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
+					d, _ := drawing.NewDrawing("Test Drawing", map[string]interface{}{"elements": []interface{}{}})
+					d.SetSlug("Xk9pQ2mR")
+					return d, nil
+				},
+				updateFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+					return tt.updateErr
+				},
+			}
+
+			service := NewService(mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+			output, err := service.UpdateDrawing(context.Background(), uuid.New().String(), UpdateDrawingInput{Slug: tt.slug})
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output.Slug != tt.expectedSlug {
+				t.Errorf("expected slug '%s', got '%s'", tt.expectedSlug, output.Slug)
+			}
+		})
+	}
+}
+
+func TestUpdateDrawingRecordsRevision(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	var recorded []*drawing.Revision
+	mockRepo := &mockDrawingRepository{
+		findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
+			d, _ := drawing.NewDrawing("Original", map[string]interface{}{"elements": []interface{}{}})
+			return d, nil
+		},
+		updateFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+			recorded = append(recorded, rev)
+			rev.SetNumber(len(recorded) + 1)
+			return nil
+		},
+	}
+
+	service := NewService(mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+
+	_, err := service.UpdateDrawing(context.Background(), uuid.New().String(), UpdateDrawingInput{Name: "Renamed"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(recorded) != 1 {
+		t.Fatalf("expected 1 revision recorded, got %d", len(recorded))
+	}
+	if recorded[0].Name() != "Renamed" {
+		t.Errorf("expected revision name 'Renamed', got '%s'", recorded[0].Name())
+	}
+
+	// A failing revision lookup surfaces as an error before anything is saved
+	saved := false
+	mockRepo.updateFunc = func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+		saved = true
+		return nil
+	}
+	failing := &mockRevisionRepository{
+		findLatestMetaFunc: func(ctx context.Context, drawingID uuid.UUID) (*drawing.RevisionMeta, error) {
+			return nil, errors.New("database connection failed")
+		},
+	}
+	service = NewService(mockRepo, failing, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+	if _, err := service.UpdateDrawing(context.Background(), uuid.New().String(), UpdateDrawingInput{Name: "Again"}); err == nil {
+		t.Error("expected error when revision cannot be recorded")
+	}
+	if saved {
+		t.Error("expected the drawing not to be saved without its revision")
+	}
+}
+
+func TestRestoreRevision(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	drawingID := uuid.New()
+	oldData := drawing.DrawingData{"elements": []interface{}{map[string]interface{}{"id": "rect-1", "type": "rectangle"}}}
+
+	tests := []struct {
+		name             string
+		number           int
+		expectedVersions []int64
+		findRevErr       error
+		expectedErr      error
+	}{
+		{name: "successful restore", number: 2},
+		{name: "restore at the expected version", number: 2, expectedVersions: []int64{1}},
+		{name: "restore over a newer version", number: 2, expectedVersions: []int64{3}, expectedErr: drawing.ErrVersionConflict},
+		{name: "revision not found", number: 9, findRevErr: drawing.ErrRevisionNotFound, expectedErr: drawing.ErrRevisionNotFound},
+		{name: "invalid revision number", number: 0, expectedErr: drawing.ErrInvalidRevisionNumber},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var recorded *drawing.Revision
+			revisionRepo := &mockRevisionRepository{
+				findByNumberFunc: func(ctx context.Context, id uuid.UUID, number int) (*drawing.Revision, error) {
+					if tt.findRevErr != nil {
+						return nil, tt.findRevErr
+					}
+					return drawing.ReconstituteRevision(uuid.New(), id, number, "Old Name", oldData, 0, "", time.Now()), nil
+				},
+			}
+			mockRepo := &mockDrawingRepository{
+				findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
+					d, _ := drawing.NewDrawing("Current Name", map[string]interface{}{"elements": []interface{}{}})
+					return d, nil
+				},
+				updateFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+					recorded = rev
+					rev.SetNumber(5)
+					return nil
+				},
+			}
+
+			service := NewService(mockRepo, revisionRepo, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+			output, err := service.RestoreRevision(context.Background(), drawingID.String(), tt.number, tt.expectedVersions)
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output.Name != "Old Name" {
+				t.Errorf("expected restored name 'Old Name', got '%s'", output.Name)
+			}
+			if elements, ok := output.Data["elements"].([]interface{}); !ok || len(elements) != 1 {
+				t.Error("expected restored elements")
+			}
+			if recorded == nil || recorded.RestoredFrom() != tt.number {
+				t.Errorf("expected restore recorded as revision restored from %d", tt.number)
+			}
+		})
+	}
+}
+
+func TestUpdateDrawingCoalescesRevisions(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	policy := drawing.RevisionPolicy{CoalesceWindow: time.Minute}
+
+	tests := []struct {
+		name           string
+		clientID       string
+		latest         *drawing.RevisionMeta
+		expectOverride bool
+	}{
+		{
+			name:           "same client within window",
+			clientID:       "tab-1",
+			latest:         &drawing.RevisionMeta{ID: uuid.New(), Number: 4, ClientID: "tab-1", CreatedAt: time.Now().UTC()},
+			expectOverride: true,
+		},
+		{
+			name:           "different client",
+			clientID:       "tab-2",
+			latest:         &drawing.RevisionMeta{ID: uuid.New(), Number: 4, ClientID: "tab-1", CreatedAt: time.Now().UTC()},
+			expectOverride: false,
+		},
+		{
+			name:           "outside window",
+			clientID:       "tab-1",
+			latest:         &drawing.RevisionMeta{ID: uuid.New(), Number: 4, ClientID: "tab-1", CreatedAt: time.Now().UTC().Add(-2 * time.Minute)},
+			expectOverride: false,
+		},
+		{
+			name:           "latest revision is a restore",
+			clientID:       "tab-1",
+			latest:         &drawing.RevisionMeta{ID: uuid.New(), Number: 4, RestoredFrom: 2, ClientID: "tab-1", CreatedAt: time.Now().UTC()},
+			expectOverride: false,
+		},
+		{
+			name:           "anonymous client",
+			clientID:       "",
+			latest:         &drawing.RevisionMeta{ID: uuid.New(), Number: 4, CreatedAt: time.Now().UTC()},
+			expectOverride: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created, overwritten := 0, 0
+			revisionRepo := &mockRevisionRepository{
+				findLatestMetaFunc: func(ctx context.Context, drawingID uuid.UUID) (*drawing.RevisionMeta, error) {
+					return tt.latest, nil
+				},
+			}
+			mockRepo := &mockDrawingRepository{
+				findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
+					d, _ := drawing.NewDrawing("Old", map[string]interface{}{"elements": []interface{}{}})
+					return d, nil
+				},
+				updateFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+					// A numbered revision overwrites the stored one, a new one is inserted
+					if rev.Number() == 0 {
+						created++
+						return nil
+					}
+					overwritten++
+					if rev.Number() != 4 || rev.Name() != "New" {
+						t.Errorf("expected revision 4 overwritten with name 'New', got %d '%s'", rev.Number(), rev.Name())
+					}
+					return nil
+				},
+			}
+
+			service := NewService(mockRepo, revisionRepo, policy, &mockSlugGenerator{}, logger)
+			_, err := service.UpdateDrawing(context.Background(), uuid.New().String(), UpdateDrawingInput{Name: "New", ClientID: tt.clientID})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.expectOverride && (overwritten != 1 || created != 0) {
+				t.Errorf("expected revision to be coalesced, got %d created and %d overwritten", created, overwritten)
+			}
+			if !tt.expectOverride && (overwritten != 0 || created != 1) {
+				t.Errorf("expected new revision, got %d created and %d overwritten", created, overwritten)
+			}
+		})
+	}
+}
+
+func TestDrawingVersionConflict(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	tests := []struct {
+		name             string
+		expectedVersions []int64
+		expectConflict   bool
+	}{
+		{
+			name:           "unconditional write",
+			expectConflict: false,
+		},
+		{
+			name:             "matching version",
+			expectedVersions: []int64{3},
+			expectConflict:   false,
+		},
+		{
+			name:             "matching one of several versions",
+			expectedVersions: []int64{2, 3},
+			expectConflict:   false,
+		},
+		{
+			name:             "stale version",
+			expectedVersions: []int64{2},
+			expectConflict:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, deleted := false, false
+			mockRepo := &mockDrawingRepository{
+				findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
+					return drawing.Reconstitute(id, "my-drawing", "Old", map[string]interface{}{"elements": []interface{}{}}, 3, time.Now().UTC(), time.Now().UTC())
+				},
+				updateFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+					updated = true
+					if d.Version() != 4 {
+						t.Errorf("expected version 4 after update, got %d", d.Version())
+					}
+					return nil
+				},
+				deleteFunc: func(ctx context.Context, id uuid.UUID, expectedVersions []int64) error {
+					if len(expectedVersions) != 0 && !slices.Contains(expectedVersions, 3) {
+						return drawing.ErrVersionConflict
+					}
+					deleted = true
+					return nil
+				},
+			}
+
+			service := NewService(mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+			id := uuid.New().String()
+
+			output, err := service.UpdateDrawing(context.Background(), id, UpdateDrawingInput{Name: "New", ExpectedVersions: tt.expectedVersions})
+			if tt.expectConflict {
+				if !errors.Is(err, drawing.ErrVersionConflict) {
+					t.Errorf("expected ErrVersionConflict on update, got %v", err)
+				}
+				if updated {
+					t.Error("expected stale update not to be persisted")
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if output.Version != 4 {
+					t.Errorf("expected output version 4, got %d", output.Version)
+				}
+			}
+
+			err = service.DeleteDrawing(context.Background(), id, tt.expectedVersions)
+			if tt.expectConflict {
+				if !errors.Is(err, drawing.ErrVersionConflict) {
+					t.Errorf("expected ErrVersionConflict on delete, got %v", err)
+				}
+				if deleted {
+					t.Error("expected stale delete not to be performed")
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestPatchDrawing(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	stored := func() drawing.DrawingData {
+		return map[string]interface{}{
+			"elements": []interface{}{
+				map[string]interface{}{"id": "a", "type": "rectangle", "x": 1.0},
+				map[string]interface{}{"id": "b", "type": "ellipse", "x": 2.0},
+			},
+			"appState": map[string]interface{}{"viewBackgroundColor": "#ffffff", "gridSize": nil},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		format      PatchFormat
+		patch       string
+		expectedErr error
+		validate    func(t *testing.T, data map[string]interface{})
+	}{
+		{
+			name:   "json patch appends element",
+			format: PatchFormatJSONPatch,
+			patch:  `[{"op":"add","path":"/elements/-","value":{"id":"c","type":"text","text":"hi"}}]`,
+			validate: func(t *testing.T, data map[string]interface{}) {
+				elements := data["elements"].([]interface{})
+				if len(elements) != 3 || elements[2].(map[string]interface{})["id"] != "c" {
+					t.Errorf("expected element c appended, got %v", elements)
+				}
+			},
+		},
+		{
+			name:   "json patch replaces nested field after test",
+			format: PatchFormatJSONPatch,
+			patch:  `[{"op":"test","path":"/elements/1/id","value":"b"},{"op":"replace","path":"/elements/1/x","value":42}]`,
+			validate: func(t *testing.T, data map[string]interface{}) {
+				element := data["elements"].([]interface{})[1].(map[string]interface{})
+				if element["x"] != 42.0 {
+					t.Errorf("expected x replaced with 42, got %v", element["x"])
+				}
+			},
+		},
+		{
+			name:   "json patch removes, moves and copies",
+			format: PatchFormatJSONPatch,
+			patch:  `[{"op":"remove","path":"/elements/0"},{"op":"copy","from":"/elements/0/x","path":"/appState/x"},{"op":"move","from":"/appState/viewBackgroundColor","path":"/appState/bg"}]`,
+			validate: func(t *testing.T, data map[string]interface{}) {
+				elements := data["elements"].([]interface{})
+				if len(elements) != 1 || elements[0].(map[string]interface{})["id"] != "b" {
+					t.Errorf("expected only element b, got %v", elements)
+				}
+				appState := data["appState"].(map[string]interface{})
+				if appState["x"] != 2.0 {
+					t.Errorf("expected x of element b copied, got %v", appState["x"])
+				}
+				if _, ok := appState["viewBackgroundColor"]; ok || appState["bg"] != "#ffffff" {
+					t.Errorf("expected viewBackgroundColor moved to bg, got %v", appState)
+				}
+			},
+		},
+		{
+			name:   "json patch unescapes pointer tokens",
+			format: PatchFormatJSONPatch,
+			patch:  `[{"op":"add","path":"/appState/a~1b~0c","value":true}]`,
+			validate: func(t *testing.T, data map[string]interface{}) {
+				if data["appState"].(map[string]interface{})["a/b~c"] != true {
+					t.Errorf("expected appState member 'a/b~c', got %v", data["appState"])
+				}
+			},
+		},
+		{
+			name:        "failed test leaves drawing untouched",
+			format:      PatchFormatJSONPatch,
+			patch:       `[{"op":"remove","path":"/elements/0"},{"op":"test","path":"/elements/0/id","value":"a"}]`,
+			expectedErr: drawing.ErrPatchConflict,
+		},
+		{
+			name:        "index out of range",
+			format:      PatchFormatJSONPatch,
+			patch:       `[{"op":"replace","path":"/elements/5","value":{}}]`,
+			expectedErr: drawing.ErrPatchConflict,
+		},
+		{
+			name:        "unknown op",
+			format:      PatchFormatJSONPatch,
+			patch:       `[{"op":"frobnicate","path":"/elements"}]`,
+			expectedErr: drawing.ErrInvalidPatch,
+		},
+		{
+			name:        "not an array",
+			format:      PatchFormatJSONPatch,
+			patch:       `{"op":"add"}`,
+			expectedErr: drawing.ErrInvalidPatch,
+		},
+		{
+			name:        "replacing the root with a non-object",
+			format:      PatchFormatJSONPatch,
+			patch:       `[{"op":"replace","path":"","value":[1,2]}]`,
+			expectedErr: drawing.ErrInvalidDrawingData,
+		},
+		{
+			name:   "merge patch updates and deletes members",
+			format: PatchFormatMergePatch,
+			patch:  `{"appState":{"viewBackgroundColor":"#000000","theme":"dark"},"files":null}`,
+			validate: func(t *testing.T, data map[string]interface{}) {
+				appState := data["appState"].(map[string]interface{})
+				if appState["viewBackgroundColor"] != "#000000" || appState["theme"] != "dark" {
+					t.Errorf("expected appState merged, got %v", appState)
+				}
+				if len(data["elements"].([]interface{})) != 2 {
+					t.Error("expected elements untouched")
+				}
+			},
+		},
+		{
+			name:        "merge patch with invalid JSON",
+			format:      PatchFormatMergePatch,
+			patch:       `{`,
+			expectedErr: drawing.ErrInvalidPatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var current *drawing.Drawing
+			mockRepo := &mockDrawingRepository{
+				findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
+					current, _ = drawing.Reconstitute(id, "my-drawing", "My Drawing", stored(), 3, time.Now().UTC(), time.Now().UTC())
+					return current, nil
+				},
+				updateFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+					return nil
+				},
+			}
+
+			service := NewService(mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+			output, err := service.PatchDrawing(context.Background(), uuid.New().String(), PatchDrawingInput{Format: tt.format, Patch: []byte(tt.patch)})
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				if current.Version() != 3 || len(current.Data()["elements"].([]interface{})) != 2 {
+					t.Error("expected drawing to be left untouched")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output.Version != 4 {
+				t.Errorf("expected version 4, got %d", output.Version)
+			}
+			tt.validate(t, output.Data)
+		})
+	}
+}
+
+func TestPatchDrawingNoOp(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	tests := []struct {
+		name   string
+		format PatchFormat
+		patch  string
+	}{
+		{name: "json patch with only a test", format: PatchFormatJSONPatch, patch: `[{"op":"test","path":"/elements/0/id","value":"a"}]`},
+		{name: "empty json patch", format: PatchFormatJSONPatch, patch: `[]`},
+		{name: "empty merge patch", format: PatchFormatMergePatch, patch: `{}`},
+		{name: "merge patch with current values", format: PatchFormatMergePatch, patch: `{"appState":{"viewBackgroundColor":"#ffffff"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := false
+			mockRepo := &mockDrawingRepository{
+				findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
+					data := map[string]interface{}{
+						"elements": []interface{}{map[string]interface{}{"id": "a", "type": "rectangle", "x": 1.0}},
+						"appState": map[string]interface{}{"viewBackgroundColor": "#ffffff"},
+					}
+					return drawing.Reconstitute(id, "my-drawing", "My Drawing", data, 3, time.Now().UTC(), time.Now().UTC())
+				},
+				updateFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+					updated = true
+					return nil
+				},
+			}
+
+			service := NewService(mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+			output, err := service.PatchDrawing(context.Background(), uuid.New().String(), PatchDrawingInput{Format: tt.format, Patch: []byte(tt.patch)})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output.Version != 3 {
+				t.Errorf("expected version to stay at 3, got %d", output.Version)
+			}
+			if updated {
+				t.Error("expected no-op patch not to be saved")
+			}
+		})
+	}
+}
+
+func TestUpdateDrawingMerge(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	element := func(id string, version, nonce float64, extra ...interface{}) map[string]interface{} {
+		el := map[string]interface{}{"id": id, "type": "rectangle", "version": version, "versionNonce": nonce}
+		for i := 0; i+1 < len(extra); i += 2 {
+			el[extra[i].(string)] = extra[i+1]
+		}
+		return el
+	}
+	file := func(id string) map[string]interface{} {
+		return map[string]interface{}{"id": id, "mimeType": "image/png", "dataURL": "data:image/png;base64,AA=="}
+	}
+	ids := func(data map[string]interface{}) []string {
+		var out []string
+		for _, el := range data["elements"].([]interface{}) {
+			m := el.(map[string]interface{})
+			id := m["id"].(string)
+			if m["isDeleted"] == true {
+				id += "(deleted)"
+			}
+			out = append(out, id)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name        string
+		stored      []interface{}
+		incoming    []interface{}
+		merge       bool
+		expectedIDs []string
+		check       func(t *testing.T, data map[string]interface{})
+	}{
+		{
+			name:        "without merge the scene is replaced",
+			stored:      []interface{}{element("a", 1, 1), element("b", 1, 1)},
+			incoming:    []interface{}{element("a", 2, 1)},
+			expectedIDs: []string{"a"},
+		},
+		{
+			name:        "elements added by another client are kept in place",
+			stored:      []interface{}{element("a", 1, 1), element("b", 1, 1), element("c", 1, 1)},
+			incoming:    []interface{}{element("a", 1, 1), element("c", 1, 1), element("d", 1, 1)},
+			merge:       true,
+			expectedIDs: []string{"a", "b", "c", "d"},
+		},
+		{
+			name:     "higher stored version wins",
+			stored:   []interface{}{element("a", 5, 1, "x", 50.0)},
+			incoming: []interface{}{element("a", 4, 1, "x", 40.0)},
+			merge:    true,
+			check: func(t *testing.T, data map[string]interface{}) {
+				if x := data["elements"].([]interface{})[0].(map[string]interface{})["x"]; x != 50.0 {
+					t.Errorf("expected stored element to win, got x=%v", x)
+				}
+			},
+		},
+		{
+			name:     "higher incoming version wins",
+			stored:   []interface{}{element("a", 4, 1, "x", 40.0)},
+			incoming: []interface{}{element("a", 5, 1, "x", 50.0)},
+			merge:    true,
+			check: func(t *testing.T, data map[string]interface{}) {
+				if x := data["elements"].([]interface{})[0].(map[string]interface{})["x"]; x != 50.0 {
+					t.Errorf("expected incoming element to win, got x=%v", x)
+				}
+			},
+		},
+		{
+			name:     "equal versions tie-break on lower versionNonce",
+			stored:   []interface{}{element("a", 3, 100, "x", 1.0)},
+			incoming: []interface{}{element("a", 3, 200, "x", 2.0)},
+			merge:    true,
+			check: func(t *testing.T, data map[string]interface{}) {
+				if x := data["elements"].([]interface{})[0].(map[string]interface{})["x"]; x != 1.0 {
+					t.Errorf("expected element with lower versionNonce to win, got x=%v", x)
+				}
+			},
+		},
+		{
+			name:        "tombstones are not resurrected by stale clients",
+			stored:      []interface{}{element("a", 3, 1, "isDeleted", true), element("b", 1, 1)},
+			incoming:    []interface{}{element("a", 2, 1), element("b", 1, 1)},
+			merge:       true,
+			expectedIDs: []string{"a(deleted)", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &mockDrawingRepository{
+				findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
+					data := map[string]interface{}{
+						"elements": tt.stored,
+						"files":    map[string]interface{}{"f1": file("f1")},
+					}
+					return drawing.Reconstitute(id, "my-drawing", "My Drawing", data, 3, time.Now().UTC(), time.Now().UTC())
+				},
+				updateFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+					return nil
+				},
+			}
+
+			service := NewService(mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+			input := UpdateDrawingInput{
+				Data: map[string]interface{}{
+					"elements": tt.incoming,
+					"appState": map[string]interface{}{"theme": "dark"},
+					"files":    map[string]interface{}{"f2": file("f2")},
+				},
+				Merge: tt.merge,
+			}
+
+			output, err := service.UpdateDrawing(context.Background(), uuid.New().String(), input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.expectedIDs != nil {
+				got := ids(output.Data)
+				if strings.Join(got, ",") != strings.Join(tt.expectedIDs, ",") {
+					t.Errorf("expected elements %v, got %v", tt.expectedIDs, got)
+				}
+			}
+			if tt.check != nil {
+				tt.check(t, output.Data)
+			}
+
+			files := output.Data["files"].(map[string]interface{})
+			if _, ok := files["f2"]; !ok {
+				t.Errorf("expected incoming files, got %v", files)
+			}
+			if _, ok := files["f1"]; tt.merge && !ok {
+				t.Errorf("expected files to be merged, got %v", files)
+			}
+			if output.Data["appState"].(map[string]interface{})["theme"] != "dark" {
+				t.Error("expected incoming appState")
+			}
+		})
+	}
+}
+
+func TestUpdateDrawingMergeRetriesOnConflict(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	element := func(id string) map[string]interface{} {
+		return map[string]interface{}{"id": id, "type": "rectangle", "version": 1.0, "versionNonce": 1.0}
+	}
+
+	tests := []struct {
+		name             string
+		expectedVersions []int64
+		conflicts        int
+		expectedErr      error
+		expectedLoads    int
+	}{
+		{name: "merge reconciles again after a concurrent save", conflicts: 1, expectedLoads: 2},
+		{name: "merge gives up after repeated conflicts", conflicts: maxMergeAttempts, expectedErr: drawing.ErrConcurrentUpdate, expectedLoads: maxMergeAttempts},
+		{name: "merge with an expected version is not retried", expectedVersions: []int64{3}, conflicts: 1, expectedErr: drawing.ErrVersionConflict, expectedLoads: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loads, saves := 0, 0
+			var saved *drawing.Drawing
+			mockRepo := &mockDrawingRepository{
+				findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
+					loads++
+					// Another client adds an element before each retry
+					elements := []interface{}{element("a")}
+					if loads > 1 {
+						elements = append(elements, element("b"))
+					}
+					return drawing.Reconstitute(id, "my-drawing", "My Drawing", map[string]interface{}{"elements": elements}, 3, time.Now().UTC(), time.Now().UTC())
+				},
+				updateFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+					saves++
+					if saves <= tt.conflicts {
+						return drawing.ErrConcurrentUpdate
+					}
+					saved = d
+					return nil
+				},
+			}
+
+			service := NewService(mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+			input := UpdateDrawingInput{
+				Data:             map[string]interface{}{"elements": []interface{}{element("a"), element("c")}},
+				Merge:            true,
+				ExpectedVersions: tt.expectedVersions,
+			}
+
+			_, err := service.UpdateDrawing(context.Background(), uuid.New().String(), input)
+			if loads != tt.expectedLoads {
+				t.Errorf("expected %d loads, got %d", tt.expectedLoads, loads)
+			}
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if n := len(saved.Data()["elements"].([]interface{})); n != 3 {
+				t.Errorf("expected the concurrent element to be kept alongside the merged one, got %d elements", n)
+			}
+		})
+	}
+}
+
+func TestCreateDrawingSceneValidation(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	tests := []struct {
+		name           string
+		data           string
+		expectedFields []string
+	}{
+		{
+			name:           "not a scene",
+			data:           `{"foo":1}`,
+			expectedFields: []string{"elements"},
+		},
+		{
+			name:           "elements is not an array",
+			data:           `{"elements":{}}`,
+			expectedFields: []string{"elements"},
+		},
+		{
+			name:           "malformed elements",
+			data:           `{"elements":[{"type":"rectangle","x":"left"},{"id":"a","type":"text"},{"id":"a","type":"arrow","points":[[0]],"opacity":150},3]}`,
+			expectedFields: []string{"elements[0].x", "elements[0].id", "elements[1].text", "elements[2].id", "elements[2].points[0]", "elements[2].opacity", "elements[3]"},
+		},
+		{
+			name:           "malformed bindings",
+			data:           `{"elements":[{"id":"a","type":"arrow","startBinding":{"focus":0},"boundElements":[{"id":"t"}]}]}`,
+			expectedFields: []string{"elements[0].boundElements[0]", "elements[0].startBinding.elementId"},
+		},
+		{
+			name:           "malformed files and app state",
+			data:           `{"elements":[],"appState":{"viewBackgroundColor":1},"files":{"f1":{"id":"f2","mimeType":"image/png"}}}`,
+			expectedFields: []string{"appState.viewBackgroundColor", "files[f1].id", "files[f1].dataURL"},
+		},
+		{
+			name: "valid scene with unknown fields",
+			data: `{"type":"excalidraw","version":2,"elements":[{"id":"a","type":"rectangle","x":1,"customData":{"k":"v"}}],"appState":{"zoom":{"value":1}},"files":{},"libraryItems":[]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.data), &data); err != nil {
+				t.Fatalf("invalid test data: %v", err)
+			}
+
+			mockRepo := &mockDrawingRepository{
+				createFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+					return nil
+				},
+			}
+			service := NewService(mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+
+			output, err := service.CreateDrawing(context.Background(), CreateDrawingInput{Name: "Scene", Data: data})
+
+			if len(tt.expectedFields) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !reflect.DeepEqual(map[string]interface{}(output.Data), data) {
+					t.Errorf("expected data to be stored unchanged, got %v", output.Data)
+				}
+				return
+			}
+
+			if !errors.Is(err, drawing.ErrInvalidDrawingData) {
+				t.Fatalf("expected ErrInvalidDrawingData, got %v", err)
+			}
+			var sceneErr *drawing.SceneError
+			if !errors.As(err, &sceneErr) {
+				t.Fatalf("expected *drawing.SceneError, got %T", err)
+			}
+
+			got := make(map[string]bool)
+			for _, f := range sceneErr.Fields {
+				got[f.Field] = true
+			}
+			for _, field := range tt.expectedFields {
+				if !got[field] {
+					t.Errorf("expected error for field %s, got %v", field, sceneErr.Fields)
+				}
+			}
+			if len(sceneErr.Fields) != len(tt.expectedFields) {
+				t.Errorf("expected %d field errors, got %v", len(tt.expectedFields), sceneErr.Fields)
+			}
+		})
+	}
+}
+
+func TestUpdateLegacyDrawing(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	// Stored before scene validation, so it is no valid scene
+	legacy := drawing.DrawingData{"foo": float64(1)}
+
+	tests := []struct {
+		name        string
+		input       UpdateDrawingInput
+		restore     bool
+		expectedErr error
+	}{
+		{name: "rename", input: UpdateDrawingInput{Name: "Renamed"}},
+		{name: "change slug", input: UpdateDrawingInput{Slug: "legacy-drawing"}},
+		{name: "restore a legacy revision", restore: true},
+		{name: "replace data with an invalid scene", input: UpdateDrawingInput{Data: map[string]interface{}{"bar": 2}}, expectedErr: drawing.ErrInvalidDrawingData},
+		{name: "empty name", input: UpdateDrawingInput{Name: "   "}, expectedErr: drawing.ErrEmptyName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := uuid.New()
+			var saved *drawing.Drawing
+			mockRepo := &mockDrawingRepository{
+				findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
+					return drawing.Reconstitute(id, "legacy", "Legacy", legacy, 2, time.Now(), time.Now())
+				},
+				updateFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+					saved = d
+					return nil
+				},
+			}
+			revisionRepo := &mockRevisionRepository{
+				findByNumberFunc: func(ctx context.Context, drawingID uuid.UUID, number int) (*drawing.Revision, error) {
+					return drawing.ReconstituteRevision(uuid.New(), drawingID, number, "Legacy", legacy, 0, "", time.Now()), nil
+				},
+			}
+			service := NewService(mockRepo, revisionRepo, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+
+			var err error
+			if tt.restore {
+				_, err = service.RestoreRevision(context.Background(), id.String(), 1, nil)
+			} else {
+				_, err = service.UpdateDrawing(context.Background(), id.String(), tt.input)
+			}
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				if saved != nil {
+					t.Error("expected the drawing not to be saved")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if saved == nil || !saved.Data().Equal(legacy) {
+				t.Fatal("expected the drawing to be saved with its legacy data unchanged")
+			}
+			if saved.Version() != 3 {
+				t.Errorf("expected version 3, got %d", saved.Version())
+			}
+		})
+	}
+}
+
+func TestRewriteSceneSchemas(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	d1, _ := drawing.NewDrawing("Drawing 1", map[string]interface{}{"elements": []interface{}{}})
+	d2, _ := drawing.NewDrawing("Drawing 2", map[string]interface{}{"elements": []interface{}{}})
+	pending := []*drawing.Drawing{d1, d2}
+
+	rewritten := make(map[uuid.UUID]bool)
+	mockRepo := &mockDrawingRepository{
+		findOutdatedFunc: func(ctx context.Context, limit int) ([]*drawing.Drawing, error) {
+			var remaining []*drawing.Drawing
+			for _, d := range pending {
+				if !rewritten[d.ID()] {
+					remaining = append(remaining, d)
+				}
+			}
+			return remaining, nil
+		},
+		rewriteDataFunc: func(ctx context.Context, d *drawing.Drawing) (bool, error) {
+			rewritten[d.ID()] = true
+			return true, nil
+		},
+	}
+
+	service := NewService(mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+
+	count, err := service.RewriteSceneSchemas(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 rewritten drawings, got %d", count)
+	}
+
+	t.Run("skips drawings saved since they were read", func(t *testing.T) {
+		batches := 0
+		mockRepo := &mockDrawingRepository{
+			findOutdatedFunc: func(ctx context.Context, limit int) ([]*drawing.Drawing, error) {
+				batches++
+				if batches > 1 {
+					// The concurrent save stored the current schema
+					return nil, nil
+				}
+				return []*drawing.Drawing{d1, d2}, nil
+			},
+			rewriteDataFunc: func(ctx context.Context, d *drawing.Drawing) (bool, error) {
+				return d.ID() != d1.ID(), nil
+			},
+		}
+		service := NewService(mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+
+		count, err := service.RewriteSceneSchemas(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if count != 1 {
+			t.Errorf("expected 1 rewritten drawing, got %d", count)
+		}
+	})
+
+	t.Run("stops on repository error", func(t *testing.T) {
+		mockRepo := &mockDrawingRepository{
+			findOutdatedFunc: func(ctx context.Context, limit int) ([]*drawing.Drawing, error) {
+				return []*drawing.Drawing{d1}, nil
+			},
+			rewriteDataFunc: func(ctx context.Context, d *drawing.Drawing) (bool, error) {
+				return false, errors.New("database error")
+			},
+		}
+		service := NewService(mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+
+		if _, err := service.RewriteSceneSchemas(context.Background()); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
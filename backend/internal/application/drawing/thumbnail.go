@@ -0,0 +1,127 @@
+package drawing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// thumbnailQueueSize is how many drawings can wait for their thumbnail to be rendered
+const thumbnailQueueSize = 256
+
+// ThumbnailScheduler queues drawings whose thumbnail must be rendered again
+type ThumbnailScheduler interface {
+	// Schedule queues a thumbnail refresh without waiting for it
+	Schedule(id uuid.UUID)
+}
+
+// ThumbnailWorker renders drawing thumbnails in the background after drawings change
+type ThumbnailWorker struct {
+	repo       drawing.Repository
+	thumbnails drawing.ThumbnailRepository
+	renderer   drawing.Renderer
+	logger     *slog.Logger
+
+	queue   chan uuid.UUID
+	mu      sync.Mutex
+	pending map[uuid.UUID]struct{}
+}
+
+// NewThumbnailWorker creates a new thumbnail worker
+func NewThumbnailWorker(repo drawing.Repository, thumbnails drawing.ThumbnailRepository, renderer drawing.Renderer, logger *slog.Logger) *ThumbnailWorker {
+	return &ThumbnailWorker{
+		repo:       repo,
+		thumbnails: thumbnails,
+		renderer:   renderer,
+		logger:     logger,
+		queue:      make(chan uuid.UUID, thumbnailQueueSize),
+		pending:    make(map[uuid.UUID]struct{}),
+	}
+}
+
+// Schedule queues a thumbnail refresh of a drawing
+// A drawing already waiting is queued once; when the queue is full the refresh is dropped,
+// and the thumbnail is rendered on its next request instead
+func (w *ThumbnailWorker) Schedule(id uuid.UUID) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.pending[id]; ok {
+		return
+	}
+
+	select {
+	case w.queue <- id:
+		w.pending[id] = struct{}{}
+	default:
+		w.logger.Error("thumbnail queue full, dropping refresh", "id", id)
+	}
+}
+
+// Run renders queued thumbnails until the context is cancelled
+func (w *ThumbnailWorker) Run(ctx context.Context) {
+	w.logger.Info("thumbnail worker started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info("thumbnail worker stopped")
+			return
+		case id := <-w.queue:
+			// Unmark first, so a save made while rendering queues another refresh
+			w.mu.Lock()
+			delete(w.pending, id)
+			w.mu.Unlock()
+
+			if _, err := w.Refresh(ctx, id); err != nil && ctx.Err() == nil && !errors.Is(err, drawing.ErrDrawingNotFound) {
+				w.logger.Error("thumbnail refresh failed", "id", id, "error", err)
+			}
+		}
+	}
+}
+
+// Refresh renders the thumbnail of a drawing's current version, unless it is already stored
+func (w *ThumbnailWorker) Refresh(ctx context.Context, id uuid.UUID) (*drawing.Thumbnail, error) {
+	d, err := w.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return w.Thumbnail(ctx, d)
+}
+
+// Thumbnail returns the stored thumbnail of a drawing, rendering and storing it first
+// when it is missing or shows an older version
+func (w *ThumbnailWorker) Thumbnail(ctx context.Context, d *drawing.Drawing) (*drawing.Thumbnail, error) {
+	existing, err := w.thumbnails.FindByDrawingID(ctx, d.ID())
+	if err == nil && existing.IsCurrent(d) {
+		return existing, nil
+	}
+	if err != nil && !errors.Is(err, drawing.ErrThumbnailNotFound) {
+		return nil, fmt.Errorf("failed to get thumbnail: %w", err)
+	}
+
+	scene, err := d.Data().Scene()
+	if err != nil {
+		return nil, err
+	}
+
+	image, err := w.renderer.RenderPNG(ctx, scene, drawing.ThumbnailOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to render thumbnail: %w", err)
+	}
+
+	t := drawing.NewThumbnail(d.ID(), image, d.Version())
+	if err := w.thumbnails.Save(ctx, t); err != nil {
+		return nil, fmt.Errorf("failed to save thumbnail: %w", err)
+	}
+
+	w.logger.Info("thumbnail rendered", "id", d.ID(), "version", d.Version(), "bytes", len(image))
+
+	return t, nil
+}
@@ -0,0 +1,164 @@
+package drawing
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// mockThumbnailRepository is a mock implementation of the thumbnail repository
+type mockThumbnailRepository struct {
+	thumbnail *drawing.Thumbnail
+	saved     int
+}
+
+func (m *mockThumbnailRepository) Save(ctx context.Context, t *drawing.Thumbnail) error {
+	m.thumbnail = t
+	m.saved++
+	return nil
+}
+
+func (m *mockThumbnailRepository) FindByDrawingID(ctx context.Context, id uuid.UUID) (*drawing.Thumbnail, error) {
+	if m.thumbnail == nil {
+		return nil, drawing.ErrThumbnailNotFound
+	}
+	return m.thumbnail, nil
+}
+
+// mockRenderer is a mock implementation of drawing.Renderer
+type mockRenderer struct {
+	rendered int
+	opts     drawing.ExportOptions
+}
+
+func (m *mockRenderer) RenderSVG(ctx context.Context, scene *drawing.Scene, opts drawing.ExportOptions) ([]byte, error) {
+	m.rendered++
+	m.opts = opts
+	return []byte("<svg/>"), nil
+}
+
+func (m *mockRenderer) RenderPNG(ctx context.Context, scene *drawing.Scene, opts drawing.ExportOptions) ([]byte, error) {
+	m.rendered++
+	m.opts = opts
+	return []byte("png"), nil
+}
+
+func (m *mockRenderer) RenderPDF(ctx context.Context, scene *drawing.Scene, opts drawing.ExportOptions) ([]byte, error) {
+	m.rendered++
+	m.opts = opts
+	return []byte("%PDF-"), nil
+}
+
+// recordingScheduler records the drawings it was asked to refresh
+type recordingScheduler struct {
+	scheduled []uuid.UUID
+}
+
+func (s *recordingScheduler) Schedule(id uuid.UUID) {
+	s.scheduled = append(s.scheduled, id)
+}
+
+func TestUpdateDrawingSchedulesThumbnail(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	existing, _ := drawing.NewDrawing("Drawing", map[string]interface{}{"elements": []interface{}{}})
+	updateErr := error(nil)
+	mockRepo := &mockDrawingRepository{
+		findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
+			return existing, nil
+		},
+		updateFunc: func(ctx context.Context, d *drawing.Drawing, rev *drawing.Revision) error {
+			return updateErr
+		},
+	}
+
+	scheduler := &recordingScheduler{}
+	service := NewService(mockRepo, &mockRevisionRepository{}, drawing.RevisionPolicy{}, &mockSlugGenerator{}, logger)
+	service.SetThumbnailScheduler(scheduler)
+
+	if _, err := service.UpdateDrawing(context.Background(), existing.ID().String(), UpdateDrawingInput{Name: "Renamed"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(scheduler.scheduled) != 1 || scheduler.scheduled[0] != existing.ID() {
+		t.Fatalf("expected a thumbnail refresh of %s, got %v", existing.ID(), scheduler.scheduled)
+	}
+
+	// A failed save leaves the thumbnail alone
+	updateErr = errors.New("database error")
+	if _, err := service.UpdateDrawing(context.Background(), existing.ID().String(), UpdateDrawingInput{Name: "Again"}); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(scheduler.scheduled) != 1 {
+		t.Errorf("expected no refresh after a failed save, got %d", len(scheduler.scheduled))
+	}
+}
+
+func TestThumbnailWorkerRefresh(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	d, _ := drawing.NewDrawing("Drawing", map[string]interface{}{"elements": []interface{}{}})
+	mockRepo := &mockDrawingRepository{
+		findByIDFunc: func(ctx context.Context, id uuid.UUID) (*drawing.Drawing, error) {
+			return d, nil
+		},
+	}
+	thumbnails := &mockThumbnailRepository{}
+	renderer := &mockRenderer{}
+	worker := NewThumbnailWorker(mockRepo, thumbnails, renderer, logger)
+
+	thumbnail, err := worker.Refresh(context.Background(), d.ID())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if thumbnail.Version() != d.Version() || thumbnails.saved != 1 {
+		t.Fatalf("expected thumbnail of version %d to be saved, got version %d and %d saves", d.Version(), thumbnail.Version(), thumbnails.saved)
+	}
+	if renderer.opts.MaxDimension != drawing.ThumbnailMaxDimension {
+		t.Errorf("expected thumbnail rendered within %d pixels, got %d", drawing.ThumbnailMaxDimension, renderer.opts.MaxDimension)
+	}
+
+	// The thumbnail of the current version is not rendered again
+	if _, err := worker.Refresh(context.Background(), d.ID()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if renderer.rendered != 1 {
+		t.Errorf("expected current thumbnail to be reused, rendered %d times", renderer.rendered)
+	}
+
+	// A new version is rendered again
+	if err := d.Update("Drawing", d.Data()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := worker.Refresh(context.Background(), d.ID()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if renderer.rendered != 2 || thumbnails.thumbnail.Version() != d.Version() {
+		t.Errorf("expected thumbnail of version %d, got version %d after %d renders", d.Version(), thumbnails.thumbnail.Version(), renderer.rendered)
+	}
+}
+
+func TestThumbnailWorkerSchedule(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	worker := NewThumbnailWorker(&mockDrawingRepository{}, &mockThumbnailRepository{}, &mockRenderer{}, logger)
+
+	id := uuid.New()
+	worker.Schedule(id)
+	worker.Schedule(id)
+	if len(worker.queue) != 1 {
+		t.Errorf("expected a waiting drawing to be queued once, got %d", len(worker.queue))
+	}
+
+	// A full queue drops refreshes instead of blocking the save
+	for i := 0; i < thumbnailQueueSize+10; i++ {
+		worker.Schedule(uuid.New())
+	}
+	if len(worker.queue) != thumbnailQueueSize {
+		t.Errorf("expected queue of %d, got %d", thumbnailQueueSize, len(worker.queue))
+	}
+}
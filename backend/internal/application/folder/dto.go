@@ -0,0 +1,63 @@
+package folder
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/personal-excalidraw/backend/internal/domain/folder"
+)
+
+// CreateFolderInput represents input for creating a folder
+type CreateFolderInput struct {
+	Name string
+
+	// ParentID is the ID of the folder to create the folder in; empty creates a top-level folder
+	ParentID string
+}
+
+// RenameFolderInput represents input for renaming a folder
+type RenameFolderInput struct {
+	Name string
+}
+
+// MoveFolderInput represents input for moving a folder
+type MoveFolderInput struct {
+	// ParentID is the ID of the folder to move the folder into; empty moves it to the top level
+	ParentID string
+}
+
+// MoveDrawingInput represents input for moving a drawing between folders
+type MoveDrawingInput struct {
+	// FolderID is the ID of the folder to move the drawing into; empty takes it out of any folder
+	FolderID string
+}
+
+// FolderOutput represents a folder response
+type FolderOutput struct {
+	ID        uuid.UUID
+	ParentID  *uuid.UUID
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ToOutput converts a domain folder to a FolderOutput DTO
+func ToOutput(f *folder.Folder) *FolderOutput {
+	return &FolderOutput{
+		ID:        f.ID(),
+		ParentID:  f.ParentID(),
+		Name:      f.Name(),
+		CreatedAt: f.CreatedAt(),
+		UpdatedAt: f.UpdatedAt(),
+	}
+}
+
+// ToOutputList converts a list of domain folders to FolderOutput DTOs
+func ToOutputList(folders []*folder.Folder) []*FolderOutput {
+	outputs := make([]*FolderOutput, len(folders))
+	for i, f := range folders {
+		outputs[i] = ToOutput(f)
+	}
+	return outputs
+}
@@ -0,0 +1,255 @@
+package folder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+	"github.com/personal-excalidraw/backend/internal/domain/folder"
+)
+
+// Service handles folder use cases
+type Service struct {
+	repo     folder.Repository
+	drawings drawing.Repository
+	logger   *slog.Logger
+}
+
+// NewService creates a new folder service
+func NewService(repo folder.Repository, drawings drawing.Repository, logger *slog.Logger) *Service {
+	return &Service{
+		repo:     repo,
+		drawings: drawings,
+		logger:   logger,
+	}
+}
+
+// CreateFolder creates a new folder, at the top level or inside another folder
+func (s *Service) CreateFolder(ctx context.Context, input CreateFolderInput) (*FolderOutput, error) {
+	s.logger.Info("creating folder", "name", input.Name, "parentID", input.ParentID)
+
+	parentID, err := parseOptionalFolderID(input.ParentID)
+	if err != nil {
+		s.logger.Error("invalid parent folder ID format", "parentID", input.ParentID, "error", err)
+		return nil, err
+	}
+
+	f, err := folder.NewFolder(input.Name, parentID)
+	if err != nil {
+		s.logger.Error("failed to create folder entity", "error", err)
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, f); err != nil {
+		s.logger.Error("failed to save folder", "id", f.ID(), "error", err)
+		return nil, fmt.Errorf("failed to save folder: %w", err)
+	}
+
+	s.logger.Info("folder created successfully", "id", f.ID())
+
+	return ToOutput(f), nil
+}
+
+// GetFolder retrieves a folder by ID
+func (s *Service) GetFolder(ctx context.Context, id string) (*FolderOutput, error) {
+	s.logger.Info("getting folder", "id", id)
+
+	folderID, err := parseFolderID(id)
+	if err != nil {
+		s.logger.Error("invalid folder ID format", "id", id, "error", err)
+		return nil, err
+	}
+
+	f, err := s.repo.FindByID(ctx, folderID)
+	if err != nil {
+		s.logger.Error("failed to get folder", "id", folderID, "error", err)
+		return nil, err
+	}
+
+	return ToOutput(f), nil
+}
+
+// ListFolders retrieves all folders ordered by name; clients assemble the tree from their parents
+func (s *Service) ListFolders(ctx context.Context) ([]*FolderOutput, error) {
+	s.logger.Info("listing folders")
+
+	folders, err := s.repo.FindAll(ctx)
+	if err != nil {
+		s.logger.Error("failed to list folders", "error", err)
+		return nil, fmt.Errorf("failed to retrieve folders: %w", err)
+	}
+
+	s.logger.Info("folders listed successfully", "count", len(folders))
+
+	return ToOutputList(folders), nil
+}
+
+// RenameFolder changes the name of a folder
+func (s *Service) RenameFolder(ctx context.Context, id string, input RenameFolderInput) (*FolderOutput, error) {
+	s.logger.Info("renaming folder", "id", id, "name", input.Name)
+
+	folderID, err := parseFolderID(id)
+	if err != nil {
+		s.logger.Error("invalid folder ID format", "id", id, "error", err)
+		return nil, err
+	}
+
+	f, err := s.repo.FindByID(ctx, folderID)
+	if err != nil {
+		s.logger.Error("failed to get folder", "id", folderID, "error", err)
+		return nil, err
+	}
+
+	if err := f.Rename(input.Name); err != nil {
+		s.logger.Error("failed to rename folder", "id", folderID, "error", err)
+		return nil, err
+	}
+
+	if err := s.repo.Rename(ctx, f); err != nil {
+		s.logger.Error("failed to save folder", "id", folderID, "error", err)
+		return nil, fmt.Errorf("failed to save folder: %w", err)
+	}
+
+	s.logger.Info("folder renamed successfully", "id", folderID)
+
+	return ToOutput(f), nil
+}
+
+// MoveFolder moves a folder, with everything inside it, into another folder or to the top level
+// A folder cannot be moved into itself or into one of its subfolders
+func (s *Service) MoveFolder(ctx context.Context, id string, input MoveFolderInput) (*FolderOutput, error) {
+	s.logger.Info("moving folder", "id", id, "parentID", input.ParentID)
+
+	folderID, err := parseFolderID(id)
+	if err != nil {
+		s.logger.Error("invalid folder ID format", "id", id, "error", err)
+		return nil, err
+	}
+	parentID, err := parseOptionalFolderID(input.ParentID)
+	if err != nil {
+		s.logger.Error("invalid parent folder ID format", "parentID", input.ParentID, "error", err)
+		return nil, err
+	}
+
+	f, err := s.repo.FindByID(ctx, folderID)
+	if err != nil {
+		s.logger.Error("failed to get folder", "id", folderID, "error", err)
+		return nil, err
+	}
+
+	// The folders above the new parent tell whether the move would close a loop
+	var ancestors []uuid.UUID
+	if parentID != nil {
+		if _, err := s.repo.FindByID(ctx, *parentID); err != nil {
+			s.logger.Error("failed to get parent folder", "parentID", parentID, "error", err)
+			return nil, err
+		}
+		if ancestors, err = s.repo.FindAncestorIDs(ctx, *parentID); err != nil {
+			s.logger.Error("failed to get folder ancestors", "parentID", parentID, "error", err)
+			return nil, fmt.Errorf("failed to get folder ancestors: %w", err)
+		}
+	}
+
+	if err := f.MoveTo(parentID, ancestors); err != nil {
+		s.logger.Info("invalid folder move", "id", folderID, "parentID", parentID, "error", err)
+		return nil, err
+	}
+
+	// The repository checks the move again against the tree as it stands when the folder is saved
+	if err := s.repo.Move(ctx, f); err != nil {
+		if errors.Is(err, folder.ErrFolderCycle) {
+			s.logger.Info("invalid folder move", "id", folderID, "parentID", parentID, "error", err)
+			return nil, err
+		}
+		s.logger.Error("failed to save folder", "id", folderID, "error", err)
+		return nil, fmt.Errorf("failed to save folder: %w", err)
+	}
+
+	s.logger.Info("folder moved successfully", "id", folderID, "parentID", parentID)
+
+	return ToOutput(f), nil
+}
+
+// DeleteFolder deletes a folder, handling its subfolders and drawings according to mode
+// An empty mode only deletes empty folders
+func (s *Service) DeleteFolder(ctx context.Context, id string, mode folder.DeleteMode) error {
+	s.logger.Info("deleting folder", "id", id, "mode", mode)
+
+	folderID, err := parseFolderID(id)
+	if err != nil {
+		s.logger.Error("invalid folder ID format", "id", id, "error", err)
+		return err
+	}
+
+	if mode == "" {
+		mode = folder.DeleteRestrict
+	}
+	if !mode.Valid() {
+		s.logger.Error("invalid folder delete mode", "mode", mode)
+		return folder.ErrInvalidDeleteMode
+	}
+
+	if err := s.repo.Delete(ctx, folderID, mode); err != nil {
+		if errors.Is(err, folder.ErrFolderNotEmpty) {
+			s.logger.Info("folder is not empty", "id", folderID)
+			return err
+		}
+		s.logger.Error("failed to delete folder", "id", folderID, "error", err)
+		return fmt.Errorf("failed to delete folder: %w", err)
+	}
+
+	s.logger.Info("folder deleted successfully", "id", folderID, "mode", mode)
+
+	return nil
+}
+
+// MoveDrawing moves a drawing into a folder, or out of any folder
+// Moving does not change the drawing, so it neither advances its version nor records a revision
+func (s *Service) MoveDrawing(ctx context.Context, drawingID string, input MoveDrawingInput) error {
+	s.logger.Info("moving drawing", "id", drawingID, "folderID", input.FolderID)
+
+	id, err := uuid.Parse(drawingID)
+	if err != nil {
+		s.logger.Error("invalid drawing ID format", "id", drawingID, "error", err)
+		return fmt.Errorf("invalid drawing ID: %w", err)
+	}
+	folderID, err := parseOptionalFolderID(input.FolderID)
+	if err != nil {
+		s.logger.Error("invalid folder ID format", "folderID", input.FolderID, "error", err)
+		return err
+	}
+
+	if err := s.drawings.MoveToFolder(ctx, id, folderID); err != nil {
+		s.logger.Error("failed to move drawing", "id", id, "folderID", folderID, "error", err)
+		return fmt.Errorf("failed to move drawing: %w", err)
+	}
+
+	s.logger.Info("drawing moved successfully", "id", id, "folderID", folderID)
+
+	return nil
+}
+
+// parseFolderID parses the ID of a folder
+func parseFolderID(id string) (uuid.UUID, error) {
+	folderID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid folder ID: %w", err)
+	}
+	return folderID, nil
+}
+
+// parseOptionalFolderID parses the ID of a folder, where empty means no folder
+func parseOptionalFolderID(id string) (*uuid.UUID, error) {
+	if id == "" {
+		return nil, nil
+	}
+	folderID, err := parseFolderID(id)
+	if err != nil {
+		return nil, err
+	}
+	return &folderID, nil
+}
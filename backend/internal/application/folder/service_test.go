@@ -0,0 +1,447 @@
+package folder
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+	"github.com/personal-excalidraw/backend/internal/domain/folder"
+)
+
+// mockFolderRepository is a mock implementation of the folder repository
+type mockFolderRepository struct {
+	createFunc          func(ctx context.Context, f *folder.Folder) error
+	findByIDFunc        func(ctx context.Context, id uuid.UUID) (*folder.Folder, error)
+	findAllFunc         func(ctx context.Context) ([]*folder.Folder, error)
+	findAncestorIDsFunc func(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error)
+	renameFunc          func(ctx context.Context, f *folder.Folder) error
+	moveFunc            func(ctx context.Context, f *folder.Folder) error
+	deleteFunc          func(ctx context.Context, id uuid.UUID, mode folder.DeleteMode) error
+}
+
+func (m *mockFolderRepository) Create(ctx context.Context, f *folder.Folder) error {
+	if m.createFunc != nil {
+		return m.createFunc(ctx, f)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *mockFolderRepository) FindByID(ctx context.Context, id uuid.UUID) (*folder.Folder, error) {
+	if m.findByIDFunc != nil {
+		return m.findByIDFunc(ctx, id)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockFolderRepository) FindAll(ctx context.Context) ([]*folder.Folder, error) {
+	if m.findAllFunc != nil {
+		return m.findAllFunc(ctx)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockFolderRepository) FindAncestorIDs(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error) {
+	if m.findAncestorIDsFunc != nil {
+		return m.findAncestorIDsFunc(ctx, id)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockFolderRepository) Rename(ctx context.Context, f *folder.Folder) error {
+	if m.renameFunc != nil {
+		return m.renameFunc(ctx, f)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *mockFolderRepository) Move(ctx context.Context, f *folder.Folder) error {
+	if m.moveFunc != nil {
+		return m.moveFunc(ctx, f)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *mockFolderRepository) Delete(ctx context.Context, id uuid.UUID, mode folder.DeleteMode) error {
+	if m.deleteFunc != nil {
+		return m.deleteFunc(ctx, id, mode)
+	}
+	return errors.New("not implemented")
+}
+
+// mockDrawingRepository is a mock implementation of the drawing repository
+// Only moving drawings is used by the folder service; the other methods are left unimplemented
+type mockDrawingRepository struct {
+	drawing.Repository
+	moveToFolderFunc func(ctx context.Context, id uuid.UUID, folderID *uuid.UUID) error
+}
+
+func (m *mockDrawingRepository) MoveToFolder(ctx context.Context, id uuid.UUID, folderID *uuid.UUID) error {
+	if m.moveToFolderFunc != nil {
+		return m.moveToFolderFunc(ctx, id, folderID)
+	}
+	return errors.New("not implemented")
+}
+
+// existingFolder returns a mock lookup finding a folder with the given ID and parent
+func existingFolder(parentID *uuid.UUID) func(ctx context.Context, id uuid.UUID) (*folder.Folder, error) {
+	return func(ctx context.Context, id uuid.UUID) (*folder.Folder, error) {
+		return folder.Reconstitute(id, parentID, "Folder", time.Now(), time.Now()), nil
+	}
+}
+
+func TestCreateFolder(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	parentID := uuid.New()
+
+	tests := []struct {
+		name           string
+		input          CreateFolderInput
+		mockRepo       *mockFolderRepository
+		expectedParent *uuid.UUID
+		expectedErr    error
+		expectError    bool
+	}{
+		{
+			name:  "top-level folder",
+			input: CreateFolderInput{Name: "  Projects  "},
+			mockRepo: &mockFolderRepository{
+				createFunc: func(ctx context.Context, f *folder.Folder) error { return nil },
+			},
+		},
+		{
+			name:  "subfolder",
+			input: CreateFolderInput{Name: "Projects", ParentID: parentID.String()},
+			mockRepo: &mockFolderRepository{
+				createFunc: func(ctx context.Context, f *folder.Folder) error { return nil },
+			},
+			expectedParent: &parentID,
+		},
+		{
+			name:        "empty name",
+			input:       CreateFolderInput{Name: "   "},
+			mockRepo:    &mockFolderRepository{},
+			expectedErr: folder.ErrEmptyName,
+			expectError: true,
+		},
+		{
+			name:        "invalid parent ID",
+			input:       CreateFolderInput{Name: "Projects", ParentID: "inbox"},
+			mockRepo:    &mockFolderRepository{},
+			expectError: true,
+		},
+		{
+			name:  "sibling with the same name",
+			input: CreateFolderInput{Name: "Projects"},
+			mockRepo: &mockFolderRepository{
+				createFunc: func(ctx context.Context, f *folder.Folder) error { return folder.ErrNameConflict },
+			},
+			expectedErr: folder.ErrNameConflict,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewService(tt.mockRepo, &mockDrawingRepository{}, logger)
+
+			output, err := service.CreateFolder(context.Background(), tt.input)
+
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("expected error but got none")
+				}
+				if tt.expectedErr != nil && !errors.Is(err, tt.expectedErr) {
+					t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if output.Name != "Projects" {
+				t.Errorf("expected name %q, got %q", "Projects", output.Name)
+			}
+			if (output.ParentID == nil) != (tt.expectedParent == nil) ||
+				(output.ParentID != nil && *output.ParentID != *tt.expectedParent) {
+				t.Errorf("expected parent %v, got %v", tt.expectedParent, output.ParentID)
+			}
+		})
+	}
+}
+
+func TestMoveFolder(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	folderID := uuid.New()
+	parentID := uuid.New()
+
+	tests := []struct {
+		name        string
+		parentID    string
+		ancestors   []uuid.UUID
+		findParent  error
+		moveErr     error
+		expectSaved bool
+		expectedErr error
+		expectError bool
+	}{
+		{
+			name:        "into another folder",
+			parentID:    parentID.String(),
+			ancestors:   []uuid.UUID{uuid.New()},
+			expectSaved: true,
+		},
+		{
+			name:        "to the top level",
+			expectSaved: true,
+		},
+		{
+			name:        "into itself",
+			parentID:    folderID.String(),
+			expectedErr: folder.ErrFolderCycle,
+			expectError: true,
+		},
+		{
+			name:        "into one of its subfolders",
+			parentID:    parentID.String(),
+			ancestors:   []uuid.UUID{uuid.New(), folderID},
+			expectedErr: folder.ErrFolderCycle,
+			expectError: true,
+		},
+		{
+			name:        "into a subfolder moved under it since the check",
+			parentID:    parentID.String(),
+			ancestors:   []uuid.UUID{uuid.New()},
+			moveErr:     folder.ErrFolderCycle,
+			expectedErr: folder.ErrFolderCycle,
+			expectError: true,
+		},
+		{
+			name:        "missing parent",
+			parentID:    parentID.String(),
+			findParent:  folder.ErrFolderNotFound,
+			expectedErr: folder.ErrFolderNotFound,
+			expectError: true,
+		},
+		{
+			name:        "invalid parent ID",
+			parentID:    "inbox",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved := false
+			repo := &mockFolderRepository{
+				findByIDFunc: func(ctx context.Context, id uuid.UUID) (*folder.Folder, error) {
+					if id == parentID && tt.findParent != nil {
+						return nil, tt.findParent
+					}
+					return existingFolder(nil)(ctx, id)
+				},
+				findAncestorIDsFunc: func(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error) {
+					return tt.ancestors, nil
+				},
+				moveFunc: func(ctx context.Context, f *folder.Folder) error {
+					if tt.moveErr != nil {
+						return tt.moveErr
+					}
+					saved = true
+					return nil
+				},
+			}
+			service := NewService(repo, &mockDrawingRepository{}, logger)
+
+			output, err := service.MoveFolder(context.Background(), folderID.String(), MoveFolderInput{ParentID: tt.parentID})
+
+			if saved != tt.expectSaved {
+				t.Errorf("expected saved %v, got %v", tt.expectSaved, saved)
+			}
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("expected error but got none")
+				}
+				if tt.expectedErr != nil && !errors.Is(err, tt.expectedErr) {
+					t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.parentID == "" && output.ParentID != nil {
+				t.Errorf("expected a top-level folder, got parent %v", output.ParentID)
+			}
+			if tt.parentID != "" && (output.ParentID == nil || output.ParentID.String() != tt.parentID) {
+				t.Errorf("expected parent %s, got %v", tt.parentID, output.ParentID)
+			}
+		})
+	}
+}
+
+func TestDeleteFolder(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	tests := []struct {
+		name         string
+		mode         folder.DeleteMode
+		deleteErr    error
+		expectedMode folder.DeleteMode
+		expectedErr  error
+		expectError  bool
+	}{
+		{
+			name:         "defaults to restrict",
+			expectedMode: folder.DeleteRestrict,
+		},
+		{
+			name:         "reparent",
+			mode:         folder.DeleteReparent,
+			expectedMode: folder.DeleteReparent,
+		},
+		{
+			name:         "cascade",
+			mode:         folder.DeleteCascade,
+			expectedMode: folder.DeleteCascade,
+		},
+		{
+			name:        "unknown mode",
+			mode:        "archive",
+			expectedErr: folder.ErrInvalidDeleteMode,
+			expectError: true,
+		},
+		{
+			name:         "non-empty folder",
+			deleteErr:    folder.ErrFolderNotEmpty,
+			expectedMode: folder.DeleteRestrict,
+			expectedErr:  folder.ErrFolderNotEmpty,
+			expectError:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deletedMode folder.DeleteMode
+			repo := &mockFolderRepository{
+				deleteFunc: func(ctx context.Context, id uuid.UUID, mode folder.DeleteMode) error {
+					deletedMode = mode
+					return tt.deleteErr
+				},
+			}
+			service := NewService(repo, &mockDrawingRepository{}, logger)
+
+			err := service.DeleteFolder(context.Background(), uuid.New().String(), tt.mode)
+
+			if deletedMode != tt.expectedMode {
+				t.Errorf("expected delete mode %q, got %q", tt.expectedMode, deletedMode)
+			}
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("expected error but got none")
+				}
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestMoveDrawing(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	drawingID := uuid.New()
+	folderID := uuid.New()
+
+	tests := []struct {
+		name           string
+		drawingID      string
+		folderID       string
+		moveErr        error
+		expectedFolder *uuid.UUID
+		expectedErr    error
+		expectError    bool
+	}{
+		{
+			name:           "into a folder",
+			drawingID:      drawingID.String(),
+			folderID:       folderID.String(),
+			expectedFolder: &folderID,
+		},
+		{
+			name:      "out of any folder",
+			drawingID: drawingID.String(),
+		},
+		{
+			name:        "missing folder",
+			drawingID:   drawingID.String(),
+			folderID:    folderID.String(),
+			moveErr:     folder.ErrFolderNotFound,
+			expectedErr: folder.ErrFolderNotFound,
+			expectError: true,
+		},
+		{
+			name:        "missing drawing",
+			drawingID:   drawingID.String(),
+			moveErr:     drawing.ErrDrawingNotFound,
+			expectedErr: drawing.ErrDrawingNotFound,
+			expectError: true,
+		},
+		{
+			name:        "invalid drawing ID",
+			drawingID:   "invalid-uuid",
+			expectError: true,
+		},
+		{
+			name:        "invalid folder ID",
+			drawingID:   drawingID.String(),
+			folderID:    "inbox",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var movedTo *uuid.UUID
+			drawings := &mockDrawingRepository{
+				moveToFolderFunc: func(ctx context.Context, id uuid.UUID, folderID *uuid.UUID) error {
+					if id != drawingID {
+						t.Errorf("expected drawing %s, got %s", drawingID, id)
+					}
+					movedTo = folderID
+					return tt.moveErr
+				},
+			}
+			service := NewService(&mockFolderRepository{}, drawings, logger)
+
+			err := service.MoveDrawing(context.Background(), tt.drawingID, MoveDrawingInput{FolderID: tt.folderID})
+
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("expected error but got none")
+				}
+				if tt.expectedErr != nil && !errors.Is(err, tt.expectedErr) {
+					t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if (movedTo == nil) != (tt.expectedFolder == nil) || (movedTo != nil && *movedTo != *tt.expectedFolder) {
+				t.Errorf("expected folder %v, got %v", tt.expectedFolder, movedTo)
+			}
+		})
+	}
+}
@@ -0,0 +1,533 @@
+package diagram
+
+import (
+	"hash/fnv"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// Default look of generated elements, matching new elements in the Excalidraw editor
+const (
+	defaultStrokeColor     = "#1e1e1e"
+	defaultBackgroundColor = "transparent"
+	defaultStrokeWidth     = 2
+
+	// fontFamily is Excalifont, the editor's default hand-drawn font
+	fontFamily = 5
+	lineHeight = 1.25
+
+	nodeFontSize  = 20
+	edgeFontSize  = 16
+	groupFontSize = 16
+
+	// charWidth estimates the average advance of a character, relative to the font size
+	charWidth = 0.6
+
+	// textPadding is the space between a node's label and its outline
+	textPadding = 20
+
+	minNodeWidth  = 120
+	minNodeHeight = 60
+
+	// boundTextPadding is the inset of the title in a group box, as the editor places bound text
+	boundTextPadding = 5
+
+	// framePadding is the space between a frame and its page, and frameSpacing between frames
+	framePadding = 40
+	frameSpacing = 100
+)
+
+// Element ID prefixes, so IDs stay stable when a diagram is converted again
+const (
+	nodeIDPrefix  = "node:"
+	edgeIDPrefix  = "edge:"
+	groupIDPrefix = "group:"
+	frameIDPrefix = "frame:"
+	textIDSuffix  = ":text"
+)
+
+// NodeElementID returns the ID of the shape element a node is drawn as
+func NodeElementID(id string) string {
+	return nodeIDPrefix + id
+}
+
+// GroupElementID returns the ID of the box element a group is drawn as
+func GroupElementID(id string) string {
+	return groupIDPrefix + id
+}
+
+// TextElementID returns the ID of the text element bound to the element with the given ID
+func TextElementID(containerID string) string {
+	return containerID + textIDSuffix
+}
+
+// SizeNodes sizes the nodes of g that have no size to fit their label in their shape
+func SizeNodes(g *Graph) {
+	for _, n := range g.Nodes {
+		if n.Width > 0 && n.Height > 0 {
+			continue
+		}
+
+		w, h := measureText(n.Label, nodeFontSize)
+		w, h = w+2*textPadding, h+2*textPadding
+
+		// Ellipses and diamonds only fit a box of half their area, or a quarter, around the label
+		switch n.Shape {
+		case ShapeEllipse:
+			w, h = w*math.Sqrt2, h*math.Sqrt2
+		case ShapeDiamond:
+			w, h = w*2, h*2
+		}
+
+		n.Width = math.Ceil(math.Max(w, minNodeWidth))
+		n.Height = math.Ceil(math.Max(h, minNodeHeight))
+	}
+}
+
+// measureText estimates the size of text set at fontSize, one line per newline
+func measureText(text string, fontSize float64) (width, height float64) {
+	lines := strings.Split(text, "\n")
+	longest := 0
+	for _, line := range lines {
+		longest = max(longest, utf8.RuneCountInString(line))
+	}
+	return math.Ceil(float64(longest) * fontSize * charWidth), float64(len(lines)) * fontSize * lineHeight
+}
+
+// Build converts a laid out graph into Excalidraw drawing data: groups become boxes titled with
+// their label, nodes shapes with their label bound to them, and edges arrows bound to their nodes
+func Build(g *Graph) drawing.DrawingData {
+	b := newBuilder()
+	b.graph(g)
+	return b.data()
+}
+
+// Page is a positioned graph drawn in its own frame by BuildFrames
+type Page struct {
+	Name  string
+	Graph *Graph
+}
+
+// BuildFrames converts several positioned graphs into a single scene, each moved into a frame
+// named after its page, with the frames side by side from left to right
+func BuildFrames(pages []*Page) drawing.DrawingData {
+	b := newBuilder()
+	x := 0.0
+	for i, page := range pages {
+		minX, minY, maxX, maxY, ok := bounds(page.Graph)
+		if !ok {
+			minX, minY, maxX, maxY = 0, 0, minNodeWidth, minNodeHeight
+		}
+		translate(page.Graph, x+framePadding-minX, framePadding-minY)
+
+		name := page.Name
+		if name == "" {
+			name = "Page " + strconv.Itoa(i+1)
+		}
+		width, height := maxX-minX+2*framePadding, maxY-minY+2*framePadding
+		frame := b.add(b.uniqueID(frameIDPrefix+strconv.Itoa(i+1)), "frame", x, 0, width, height, map[string]interface{}{"name": name})
+
+		b.frameID = frame["id"]
+		b.graph(page.Graph)
+		b.frameID = nil
+		x += width + frameSpacing
+	}
+	return b.data()
+}
+
+// bounds returns the box around the nodes, group boxes and edge routes of g
+func bounds(g *Graph) (minX, minY, maxX, maxY float64, ok bool) {
+	minX, minY = math.Inf(1), math.Inf(1)
+	maxX, maxY = math.Inf(-1), math.Inf(-1)
+	extend := func(x, y, w, h float64) {
+		minX, minY = math.Min(minX, x), math.Min(minY, y)
+		maxX, maxY = math.Max(maxX, x+w), math.Max(maxY, y+h)
+	}
+	for _, n := range g.Nodes {
+		extend(n.X, n.Y, n.Width, n.Height)
+	}
+	for _, gr := range g.Groups {
+		if gr.Width > 0 {
+			extend(gr.X, gr.Y, gr.Width, gr.Height)
+		}
+	}
+	for _, e := range g.Edges {
+		for _, p := range e.Points {
+			extend(p.X, p.Y, 0, 0)
+		}
+	}
+	return minX, minY, maxX, maxY, !math.IsInf(minX, 1)
+}
+
+// translate moves every node, group box and edge route of g by (dx, dy)
+func translate(g *Graph, dx, dy float64) {
+	for _, n := range g.Nodes {
+		n.X, n.Y = n.X+dx, n.Y+dy
+	}
+	for _, gr := range g.Groups {
+		gr.X, gr.Y = gr.X+dx, gr.Y+dy
+	}
+	for _, e := range g.Edges {
+		for i := range e.Points {
+			e.Points[i].X += dx
+			e.Points[i].Y += dy
+		}
+	}
+}
+
+// newBuilder returns a builder for a new scene
+func newBuilder() *builder {
+	return &builder{updated: time.Now().UnixMilli(), ids: make(map[string]bool)}
+}
+
+// graph adds the elements of a laid out graph
+func (b *builder) graph(g *Graph) {
+	// Outer groups first, so nested groups and nodes are drawn over them
+	depth := make(map[string]int, len(g.Groups))
+	for _, gr := range g.Groups {
+		depth[gr.ID] = len(g.groupPath(gr.ID))
+	}
+
+	// Edges may connect to group boxes as well as to nodes
+	shapes := make(map[string]map[string]interface{}, len(g.Nodes)+len(g.Groups))
+	for d := 1; d <= len(g.Groups); d++ {
+		for _, gr := range g.Groups {
+			if depth[gr.ID] == d && gr.Width > 0 {
+				shapes[gr.ID] = b.group(gr)
+			}
+		}
+	}
+	for _, n := range g.Nodes {
+		shapes[n.ID] = b.node(n)
+	}
+
+	for _, e := range g.Edges {
+		if len(e.Points) < 2 {
+			continue
+		}
+		b.edge(e, shapes[e.From], shapes[e.To])
+	}
+}
+
+// data returns the scene of the elements added so far
+func (b *builder) data() drawing.DrawingData {
+	return drawing.DrawingData{
+		"elements": b.elements,
+		"appState": map[string]interface{}{"viewBackgroundColor": "#ffffff", "gridSize": nil},
+		"files":    map[string]interface{}{},
+	}
+}
+
+// builder accumulates the elements of a scene
+type builder struct {
+	elements []interface{}
+	ids      map[string]bool
+	updated  int64
+
+	// frameID is the frame new elements are added to; nil for none
+	frameID interface{}
+}
+
+// uniqueID returns id, suffixed if an element already uses it
+func (b *builder) uniqueID(id string) string {
+	unique := id
+	for i := 2; b.ids[unique]; i++ {
+		unique = id + "~" + strconv.Itoa(i)
+	}
+	b.ids[unique] = true
+	return unique
+}
+
+// add appends a new element with the editor's default properties, overridden by props
+func (b *builder) add(id, kind string, x, y, width, height float64, props map[string]interface{}) map[string]interface{} {
+	el := map[string]interface{}{
+		"id":              id,
+		"type":            kind,
+		"x":               x,
+		"y":               y,
+		"width":           width,
+		"height":          height,
+		"angle":           0,
+		"strokeColor":     defaultStrokeColor,
+		"backgroundColor": defaultBackgroundColor,
+		"fillStyle":       "solid",
+		"strokeWidth":     defaultStrokeWidth,
+		"strokeStyle":     "solid",
+		"roughness":       1,
+		"opacity":         100,
+		"groupIds":        []interface{}{},
+		"frameId":         b.frameID,
+		"roundness":       nil,
+		"seed":            seed(id, "seed"),
+		"version":         1,
+		"versionNonce":    seed(id, "nonce"),
+		"isDeleted":       false,
+		"boundElements":   []interface{}{},
+		"updated":         b.updated,
+		"link":            nil,
+		"locked":          false,
+	}
+	for k, v := range props {
+		el[k] = v
+	}
+	b.elements = append(b.elements, el)
+	return el
+}
+
+// text adds a text element bound to container, centered in it unless props align it to the top
+// Labels of shapes are wrapped to the width of the shape, as the editor does
+func (b *builder) text(container map[string]interface{}, label string, fontSize float64, style Style, props map[string]interface{}) {
+	id := b.uniqueID(TextElementID(container["id"].(string)))
+	x, y := container["x"].(float64), container["y"].(float64)
+	cw, ch := container["width"].(float64), container["height"].(float64)
+	if style.FontSize > 0 {
+		fontSize = style.FontSize
+	}
+
+	text := label
+	if container["type"] != "arrow" {
+		text = wrapText(label, cw-2*boundTextPadding, fontSize)
+	}
+	w, h := measureText(text, fontSize)
+
+	el := map[string]interface{}{
+		"strokeColor":   valueOr(style.TextColor, defaultStrokeColor),
+		"text":          text,
+		"originalText":  label,
+		"fontSize":      fontSize,
+		"fontFamily":    fontFamily,
+		"textAlign":     "center",
+		"verticalAlign": "middle",
+		"containerId":   container["id"],
+		"lineHeight":    lineHeight,
+		"autoResize":    true,
+	}
+	for k, v := range props {
+		el[k] = v
+	}
+	if el["verticalAlign"] == "top" {
+		b.add(id, "text", x+boundTextPadding, y+boundTextPadding, w, h, el)
+	} else {
+		b.add(id, "text", x+(cw-w)/2, y+(ch-h)/2, w, h, el)
+	}
+	bind(container, id, "text")
+}
+
+// group adds the box of a group, with its title in the top left corner
+func (b *builder) group(gr *Group) map[string]interface{} {
+	id := b.uniqueID(GroupElementID(gr.ID))
+	box := b.add(id, "rectangle", gr.X, gr.Y, gr.Width, gr.Height, styleProps(gr.Style, map[string]interface{}{
+		"strokeStyle": "dashed",
+		"strokeWidth": 1,
+		"roundness":   map[string]interface{}{"type": 3},
+	}))
+	if gr.Label != "" {
+		b.text(box, gr.Label, groupFontSize, gr.Style, map[string]interface{}{"textAlign": "left", "verticalAlign": "top"})
+	}
+	return box
+}
+
+// node adds the shape of a node and its label
+func (b *builder) node(n *Node) map[string]interface{} {
+	id := b.uniqueID(NodeElementID(n.ID))
+	kind := string(n.Shape)
+	props := map[string]interface{}{}
+	switch n.Shape {
+	case ShapeRounded:
+		kind = "rectangle"
+		props["roundness"] = map[string]interface{}{"type": 3}
+	case ShapeDiamond, ShapeEllipse:
+		props["roundness"] = map[string]interface{}{"type": 2}
+	case ShapeText:
+		return b.freeText(id, n)
+	default:
+		kind = "rectangle"
+	}
+
+	shape := b.add(id, kind, n.X, n.Y, n.Width, n.Height, styleProps(n.Style, props))
+	if n.Label != "" {
+		b.text(shape, n.Label, nodeFontSize, n.Style, nil)
+	}
+	return shape
+}
+
+// freeText adds a node drawn as text alone, centered in the node's box
+func (b *builder) freeText(id string, n *Node) map[string]interface{} {
+	fontSize := float64(nodeFontSize)
+	if n.Style.FontSize > 0 {
+		fontSize = n.Style.FontSize
+	}
+	text := wrapText(n.Label, n.Width, fontSize)
+	w, h := measureText(text, fontSize)
+
+	return b.add(id, "text", n.X+(n.Width-w)/2, n.Y+(n.Height-h)/2, w, h, map[string]interface{}{
+		"strokeColor":   valueOr(n.Style.TextColor, valueOr(n.Style.StrokeColor, defaultStrokeColor)),
+		"text":          text,
+		"originalText":  n.Label,
+		"fontSize":      fontSize,
+		"fontFamily":    fontFamily,
+		"textAlign":     "center",
+		"verticalAlign": "middle",
+		"containerId":   nil,
+		"lineHeight":    lineHeight,
+		"autoResize":    true,
+	})
+}
+
+// edge adds the arrow of an edge, bound to the shapes of its ends if it has them, and its label
+func (b *builder) edge(e *Edge, from, to map[string]interface{}) {
+	id := edgeIDPrefix + e.From + ">" + e.To
+	if e.ID != "" {
+		id = edgeIDPrefix + e.ID
+	}
+	id = b.uniqueID(id)
+
+	origin := e.Points[0]
+	points := make([]interface{}, len(e.Points))
+	minX, minY, maxX, maxY := 0.0, 0.0, 0.0, 0.0
+	for i, p := range e.Points {
+		dx, dy := p.X-origin.X, p.Y-origin.Y
+		points[i] = []interface{}{dx, dy}
+		minX, minY = math.Min(minX, dx), math.Min(minY, dy)
+		maxX, maxY = math.Max(maxX, dx), math.Max(maxY, dy)
+	}
+
+	props := map[string]interface{}{
+		"points":             points,
+		"lastCommittedPoint": nil,
+		"startBinding":       binding(from),
+		"endBinding":         binding(to),
+		"startArrowhead":     arrowhead(e.StartArrowhead),
+		"endArrowhead":       arrowhead(e.EndArrowhead),
+		"elbowed":            false,
+	}
+	if e.Curved && len(e.Points) > 2 {
+		props["roundness"] = map[string]interface{}{"type": 2}
+	}
+
+	arrow := b.add(id, "arrow", origin.X, origin.Y, maxX-minX, maxY-minY, styleProps(e.Style, props))
+	if from != nil {
+		bind(from, id, "arrow")
+	}
+	if to != nil && (from == nil || to["id"] != from["id"]) {
+		bind(to, id, "arrow")
+	}
+
+	if e.Label != "" {
+		// The editor centers arrow labels on the middle of the route
+		mid := midpoint(e.Points)
+		w, h := measureText(e.Label, edgeFontSize)
+		b.text(arrow, e.Label, edgeFontSize, e.Style, map[string]interface{}{
+			"x": mid.X - w/2,
+			"y": mid.Y - h/2,
+		})
+	}
+}
+
+// styleProps applies a style over the properties of an element
+func styleProps(s Style, props map[string]interface{}) map[string]interface{} {
+	if s.StrokeColor != "" {
+		props["strokeColor"] = s.StrokeColor
+	}
+	if s.BackgroundColor != "" {
+		props["backgroundColor"] = s.BackgroundColor
+	}
+	if s.StrokeWidth > 0 {
+		props["strokeWidth"] = s.StrokeWidth
+	}
+	if s.StrokeStyle != "" {
+		props["strokeStyle"] = s.StrokeStyle
+	}
+	return props
+}
+
+// bind records on container that the element id of the given type is bound to it
+func bind(container map[string]interface{}, id, kind string) {
+	bound := container["boundElements"].([]interface{})
+	container["boundElements"] = append(bound, map[string]interface{}{"id": id, "type": kind})
+}
+
+// binding returns the binding of an arrow end to an element, null for an unbound end
+func binding(el map[string]interface{}) interface{} {
+	if el == nil {
+		return nil
+	}
+	return map[string]interface{}{"elementId": el["id"], "focus": 0, "gap": BindingGap}
+}
+
+// arrowhead returns the element value of an arrowhead, null for none
+func arrowhead(a Arrowhead) interface{} {
+	if a == ArrowheadNone {
+		return nil
+	}
+	return string(a)
+}
+
+// wrapText breaks the lines of text at spaces so they fit within width at fontSize
+func wrapText(text string, width, fontSize float64) string {
+	limit := int(width / (fontSize * charWidth))
+	if limit < 1 {
+		return text
+	}
+
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		words := strings.Fields(line)
+		if utf8.RuneCountInString(line) <= limit || len(words) < 2 {
+			lines = append(lines, line)
+			continue
+		}
+		current := words[0]
+		for _, word := range words[1:] {
+			if utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) > limit {
+				lines = append(lines, current)
+				current = word
+				continue
+			}
+			current += " " + word
+		}
+		lines = append(lines, current)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// midpoint returns the point halfway along a route
+func midpoint(points []Point) Point {
+	total := 0.0
+	for i := 1; i < len(points); i++ {
+		total += math.Hypot(points[i].X-points[i-1].X, points[i].Y-points[i-1].Y)
+	}
+
+	remaining := total / 2
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		length := math.Hypot(b.X-a.X, b.Y-a.Y)
+		if length >= remaining && length > 0 {
+			t := remaining / length
+			return Point{a.X + (b.X-a.X)*t, a.Y + (b.Y-a.Y)*t}
+		}
+		remaining -= length
+	}
+	return points[len(points)-1]
+}
+
+// seed derives a stable random seed for an element from its ID, so rebuilding a diagram draws it the same way
+func seed(id, salt string) int64 {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	h.Write([]byte(salt))
+	return int64(h.Sum32()&math.MaxInt32) + 1
+}
+
+// valueOr returns s, or fallback if s is empty
+func valueOr(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
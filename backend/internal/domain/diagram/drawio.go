@@ -0,0 +1,751 @@
+package diagram
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DrawioFileExtension is the file name extension of draw.io (diagrams.net) files
+const DrawioFileExtension = ".drawio"
+
+// maxDrawioPages limits the pages of a file
+const maxDrawioPages = 100
+
+// maxDrawioBytes limits the size of all compressed pages of a file together once inflated
+const maxDrawioBytes = 64 << 20
+
+// maxDrawioPageCells limits the cells of a page; pages keep their positions, so they are not held
+// to the layout limits
+const maxDrawioPageCells = 7000
+
+// maxDrawioCells limits the cells of all pages of a file together
+const maxDrawioCells = 4 * maxDrawioPageCells
+
+// Defaults of draw.io cells
+const (
+	drawioFontSize = 12
+	drawioFill     = "#ffffff"
+	drawioStroke   = "#000000"
+)
+
+var (
+	// drawioBlockTag matches the HTML tags of a label that end a line
+	drawioBlockTag = regexp.MustCompile(`(?i)<br\s*/?>|</div>|</p>|</li>`)
+
+	// drawioTag matches any other HTML tag of a label
+	drawioTag = regexp.MustCompile(`<[^>]*>`)
+)
+
+// IsDrawio reports whether content looks like a draw.io file: an mxfile document, a bare
+// mxGraphModel, or an SVG image exported with the diagram embedded
+func IsDrawio(content []byte) bool {
+	head := content[:min(len(content), 4096)]
+	return bytes.Contains(head, []byte("<mxfile")) ||
+		bytes.Contains(head, []byte("<mxGraphModel")) ||
+		bytes.Contains(head, []byte("&lt;mxfile"))
+}
+
+// ConvertDrawio converts each page of a draw.io file into a separate scene, titled with the page name
+func ConvertDrawio(content []byte) ([]*Result, error) {
+	pages, err := ParseDrawio(content)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*Result, len(pages))
+	for i, page := range pages {
+		results[i] = &Result{Title: page.Name, Data: Build(page.Graph)}
+	}
+	return results, nil
+}
+
+// ConvertDrawioFrames converts every page of a draw.io file into a single scene, one frame per page
+func ConvertDrawioFrames(content []byte) (*Result, error) {
+	pages, err := ParseDrawio(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var title string
+	if len(pages) == 1 {
+		title = pages[0].Name
+	}
+	return &Result{Title: title, Data: BuildFrames(pages)}, nil
+}
+
+// mxElement is an element of draw.io XML: a cell or one of its descendants
+type mxElement struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr   `xml:",any,attr"`
+	Text     string       `xml:",chardata"`
+	Children []*mxElement `xml:",any"`
+}
+
+// attr returns the value of the named attribute, or empty
+func (e *mxElement) attr(name string) string {
+	return xmlAttr(e.Attrs, name)
+}
+
+// child returns the first child element with the given name, or nil
+func (e *mxElement) child(name string) *mxElement {
+	for _, c := range e.Children {
+		if c.XMLName.Local == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// number returns the named attribute as a number, or 0
+func (e *mxElement) number(name string) float64 {
+	v, err := strconv.ParseFloat(e.attr(name), 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0
+	}
+	return v
+}
+
+// ParseDrawio reads the pages of a draw.io file into positioned graphs
+// Pages may be stored as plain XML or compressed, as draw.io does by default
+func ParseDrawio(content []byte) ([]*Page, error) {
+	budget := &drawioBudget{pages: maxDrawioPages, bytes: maxDrawioBytes, cells: maxDrawioCells}
+	return budget.parseFile(content, true)
+}
+
+// drawioBudget is what is left of the limits shared by the pages of a file
+type drawioBudget struct {
+	pages, bytes, cells int
+}
+
+// parseFile reads the pages of a draw.io document, or of the one embedded in an SVG image
+func (b *drawioBudget) parseFile(content []byte, allowSVG bool) ([]*Page, error) {
+	dec := xml.NewDecoder(bytes.NewReader(content))
+	root, err := drawioRoot(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	var pages []*Page
+	switch root.Name.Local {
+	case "svg":
+		// SVG exports carry the file in their content attribute
+		embedded := xmlAttr(root.Attr, "content")
+		if !allowSVG || embedded == "" {
+			return nil, fmt.Errorf("%w: SVG image has no embedded draw.io diagram", ErrInvalidDiagram)
+		}
+		return b.parseFile([]byte(embedded), false)
+	case "mxGraphModel":
+		if err := b.addPage(); err != nil {
+			return nil, err
+		}
+		page, err := b.parseModel(dec, root, "")
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, page)
+	case "mxfile":
+		for {
+			tok, err := drawioToken(dec)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := tok.(xml.EndElement); ok {
+				break
+			}
+			diagram, ok := tok.(xml.StartElement)
+			if !ok {
+				continue
+			}
+			if diagram.Name.Local != "diagram" {
+				if err := dec.Skip(); err != nil {
+					return nil, fmt.Errorf("%w: malformed XML: %v", ErrInvalidDiagram, err)
+				}
+				continue
+			}
+			if err := b.addPage(); err != nil {
+				return nil, err
+			}
+			page, err := b.parseDiagram(dec, diagram)
+			if err != nil {
+				return nil, err
+			}
+			pages = append(pages, page)
+		}
+	default:
+		return nil, fmt.Errorf("%w: expected an mxfile document, got <%s>", ErrInvalidDiagram, root.Name.Local)
+	}
+
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("%w: file has no pages", ErrInvalidDiagram)
+	}
+	return pages, nil
+}
+
+// addPage counts a page against the page limit of the file
+func (b *drawioBudget) addPage() error {
+	if b.pages == 0 {
+		return fmt.Errorf("%w: file has more than %d pages", ErrDiagramTooLarge, maxDrawioPages)
+	}
+	b.pages--
+	return nil
+}
+
+// parseDiagram reads the page of a diagram element, inflating it if it is compressed: the deflated,
+// URI-encoded XML is stored in base64 as the text of the diagram element
+func (b *drawioBudget) parseDiagram(dec *xml.Decoder, diagram xml.StartElement) (*Page, error) {
+	name := xmlAttr(diagram.Attr, "name")
+
+	var (
+		page *Page
+		text []byte
+	)
+	for {
+		tok, err := drawioToken(dec)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := tok.(xml.EndElement); ok {
+			break
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			text = append(text, t...)
+		case xml.StartElement:
+			if t.Name.Local != "mxGraphModel" || page != nil {
+				if err := dec.Skip(); err != nil {
+					return nil, fmt.Errorf("%w: malformed XML: %v", ErrInvalidDiagram, err)
+				}
+				continue
+			}
+			if page, err = b.parseModel(dec, t, name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if page != nil {
+		return page, nil
+	}
+
+	model, err := b.inflate(bytes.TrimSpace(text))
+	if err != nil {
+		return nil, fmt.Errorf("page %q: %w", name, err)
+	}
+
+	dec = xml.NewDecoder(bytes.NewReader(model))
+	root, err := drawioRoot(dec)
+	if err != nil {
+		return nil, err
+	}
+	if root.Name.Local != "mxGraphModel" {
+		return nil, fmt.Errorf("page %q: %w: expected an mxGraphModel, got <%s>", name, ErrInvalidDiagram, root.Name.Local)
+	}
+	return b.parseModel(dec, root, name)
+}
+
+// inflate decodes the text of a compressed page, counting it against the inflated size limit of the file
+func (b *drawioBudget) inflate(text []byte) ([]byte, error) {
+	if len(text) == 0 {
+		return nil, fmt.Errorf("%w: page is empty", ErrInvalidDiagram)
+	}
+	compressed := make([]byte, base64.StdEncoding.DecodedLen(len(text)))
+	n, err := base64.StdEncoding.Decode(compressed, text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: compressed page is not base64", ErrInvalidDiagram)
+	}
+	inflated, err := io.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(compressed[:n])), int64(b.bytes)+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: compressed page cannot be inflated: %v", ErrInvalidDiagram, err)
+	}
+	if len(inflated) > b.bytes {
+		return nil, fmt.Errorf("%w: pages are larger than %d bytes once inflated", ErrDiagramTooLarge, maxDrawioBytes)
+	}
+	b.bytes -= len(inflated)
+
+	decoded, err := url.PathUnescape(string(inflated))
+	if err != nil {
+		return nil, fmt.Errorf("%w: compressed page is not URI encoded", ErrInvalidDiagram)
+	}
+	return []byte(decoded), nil
+}
+
+// parseModel reads the cells of a graph model as they are decoded, stopping as soon as the page
+// or the file has too many of them
+func (b *drawioBudget) parseModel(dec *xml.Decoder, model xml.StartElement, name string) (*Page, error) {
+	var (
+		cells    []*drawioCell
+		seenRoot bool
+	)
+	for {
+		tok, err := drawioToken(dec)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := tok.(xml.EndElement); ok {
+			break
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local != "root" || seenRoot {
+			if err := dec.Skip(); err != nil {
+				return nil, fmt.Errorf("%w: malformed XML: %v", ErrInvalidDiagram, err)
+			}
+			continue
+		}
+		seenRoot = true
+		if cells, err = b.parseCells(dec, name); err != nil {
+			return nil, err
+		}
+	}
+	if !seenRoot {
+		return nil, fmt.Errorf("%w: graph model has no root", ErrInvalidDiagram)
+	}
+
+	return buildDrawioPage(name, cells), nil
+}
+
+// parseCells reads the cells of the root element of a graph model up to its end
+func (b *drawioBudget) parseCells(dec *xml.Decoder, name string) ([]*drawioCell, error) {
+	var cells []*drawioCell
+	for count := 0; ; {
+		tok, err := drawioToken(dec)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := tok.(xml.EndElement); ok {
+			return cells, nil
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "mxCell", "object", "UserObject":
+			count++
+			if count > maxDrawioPageCells {
+				return nil, fmt.Errorf("%w: page %q has more than %d cells", ErrDiagramTooLarge, name, maxDrawioPageCells)
+			}
+			if b.cells == 0 {
+				return nil, fmt.Errorf("%w: file has more than %d cells", ErrDiagramTooLarge, maxDrawioCells)
+			}
+			b.cells--
+		default:
+			if err := dec.Skip(); err != nil {
+				return nil, fmt.Errorf("%w: malformed XML: %v", ErrInvalidDiagram, err)
+			}
+			continue
+		}
+
+		var el mxElement
+		if err := dec.DecodeElement(&el, &start); err != nil {
+			return nil, fmt.Errorf("%w: malformed XML: %v", ErrInvalidDiagram, err)
+		}
+		if cell := newDrawioCell(&el); cell != nil {
+			cells = append(cells, cell)
+		}
+	}
+}
+
+// drawioRoot returns the root element of an XML document
+func drawioRoot(dec *xml.Decoder) (xml.StartElement, error) {
+	for {
+		tok, err := drawioToken(dec)
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start, nil
+		}
+	}
+}
+
+// drawioToken returns the next token of an XML document; the end of input is malformed
+// as the document is only read while elements are still open
+func drawioToken(dec *xml.Decoder) (xml.Token, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed XML: %v", ErrInvalidDiagram, err)
+	}
+	return tok, nil
+}
+
+// xmlAttr returns the value of the named attribute, or empty
+func xmlAttr(attrs []xml.Attr, name string) string {
+	for _, a := range attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// drawioCell is a cell of a graph model, with the attributes of its object wrapper if any
+type drawioCell struct {
+	id, label, parent    string
+	source, target       string
+	vertex, edge         bool
+	style                map[string]string
+	geometry             *mxElement
+	x, y, width, height  float64
+	absoluteX, absoluteY float64
+	container            bool
+}
+
+// buildDrawioPage converts the cells of a graph model into a graph with the positions of the model
+func buildDrawioPage(name string, cells []*drawioCell) *Page {
+	byID := make(map[string]*drawioCell, len(cells))
+	for _, cell := range cells {
+		byID[cell.id] = cell
+	}
+
+	// Geometries are relative to the enclosing vertex, if any
+	for _, cell := range cells {
+		if parent := byID[cell.parent]; parent != nil && parent.vertex {
+			parent.container = true
+		}
+	}
+	var absolute func(c *drawioCell, depth int) (float64, float64)
+	absolute = func(c *drawioCell, depth int) (float64, float64) {
+		parent := byID[c.parent]
+		if parent == nil || !parent.vertex || depth > len(cells) {
+			return 0, 0
+		}
+		px, py := absolute(parent, depth+1)
+		return px + parent.x, py + parent.y
+	}
+	for _, cell := range cells {
+		cell.absoluteX, cell.absoluteY = absolute(cell, 0)
+	}
+
+	g := &Graph{Direction: TopToBottom}
+	edgeLabels := make(map[string][]string)
+	for _, cell := range cells {
+		switch {
+		case cell.vertex && byID[cell.parent] != nil && byID[cell.parent].edge:
+			// Labels placed along an edge are vertices parented to it
+			if cell.label != "" {
+				edgeLabels[cell.parent] = append(edgeLabels[cell.parent], cell.label)
+			}
+		case cell.vertex && cell.style["shape"] == "swimlane", cell.vertex && cell.container && cell.label != "" && cell.style["group"] == "":
+			g.Groups = append(g.Groups, drawioGroup(cell, byID))
+		case cell.vertex && cell.style["group"] != "":
+			// Invisible groups only position their children
+		case cell.vertex:
+			g.Nodes = append(g.Nodes, drawioNode(cell, byID))
+		}
+	}
+
+	for _, cell := range cells {
+		if !cell.edge {
+			continue
+		}
+		e := drawioEdge(cell, g)
+		if e.Label == "" {
+			e.Label = strings.Join(edgeLabels[cell.id], "\n")
+		}
+		if len(e.Points) >= 2 {
+			g.Edges = append(g.Edges, e)
+		}
+	}
+
+	return &Page{Name: name, Graph: g}
+}
+
+// newDrawioCell reads an mxCell, or an object or UserObject wrapping one; other elements return nil
+func newDrawioCell(el *mxElement) *drawioCell {
+	cellEl, id, label := el, el.attr("id"), el.attr("value")
+	switch el.XMLName.Local {
+	case "mxCell":
+	case "object", "UserObject":
+		cellEl, label = el.child("mxCell"), el.attr("label")
+		if cellEl == nil {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	cell := &drawioCell{
+		id:       id,
+		label:    drawioLabel(label),
+		parent:   cellEl.attr("parent"),
+		source:   cellEl.attr("source"),
+		target:   cellEl.attr("target"),
+		vertex:   cellEl.attr("vertex") == "1",
+		edge:     cellEl.attr("edge") == "1",
+		style:    parseDrawioStyle(cellEl.attr("style")),
+		geometry: cellEl.child("mxGeometry"),
+	}
+	if cell.geometry != nil {
+		cell.x, cell.y = cell.geometry.number("x"), cell.geometry.number("y")
+		cell.width, cell.height = math.Abs(cell.geometry.number("width")), math.Abs(cell.geometry.number("height"))
+	}
+	return cell
+}
+
+// parseDrawioStyle parses a cell style such as "ellipse;whiteSpace=wrap;fillColor=#dae8fc;"
+// Bare names, such as "ellipse" or "text", are stored under the shape key unless they are a known flag
+func parseDrawioStyle(style string) map[string]string {
+	props := make(map[string]string)
+	for _, part := range strings.Split(style, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		switch {
+		case ok:
+			props[key] = value
+		case part == "group" || part == "text" || part == "edgeLabel":
+			props[part] = "1"
+			if part == "text" {
+				props["shape"] = "text"
+			}
+		default:
+			if _, set := props["shape"]; !set {
+				props["shape"] = part
+			}
+		}
+	}
+	return props
+}
+
+// drawioLabel converts a cell value, which may be HTML, to plain text with newlines
+func drawioLabel(value string) string {
+	if !strings.Contains(value, "<") && !strings.Contains(value, "&") {
+		return strings.TrimSpace(value)
+	}
+	text := drawioBlockTag.ReplaceAllString(value, "\n")
+	text = drawioTag.ReplaceAllString(text, "")
+	text = strings.ReplaceAll(html.UnescapeString(text), "\u00a0", " ")
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// drawioStyle converts the colors, stroke and font of a cell style
+func drawioStyle(props map[string]string, fill bool) Style {
+	s := Style{
+		StrokeColor: drawioColor(props["strokeColor"], drawioStroke),
+		TextColor:   drawioColor(props["fontColor"], ""),
+		FontSize:    drawioFontSize,
+	}
+	if fill {
+		s.BackgroundColor = drawioColor(props["fillColor"], drawioFill)
+	}
+	if w, err := strconv.ParseFloat(props["strokeWidth"], 64); err == nil && w > 0 {
+		s.StrokeWidth = w
+	} else {
+		s.StrokeWidth = 1
+	}
+	if size, err := strconv.ParseFloat(props["fontSize"], 64); err == nil && size > 0 {
+		s.FontSize = size
+	}
+	if props["dashed"] == "1" {
+		s.StrokeStyle = "dashed"
+	}
+	return s
+}
+
+// drawioColor converts a draw.io color: "none" is transparent, and "default" or empty the fallback
+func drawioColor(color, fallback string) string {
+	switch color {
+	case "none":
+		return "transparent"
+	case "", "default", "inherit":
+		return fallback
+	default:
+		return color
+	}
+}
+
+// drawioNode converts a vertex into a node
+func drawioNode(cell *drawioCell, byID map[string]*drawioCell) *Node {
+	n := &Node{
+		ID:     cell.id,
+		Label:  cell.label,
+		Shape:  ShapeRectangle,
+		Style:  drawioStyle(cell.style, true),
+		Group:  drawioParentGroup(cell, byID),
+		X:      cell.absoluteX + cell.x,
+		Y:      cell.absoluteY + cell.y,
+		Width:  cell.width,
+		Height: cell.height,
+	}
+
+	switch shape := cell.style["shape"]; {
+	case shape == "ellipse" || shape == "doubleEllipse" || strings.HasPrefix(shape, "mxgraph.flowchart.start") || shape == "mxgraph.flowchart.on-page_reference":
+		n.Shape = ShapeEllipse
+	case shape == "rhombus" || shape == "mxgraph.flowchart.decision":
+		n.Shape = ShapeDiamond
+	case shape == "text" || shape == "edgeLabel" || (cell.style["strokeColor"] == "none" && drawioColor(cell.style["fillColor"], "none") == "transparent"):
+		n.Shape = ShapeText
+	case cell.style["rounded"] == "1":
+		n.Shape = ShapeRounded
+	}
+	return n
+}
+
+// drawioGroup converts a swimlane or a labelled container into a group box
+func drawioGroup(cell *drawioCell, byID map[string]*drawioCell) *Group {
+	style := drawioStyle(cell.style, true)
+	if style.StrokeStyle == "" {
+		style.StrokeStyle = "solid"
+	}
+	return &Group{
+		ID:     cell.id,
+		Label:  cell.label,
+		Style:  style,
+		Parent: drawioParentGroup(cell, byID),
+		X:      cell.absoluteX + cell.x,
+		Y:      cell.absoluteY + cell.y,
+		Width:  cell.width,
+		Height: cell.height,
+	}
+}
+
+// drawioParentGroup returns the ID of the swimlane or labelled container enclosing a cell, or empty
+func drawioParentGroup(cell *drawioCell, byID map[string]*drawioCell) string {
+	for parent, depth := byID[cell.parent], 0; parent != nil && parent.vertex && depth < len(byID); parent, depth = byID[parent.parent], depth+1 {
+		if parent.style["shape"] == "swimlane" || (parent.label != "" && parent.style["group"] == "") {
+			return parent.id
+		}
+	}
+	return ""
+}
+
+// drawioEdge converts an edge cell: its ends are bound to the nodes or groups it connects, and its
+// route goes through its waypoints, or bends at right angles for orthogonal edges without any
+func drawioEdge(cell *drawioCell, g *Graph) *Edge {
+	e := &Edge{
+		ID:             cell.id,
+		Label:          cell.label,
+		Style:          drawioStyle(cell.style, false),
+		StartArrowhead: drawioArrowhead(cell.style["startArrow"], ArrowheadNone),
+		EndArrowhead:   drawioArrowhead(cell.style["endArrow"], ArrowheadArrow),
+		Curved:         cell.style["curved"] == "1",
+	}
+
+	var via []Point
+	var sourcePoint, targetPoint *Point
+	if cell.geometry != nil {
+		for _, child := range cell.geometry.Children {
+			switch {
+			case child.XMLName.Local == "Array" && child.attr("as") == "points":
+				for _, p := range child.Children {
+					via = append(via, Point{cell.absoluteX + p.number("x"), cell.absoluteY + p.number("y")})
+				}
+			case child.XMLName.Local == "mxPoint" && child.attr("as") == "sourcePoint":
+				sourcePoint = &Point{cell.absoluteX + child.number("x"), cell.absoluteY + child.number("y")}
+			case child.XMLName.Local == "mxPoint" && child.attr("as") == "targetPoint":
+				targetPoint = &Point{cell.absoluteX + child.number("x"), cell.absoluteY + child.number("y")}
+			}
+		}
+	}
+
+	from, to := drawioTerminal(g, cell.source), drawioTerminal(g, cell.target)
+	if from != nil {
+		e.From = cell.source
+	}
+	if to != nil {
+		e.To = cell.target
+	}
+
+	// Without a terminal, the end is at the point the edge was drawn to
+	start, end := sourcePoint, targetPoint
+	if from != nil {
+		c := from.Center()
+		start = &c
+	}
+	if to != nil {
+		c := to.Center()
+		end = &c
+	}
+	if start == nil || end == nil {
+		return e
+	}
+
+	edgeStyle := cell.style["edgeStyle"]
+	orthogonal := strings.Contains(edgeStyle, "orthogonal") || strings.Contains(edgeStyle, "elbow")
+	if orthogonal && len(via) == 0 && start.X != end.X && start.Y != end.Y {
+		if math.Abs(end.X-start.X) >= math.Abs(end.Y-start.Y) {
+			mid := (start.X + end.X) / 2
+			via = []Point{{mid, start.Y}, {mid, end.Y}}
+		} else {
+			mid := (start.Y + end.Y) / 2
+			via = []Point{{start.X, mid}, {end.X, mid}}
+		}
+	}
+
+	first, last := *end, *start
+	if len(via) > 0 {
+		first, last = via[0], via[len(via)-1]
+	}
+	e.Points = append(e.Points, drawioEndpoint(from, cell.style, "exit", first, *start))
+	e.Points = append(e.Points, via...)
+	e.Points = append(e.Points, drawioEndpoint(to, cell.style, "entry", last, *end))
+	return e
+}
+
+// drawioTerminal returns the node or group box an edge connects to as a node, or nil
+func drawioTerminal(g *Graph, id string) *Node {
+	if id == "" {
+		return nil
+	}
+	if n := g.Node(id); n != nil {
+		return n
+	}
+	if gr := g.Group(id); gr != nil {
+		return &Node{ID: gr.ID, Shape: ShapeRectangle, X: gr.X, Y: gr.Y, Width: gr.Width, Height: gr.Height}
+	}
+	return nil
+}
+
+// drawioEndpoint returns where an edge leaves or enters a terminal: the constraint point of the
+// style (exitX/exitY or entryX/entryY) if set, or the border towards the next point of the route
+func drawioEndpoint(terminal *Node, style map[string]string, prefix string, toward, fallback Point) Point {
+	if terminal == nil {
+		return fallback
+	}
+	fx, errX := strconv.ParseFloat(style[prefix+"X"], 64)
+	fy, errY := strconv.ParseFloat(style[prefix+"Y"], 64)
+	if errX == nil && errY == nil {
+		return Point{terminal.X + terminal.Width*fx, terminal.Y + terminal.Height*fy}
+	}
+	return Boundary(terminal, toward)
+}
+
+// drawioArrowhead converts the startArrow or endArrow of an edge style
+func drawioArrowhead(marker string, fallback Arrowhead) Arrowhead {
+	switch marker {
+	case "":
+		return fallback
+	case "none":
+		return ArrowheadNone
+	case "block", "blockThin":
+		return ArrowheadTriangle
+	case "oval", "circle", "circlePlus":
+		return ArrowheadCircle
+	case "diamond", "diamondThin":
+		return ArrowheadDiamond
+	case "dash", "ERone", "ERmandOne", "cross":
+		return ArrowheadBar
+	default:
+		return ArrowheadArrow
+	}
+}
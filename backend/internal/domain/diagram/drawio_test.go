@@ -0,0 +1,234 @@
+package diagram
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// compressDrawio encodes a graph model the way draw.io stores compressed pages
+func compressDrawio(t *testing.T, model string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(url.PathEscape(model))); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+const drawioFlow = `<mxGraphModel><root>
+  <mxCell id="0"/>
+  <mxCell id="1" parent="0"/>
+  <mxCell id="lane" value="Backend" style="swimlane;fillColor=#dae8fc;" vertex="1" parent="1">
+    <mxGeometry x="300" y="0" width="200" height="200" as="geometry"/>
+  </mxCell>
+  <mxCell id="a" value="&lt;b&gt;Start&lt;/b&gt;&lt;br&gt;here" style="ellipse;whiteSpace=wrap;html=1;fillColor=#d5e8d4;strokeColor=#82b366;" vertex="1" parent="1">
+    <mxGeometry x="40" y="60" width="120" height="80" as="geometry"/>
+  </mxCell>
+  <mxCell id="b" value="Check" style="rhombus;dashed=1;fontSize=16;" vertex="1" parent="lane">
+    <mxGeometry x="40" y="100" width="120" height="80" as="geometry"/>
+  </mxCell>
+  <UserObject id="c" label="Linked" link="https://example.com">
+    <mxCell style="rounded=1;" vertex="1" parent="1">
+      <mxGeometry x="40" y="300" width="120" height="60" as="geometry"/>
+    </mxCell>
+  </UserObject>
+  <mxCell id="note" value="A note" style="text;html=1;" vertex="1" parent="1">
+    <mxGeometry x="600" y="10" width="80" height="20" as="geometry"/>
+  </mxCell>
+  <mxCell id="e1" value="go" style="edgeStyle=orthogonalEdgeStyle;endArrow=block;startArrow=oval;" edge="1" parent="1" source="a" target="b">
+    <mxGeometry relative="1" as="geometry"/>
+  </mxCell>
+  <mxCell id="e2" style="endArrow=none;strokeWidth=3;" edge="1" parent="1" source="b" target="c">
+    <mxGeometry relative="1" as="geometry">
+      <Array as="points"><mxPoint x="400" y="330"/></Array>
+    </mxGeometry>
+  </mxCell>
+  <mxCell id="e2label" value="back" style="edgeLabel;" vertex="1" connectable="0" parent="e2">
+    <mxGeometry relative="1" as="geometry"/>
+  </mxCell>
+  <mxCell id="e3" edge="1" parent="1" source="c">
+    <mxGeometry relative="1" as="geometry">
+      <mxPoint x="400" y="500" as="targetPoint"/>
+    </mxGeometry>
+  </mxCell>
+</root></mxGraphModel>`
+
+func TestConvertDrawioPages(t *testing.T) {
+	second := `<mxGraphModel><root><mxCell id="0"/><mxCell id="1" parent="0"/>` +
+		`<mxCell id="x" value="Only" vertex="1" parent="1"><mxGeometry x="0" y="0" width="80" height="40" as="geometry"/></mxCell>` +
+		`</root></mxGraphModel>`
+	file := `<mxfile host="app.diagrams.net">` +
+		`<diagram id="p1" name="Flow">` + drawioFlow + `</diagram>` +
+		`<diagram id="p2" name="Compressed">` + compressDrawio(t, second) + `</diagram>` +
+		`</mxfile>`
+
+	if !IsDrawio([]byte(file)) {
+		t.Fatalf("expected the file to be detected as draw.io")
+	}
+	results, err := ConvertDrawio([]byte(file))
+	if err != nil {
+		t.Fatalf("ConvertDrawio failed: %v", err)
+	}
+	if len(results) != 2 || results[0].Title != "Flow" || results[1].Title != "Compressed" {
+		t.Fatalf("expected a scene per page titled with its name, got %d", len(results))
+	}
+	if els := elementsByID(t, results[1]); els["node:x"] == nil || els["node:x:text"]["text"] != "Only" {
+		t.Errorf("expected the compressed page to be inflated, got %v", els)
+	}
+
+	els := elementsByID(t, results[0])
+	a, b, c := els["node:a"], els["node:b"], els["node:c"]
+	if a["type"] != "ellipse" || b["type"] != "diamond" || c["type"] != "rectangle" || c["roundness"] == nil {
+		t.Errorf("expected an ellipse, a diamond and a rounded rectangle, got %v, %v and %v", a["type"], b["type"], c["type"])
+	}
+	if a["backgroundColor"] != "#d5e8d4" || a["strokeColor"] != "#82b366" || a["strokeWidth"] != 1.0 {
+		t.Errorf("expected the style of a, got %v %v %v", a["backgroundColor"], a["strokeColor"], a["strokeWidth"])
+	}
+	if text := els["node:a:text"]; text == nil || text["text"] != "Start\nhere" || text["fontSize"] != 12.0 {
+		t.Errorf("expected the HTML label of a as plain text, got %v", text)
+	}
+	if b["strokeStyle"] != "dashed" || els["node:b:text"]["fontSize"] != 16.0 {
+		t.Errorf("expected b dashed with a larger font")
+	}
+	if els["node:c:text"]["text"] != "Linked" {
+		t.Errorf("expected the label of the user object, got %v", els["node:c:text"]["text"])
+	}
+	if note := els["node:note"]; note == nil || note["type"] != "text" || note["text"] != "A note" {
+		t.Errorf("expected free text for the text cell, got %v", note)
+	}
+
+	// Children of a container are positioned relative to it, and swimlanes become groups
+	if x, y, _, _ := box(b); x != 340 || y != 100 {
+		t.Errorf("expected b at (340, 100), got (%v, %v)", x, y)
+	}
+	if lane := els["group:lane"]; lane == nil || lane["backgroundColor"] != "#dae8fc" || els["group:lane:text"]["text"] != "Backend" {
+		t.Errorf("expected the swimlane as a titled group, got %v", lane)
+	}
+
+	e1 := els["edge:e1"]
+	if e1 == nil || e1["startBinding"].(map[string]interface{})["elementId"] != "node:a" || e1["endBinding"].(map[string]interface{})["elementId"] != "node:b" {
+		t.Fatalf("expected e1 bound to a and b, got %v", e1)
+	}
+	if e1["startArrowhead"] != "circle" || e1["endArrowhead"] != "triangle" {
+		t.Errorf("expected the markers of e1, got %v %v", e1["startArrowhead"], e1["endArrowhead"])
+	}
+	if len(e1["points"].([]interface{})) != 4 || e1["roundness"] != nil {
+		t.Errorf("expected a sharp orthogonal route with two bends, got %v", e1["points"])
+	}
+	if els["edge:e1:text"]["text"] != "go" {
+		t.Errorf("expected the label of e1")
+	}
+
+	e2 := els["edge:e2"]
+	if e2["endArrowhead"] != nil || e2["strokeWidth"] != 3.0 || len(e2["points"].([]interface{})) != 3 {
+		t.Errorf("expected a plain, thick edge through its waypoint, got %v %v %v", e2["endArrowhead"], e2["strokeWidth"], e2["points"])
+	}
+	if label := els["edge:e2:text"]; label == nil || label["text"] != "back" {
+		t.Errorf("expected the edge label cell bound to e2, got %v", label)
+	}
+
+	e3 := els["edge:e3"]
+	if e3 == nil || e3["startBinding"] == nil || e3["endBinding"] != nil {
+		t.Errorf("expected e3 bound at its start only, got %v", e3)
+	}
+}
+
+func TestConvertDrawioFrames(t *testing.T) {
+	file := `<mxfile>` +
+		`<diagram name="One">` + drawioFlow + `</diagram>` +
+		`<diagram name="Two">` + compressDrawio(t, drawioFlow) + `</diagram>` +
+		`</mxfile>`
+
+	res, err := ConvertDrawioFrames([]byte(file))
+	if err != nil {
+		t.Fatalf("ConvertDrawioFrames failed: %v", err)
+	}
+	els := elementsByID(t, res)
+
+	one, two := els["frame:1"], els["frame:2"]
+	if one == nil || two == nil || one["type"] != "frame" || one["name"] != "One" || two["name"] != "Two" {
+		t.Fatalf("expected a frame per page named after it, got %v and %v", one, two)
+	}
+	ox, _, ow, _ := box(one)
+	if tx, _, _, _ := box(two); tx < ox+ow {
+		t.Errorf("expected the frames side by side, got %v and %v", ox+ow, tx)
+	}
+
+	// Element IDs repeated across pages are kept unique
+	if els["node:a"]["frameId"] != "frame:1" || els["node:a~2"] == nil || els["node:a~2"]["frameId"] != "frame:2" {
+		t.Errorf("expected the shapes of each page in its frame")
+	}
+	if ax, _, _, _ := box(els["node:a~2"]); ax < ox+ow {
+		t.Errorf("expected the second page moved into its frame, got x %v", ax)
+	}
+	if e1 := els["edge:e1~2"]; e1 == nil || e1["startBinding"].(map[string]interface{})["elementId"] != "node:a~2" {
+		t.Errorf("expected the arrows of the second page bound to its shapes, got %v", e1)
+	}
+}
+
+func TestConvertDrawioErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"malformed", `<mxfile><diagram>`},
+		{"not draw.io", `<html></html>`},
+		{"no pages", `<mxfile></mxfile>`},
+		{"empty page", `<mxfile><diagram name="a"></diagram></mxfile>`},
+		{"bad compression", `<mxfile><diagram name="a">bm90IGRlZmxhdGU=</diagram></mxfile>`},
+		{"svg without diagram", `<svg xmlns="http://www.w3.org/2000/svg"></svg>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ConvertDrawio([]byte(tt.content))
+			if !errors.Is(err, ErrInvalidDiagram) {
+				t.Errorf("expected ErrInvalidDiagram, got %v", err)
+			}
+		})
+	}
+}
+
+func TestConvertDrawioLimits(t *testing.T) {
+	// A compressed page of the given number of cells
+	page := func(cells int) string {
+		var b strings.Builder
+		b.WriteString(`<mxGraphModel><root>`)
+		for i := 0; i < cells; i++ {
+			fmt.Fprintf(&b, `<mxCell id="c%d" vertex="1"/>`, i)
+		}
+		b.WriteString(`</root></mxGraphModel>`)
+		return `<diagram name="p">` + compressDrawio(t, b.String()) + `</diagram>`
+	}
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"too many cells on a page", `<mxfile>` + page(maxDrawioPageCells+1) + `</mxfile>`},
+		{"too many cells across pages", `<mxfile>` + strings.Repeat(page(maxDrawioPageCells), maxDrawioCells/maxDrawioPageCells+1) + `</mxfile>`},
+		{"too many pages", `<mxfile>` + strings.Repeat(page(1), maxDrawioPages+1) + `</mxfile>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ConvertDrawio([]byte(tt.content))
+			if !errors.Is(err, ErrDiagramTooLarge) {
+				t.Errorf("expected ErrDiagramTooLarge, got %v", err)
+			}
+		})
+	}
+}
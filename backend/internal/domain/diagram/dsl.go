@@ -0,0 +1,587 @@
+package diagram
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// DSL source looks like this:
+//
+//	title "Checkout"
+//	direction LR
+//	style critical stroke=#e03131 width=4
+//
+//	group backend "Backend" {
+//	  service api "API Gateway"
+//	  database orders "Orders DB" class=critical
+//	}
+//	queue events "Order events"
+//
+//	api -> orders "SQL"
+//	api ..> events "publish" end=triangle
+//
+// A statement takes one line; # starts a comment. Nodes are declared with their kind, groups
+// enclose the statements between { and }, and edges join node IDs with an operator. Labels are
+// quoted, and attributes are key=value pairs
+
+var (
+	// dslID matches the ID of a node or group
+	dslID = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+	// dslColor matches a color attribute: a hex color or a CSS color name
+	dslColor = regexp.MustCompile(`^(#[0-9A-Fa-f]{3,8}|[A-Za-z]+)$`)
+)
+
+// dslKind is the default look of a kind of node
+type dslKind struct {
+	shape Shape
+	style Style
+}
+
+// dslKinds are the keywords that declare a node, by the look they give it
+var dslKinds = map[string]dslKind{
+	"node":     {shape: ShapeRectangle},
+	"service":  {shape: ShapeRounded, style: Style{StrokeColor: "#1971c2", BackgroundColor: "#e7f5ff"}},
+	"database": {shape: ShapeEllipse, style: Style{StrokeColor: "#2f9e44", BackgroundColor: "#ebfbee"}},
+	"queue":    {shape: ShapeRectangle, style: Style{StrokeColor: "#f08c00", BackgroundColor: "#fff9db"}},
+	"external": {shape: ShapeRectangle, style: Style{StrokeColor: "#868e96", StrokeStyle: "dashed"}},
+	"text":     {shape: ShapeText},
+}
+
+// dslOperators are the edge operators, by the arrowheads and stroke style they draw
+var dslOperators = map[string]struct {
+	start, end Arrowhead
+	dashed     bool
+}{
+	"->":   {end: ArrowheadArrow},
+	"<-":   {start: ArrowheadArrow},
+	"<->":  {start: ArrowheadArrow, end: ArrowheadArrow},
+	"--":   {},
+	"..>":  {end: ArrowheadArrow, dashed: true},
+	"<..":  {start: ArrowheadArrow, dashed: true},
+	"<..>": {start: ArrowheadArrow, end: ArrowheadArrow, dashed: true},
+	"..":   {dashed: true},
+}
+
+// CompileDSL compiles diagram source in the DSL into a scene laid out in ranks
+// Given the scene an earlier compilation produced, possibly edited since, the scene is updated
+// instead: nodes the user moved keep their position, elements added by the user are kept, and
+// generated elements that are no longer declared are deleted
+func CompileDSL(ctx context.Context, source string, previous drawing.DrawingData) (*Result, error) {
+	p := &dslParser{
+		g:       &Graph{Direction: TopToBottom},
+		styles:  make(map[string][]dslAttr),
+		nodes:   make(map[string]*dslNode),
+		groups:  make(map[string]*dslGroup),
+		defined: make(map[string]int),
+	}
+	if err := p.parse(source); err != nil {
+		return nil, err
+	}
+	if err := p.resolve(); err != nil {
+		return nil, err
+	}
+
+	SizeNodes(p.g)
+	if err := LayeredLayout(ctx, p.g); err != nil {
+		return nil, err
+	}
+	layout := make(map[string]Point, len(p.g.Nodes))
+	for _, n := range p.g.Nodes {
+		layout[NodeElementID(n.ID)] = Point{n.X, n.Y}
+	}
+
+	if previous != nil {
+		pinMovedNodes(p.g, previous)
+	}
+	data := Build(p.g)
+	recordLayout(data, layout)
+	if previous != nil {
+		data = mergeScene(previous, data)
+	}
+
+	return &Result{Title: p.title, Data: data}, nil
+}
+
+// dslAttr is a key=value attribute, or a flag such as "dashed" with an empty value
+type dslAttr struct {
+	key, value string
+	line       int
+}
+
+// dslNode is a declared node with the attributes of its declarations, applied in order
+type dslNode struct {
+	node  *Node
+	kind  string
+	attrs []dslAttr
+
+	// declared is set once the node has a declaration of its own, rather than only edges
+	declared bool
+}
+
+// dslGroup is a declared group with its attributes
+type dslGroup struct {
+	group *Group
+	attrs []dslAttr
+}
+
+// dslParser reads the statements of DSL source into a graph
+type dslParser struct {
+	g     *Graph
+	title string
+
+	// styles are the named styles, applied with the class attribute
+	styles map[string][]dslAttr
+
+	nodes     map[string]*dslNode
+	groups    map[string]*dslGroup
+	edgeAttrs [][]dslAttr
+
+	// defined records the line each node or group is first declared on
+	defined map[string]int
+
+	// open is the stack of groups enclosing the current statement
+	open []string
+}
+
+// parse reads every statement of source
+func (p *dslParser) parse(source string) error {
+	for i, line := range strings.Split(source, "\n") {
+		tokens, err := dslTokens(line)
+		if err != nil {
+			return fmt.Errorf("%w: line %d: %v", ErrInvalidDiagram, i+1, err)
+		}
+		if len(tokens) == 0 {
+			continue
+		}
+		if err := p.statement(tokens, i+1); err != nil {
+			return fmt.Errorf("%w: line %d: %v", ErrInvalidDiagram, i+1, err)
+		}
+	}
+
+	if len(p.open) > 0 {
+		return fmt.Errorf("%w: group %q is not closed with }", ErrInvalidDiagram, p.open[len(p.open)-1])
+	}
+	if len(p.g.Nodes) == 0 {
+		return fmt.Errorf("%w: diagram declares no nodes", ErrInvalidDiagram)
+	}
+	return nil
+}
+
+// statement parses a single statement
+func (p *dslParser) statement(tokens []dslToken, line int) error {
+	first := tokens[0]
+	if first.quoted {
+		return fmt.Errorf("unexpected %q", first.text)
+	}
+
+	if len(tokens) > 1 && !tokens[1].quoted {
+		if _, ok := dslOperators[tokens[1].text]; ok {
+			return p.edges(tokens, line)
+		}
+	}
+
+	switch keyword := first.text; keyword {
+	case "}":
+		if len(tokens) > 1 {
+			return fmt.Errorf("unexpected %q after }", tokens[1].text)
+		}
+		if len(p.open) == 0 {
+			return fmt.Errorf("} without a group")
+		}
+		p.open = p.open[:len(p.open)-1]
+		return nil
+	case "title":
+		words := make([]string, 0, len(tokens)-1)
+		for _, t := range tokens[1:] {
+			words = append(words, t.text)
+		}
+		p.title = strings.Join(words, " ")
+		return nil
+	case "direction":
+		if len(tokens) != 2 {
+			return fmt.Errorf("direction takes one of TB, BT, LR or RL")
+		}
+		switch d := Direction(strings.ToUpper(tokens[1].text)); d {
+		case TopToBottom, BottomToTop, LeftToRight, RightToLeft:
+			p.g.Direction = d
+			return nil
+		}
+		return fmt.Errorf("unknown direction %q, expected TB, BT, LR or RL", tokens[1].text)
+	case "style":
+		if len(tokens) < 2 || !dslID.MatchString(tokens[1].text) {
+			return fmt.Errorf("style takes a name and attributes")
+		}
+		_, attrs, err := dslLabelAndAttrs(tokens[2:], line)
+		if err != nil {
+			return err
+		}
+		for _, a := range attrs {
+			if a.key == "class" {
+				return fmt.Errorf("styles cannot use class")
+			}
+		}
+		p.styles[tokens[1].text] = append(p.styles[tokens[1].text], attrs...)
+		return nil
+	case "group":
+		return p.group(tokens, line)
+	}
+
+	if _, ok := dslKinds[first.text]; ok {
+		return p.node(tokens, line)
+	}
+	return fmt.Errorf("unexpected %q, expected a node kind, group, style, title, direction or an edge", first.text)
+}
+
+// node parses a node declaration: kind, ID, optional label and attributes
+// Declaring a node again adds to its attributes
+func (p *dslParser) node(tokens []dslToken, line int) error {
+	if len(tokens) < 2 || tokens[1].quoted {
+		return fmt.Errorf("%s needs an ID", tokens[0].text)
+	}
+	id := tokens[1].text
+	label, attrs, err := dslLabelAndAttrs(tokens[2:], line)
+	if err != nil {
+		return err
+	}
+
+	n, err := p.declareNode(id, line)
+	if err != nil {
+		return err
+	}
+	// A node used by an edge before its declaration belongs to the group it is declared in
+	if !n.declared && len(p.open) > 0 {
+		n.node.Group = p.open[len(p.open)-1]
+	}
+	n.declared = true
+	n.kind = tokens[0].text
+	if label != nil {
+		n.node.Label = *label
+	}
+	n.attrs = append(n.attrs, attrs...)
+	return nil
+}
+
+// declareNode returns the node with the given ID, adding it to the innermost open group if it is new
+func (p *dslParser) declareNode(id string, line int) (*dslNode, error) {
+	if !dslID.MatchString(id) {
+		return nil, fmt.Errorf("invalid ID %q, use letters, digits, '_', '.' and '-'", id)
+	}
+	if n, ok := p.nodes[id]; ok {
+		return n, nil
+	}
+	if _, ok := p.groups[id]; ok {
+		return nil, fmt.Errorf("%q is a group declared on line %d, edges connect nodes", id, p.defined[id])
+	}
+
+	n := &dslNode{node: &Node{ID: id, Label: id, Shape: ShapeRectangle}, kind: "node"}
+	if len(p.open) > 0 {
+		n.node.Group = p.open[len(p.open)-1]
+	}
+	p.nodes[id] = n
+	p.defined[id] = line
+	p.g.Nodes = append(p.g.Nodes, n.node)
+	return n, nil
+}
+
+// group parses the start of a group: group, ID, optional label and attributes, then {
+func (p *dslParser) group(tokens []dslToken, line int) error {
+	if len(tokens) < 3 || tokens[len(tokens)-1].text != "{" || tokens[len(tokens)-1].quoted {
+		return fmt.Errorf("group needs an ID and must end with {")
+	}
+	id := tokens[1].text
+	if tokens[1].quoted || !dslID.MatchString(id) {
+		return fmt.Errorf("invalid group ID %q", id)
+	}
+	if _, ok := p.nodes[id]; ok {
+		return fmt.Errorf("%q is already a node declared on line %d", id, p.defined[id])
+	}
+	if _, ok := p.groups[id]; ok {
+		return fmt.Errorf("group %q is already declared on line %d", id, p.defined[id])
+	}
+	label, attrs, err := dslLabelAndAttrs(tokens[2:len(tokens)-1], line)
+	if err != nil {
+		return err
+	}
+
+	gr := &Group{ID: id, Label: id}
+	if label != nil {
+		gr.Label = *label
+	}
+	if len(p.open) > 0 {
+		gr.Parent = p.open[len(p.open)-1]
+	}
+	p.groups[id] = &dslGroup{group: gr, attrs: attrs}
+	p.defined[id] = line
+	p.g.Groups = append(p.g.Groups, gr)
+	p.open = append(p.open, id)
+	return nil
+}
+
+// edges parses a chain of edges such as "a -> b ..> c", followed by a label and attributes
+// that apply to every edge of the chain
+func (p *dslParser) edges(tokens []dslToken, line int) error {
+	ids := []string{tokens[0].text}
+	var ops []string
+	i := 1
+	for i+1 < len(tokens) && !tokens[i].quoted {
+		if _, ok := dslOperators[tokens[i].text]; !ok {
+			break
+		}
+		if tokens[i+1].quoted {
+			return fmt.Errorf("expected a node ID after %s", tokens[i].text)
+		}
+		ops = append(ops, tokens[i].text)
+		ids = append(ids, tokens[i+1].text)
+		i += 2
+	}
+	if i < len(tokens) && !tokens[i].quoted {
+		if _, ok := dslOperators[tokens[i].text]; ok {
+			return fmt.Errorf("expected a node ID after %s", tokens[i].text)
+		}
+	}
+
+	label, attrs, err := dslLabelAndAttrs(tokens[i:], line)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if _, err := p.declareNode(id, line); err != nil {
+			return err
+		}
+	}
+	for j, op := range ops {
+		style := dslOperators[op]
+		e := &Edge{From: ids[j], To: ids[j+1], StartArrowhead: style.start, EndArrowhead: style.end}
+		if style.dashed {
+			e.Style.StrokeStyle = "dashed"
+		}
+		if label != nil {
+			e.Label = *label
+		}
+		p.g.Edges = append(p.g.Edges, e)
+		p.edgeAttrs = append(p.edgeAttrs, attrs)
+	}
+	return nil
+}
+
+// resolve applies the look of each node's kind, then named styles and attributes in the order they were given
+func (p *dslParser) resolve() error {
+	for _, node := range p.g.Nodes {
+		n := p.nodes[node.ID]
+		kind := dslKinds[n.kind]
+		n.node.Shape = kind.shape
+		n.node.Style = kind.style
+		if err := p.apply(n.attrs, &n.node.Label, &n.node.Style, &n.node.Shape, nil); err != nil {
+			return err
+		}
+	}
+	for _, group := range p.g.Groups {
+		gr := p.groups[group.ID]
+		if err := p.apply(gr.attrs, &gr.group.Label, &gr.group.Style, nil, nil); err != nil {
+			return err
+		}
+	}
+	for i, e := range p.g.Edges {
+		if err := p.apply(p.edgeAttrs[i], &e.Label, &e.Style, nil, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply sets attributes on the label, style and shape of an element; shape is nil for groups and
+// edges, and edge is set for edges only
+func (p *dslParser) apply(attrs []dslAttr, label *string, style *Style, shape *Shape, edge *Edge) error {
+	for _, a := range attrs {
+		if a.key == "class" {
+			for _, name := range strings.Split(a.value, ",") {
+				named, ok := p.styles[name]
+				if !ok {
+					return fmt.Errorf("%w: line %d: unknown style %q", ErrInvalidDiagram, a.line, name)
+				}
+				if err := p.apply(named, label, style, shape, edge); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := applyDSLAttr(a, label, style, shape, edge); err != nil {
+			return fmt.Errorf("%w: line %d: %v", ErrInvalidDiagram, a.line, err)
+		}
+	}
+	return nil
+}
+
+// applyDSLAttr sets a single attribute
+func applyDSLAttr(a dslAttr, label *string, style *Style, shape *Shape, edge *Edge) error {
+	switch a.key {
+	case "label":
+		*label = a.value
+	case "fill", "stroke", "color":
+		if !dslColor.MatchString(a.value) {
+			return fmt.Errorf("invalid color %q for %s", a.value, a.key)
+		}
+		switch a.key {
+		case "fill":
+			style.BackgroundColor = a.value
+		case "stroke":
+			style.StrokeColor = a.value
+		default:
+			style.TextColor = a.value
+		}
+	case "width", "font":
+		v, err := strconv.ParseFloat(a.value, 64)
+		if err != nil || v <= 0 || v > 200 {
+			return fmt.Errorf("invalid %s %q, expected a positive number", a.key, a.value)
+		}
+		if a.key == "width" {
+			style.StrokeWidth = v
+		} else {
+			style.FontSize = v
+		}
+	case "dash":
+		if a.value != "solid" && a.value != "dashed" && a.value != "dotted" {
+			return fmt.Errorf("invalid dash %q, expected solid, dashed or dotted", a.value)
+		}
+		style.StrokeStyle = a.value
+	case "dashed", "dotted", "solid":
+		if a.value != "" {
+			return fmt.Errorf("%s takes no value", a.key)
+		}
+		style.StrokeStyle = a.key
+	case "shape":
+		if shape == nil {
+			return fmt.Errorf("shape only applies to nodes")
+		}
+		switch s := Shape(a.value); s {
+		case ShapeRectangle, ShapeRounded, ShapeEllipse, ShapeDiamond, ShapeText:
+			*shape = s
+		default:
+			return fmt.Errorf("invalid shape %q, expected rectangle, rounded, ellipse, diamond or text", a.value)
+		}
+	case "start", "end":
+		if edge == nil {
+			return fmt.Errorf("%s only applies to edges", a.key)
+		}
+		var head Arrowhead
+		switch h := Arrowhead(a.value); h {
+		case ArrowheadArrow, ArrowheadBar, ArrowheadCircle, ArrowheadTriangle, ArrowheadDiamond:
+			head = h
+		case "none":
+		default:
+			return fmt.Errorf("invalid arrowhead %q, expected none, arrow, bar, circle, triangle or diamond", a.value)
+		}
+		if a.key == "start" {
+			edge.StartArrowhead = head
+		} else {
+			edge.EndArrowhead = head
+		}
+	default:
+		return fmt.Errorf("unknown attribute %q", a.key)
+	}
+	return nil
+}
+
+// dslLabelAndAttrs reads an optional quoted label followed by attributes
+func dslLabelAndAttrs(tokens []dslToken, line int) (*string, []dslAttr, error) {
+	var label *string
+	if len(tokens) > 0 && tokens[0].quoted {
+		label = &tokens[0].text
+		tokens = tokens[1:]
+	}
+
+	attrs := make([]dslAttr, 0, len(tokens))
+	for _, t := range tokens {
+		if t.quoted {
+			return nil, nil, fmt.Errorf("unexpected label %q, the label comes first", t.text)
+		}
+		key, value, hasValue := strings.Cut(t.text, "=")
+		if key == "" || (hasValue && value == "" && !t.quotedValue) {
+			return nil, nil, fmt.Errorf("invalid attribute %q, expected key=value", t.text)
+		}
+		attrs = append(attrs, dslAttr{key: key, value: value, line: line})
+	}
+	return label, attrs, nil
+}
+
+// dslToken is a word, operator or brace of a statement, or a quoted label
+type dslToken struct {
+	text   string
+	quoted bool
+
+	// quotedValue is set for attributes whose value was quoted, such as label="a b"
+	quotedValue bool
+}
+
+// dslTokens splits a line into tokens, dropping the comment that ends it
+// Quoted strings may contain \" and \n escapes
+func dslTokens(line string) ([]dslToken, error) {
+	var tokens []dslToken
+	i := 0
+	for i < len(line) {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '#':
+			return tokens, nil
+		case c == '"':
+			text, next, err := dslQuoted(line, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, dslToken{text: text, quoted: true})
+			i = next
+		default:
+			start := i
+			for i < len(line) && line[i] != ' ' && line[i] != '\t' && line[i] != '\r' && line[i] != '"' {
+				i++
+			}
+			t := dslToken{text: line[start:i]}
+			// key="quoted value"
+			if strings.HasSuffix(t.text, "=") && i < len(line) && line[i] == '"' {
+				value, next, err := dslQuoted(line, i)
+				if err != nil {
+					return nil, err
+				}
+				t.text += value
+				t.quotedValue = true
+				i = next
+			}
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens, nil
+}
+
+// dslQuoted reads the quoted string starting at line[start], returning it unescaped and the index after it
+func dslQuoted(line string, start int) (string, int, error) {
+	var b strings.Builder
+	for i := start + 1; i < len(line); i++ {
+		switch c := line[i]; c {
+		case '"':
+			return b.String(), i + 1, nil
+		case '\\':
+			if i+1 < len(line) {
+				i++
+				if line[i] == 'n' {
+					b.WriteByte('\n')
+				} else {
+					b.WriteByte(line[i])
+				}
+				continue
+			}
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unclosed quote")
+}
@@ -0,0 +1,210 @@
+package diagram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+const dslArchitecture = `# Checkout platform
+title "Checkout"
+direction LR
+style critical stroke=#e03131 width=4
+
+group backend "Backend" {
+  service api "API Gateway"
+  database orders "Orders DB" class=critical
+}
+queue events "Order\nevents"
+external stripe "Stripe"
+
+api -> orders "SQL"
+api ..> events "publish" end=triangle
+events -> worker -> orders
+api <-> stripe dashed stroke=#1971c2
+`
+
+// reload round-trips a scene through JSON, as a stored drawing is loaded
+func reload(t *testing.T, data drawing.DrawingData) drawing.DrawingData {
+	t.Helper()
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded drawing.DrawingData
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+func TestCompileDSL(t *testing.T) {
+	res, err := CompileDSL(context.Background(), dslArchitecture, nil)
+	if err != nil {
+		t.Fatalf("CompileDSL failed: %v", err)
+	}
+	if res.Title != "Checkout" {
+		t.Errorf("expected the declared title, got %q", res.Title)
+	}
+	els := elementsByID(t, res)
+
+	api, orders, events, stripe, worker := els["node:api"], els["node:orders"], els["node:events"], els["node:stripe"], els["node:worker"]
+	if api["type"] != "rectangle" || api["roundness"] == nil || api["backgroundColor"] != "#e7f5ff" {
+		t.Errorf("expected a rounded, filled service, got %v %v %v", api["type"], api["roundness"], api["backgroundColor"])
+	}
+	if orders["type"] != "ellipse" || orders["strokeColor"] != "#e03131" || orders["strokeWidth"] != 4.0 {
+		t.Errorf("expected the critical style over the database look, got %v %v %v", orders["type"], orders["strokeColor"], orders["strokeWidth"])
+	}
+	if events["backgroundColor"] != "#fff9db" || els["node:events:text"]["text"] != "Order\nevents" {
+		t.Errorf("expected a queue with an escaped line break, got %v %v", events["backgroundColor"], els["node:events:text"]["text"])
+	}
+	if stripe["strokeStyle"] != "dashed" || worker == nil || els["node:worker:text"]["text"] != "worker" {
+		t.Errorf("expected a dashed external node and an implicit node labelled with its ID")
+	}
+
+	// Group boxes enclose their members
+	gx, gy, gw, gh := box(els["group:backend"])
+	for _, id := range []string{"node:api", "node:orders"} {
+		x, y, w, h := box(els[id])
+		if x < gx || y < gy || x+w > gx+gw || y+h > gy+gh {
+			t.Errorf("expected %s inside the backend group", id)
+		}
+	}
+	if els["group:backend:text"]["text"] != "Backend" {
+		t.Errorf("expected the group title")
+	}
+
+	publish := els["edge:api>events"]
+	if publish["strokeStyle"] != "dashed" || publish["endArrowhead"] != "triangle" || els["edge:api>events:text"]["text"] != "publish" {
+		t.Errorf("expected a dashed edge with a triangle and its label, got %v %v", publish["strokeStyle"], publish["endArrowhead"])
+	}
+	both := els["edge:api>stripe"]
+	if both["startArrowhead"] != "arrow" || both["endArrowhead"] != "arrow" || both["strokeColor"] != "#1971c2" {
+		t.Errorf("expected a two-way edge with its color, got %v %v %v", both["startArrowhead"], both["endArrowhead"], both["strokeColor"])
+	}
+	if els["edge:events>worker"] == nil || els["edge:worker>orders"] == nil {
+		t.Errorf("expected both edges of the chain")
+	}
+	if els["edge:api>orders"]["endBinding"].(map[string]interface{})["elementId"] != "node:orders" {
+		t.Errorf("expected the edge bound to its target")
+	}
+
+	// Shapes record where the layout put them
+	layout, ok := recordedLayout(api)
+	if x, y, _, _ := box(api); !ok || layout.X != x || layout.Y != y {
+		t.Errorf("expected the layout position in customData, got %v", api["customData"])
+	}
+}
+
+func TestCompileDSLUpsert(t *testing.T) {
+	first, err := CompileDSL(context.Background(), dslArchitecture, nil)
+	if err != nil {
+		t.Fatalf("CompileDSL failed: %v", err)
+	}
+
+	// The user moves a node, draws a note and binds an arrow of their own to a generated shape
+	els := elementsByID(t, first)
+	els["node:api"]["x"], els["node:api"]["y"] = 2000.0, 1500.0
+	els["node:orders"]["boundElements"] = append(els["node:orders"]["boundElements"].([]interface{}), map[string]interface{}{"id": "my-arrow", "type": "arrow"})
+	first.Data["elements"] = append(first.Data["elements"].([]interface{}),
+		map[string]interface{}{"id": "note", "type": "rectangle", "x": -200.0, "y": 0.0, "width": 100.0, "height": 50.0},
+		map[string]interface{}{"id": "my-arrow", "type": "arrow", "x": -100.0, "y": 0.0, "width": 100.0, "height": 0.0, "points": []interface{}{[]interface{}{0.0, 0.0}, []interface{}{100.0, 0.0}}},
+	)
+	first.Data["appState"] = map[string]interface{}{"viewBackgroundColor": "#f8f9fa"}
+	previous := reload(t, first.Data)
+
+	// The stripe node and its edge are removed, and a cache is added
+	second, err := CompileDSL(context.Background(), `direction LR
+group backend "Backend" {
+  service api "API Gateway"
+  database orders "Orders DB"
+  node cache "Cache"
+}
+queue events "Order events"
+api -> orders "SQL"
+api -> cache
+api ..> events "publish"
+events -> worker -> orders
+`, previous)
+	if err != nil {
+		t.Fatalf("CompileDSL upsert failed: %v", err)
+	}
+	updated := elementsByID(t, second)
+
+	if x, y, _, _ := box(updated["node:api"]); x != 2000 || y != 1500 {
+		t.Errorf("expected the moved node to stay where it was put, got (%v, %v)", x, y)
+	}
+	if tx, _, _, _ := box(updated["node:api:text"]); tx < 2000 {
+		t.Errorf("expected the label to follow the moved node, got x %v", tx)
+	}
+	start := updated["edge:api>cache"]["x"].(float64)
+	if start < 2000 {
+		t.Errorf("expected the edges of the moved node to start from it, got x %v", start)
+	}
+	if x, _, _, _ := box(updated["node:orders"]); x == 2000 {
+		t.Errorf("expected unmoved nodes to follow the layout")
+	}
+	if updated["node:cache"] == nil {
+		t.Errorf("expected the new node")
+	}
+
+	// Replaced elements have a higher version; removed ones are deleted
+	if updated["node:orders"]["version"] != int64(2) {
+		t.Errorf("expected the regenerated node at version 2, got %v", updated["node:orders"]["version"])
+	}
+	if stripe := updated["node:stripe"]; stripe == nil || stripe["isDeleted"] != true {
+		t.Errorf("expected the removed node as a deleted tombstone, got %v", stripe)
+	}
+	if edge := updated["edge:api>stripe"]; edge == nil || edge["isDeleted"] != true {
+		t.Errorf("expected the removed edge as a deleted tombstone")
+	}
+
+	// The user's elements, their bindings and the appState are kept
+	if updated["note"] == nil || updated["my-arrow"] == nil {
+		t.Errorf("expected the elements added by the user to be kept")
+	}
+	kept := false
+	for _, b := range updated["node:orders"]["boundElements"].([]interface{}) {
+		if b.(map[string]interface{})["id"] == "my-arrow" {
+			kept = true
+		}
+	}
+	if !kept {
+		t.Errorf("expected the user's arrow to stay bound to the regenerated shape")
+	}
+	if second.Data["appState"].(map[string]interface{})["viewBackgroundColor"] != "#f8f9fa" {
+		t.Errorf("expected the previous appState to be kept")
+	}
+}
+
+func TestCompileDSLErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"empty", "# nothing here\n"},
+		{"unknown statement", "box a"},
+		{"unclosed group", "group g {\n  node a"},
+		{"stray brace", "node a\n}"},
+		{"unclosed quote", `node a "A`},
+		{"unknown attribute", "node a size=3"},
+		{"invalid color", "node a fill=url(x)"},
+		{"unknown style", "node a class=missing"},
+		{"edge to a group", "group g {\n  node a\n}\nnode b\nb -> g"},
+		{"dangling operator", "a -> b ->"},
+		{"invalid ID", "node a:b"},
+		{"unknown direction", "direction up\nnode a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CompileDSL(context.Background(), tt.source, nil)
+			if !errors.Is(err, ErrInvalidDiagram) {
+				t.Errorf("expected ErrInvalidDiagram, got %v", err)
+			}
+		})
+	}
+}
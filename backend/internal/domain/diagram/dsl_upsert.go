@@ -0,0 +1,205 @@
+package diagram
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// layoutDataKey is the customData member in which generated shapes record the position the
+// layout gave them, so a later compilation can tell whether the user moved them
+const layoutDataKey = "layout"
+
+// movedTolerance is how far a shape may be from its recorded layout position without counting as moved
+const movedTolerance = 0.5
+
+// generatedPrefixes are the prefixes of the IDs of elements the compiler generates
+var generatedPrefixes = []string{nodeIDPrefix, edgeIDPrefix, groupIDPrefix}
+
+// isGenerated reports whether an element ID is one the compiler generates
+func isGenerated(id string) bool {
+	for _, prefix := range generatedPrefixes {
+		if strings.HasPrefix(id, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// pinMovedNodes keeps the nodes the user moved in the previous scene where they were put: group
+// boxes are refitted around them, and the edges touching them are drawn straight
+func pinMovedNodes(g *Graph, previous drawing.DrawingData) {
+	byID := elementMap(previous)
+	pinned := make(map[string]bool)
+
+	for _, n := range g.Nodes {
+		el := byID[NodeElementID(n.ID)]
+		if el == nil || el["isDeleted"] == true {
+			continue
+		}
+		x, okX := elementNumber(el, "x")
+		y, okY := elementNumber(el, "y")
+		if !okX || !okY {
+			continue
+		}
+		if layout, ok := recordedLayout(el); ok && math.Abs(x-layout.X) <= movedTolerance && math.Abs(y-layout.Y) <= movedTolerance {
+			continue
+		}
+		n.X, n.Y = x, y
+		pinned[n.ID] = true
+	}
+	if len(pinned) == 0 {
+		return
+	}
+
+	layoutGroups(g)
+	for _, e := range g.Edges {
+		if !pinned[e.From] && !pinned[e.To] {
+			continue
+		}
+		from, to := g.Node(e.From), g.Node(e.To)
+		if from == to {
+			e.Points, e.Curved = selfLoop(from), true
+			continue
+		}
+		e.Points, e.Curved = routeBetween(from, to, nil), false
+	}
+}
+
+// recordLayout records the layout position of each generated node shape in its customData
+func recordLayout(data drawing.DrawingData, layout map[string]Point) {
+	for id, el := range elementMap(data) {
+		if p, ok := layout[id]; ok {
+			el["customData"] = map[string]interface{}{
+				layoutDataKey: map[string]interface{}{"x": p.X, "y": p.Y},
+			}
+		}
+	}
+}
+
+// recordedLayout returns the layout position recorded in an element's customData
+func recordedLayout(el map[string]interface{}) (Point, bool) {
+	custom, _ := el["customData"].(map[string]interface{})
+	layout, _ := custom[layoutDataKey].(map[string]interface{})
+	x, okX := elementNumber(layout, "x")
+	y, okY := elementNumber(layout, "y")
+	return Point{x, y}, okX && okY
+}
+
+// mergeScene updates the previous scene with freshly generated elements
+// Generated elements replace their previous copy with a higher version, so editors holding the
+// old copy take the new one; generated elements no longer produced are kept as deleted
+// tombstones; elements the user added are kept above the generated ones, along with their
+// bindings to generated shapes. The previous appState and files are kept
+func mergeScene(previous, generated drawing.DrawingData) drawing.DrawingData {
+	prevElements := sceneElements(previous)
+	prevByID := elementMap(previous)
+	genElements := sceneElements(generated)
+	genIDs := make(map[string]bool, len(genElements))
+	for _, el := range genElements {
+		genIDs[el["id"].(string)] = true
+	}
+
+	// Elements added by the user, which keep their bindings to generated shapes
+	user := make(map[string]bool)
+	for _, el := range prevElements {
+		id, _ := el["id"].(string)
+		if !isGenerated(id) && el["isDeleted"] != true {
+			user[id] = true
+		}
+	}
+
+	elements := make([]interface{}, 0, len(prevElements)+len(genElements))
+	for _, el := range genElements {
+		id := el["id"].(string)
+		if prev := prevByID[id]; prev != nil {
+			version, _ := elementNumber(prev, "version")
+			next := int64(version) + 1
+			el["version"] = next
+			el["versionNonce"] = seed(id, "nonce"+strconv.FormatInt(next, 10))
+
+			bound, _ := prev["boundElements"].([]interface{})
+			for _, b := range bound {
+				ref, _ := b.(map[string]interface{})
+				if refID, _ := ref["id"].(string); user[refID] {
+					el["boundElements"] = append(el["boundElements"].([]interface{}), ref)
+				}
+			}
+		}
+		elements = append(elements, el)
+	}
+
+	for _, el := range prevElements {
+		id, _ := el["id"].(string)
+		switch {
+		case genIDs[id]:
+			continue
+		case isGenerated(id) && el["isDeleted"] != true:
+			tombstone := make(map[string]interface{}, len(el))
+			for k, v := range el {
+				tombstone[k] = v
+			}
+			version, _ := elementNumber(el, "version")
+			tombstone["isDeleted"] = true
+			tombstone["version"] = int64(version) + 1
+			tombstone["versionNonce"] = seed(id, "nonce"+strconv.FormatInt(int64(version)+1, 10))
+			elements = append(elements, tombstone)
+		default:
+			elements = append(elements, el)
+		}
+	}
+
+	merged := drawing.DrawingData{"elements": elements, "appState": generated["appState"], "files": generated["files"]}
+	if appState, ok := previous["appState"]; ok && appState != nil {
+		merged["appState"] = appState
+	}
+	if files, ok := previous["files"]; ok && files != nil {
+		merged["files"] = files
+	}
+	return merged
+}
+
+// sceneElements returns the elements of a scene that are JSON objects
+func sceneElements(data drawing.DrawingData) []map[string]interface{} {
+	items, _ := data["elements"].([]interface{})
+	elements := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		if el, ok := item.(map[string]interface{}); ok {
+			elements = append(elements, el)
+		}
+	}
+	return elements
+}
+
+// elementMap indexes the elements of a scene by ID
+func elementMap(data drawing.DrawingData) map[string]map[string]interface{} {
+	elements := sceneElements(data)
+	byID := make(map[string]map[string]interface{}, len(elements))
+	for _, el := range elements {
+		if id, ok := el["id"].(string); ok {
+			byID[id] = el
+		}
+	}
+	return byID
+}
+
+// elementNumber returns a numeric member of an element, decoded from JSON or set by the builder
+func elementNumber(el map[string]interface{}, key string) (float64, bool) {
+	return numberValue(el[key])
+}
+
+// numberValue returns a number decoded from JSON or set by the builder
+func numberValue(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, !math.IsNaN(v) && !math.IsInf(v, 0)
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,14 @@
+package diagram
+
+import "errors"
+
+var (
+	// ErrInvalidDiagram is returned when a diagram source cannot be parsed
+	ErrInvalidDiagram = errors.New("invalid diagram")
+
+	// ErrUnsupportedDiagram is returned when a diagram source is of a kind that cannot be converted
+	ErrUnsupportedDiagram = errors.New("unsupported diagram type")
+
+	// ErrDiagramTooLarge is returned when a diagram has more nodes or edges than can be laid out
+	ErrDiagramTooLarge = errors.New("diagram too large")
+)
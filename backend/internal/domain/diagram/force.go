@@ -0,0 +1,183 @@
+package diagram
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// MaxForceLayoutNodes limits the size of graphs the force-directed layout accepts, as every pair
+// of nodes repels each other in each iteration
+const MaxForceLayoutNodes = 500
+
+// Parameters of the force-directed layout
+const (
+	forceIterations = 300
+
+	// forceGravity pulls every node towards the center, so disconnected parts stay close
+	forceGravity = 0.05
+
+	// overlapIterations bounds the passes that push overlapping nodes apart once the forces settle
+	overlapIterations = 50
+)
+
+// ForceLayout positions the nodes of g as Fruchterman and Reingold's force-directed method does:
+// nodes repel each other while edges pull their ends together, starting from the current positions
+// and cooling down until the forces balance. Overlapping nodes are then pushed apart, and edges
+// are drawn straight between the borders of their nodes
+func ForceLayout(ctx context.Context, g *Graph) error {
+	if len(g.Nodes) > MaxForceLayoutNodes || len(g.Edges) > MaxLayoutEdges {
+		return fmt.Errorf("%w: at most %d nodes and %d edges can be laid out by force", ErrDiagramTooLarge, MaxForceLayoutNodes, MaxLayoutEdges)
+	}
+	if len(g.Nodes) == 0 {
+		return nil
+	}
+
+	// The ideal edge length fits the average node with room to spare
+	size := 0.0
+	for _, n := range g.Nodes {
+		size += math.Hypot(n.Width, n.Height)
+	}
+	k := size/float64(len(g.Nodes)) + nodeSpacing
+
+	centers := forceStart(g)
+	index := make(map[string]int, len(g.Nodes))
+	for i, n := range g.Nodes {
+		index[n.ID] = i
+	}
+
+	temperature := k * math.Sqrt(float64(len(g.Nodes)))
+	cooling := temperature / forceIterations
+	shift := make([]Point, len(g.Nodes))
+	for iter := 0; iter < forceIterations && temperature > 0; iter++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var cx, cy float64
+		for _, c := range centers {
+			cx, cy = cx+c.X, cy+c.Y
+		}
+		cx, cy = cx/float64(len(centers)), cy/float64(len(centers))
+
+		for i := range shift {
+			shift[i] = Point{(cx - centers[i].X) * forceGravity, (cy - centers[i].Y) * forceGravity}
+		}
+
+		// Every pair of nodes repels each other
+		for i := range centers {
+			for j := i + 1; j < len(centers); j++ {
+				dx, dy := centers[i].X-centers[j].X, centers[i].Y-centers[j].Y
+				d := math.Max(math.Hypot(dx, dy), 1)
+				f := k * k / d
+				shift[i].X, shift[i].Y = shift[i].X+dx/d*f, shift[i].Y+dy/d*f
+				shift[j].X, shift[j].Y = shift[j].X-dx/d*f, shift[j].Y-dy/d*f
+			}
+		}
+
+		// Edges pull their ends together
+		for _, e := range g.Edges {
+			i, okFrom := index[e.From]
+			j, okTo := index[e.To]
+			if !okFrom || !okTo || i == j {
+				continue
+			}
+			dx, dy := centers[i].X-centers[j].X, centers[i].Y-centers[j].Y
+			d := math.Max(math.Hypot(dx, dy), 1)
+			f := d * d / k
+			shift[i].X, shift[i].Y = shift[i].X-dx/d*f, shift[i].Y-dy/d*f
+			shift[j].X, shift[j].Y = shift[j].X+dx/d*f, shift[j].Y+dy/d*f
+		}
+
+		// Nodes move along their force, by no more than the temperature
+		for i := range centers {
+			length := math.Hypot(shift[i].X, shift[i].Y)
+			if length == 0 {
+				continue
+			}
+			step := math.Min(length, temperature)
+			centers[i].X += shift[i].X / length * step
+			centers[i].Y += shift[i].Y / length * step
+		}
+		temperature -= cooling
+	}
+
+	for i, n := range g.Nodes {
+		n.X, n.Y = centers[i].X-n.Width/2, centers[i].Y-n.Height/2
+	}
+	removeOverlaps(g.Nodes)
+
+	for _, e := range g.Edges {
+		from, to := g.Node(e.From), g.Node(e.To)
+		switch {
+		case from == nil || to == nil:
+			e.Points = nil
+		case from == to:
+			e.Points, e.Curved = selfLoop(from), true
+		default:
+			e.Points, e.Curved = routeBetween(from, to, nil), false
+		}
+	}
+
+	layoutGroups(g)
+	normalize(g)
+	return nil
+}
+
+// forceStart returns the starting centers of the nodes: their current centers, spread on a spiral
+// where several nodes share a position, so the layout is deterministic
+func forceStart(g *Graph) []Point {
+	centers := make([]Point, len(g.Nodes))
+	seen := make(map[Point]int, len(g.Nodes))
+	for i, n := range g.Nodes {
+		c := n.Center()
+		if count := seen[c]; count > 0 {
+			// The golden angle spreads the nodes of a spiral evenly
+			angle := float64(count) * math.Pi * (3 - math.Sqrt(5))
+			radius := nodeSpacing * math.Sqrt(float64(count))
+			seen[c]++
+			c = Point{c.X + radius*math.Cos(angle), c.Y + radius*math.Sin(angle)}
+		} else {
+			seen[c] = 1
+		}
+		centers[i] = c
+	}
+	return centers
+}
+
+// removeOverlaps pushes apart nodes closer than nodeSpacing/2 to each other, along the axis they
+// overlap least on
+func removeOverlaps(nodes []*Node) {
+	gap := float64(nodeSpacing) / 2
+	for iter := 0; iter < overlapIterations; iter++ {
+		moved := false
+		for i, a := range nodes {
+			for _, b := range nodes[i+1:] {
+				ox := math.Min(a.X+a.Width, b.X+b.Width) - math.Max(a.X, b.X) + gap
+				oy := math.Min(a.Y+a.Height, b.Y+b.Height) - math.Max(a.Y, b.Y) + gap
+				if ox <= 0 || oy <= 0 {
+					continue
+				}
+				moved = true
+
+				ac, bc := a.Center(), b.Center()
+				if ox < oy {
+					d := ox / 2
+					if ac.X < bc.X || (ac.X == bc.X && a.ID < b.ID) {
+						d = -d
+					}
+					a.X, b.X = a.X+d, b.X-d
+				} else {
+					d := oy / 2
+					if ac.Y < bc.Y || (ac.Y == bc.Y && a.ID < b.ID) {
+						d = -d
+					}
+					a.Y, b.Y = a.Y+d, b.Y-d
+				}
+			}
+		}
+		if !moved {
+			return
+		}
+	}
+}
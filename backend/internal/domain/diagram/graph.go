@@ -0,0 +1,180 @@
+// Package diagram converts diagrams described as graphs of nodes and edges, such as Mermaid
+// flowcharts, into Excalidraw scenes, laying them out automatically
+package diagram
+
+// Direction is the direction a layered layout flows in
+type Direction string
+
+const (
+	TopToBottom Direction = "TB"
+	BottomToTop Direction = "BT"
+	LeftToRight Direction = "LR"
+	RightToLeft Direction = "RL"
+)
+
+// Horizontal reports whether ranks are laid out from left to right or right to left
+func (d Direction) Horizontal() bool {
+	return d == LeftToRight || d == RightToLeft
+}
+
+// Shape is the outline a node is drawn with
+type Shape string
+
+const (
+	ShapeRectangle Shape = "rectangle"
+	ShapeRounded   Shape = "rounded"
+	ShapeEllipse   Shape = "ellipse"
+	ShapeDiamond   Shape = "diamond"
+
+	// ShapeText draws the label alone, without an outline
+	ShapeText Shape = "text"
+)
+
+// Arrowhead is the marker drawn at an end of an edge; empty for none
+type Arrowhead string
+
+const (
+	ArrowheadNone     Arrowhead = ""
+	ArrowheadArrow    Arrowhead = "arrow"
+	ArrowheadBar      Arrowhead = "bar"
+	ArrowheadCircle   Arrowhead = "circle"
+	ArrowheadTriangle Arrowhead = "triangle"
+	ArrowheadDiamond  Arrowhead = "diamond"
+)
+
+// Point is a position in scene coordinates
+type Point struct {
+	X, Y float64
+}
+
+// Style overrides the default look of a node, edge or group; empty fields keep the default
+type Style struct {
+	StrokeColor     string
+	BackgroundColor string
+	TextColor       string
+	StrokeWidth     float64
+	FontSize        float64
+
+	// StrokeStyle is "solid", "dashed" or "dotted"
+	StrokeStyle string
+}
+
+// merge returns s with the fields set in o replacing its own
+func (s Style) merge(o Style) Style {
+	if o.StrokeColor != "" {
+		s.StrokeColor = o.StrokeColor
+	}
+	if o.BackgroundColor != "" {
+		s.BackgroundColor = o.BackgroundColor
+	}
+	if o.TextColor != "" {
+		s.TextColor = o.TextColor
+	}
+	if o.StrokeWidth != 0 {
+		s.StrokeWidth = o.StrokeWidth
+	}
+	if o.FontSize != 0 {
+		s.FontSize = o.FontSize
+	}
+	if o.StrokeStyle != "" {
+		s.StrokeStyle = o.StrokeStyle
+	}
+	return s
+}
+
+// Node is a box of the diagram
+type Node struct {
+	ID    string
+	Label string
+	Shape Shape
+	Style Style
+
+	// Group is the ID of the innermost group containing the node; empty for none
+	Group string
+
+	// X and Y locate the top left corner; set by a layout unless the source positions nodes
+	X, Y          float64
+	Width, Height float64
+}
+
+// Center returns the center of the node
+func (n *Node) Center() Point {
+	return Point{n.X + n.Width/2, n.Y + n.Height/2}
+}
+
+// Edge is a connection between two nodes
+type Edge struct {
+	// ID identifies the edge in its source, if it has one
+	ID string
+
+	// From and To are the IDs of the nodes or groups joined; empty for an end left unconnected
+	From, To string
+	Label    string
+	Style    Style
+
+	StartArrowhead Arrowhead
+	EndArrowhead   Arrowhead
+
+	// Points is the route of the edge from the border of From to the border of To, set by a layout
+	// or read from the source
+	Points []Point
+
+	// Curved draws the route as a curve through its points instead of straight segments
+	Curved bool
+}
+
+// Group is a titled box around nodes, such as a Mermaid subgraph
+type Group struct {
+	ID    string
+	Label string
+	Style Style
+
+	// Parent is the ID of the enclosing group; empty for a top-level group
+	Parent string
+
+	// X, Y, Width and Height are the box around the members, set by a layout
+	X, Y          float64
+	Width, Height float64
+}
+
+// Graph is a diagram of nodes joined by edges, independent of the format it was read from
+type Graph struct {
+	Direction Direction
+	Nodes     []*Node
+	Edges     []*Edge
+	Groups    []*Group
+}
+
+// Node returns the node with the given ID, or nil
+func (g *Graph) Node(id string) *Node {
+	for _, n := range g.Nodes {
+		if n.ID == id {
+			return n
+		}
+	}
+	return nil
+}
+
+// Group returns the group with the given ID, or nil
+func (g *Graph) Group(id string) *Group {
+	for _, gr := range g.Groups {
+		if gr.ID == id {
+			return gr
+		}
+	}
+	return nil
+}
+
+// groupPath returns the IDs of the groups containing a group or node's group, innermost first
+func (g *Graph) groupPath(id string) []string {
+	var path []string
+	for id != "" && len(path) <= len(g.Groups) {
+		path = append(path, id)
+		gr := g.Group(id)
+		if gr == nil {
+			break
+		}
+		id = gr.Parent
+	}
+	return path
+}
@@ -0,0 +1,769 @@
+package diagram
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Limits on the size of graphs a layout accepts
+const (
+	MaxLayoutNodes = 1000
+	MaxLayoutEdges = 2000
+)
+
+// Spacing of layered layouts, in scene units
+const (
+	// nodeSpacing separates neighbouring nodes of a rank
+	nodeSpacing = 50
+
+	// rankSpacing separates consecutive ranks
+	rankSpacing = 80
+
+	// dummyBreadth is the room an edge passing through a rank takes in it
+	dummyBreadth = 10
+
+	// groupPadding is the space between a group's box and its members
+	groupPadding = 20
+
+	// groupTitleHeight is the room above a group's members for its title
+	groupTitleHeight = 30
+
+	// BindingGap is the distance between a bound arrow's end and the border of its node
+	BindingGap = 5
+)
+
+// Iterations of the layered layout's ordering and placement phases
+const (
+	orderingIterations  = 24
+	placementIterations = 8
+)
+
+// Limits on the work of a layered layout, which grows with the dummies of edges spanning many ranks
+const (
+	// maxLayerNodes limits the nodes and dummies of a layout
+	maxLayerNodes = 20000
+
+	// maxOrderingWork bounds the nodes and dummies sorted over all ordering iterations;
+	// larger layouts get fewer iterations
+	maxOrderingWork = 300000
+)
+
+// layerNode is a node of the layered layout: a graph node, or a dummy an edge spanning several
+// ranks passes through
+type layerNode struct {
+	node  *Node
+	rank  int
+	order int
+
+	// breadth and depth are the node's extents across and along the ranks
+	breadth, depth float64
+
+	// x is the center of the node across the ranks
+	x float64
+
+	// group is the outermost group of the node, which orders the members of a group together
+	group string
+
+	// groups are the IDs of every group containing the node
+	groups []string
+
+	up, down []*layerNode
+}
+
+// layerEdge is an edge of the layered layout, through its dummies
+type layerEdge struct {
+	edge     *Edge
+	chain    []*layerNode
+	reversed bool
+}
+
+// LayeredLayout positions the nodes of g in ranks along its direction, as Sugiyama's method does:
+// cycles are broken, nodes are ranked by their longest path from a source, the order within each
+// rank is chosen to limit edge crossings, and nodes are centered on their neighbours
+// Nodes must be sized; edges are routed through the ranks they span and groups are boxed around their members.
+// The layout stops with ctx's error once ctx is done
+func LayeredLayout(ctx context.Context, g *Graph) error {
+	if len(g.Nodes) > MaxLayoutNodes || len(g.Edges) > MaxLayoutEdges {
+		return fmt.Errorf("%w: at most %d nodes and %d edges can be laid out", ErrDiagramTooLarge, MaxLayoutNodes, MaxLayoutEdges)
+	}
+
+	l, err := newLayered(g)
+	if err != nil {
+		return err
+	}
+	if err := l.orderRanks(ctx); err != nil {
+		return err
+	}
+	if err := l.place(ctx); err != nil {
+		return err
+	}
+	l.separateGroups()
+	l.apply()
+	layoutGroups(g)
+	normalize(g)
+	return nil
+}
+
+// layered holds the state of a layered layout
+type layered struct {
+	g     *Graph
+	nodes map[string]*layerNode
+	edges []*layerEdge
+	loops []*Edge
+	ranks [][]*layerNode
+
+	// size is the number of nodes and dummies in the ranks
+	size int
+}
+
+// newLayered ranks the nodes of g and splits edges spanning several ranks with dummies
+// Graphs needing more than maxLayerNodes nodes and dummies are rejected
+func newLayered(g *Graph) (*layered, error) {
+	l := &layered{g: g, nodes: make(map[string]*layerNode, len(g.Nodes))}
+
+	var order []*layerNode
+	for _, n := range g.Nodes {
+		ln := &layerNode{node: n, breadth: n.Width, depth: n.Height}
+		if g.Direction.Horizontal() {
+			ln.breadth, ln.depth = n.Height, n.Width
+		}
+		if path := g.groupPath(n.Group); len(path) > 0 {
+			ln.group, ln.groups = path[len(path)-1], path
+		}
+		l.nodes[n.ID] = ln
+		order = append(order, ln)
+	}
+
+	var edges []*layerEdge
+	for _, e := range g.Edges {
+		from, to := l.nodes[e.From], l.nodes[e.To]
+		switch {
+		case from == nil || to == nil:
+			continue
+		case from == to:
+			l.loops = append(l.loops, e)
+			continue
+		}
+		edges = append(edges, &layerEdge{edge: e, chain: []*layerNode{from, to}})
+	}
+
+	reverseCycles(order, edges)
+	rank(order, edges)
+
+	maxRank := 0
+	for _, ln := range order {
+		maxRank = max(maxRank, ln.rank)
+	}
+	l.ranks = make([][]*layerNode, maxRank+1)
+	for _, ln := range order {
+		l.ranks[ln.rank] = append(l.ranks[ln.rank], ln)
+	}
+
+	// Each edge needs a dummy in every rank it crosses
+	l.size = len(order)
+	for _, le := range edges {
+		l.size += le.chain[1].rank - le.chain[0].rank - 1
+	}
+	if l.size > maxLayerNodes {
+		return nil, fmt.Errorf("%w: edges span too many ranks to be laid out", ErrDiagramTooLarge)
+	}
+
+	// Replace each edge by a chain through one dummy per rank it crosses
+	for _, le := range edges {
+		from, to := le.chain[0], le.chain[1]
+		chain := []*layerNode{from}
+		for r := from.rank + 1; r < to.rank; r++ {
+			dummy := &layerNode{rank: r, breadth: dummyBreadth}
+			l.ranks[r] = append(l.ranks[r], dummy)
+			chain = append(chain, dummy)
+		}
+		chain = append(chain, to)
+		for i := 0; i+1 < len(chain); i++ {
+			chain[i].down = append(chain[i].down, chain[i+1])
+			chain[i+1].up = append(chain[i+1].up, chain[i])
+		}
+		le.chain = chain
+	}
+	l.edges = edges
+
+	for _, nodes := range l.ranks {
+		for i, ln := range nodes {
+			ln.order = i
+		}
+	}
+	return l, nil
+}
+
+// reverseCycles makes the graph acyclic by reversing the edges that close a cycle in a depth-first search
+func reverseCycles(nodes []*layerNode, edges []*layerEdge) {
+	out := make(map[*layerNode][]*layerEdge)
+	for _, le := range edges {
+		out[le.chain[0]] = append(out[le.chain[0]], le)
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[*layerNode]int, len(nodes))
+	var visit func(n *layerNode)
+	visit = func(n *layerNode) {
+		state[n] = visiting
+		for _, le := range out[n] {
+			switch state[le.chain[1]] {
+			case visiting:
+				le.reversed = true
+			case unvisited:
+				visit(le.chain[1])
+			}
+		}
+		state[n] = visited
+	}
+	for _, n := range nodes {
+		if state[n] == unvisited {
+			visit(n)
+		}
+	}
+
+	for _, le := range edges {
+		if le.reversed {
+			le.chain[0], le.chain[1] = le.chain[1], le.chain[0]
+		}
+	}
+}
+
+// rank assigns each node the length of the longest path reaching it, then moves sources down
+// next to their first successor
+func rank(nodes []*layerNode, edges []*layerEdge) {
+	in := make(map[*layerNode]int, len(nodes))
+	out := make(map[*layerNode][]*layerNode, len(nodes))
+	for _, le := range edges {
+		in[le.chain[1]]++
+		out[le.chain[0]] = append(out[le.chain[0]], le.chain[1])
+	}
+
+	var queue, sorted []*layerNode
+	for _, n := range nodes {
+		if in[n] == 0 {
+			queue = append(queue, n)
+		}
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		sorted = append(sorted, n)
+		for _, m := range out[n] {
+			m.rank = max(m.rank, n.rank+1)
+			if in[m]--; in[m] == 0 {
+				queue = append(queue, m)
+			}
+		}
+	}
+
+	hasIn := make(map[*layerNode]bool, len(nodes))
+	for _, le := range edges {
+		hasIn[le.chain[1]] = true
+	}
+	for i := len(sorted) - 1; i >= 0; i-- {
+		n := sorted[i]
+		if hasIn[n] || len(out[n]) == 0 {
+			continue
+		}
+		closest := math.MaxInt
+		for _, m := range out[n] {
+			closest = min(closest, m.rank-1)
+		}
+		n.rank = closest
+	}
+}
+
+// orderRanks reorders the nodes of each rank by the barycenter of their neighbours, sweeping down
+// and up the ranks, and keeps the order with the fewest crossings
+// Layouts with many nodes and dummies get fewer sweeps, keeping within maxOrderingWork
+func (l *layered) orderRanks(ctx context.Context) error {
+	best := l.snapshot()
+	bestCrossings := l.crossings()
+
+	iterations := min(orderingIterations, maxOrderingWork/max(l.size, 1))
+	for i := 0; i < iterations && bestCrossings > 0; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if i%2 == 0 {
+			for r := 1; r < len(l.ranks); r++ {
+				l.sortRank(r, func(n *layerNode) []*layerNode { return n.up })
+			}
+		} else {
+			for r := len(l.ranks) - 2; r >= 0; r-- {
+				l.sortRank(r, func(n *layerNode) []*layerNode { return n.down })
+			}
+		}
+
+		if c := l.crossings(); c < bestCrossings {
+			best, bestCrossings = l.snapshot(), c
+		}
+	}
+
+	l.ranks = best
+	for _, nodes := range l.ranks {
+		for i, ln := range nodes {
+			ln.order = i
+		}
+	}
+	return nil
+}
+
+// sortRank orders rank r by the mean order of each node's neighbours, keeping groups together
+func (l *layered) sortRank(r int, neighbours func(*layerNode) []*layerNode) {
+	nodes := l.ranks[r]
+	bary := make(map[*layerNode]float64, len(nodes))
+	for _, n := range nodes {
+		adjacent := neighbours(n)
+		if len(adjacent) == 0 {
+			bary[n] = float64(n.order)
+			continue
+		}
+		sum := 0.0
+		for _, m := range adjacent {
+			sum += float64(m.order)
+		}
+		bary[n] = sum / float64(len(adjacent))
+	}
+
+	// Members of a group are ordered by the mean barycenter of the group, so they stay adjacent
+	groupSum := make(map[string]float64)
+	groupCount := make(map[string]float64)
+	for _, n := range nodes {
+		if n.group != "" {
+			groupSum[n.group] += bary[n]
+			groupCount[n.group]++
+		}
+	}
+	key := func(n *layerNode) float64 {
+		if n.group == "" {
+			return bary[n]
+		}
+		return groupSum[n.group] / groupCount[n.group]
+	}
+
+	sort.SliceStable(nodes, func(i, j int) bool {
+		ki, kj := key(nodes[i]), key(nodes[j])
+		if ki != kj {
+			return ki < kj
+		}
+		if nodes[i].group != nodes[j].group {
+			return nodes[i].group < nodes[j].group
+		}
+		return bary[nodes[i]] < bary[nodes[j]]
+	})
+	for i, n := range nodes {
+		n.order = i
+	}
+}
+
+// snapshot copies the current order of every rank
+func (l *layered) snapshot() [][]*layerNode {
+	ranks := make([][]*layerNode, len(l.ranks))
+	for r, nodes := range l.ranks {
+		ranks[r] = append([]*layerNode(nil), nodes...)
+	}
+	return ranks
+}
+
+// crossings counts the pairs of edges crossing between consecutive ranks
+// With the edges sorted by their upper ends, two edges cross when their lower ends are inverted;
+// inversions are counted with a Fenwick tree over the lower rank
+func (l *layered) crossings() int {
+	total := 0
+	for r := 0; r+1 < len(l.ranks); r++ {
+		var pairs [][2]int
+		for _, n := range l.ranks[r] {
+			for _, m := range n.down {
+				pairs = append(pairs, [2]int{n.order, m.order})
+			}
+		}
+		sort.Slice(pairs, func(i, j int) bool {
+			if pairs[i][0] != pairs[j][0] {
+				return pairs[i][0] < pairs[j][0]
+			}
+			return pairs[i][1] < pairs[j][1]
+		})
+
+		tree := make([]int, len(l.ranks[r+1])+1)
+		for i, p := range pairs {
+			// Edges already seen whose lower end is right of this one cross it
+			seen := 0
+			for k := p[1] + 1; k > 0; k -= k & -k {
+				seen += tree[k]
+			}
+			total += i - seen
+			for k := p[1] + 1; k < len(tree); k += k & -k {
+				tree[k]++
+			}
+		}
+	}
+	return total
+}
+
+// place positions the nodes across the ranks: packed first, then moved towards the median of
+// their neighbours without overlapping
+func (l *layered) place(ctx context.Context) error {
+	for _, nodes := range l.ranks {
+		x := 0.0
+		for i, n := range nodes {
+			if i > 0 {
+				x += separation(nodes[i-1], n)
+			}
+			n.x = x
+		}
+	}
+
+	for i := 0; i < placementIterations; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if i%2 == 0 {
+			for r := 1; r < len(l.ranks); r++ {
+				alignRank(l.ranks[r], func(n *layerNode) []*layerNode { return n.up })
+			}
+		} else {
+			for r := len(l.ranks) - 2; r >= 0; r-- {
+				alignRank(l.ranks[r], func(n *layerNode) []*layerNode { return n.down })
+			}
+		}
+	}
+	return nil
+}
+
+// separateGroups moves the nodes that are not members of a group out of the span its members
+// cover across the ranks, so group boxes drawn around the members do not enclose other nodes
+func (l *layered) separateGroups() {
+	for pass := 0; pass < 3; pass++ {
+		for _, gr := range l.g.Groups {
+			member := func(n *layerNode) bool { return containsGroup(n.groups, gr.ID) }
+
+			// Each group between a member and this one pads the box, and in horizontal layouts
+			// the titles are stacked across the ranks too
+			lo, hi := math.Inf(1), math.Inf(-1)
+			firstRank, lastRank := len(l.ranks), -1
+			for r, nodes := range l.ranks {
+				for _, n := range nodes {
+					if !member(n) {
+						continue
+					}
+					nesting := 0.0
+					for _, id := range n.groups {
+						nesting++
+						if id == gr.ID {
+							break
+						}
+					}
+					before, after := nesting*groupPadding, nesting*groupPadding
+					if l.g.Direction.Horizontal() {
+						before += nesting * groupTitleHeight
+					}
+					lo, hi = math.Min(lo, n.x-n.breadth/2-before), math.Max(hi, n.x+n.breadth/2+after)
+					firstRank, lastRank = min(firstRank, r), max(lastRank, r)
+				}
+			}
+
+			for r := firstRank; r <= lastRank; r++ {
+				nodes := l.ranks[r]
+				first, last := len(nodes), -1
+				for i, n := range nodes {
+					if member(n) {
+						first, last = min(first, i), max(last, i)
+					}
+				}
+				if last < 0 {
+					// No member in this rank: split the others around the middle of the group
+					first = sort.Search(len(nodes), func(i int) bool { return nodes[i].x >= (lo+hi)/2 })
+					last = first - 1
+				}
+
+				limit := lo - nodeSpacing/2
+				for i := first - 1; i >= 0; i-- {
+					if member(nodes[i]) {
+						continue
+					}
+					nodes[i].x = math.Min(nodes[i].x, limit-nodes[i].breadth/2)
+					limit = nodes[i].x - nodes[i].breadth/2 - nodeSpacing
+				}
+				limit = hi + nodeSpacing/2
+				for i := last + 1; i < len(nodes); i++ {
+					if member(nodes[i]) {
+						continue
+					}
+					nodes[i].x = math.Max(nodes[i].x, limit+nodes[i].breadth/2)
+					limit = nodes[i].x + nodes[i].breadth/2 + nodeSpacing
+				}
+			}
+		}
+	}
+}
+
+// separation returns the distance between the centers of neighbouring nodes a and b
+func separation(a, b *layerNode) float64 {
+	gap := float64(nodeSpacing)
+	if a.node == nil && b.node == nil {
+		gap /= 2
+	}
+	return (a.breadth+b.breadth)/2 + gap
+}
+
+// alignRank moves the nodes of a rank towards the median position of their neighbours
+// The desired positions are packed from the left and from the right, and the two averaged,
+// which keeps the order and the separation of the nodes
+func alignRank(nodes []*layerNode, neighbours func(*layerNode) []*layerNode) {
+	if len(nodes) == 0 {
+		return
+	}
+
+	desired := make([]float64, len(nodes))
+	for i, n := range nodes {
+		desired[i] = n.x
+		if xs := positions(neighbours(n)); len(xs) > 0 {
+			desired[i] = median(xs)
+		}
+	}
+
+	left := make([]float64, len(nodes))
+	right := make([]float64, len(nodes))
+	for i := range nodes {
+		left[i] = desired[i]
+		if i > 0 {
+			left[i] = math.Max(desired[i], left[i-1]+separation(nodes[i-1], nodes[i]))
+		}
+	}
+	for i := len(nodes) - 1; i >= 0; i-- {
+		right[i] = desired[i]
+		if i < len(nodes)-1 {
+			right[i] = math.Min(desired[i], right[i+1]-separation(nodes[i], nodes[i+1]))
+		}
+	}
+	for i, n := range nodes {
+		n.x = (left[i] + right[i]) / 2
+	}
+}
+
+// positions returns the positions of nodes across the ranks
+func positions(nodes []*layerNode) []float64 {
+	xs := make([]float64, len(nodes))
+	for i, n := range nodes {
+		xs[i] = n.x
+	}
+	return xs
+}
+
+// median returns the median of values
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// apply writes the layout back to the graph: node positions, and edge routes through their dummies
+func (l *layered) apply() {
+	// Each rank is as deep as its deepest node; ranks joined by labelled edges leave room for the labels
+	rankCenters := make([]float64, len(l.ranks))
+	y := 0.0
+	for r, nodes := range l.ranks {
+		depth := 0.0
+		for _, n := range nodes {
+			depth = math.Max(depth, n.depth)
+		}
+		if r > 0 {
+			y += rankSpacing + l.labelRoom(r-1)
+		}
+		rankCenters[r] = y + depth/2
+		y += depth
+	}
+
+	toScene := func(x, y float64) Point {
+		switch l.g.Direction {
+		case BottomToTop:
+			return Point{x, -y}
+		case LeftToRight:
+			return Point{y, x}
+		case RightToLeft:
+			return Point{-y, x}
+		default:
+			return Point{x, y}
+		}
+	}
+
+	for _, ln := range l.nodes {
+		c := toScene(ln.x, rankCenters[ln.rank])
+		ln.node.X, ln.node.Y = c.X-ln.node.Width/2, c.Y-ln.node.Height/2
+	}
+
+	for _, le := range l.edges {
+		points := make([]Point, len(le.chain))
+		for i, n := range le.chain {
+			points[i] = toScene(n.x, rankCenters[n.rank])
+		}
+		if le.reversed {
+			for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+				points[i], points[j] = points[j], points[i]
+			}
+		}
+		le.edge.Points = routeBetween(l.g.Node(le.edge.From), l.g.Node(le.edge.To), points[1:len(points)-1])
+
+		// Routes through several ranks are drawn as curves through their bends
+		le.edge.Curved = len(le.edge.Points) > 2
+	}
+
+	for _, e := range l.loops {
+		e.Points, e.Curved = selfLoop(l.g.Node(e.From)), true
+	}
+}
+
+// labelRoom returns the extra spacing after rank r for the labels of edges leaving it
+func (l *layered) labelRoom(r int) float64 {
+	room := 0.0
+	for _, n := range l.ranks[r] {
+		for _, le := range l.edges {
+			if le.chain[0] == n && le.edge.Label != "" {
+				w, h := measureText(le.edge.Label, edgeFontSize)
+				if l.g.Direction.Horizontal() {
+					h = w
+				}
+				room = math.Max(room, h)
+			}
+		}
+	}
+	return room
+}
+
+// routeBetween returns the route of an edge from the border of from, through via, to the border of to
+func routeBetween(from, to *Node, via []Point) []Point {
+	first, last := to.Center(), from.Center()
+	if len(via) > 0 {
+		first, last = via[0], via[len(via)-1]
+	}
+
+	points := []Point{Boundary(from, first)}
+	points = append(points, via...)
+	return append(points, Boundary(to, last))
+}
+
+// selfLoop returns the route of an edge from a node back to itself, around its right side
+func selfLoop(n *Node) []Point {
+	right := n.X + n.Width + BindingGap
+	top, bottom := n.Y+n.Height/4, n.Y+n.Height*3/4
+	return []Point{{right, top}, {right + 30, top}, {right + 30, bottom}, {right, bottom}}
+}
+
+// Boundary returns where the line from the center of n towards p leaves the node's outline,
+// moved out by BindingGap
+func Boundary(n *Node, p Point) Point {
+	c := n.Center()
+	dx, dy := p.X-c.X, p.Y-c.Y
+	length := math.Hypot(dx, dy)
+	if length == 0 || n.Width == 0 || n.Height == 0 {
+		return c
+	}
+
+	rx, ry := n.Width/2, n.Height/2
+	var t float64
+	switch n.Shape {
+	case ShapeEllipse:
+		t = 1 / math.Hypot(dx/rx, dy/ry)
+	case ShapeDiamond:
+		t = 1 / (math.Abs(dx)/rx + math.Abs(dy)/ry)
+	default:
+		t = math.Inf(1)
+		if dx != 0 {
+			t = rx / math.Abs(dx)
+		}
+		if dy != 0 {
+			t = math.Min(t, ry/math.Abs(dy))
+		}
+	}
+
+	t += BindingGap / length
+	return Point{c.X + dx*t, c.Y + dy*t}
+}
+
+// layoutGroups sizes each group's box around its members and nested groups, innermost first
+func layoutGroups(g *Graph) {
+	depth := make(map[string]int, len(g.Groups))
+	for _, gr := range g.Groups {
+		depth[gr.ID] = len(g.groupPath(gr.ID))
+	}
+	groups := append([]*Group(nil), g.Groups...)
+	sort.SliceStable(groups, func(i, j int) bool { return depth[groups[i].ID] > depth[groups[j].ID] })
+
+	for _, gr := range groups {
+		minX, minY := math.Inf(1), math.Inf(1)
+		maxX, maxY := math.Inf(-1), math.Inf(-1)
+		extend := func(x, y, w, h float64) {
+			minX, minY = math.Min(minX, x), math.Min(minY, y)
+			maxX, maxY = math.Max(maxX, x+w), math.Max(maxY, y+h)
+		}
+
+		for _, n := range g.Nodes {
+			if n.Group == gr.ID {
+				extend(n.X, n.Y, n.Width, n.Height)
+			}
+		}
+		for _, child := range g.Groups {
+			if child.Parent == gr.ID && child.Width > 0 {
+				extend(child.X, child.Y, child.Width, child.Height)
+			}
+		}
+		if math.IsInf(minX, 1) {
+			gr.X, gr.Y, gr.Width, gr.Height = 0, 0, 0, 0
+			continue
+		}
+
+		gr.X, gr.Y = minX-groupPadding, minY-groupPadding-groupTitleHeight
+		gr.Width = maxX - minX + 2*groupPadding
+		gr.Height = maxY - minY + 2*groupPadding + groupTitleHeight
+	}
+}
+
+// normalize moves the diagram so its top left corner is at the origin
+func normalize(g *Graph) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	for _, n := range g.Nodes {
+		minX, minY = math.Min(minX, n.X), math.Min(minY, n.Y)
+	}
+	for _, gr := range g.Groups {
+		if gr.Width > 0 {
+			minX, minY = math.Min(minX, gr.X), math.Min(minY, gr.Y)
+		}
+	}
+	for _, e := range g.Edges {
+		for _, p := range e.Points {
+			minX, minY = math.Min(minX, p.X), math.Min(minY, p.Y)
+		}
+	}
+	if math.IsInf(minX, 1) {
+		return
+	}
+
+	for _, n := range g.Nodes {
+		n.X, n.Y = n.X-minX, n.Y-minY
+	}
+	for _, gr := range g.Groups {
+		if gr.Width > 0 {
+			gr.X, gr.Y = gr.X-minX, gr.Y-minY
+		}
+	}
+	for _, e := range g.Edges {
+		for i := range e.Points {
+			e.Points[i].X -= minX
+			e.Points[i].Y -= minY
+		}
+	}
+}
@@ -0,0 +1,645 @@
+package diagram
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// Result is a diagram converted to drawing data
+type Result struct {
+	// Title is the title declared by the source, if any
+	Title string
+
+	Data drawing.DrawingData
+}
+
+// ConvertMermaid converts Mermaid source text into an Excalidraw scene
+// Flowcharts (flowchart and graph) are laid out in ranks along their direction; sequence diagrams
+// place participants in columns with messages flowing down their lifelines
+func ConvertMermaid(ctx context.Context, source string) (*Result, error) {
+	title, lines := mermaidLines(source)
+
+	header := -1
+	for i, line := range lines {
+		if line.text != "" {
+			header = i
+			break
+		}
+	}
+	if header < 0 {
+		return nil, fmt.Errorf("%w: source is empty", ErrInvalidDiagram)
+	}
+
+	keyword, rest, _ := strings.Cut(lines[header].text, " ")
+	body := lines[header+1:]
+
+	var (
+		data drawing.DrawingData
+		err  error
+	)
+	switch keyword {
+	case "flowchart", "graph", "flowchart-elk":
+		var g *Graph
+		g, err = parseFlowchart(strings.TrimSpace(rest), body)
+		if err != nil {
+			return nil, err
+		}
+		SizeNodes(g)
+		if err = LayeredLayout(ctx, g); err != nil {
+			return nil, err
+		}
+		data = Build(g)
+	case "sequenceDiagram":
+		var s *sequence
+		s, err = parseSequence(body)
+		if err != nil {
+			return nil, err
+		}
+		if title == "" {
+			title = s.title
+		}
+		data, err = s.build()
+	default:
+		return nil, fmt.Errorf("%w: %q diagrams are not supported, only flowchart, graph and sequenceDiagram", ErrUnsupportedDiagram, keyword)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{Title: title, Data: data}, nil
+}
+
+// mermaidLine is a statement of Mermaid source with the line it starts on
+type mermaidLine struct {
+	number int
+	text   string
+}
+
+// mermaidLines splits source into trimmed statements, one per line or semicolon, without comments,
+// directives and the front matter, whose title is returned
+func mermaidLines(source string) (title string, lines []mermaidLine) {
+	raw := strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n")
+
+	start := 0
+	for start < len(raw) && strings.TrimSpace(raw[start]) == "" {
+		start++
+	}
+	if start < len(raw) && strings.TrimSpace(raw[start]) == "---" {
+		for i := start + 1; i < len(raw); i++ {
+			line := strings.TrimSpace(raw[i])
+			if line == "---" {
+				start = i + 1
+				break
+			}
+			if value, ok := strings.CutPrefix(line, "title:"); ok {
+				title = unquote(strings.TrimSpace(value))
+			}
+		}
+	}
+
+	for i := start; i < len(raw); i++ {
+		line := strings.TrimSpace(raw[i])
+		if strings.HasPrefix(line, "%%") {
+			continue
+		}
+		for _, stmt := range splitStatements(line) {
+			lines = append(lines, mermaidLine{number: i + 1, text: stmt})
+		}
+	}
+	return title, lines
+}
+
+// splitStatements splits a line on the semicolons outside quotes and brackets
+func splitStatements(line string) []string {
+	var (
+		stmts []string
+		depth int
+		quote bool
+		start int
+	)
+	for i, r := range line {
+		switch {
+		case r == '"':
+			quote = !quote
+		case quote:
+		case r == '[' || r == '(' || r == '{':
+			depth++
+		case r == ']' || r == ')' || r == '}':
+			depth = max(depth-1, 0)
+		case r == ';' && depth == 0:
+			stmts = append(stmts, strings.TrimSpace(line[start:i]))
+			start = i + 1
+		}
+	}
+	if rest := strings.TrimSpace(line[start:]); rest != "" || len(stmts) == 0 {
+		stmts = append(stmts, rest)
+	}
+	return stmts
+}
+
+// flowchartShapes are the delimiters of node labels, longest first, with the shape each is drawn as
+var flowchartShapes = []struct {
+	open   string
+	closes []string
+	shape  Shape
+}{
+	{"(((", []string{")))"}, ShapeEllipse},
+	{"((", []string{"))"}, ShapeEllipse},
+	{"([", []string{"])"}, ShapeRounded},
+	{"[[", []string{"]]"}, ShapeRectangle},
+	{"[(", []string{")]"}, ShapeRounded},
+	{"[/", []string{"/]", `\]`}, ShapeRectangle},
+	{`[\`, []string{`\]`, "/]"}, ShapeRectangle},
+	{"{{", []string{"}}"}, ShapeRectangle},
+	{"{", []string{"}"}, ShapeDiamond},
+	{"(", []string{")"}, ShapeRounded},
+	{"[", []string{"]"}, ShapeRectangle},
+	{">", []string{"]"}, ShapeRectangle},
+}
+
+var (
+	// flowchartLabelledLink matches a link with its label inline, such as "-- yes -->"
+	flowchartLabelledLink = regexp.MustCompile(`^([<ox]?)(--|==|-\.)\s+(.+?)\s*(-{2,}|={2,}|\.+-)([>ox]?)`)
+
+	// flowchartLink matches a link such as "-->", "-.->", "==>", "<-->" or "---o"
+	flowchartLink = regexp.MustCompile(`^([<ox]?)(-{2,}|={2,}|-\.+-|~{3,})([>ox]?)`)
+
+	// flowchartLinkLabel matches the label of a link between pipes
+	flowchartLinkLabel = regexp.MustCompile(`^\s*\|([^|]*)\|`)
+
+	// subgraphTitled matches a subgraph declared with an ID and a title
+	subgraphTitled = regexp.MustCompile(`^(\S+?)\s*\[(.*)\]$`)
+
+	// lineBreak matches the HTML line breaks allowed in labels
+	lineBreak = regexp.MustCompile(`(?i)<br\s*/?>`)
+)
+
+// flowchart is the state of a flowchart being parsed
+type flowchart struct {
+	g        *Graph
+	groups   []string
+	declared map[string]bool
+
+	classDefs   map[string]Style
+	classes     map[string][]string
+	styles      map[string]Style
+	linkStyles  map[int]Style
+	defaultLink *Style
+}
+
+// parseFlowchart parses the statements of a flowchart whose header declared direction
+func parseFlowchart(direction string, lines []mermaidLine) (*Graph, error) {
+	f := &flowchart{
+		g:          &Graph{Direction: flowchartDirection(direction)},
+		declared:   make(map[string]bool),
+		classDefs:  make(map[string]Style),
+		classes:    make(map[string][]string),
+		styles:     make(map[string]Style),
+		linkStyles: make(map[int]Style),
+	}
+
+	for _, line := range lines {
+		if line.text == "" {
+			continue
+		}
+		if err := f.statement(line.text); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidDiagram, line.number, err)
+		}
+	}
+	if len(f.groups) > 0 {
+		return nil, fmt.Errorf("%w: subgraph %q is not closed with end", ErrInvalidDiagram, f.groups[len(f.groups)-1])
+	}
+
+	f.resolveGroupEndpoints()
+	f.applyStyles()
+	return f.g, nil
+}
+
+// flowchartDirection converts a Mermaid direction, TB by default
+func flowchartDirection(d string) Direction {
+	switch strings.ToUpper(d) {
+	case "BT":
+		return BottomToTop
+	case "LR":
+		return LeftToRight
+	case "RL":
+		return RightToLeft
+	default:
+		return TopToBottom
+	}
+}
+
+// statement parses a single flowchart statement
+func (f *flowchart) statement(stmt string) error {
+	keyword, rest, _ := strings.Cut(stmt, " ")
+	rest = strings.TrimSpace(rest)
+
+	switch keyword {
+	case "subgraph":
+		f.subgraph(rest)
+		return nil
+	case "end":
+		if len(f.groups) == 0 {
+			return fmt.Errorf("end without subgraph")
+		}
+		f.groups = f.groups[:len(f.groups)-1]
+		return nil
+	case "direction", "click", "accTitle:", "accDescr:", "accDescr":
+		// Subgraph directions are not supported by the layout; interactions and accessibility have no equivalent
+		return nil
+	case "classDef":
+		names, props, _ := strings.Cut(rest, " ")
+		style := parseMermaidStyle(props)
+		for _, name := range strings.Split(names, ",") {
+			f.classDefs[strings.TrimSpace(name)] = style
+		}
+		return nil
+	case "class":
+		ids, name, _ := strings.Cut(rest, " ")
+		for _, id := range strings.Split(ids, ",") {
+			id = strings.TrimSpace(id)
+			f.classes[id] = append(f.classes[id], strings.TrimSpace(name))
+		}
+		return nil
+	case "style":
+		id, props, _ := strings.Cut(rest, " ")
+		f.styles[id] = f.styles[id].merge(parseMermaidStyle(props))
+		return nil
+	case "linkStyle":
+		indexes, props, _ := strings.Cut(rest, " ")
+		style := parseMermaidStyle(props)
+		for _, index := range strings.Split(indexes, ",") {
+			if index == "default" {
+				f.defaultLink = &style
+				continue
+			}
+			if i, err := strconv.Atoi(strings.TrimSpace(index)); err == nil {
+				f.linkStyles[i] = f.linkStyles[i].merge(style)
+			}
+		}
+		return nil
+	}
+
+	return f.chain(stmt)
+}
+
+// subgraph opens a subgraph declared as "id", "id [title]", "\"title\"" or "title with spaces"
+func (f *flowchart) subgraph(decl string) {
+	id, label := decl, decl
+	switch {
+	case decl == "":
+		id = fmt.Sprintf("subgraph%d", len(f.g.Groups)+1)
+	case strings.HasPrefix(decl, `"`):
+		id, label = fmt.Sprintf("subgraph%d", len(f.g.Groups)+1), unquote(decl)
+	default:
+		if m := subgraphTitled.FindStringSubmatch(decl); m != nil {
+			id, label = m[1], unquote(strings.TrimSpace(m[2]))
+		}
+	}
+
+	if f.g.Group(id) == nil {
+		f.g.Groups = append(f.g.Groups, &Group{ID: id, Label: cleanLabel(label), Parent: f.currentGroup()})
+	}
+	f.groups = append(f.groups, id)
+}
+
+// currentGroup returns the ID of the innermost open subgraph, or empty
+func (f *flowchart) currentGroup() string {
+	if len(f.groups) == 0 {
+		return ""
+	}
+	return f.groups[len(f.groups)-1]
+}
+
+// chain parses nodes joined by links, such as "A & B --> C -- label --> D"
+func (f *flowchart) chain(stmt string) error {
+	s := stmt
+	previous, err := f.nodes(&s)
+	if err != nil {
+		return err
+	}
+	if len(previous) == 0 {
+		return fmt.Errorf("unexpected %q", stmt)
+	}
+
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return nil
+		}
+
+		edge, ok := parseFlowchartLink(&s)
+		if !ok {
+			return fmt.Errorf("unexpected %q", s)
+		}
+		next, err := f.nodes(&s)
+		if err != nil {
+			return err
+		}
+		if len(next) == 0 {
+			return fmt.Errorf("link without a target node")
+		}
+
+		if edge != nil {
+			for _, from := range previous {
+				for _, to := range next {
+					e := *edge
+					e.From, e.To = from, to
+					f.g.Edges = append(f.g.Edges, &e)
+				}
+			}
+		}
+		previous = next
+	}
+}
+
+// nodes parses one or more node references joined by &
+func (f *flowchart) nodes(s *string) ([]string, error) {
+	var ids []string
+	for {
+		id, err := f.node(s)
+		if err != nil {
+			return nil, err
+		}
+		if id == "" {
+			return ids, nil
+		}
+		ids = append(ids, id)
+
+		rest := strings.TrimLeft(*s, " \t")
+		if !strings.HasPrefix(rest, "&") {
+			return ids, nil
+		}
+		*s = rest[1:]
+	}
+}
+
+// node parses a node reference, declaring the node with its shape and label if given
+func (f *flowchart) node(s *string) (string, error) {
+	rest := strings.TrimLeft(*s, " \t")
+
+	end := 0
+	for end < len(rest) {
+		c := rest[end]
+		if c == ' ' || c == '\t' || strings.IndexByte("[({>&;:|\"", c) >= 0 || startsLink(rest[end:]) {
+			break
+		}
+		end++
+	}
+	id := rest[:end]
+	rest = rest[end:]
+	if id == "" {
+		*s = rest
+		return "", nil
+	}
+
+	n := f.g.Node(id)
+	if n == nil {
+		n = &Node{ID: id, Label: id, Shape: ShapeRectangle}
+		f.g.Nodes = append(f.g.Nodes, n)
+	}
+	if n.Group == "" {
+		n.Group = f.currentGroup()
+	}
+
+	for _, shape := range flowchartShapes {
+		if !strings.HasPrefix(rest, shape.open) {
+			continue
+		}
+		label, after, ok := cutLabel(rest[len(shape.open):], shape.closes)
+		if !ok {
+			return "", fmt.Errorf("node %q: label is not closed", id)
+		}
+		n.Label, n.Shape = cleanLabel(label), shape.shape
+		f.declared[id] = true
+		rest = after
+		break
+	}
+
+	if classes, ok := strings.CutPrefix(rest, ":::"); ok {
+		end := strings.IndexAny(classes, " \t&;")
+		if end < 0 {
+			end = len(classes)
+		}
+		f.classes[id] = append(f.classes[id], classes[:end])
+		rest = classes[end:]
+	}
+
+	*s = rest
+	return id, nil
+}
+
+// startsLink reports whether s starts with a link rather than continuing a node ID
+func startsLink(s string) bool {
+	for _, prefix := range []string{"--", "==", "-.", "~~~", "<-", "<=", "<.."} {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// cutLabel returns the label before the first of closes, which may be quoted, and the text after it
+func cutLabel(s string, closes []string) (label, rest string, ok bool) {
+	trimmed := strings.TrimLeft(s, " ")
+	if strings.HasPrefix(trimmed, `"`) {
+		if end := strings.Index(trimmed[1:], `"`); end >= 0 {
+			after := strings.TrimLeft(trimmed[end+2:], " ")
+			for _, c := range closes {
+				if strings.HasPrefix(after, c) {
+					return trimmed[1 : end+1], after[len(c):], true
+				}
+			}
+		}
+	}
+
+	best := -1
+	var closing string
+	for _, c := range closes {
+		if i := strings.Index(s, c); i >= 0 && (best < 0 || i < best) {
+			best, closing = i, c
+		}
+	}
+	if best < 0 {
+		return "", s, false
+	}
+	return strings.TrimSpace(s[:best]), s[best+len(closing):], true
+}
+
+// parseFlowchartLink parses the link at the start of s and its label
+// Invisible links (~~~) return a nil edge
+func parseFlowchartLink(s *string) (*Edge, bool) {
+	var start, stroke, head, label string
+	if m := flowchartLabelledLink.FindStringSubmatch(*s); m != nil {
+		start, stroke, label, head = m[1], m[2], m[3], m[5]
+		*s = (*s)[len(m[0]):]
+	} else if m := flowchartLink.FindStringSubmatch(*s); m != nil {
+		start, stroke, head = m[1], m[2], m[3]
+		*s = (*s)[len(m[0]):]
+	} else {
+		return nil, false
+	}
+
+	if m := flowchartLinkLabel.FindStringSubmatch(*s); m != nil {
+		label = m[1]
+		*s = (*s)[len(m[0]):]
+	}
+
+	if strings.HasPrefix(stroke, "~") {
+		return nil, true
+	}
+
+	e := &Edge{
+		Label:          cleanLabel(unquote(strings.TrimSpace(label))),
+		StartArrowhead: mermaidArrowhead(start),
+		EndArrowhead:   mermaidArrowhead(head),
+	}
+	switch {
+	case strings.HasPrefix(stroke, "="):
+		e.Style.StrokeWidth = 4
+	case strings.Contains(stroke, "."):
+		e.Style.StrokeStyle = "dashed"
+	}
+	return e, true
+}
+
+// mermaidArrowhead converts the marker at an end of a Mermaid link
+func mermaidArrowhead(marker string) Arrowhead {
+	switch marker {
+	case "<", ">":
+		return ArrowheadArrow
+	case "o":
+		return ArrowheadCircle
+	case "x":
+		return ArrowheadBar
+	default:
+		return ArrowheadNone
+	}
+}
+
+// resolveGroupEndpoints redirects links to or from a subgraph to its first node, as the
+// subgraph's ID was read as an undeclared node
+func (f *flowchart) resolveGroupEndpoints() {
+	for _, gr := range f.g.Groups {
+		n := f.g.Node(gr.ID)
+		if n == nil || f.declared[gr.ID] {
+			continue
+		}
+
+		var member *Node
+		for _, candidate := range f.g.Nodes {
+			if candidate != n && containsGroup(f.g.groupPath(candidate.Group), gr.ID) {
+				member = candidate
+				break
+			}
+		}
+		if member == nil {
+			continue
+		}
+
+		for _, e := range f.g.Edges {
+			if e.From == gr.ID {
+				e.From = member.ID
+			}
+			if e.To == gr.ID {
+				e.To = member.ID
+			}
+		}
+		f.removeNode(gr.ID)
+	}
+}
+
+// removeNode removes the node with the given ID
+func (f *flowchart) removeNode(id string) {
+	for i, n := range f.g.Nodes {
+		if n.ID == id {
+			f.g.Nodes = append(f.g.Nodes[:i], f.g.Nodes[i+1:]...)
+			return
+		}
+	}
+}
+
+// containsGroup reports whether path contains the group id
+func containsGroup(path []string, id string) bool {
+	for _, p := range path {
+		if p == id {
+			return true
+		}
+	}
+	return false
+}
+
+// applyStyles applies the default class, the classes and the style statements of each node and group,
+// and the link styles of each edge
+func (f *flowchart) applyStyles() {
+	for _, n := range f.g.Nodes {
+		classes, ok := f.classes[n.ID]
+		if !ok {
+			classes = []string{"default"}
+		}
+		for _, class := range classes {
+			n.Style = n.Style.merge(f.classDefs[class])
+		}
+		n.Style = n.Style.merge(f.styles[n.ID])
+	}
+	for _, gr := range f.g.Groups {
+		for _, class := range f.classes[gr.ID] {
+			gr.Style = gr.Style.merge(f.classDefs[class])
+		}
+		gr.Style = gr.Style.merge(f.styles[gr.ID])
+	}
+	for i, e := range f.g.Edges {
+		if f.defaultLink != nil {
+			e.Style = e.Style.merge(*f.defaultLink)
+		}
+		e.Style = e.Style.merge(f.linkStyles[i])
+	}
+}
+
+// parseMermaidStyle parses CSS-like style properties such as "fill:#f9f,stroke:#333,stroke-width:4px"
+func parseMermaidStyle(props string) Style {
+	var s Style
+	for _, prop := range strings.Split(strings.TrimSuffix(strings.TrimSpace(props), ";"), ",") {
+		name, value, ok := strings.Cut(prop, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "!important"))
+		switch strings.TrimSpace(name) {
+		case "fill":
+			s.BackgroundColor = value
+		case "stroke":
+			s.StrokeColor = value
+		case "color":
+			s.TextColor = value
+		case "stroke-width":
+			if w, err := strconv.ParseFloat(strings.TrimSuffix(value, "px"), 64); err == nil && w > 0 {
+				s.StrokeWidth = w
+			}
+		case "stroke-dasharray":
+			s.StrokeStyle = "dashed"
+		}
+	}
+	return s
+}
+
+// cleanLabel converts the line breaks of a Mermaid label to newlines and drops markdown quoting
+func cleanLabel(label string) string {
+	label = lineBreak.ReplaceAllString(label, "\n")
+	label = strings.Trim(label, "`")
+	label = strings.ReplaceAll(label, "#quot;", `"`)
+	return label
+}
+
+// unquote removes the double quotes around s, if any
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
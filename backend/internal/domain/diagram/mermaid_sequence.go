@@ -0,0 +1,591 @@
+package diagram
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// Limits on the size of sequence diagrams
+const (
+	MaxSequenceParticipants = 200
+	MaxSequenceSteps        = 5000
+)
+
+// Spacing of sequence diagrams, in scene units
+const (
+	participantSpacing  = 50
+	minParticipantWidth = 150
+	participantHeight   = 60
+
+	// stepSpacing separates consecutive messages and notes
+	stepSpacing = 20
+
+	// messageLabelGap is the space between a message's arrow and its label
+	messageLabelGap = 4
+
+	// selfMessageWidth is how far a message from a participant to itself loops out
+	selfMessageWidth = 40
+
+	activationWidth = 12
+	blockPadding    = 20
+	notePadding     = 10
+	noteColor       = "#fff3bf"
+	rectBlockColor  = "#e7f5ff"
+)
+
+var (
+	// sequenceMessage matches a message such as "Alice->>+Bob: Hello"
+	sequenceMessage = regexp.MustCompile(`^(.+?)\s*(<<-->>|<<->>|-->>|->>|--x|-x|--\)|-\)|-->|->)\s*([+-]?)\s*([^:]+?)\s*(?::(.*))?$`)
+
+	// sequenceNote matches a note such as "Note right of Alice: text" or "Note over Alice,Bob: text"
+	sequenceNote = regexp.MustCompile(`(?i)^note\s+(left of|right of|over)\s+([^:]+?)\s*:(.*)$`)
+
+	// sequenceParticipant matches a participant declaration such as "participant A as Alice"
+	sequenceParticipant = regexp.MustCompile(`^(?:create\s+)?(participant|actor)\s+(.+?)(?:\s+as\s+(.+))?$`)
+)
+
+// sequence is a parsed sequence diagram
+type sequence struct {
+	title        string
+	participants []*participant
+	steps        []step
+	autonumber   bool
+}
+
+// participant is a column of a sequence diagram
+type participant struct {
+	id    string
+	label string
+	actor bool
+
+	// center and width are set by the layout
+	center, width float64
+}
+
+// stepKind identifies the kind of a step of a sequence diagram
+type stepKind int
+
+const (
+	stepMessage stepKind = iota
+	stepNote
+	stepBlockStart
+	stepBlockDivider
+	stepBlockEnd
+	stepActivate
+	stepDeactivate
+)
+
+// step is a statement of a sequence diagram, drawn top to bottom in order
+type step struct {
+	kind  stepKind
+	label string
+
+	// from and to are participant indexes: the ends of a message, or the span of a note
+	from, to int
+
+	// arrow is the Mermaid arrow of a message, and activation its +/- suffix
+	arrow      string
+	activation string
+
+	// position is where a note is placed: "left of", "right of" or "over"
+	position string
+
+	// block is the keyword of a block start, such as "loop" or "alt"
+	block string
+}
+
+// parseSequence parses the statements of a sequence diagram
+func parseSequence(lines []mermaidLine) (*sequence, error) {
+	s := &sequence{}
+	depth := 0
+
+	for _, line := range lines {
+		text := line.text
+		if text == "" {
+			continue
+		}
+		if err := s.statement(text, &depth); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidDiagram, line.number, err)
+		}
+		if len(s.participants) > MaxSequenceParticipants || len(s.steps) > MaxSequenceSteps {
+			return nil, fmt.Errorf("%w: at most %d participants and %d statements are supported", ErrDiagramTooLarge, MaxSequenceParticipants, MaxSequenceSteps)
+		}
+	}
+	if depth > 0 {
+		return nil, fmt.Errorf("%w: block is not closed with end", ErrInvalidDiagram)
+	}
+
+	return s, nil
+}
+
+// statement parses a single sequence diagram statement
+func (s *sequence) statement(text string, depth *int) error {
+	keyword, rest, _ := strings.Cut(text, " ")
+	rest = strings.TrimSpace(rest)
+
+	switch strings.ToLower(keyword) {
+	case "title", "title:":
+		s.title = strings.TrimSpace(strings.TrimPrefix(rest, ":"))
+		return nil
+	case "autonumber":
+		s.autonumber = rest != "off"
+		return nil
+	case "loop", "alt", "opt", "par", "critical", "break", "rect", "box":
+		*depth++
+		s.steps = append(s.steps, step{kind: stepBlockStart, block: strings.ToLower(keyword), label: rest})
+		return nil
+	case "else", "and", "option":
+		if *depth == 0 {
+			return fmt.Errorf("%s outside a block", keyword)
+		}
+		s.steps = append(s.steps, step{kind: stepBlockDivider, label: rest})
+		return nil
+	case "end":
+		if *depth == 0 {
+			return fmt.Errorf("end without a block")
+		}
+		*depth--
+		s.steps = append(s.steps, step{kind: stepBlockEnd})
+		return nil
+	case "activate", "deactivate":
+		kind := stepActivate
+		if strings.EqualFold(keyword, "deactivate") {
+			kind = stepDeactivate
+		}
+		s.steps = append(s.steps, step{kind: kind, from: s.participant(rest, false)})
+		return nil
+	case "destroy", "links", "link", "properties", "details", "accTitle:", "accDescr:":
+		return nil
+	}
+
+	if m := sequenceParticipant.FindStringSubmatch(text); m != nil {
+		i := s.participant(m[2], m[1] == "actor")
+		if m[3] != "" {
+			s.participants[i].label = cleanLabel(strings.TrimSpace(m[3]))
+		}
+		return nil
+	}
+
+	if m := sequenceNote.FindStringSubmatch(text); m != nil {
+		names := strings.Split(m[2], ",")
+		from := s.participant(names[0], false)
+		to := from
+		if len(names) > 1 {
+			to = s.participant(names[1], false)
+		}
+		s.steps = append(s.steps, step{
+			kind:     stepNote,
+			label:    cleanLabel(strings.TrimSpace(m[3])),
+			from:     min(from, to),
+			to:       max(from, to),
+			position: strings.ToLower(m[1]),
+		})
+		return nil
+	}
+
+	if m := sequenceMessage.FindStringSubmatch(text); m != nil {
+		s.steps = append(s.steps, step{
+			kind:       stepMessage,
+			from:       s.participant(m[1], false),
+			to:         s.participant(m[4], false),
+			arrow:      m[2],
+			activation: m[3],
+			label:      cleanLabel(strings.TrimSpace(m[5])),
+		})
+		return nil
+	}
+
+	return fmt.Errorf("unexpected %q", text)
+}
+
+// participant returns the index of the participant with the given ID, adding it if it is new
+func (s *sequence) participant(id string, actor bool) int {
+	id = strings.TrimSpace(id)
+	for i, p := range s.participants {
+		if p.id == id {
+			p.actor = p.actor || actor
+			return i
+		}
+	}
+	s.participants = append(s.participants, &participant{id: id, label: id, actor: actor})
+	return len(s.participants) - 1
+}
+
+// sequenceBlock is a block being laid out
+type sequenceBlock struct {
+	step     step
+	top      float64
+	from, to int
+	dividers []sequenceDivider
+
+	// depth is the number of enclosing blocks, by which the block is inset
+	depth int
+}
+
+// sequenceDivider is a section of a block, such as the else branch of an alt block
+type sequenceDivider struct {
+	y     float64
+	label string
+}
+
+// build lays out the diagram and converts it to drawing data: participants become boxes at the top
+// and bottom of dashed lifelines, messages arrows between the lifelines, notes yellow boxes, and
+// blocks dashed boxes around their steps
+func (s *sequence) build() (drawing.DrawingData, error) {
+	if len(s.participants) == 0 {
+		return nil, fmt.Errorf("%w: sequence diagram has no participants", ErrInvalidDiagram)
+	}
+
+	s.placeParticipants()
+	b := &builder{updated: time.Now().UnixMilli(), ids: make(map[string]bool)}
+
+	// Blocks are drawn behind the steps they contain, so their boxes are added first once their extent is known
+	var (
+		blocks     []*sequenceBlock
+		open       []*sequenceBlock
+		foreground []func()
+	)
+	activations := make(map[int][]float64)
+	number := 0
+	y := float64(participantHeight + stepSpacing*2)
+
+	touch := func(from, to int) {
+		for _, block := range open {
+			block.from, block.to = min(block.from, from), max(block.to, to)
+		}
+	}
+
+	for _, st := range s.steps {
+		switch st.kind {
+		case stepMessage:
+			label := st.label
+			if s.autonumber {
+				number++
+				label = strconv.Itoa(number) + ". " + label
+			}
+			_, h := measureText(label, edgeFontSize)
+			if label == "" {
+				h = 0
+			}
+			y += h + messageLabelGap
+			top := y
+			foreground = append(foreground, func() { s.message(b, st, label, top) })
+			touch(min(st.from, st.to), max(st.from, st.to))
+
+			if st.from == st.to {
+				y += stepSpacing + 5
+			}
+			switch st.activation {
+			case "+":
+				activations[st.to] = append(activations[st.to], y)
+			case "-":
+				foreground = append(foreground, s.deactivate(b, activations, st.from, y))
+			}
+			y += stepSpacing * 2
+		case stepNote:
+			x, width := s.notePlacement(st)
+			_, h := measureText(st.label, edgeFontSize)
+			h += 2 * notePadding
+			top := y
+			foreground = append(foreground, func() {
+				note := b.add(b.uniqueID(fmt.Sprintf("note:%d", len(b.elements))), "rectangle", x, top, width, h, map[string]interface{}{
+					"backgroundColor": noteColor,
+					"strokeWidth":     1,
+				})
+				b.text(note, st.label, edgeFontSize, Style{}, nil)
+			})
+			touch(st.from, st.to)
+			y += h + stepSpacing
+		case stepActivate:
+			activations[st.from] = append(activations[st.from], y)
+		case stepDeactivate:
+			foreground = append(foreground, s.deactivate(b, activations, st.from, y))
+		case stepBlockStart:
+			block := &sequenceBlock{step: st, top: y, depth: len(open), from: len(s.participants), to: -1}
+			open = append(open, block)
+			blocks = append(blocks, block)
+			_, h := measureText(st.block, edgeFontSize)
+			y += h + stepSpacing
+		case stepBlockDivider:
+			block := open[len(open)-1]
+			block.dividers = append(block.dividers, sequenceDivider{y: y, label: st.label})
+			_, h := measureText(st.label, edgeFontSize)
+			y += h + stepSpacing
+		case stepBlockEnd:
+			block := open[len(open)-1]
+			open = open[:len(open)-1]
+			s.closeBlock(block, y)
+			y += stepSpacing
+		}
+	}
+
+	for _, block := range blocks {
+		s.drawBlock(b, block)
+	}
+	for i, p := range s.participants {
+		for _, start := range activations[i] {
+			s.activation(b, p, start, y)
+		}
+	}
+	for _, draw := range foreground {
+		draw()
+	}
+
+	bottom := y + stepSpacing
+	for _, p := range s.participants {
+		s.lifeline(b, p, bottom)
+	}
+
+	return drawing.DrawingData{
+		"elements": b.elements,
+		"appState": map[string]interface{}{"viewBackgroundColor": "#ffffff", "gridSize": nil},
+		"files":    map[string]interface{}{},
+	}, nil
+}
+
+// placeParticipants sizes the participants and spaces their columns so message labels fit between them
+func (s *sequence) placeParticipants() {
+	for _, p := range s.participants {
+		w, _ := measureText(p.label, nodeFontSize)
+		p.width = math.Max(w+2*textPadding, minParticipantWidth)
+	}
+
+	for i, p := range s.participants {
+		p.center = p.width / 2
+		if i > 0 {
+			prev := s.participants[i-1]
+			p.center = prev.center + prev.width/2 + participantSpacing + p.width/2
+		}
+	}
+
+	for _, st := range s.steps {
+		if st.kind != stepMessage && st.kind != stepNote {
+			continue
+		}
+		w, _ := measureText(st.label, edgeFontSize)
+		left, right := min(st.from, st.to), max(st.from, st.to)
+		if left == right {
+			if st.kind == stepNote || right+1 >= len(s.participants) {
+				continue
+			}
+			right++
+			w += selfMessageWidth
+		}
+
+		need := w + 2*stepSpacing
+		if gap := s.participants[right].center - s.participants[left].center; gap < need {
+			for _, p := range s.participants[right:] {
+				p.center += need - gap
+			}
+		}
+	}
+}
+
+// message draws a message arrow at y, with its label above it
+func (s *sequence) message(b *builder, st step, label string, y float64) {
+	from, to := s.participants[st.from], s.participants[st.to]
+	x1, x2 := from.center, to.center
+
+	points := []interface{}{[]interface{}{0.0, 0.0}, []interface{}{x2 - x1, 0.0}}
+	width, height := math.Abs(x2-x1), 0.0
+	labelX := (x1 + x2) / 2
+	if st.from == st.to {
+		points = []interface{}{
+			[]interface{}{0.0, 0.0},
+			[]interface{}{float64(selfMessageWidth), 0.0},
+			[]interface{}{float64(selfMessageWidth), float64(stepSpacing)},
+			[]interface{}{0.0, float64(stepSpacing)},
+		}
+		width, height = selfMessageWidth, stepSpacing
+		labelX = x1 + selfMessageWidth
+	}
+
+	start, end := sequenceArrowheads(st.arrow)
+	props := map[string]interface{}{
+		"points":             points,
+		"lastCommittedPoint": nil,
+		"startBinding":       nil,
+		"endBinding":         nil,
+		"startArrowhead":     arrowhead(start),
+		"endArrowhead":       arrowhead(end),
+		"elbowed":            false,
+	}
+	if strings.HasPrefix(st.arrow, "--") || strings.HasPrefix(st.arrow, "<<--") {
+		props["strokeStyle"] = "dashed"
+	}
+	b.add(b.uniqueID(fmt.Sprintf("message:%d", len(b.elements))), "arrow", x1, y, width, height, props)
+
+	if label != "" {
+		w, h := measureText(label, edgeFontSize)
+		if st.from != st.to {
+			labelX -= w / 2
+		} else {
+			labelX += messageLabelGap
+		}
+		s.freeText(b, "message-label", label, labelX, y-h-messageLabelGap, w, h, "center")
+	}
+}
+
+// sequenceArrowheads returns the arrowheads of a Mermaid message arrow
+func sequenceArrowheads(arrow string) (start, end Arrowhead) {
+	switch {
+	case strings.HasPrefix(arrow, "<<"):
+		return ArrowheadArrow, ArrowheadArrow
+	case strings.HasSuffix(arrow, ">>"):
+		return ArrowheadNone, ArrowheadArrow
+	case strings.HasSuffix(arrow, "x"):
+		return ArrowheadNone, ArrowheadBar
+	case strings.HasSuffix(arrow, ")"):
+		return ArrowheadNone, ArrowheadTriangle
+	default:
+		return ArrowheadNone, ArrowheadNone
+	}
+}
+
+// notePlacement returns the horizontal extent of a note
+func (s *sequence) notePlacement(st step) (x, width float64) {
+	w, _ := measureText(st.label, edgeFontSize)
+	w += 2 * notePadding
+	from, to := s.participants[st.from], s.participants[st.to]
+
+	switch st.position {
+	case "left of":
+		return from.center - w - activationWidth, w
+	case "right of":
+		return from.center + activationWidth, w
+	default:
+		left, right := from.center-participantSpacing, to.center+participantSpacing
+		if right-left < w {
+			mid := (from.center + to.center) / 2
+			left, right = mid-w/2, mid+w/2
+		}
+		return left, right - left
+	}
+}
+
+// deactivate returns the drawing of the innermost activation of a participant, ending at y
+func (s *sequence) deactivate(b *builder, activations map[int][]float64, index int, y float64) func() {
+	stack := activations[index]
+	if len(stack) == 0 {
+		return func() {}
+	}
+	start := stack[len(stack)-1]
+	activations[index] = stack[:len(stack)-1]
+	p := s.participants[index]
+	return func() { s.activation(b, p, start, y) }
+}
+
+// activation draws the box of an activation on a participant's lifeline
+func (s *sequence) activation(b *builder, p *participant, top, bottom float64) {
+	b.add(b.uniqueID("activation:"+p.id), "rectangle", p.center-activationWidth/2, top, activationWidth, math.Max(bottom-top, stepSpacing), map[string]interface{}{
+		"backgroundColor": "#f1f3f5",
+		"strokeWidth":     1,
+	})
+}
+
+// closeBlock records where a block ends; a block without steps spans every participant
+func (s *sequence) closeBlock(block *sequenceBlock, y float64) {
+	if block.to < 0 {
+		block.from, block.to = 0, len(s.participants)-1
+	}
+	block.dividers = append(block.dividers, sequenceDivider{y: y})
+}
+
+// drawBlock draws the dashed box of a block, its label and the dividers between its sections
+func (s *sequence) drawBlock(b *builder, block *sequenceBlock) {
+	if block.step.block == "box" {
+		return
+	}
+
+	from, to := s.participants[block.from], s.participants[block.to]
+	inset := float64(block.depth) * blockPadding / 4
+	left := from.center - from.width/2 - blockPadding/2 + inset
+	right := to.center + to.width/2 + blockPadding/2 - inset
+	bottom := block.dividers[len(block.dividers)-1].y
+
+	props := map[string]interface{}{"strokeStyle": "dashed", "strokeWidth": 1}
+	if block.step.block == "rect" {
+		props = map[string]interface{}{"backgroundColor": rectBlockColor, "strokeColor": "transparent"}
+	}
+	box := b.add(b.uniqueID(fmt.Sprintf("block:%d", len(b.elements))), "rectangle", left, block.top, right-left, bottom-block.top, props)
+	if block.step.block == "rect" {
+		return
+	}
+
+	title := block.step.block
+	if block.step.label != "" {
+		title += " [" + block.step.label + "]"
+	}
+	b.text(box, title, edgeFontSize, Style{}, map[string]interface{}{"textAlign": "left", "verticalAlign": "top"})
+
+	for _, divider := range block.dividers[:len(block.dividers)-1] {
+		b.add(b.uniqueID(fmt.Sprintf("divider:%d", len(b.elements))), "line", left, divider.y, right-left, 0, map[string]interface{}{
+			"points":             []interface{}{[]interface{}{0.0, 0.0}, []interface{}{right - left, 0.0}},
+			"lastCommittedPoint": nil,
+			"startBinding":       nil,
+			"endBinding":         nil,
+			"startArrowhead":     nil,
+			"endArrowhead":       nil,
+			"strokeStyle":        "dashed",
+			"strokeWidth":        1,
+		})
+		if divider.label != "" {
+			w, h := measureText("["+divider.label+"]", edgeFontSize)
+			s.freeText(b, "divider-label", "["+divider.label+"]", left+boundTextPadding, divider.y+boundTextPadding, w, h, "left")
+		}
+	}
+}
+
+// lifeline draws a participant's boxes at the top and bottom of the diagram, joined by a dashed line
+func (s *sequence) lifeline(b *builder, p *participant, bottom float64) {
+	kind, props := "rectangle", map[string]interface{}{"roundness": map[string]interface{}{"type": 3}}
+	if p.actor {
+		kind, props = "ellipse", map[string]interface{}{"roundness": map[string]interface{}{"type": 2}}
+	}
+
+	x := p.center - p.width/2
+	for _, box := range []struct {
+		id string
+		y  float64
+	}{{"participant:" + p.id, 0}, {"participant:" + p.id + ":bottom", bottom}} {
+		copied := make(map[string]interface{}, len(props))
+		for k, v := range props {
+			copied[k] = v
+		}
+		el := b.add(b.uniqueID(box.id), kind, x, box.y, p.width, participantHeight, copied)
+		b.text(el, p.label, nodeFontSize, Style{}, nil)
+	}
+
+	length := bottom - participantHeight
+	b.add(b.uniqueID("lifeline:"+p.id), "line", p.center, participantHeight, 0, length, map[string]interface{}{
+		"points":             []interface{}{[]interface{}{0.0, 0.0}, []interface{}{0.0, length}},
+		"lastCommittedPoint": nil,
+		"startBinding":       nil,
+		"endBinding":         nil,
+		"startArrowhead":     nil,
+		"endArrowhead":       nil,
+		"strokeStyle":        "dashed",
+		"strokeWidth":        1,
+	})
+}
+
+// freeText adds a text element that is not bound to a container
+func (s *sequence) freeText(b *builder, prefix, text string, x, y, width, height float64, align string) {
+	b.add(b.uniqueID(fmt.Sprintf("%s:%d", prefix, len(b.elements))), "text", x, y, width, height, map[string]interface{}{
+		"text":          text,
+		"originalText":  text,
+		"fontSize":      edgeFontSize,
+		"fontFamily":    fontFamily,
+		"textAlign":     align,
+		"verticalAlign": "top",
+		"containerId":   nil,
+		"lineHeight":    lineHeight,
+		"autoResize":    true,
+	})
+}
@@ -0,0 +1,323 @@
+package diagram
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+// elementsByID indexes the elements of converted drawing data
+func elementsByID(t *testing.T, res *Result) map[string]map[string]interface{} {
+	t.Helper()
+	if err := res.Data.Validate(); err != nil {
+		t.Fatalf("converted scene is invalid: %v", err)
+	}
+	byID := make(map[string]map[string]interface{})
+	for _, item := range res.Data["elements"].([]interface{}) {
+		el := item.(map[string]interface{})
+		byID[el["id"].(string)] = el
+	}
+	return byID
+}
+
+// box returns the bounds of an element
+func box(el map[string]interface{}) (x, y, w, h float64) {
+	return el["x"].(float64), el["y"].(float64), el["width"].(float64), el["height"].(float64)
+}
+
+func TestConvertMermaidFlowchart(t *testing.T) {
+	res, err := ConvertMermaid(context.Background(), `---
+title: Checkout
+---
+%% order flow
+flowchart LR
+  A[Start] --> B{"Paid?"}
+  B -->|yes| C([Ship])
+  B -- no --> D((Retry)) -.-> B
+  C & D ==> E[End<br>done]:::final
+  classDef final fill:#b2f2bb,stroke:#2f9e44
+  style A stroke-dasharray: 5 5
+`)
+	if err != nil {
+		t.Fatalf("ConvertMermaid failed: %v", err)
+	}
+	if res.Title != "Checkout" {
+		t.Errorf("expected title from the front matter, got %q", res.Title)
+	}
+	els := elementsByID(t, res)
+
+	shapes := map[string]string{"node:A": "rectangle", "node:B": "diamond", "node:C": "rectangle", "node:D": "ellipse", "node:E": "rectangle"}
+	for id, kind := range shapes {
+		if els[id] == nil || els[id]["type"] != kind {
+			t.Errorf("expected %s to be a %s, got %v", id, kind, els[id])
+		}
+	}
+	if els["node:C"]["roundness"] == nil {
+		t.Errorf("expected the stadium node to be rounded")
+	}
+	if text := els["node:E:text"]; text == nil || text["text"] != "End\ndone" || text["containerId"] != "node:E" {
+		t.Errorf("expected the label of E bound to it with a line break, got %v", text)
+	}
+	if els["node:E"]["backgroundColor"] != "#b2f2bb" || els["node:E"]["strokeColor"] != "#2f9e44" {
+		t.Errorf("expected the final class on E, got %v %v", els["node:E"]["backgroundColor"], els["node:E"]["strokeColor"])
+	}
+	if els["node:A"]["strokeStyle"] != "dashed" {
+		t.Errorf("expected the style statement on A, got %v", els["node:A"]["strokeStyle"])
+	}
+
+	yes := els["edge:B>C"]
+	if yes == nil {
+		t.Fatalf("expected an arrow from B to C")
+	}
+	if yes["startBinding"].(map[string]interface{})["elementId"] != "node:B" || yes["endBinding"].(map[string]interface{})["elementId"] != "node:C" {
+		t.Errorf("expected the arrow bound to B and C, got %v %v", yes["startBinding"], yes["endBinding"])
+	}
+	if yes["endArrowhead"] != "arrow" || yes["startArrowhead"] != nil {
+		t.Errorf("expected an arrowhead at the end only, got %v %v", yes["startArrowhead"], yes["endArrowhead"])
+	}
+	if label := els["edge:B>C:text"]; label == nil || label["text"] != "yes" || label["containerId"] != "edge:B>C" {
+		t.Errorf("expected the pipe label bound to the arrow, got %v", label)
+	}
+	if label := els["edge:B>D:text"]; label == nil || label["text"] != "no" {
+		t.Errorf("expected the inline label bound to the arrow, got %v", label)
+	}
+	if els["edge:D>B"]["strokeStyle"] != "dashed" {
+		t.Errorf("expected the dotted link to be dashed")
+	}
+	if els["edge:C>E"]["strokeWidth"] != 4.0 || els["edge:D>E"] == nil {
+		t.Errorf("expected thick links from both C and D to E")
+	}
+
+	// Every arrow is listed on the shapes it is bound to
+	bound := 0
+	for _, b := range els["node:B"]["boundElements"].([]interface{}) {
+		if b.(map[string]interface{})["type"] == "arrow" {
+			bound++
+		}
+	}
+	if bound != 4 {
+		t.Errorf("expected 4 arrows bound to B, got %d", bound)
+	}
+
+	// Left to right: each rank is to the right of the previous one
+	ax, _, aw, _ := box(els["node:A"])
+	bx, _, _, _ := box(els["node:B"])
+	if bx < ax+aw {
+		t.Errorf("expected B to the right of A, got A at %v (width %v) and B at %v", ax, aw, bx)
+	}
+}
+
+func TestConvertMermaidSubgraphs(t *testing.T) {
+	res, err := ConvertMermaid(context.Background(), `graph TD
+  start --> a1
+  subgraph one [Build]
+    a1 --> a2
+    subgraph inner
+      a3
+    end
+  end
+  a2 --> a3
+  start --> other --> a3
+  one --> done`)
+	if err != nil {
+		t.Fatalf("ConvertMermaid failed: %v", err)
+	}
+	els := elementsByID(t, res)
+
+	contains := func(outer, inner map[string]interface{}) bool {
+		ox, oy, ow, oh := box(outer)
+		ix, iy, iw, ih := box(inner)
+		return ix >= ox && iy >= oy && ix+iw <= ox+ow && iy+ih <= oy+oh
+	}
+	overlaps := func(a, b map[string]interface{}) bool {
+		ax, ay, aw, ah := box(a)
+		bx, by, bw, bh := box(b)
+		return ax < bx+bw && bx < ax+aw && ay < by+bh && by < ay+ah
+	}
+
+	group, inner := els["group:one"], els["group:inner"]
+	if group == nil || inner == nil {
+		t.Fatalf("expected boxes for both subgraphs")
+	}
+	if title := els["group:one:text"]; title == nil || title["text"] != "Build" {
+		t.Errorf("expected the subgraph title in its box, got %v", title)
+	}
+	for _, id := range []string{"node:a1", "node:a2", "node:a3", "group:inner"} {
+		if !contains(group, els[id]) {
+			t.Errorf("expected %s inside the subgraph box", id)
+		}
+	}
+	if !contains(inner, els["node:a3"]) {
+		t.Errorf("expected a3 inside the nested subgraph box")
+	}
+	for _, id := range []string{"node:start", "node:other", "node:done"} {
+		if overlaps(group, els[id]) {
+			t.Errorf("expected %s outside the subgraph box", id)
+		}
+	}
+
+	// A link from a subgraph starts at its first node
+	if els["node:one"] != nil || els["edge:a1>done"] == nil {
+		t.Errorf("expected the link from the subgraph to start at a1")
+	}
+}
+
+func TestConvertMermaidSequence(t *testing.T) {
+	res, err := ConvertMermaid(context.Background(), `sequenceDiagram
+  title Login
+  autonumber
+  participant B as Browser
+  actor U as User
+  U->>B: Open app
+  B->>+API: POST /login
+  alt valid
+    API-->>-B: 200
+  else invalid
+    API--xB: 401
+  end
+  B->>B: Render
+  Note over B,API: Session cookie`)
+	if err != nil {
+		t.Fatalf("ConvertMermaid failed: %v", err)
+	}
+	if res.Title != "Login" {
+		t.Errorf("expected the title statement, got %q", res.Title)
+	}
+	els := elementsByID(t, res)
+
+	for _, id := range []string{"B", "U", "API"} {
+		if els["participant:"+id] == nil || els["participant:"+id+":bottom"] == nil || els["lifeline:"+id] == nil {
+			t.Errorf("expected boxes and a lifeline for %s", id)
+		}
+	}
+	if els["participant:U"]["type"] != "ellipse" {
+		t.Errorf("expected the actor drawn as an ellipse")
+	}
+	if els["participant:B:text"]["text"] != "Browser" {
+		t.Errorf("expected the participant alias, got %v", els["participant:B:text"]["text"])
+	}
+
+	// Participants are columns in declaration order
+	bx, _, _, _ := box(els["participant:B"])
+	ux, _, _, _ := box(els["participant:U"])
+	apix, _, _, _ := box(els["participant:API"])
+	if !(bx < ux && ux < apix) {
+		t.Errorf("expected columns B, U, API, got %v, %v, %v", bx, ux, apix)
+	}
+
+	var arrows, dashed int
+	var labels []string
+	var notes, blocks, activations int
+	for _, item := range res.Data["elements"].([]interface{}) {
+		el := item.(map[string]interface{})
+		switch {
+		case el["type"] == "arrow":
+			arrows++
+			if el["strokeStyle"] == "dashed" {
+				dashed++
+			}
+		case el["type"] == "text" && el["containerId"] == nil:
+			labels = append(labels, el["text"].(string))
+		case el["backgroundColor"] == noteColor:
+			notes++
+		case el["strokeStyle"] == "dashed" && el["type"] == "rectangle":
+			blocks++
+		case el["width"] == float64(activationWidth):
+			activations++
+		}
+	}
+	if arrows != 5 || dashed != 2 {
+		t.Errorf("expected 5 messages, 2 of them dashed, got %d and %d", arrows, dashed)
+	}
+	want := []string{"[invalid]", "1. Open app", "2. POST /login", "3. 200", "4. 401", "5. Render"}
+	if len(labels) != len(want) {
+		t.Fatalf("expected labels %q, got %q", want, labels)
+	}
+	for i := range want {
+		if labels[i] != want[i] {
+			t.Errorf("label %d: expected %q, got %q", i, want[i], labels[i])
+		}
+	}
+	if notes != 1 || blocks != 1 || activations != 1 {
+		t.Errorf("expected a note, an alt block and an activation, got %d, %d and %d", notes, blocks, activations)
+	}
+}
+
+func TestConvertMermaidErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		err    error
+	}{
+		{"empty", "  \n%% nothing\n", ErrInvalidDiagram},
+		{"unsupported type", "pie\n  \"a\": 1", ErrUnsupportedDiagram},
+		{"unclosed subgraph", "flowchart TD\n  subgraph a\n  x --> y", ErrInvalidDiagram},
+		{"unclosed label", "flowchart TD\n  x[oops --> y", ErrInvalidDiagram},
+		{"dangling link", "flowchart TD\n  x -->", ErrInvalidDiagram},
+		{"end outside a block", "sequenceDiagram\n  A->>B: hi\n  end", ErrInvalidDiagram},
+		{"no participants", "sequenceDiagram\n", ErrInvalidDiagram},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ConvertMermaid(context.Background(), tt.source)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestLayeredLayoutTooLarge(t *testing.T) {
+	g := &Graph{Direction: TopToBottom}
+	for i := 0; i <= MaxLayoutNodes; i++ {
+		g.Nodes = append(g.Nodes, &Node{ID: string(rune('a' + i)), Width: 10, Height: 10})
+	}
+	if err := LayeredLayout(context.Background(), g); !errors.Is(err, ErrDiagramTooLarge) {
+		t.Errorf("expected ErrDiagramTooLarge, got %v", err)
+	}
+
+	// A chain ranks its nodes one below the other, so edges skipping it need a dummy in every rank
+	g = &Graph{Direction: TopToBottom}
+	for i := 0; i < MaxLayoutNodes; i++ {
+		g.Nodes = append(g.Nodes, &Node{ID: strconv.Itoa(i), Width: 10, Height: 10})
+		if i > 0 {
+			g.Edges = append(g.Edges, &Edge{From: strconv.Itoa(i - 1), To: strconv.Itoa(i)})
+		}
+	}
+	for i := 0; len(g.Edges) < MaxLayoutEdges; i++ {
+		g.Edges = append(g.Edges, &Edge{From: strconv.Itoa(i % 10), To: strconv.Itoa(MaxLayoutNodes - 1 - i%10)})
+	}
+	if err := LayeredLayout(context.Background(), g); !errors.Is(err, ErrDiagramTooLarge) {
+		t.Errorf("expected ErrDiagramTooLarge for edges spanning many ranks, got %v", err)
+	}
+}
+
+func TestLayeredLayoutCanceled(t *testing.T) {
+	// Two crossing edges leave the ordering work to do
+	g := &Graph{Direction: TopToBottom}
+	for _, id := range []string{"a", "b", "c", "d"} {
+		g.Nodes = append(g.Nodes, &Node{ID: id, Width: 10, Height: 10})
+	}
+	g.Edges = []*Edge{{From: "a", To: "d"}, {From: "b", To: "c"}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := LayeredLayout(ctx, g); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestCrossings(t *testing.T) {
+	// a-d crosses both b-c edges; edges sharing an end do not cross
+	a, b := &layerNode{order: 0}, &layerNode{order: 1}
+	c, d := &layerNode{order: 0}, &layerNode{order: 1}
+	a.down = []*layerNode{d, c}
+	b.down = []*layerNode{c, c}
+	l := &layered{ranks: [][]*layerNode{{a, b}, {c, d}}}
+
+	if got := l.crossings(); got != 2 {
+		t.Errorf("expected 2 crossings, got %d", got)
+	}
+}
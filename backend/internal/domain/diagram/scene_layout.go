@@ -0,0 +1,370 @@
+package diagram
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// LayoutAlgorithm selects how LayoutScene positions the shapes of a scene
+type LayoutAlgorithm string
+
+const (
+	// LayoutLayered arranges the shapes in ranks along the direction of the arrows
+	LayoutLayered LayoutAlgorithm = "layered"
+
+	// LayoutForce spreads the shapes out as if arrows were springs pulling them together
+	LayoutForce LayoutAlgorithm = "force"
+)
+
+// SceneLayoutOptions configures LayoutScene
+type SceneLayoutOptions struct {
+	Algorithm LayoutAlgorithm
+
+	// Direction is the direction of a layered layout; empty lays out top to bottom
+	Direction Direction
+}
+
+// layoutShapeTypes are the types of elements laid out as shapes; other elements are only moved
+// when they belong to a group with a shape, are bound to one, or an arrow is bound to them
+var layoutShapeTypes = map[string]bool{
+	"rectangle":  true,
+	"ellipse":    true,
+	"diamond":    true,
+	"image":      true,
+	"embeddable": true,
+	"iframe":     true,
+}
+
+// sceneUnit is a shape, or an Excalidraw group of elements, that the layout moves as one node
+type sceneUnit struct {
+	node    *Node
+	members []map[string]interface{}
+
+	// x and y are the position of the unit before the layout
+	x, y float64
+}
+
+// LayoutScene repositions the shapes of a scene and the arrows bound between them
+// Each shape, or each group of elements holding one, is a node, and each arrow whose ends are
+// bound to two of them an edge. Shapes move with their bound text; arrows between shapes are
+// redrawn along the routes of the layout, and arrows bound at one end follow the shape they are
+// bound to. Locked elements and frames stay in place, and the laid out diagram keeps the top left
+// corner of the shapes it moves. Elements that move get a new version, so editors take the change
+func LayoutScene(ctx context.Context, data drawing.DrawingData, options SceneLayoutOptions) (drawing.DrawingData, error) {
+	elements := sceneElements(data)
+	var live []map[string]interface{}
+	for _, el := range elements {
+		if _, ok := el["id"].(string); ok && el["isDeleted"] != true {
+			live = append(live, el)
+		}
+	}
+
+	// Elements arrows are bound to are laid out too, such as free text
+	bound := make(map[string]bool)
+	for _, el := range live {
+		if el["type"] == "arrow" {
+			bound[bindingTarget(el, "startBinding")] = true
+			bound[bindingTarget(el, "endBinding")] = true
+		}
+	}
+
+	// Shapes become units of their own, or of the outermost group they belong to
+	units := make(map[string]*sceneUnit)
+	var order []string
+	unitOf := make(map[string]*sceneUnit)
+	for _, el := range live {
+		id := el["id"].(string)
+		kind, _ := el["type"].(string)
+		if el["locked"] == true || el["containerId"] != nil && el["containerId"] != "" || kind == "frame" || kind == "magicframe" {
+			continue
+		}
+		if !layoutShapeTypes[kind] && !bound[id] {
+			continue
+		}
+
+		key := "element:" + id
+		if group := outermostGroup(el); group != "" {
+			key = "group:" + group
+		}
+		if units[key] == nil {
+			units[key] = &sceneUnit{node: &Node{ID: key, Shape: ShapeRectangle}}
+			order = append(order, key)
+		}
+		unitOf[id] = units[key]
+	}
+	if len(units) == 0 {
+		return nil, fmt.Errorf("%w: drawing has no shapes to lay out", ErrInvalidDiagram)
+	}
+
+	// Groups move with every element in them
+	for _, el := range live {
+		id := el["id"].(string)
+		if u := unitOf[id]; u != nil {
+			u.members = append(u.members, el)
+			continue
+		}
+		if group := outermostGroup(el); group != "" && units["group:"+group] != nil {
+			u := units["group:"+group]
+			u.members = append(u.members, el)
+			unitOf[id] = u
+		}
+	}
+
+	g := &Graph{Direction: options.Direction}
+	if g.Direction == "" {
+		g.Direction = TopToBottom
+	}
+	minX, minY := math.Inf(1), math.Inf(1)
+	for _, key := range order {
+		u := units[key]
+		x0, y0, x1, y1 := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+		for _, el := range u.members {
+			ex0, ey0, ex1, ey1 := elementBounds(el)
+			x0, y0, x1, y1 = math.Min(x0, ex0), math.Min(y0, ey0), math.Max(x1, ex1), math.Max(y1, ey1)
+		}
+		u.x, u.y = x0, y0
+		u.node.X, u.node.Y, u.node.Width, u.node.Height = x0, y0, math.Max(x1-x0, 1), math.Max(y1-y0, 1)
+		if len(u.members) == 1 {
+			switch u.members[0]["type"] {
+			case "ellipse":
+				u.node.Shape = ShapeEllipse
+			case "diamond":
+				u.node.Shape = ShapeDiamond
+			}
+		}
+		minX, minY = math.Min(minX, x0), math.Min(minY, y0)
+		g.Nodes = append(g.Nodes, u.node)
+	}
+
+	// Arrows bound between two units are the edges, unless they are part of a group that moves whole
+	edgeOf := make(map[string]*Edge)
+	for _, el := range live {
+		id := el["id"].(string)
+		if el["type"] != "arrow" || unitOf[id] != nil {
+			continue
+		}
+		from, to := unitOf[bindingTarget(el, "startBinding")], unitOf[bindingTarget(el, "endBinding")]
+		if from == nil || to == nil {
+			continue
+		}
+		e := &Edge{ID: id, From: from.node.ID, To: to.node.ID}
+		g.Edges = append(g.Edges, e)
+		edgeOf[id] = e
+	}
+
+	var err error
+	switch options.Algorithm {
+	case LayoutForce:
+		err = ForceLayout(ctx, g)
+	case LayoutLayered, "":
+		err = LayeredLayout(ctx, g)
+	default:
+		err = fmt.Errorf("%w: unknown layout algorithm %q", ErrInvalidDiagram, options.Algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+	translate(g, minX, minY)
+
+	// Apply the moves to copies of the elements, keeping the stored scene untouched
+	updated := time.Now().UnixMilli()
+	changed := make(map[string]map[string]interface{})
+	change := func(el map[string]interface{}) map[string]interface{} {
+		id := el["id"].(string)
+		if c, ok := changed[id]; ok {
+			return c
+		}
+		c := make(map[string]interface{}, len(el)+1)
+		for k, v := range el {
+			c[k] = v
+		}
+		version, _ := elementNumber(el, "version")
+		c["version"] = int64(version) + 1
+		c["versionNonce"] = seed(id, "layout"+strconv.FormatInt(int64(version)+1, 10))
+		c["updated"] = updated
+		changed[id] = c
+		return c
+	}
+	move := func(el map[string]interface{}, dx, dy float64) {
+		c := change(el)
+		x, _ := elementNumber(c, "x")
+		y, _ := elementNumber(c, "y")
+		c["x"], c["y"] = x+dx, y+dy
+	}
+
+	deltas := make(map[*sceneUnit]Point, len(units))
+	for _, u := range units {
+		dx, dy := u.node.X-u.x, u.node.Y-u.y
+		deltas[u] = Point{dx, dy}
+		if dx == 0 && dy == 0 {
+			continue
+		}
+		for _, el := range u.members {
+			move(el, dx, dy)
+		}
+	}
+
+	// Bound text moves with its container
+	for _, el := range live {
+		container, _ := el["containerId"].(string)
+		if u := unitOf[container]; u != nil && el["type"] == "text" {
+			if d := deltas[u]; d.X != 0 || d.Y != 0 {
+				move(el, d.X, d.Y)
+			}
+		}
+	}
+
+	for _, el := range live {
+		id := el["id"].(string)
+		if el["type"] != "arrow" || unitOf[id] != nil {
+			continue
+		}
+		var route []Point
+		if e := edgeOf[id]; e != nil && len(e.Points) >= 2 {
+			route = e.Points
+		} else {
+			route = followBindings(el, deltas[unitOf[bindingTarget(el, "startBinding")]], deltas[unitOf[bindingTarget(el, "endBinding")]])
+		}
+		if route == nil {
+			continue
+		}
+
+		c := change(el)
+		setRoute(c, route, edgeOf[id] != nil && edgeOf[id].Curved)
+		for _, label := range live {
+			if label["containerId"] == id && label["type"] == "text" {
+				mid := midpoint(route)
+				w, _ := elementNumber(label, "width")
+				h, _ := elementNumber(label, "height")
+				lc := change(label)
+				lc["x"], lc["y"] = mid.X-w/2, mid.Y-h/2
+			}
+		}
+	}
+
+	result := make([]interface{}, 0, len(elements))
+	for _, item := range data["elements"].([]interface{}) {
+		if el, ok := item.(map[string]interface{}); ok {
+			if id, _ := el["id"].(string); changed[id] != nil {
+				item = changed[id]
+			}
+		}
+		result = append(result, item)
+	}
+
+	laidOut := make(drawing.DrawingData, len(data))
+	for k, v := range data {
+		laidOut[k] = v
+	}
+	laidOut["elements"] = result
+	return laidOut, nil
+}
+
+// outermostGroup returns the outermost Excalidraw group of an element, or empty
+// The editor lists the groups of an element from the innermost to the outermost
+func outermostGroup(el map[string]interface{}) string {
+	groups, _ := el["groupIds"].([]interface{})
+	if len(groups) == 0 {
+		return ""
+	}
+	group, _ := groups[len(groups)-1].(string)
+	return group
+}
+
+// bindingTarget returns the ID of the element an arrow end is bound to, or empty
+func bindingTarget(el map[string]interface{}, end string) string {
+	b, _ := el[end].(map[string]interface{})
+	id, _ := b["elementId"].(string)
+	return id
+}
+
+// elementBounds returns the box around an element; the points of linear elements are relative to its position
+func elementBounds(el map[string]interface{}) (x0, y0, x1, y1 float64) {
+	x, _ := elementNumber(el, "x")
+	y, _ := elementNumber(el, "y")
+	points := elementPoints(el)
+	if len(points) == 0 {
+		w, _ := elementNumber(el, "width")
+		h, _ := elementNumber(el, "height")
+		return x, y, x + w, y + h
+	}
+
+	x0, y0, x1, y1 = math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, p := range points {
+		x0, y0 = math.Min(x0, x+p.X), math.Min(y0, y+p.Y)
+		x1, y1 = math.Max(x1, x+p.X), math.Max(y1, y+p.Y)
+	}
+	return x0, y0, x1, y1
+}
+
+// elementPoints returns the points of a linear element, relative to its position
+func elementPoints(el map[string]interface{}) []Point {
+	items, _ := el["points"].([]interface{})
+	points := make([]Point, 0, len(items))
+	for _, item := range items {
+		pair, _ := item.([]interface{})
+		if len(pair) < 2 {
+			continue
+		}
+		px, okX := numberValue(pair[0])
+		py, okY := numberValue(pair[1])
+		if okX && okY {
+			points = append(points, Point{px, py})
+		}
+	}
+	return points
+}
+
+// followBindings returns the route of an arrow whose bound ends moved by start and end: the whole
+// arrow moves if both ends moved alike, otherwise only its ends do. It returns nil if nothing moved
+func followBindings(el map[string]interface{}, start, end Point) []Point {
+	if start == (Point{}) && end == (Point{}) {
+		return nil
+	}
+	x, _ := elementNumber(el, "x")
+	y, _ := elementNumber(el, "y")
+	points := elementPoints(el)
+	if len(points) < 2 {
+		return nil
+	}
+
+	route := make([]Point, len(points))
+	for i, p := range points {
+		route[i] = Point{x + p.X, y + p.Y}
+		if start == end {
+			route[i].X, route[i].Y = route[i].X+start.X, route[i].Y+start.Y
+		}
+	}
+	if start != end {
+		last := len(route) - 1
+		route[0].X, route[0].Y = route[0].X+start.X, route[0].Y+start.Y
+		route[last].X, route[last].Y = route[last].X+end.X, route[last].Y+end.Y
+	}
+	return route
+}
+
+// setRoute sets the position, points and size of a linear element to follow a route in scene coordinates
+func setRoute(el map[string]interface{}, route []Point, curved bool) {
+	origin := route[0]
+	points := make([]interface{}, len(route))
+	minX, minY, maxX, maxY := 0.0, 0.0, 0.0, 0.0
+	for i, p := range route {
+		dx, dy := p.X-origin.X, p.Y-origin.Y
+		points[i] = []interface{}{dx, dy}
+		minX, minY = math.Min(minX, dx), math.Min(minY, dy)
+		maxX, maxY = math.Max(maxX, dx), math.Max(maxY, dy)
+	}
+
+	el["x"], el["y"] = origin.X, origin.Y
+	el["points"] = points
+	el["width"], el["height"] = maxX-minX, maxY-minY
+	el["lastCommittedPoint"] = nil
+	if curved {
+		el["roundness"] = map[string]interface{}{"type": 2}
+	}
+}
@@ -0,0 +1,151 @@
+package diagram
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/personal-excalidraw/backend/internal/domain/drawing"
+)
+
+// boundArrow returns an arrow element bound from one element to another
+func boundArrow(id, from, to string) map[string]interface{} {
+	arrow := map[string]interface{}{
+		"id": id, "type": "arrow", "x": 0.0, "y": 0.0, "width": 10.0, "height": 10.0, "version": 1.0,
+		"points": []interface{}{[]interface{}{0.0, 0.0}, []interface{}{10.0, 10.0}},
+	}
+	if from != "" {
+		arrow["startBinding"] = map[string]interface{}{"elementId": from, "focus": 0.0, "gap": 4.0}
+	}
+	if to != "" {
+		arrow["endBinding"] = map[string]interface{}{"elementId": to, "focus": 0.0, "gap": 4.0}
+	}
+	return arrow
+}
+
+// layoutScene returns a scene of shapes piled up at random, with the arrows drawn between them
+func layoutScene(t *testing.T) drawing.DrawingData {
+	t.Helper()
+	return reload(t, drawing.DrawingData{
+		"elements": []interface{}{
+			map[string]interface{}{"id": "a", "type": "rectangle", "x": 400.0, "y": 300.0, "width": 120.0, "height": 60.0, "version": 3.0},
+			map[string]interface{}{"id": "a-text", "type": "text", "x": 420.0, "y": 320.0, "width": 80.0, "height": 20.0, "containerId": "a", "text": "A", "version": 1.0},
+			map[string]interface{}{"id": "b", "type": "ellipse", "x": 0.0, "y": 0.0, "width": 100.0, "height": 60.0, "version": 1.0},
+			map[string]interface{}{"id": "c", "type": "diamond", "x": 410.0, "y": 310.0, "width": 80.0, "height": 80.0, "version": 1.0},
+			map[string]interface{}{"id": "g-box", "type": "rectangle", "x": 50.0, "y": 400.0, "width": 100.0, "height": 40.0, "groupIds": []interface{}{"inner", "outer"}, "version": 1.0},
+			map[string]interface{}{"id": "g-line", "type": "line", "x": 50.0, "y": 450.0, "width": 100.0, "height": 0.0, "groupIds": []interface{}{"outer"}, "version": 1.0,
+				"points": []interface{}{[]interface{}{0.0, 0.0}, []interface{}{100.0, 0.0}}},
+			map[string]interface{}{"id": "locked", "type": "rectangle", "x": -300.0, "y": -300.0, "width": 50.0, "height": 50.0, "locked": true, "version": 1.0},
+			boundArrow("a-b", "a", "b"),
+			map[string]interface{}{"id": "a-b-label", "type": "text", "x": 0.0, "y": 0.0, "width": 30.0, "height": 20.0, "containerId": "a-b", "text": "uses", "version": 1.0},
+			boundArrow("a-c", "a", "c"),
+			boundArrow("a-g", "a", "g-box"),
+			boundArrow("loose", "b", ""),
+		},
+		"appState": map[string]interface{}{"viewBackgroundColor": "#ffffff"},
+	})
+}
+
+func TestLayoutScene(t *testing.T) {
+	data := layoutScene(t)
+	laidOut, err := LayoutScene(context.Background(), data, SceneLayoutOptions{Algorithm: LayoutLayered, Direction: TopToBottom})
+	if err != nil {
+		t.Fatalf("LayoutScene failed: %v", err)
+	}
+	if err := laidOut.Validate(); err != nil {
+		t.Fatalf("laid out scene is invalid: %v", err)
+	}
+	els := elementMap(laidOut)
+
+	// The source of the arrows is ranked above their targets
+	_, ay, _, ah := box(els["a"])
+	for _, id := range []string{"b", "c", "g-box"} {
+		if _, y, _, _ := box(els[id]); y < ay+ah {
+			t.Errorf("expected %s below a, got y %v under %v", id, y, ay+ah)
+		}
+	}
+
+	// Bound text and grouped elements move with their shape
+	ax, _, _, _ := box(els["a"])
+	if tx, ty, _, _ := box(els["a-text"]); tx-ax != 20 || ty-ay != 20 {
+		t.Errorf("expected the text to keep its place in a, got offset (%v, %v)", tx-ax, ty-ay)
+	}
+	gx, gy, _, _ := box(els["g-box"])
+	if lx, ly, _, _ := box(els["g-line"]); lx != gx || ly-gy != 50 {
+		t.Errorf("expected the group to move as one, got offset (%v, %v)", lx-gx, ly-gy)
+	}
+	if x, y, _, _ := box(els["locked"]); x != -300 || y != -300 || els["locked"]["version"] != 1.0 {
+		t.Errorf("expected the locked shape to stay in place")
+	}
+
+	// Arrows between shapes are redrawn from their source, keeping their bindings and label
+	arrow := els["a-b"]
+	if x, y, _, _ := box(arrow); y < ay+ah/2 || x < ax || x > ax+120 {
+		t.Errorf("expected the arrow to start from a, got (%v, %v)", x, y)
+	}
+	if arrow["endBinding"].(map[string]interface{})["elementId"] != "b" || arrow["version"] != int64(2) {
+		t.Errorf("expected the arrow bound to b at a new version, got %v", arrow["version"])
+	}
+	mid := midpoint(elementPoints(arrow))
+	if lx, ly, _, _ := box(els["a-b-label"]); lx+15 != arrow["x"].(float64)+mid.X || ly+10 != arrow["y"].(float64)+mid.Y {
+		t.Errorf("expected the label centered on the arrow")
+	}
+
+	// An arrow bound at one end follows that end only
+	bx, by, _, _ := box(els["b"])
+	if x, y, _, _ := box(els["loose"]); x != bx || y != by {
+		t.Errorf("expected the loose arrow to start where b moved, got (%v, %v) for (%v, %v)", x, y, bx, by)
+	}
+
+	// The stored scene is left untouched
+	if x, _, _, _ := box(elementMap(data)["a"]); x != 400 {
+		t.Errorf("expected the original scene unchanged, got x %v", x)
+	}
+	if laidOut["appState"].(map[string]interface{})["viewBackgroundColor"] != "#ffffff" {
+		t.Errorf("expected the appState to be kept")
+	}
+}
+
+func TestLayoutSceneForce(t *testing.T) {
+	laidOut, err := LayoutScene(context.Background(), layoutScene(t), SceneLayoutOptions{Algorithm: LayoutForce})
+	if err != nil {
+		t.Fatalf("LayoutScene failed: %v", err)
+	}
+	els := elementMap(laidOut)
+
+	shapes := []string{"a", "b", "c", "g-box"}
+	for i, first := range shapes {
+		x0, y0, w0, h0 := box(els[first])
+		for _, second := range shapes[i+1:] {
+			x1, y1, w1, h1 := box(els[second])
+			if x0 < x1+w1 && x1 < x0+w0 && y0 < y1+h1 && y1 < y0+h0 {
+				t.Errorf("expected %s and %s apart, got (%v, %v) and (%v, %v)", first, second, x0, y0, x1, y1)
+			}
+		}
+	}
+	if els["a-c"]["version"] != int64(2) || len(elementPoints(els["a-c"])) != 2 {
+		t.Errorf("expected a straight arrow between a and c")
+	}
+}
+
+func TestLayoutSceneErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    drawing.DrawingData
+		options SceneLayoutOptions
+	}{
+		{"empty scene", drawing.DrawingData{"elements": []interface{}{}}, SceneLayoutOptions{}},
+		{"only text", drawing.DrawingData{"elements": []interface{}{
+			map[string]interface{}{"id": "t", "type": "text", "text": "note", "x": 0.0, "y": 0.0, "width": 10.0, "height": 10.0},
+		}}, SceneLayoutOptions{}},
+		{"unknown algorithm", layoutScene(t), SceneLayoutOptions{Algorithm: "circular"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LayoutScene(context.Background(), tt.data, tt.options); !errors.Is(err, ErrInvalidDiagram) {
+				t.Errorf("expected ErrInvalidDiagram, got %v", err)
+			}
+		})
+	}
+}
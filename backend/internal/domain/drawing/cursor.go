@@ -0,0 +1,81 @@
+package drawing
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// cursorTimeFormat formats sort keys that are timestamps, which are stored in UTC without a zone
+const cursorTimeFormat = "2006-01-02 15:04:05.999999"
+
+// Cursor is a position in a list of drawings, next to the drawing it was taken from
+// Drawings are ordered by their sort key with ties broken by ID, so a cursor stays valid
+// however many drawings are created or deleted before it
+type Cursor struct {
+	// Sort and Descending are the order of the list the cursor was taken from
+	Sort       SortField `json:"s,omitempty"`
+	Descending bool      `json:"d,omitempty"`
+
+	// Key is the sort key of the drawing the cursor was taken from, as text
+	Key string `json:"k"`
+
+	// ID is the ID of the drawing the cursor was taken from
+	ID uuid.UUID `json:"id"`
+
+	// Before selects the drawings preceding the position instead of those following it
+	Before bool `json:"b,omitempty"`
+}
+
+// CursorAfter returns the cursor selecting the drawings that follow a drawing in a list in the given order
+func CursorAfter(s *DrawingSummary, order SortOrder) Cursor {
+	return Cursor{Sort: order.Field, Descending: order.Descending, Key: order.Field.key(s), ID: s.ID}
+}
+
+// CursorBefore returns the cursor selecting the drawings that precede a drawing in a list in the given order
+func CursorBefore(s *DrawingSummary, order SortOrder) Cursor {
+	c := CursorAfter(s, order)
+	c.Before = true
+	return c
+}
+
+// Order returns the order of the list the cursor was taken from
+func (c Cursor) Order() SortOrder {
+	return SortOrder{Field: c.Sort, Descending: c.Descending}
+}
+
+// Reverse returns the cursor at the same position selecting the drawings on the other side
+func (c Cursor) Reverse() Cursor {
+	c.Before = !c.Before
+	return c
+}
+
+// Encode returns the cursor as an opaque URL-safe token
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a token returned by Cursor.Encode
+func DecodeCursor(token string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("%w: not a cursor token", ErrInvalidCursor)
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == uuid.Nil {
+		return Cursor{}, fmt.Errorf("%w: not a cursor token", ErrInvalidCursor)
+	}
+	// Cursors from before lists could be sorted come from the default order
+	if c.Sort == "" {
+		c.Sort, c.Descending = DefaultSortOrder.Field, DefaultSortOrder.Descending
+	}
+	if !c.Sort.validKey(c.Key) {
+		return Cursor{}, fmt.Errorf("%w: malformed sort key", ErrInvalidCursor)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,97 @@
+package drawing
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	s := &DrawingSummary{
+		ID:        uuid.New(),
+		Name:      "Roadmap",
+		CreatedAt: time.Date(2025, 12, 10, 12, 30, 0, 123456000, time.UTC),
+		UpdatedAt: time.Date(2025, 12, 11, 8, 0, 0, 0, time.UTC),
+		ByteSize:  2048,
+	}
+
+	for _, order := range []SortOrder{
+		DefaultSortOrder,
+		{Field: SortByName},
+		{Field: SortByUpdatedAt, Descending: true},
+		{Field: SortBySize},
+	} {
+		t.Run(string(order.Field), func(t *testing.T) {
+			c := CursorBefore(s, order)
+
+			decoded, err := DecodeCursor(c.Encode())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if decoded != c {
+				t.Errorf("expected %+v, got %+v", c, decoded)
+			}
+			if decoded.Order() != order {
+				t.Errorf("expected order %+v, got %+v", order, decoded.Order())
+			}
+		})
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	id := uuid.MustParse("8f14e45f-ceea-467f-a0e6-29b7a3c1d2e4")
+	token := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+
+	tests := []struct {
+		name        string
+		token       string
+		expected    Cursor
+		expectedErr bool
+	}{
+		{
+			name:     "cursor without an order takes the default one",
+			token:    token(`{"k":"2025-12-10 12:30:00","id":"` + id.String() + `"}`),
+			expected: Cursor{Sort: SortByCreatedAt, Descending: true, Key: "2025-12-10 12:30:00", ID: id},
+		},
+		{
+			name:     "any name is a valid key",
+			token:    token(`{"s":"name","k":"","id":"` + id.String() + `"}`),
+			expected: Cursor{Sort: SortByName, ID: id},
+		},
+		{name: "empty token", token: "", expectedErr: true},
+		{name: "not base64", token: "not a cursor!", expectedErr: true},
+		{name: "padded base64", token: base64.URLEncoding.EncodeToString([]byte(`{"k":"1","id":"` + id.String() + `"}`)), expectedErr: true},
+		{name: "not JSON", token: token("cursor"), expectedErr: true},
+		{name: "JSON array", token: token(`[1,2]`), expectedErr: true},
+		{name: "missing ID", token: token(`{"s":"name","k":"a"}`), expectedErr: true},
+		{name: "nil ID", token: token(`{"s":"name","k":"a","id":"` + uuid.Nil.String() + `"}`), expectedErr: true},
+		{name: "malformed ID", token: token(`{"s":"name","k":"a","id":"123"}`), expectedErr: true},
+		{name: "unknown sort field", token: token(`{"s":"color","k":"red","id":"` + id.String() + `"}`), expectedErr: true},
+		{name: "malformed time key", token: token(`{"s":"updated_at","k":"yesterday","id":"` + id.String() + `"}`), expectedErr: true},
+		{name: "time key with a zone", token: token(`{"k":"2025-12-10T12:30:00Z","id":"` + id.String() + `"}`), expectedErr: true},
+		{name: "malformed size key", token: token(`{"s":"size","k":"1.5","id":"` + id.String() + `"}`), expectedErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := DecodeCursor(tt.token)
+			if tt.expectedErr {
+				if !errors.Is(err, ErrInvalidCursor) {
+					t.Errorf("expected ErrInvalidCursor, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if c != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, c)
+			}
+		})
+	}
+}
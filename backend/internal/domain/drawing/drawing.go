@@ -1,6 +1,7 @@
 package drawing
 
 import (
+	"slices"
 	"strings"
 	"time"
 
@@ -18,8 +19,12 @@ type Drawing struct {
 	slug      string
 	name      string
 	data      DrawingData
+	version   int64
 	createdAt time.Time
 	updatedAt time.Time
+
+	// folderID is the folder holding the drawing; nil for drawings outside any folder
+	folderID *uuid.UUID
 }
 
 // NewDrawing creates a new drawing with validation
@@ -29,6 +34,7 @@ func NewDrawing(name string, data DrawingData) (*Drawing, error) {
 		slug:      "", // Slug will be set by the service layer
 		name:      name,
 		data:      data,
+		version:   1,
 		createdAt: time.Now().UTC(),
 		updatedAt: time.Now().UTC(),
 	}
@@ -45,33 +51,91 @@ func (d *Drawing) SetSlug(slug string) {
 	d.slug = slug
 }
 
+// SetFolder records the folder holding the drawing (to be called by the repository)
+// Drawings are moved between folders with Repository.MoveToFolder, which leaves their version alone
+func (d *Drawing) SetFolder(folderID *uuid.UUID) {
+	d.folderID = folderID
+}
+
+// ChangeSlug replaces the slug with a user-chosen custom slug
+// It does not advance the version; it is saved together with an Update
+func (d *Drawing) ChangeSlug(slug string) error {
+	if err := ValidateSlug(slug); err != nil {
+		return err
+	}
+
+	d.slug = slug
+	d.updatedAt = time.Now().UTC()
+
+	return nil
+}
+
 // Reconstitute creates a drawing from persisted data (for repository use)
-func Reconstitute(id uuid.UUID, slug, name string, data DrawingData, createdAt, updatedAt time.Time) (*Drawing, error) {
+func Reconstitute(id uuid.UUID, slug, name string, data DrawingData, version int64, createdAt, updatedAt time.Time) (*Drawing, error) {
 	d := &Drawing{
 		id:        id,
 		slug:      slug,
 		name:      name,
 		data:      data,
+		version:   version,
 		createdAt: createdAt,
 		updatedAt: updatedAt,
 	}
 
-	if err := d.Validate(); err != nil {
+	// Persisted data is trusted: drawings saved before scene validation must still load
+	if err := d.validateName(); err != nil {
+		return nil, err
+	}
+	if err := d.data.validateEncoding(); err != nil {
 		return nil, err
 	}
 
 	return d, nil
 }
 
-// Update updates the drawing with new data
+// Update updates the drawing with new data and advances its version
 func (d *Drawing) Update(name string, data DrawingData) error {
 	d.name = name
 	d.data = data
+	d.version++
 	d.updatedAt = time.Now().UTC()
 
 	return d.Validate()
 }
 
+// Rename changes the name and advances the version, keeping the data as stored
+// Only the name is validated, so drawings saved before scene validation can still be renamed
+func (d *Drawing) Rename(name string) error {
+	d.name = name
+	d.version++
+	d.updatedAt = time.Now().UTC()
+
+	return d.validateName()
+}
+
+// Restore replaces the name and data with those of an earlier revision and advances the version
+// Revisions are persisted data, so like Reconstitute it accepts scenes saved before scene validation
+func (d *Drawing) Restore(rev *Revision) error {
+	d.name = rev.Name()
+	d.data = rev.Data()
+	d.version++
+	d.updatedAt = time.Now().UTC()
+
+	if err := d.validateName(); err != nil {
+		return err
+	}
+	return d.data.validateEncoding()
+}
+
+// CheckVersion ensures the drawing is still at one of the versions a client accepts
+// No expected versions means the client did not ask for a check
+func (d *Drawing) CheckVersion(expected ...int64) error {
+	if len(expected) == 0 || slices.Contains(expected, d.version) {
+		return nil
+	}
+	return ErrVersionConflict
+}
+
 // Validate ensures the drawing is in a valid state
 func (d *Drawing) Validate() error {
 	// Validate name
@@ -122,6 +186,16 @@ func (d *Drawing) Data() DrawingData {
 	return d.data
 }
 
+// Version returns the drawing version, incremented on every update
+func (d *Drawing) Version() int64 {
+	return d.version
+}
+
+// FolderID returns the ID of the folder holding the drawing; nil outside any folder
+func (d *Drawing) FolderID() *uuid.UUID {
+	return d.folderID
+}
+
 // CreatedAt returns the creation timestamp
 func (d *Drawing) CreatedAt() time.Time {
 	return d.createdAt
@@ -0,0 +1,172 @@
+package drawing
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// EmbeddedSceneMimeType is the PNG text keyword and SVG payload type of a scene embedded in an exported image
+const EmbeddedSceneMimeType = "application/vnd.excalidraw+json"
+
+// Markers delimiting the base64 payload of a scene embedded in SVG metadata, as Excalidraw writes them
+const (
+	svgPayloadStart = "<!-- payload-start -->"
+	svgPayloadEnd   = "<!-- payload-end -->"
+)
+
+// pngSignature starts every PNG file
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// embeddedScene is Excalidraw's envelope of an embedded scene: the .excalidraw file, deflated and
+// stored as a byte string (one character per byte)
+type embeddedScene struct {
+	Version    string `json:"version"`
+	Encoding   string `json:"encoding"`
+	Compressed bool   `json:"compressed"`
+	Encoded    string `json:"encoded"`
+}
+
+// EncodeEmbeddedScene wraps a .excalidraw file in the envelope Excalidraw embeds in images
+// The result is Latin-1 text, as PNG text chunks require
+func EncodeEmbeddedScene(file []byte) ([]byte, error) {
+	var deflated bytes.Buffer
+	zw := zlib.NewWriter(&deflated)
+	if _, err := zw.Write(file); err != nil {
+		return nil, fmt.Errorf("failed to compress scene: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress scene: %w", err)
+	}
+
+	envelope, err := json.Marshal(embeddedScene{
+		Version:    "1",
+		Encoding:   "bstring",
+		Compressed: true,
+		Encoded:    byteString(deflated.Bytes()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode scene: %w", err)
+	}
+	return latin1(string(envelope)), nil
+}
+
+// EmbedSceneSVG returns the metadata element carrying an embedded scene envelope in an SVG image
+func EmbedSceneSVG(envelope []byte) string {
+	return "<metadata><!-- payload-type:" + EmbeddedSceneMimeType + " --><!-- payload-version:2 -->" +
+		svgPayloadStart + base64.StdEncoding.EncodeToString(envelope) + svgPayloadEnd + "</metadata>"
+}
+
+// ExtractEmbeddedScene returns the .excalidraw file embedded in an exported PNG or SVG image
+func ExtractEmbeddedScene(content []byte) ([]byte, error) {
+	var envelope []byte
+	switch {
+	case bytes.HasPrefix(content, pngSignature):
+		text, err := pngText(content, EmbeddedSceneMimeType)
+		if err != nil {
+			return nil, err
+		}
+		envelope = text
+	case bytes.Contains(content, []byte(svgPayloadStart)):
+		_, rest, _ := bytes.Cut(content, []byte(svgPayloadStart))
+		payload, _, ok := bytes.Cut(rest, []byte(svgPayloadEnd))
+		if !ok {
+			return nil, fmt.Errorf("%w: unterminated SVG scene payload", ErrInvalidExcalidrawFile)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(payload)))
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid SVG scene payload", ErrInvalidExcalidrawFile)
+		}
+		envelope = decoded
+	default:
+		return nil, fmt.Errorf("%w: no embedded scene", ErrInvalidExcalidrawFile)
+	}
+
+	return decodeEmbeddedScene(envelope)
+}
+
+// decodeEmbeddedScene unwraps the .excalidraw file from an embedded scene envelope
+// Images exported before the envelope was introduced embed the file itself
+func decodeEmbeddedScene(envelope []byte) ([]byte, error) {
+	text := []byte(fromLatin1(envelope))
+
+	var e embeddedScene
+	if err := json.Unmarshal(text, &e); err != nil {
+		return nil, fmt.Errorf("%w: invalid embedded scene", ErrInvalidExcalidrawFile)
+	}
+	if e.Encoding == "" {
+		return text, nil
+	}
+	if e.Encoding != "bstring" {
+		return nil, fmt.Errorf("%w: unsupported scene encoding %q", ErrInvalidExcalidrawFile, e.Encoding)
+	}
+
+	file := latin1(e.Encoded)
+	if !e.Compressed {
+		// Uncompressed byte strings hold the UTF-8 encoding of the file
+		return file, nil
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(file))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid compressed scene", ErrInvalidExcalidrawFile)
+	}
+	inflated, err := io.ReadAll(io.LimitReader(zr, MaxImportBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid compressed scene", ErrInvalidExcalidrawFile)
+	}
+	if len(inflated) > MaxImportBytes {
+		return nil, fmt.Errorf("%w: embedded scene exceeds %d bytes", ErrInvalidExcalidrawFile, MaxImportBytes)
+	}
+	return inflated, nil
+}
+
+// pngText returns the text of the first tEXt chunk of a PNG image with the given keyword
+func pngText(content []byte, keyword string) ([]byte, error) {
+	rest := content[len(pngSignature):]
+	for len(rest) >= 12 {
+		length := binary.BigEndian.Uint32(rest)
+		if uint64(length)+12 > uint64(len(rest)) {
+			break
+		}
+		kind, data := string(rest[4:8]), rest[8:8+length]
+		if kind == "tEXt" {
+			if key, text, ok := bytes.Cut(data, []byte{0}); ok && string(key) == keyword {
+				return text, nil
+			}
+		}
+		if kind == "IEND" {
+			break
+		}
+		rest = rest[12+length:]
+	}
+	return nil, fmt.Errorf("%w: the PNG image has no embedded scene", ErrInvalidExcalidrawFile)
+}
+
+// byteString stores bytes in a string one character per byte, as JavaScript byte strings do
+func byteString(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+// latin1 encodes a string of characters up to U+00FF one byte per character
+func latin1(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		out = append(out, byte(r))
+	}
+	return out
+}
+
+// fromLatin1 decodes Latin-1 bytes
+func fromLatin1(b []byte) string {
+	return byteString(b)
+}
@@ -17,4 +17,43 @@ var (
 
 	// ErrNameTooLong is returned when a drawing name exceeds maximum length
 	ErrNameTooLong = errors.New("drawing name exceeds maximum length")
+
+	// ErrSlugConflict is returned when a slug is already used by another drawing
+	ErrSlugConflict = errors.New("drawing slug already exists")
+
+	// ErrInvalidSlug is returned when a custom slug is malformed
+	ErrInvalidSlug = errors.New("invalid drawing slug")
+
+	// ErrReservedSlug is returned when a custom slug is a reserved word
+	ErrReservedSlug = errors.New("drawing slug is reserved")
+
+	// ErrVersionConflict is returned when a drawing was changed since the version a client last saw
+	ErrVersionConflict = errors.New("drawing version conflict")
+
+	// ErrConcurrentUpdate is returned when another client saved a drawing while it was being updated
+	ErrConcurrentUpdate = errors.New("drawing was saved concurrently")
+
+	// ErrInvalidPatch is returned when a patch document is malformed
+	ErrInvalidPatch = errors.New("invalid patch document")
+
+	// ErrPatchConflict is returned when a patch does not apply to the current drawing data
+	ErrPatchConflict = errors.New("patch does not apply to drawing")
+
+	// ErrInvalidExportOptions is returned when export options are out of range
+	ErrInvalidExportOptions = errors.New("invalid export options")
+
+	// ErrInvalidExcalidrawFile is returned when an imported file is not a .excalidraw scene
+	ErrInvalidExcalidrawFile = errors.New("invalid excalidraw file")
+
+	// ErrThumbnailNotFound is returned when a drawing has no thumbnail yet
+	ErrThumbnailNotFound = errors.New("drawing thumbnail not found")
+
+	// ErrInvalidCursor is returned when a pagination cursor is malformed
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	// ErrRevisionNotFound is returned when a drawing revision is not found
+	ErrRevisionNotFound = errors.New("drawing revision not found")
+
+	// ErrInvalidRevisionNumber is returned when a revision number is malformed
+	ErrInvalidRevisionNumber = errors.New("invalid revision number")
 )
@@ -0,0 +1,146 @@
+package drawing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// Header fields of .excalidraw files, as written by the Excalidraw editor and desktop app
+const (
+	ExcalidrawFileType    = "excalidraw"
+	ExcalidrawFileVersion = 2
+	ExcalidrawFileSource  = "https://excalidraw.com"
+
+	// ExcalidrawFileExtension is the file name extension of .excalidraw files
+	ExcalidrawFileExtension = ".excalidraw"
+)
+
+// DefaultImportName names drawings imported from files without a usable name
+const DefaultImportName = "Untitled"
+
+// MaxImportBytes limits the size of an import, all files included, and of a scene embedded in an image
+const MaxImportBytes = 64 << 20
+
+// excalidrawFile is the JSON document of a .excalidraw file
+type excalidrawFile struct {
+	Type     string          `json:"type"`
+	Version  int             `json:"version"`
+	Source   string          `json:"source"`
+	Elements json.RawMessage `json:"elements"`
+	AppState json.RawMessage `json:"appState,omitempty"`
+	Files    json.RawMessage `json:"files,omitempty"`
+}
+
+// ParseExcalidrawFile reads the scene of a .excalidraw file into drawing data
+// Only elements, appState and files are kept; legacy element fields are upgraded to the current schema
+func ParseExcalidrawFile(content []byte) (DrawingData, error) {
+	var file excalidrawFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("%w: not a JSON object", ErrInvalidExcalidrawFile)
+	}
+	if file.Type != ExcalidrawFileType {
+		return nil, fmt.Errorf("%w: type must be %q, got %q", ErrInvalidExcalidrawFile, ExcalidrawFileType, file.Type)
+	}
+	if len(file.Elements) == 0 {
+		return nil, fmt.Errorf("%w: elements are missing", ErrInvalidExcalidrawFile)
+	}
+
+	data := DrawingData{}
+	for key, raw := range map[string]json.RawMessage{"elements": file.Elements, "appState": file.AppState, "files": file.Files} {
+		if len(raw) == 0 || string(raw) == "null" {
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, fmt.Errorf("%w: invalid %s", ErrInvalidExcalidrawFile, key)
+		}
+		data[key] = v
+	}
+
+	// Files predate the scene schema registry, so every upgrade applies
+	if err := UpgradeScene(data, 1); err != nil {
+		return nil, err
+	}
+	if err := data.Validate(); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// ParseSceneFile reads the scene of an imported file: a .excalidraw file, or a PNG or SVG image
+// exported with the scene embedded
+func ParseSceneFile(content []byte) (DrawingData, error) {
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		return ParseExcalidrawFile(content)
+	}
+
+	file, err := ExtractEmbeddedScene(content)
+	if err != nil {
+		return nil, err
+	}
+	return ParseExcalidrawFile(file)
+}
+
+// NewExcalidrawFile writes drawing data as a .excalidraw file the Excalidraw editor and desktop app can open
+func NewExcalidrawFile(data DrawingData) ([]byte, error) {
+	// Members are written in the order of files saved by the editor
+	file := struct {
+		Type     string      `json:"type"`
+		Version  int         `json:"version"`
+		Source   string      `json:"source"`
+		Elements interface{} `json:"elements"`
+		AppState interface{} `json:"appState"`
+		Files    interface{} `json:"files"`
+	}{
+		Type:     ExcalidrawFileType,
+		Version:  ExcalidrawFileVersion,
+		Source:   ExcalidrawFileSource,
+		Elements: valueOrEmpty(data["elements"], []interface{}{}),
+		AppState: valueOrEmpty(data["appState"], map[string]interface{}{}),
+		Files:    valueOrEmpty(data["files"], map[string]interface{}{}),
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(file); err != nil {
+		return nil, fmt.Errorf("%w: cannot marshal file: %v", ErrInvalidDrawingData, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// valueOrEmpty returns v, or empty when v is missing
+func valueOrEmpty(v, empty interface{}) interface{} {
+	if v == nil {
+		return empty
+	}
+	return v
+}
+
+// ImportName derives a drawing name from the name of an imported file: its base name without the extension
+// Images exported with an embedded scene are named like "flow.excalidraw.png", so both extensions are removed
+func ImportName(filename string) string {
+	name := path.Base(strings.ReplaceAll(filename, `\`, "/"))
+	name = strings.TrimSuffix(name, path.Ext(name))
+	name = strings.TrimSuffix(name, ExcalidrawFileExtension)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		return DefaultImportName
+	}
+
+	// Cut long names at a character boundary
+	if len(name) > MaxNameLength {
+		name = name[:MaxNameLength]
+		for !utf8.ValidString(name) {
+			name = name[:len(name)-1]
+		}
+	}
+	return name
+}
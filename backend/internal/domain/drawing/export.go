@@ -0,0 +1,65 @@
+package drawing
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	// DefaultExportPadding is the space around the elements of an export, as in Excalidraw
+	DefaultExportPadding = 10
+
+	// MaxExportPadding is the largest padding an export accepts
+	MaxExportPadding = 1000
+
+	// MaxExportScale is the largest scale a raster export accepts
+	MaxExportScale = 4
+
+	// MaxExportPixels is the largest number of pixels a raster export may have
+	MaxExportPixels = 16 * 1024 * 1024
+)
+
+// ExportOptions controls how a scene is rendered for export
+type ExportOptions struct {
+	// Padding is the space around the elements, in scene units
+	Padding float64
+
+	// DarkMode renders the scene with the colors of the editor's dark theme
+	DarkMode bool
+
+	// Scale is the number of pixels per scene unit of raster exports; 0 means 1
+	Scale float64
+
+	// MaxDimension, when set, scales raster exports down so neither side exceeds it in pixels
+	MaxDimension int
+
+	// EmbeddedScene, when set, is the scene envelope (see EncodeEmbeddedScene) PNG and SVG exports
+	// carry so the image can be opened again as an editable drawing
+	EmbeddedScene []byte
+}
+
+// Validate checks the export options are in range
+func (o ExportOptions) Validate() error {
+	if o.Padding < 0 || o.Padding > MaxExportPadding {
+		return fmt.Errorf("%w: padding must be between 0 and %d", ErrInvalidExportOptions, MaxExportPadding)
+	}
+	if o.Scale < 0 || o.Scale > MaxExportScale {
+		return fmt.Errorf("%w: scale must be between 0 and %d", ErrInvalidExportOptions, MaxExportScale)
+	}
+	if o.MaxDimension < 0 {
+		return fmt.Errorf("%w: max dimension cannot be negative", ErrInvalidExportOptions)
+	}
+	return nil
+}
+
+// Renderer renders scenes to image formats
+type Renderer interface {
+	// RenderSVG renders the scene as an SVG document
+	RenderSVG(ctx context.Context, scene *Scene, opts ExportOptions) ([]byte, error)
+
+	// RenderPNG renders the scene as a PNG image
+	RenderPNG(ctx context.Context, scene *Scene, opts ExportOptions) ([]byte, error)
+
+	// RenderPDF renders the scene as a vector PDF with one page per frame, or a single page without frames
+	RenderPDF(ctx context.Context, scene *Scene, opts ExportOptions) ([]byte, error)
+}
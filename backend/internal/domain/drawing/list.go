@@ -0,0 +1,119 @@
+package drawing
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SortField is a key drawings can be listed by
+type SortField string
+
+const (
+	// SortByName orders drawings alphabetically by name
+	SortByName SortField = "name"
+
+	// SortByCreatedAt orders drawings by creation time
+	SortByCreatedAt SortField = "created_at"
+
+	// SortByUpdatedAt orders drawings by the time of their last change
+	SortByUpdatedAt SortField = "updated_at"
+
+	// SortBySize orders drawings by the size of their scene
+	SortBySize SortField = "size"
+)
+
+// SortFields lists the keys drawings can be listed by
+var SortFields = []SortField{SortByName, SortByCreatedAt, SortByUpdatedAt, SortBySize}
+
+// Valid reports whether f is a key drawings can be listed by
+func (f SortField) Valid() bool {
+	for _, field := range SortFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultDescending reports the direction a list sorted by f takes when none is given:
+// names read A to Z, while times and sizes put the newest and largest first
+func (f SortField) DefaultDescending() bool {
+	return f != SortByName
+}
+
+// key returns the sort key of a drawing as text
+func (f SortField) key(s *DrawingSummary) string {
+	switch f {
+	case SortByName:
+		return s.Name
+	case SortByUpdatedAt:
+		return s.UpdatedAt.UTC().Format(cursorTimeFormat)
+	case SortBySize:
+		return strconv.FormatInt(s.ByteSize, 10)
+	default:
+		return s.CreatedAt.UTC().Format(cursorTimeFormat)
+	}
+}
+
+// validKey reports whether key is a well-formed sort key for f
+func (f SortField) validKey(key string) bool {
+	switch f {
+	case SortByName:
+		return true
+	case SortByCreatedAt, SortByUpdatedAt:
+		_, err := time.Parse(cursorTimeFormat, key)
+		return err == nil
+	case SortBySize:
+		_, err := strconv.ParseInt(key, 10, 64)
+		return err == nil
+	default:
+		return false
+	}
+}
+
+// SortOrder is the order of a list of drawings
+type SortOrder struct {
+	Field      SortField
+	Descending bool
+}
+
+// DefaultSortOrder lists the newest drawings first
+var DefaultSortOrder = SortOrder{Field: SortByCreatedAt, Descending: true}
+
+// DrawingFilter narrows a list of drawings; zero values leave a criterion out
+type DrawingFilter struct {
+	// NamePrefix keeps the drawings whose name starts with it, ignoring case
+	NamePrefix string
+
+	// NameContains keeps the drawings whose name contains it, ignoring case
+	NameContains string
+
+	// CreatedAfter and CreatedBefore bound the creation time, the first inclusively
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+
+	// UpdatedAfter and UpdatedBefore bound the time of the last change, the first inclusively
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+
+	// MinElements and MaxElements bound the number of elements that are not deleted, inclusively
+	MinElements *int
+	MaxElements *int
+
+	// FolderID keeps the drawings directly inside a folder
+	FolderID *uuid.UUID
+
+	// Unfiled keeps the drawings outside any folder
+	Unfiled bool
+}
+
+// SummaryQuery selects the drawings of a list, their order and the heavy fields to include
+type SummaryQuery struct {
+	Filter DrawingFilter
+	Order  SortOrder
+
+	// Fields lists the heavy fields to include; the summaries leave the scene out by default
+	Fields []SummaryField
+}
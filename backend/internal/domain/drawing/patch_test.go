@@ -0,0 +1,167 @@
+package drawing
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestApplyJSONPatch(t *testing.T) {
+	original := `{"elements":[{"id":"a","x":1},{"id":"b","x":2}],"appState":{"theme":"light","a/b":1,"m~n":2}}`
+
+	tests := []struct {
+		name        string
+		patch       string
+		expected    string
+		expectedErr error
+	}{
+		{
+			name:     "add member",
+			patch:    `[{"op":"add","path":"/appState/gridSize","value":20}]`,
+			expected: `{"elements":[{"id":"a","x":1},{"id":"b","x":2}],"appState":{"theme":"light","a/b":1,"m~n":2,"gridSize":20}}`,
+		},
+		{
+			name:     "add inserts into an array",
+			patch:    `[{"op":"add","path":"/elements/1","value":{"id":"c"}}]`,
+			expected: `{"elements":[{"id":"a","x":1},{"id":"c"},{"id":"b","x":2}],"appState":{"theme":"light","a/b":1,"m~n":2}}`,
+		},
+		{
+			name:     "add appends with -",
+			patch:    `[{"op":"add","path":"/elements/-","value":{"id":"c"}}]`,
+			expected: `{"elements":[{"id":"a","x":1},{"id":"b","x":2},{"id":"c"}],"appState":{"theme":"light","a/b":1,"m~n":2}}`,
+		},
+		{
+			name:     "remove array element",
+			patch:    `[{"op":"remove","path":"/elements/0"}]`,
+			expected: `{"elements":[{"id":"b","x":2}],"appState":{"theme":"light","a/b":1,"m~n":2}}`,
+		},
+		{
+			name:     "replace with escaped tokens",
+			patch:    `[{"op":"replace","path":"/appState/a~1b","value":3},{"op":"replace","path":"/appState/m~0n","value":4}]`,
+			expected: `{"elements":[{"id":"a","x":1},{"id":"b","x":2}],"appState":{"theme":"light","a/b":3,"m~n":4}}`,
+		},
+		{
+			name:     "move",
+			patch:    `[{"op":"move","from":"/elements/0","path":"/elements/1"}]`,
+			expected: `{"elements":[{"id":"b","x":2},{"id":"a","x":1}],"appState":{"theme":"light","a/b":1,"m~n":2}}`,
+		},
+		{
+			name:     "copy is independent of its source",
+			patch:    `[{"op":"copy","from":"/elements/0","path":"/elements/-"},{"op":"replace","path":"/elements/2/x","value":9}]`,
+			expected: `{"elements":[{"id":"a","x":1},{"id":"b","x":2},{"id":"a","x":9}],"appState":{"theme":"light","a/b":1,"m~n":2}}`,
+		},
+		{
+			name:     "passing test",
+			patch:    `[{"op":"test","path":"/elements/1/x","value":2},{"op":"remove","path":"/appState"}]`,
+			expected: `{"elements":[{"id":"a","x":1},{"id":"b","x":2}]}`,
+		},
+		{
+			name:        "failing test leaves the data untouched",
+			patch:       `[{"op":"remove","path":"/appState"},{"op":"test","path":"/elements/1/x","value":3}]`,
+			expectedErr: ErrPatchConflict,
+		},
+		{name: "replace missing member", patch: `[{"op":"replace","path":"/appState/zoom","value":1}]`, expectedErr: ErrPatchConflict},
+		{name: "remove missing index", patch: `[{"op":"remove","path":"/elements/5"}]`, expectedErr: ErrPatchConflict},
+		{name: "index out of range", patch: `[{"op":"add","path":"/elements/3","value":{}}]`, expectedErr: ErrPatchConflict},
+		{name: "leading zero index", patch: `[{"op":"remove","path":"/elements/01"}]`, expectedErr: ErrInvalidPatch},
+		{name: "move into own child", patch: `[{"op":"move","from":"/elements","path":"/elements/0"}]`, expectedErr: ErrInvalidPatch},
+		{name: "replace root with non-object", patch: `[{"op":"replace","path":"","value":[]}]`, expectedErr: ErrInvalidDrawingData},
+		{name: "missing value", patch: `[{"op":"add","path":"/x"}]`, expectedErr: ErrInvalidPatch},
+		{name: "missing from", patch: `[{"op":"copy","path":"/x"}]`, expectedErr: ErrInvalidPatch},
+		{name: "missing path", patch: `[{"op":"remove"}]`, expectedErr: ErrInvalidPatch},
+		{name: "relative path", patch: `[{"op":"remove","path":"elements"}]`, expectedErr: ErrInvalidPatch},
+		{name: "unknown op", patch: `[{"op":"increment","path":"/x"}]`, expectedErr: ErrInvalidPatch},
+		{name: "not an array", patch: `{"op":"remove","path":"/x"}`, expectedErr: ErrInvalidPatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := mustData(t, original)
+
+			patched, err := data.ApplyJSONPatch([]byte(tt.patch))
+			if !reflect.DeepEqual(data, mustData(t, original)) {
+				t.Errorf("expected the original data to be left untouched, got %v", data)
+			}
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(patched, mustData(t, tt.expected)) {
+				t.Errorf("expected %s, got %v", tt.expected, patched)
+			}
+		})
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	original := `{"elements":[{"id":"a"}],"appState":{"theme":"light","gridSize":20}}`
+
+	tests := []struct {
+		name        string
+		patch       string
+		expected    string
+		expectedErr error
+	}{
+		{
+			name:     "merge nested members",
+			patch:    `{"appState":{"theme":"dark","zoom":{"value":2}}}`,
+			expected: `{"elements":[{"id":"a"}],"appState":{"theme":"dark","gridSize":20,"zoom":{"value":2}}}`,
+		},
+		{
+			name:     "null removes a member",
+			patch:    `{"appState":{"gridSize":null}}`,
+			expected: `{"elements":[{"id":"a"}],"appState":{"theme":"light"}}`,
+		},
+		{
+			name:     "arrays are replaced",
+			patch:    `{"elements":[{"id":"b"}]}`,
+			expected: `{"elements":[{"id":"b"}],"appState":{"theme":"light","gridSize":20}}`,
+		},
+		{
+			name:     "empty patch changes nothing",
+			patch:    `{}`,
+			expected: original,
+		},
+		{name: "non-object patch replaces the root", patch: `[1]`, expectedErr: ErrInvalidDrawingData},
+		{name: "invalid JSON", patch: `{`, expectedErr: ErrInvalidPatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := mustData(t, original)
+
+			patched, err := data.ApplyMergePatch([]byte(tt.patch))
+			if !reflect.DeepEqual(data, mustData(t, original)) {
+				t.Errorf("expected the original data to be left untouched, got %v", data)
+			}
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(patched, mustData(t, tt.expected)) {
+				t.Errorf("expected %s, got %v", tt.expected, patched)
+			}
+		})
+	}
+}
+
+// mustData parses drawing data from JSON
+func mustData(t *testing.T, raw string) DrawingData {
+	t.Helper()
+	var data DrawingData
+	if err := json.Unmarshal([]byte(raw), &data); err != nil {
+		t.Fatalf("invalid test data: %v", err)
+	}
+	return data
+}
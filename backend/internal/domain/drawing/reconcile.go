@@ -0,0 +1,113 @@
+package drawing
+
+import (
+	"fmt"
+)
+
+// Reconcile merges an incoming scene into the stored one the way Excalidraw reconciles
+// remote scenes, so concurrent saves from several clients do not overwrite each other.
+//
+// Elements are matched by id. The copy with the higher version wins; on equal versions
+// the lower versionNonce wins, which is the tie-break every Excalidraw client uses.
+// Deleted elements are kept as isDeleted tombstones by the same rule, so a client that
+// has not seen a deletion cannot resurrect the element. Elements the incoming scene does
+// not know about are kept next to their previous neighbours. Files are merged by id and
+// every other member (appState, ...) is taken from the incoming scene
+func (d DrawingData) Reconcile(incoming DrawingData) (DrawingData, error) {
+	stored, err := d.Scene()
+	if err != nil {
+		// The stored scene is not the client's fault, so it must not read as invalid input
+		return nil, fmt.Errorf("stored scene is invalid: %v", err)
+	}
+	merged, err := incoming.Scene()
+	if err != nil {
+		return nil, err
+	}
+
+	merged.Elements = reconcileElements(stored.Elements, merged.Elements)
+
+	if len(stored.Files) > 0 {
+		files := make(map[string]*File, len(stored.Files)+len(merged.Files))
+		for id, f := range stored.Files {
+			files[id] = f
+		}
+		for id, f := range merged.Files {
+			files[id] = f
+		}
+		merged.Files = files
+	}
+
+	return NewDrawingData(merged)
+}
+
+// reconcileElements merges two element lists, keeping the incoming order
+func reconcileElements(stored, incoming []*Element) []*Element {
+	storedByID := make(map[string]*Element, len(stored))
+	for _, el := range stored {
+		storedByID[el.ID] = el
+	}
+
+	// Pick the winning copy of every incoming element
+	winners := make([]*Element, 0, len(incoming))
+	seen := make(map[string]bool, len(incoming))
+	for _, el := range incoming {
+		if seen[el.ID] {
+			continue
+		}
+		seen[el.ID] = true
+
+		if local, ok := storedByID[el.ID]; ok && !supersedes(el, local) {
+			el = local
+		}
+		winners = append(winners, el)
+	}
+
+	// Group the stored elements the incoming scene does not know about under the
+	// closest element before them in the stored scene that it does know about
+	var leading []*Element
+	following := make(map[string][]*Element)
+	kept := make(map[string]bool)
+	anchor := ""
+	for _, el := range stored {
+		if seen[el.ID] {
+			anchor = el.ID
+			continue
+		}
+		if kept[el.ID] {
+			continue
+		}
+		kept[el.ID] = true
+
+		if anchor == "" {
+			leading = append(leading, el)
+		} else {
+			following[anchor] = append(following[anchor], el)
+		}
+	}
+
+	result := make([]*Element, 0, len(winners)+len(stored))
+	result = append(result, leading...)
+	for _, el := range winners {
+		result = append(result, el)
+		result = append(result, following[el.ID]...)
+	}
+
+	return result
+}
+
+// supersedes reports whether the remote copy of an element wins over the local one
+func supersedes(remote, local *Element) bool {
+	remoteVersion, localVersion := derefInt(remote.Version), derefInt(local.Version)
+	if remoteVersion != localVersion {
+		return remoteVersion > localVersion
+	}
+	return derefInt(remote.VersionNonce) <= derefInt(local.VersionNonce)
+}
+
+// derefInt returns the value of an optional integer, or 0
+func derefInt(v *int64) int64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
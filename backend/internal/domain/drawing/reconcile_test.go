@@ -0,0 +1,130 @@
+package drawing
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestReconcile(t *testing.T) {
+	tests := []struct {
+		name     string
+		stored   string
+		incoming string
+		expected []string // id:version:nonce of the merged elements, in order
+	}{
+		{
+			name:     "higher version wins",
+			stored:   `[{"type":"rectangle","id":"a","version":3,"versionNonce":9}]`,
+			incoming: `[{"type":"rectangle","id":"a","version":2,"versionNonce":1}]`,
+			expected: []string{"a:3:9"},
+		},
+		{
+			name:     "higher incoming version wins",
+			stored:   `[{"type":"rectangle","id":"a","version":2,"versionNonce":1}]`,
+			incoming: `[{"type":"rectangle","id":"a","version":3,"versionNonce":9}]`,
+			expected: []string{"a:3:9"},
+		},
+		{
+			name:     "equal versions keep the lower stored nonce",
+			stored:   `[{"type":"rectangle","id":"a","version":2,"versionNonce":1}]`,
+			incoming: `[{"type":"rectangle","id":"a","version":2,"versionNonce":5}]`,
+			expected: []string{"a:2:1"},
+		},
+		{
+			name:     "equal versions take the lower incoming nonce",
+			stored:   `[{"type":"rectangle","id":"a","version":2,"versionNonce":5}]`,
+			incoming: `[{"type":"rectangle","id":"a","version":2,"versionNonce":1}]`,
+			expected: []string{"a:2:1"},
+		},
+		{
+			name:     "missing versions count as zero",
+			stored:   `[{"type":"rectangle","id":"a","version":1}]`,
+			incoming: `[{"type":"rectangle","id":"a"}]`,
+			expected: []string{"a:1:0"},
+		},
+		{
+			name:     "incoming order is kept",
+			stored:   `[{"type":"rectangle","id":"a","version":1},{"type":"rectangle","id":"b","version":1}]`,
+			incoming: `[{"type":"rectangle","id":"b","version":1},{"type":"rectangle","id":"a","version":1}]`,
+			expected: []string{"b:1:0", "a:1:0"},
+		},
+		{
+			name:     "unknown stored elements follow their previous neighbour",
+			stored:   `[{"type":"rectangle","id":"x","version":1},{"type":"rectangle","id":"a","version":1},{"type":"rectangle","id":"y","version":1},{"type":"rectangle","id":"z","version":1},{"type":"rectangle","id":"b","version":1}]`,
+			incoming: `[{"type":"rectangle","id":"b","version":1},{"type":"rectangle","id":"a","version":1},{"type":"rectangle","id":"c","version":1}]`,
+			expected: []string{"x:1:0", "b:1:0", "a:1:0", "y:1:0", "z:1:0", "c:1:0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored := mustData(t, elementsScene(tt.stored))
+			incoming := mustData(t, elementsScene(tt.incoming))
+
+			merged, err := stored.Reconcile(incoming)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			scene, err := merged.Scene()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := make([]string, len(scene.Elements))
+			for i, el := range scene.Elements {
+				got[i] = fmt.Sprintf("%s:%d:%d", el.ID, derefInt(el.Version), derefInt(el.VersionNonce))
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestReconcileKeepsTombstonesAndFiles(t *testing.T) {
+	stored := mustData(t, `{"elements":[{"id":"a","type":"image","fileId":"f1","version":4,"isDeleted":true}],"files":{"f1":{"id":"f1","mimeType":"image/png","dataURL":"data:image/png;base64,AA=="}},"appState":{"theme":"light"}}`)
+	incoming := mustData(t, `{"elements":[{"id":"a","type":"image","fileId":"f1","version":3}],"files":{"f2":{"id":"f2","mimeType":"image/png","dataURL":"data:image/png;base64,AQ=="}},"appState":{"theme":"dark"}}`)
+
+	merged, err := stored.Reconcile(incoming)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	scene, err := merged.Scene()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if el := scene.Elements[0]; el.IsDeleted == nil || !*el.IsDeleted {
+		t.Errorf("expected the newer deletion to win, got %+v", el)
+	}
+	if len(scene.Files) != 2 {
+		t.Errorf("expected files from both scenes, got %v", scene.Files)
+	}
+	if theme := merged["appState"].(map[string]interface{})["theme"]; theme != "dark" {
+		t.Errorf("expected the incoming appState, got theme %v", theme)
+	}
+}
+
+func TestReconcileInvalidScenes(t *testing.T) {
+	valid := mustData(t, elementsScene(`[{"type":"rectangle","id":"a"}]`))
+	invalid := mustData(t, elementsScene(`[{"type":"rectangle","id":""}]`))
+
+	// A malformed incoming scene is the client's fault
+	if _, err := valid.Reconcile(invalid); !errors.Is(err, ErrInvalidDrawingData) {
+		t.Errorf("expected ErrInvalidDrawingData, got %v", err)
+	}
+
+	// A malformed stored scene is not
+	_, err := invalid.Reconcile(valid)
+	var sceneErr *SceneError
+	if err == nil || errors.Is(err, ErrInvalidDrawingData) || errors.As(err, &sceneErr) {
+		t.Errorf("expected an internal error, got %v", err)
+	}
+}
+
+// elementsScene returns a scene JSON document holding the given elements
+func elementsScene(elements string) string {
+	return `{"elements":` + elements + `}`
+}
@@ -2,14 +2,15 @@ package drawing
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 // Repository defines the contract for drawing persistence
 type Repository interface {
-	// Create stores a new drawing
-	Create(ctx context.Context, drawing *Drawing) error
+	// Create stores a new drawing along with its first revision, in one transaction
+	Create(ctx context.Context, drawing *Drawing, revision *Revision) error
 
 	// FindByID retrieves a drawing by ID
 	FindByID(ctx context.Context, id uuid.UUID) (*Drawing, error)
@@ -17,15 +18,89 @@ type Repository interface {
 	// FindBySlug retrieves a drawing by slug
 	FindBySlug(ctx context.Context, slug string) (*Drawing, error)
 
-	// FindAll retrieves all drawings with pagination
-	FindAll(ctx context.Context, limit, offset int) ([]*Drawing, error)
+	// FindBySlugAlias retrieves a drawing by one of its previous slugs
+	FindBySlugAlias(ctx context.Context, slug string) (*Drawing, error)
 
-	// Update updates an existing drawing
-	Update(ctx context.Context, drawing *Drawing) error
+	// FindSummaries retrieves summaries of the drawings matching a query with pagination, in query order
+	// The scene is left out, except for the fields asked for
+	FindSummaries(ctx context.Context, query SummaryQuery, limit, offset int) ([]*DrawingSummary, error)
+
+	// FindSummariesByCursor retrieves up to limit summaries of the drawings matching a query next to
+	// a cursor, in query order. A nil cursor starts at the first drawing
+	FindSummariesByCursor(ctx context.Context, query SummaryQuery, cursor *Cursor, limit int) ([]*DrawingSummary, error)
+
+	// Search retrieves the drawings whose name or scene text matches a full-text search query,
+	// best match first, with pagination
+	Search(ctx context.Context, query string, limit, offset int) ([]*SearchResult, error)
+
+	// CountSearch returns the number of drawings matching a full-text search query
+	CountSearch(ctx context.Context, query string) (int64, error)
+
+	// Update updates an existing drawing and records it in its revision history, in one transaction
+	// A revision without a number is stored as a new revision; a numbered one, which the save was
+	// coalesced into, overwrites the stored revision. When the slug changes, the previous slug is kept as an alias
+	// Returns ErrConcurrentUpdate if the drawing was saved since it was loaded
+	Update(ctx context.Context, drawing *Drawing, revision *Revision) error
 
 	// Delete removes a drawing by ID
-	Delete(ctx context.Context, id uuid.UUID) error
+	// The stored version must be one of expectedVersions, if any, or ErrVersionConflict is returned
+	Delete(ctx context.Context, id uuid.UUID, expectedVersions []int64) error
+
+	// MoveToFolder places a drawing in a folder, or outside any folder when folderID is nil
+	// Moving is not an edit: the version of the drawing is left alone, only its update time moves on
+	MoveToFolder(ctx context.Context, id uuid.UUID, folderID *uuid.UUID) error
+
+	// Count returns the number of drawings matching a filter
+	Count(ctx context.Context, filter DrawingFilter) (int64, error)
+
+	// FindWithoutSlug retrieves up to limit drawings that have no slug yet
+	FindWithoutSlug(ctx context.Context, limit int) ([]*Drawing, error)
+
+	// UpdateSlug sets the slug of an existing drawing
+	UpdateSlug(ctx context.Context, id uuid.UUID, slug string) error
+
+	// FindWithOutdatedSchema retrieves up to limit drawings stored with a scene schema
+	// older than CurrentSceneSchema, already upgraded in memory
+	FindWithOutdatedSchema(ctx context.Context, limit int) ([]*Drawing, error)
+
+	// RewriteData stores the data of a drawing at CurrentSceneSchema without changing its version
+	// It reports false, writing nothing, if the drawing was saved or deleted since it was read
+	RewriteData(ctx context.Context, d *Drawing) (bool, error)
+}
+
+// RevisionRepository defines the contract for drawing revision persistence
+type RevisionRepository interface {
+	// FindByDrawingID retrieves the revisions of a drawing, newest first, with pagination
+	FindByDrawingID(ctx context.Context, drawingID uuid.UUID, limit, offset int) ([]*Revision, error)
+
+	// FindByNumber retrieves a single revision of a drawing
+	FindByNumber(ctx context.Context, drawingID uuid.UUID, number int) (*Revision, error)
+
+	// CountByDrawingID returns the number of revisions of a drawing
+	CountByDrawingID(ctx context.Context, drawingID uuid.UUID) (int64, error)
+
+	// FindLatestMeta retrieves the metadata of the most recent revision of a drawing, without its data
+	FindLatestMeta(ctx context.Context, drawingID uuid.UUID) (*RevisionMeta, error)
+
+	// FindCompactionCandidates retrieves IDs of drawings whose revisions may have aged into a coarser
+	// retention bucket of the policy since the drawings were last compacted
+	FindCompactionCandidates(ctx context.Context, policy RevisionPolicy, now time.Time) ([]uuid.UUID, error)
+
+	// MarkCompacted records that the revisions of a drawing were compacted at the given time
+	MarkCompacted(ctx context.Context, drawingID uuid.UUID, at time.Time) error
+
+	// FindMetaByDrawingID retrieves the lightweight metadata of all revisions of a drawing
+	FindMetaByDrawingID(ctx context.Context, drawingID uuid.UUID) ([]RevisionMeta, error)
+
+	// DeleteByIDs removes revisions by ID
+	DeleteByIDs(ctx context.Context, ids []uuid.UUID) error
+}
+
+// ThumbnailRepository defines the contract for drawing thumbnail persistence
+type ThumbnailRepository interface {
+	// Save stores the thumbnail of a drawing, unless a thumbnail of a newer version is already stored
+	Save(ctx context.Context, thumbnail *Thumbnail) error
 
-	// Count returns the total number of drawings
-	Count(ctx context.Context) (int64, error)
+	// FindByDrawingID retrieves the thumbnail of a drawing
+	FindByDrawingID(ctx context.Context, drawingID uuid.UUID) (*Thumbnail, error)
 }
@@ -0,0 +1,104 @@
+package drawing
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RevisionPolicy controls how many revisions are recorded and kept for a drawing
+type RevisionPolicy struct {
+	// CoalesceWindow is how long a client's consecutive saves keep overwriting
+	// the revision they started instead of recording new ones
+	CoalesceWindow time.Duration
+
+	// KeepAll is the age below which every revision is kept
+	KeepAll time.Duration
+
+	// KeepHourly is the age below which one revision per hour is kept;
+	// older revisions are thinned to one per day
+	KeepHourly time.Duration
+}
+
+// RevisionMeta is the lightweight view of a revision, without its data, used for coalescing and compaction
+type RevisionMeta struct {
+	ID           uuid.UUID
+	Number       int
+	RestoredFrom int
+	ClientID     string
+	CreatedAt    time.Time
+}
+
+// CanCoalesce reports whether a save by clientID at the given time should
+// overwrite the latest revision instead of recording a new one
+func (p RevisionPolicy) CanCoalesce(latest *RevisionMeta, clientID string, at time.Time) bool {
+	if latest == nil || clientID == "" || p.CoalesceWindow <= 0 {
+		return false
+	}
+
+	// Restores are explicit user actions and are never folded into autosaves
+	if latest.RestoredFrom != 0 || latest.ClientID != clientID {
+		return false
+	}
+
+	return at.Sub(latest.CreatedAt) < p.CoalesceWindow
+}
+
+// Prune returns the revisions the policy discards at the given time
+// Within each hourly or daily bucket the newest revision survives, and the
+// newest revision overall is always kept since it mirrors the drawing
+func (p RevisionPolicy) Prune(revisions []RevisionMeta, now time.Time) []RevisionMeta {
+	if len(revisions) < 2 {
+		return nil
+	}
+
+	newest := revisions[0]
+	for _, r := range revisions[1:] {
+		if r.Number > newest.Number {
+			newest = r
+		}
+	}
+
+	// Find the newest revision of every bucket
+	keep := make(map[time.Time]RevisionMeta)
+	for _, r := range revisions {
+		bucket, ok := p.bucket(r.CreatedAt, now)
+		if !ok {
+			continue
+		}
+		if current, exists := keep[bucket]; !exists || r.Number > current.Number {
+			keep[bucket] = r
+		}
+	}
+
+	var pruned []RevisionMeta
+	for _, r := range revisions {
+		if r.Number == newest.Number {
+			continue
+		}
+		bucket, ok := p.bucket(r.CreatedAt, now)
+		if !ok {
+			continue
+		}
+		if keep[bucket].Number != r.Number {
+			pruned = append(pruned, r)
+		}
+	}
+
+	return pruned
+}
+
+// bucket returns the retention bucket of a revision created at the given time,
+// or false when the revision is young enough to be kept unconditionally
+func (p RevisionPolicy) bucket(createdAt, now time.Time) (time.Time, bool) {
+	age := now.Sub(createdAt)
+
+	switch {
+	case age < p.KeepAll:
+		return time.Time{}, false
+	case age < p.KeepHourly:
+		return createdAt.UTC().Truncate(time.Hour), true
+	default:
+		return createdAt.UTC().Truncate(24 * time.Hour), true
+	}
+}
@@ -0,0 +1,103 @@
+package drawing
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRevisionPolicyPrune(t *testing.T) {
+	policy := RevisionPolicy{KeepAll: time.Hour, KeepHourly: 24 * time.Hour}
+	now := time.Date(2025, 12, 10, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		policy   RevisionPolicy
+		ages     map[int]time.Duration // revision number to age
+		expected []int                 // numbers pruned
+	}{
+		{
+			name:   "single revision",
+			policy: policy,
+			ages:   map[int]time.Duration{1: 30 * 24 * time.Hour},
+		},
+		{
+			name:   "all within the keep-all window",
+			policy: policy,
+			ages:   map[int]time.Duration{1: 50 * time.Minute, 2: 30 * time.Minute, 3: time.Minute},
+		},
+		{
+			name:     "newest of each hour survives",
+			policy:   policy,
+			ages:     map[int]time.Duration{1: 5*time.Hour + 25*time.Minute, 2: 5*time.Hour + 10*time.Minute, 3: 4*time.Hour + 20*time.Minute, 4: time.Minute},
+			expected: []int{1},
+		},
+		{
+			name:     "newest of each day survives",
+			policy:   policy,
+			ages:     map[int]time.Duration{1: 3*24*time.Hour + 10*time.Hour, 2: 3*24*time.Hour + time.Hour, 3: 2*24*time.Hour + 11*time.Hour, 4: time.Minute},
+			expected: []int{1},
+		},
+		{
+			name:     "zero windows keep one revision per day",
+			policy:   RevisionPolicy{},
+			ages:     map[int]time.Duration{1: 2 * time.Hour, 2: time.Hour, 3: time.Minute},
+			expected: []int{1, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var revisions []RevisionMeta
+			for number, age := range tt.ages {
+				revisions = append(revisions, RevisionMeta{ID: uuid.New(), Number: number, CreatedAt: now.Add(-age)})
+			}
+
+			var pruned []int
+			for _, r := range tt.policy.Prune(revisions, now) {
+				pruned = append(pruned, r.Number)
+			}
+			sort.Ints(pruned)
+
+			if len(pruned) != len(tt.expected) {
+				t.Fatalf("expected %v pruned, got %v", tt.expected, pruned)
+			}
+			for i := range pruned {
+				if pruned[i] != tt.expected[i] {
+					t.Fatalf("expected %v pruned, got %v", tt.expected, pruned)
+				}
+			}
+		})
+	}
+}
+
+func TestRevisionPolicyCanCoalesce(t *testing.T) {
+	policy := RevisionPolicy{CoalesceWindow: time.Minute}
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name     string
+		policy   RevisionPolicy
+		latest   *RevisionMeta
+		clientID string
+		expected bool
+	}{
+		{name: "same client within window", policy: policy, latest: &RevisionMeta{ClientID: "tab-1", CreatedAt: now.Add(-30 * time.Second)}, clientID: "tab-1", expected: true},
+		{name: "window elapsed", policy: policy, latest: &RevisionMeta{ClientID: "tab-1", CreatedAt: now.Add(-time.Minute)}, clientID: "tab-1"},
+		{name: "other client", policy: policy, latest: &RevisionMeta{ClientID: "tab-1", CreatedAt: now}, clientID: "tab-2"},
+		{name: "anonymous client", policy: policy, latest: &RevisionMeta{CreatedAt: now}},
+		{name: "restore", policy: policy, latest: &RevisionMeta{ClientID: "tab-1", RestoredFrom: 2, CreatedAt: now}, clientID: "tab-1"},
+		{name: "no revision yet", policy: policy, clientID: "tab-1"},
+		{name: "coalescing disabled", latest: &RevisionMeta{ClientID: "tab-1", CreatedAt: now}, clientID: "tab-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.CanCoalesce(tt.latest, tt.clientID, now); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
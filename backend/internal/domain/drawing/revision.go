@@ -0,0 +1,113 @@
+package drawing
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Revision is an immutable snapshot of a drawing taken after it was created or changed
+type Revision struct {
+	id           uuid.UUID
+	drawingID    uuid.UUID
+	number       int
+	name         string
+	data         DrawingData
+	restoredFrom int
+	clientID     string
+	createdAt    time.Time
+}
+
+// NewRevision captures the current state of a drawing saved by the given client
+// The revision number is assigned by the repository when the revision is stored
+func NewRevision(d *Drawing, clientID string) *Revision {
+	return &Revision{
+		id:        uuid.New(),
+		drawingID: d.ID(),
+		name:      d.Name(),
+		data:      d.Data(),
+		clientID:  clientID,
+		createdAt: d.UpdatedAt(),
+	}
+}
+
+// NewRestoredRevision captures the state of a drawing that was restored from an earlier revision
+func NewRestoredRevision(d *Drawing, restoredFrom int) *Revision {
+	r := NewRevision(d, "")
+	r.restoredFrom = restoredFrom
+	return r
+}
+
+// ReconstituteRevision creates a revision from persisted data (for repository use)
+func ReconstituteRevision(id, drawingID uuid.UUID, number int, name string, data DrawingData, restoredFrom int, clientID string, createdAt time.Time) *Revision {
+	return &Revision{
+		id:           id,
+		drawingID:    drawingID,
+		number:       number,
+		name:         name,
+		data:         data,
+		restoredFrom: restoredFrom,
+		clientID:     clientID,
+		createdAt:    createdAt,
+	}
+}
+
+// NewCoalescedRevision folds a newer save of the drawing into the revision described by latest
+// The revision keeps its number and creation time, so a burst of autosaves
+// is stored as a single revision holding the latest state
+func NewCoalescedRevision(d *Drawing, latest RevisionMeta) *Revision {
+	return &Revision{
+		id:        latest.ID,
+		drawingID: d.ID(),
+		number:    latest.Number,
+		name:      d.Name(),
+		data:      d.Data(),
+		clientID:  latest.ClientID,
+		createdAt: latest.CreatedAt,
+	}
+}
+
+// SetNumber sets the revision number (to be called by repository)
+func (r *Revision) SetNumber(number int) {
+	r.number = number
+}
+
+// ID returns the revision ID
+func (r *Revision) ID() uuid.UUID {
+	return r.id
+}
+
+// DrawingID returns the ID of the drawing this revision belongs to
+func (r *Revision) DrawingID() uuid.UUID {
+	return r.drawingID
+}
+
+// Number returns the revision number, starting at 1 for each drawing
+func (r *Revision) Number() int {
+	return r.number
+}
+
+// Name returns the drawing name at this revision
+func (r *Revision) Name() string {
+	return r.name
+}
+
+// Data returns the drawing data at this revision
+func (r *Revision) Data() DrawingData {
+	return r.data
+}
+
+// RestoredFrom returns the revision number this revision was restored from, or 0
+func (r *Revision) RestoredFrom() int {
+	return r.restoredFrom
+}
+
+// ClientID returns the identifier of the client that saved this revision, if known
+func (r *Revision) ClientID() string {
+	return r.clientID
+}
+
+// CreatedAt returns the creation timestamp
+func (r *Revision) CreatedAt() time.Time {
+	return r.createdAt
+}
@@ -0,0 +1,383 @@
+package drawing
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Scene is the typed model of the Excalidraw scene stored as DrawingData
+// Every type keeps the members it does not model, so a scene round-trips losslessly
+type Scene struct {
+	Type     *string          `json:"type,omitempty"`
+	Version  *int64           `json:"version,omitempty"`
+	Source   *string          `json:"source,omitempty"`
+	Elements []*Element       `json:"elements"`
+	AppState *AppState        `json:"appState,omitempty"`
+	Files    map[string]*File `json:"files,omitempty"`
+
+	raw rawObject
+}
+
+// Element is a single Excalidraw element (shape, arrow, text, image, frame, ...)
+type Element struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+
+	// Geometry
+	X      *float64    `json:"x,omitempty"`
+	Y      *float64    `json:"y,omitempty"`
+	Width  *float64    `json:"width,omitempty"`
+	Height *float64    `json:"height,omitempty"`
+	Angle  *float64    `json:"angle,omitempty"`
+	Points [][]float64 `json:"points,omitempty"`
+
+	// Style
+	StrokeColor     *string    `json:"strokeColor,omitempty"`
+	BackgroundColor *string    `json:"backgroundColor,omitempty"`
+	FillStyle       *string    `json:"fillStyle,omitempty"`
+	StrokeWidth     *float64   `json:"strokeWidth,omitempty"`
+	StrokeStyle     *string    `json:"strokeStyle,omitempty"`
+	Roughness       *float64   `json:"roughness,omitempty"`
+	Opacity         *float64   `json:"opacity,omitempty"`
+	Seed            *int64     `json:"seed,omitempty"`
+	Roundness       *Roundness `json:"roundness,omitempty"`
+
+	// Lines, arrows and freedraw
+	StartArrowhead *string   `json:"startArrowhead,omitempty"`
+	EndArrowhead   *string   `json:"endArrowhead,omitempty"`
+	Pressures      []float64 `json:"pressures,omitempty"`
+
+	// Versioning, used to reconcile concurrent edits
+	Version      *int64 `json:"version,omitempty"`
+	VersionNonce *int64 `json:"versionNonce,omitempty"`
+	IsDeleted    *bool  `json:"isDeleted,omitempty"`
+
+	// Grouping and bindings
+	GroupIDs      []string       `json:"groupIds,omitempty"`
+	FrameID       *string        `json:"frameId,omitempty"`
+	ContainerID   *string        `json:"containerId,omitempty"`
+	BoundElements []BoundElement `json:"boundElements,omitempty"`
+	StartBinding  *Binding       `json:"startBinding,omitempty"`
+	EndBinding    *Binding       `json:"endBinding,omitempty"`
+
+	// Text
+	Text          *string  `json:"text,omitempty"`
+	OriginalText  *string  `json:"originalText,omitempty"`
+	FontSize      *float64 `json:"fontSize,omitempty"`
+	FontFamily    *int     `json:"fontFamily,omitempty"`
+	TextAlign     *string  `json:"textAlign,omitempty"`
+	VerticalAlign *string  `json:"verticalAlign,omitempty"`
+	LineHeight    *float64 `json:"lineHeight,omitempty"`
+
+	// Image
+	FileID *string `json:"fileId,omitempty"`
+
+	// Frame
+	Name *string `json:"name,omitempty"`
+
+	raw rawObject
+}
+
+// BoundElement references a text or arrow element bound to a container or shape
+type BoundElement struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// Roundness describes how the corners of an element are rounded
+type Roundness struct {
+	Type  int      `json:"type"`
+	Value *float64 `json:"value,omitempty"`
+}
+
+// Binding attaches an end of an arrow to another element
+type Binding struct {
+	ElementID string   `json:"elementId"`
+	Focus     *float64 `json:"focus,omitempty"`
+	Gap       *float64 `json:"gap,omitempty"`
+}
+
+// AppState holds the editor settings saved with a scene
+type AppState struct {
+	ViewBackgroundColor *string  `json:"viewBackgroundColor,omitempty"`
+	GridSize            *float64 `json:"gridSize,omitempty"`
+	Theme               *string  `json:"theme,omitempty"`
+	Name                *string  `json:"name,omitempty"`
+
+	raw rawObject
+}
+
+// File is a binary file (usually an image) embedded in a scene as a data URL
+type File struct {
+	ID       *string  `json:"id,omitempty"`
+	MimeType *string  `json:"mimeType,omitempty"`
+	DataURL  *string  `json:"dataURL,omitempty"`
+	Created  *float64 `json:"created,omitempty"`
+
+	raw rawObject
+}
+
+// rawObject holds the members of a JSON object in their original encoding
+type rawObject map[string]json.RawMessage
+
+// ParseScene parses drawing data into a typed scene and validates its structure
+// Invalid scenes return a *SceneError listing every invalid field
+func ParseScene(data []byte) (*Scene, error) {
+	var members rawObject
+	if err := json.Unmarshal(data, &members); err != nil || members == nil {
+		return nil, newSceneError("", "must be a JSON object")
+	}
+
+	s := &Scene{raw: members}
+	errs := &SceneError{}
+
+	decodeMember(members, "type", &s.Type, errs)
+	decodeMember(members, "version", &s.Version, errs)
+	decodeMember(members, "source", &s.Source, errs)
+
+	if raw, ok := members["elements"]; ok {
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			errs.add("elements", "must be an array")
+		}
+		for i, item := range items {
+			el := &Element{}
+			if err := json.Unmarshal(item, el); err != nil {
+				errs.addDecodeError(fmt.Sprintf("elements[%d]", i), err)
+			}
+			s.Elements = append(s.Elements, el)
+		}
+	}
+
+	if raw, ok := members["appState"]; ok && !isNull(raw) {
+		s.AppState = &AppState{}
+		if err := json.Unmarshal(raw, s.AppState); err != nil {
+			errs.addDecodeError("appState", err)
+		}
+	}
+
+	if raw, ok := members["files"]; ok && !isNull(raw) {
+		var files map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &files); err != nil {
+			errs.add("files", "must be an object")
+		}
+		s.Files = make(map[string]*File, len(files))
+		for id, item := range files {
+			f := &File{}
+			if err := json.Unmarshal(item, f); err != nil {
+				errs.addDecodeError(fmt.Sprintf("files[%s]", id), err)
+			}
+			s.Files[id] = f
+		}
+	}
+
+	s.validate(errs)
+	if len(errs.Fields) > 0 {
+		return nil, errs
+	}
+
+	return s, nil
+}
+
+// Scene parses the drawing data into a typed scene
+func (d DrawingData) Scene() (*Scene, error) {
+	raw, err := json.Marshal(d)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot marshal to JSON: %v", ErrInvalidDrawingData, err)
+	}
+	return ParseScene(raw)
+}
+
+// NewDrawingData converts a typed scene back to drawing data
+func NewDrawingData(s *Scene) (DrawingData, error) {
+	raw, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot marshal scene: %v", ErrInvalidDrawingData, err)
+	}
+	return FromJSON(raw)
+}
+
+// MarshalJSON encodes the scene together with the members it does not model
+func (s *Scene) MarshalJSON() ([]byte, error) {
+	type plain Scene
+	p := plain(*s)
+
+	// Scenes always have an elements array, even once every element is removed
+	if p.Elements == nil {
+		p.Elements = []*Element{}
+	}
+	return encodeObject(&p, s.raw)
+}
+
+// UnmarshalJSON decodes the element, keeping the members it does not model
+func (e *Element) UnmarshalJSON(data []byte) error {
+	type plain Element
+	raw, err := decodeObject(data, (*plain)(e))
+	e.raw = raw
+	return err
+}
+
+// MarshalJSON encodes the element together with the members it does not model
+func (e *Element) MarshalJSON() ([]byte, error) {
+	type plain Element
+	return encodeObject((*plain)(e), e.raw)
+}
+
+// IsLinear reports whether the element is drawn from a list of points
+func (e *Element) IsLinear() bool {
+	return e.Type == "line" || e.Type == "arrow" || e.Type == "freedraw"
+}
+
+// Arrowheads returns the arrowheads drawn at the start and end of a linear element ("" for none)
+// Arrows saved before arrowheads were configurable have no endArrowhead member and end in an arrow
+func (e *Element) Arrowheads() (start, end string) {
+	if e.StartArrowhead != nil {
+		start = *e.StartArrowhead
+	}
+	if e.EndArrowhead != nil {
+		end = *e.EndArrowhead
+	} else if _, ok := e.raw["endArrowhead"]; !ok && e.Type == "arrow" {
+		end = "arrow"
+	}
+	return start, end
+}
+
+// Deleted reports whether the element is a tombstone
+func (e *Element) Deleted() bool {
+	return e.IsDeleted != nil && *e.IsDeleted
+}
+
+// UnmarshalJSON decodes the app state, keeping the members it does not model
+func (a *AppState) UnmarshalJSON(data []byte) error {
+	type plain AppState
+	raw, err := decodeObject(data, (*plain)(a))
+	a.raw = raw
+	return err
+}
+
+// MarshalJSON encodes the app state together with the members it does not model
+func (a *AppState) MarshalJSON() ([]byte, error) {
+	type plain AppState
+	return encodeObject((*plain)(a), a.raw)
+}
+
+// UnmarshalJSON decodes the file, keeping the members it does not model
+func (f *File) UnmarshalJSON(data []byte) error {
+	type plain File
+	raw, err := decodeObject(data, (*plain)(f))
+	f.raw = raw
+	return err
+}
+
+// MarshalJSON encodes the file together with the members it does not model
+func (f *File) MarshalJSON() ([]byte, error) {
+	type plain File
+	return encodeObject((*plain)(f), f.raw)
+}
+
+// errNotObject is returned when a JSON value that must be an object is not
+var errNotObject = errors.New("must be an object")
+
+// decodeObject decodes data into v and returns every member in its original encoding
+func decodeObject(data []byte, v interface{}) (rawObject, error) {
+	var raw rawObject
+	if err := json.Unmarshal(data, &raw); err != nil || raw == nil {
+		return nil, errNotObject
+	}
+	return raw, json.Unmarshal(data, v)
+}
+
+// encodeObject encodes v over the original members, so members v does not model keep their
+// original encoding. Modelled members come from v alone, so clearing one removes it; only a member
+// that was already empty, such as a null frameId, keeps its encoding when v leaves it out
+func encodeObject(v interface{}, raw rawObject) ([]byte, error) {
+	typed, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var members rawObject
+	if err := json.Unmarshal(typed, &members); err != nil {
+		return nil, err
+	}
+
+	modelled := modelledKeys(reflect.TypeOf(v).Elem())
+	out := make(rawObject, len(raw)+len(members))
+	for key, value := range raw {
+		if modelled[key] && !isEmpty(value) {
+			continue
+		}
+		out[key] = value
+	}
+	for key, value := range members {
+		out[key] = value
+	}
+
+	return json.Marshal(out)
+}
+
+// modelledKeyCache holds the JSON member names of each struct type passed to modelledKeys
+var modelledKeyCache sync.Map
+
+// modelledKeys returns the JSON member names of the fields of a struct type
+func modelledKeys(t reflect.Type) map[string]bool {
+	if keys, ok := modelledKeyCache.Load(t); ok {
+		return keys.(map[string]bool)
+	}
+
+	keys := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = field.Name
+		}
+		keys[name] = true
+	}
+
+	modelledKeyCache.Store(t, keys)
+	return keys
+}
+
+// isEmpty reports whether a raw JSON value is one that omitempty leaves out: null, false, 0, "", [] or {}
+func isEmpty(raw json.RawMessage) bool {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err != nil {
+		return false
+	}
+	switch value := compact.String(); value {
+	case "null", "false", `""`, "[]", "{}":
+		return true
+	default:
+		n, err := strconv.ParseFloat(value, 64)
+		return err == nil && n == 0
+	}
+}
+
+// decodeMember decodes one top-level scene member into v, recording a field error on mismatch
+func decodeMember(members rawObject, key string, v interface{}, errs *SceneError) {
+	raw, ok := members[key]
+	if !ok {
+		return
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		errs.addDecodeError(key, err)
+	}
+}
+
+// isNull reports whether a raw JSON value is null
+func isNull(raw json.RawMessage) bool {
+	return string(raw) == "null"
+}
@@ -0,0 +1,110 @@
+package drawing
+
+import (
+	"fmt"
+)
+
+// CurrentSceneSchema is the scene schema version drawings are upgraded to when read
+const CurrentSceneSchema = 3
+
+// SceneUpgrade upgrades scene data from schema version From to From+1
+type SceneUpgrade struct {
+	From        int
+	Description string
+	Apply       func(data DrawingData)
+}
+
+// sceneUpgrades is the registry of scene upgrades, one per schema version below CurrentSceneSchema
+// Add an entry and bump CurrentSceneSchema whenever the stored scene format changes
+var sceneUpgrades = []SceneUpgrade{
+	{From: 1, Description: "replace boundElementIds with boundElements", Apply: upgradeBoundElementIDs},
+	{From: 2, Description: "replace strokeSharpness with roundness", Apply: upgradeStrokeSharpness},
+}
+
+// SceneUpgrades returns the registered scene upgrades in order
+func SceneUpgrades() []SceneUpgrade {
+	return append([]SceneUpgrade(nil), sceneUpgrades...)
+}
+
+// UpgradeScene upgrades data stored at schema version from to CurrentSceneSchema in place
+func UpgradeScene(data DrawingData, from int) error {
+	if from < 1 || from > CurrentSceneSchema {
+		return fmt.Errorf("%w: unknown scene schema version %d", ErrInvalidDrawingData, from)
+	}
+
+	for _, u := range sceneUpgrades {
+		if u.From >= from {
+			u.Apply(data)
+		}
+	}
+
+	return nil
+}
+
+// sceneElements returns the element objects of a scene, skipping malformed entries
+func sceneElements(data DrawingData) []map[string]interface{} {
+	list, _ := data["elements"].([]interface{})
+	elements := make([]map[string]interface{}, 0, len(list))
+	for _, item := range list {
+		if el, ok := item.(map[string]interface{}); ok {
+			elements = append(elements, el)
+		}
+	}
+	return elements
+}
+
+// upgradeBoundElementIDs converts the pre-2022 boundElementIds list of arrow ids to boundElements
+func upgradeBoundElementIDs(data DrawingData) {
+	for _, el := range sceneElements(data) {
+		ids, ok := el["boundElementIds"]
+		if !ok {
+			continue
+		}
+		delete(el, "boundElementIds")
+
+		if _, ok := el["boundElements"]; ok {
+			continue
+		}
+		list, _ := ids.([]interface{})
+		bound := make([]interface{}, 0, len(list))
+		for _, id := range list {
+			if s, ok := id.(string); ok {
+				bound = append(bound, map[string]interface{}{"id": s, "type": "arrow"})
+			}
+		}
+		el["boundElements"] = bound
+	}
+}
+
+// Roundness types, as defined by Excalidraw
+const (
+	RoundnessProportionalRadius = 2
+	RoundnessAdaptiveRadius     = 3
+)
+
+// upgradeStrokeSharpness converts the pre-2023 strokeSharpness ("round" or "sharp") to roundness
+func upgradeStrokeSharpness(data DrawingData) {
+	for _, el := range sceneElements(data) {
+		sharpness, ok := el["strokeSharpness"]
+		if !ok {
+			continue
+		}
+		delete(el, "strokeSharpness")
+
+		if el["roundness"] != nil {
+			continue
+		}
+		if sharpness != "round" {
+			el["roundness"] = nil
+			continue
+		}
+
+		// Rectangles and images keep a fixed corner radius; other shapes scale it with their size
+		roundnessType := RoundnessProportionalRadius
+		switch el["type"] {
+		case "rectangle", "image", "embeddable", "iframe":
+			roundnessType = RoundnessAdaptiveRadius
+		}
+		el["roundness"] = map[string]interface{}{"type": float64(roundnessType)}
+	}
+}
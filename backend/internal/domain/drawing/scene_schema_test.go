@@ -0,0 +1,96 @@
+package drawing
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestUpgradeScene(t *testing.T) {
+	upgrades := SceneUpgrades()
+	for i, u := range upgrades {
+		if u.From != i+1 {
+			t.Errorf("upgrade %d: expected From %d, got %d", i, i+1, u.From)
+		}
+	}
+	if len(upgrades)+1 != CurrentSceneSchema {
+		t.Errorf("expected %d upgrades for schema %d, got %d", CurrentSceneSchema-1, CurrentSceneSchema, len(upgrades))
+	}
+
+	legacy := func() DrawingData {
+		return DrawingData{
+			"elements": []interface{}{
+				map[string]interface{}{"id": "rect-1", "type": "rectangle", "strokeSharpness": "round", "boundElementIds": []interface{}{"arrow-1"}},
+				map[string]interface{}{"id": "ellipse-1", "type": "ellipse", "strokeSharpness": "round"},
+				map[string]interface{}{"id": "arrow-1", "type": "arrow", "strokeSharpness": "sharp"},
+			},
+		}
+	}
+
+	t.Run("upgrades from schema 1", func(t *testing.T) {
+		data := legacy()
+		if err := UpgradeScene(data, 1); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		elements := data["elements"].([]interface{})
+		rect := elements[0].(map[string]interface{})
+		if _, ok := rect["boundElementIds"]; ok {
+			t.Error("expected boundElementIds to be removed")
+		}
+		want := []interface{}{map[string]interface{}{"id": "arrow-1", "type": "arrow"}}
+		if !reflect.DeepEqual(rect["boundElements"], want) {
+			t.Errorf("expected boundElements %v, got %v", want, rect["boundElements"])
+		}
+		if !reflect.DeepEqual(rect["roundness"], map[string]interface{}{"type": float64(RoundnessAdaptiveRadius)}) {
+			t.Errorf("unexpected rectangle roundness %v", rect["roundness"])
+		}
+
+		ellipse := elements[1].(map[string]interface{})
+		if !reflect.DeepEqual(ellipse["roundness"], map[string]interface{}{"type": float64(RoundnessProportionalRadius)}) {
+			t.Errorf("unexpected ellipse roundness %v", ellipse["roundness"])
+		}
+
+		arrow := elements[2].(map[string]interface{})
+		if r, ok := arrow["roundness"]; !ok || r != nil {
+			t.Errorf("expected null roundness for sharp arrow, got %v", r)
+		}
+		if _, err := data.Scene(); err != nil {
+			t.Errorf("expected upgraded scene to be valid, got %v", err)
+		}
+	})
+
+	t.Run("skips upgrades already applied", func(t *testing.T) {
+		data := legacy()
+		if err := UpgradeScene(data, 2); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		rect := data["elements"].([]interface{})[0].(map[string]interface{})
+		if _, ok := rect["boundElementIds"]; !ok {
+			t.Error("expected boundElementIds to be left alone from schema 2")
+		}
+		if _, ok := rect["strokeSharpness"]; ok {
+			t.Error("expected strokeSharpness to be upgraded from schema 2")
+		}
+	})
+
+	t.Run("current schema is unchanged", func(t *testing.T) {
+		data := legacy()
+		if err := UpgradeScene(data, CurrentSceneSchema); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(data, legacy()) {
+			t.Errorf("expected data to be unchanged, got %v", data)
+		}
+	})
+
+	t.Run("unknown schema version", func(t *testing.T) {
+		for _, version := range []int{0, CurrentSceneSchema + 1} {
+			err := UpgradeScene(legacy(), version)
+			if !errors.Is(err, ErrInvalidDrawingData) {
+				t.Errorf("version %d: expected ErrInvalidDrawingData, got %v", version, err)
+			}
+		}
+	})
+}
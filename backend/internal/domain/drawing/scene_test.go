@@ -0,0 +1,93 @@
+package drawing
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSceneRoundTrip(t *testing.T) {
+	raw := `{"appState":{"gridSize":null,"viewBackgroundColor":"#fff","zoom":{"value":1.5}},"elements":[{"boundElements":[],"customData":{"k":[1,2]},"groupIds":[],"id":"a","isDeleted":false,"type":"rectangle","version":3,"x":10}],"files":{"f1":{"created":1,"dataURL":"data:image/png;base64,AA==","id":"f1","mimeType":"image/png","status":"saved"}},"source":"https://excalidraw.com","type":"excalidraw","version":2}`
+
+	scene, err := ParseScene([]byte(raw))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(scene.Elements) != 1 || scene.Elements[0].ID != "a" || *scene.Elements[0].X != 10 {
+		t.Errorf("expected typed element a at x=10, got %+v", scene.Elements)
+	}
+
+	out, err := json.Marshal(scene)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(out) != raw {
+		t.Errorf("expected lossless round-trip\nwant %s\ngot  %s", raw, out)
+	}
+
+	// Typed edits are written back while unknown members survive
+	x := 42.0
+	scene.Elements[0].X = &x
+	data, err := NewDrawingData(scene)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	element := data["elements"].([]interface{})[0].(map[string]interface{})
+	if element["x"] != 42.0 || element["customData"] == nil {
+		t.Errorf("expected edited x and preserved customData, got %v", element)
+	}
+}
+
+func TestSceneClearsModelledMembers(t *testing.T) {
+	raw := `{"elements":[{"id":"a","type":"text","text":"hi","frameId":"f1","containerId":"box","groupIds":["g"],"startBinding":null,"customData":{"k":1}}],"files":{"f1":{"id":"f1","mimeType":"image/png","dataURL":"data:image/png;base64,AA=="}}}`
+
+	scene, err := ParseScene([]byte(raw))
+	if err != nil {
+		t.Fatalf("ParseScene failed: %v", err)
+	}
+
+	el := scene.Elements[0]
+	el.FrameID = nil
+	el.ContainerID = nil
+	el.GroupIDs = nil
+	scene.Files = nil
+
+	out, err := json.Marshal(scene)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var got struct {
+		Elements []map[string]json.RawMessage `json:"elements"`
+		Files    json.RawMessage              `json:"files"`
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("invalid scene JSON: %v", err)
+	}
+	if got.Files != nil {
+		t.Errorf("expected files to be cleared, got %s", got.Files)
+	}
+	members := got.Elements[0]
+	for _, key := range []string{"frameId", "containerId", "groupIds"} {
+		if value, ok := members[key]; ok {
+			t.Errorf("expected %s to be cleared, got %s", key, value)
+		}
+	}
+
+	// Members the model does not know, and modelled ones that were already empty, are kept
+	if string(members["customData"]) != `{"k":1}` {
+		t.Errorf("expected customData to be kept, got %s", members["customData"])
+	}
+	if string(members["startBinding"]) != "null" {
+		t.Errorf("expected the null startBinding to be kept, got %s", members["startBinding"])
+	}
+
+	// Removing every element still leaves an elements array
+	scene.Elements = nil
+	if out, err = json.Marshal(scene); err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if _, err := ParseScene(out); err != nil {
+		t.Errorf("expected a scene without elements to stay valid, got %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package drawing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// maxSceneFieldErrors caps how many invalid fields a SceneError reports
+const maxSceneFieldErrors = 50
+
+// FieldError describes a single invalid field of a scene
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// SceneError lists the invalid fields of a scene; it matches ErrInvalidDrawingData with errors.Is
+type SceneError struct {
+	Fields []FieldError
+}
+
+// newSceneError creates a SceneError for a single field
+func newSceneError(field, message string) *SceneError {
+	e := &SceneError{}
+	e.add(field, message)
+	return e
+}
+
+// Error lists the invalid fields
+func (e *SceneError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		if f.Field == "" {
+			parts[i] = f.Message
+		} else {
+			parts[i] = f.Field + " " + f.Message
+		}
+	}
+	return ErrInvalidDrawingData.Error() + ": " + strings.Join(parts, "; ")
+}
+
+// Unwrap makes SceneError match ErrInvalidDrawingData
+func (e *SceneError) Unwrap() error {
+	return ErrInvalidDrawingData
+}
+
+// add records an invalid field
+func (e *SceneError) add(field, message string) {
+	if len(e.Fields) < maxSceneFieldErrors {
+		e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+	}
+}
+
+// addDecodeError records a JSON type mismatch below prefix
+func (e *SceneError) addDecodeError(prefix string, err error) {
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		e.add(prefix+"."+typeErr.Field, "must be "+describeType(typeErr.Type))
+	case errors.As(err, &typeErr):
+		e.add(prefix, "must be "+describeType(typeErr.Type))
+	case errors.Is(err, errNotObject):
+		e.add(prefix, err.Error())
+	default:
+		e.add(prefix, "is invalid")
+	}
+}
+
+// describeType names the JSON type expected for a Go type
+func describeType(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
+
+// validate checks the structural rules of the scene
+func (s *Scene) validate(errs *SceneError) {
+	if _, ok := s.raw["elements"]; !ok {
+		errs.add("elements", "is required")
+	}
+
+	if s.Type != nil && *s.Type != "excalidraw" {
+		errs.add("type", `must be "excalidraw"`)
+	}
+
+	ids := make(map[string]bool, len(s.Elements))
+	for i, el := range s.Elements {
+		prefix := fmt.Sprintf("elements[%d]", i)
+		if el.ID != "" {
+			if ids[el.ID] {
+				errs.add(prefix+".id", fmt.Sprintf("duplicates id %q", el.ID))
+			}
+			ids[el.ID] = true
+		}
+		el.validate(prefix, errs)
+	}
+
+	for id, f := range s.Files {
+		f.validate(fmt.Sprintf("files[%s]", id), id, errs)
+	}
+}
+
+// validate checks the structural rules of a single element
+func (e *Element) validate(prefix string, errs *SceneError) {
+	if e.raw == nil {
+		// Not an object; the decode error was already recorded
+		return
+	}
+
+	if e.ID == "" {
+		errs.add(prefix+".id", "is required")
+	}
+	if e.Type == "" {
+		errs.add(prefix+".type", "is required")
+	}
+
+	if e.Width != nil && *e.Width < 0 {
+		errs.add(prefix+".width", "must not be negative")
+	}
+	if e.Height != nil && *e.Height < 0 {
+		errs.add(prefix+".height", "must not be negative")
+	}
+	if e.StrokeWidth != nil && *e.StrokeWidth < 0 {
+		errs.add(prefix+".strokeWidth", "must not be negative")
+	}
+	if e.Roughness != nil && *e.Roughness < 0 {
+		errs.add(prefix+".roughness", "must not be negative")
+	}
+	if e.Opacity != nil && (*e.Opacity < 0 || *e.Opacity > 100) {
+		errs.add(prefix+".opacity", "must be between 0 and 100")
+	}
+
+	for i, p := range e.Points {
+		if len(p) != 2 {
+			errs.add(fmt.Sprintf("%s.points[%d]", prefix, i), "must be an [x, y] pair")
+		}
+	}
+
+	if e.Type == "text" && e.Text == nil {
+		errs.add(prefix+".text", "is required for text elements")
+	}
+
+	for i, b := range e.BoundElements {
+		if b.ID == "" || b.Type == "" {
+			errs.add(fmt.Sprintf("%s.boundElements[%d]", prefix, i), "must have an id and a type")
+		}
+	}
+	if e.StartBinding != nil && e.StartBinding.ElementID == "" {
+		errs.add(prefix+".startBinding.elementId", "is required")
+	}
+	if e.EndBinding != nil && e.EndBinding.ElementID == "" {
+		errs.add(prefix+".endBinding.elementId", "is required")
+	}
+}
+
+// validate checks the structural rules of an embedded file stored under key
+func (f *File) validate(prefix, key string, errs *SceneError) {
+	if f.raw == nil {
+		return
+	}
+
+	if f.ID != nil && *f.ID != key {
+		errs.add(prefix+".id", "must match its key in files")
+	}
+	if f.MimeType == nil || *f.MimeType == "" {
+		errs.add(prefix+".mimeType", "is required")
+	}
+	if f.DataURL == nil || *f.DataURL == "" {
+		errs.add(prefix+".dataURL", "is required")
+	}
+}
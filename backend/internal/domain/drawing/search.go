@@ -0,0 +1,35 @@
+package drawing
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxSearchQueryLength is the longest full-text search query accepted, in characters
+const MaxSearchQueryLength = 200
+
+// SearchResult is a drawing matching a full-text search of drawing names and scene text
+type SearchResult struct {
+	ID      uuid.UUID
+	Slug    string
+	Name    string
+	Version int64
+
+	// Rank scores how well the drawing matches; matches in the name weigh more than in the scene
+	Rank float64
+
+	// Snippet is an excerpt of the name and scene text around the matches
+	Snippet []SnippetFragment
+
+	// ElementIDs lists the text elements and frames whose text matches, in scene order
+	ElementIDs []string
+
+	UpdatedAt time.Time
+}
+
+// SnippetFragment is a run of snippet text, either a match of the search or the text between matches
+type SnippetFragment struct {
+	Text  string
+	Match bool
+}
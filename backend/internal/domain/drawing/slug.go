@@ -0,0 +1,65 @@
+package drawing
+
+import "fmt"
+
+const (
+	// MinSlugLength is the minimum allowed length for a custom slug
+	MinSlugLength = 3
+
+	// MaxSlugLength is the maximum allowed length for a slug (matches the slug column)
+	MaxSlugLength = 50
+)
+
+// reservedSlugs are words that cannot be used as custom slugs because they
+// clash with routes or would be confusing in shared URLs
+var reservedSlugs = map[string]struct{}{
+	"admin":     {},
+	"api":       {},
+	"auth":      {},
+	"by-slug":   {},
+	"drawing":   {},
+	"drawings":  {},
+	"edit":      {},
+	"export":    {},
+	"generate":  {},
+	"health":    {},
+	"import":    {},
+	"new":       {},
+	"revisions": {},
+	"search":    {},
+}
+
+// ValidateSlug checks that a custom slug is well formed
+// Custom slugs use lowercase letters, digits and single hyphens between words
+func ValidateSlug(slug string) error {
+	if len(slug) < MinSlugLength {
+		return fmt.Errorf("%w: must be at least %d characters", ErrInvalidSlug, MinSlugLength)
+	}
+
+	if len(slug) > MaxSlugLength {
+		return fmt.Errorf("%w: must be at most %d characters", ErrInvalidSlug, MaxSlugLength)
+	}
+
+	for i := 0; i < len(slug); i++ {
+		c := slug[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+			continue
+		case c == '-':
+			if i == 0 || i == len(slug)-1 {
+				return fmt.Errorf("%w: cannot start or end with a hyphen", ErrInvalidSlug)
+			}
+			if slug[i-1] == '-' {
+				return fmt.Errorf("%w: cannot contain consecutive hyphens", ErrInvalidSlug)
+			}
+		default:
+			return fmt.Errorf("%w: only lowercase letters, digits and hyphens are allowed", ErrInvalidSlug)
+		}
+	}
+
+	if _, reserved := reservedSlugs[slug]; reserved {
+		return ErrReservedSlug
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package drawing
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateSlug(t *testing.T) {
+	tests := []struct {
+		name        string
+		slug        string
+		expectedErr error
+	}{
+		{name: "words and digits", slug: "q3-roadmap-2025"},
+		{name: "shortest", slug: "abc"},
+		{name: "longest", slug: strings.Repeat("a", MaxSlugLength)},
+		{name: "too short", slug: "ab", expectedErr: ErrInvalidSlug},
+		{name: "empty", slug: "", expectedErr: ErrInvalidSlug},
+		{name: "too long", slug: strings.Repeat("a", MaxSlugLength+1), expectedErr: ErrInvalidSlug},
+		{name: "uppercase", slug: "Roadmap", expectedErr: ErrInvalidSlug},
+		{name: "underscore", slug: "road_map", expectedErr: ErrInvalidSlug},
+		{name: "non-ASCII", slug: "café-notes", expectedErr: ErrInvalidSlug},
+		{name: "leading hyphen", slug: "-roadmap", expectedErr: ErrInvalidSlug},
+		{name: "trailing hyphen", slug: "roadmap-", expectedErr: ErrInvalidSlug},
+		{name: "consecutive hyphens", slug: "road--map", expectedErr: ErrInvalidSlug},
+		{name: "reserved", slug: "search", expectedErr: ErrReservedSlug},
+		{name: "reserved with hyphen", slug: "by-slug", expectedErr: ErrReservedSlug},
+		{name: "reserved word inside a slug", slug: "api-notes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSlug(tt.slug)
+			if tt.expectedErr == nil {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
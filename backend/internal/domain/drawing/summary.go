@@ -0,0 +1,63 @@
+package drawing
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SummaryField is a heavy field of a drawing that summaries leave out unless it is asked for
+type SummaryField string
+
+const (
+	// SummaryFieldData is the whole scene of the drawing
+	SummaryFieldData SummaryField = "data"
+
+	// SummaryFieldElements is the elements member of the scene
+	SummaryFieldElements SummaryField = "elements"
+
+	// SummaryFieldAppState is the appState member of the scene
+	SummaryFieldAppState SummaryField = "appState"
+
+	// SummaryFieldFiles is the files member of the scene, holding embedded images
+	SummaryFieldFiles SummaryField = "files"
+)
+
+// SummaryFields lists the fields summaries can include
+var SummaryFields = []SummaryField{SummaryFieldData, SummaryFieldElements, SummaryFieldAppState, SummaryFieldFiles}
+
+// Valid reports whether f is a field summaries can include
+func (f SummaryField) Valid() bool {
+	for _, field := range SummaryFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// DrawingSummary is a lightweight projection of a drawing for lists, without its scene
+type DrawingSummary struct {
+	ID      uuid.UUID
+	Slug    string
+	Name    string
+	Version int64
+
+	// FolderID is the folder holding the drawing; nil outside any folder
+	FolderID *uuid.UUID
+
+	// ElementCount is the number of elements in the scene that are not deleted
+	ElementCount int
+
+	// ByteSize is the size of the scene encoded as JSON
+	ByteSize int64
+
+	// ThumbnailVersion is the version of the drawing the stored thumbnail shows; 0 if there is none
+	ThumbnailVersion int64
+
+	// Data holds the scene, or the members of it asked for with summary fields; nil otherwise
+	Data DrawingData
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
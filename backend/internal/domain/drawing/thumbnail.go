@@ -0,0 +1,68 @@
+package drawing
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ThumbnailMaxDimension is the largest width or height of a thumbnail, in pixels
+const ThumbnailMaxDimension = 320
+
+// Thumbnail is a small PNG preview of a drawing, rendered from one of its versions
+type Thumbnail struct {
+	drawingID uuid.UUID
+	image     []byte
+	version   int64
+	createdAt time.Time
+}
+
+// NewThumbnail creates a thumbnail of the given version of a drawing
+func NewThumbnail(drawingID uuid.UUID, image []byte, version int64) *Thumbnail {
+	return &Thumbnail{
+		drawingID: drawingID,
+		image:     image,
+		version:   version,
+		createdAt: time.Now().UTC(),
+	}
+}
+
+// ReconstituteThumbnail creates a thumbnail from persisted data (for repository use)
+func ReconstituteThumbnail(drawingID uuid.UUID, image []byte, version int64, createdAt time.Time) *Thumbnail {
+	return &Thumbnail{
+		drawingID: drawingID,
+		image:     image,
+		version:   version,
+		createdAt: createdAt,
+	}
+}
+
+// ThumbnailOptions returns the export options thumbnails are rendered with
+func ThumbnailOptions() ExportOptions {
+	return ExportOptions{Padding: DefaultExportPadding, MaxDimension: ThumbnailMaxDimension}
+}
+
+// DrawingID returns the ID of the drawing the thumbnail shows
+func (t *Thumbnail) DrawingID() uuid.UUID {
+	return t.drawingID
+}
+
+// Image returns the PNG encoded thumbnail
+func (t *Thumbnail) Image() []byte {
+	return t.image
+}
+
+// Version returns the drawing version the thumbnail was rendered from
+func (t *Thumbnail) Version() int64 {
+	return t.version
+}
+
+// CreatedAt returns when the thumbnail was rendered
+func (t *Thumbnail) CreatedAt() time.Time {
+	return t.createdAt
+}
+
+// IsCurrent reports whether the thumbnail shows the current version of d
+func (t *Thumbnail) IsCurrent(d *Drawing) bool {
+	return t.version >= d.Version()
+}
@@ -1,6 +1,7 @@
 package drawing
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 )
@@ -9,8 +10,19 @@ import (
 // It stores the drawing elements, appState, and files
 type DrawingData map[string]interface{}
 
-// Validate ensures the drawing data structure is valid
+// Validate ensures the drawing data is a structurally valid Excalidraw scene
+// Invalid scenes return a *SceneError listing every invalid field
 func (d DrawingData) Validate() error {
+	if err := d.validateEncoding(); err != nil {
+		return err
+	}
+
+	_, err := d.Scene()
+	return err
+}
+
+// validateEncoding ensures the drawing data can be stored as JSON
+func (d DrawingData) validateEncoding() error {
 	if d == nil {
 		return fmt.Errorf("%w: data cannot be nil", ErrInvalidDrawingData)
 	}
@@ -25,7 +37,7 @@ func (d DrawingData) Validate() error {
 
 // ToJSON converts DrawingData to JSON bytes for storage
 func (d DrawingData) ToJSON() ([]byte, error) {
-	if err := d.Validate(); err != nil {
+	if err := d.validateEncoding(); err != nil {
 		return nil, err
 	}
 
@@ -37,6 +49,19 @@ func (d DrawingData) ToJSON() ([]byte, error) {
 	return data, nil
 }
 
+// Equal reports whether the data serializes to the same JSON as other
+func (d DrawingData) Equal(other DrawingData) bool {
+	a, err := d.ToJSON()
+	if err != nil {
+		return false
+	}
+	b, err := other.ToJSON()
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(a, b)
+}
+
 // FromJSON creates DrawingData from JSON bytes
 func FromJSON(data []byte) (DrawingData, error) {
 	if len(data) == 0 {
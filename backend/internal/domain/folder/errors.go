@@ -0,0 +1,26 @@
+package folder
+
+import "errors"
+
+var (
+	// ErrFolderNotFound is returned when a folder is not found
+	ErrFolderNotFound = errors.New("folder not found")
+
+	// ErrEmptyName is returned when a folder name is empty
+	ErrEmptyName = errors.New("folder name cannot be empty")
+
+	// ErrNameTooLong is returned when a folder name exceeds maximum length
+	ErrNameTooLong = errors.New("folder name exceeds maximum length")
+
+	// ErrNameConflict is returned when a folder already holds a subfolder with the same name
+	ErrNameConflict = errors.New("folder name already exists in the parent folder")
+
+	// ErrFolderCycle is returned when a folder would be moved into itself or one of its descendants
+	ErrFolderCycle = errors.New("folder cannot be moved into itself or one of its subfolders")
+
+	// ErrFolderNotEmpty is returned when deleting a folder that still holds subfolders or drawings
+	ErrFolderNotEmpty = errors.New("folder is not empty")
+
+	// ErrInvalidDeleteMode is returned when a folder is deleted with an unknown mode
+	ErrInvalidDeleteMode = errors.New("invalid folder delete mode")
+)
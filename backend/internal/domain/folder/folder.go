@@ -0,0 +1,122 @@
+package folder
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxNameLength is the maximum allowed length for a folder name
+	MaxNameLength = 255
+)
+
+// Folder represents the folder aggregate root
+// Folders nest through a parent pointer; a nil parent places the folder at the top level
+type Folder struct {
+	id        uuid.UUID
+	parentID  *uuid.UUID
+	name      string
+	createdAt time.Time
+	updatedAt time.Time
+}
+
+// NewFolder creates a new folder in a parent folder, or at the top level when parentID is nil
+func NewFolder(name string, parentID *uuid.UUID) (*Folder, error) {
+	f := &Folder{
+		id:        uuid.New(),
+		parentID:  parentID,
+		name:      strings.TrimSpace(name),
+		createdAt: time.Now().UTC(),
+		updatedAt: time.Now().UTC(),
+	}
+
+	if err := f.validateName(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Reconstitute creates a folder from persisted data (for repository use)
+func Reconstitute(id uuid.UUID, parentID *uuid.UUID, name string, createdAt, updatedAt time.Time) *Folder {
+	return &Folder{
+		id:        id,
+		parentID:  parentID,
+		name:      name,
+		createdAt: createdAt,
+		updatedAt: updatedAt,
+	}
+}
+
+// Rename changes the name of the folder
+func (f *Folder) Rename(name string) error {
+	previous := f.name
+	f.name = strings.TrimSpace(name)
+	if err := f.validateName(); err != nil {
+		f.name = previous
+		return err
+	}
+
+	f.updatedAt = time.Now().UTC()
+	return nil
+}
+
+// MoveTo moves the folder into a parent folder, or to the top level when parentID is nil
+// ancestors lists the IDs of the folders above the new parent; a folder cannot move into itself
+// or into one of its own descendants
+func (f *Folder) MoveTo(parentID *uuid.UUID, ancestors []uuid.UUID) error {
+	if parentID != nil {
+		if *parentID == f.id {
+			return ErrFolderCycle
+		}
+		for _, id := range ancestors {
+			if id == f.id {
+				return ErrFolderCycle
+			}
+		}
+	}
+
+	f.parentID = parentID
+	f.updatedAt = time.Now().UTC()
+	return nil
+}
+
+// validateName checks if the name is valid
+func (f *Folder) validateName() error {
+	if f.name == "" {
+		return ErrEmptyName
+	}
+
+	if len(f.name) > MaxNameLength {
+		return ErrNameTooLong
+	}
+
+	return nil
+}
+
+// ID returns the folder ID
+func (f *Folder) ID() uuid.UUID {
+	return f.id
+}
+
+// ParentID returns the ID of the parent folder; nil for top-level folders
+func (f *Folder) ParentID() *uuid.UUID {
+	return f.parentID
+}
+
+// Name returns the folder name
+func (f *Folder) Name() string {
+	return f.name
+}
+
+// CreatedAt returns the creation timestamp
+func (f *Folder) CreatedAt() time.Time {
+	return f.createdAt
+}
+
+// UpdatedAt returns the last update timestamp
+func (f *Folder) UpdatedAt() time.Time {
+	return f.updatedAt
+}
@@ -0,0 +1,55 @@
+package folder
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// DeleteMode selects what happens to the contents of a folder when it is deleted
+type DeleteMode string
+
+const (
+	// DeleteRestrict only deletes empty folders, failing with ErrFolderNotEmpty otherwise
+	DeleteRestrict DeleteMode = "restrict"
+
+	// DeleteReparent moves the subfolders and drawings of the folder up into its parent first
+	DeleteReparent DeleteMode = "reparent"
+
+	// DeleteCascade deletes the subfolders of the folder and every drawing inside them
+	DeleteCascade DeleteMode = "cascade"
+)
+
+// Valid reports whether m is a known delete mode
+func (m DeleteMode) Valid() bool {
+	return m == DeleteRestrict || m == DeleteReparent || m == DeleteCascade
+}
+
+// Repository defines the contract for folder persistence
+type Repository interface {
+	// Create stores a new folder
+	// Returns ErrNameConflict if the parent already holds a folder with the same name
+	Create(ctx context.Context, folder *Folder) error
+
+	// FindByID retrieves a folder by ID
+	FindByID(ctx context.Context, id uuid.UUID) (*Folder, error)
+
+	// FindAll retrieves all folders ordered by name, for clients to assemble the tree
+	FindAll(ctx context.Context) ([]*Folder, error)
+
+	// FindAncestorIDs retrieves the IDs of the folders above a folder, nearest first
+	FindAncestorIDs(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error)
+
+	// Rename stores the name of an existing folder, leaving its parent as currently stored
+	// Returns ErrNameConflict if the parent already holds a folder with the same name
+	Rename(ctx context.Context, folder *Folder) error
+
+	// Move stores the new parent of an existing folder, leaving its name as currently stored,
+	// and checks the move against the current tree
+	// Returns ErrFolderCycle if the new parent lies inside the folder, ErrFolderNotFound if either
+	// folder is gone and ErrNameConflict if the new parent already holds a folder with the same name
+	Move(ctx context.Context, folder *Folder) error
+
+	// Delete removes a folder, handling its subfolders and drawings according to mode
+	Delete(ctx context.Context, id uuid.UUID, mode DeleteMode) error
+}
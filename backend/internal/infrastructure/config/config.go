@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"strconv"
 	"strings"
@@ -15,6 +16,7 @@ type Config struct {
 	Logger   LoggerConfig
 	Database DatabaseConfig
 	Auth     AuthConfig
+	Revision RevisionConfig
 }
 
 // ServerConfig holds server-related configuration
@@ -30,6 +32,7 @@ type CORSConfig struct {
 	AllowedOrigins []string
 	AllowedMethods []string
 	AllowedHeaders []string
+	ExposedHeaders []string
 }
 
 // LoggerConfig holds logger-related configuration
@@ -56,6 +59,14 @@ type AuthConfig struct {
 	Enabled   bool
 }
 
+// RevisionConfig holds drawing revision retention configuration
+type RevisionConfig struct {
+	CoalesceSeconds           int // saves by the same client within this window share one revision
+	KeepAllMinutes            int // every revision younger than this is kept
+	KeepHourlyHours           int // then one revision per hour is kept up to this age, one per day afterwards
+	CompactionIntervalMinutes int // how often the compaction worker runs
+}
+
 // Load loads configuration from environment variables
 func Load() (*Config, error) {
 	// Load .env file if exists (ignore error if not found)
@@ -70,8 +81,9 @@ func Load() (*Config, error) {
 		},
 		CORS: CORSConfig{
 			AllowedOrigins: getEnvList("CORS_ALLOWED_ORIGINS", []string{"http://localhost:5173"}),
-			AllowedMethods: getEnvList("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
-			AllowedHeaders: getEnvList("CORS_ALLOWED_HEADERS", []string{"Content-Type", "Authorization"}),
+			AllowedMethods: getEnvList("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),
+			AllowedHeaders: getEnvList("CORS_ALLOWED_HEADERS", []string{"Content-Type", "Authorization", "X-Client-ID", "If-Match", "If-None-Match", "If-Modified-Since"}),
+			ExposedHeaders: getEnvList("CORS_EXPOSED_HEADERS", []string{"ETag", "Last-Modified"}),
 		},
 		Logger: LoggerConfig{
 			Level:  getEnv("LOG_LEVEL", "info"),
@@ -91,11 +103,35 @@ func Load() (*Config, error) {
 			AccessKey: getEnv("ACCESS_KEY", ""),
 			Enabled:   getEnv("AUTH_ENABLED", "true") == "true",
 		},
+		Revision: RevisionConfig{
+			CoalesceSeconds:           getEnvInt("REVISION_COALESCE_SECONDS", 60),
+			KeepAllMinutes:            getEnvInt("REVISION_KEEP_ALL_MINUTES", 60),
+			KeepHourlyHours:           getEnvInt("REVISION_KEEP_HOURLY_HOURS", 24),
+			CompactionIntervalMinutes: getEnvInt("REVISION_COMPACTION_INTERVAL_MINUTES", 15),
+		},
+	}
+
+	if err := cfg.Revision.validate(); err != nil {
+		return nil, err
 	}
 
 	return cfg, nil
 }
 
+// validate rejects retention settings that would make revision pruning unpredictable
+func (c RevisionConfig) validate() error {
+	if c.CoalesceSeconds < 0 || c.KeepAllMinutes < 0 || c.KeepHourlyHours < 0 {
+		return errors.New("revision coalesce and retention windows must not be negative")
+	}
+	if c.KeepAllMinutes > c.KeepHourlyHours*60 {
+		return errors.New("REVISION_KEEP_ALL_MINUTES must not exceed REVISION_KEEP_HOURLY_HOURS")
+	}
+	if c.CompactionIntervalMinutes <= 0 {
+		return errors.New("REVISION_COMPACTION_INTERVAL_MINUTES must be positive")
+	}
+	return nil
+}
+
 // getEnv retrieves an environment variable or returns a default value
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -0,0 +1,16 @@
+-- Create table of immutable drawing snapshots
+CREATE TABLE drawing_revisions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    drawing_id UUID NOT NULL REFERENCES drawings(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    data JSONB NOT NULL,
+    restored_from INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (drawing_id, revision)
+);
+
+-- Seed the history of existing drawings with their current state as revision 1
+INSERT INTO drawing_revisions (drawing_id, revision, name, data, created_at)
+SELECT id, 1, name, data, updated_at
+FROM drawings;
@@ -0,0 +1,3 @@
+-- Drop the index and column
+DROP INDEX IF EXISTS idx_drawing_revisions_created_at;
+ALTER TABLE drawing_revisions DROP COLUMN IF EXISTS client_id;
@@ -0,0 +1,2 @@
+-- Add a version counter incremented on every update for optimistic concurrency control
+ALTER TABLE drawings ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,4 @@
+-- Drop the scene schema version columns
+DROP INDEX IF EXISTS idx_drawings_schema_version;
+ALTER TABLE drawing_revisions DROP COLUMN IF EXISTS schema_version;
+ALTER TABLE drawings DROP COLUMN IF EXISTS schema_version;
@@ -0,0 +1,2 @@
+-- Drop the drawing thumbnails table
+DROP TABLE IF EXISTS drawing_thumbnails;
@@ -0,0 +1,7 @@
+-- Create table of rendered drawing previews, one per drawing
+CREATE TABLE drawing_thumbnails (
+    drawing_id UUID PRIMARY KEY REFERENCES drawings(id) ON DELETE CASCADE,
+    image BYTEA NOT NULL,
+    version BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,3 @@
+-- Restore the index on created_at alone
+CREATE INDEX idx_drawings_created_at ON drawings(created_at DESC);
+DROP INDEX IF EXISTS idx_drawings_created_at_id;
@@ -0,0 +1,9 @@
+-- Drop the list filter and sort indexes and columns
+DROP INDEX IF EXISTS idx_drawings_element_count;
+DROP INDEX IF EXISTS idx_drawings_name_trgm;
+DROP INDEX IF EXISTS idx_drawings_byte_size_id;
+DROP INDEX IF EXISTS idx_drawings_updated_at_id;
+DROP INDEX IF EXISTS idx_drawings_name_id;
+ALTER TABLE drawings DROP COLUMN IF EXISTS byte_size;
+ALTER TABLE drawings DROP COLUMN IF EXISTS element_count;
+DROP FUNCTION IF EXISTS drawing_element_count(JSONB);
@@ -0,0 +1,4 @@
+-- Drop the full-text search document
+DROP INDEX IF EXISTS idx_drawings_search_vector;
+ALTER TABLE drawings DROP COLUMN IF EXISTS search_vector;
+DROP FUNCTION IF EXISTS drawing_search_text(JSONB);
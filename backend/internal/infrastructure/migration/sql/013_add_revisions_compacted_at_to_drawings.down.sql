@@ -0,0 +1,2 @@
+-- Drop the revision compaction high-water mark
+ALTER TABLE drawings DROP COLUMN IF EXISTS revisions_compacted_at;
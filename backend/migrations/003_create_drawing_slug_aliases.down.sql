@@ -0,0 +1,2 @@
+-- Drop the slug aliases table
+DROP TABLE IF EXISTS drawing_slug_aliases;
@@ -0,0 +1,9 @@
+-- Create table of previous slugs so renamed drawings keep resolving
+CREATE TABLE drawing_slug_aliases (
+    slug VARCHAR(50) PRIMARY KEY,
+    drawing_id UUID NOT NULL REFERENCES drawings(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create index on drawing_id for cascading deletes and lookups by drawing
+CREATE INDEX idx_drawing_slug_aliases_drawing_id ON drawing_slug_aliases(drawing_id);
@@ -0,0 +1,2 @@
+-- Drop the drawing revisions table
+DROP TABLE IF EXISTS drawing_revisions;
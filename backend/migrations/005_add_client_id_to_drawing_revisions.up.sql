@@ -0,0 +1,5 @@
+-- Add the client that saved each revision so autosave bursts can be coalesced
+ALTER TABLE drawing_revisions ADD COLUMN client_id VARCHAR(64) NOT NULL DEFAULT '';
+
+-- Create index on created_at for finding revisions eligible for compaction
+CREATE INDEX idx_drawing_revisions_created_at ON drawing_revisions(created_at);
@@ -0,0 +1,2 @@
+-- Drop the version column
+ALTER TABLE drawings DROP COLUMN IF EXISTS version;
@@ -0,0 +1,6 @@
+-- Record the scene schema version of stored drawing data so old scenes can be upgraded on read
+ALTER TABLE drawings ADD COLUMN schema_version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE drawing_revisions ADD COLUMN schema_version INTEGER NOT NULL DEFAULT 1;
+
+-- Speed up finding drawings that still need a bulk rewrite
+CREATE INDEX idx_drawings_schema_version ON drawings(schema_version);
@@ -0,0 +1,3 @@
+-- Index the list order with its tie-breaker, so cursor pages seek instead of scanning past earlier rows
+CREATE INDEX idx_drawings_created_at_id ON drawings(created_at DESC, id DESC);
+DROP INDEX IF EXISTS idx_drawings_created_at;
@@ -0,0 +1,18 @@
+-- Collect the searchable text of a scene: the text of text elements, including text bound inside
+-- shapes, and the names of frames, one per line
+CREATE OR REPLACE FUNCTION drawing_search_text(data JSONB) RETURNS TEXT
+LANGUAGE sql IMMUTABLE AS $$
+    SELECT COALESCE(string_agg(CASE WHEN e->>'type' = 'text' THEN e->>'text' ELSE e->>'name' END, E'\n'), '')
+    FROM jsonb_array_elements(CASE WHEN jsonb_typeof(data->'elements') = 'array' THEN data->'elements' ELSE '[]'::jsonb END) e
+    WHERE COALESCE(e->>'isDeleted', 'false') <> 'true'
+        AND e->>'type' IN ('text', 'frame', 'magicframe')
+$$;
+
+-- Keep a full-text search document of the name and scene text, recomputed on every write;
+-- matches in the name weigh more than matches in the scene
+ALTER TABLE drawings ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', name), 'A') ||
+    setweight(to_tsvector('english', drawing_search_text(data)), 'B')
+) STORED;
+
+CREATE INDEX idx_drawings_search_vector ON drawings USING GIN (search_vector);
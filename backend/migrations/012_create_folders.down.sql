@@ -0,0 +1,4 @@
+-- Take drawings out of folders and drop the folders table
+DROP INDEX IF EXISTS idx_drawings_folder_id;
+ALTER TABLE drawings DROP COLUMN IF EXISTS folder_id;
+DROP TABLE IF EXISTS folders;
@@ -0,0 +1,24 @@
+-- Create folders table; folders nest through parent_id, NULL at the top level
+CREATE TABLE folders (
+    id UUID PRIMARY KEY,
+    parent_id UUID REFERENCES folders(id),
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    -- Sibling folders have distinct names
+    CONSTRAINT folders_parent_id_name_key UNIQUE (parent_id, name)
+);
+
+-- Top-level folders have distinct names too; the constraint above treats their NULL parents as distinct
+CREATE UNIQUE INDEX idx_folders_top_level_name ON folders(name) WHERE parent_id IS NULL;
+
+-- Speed up finding the subfolders of a folder
+CREATE INDEX idx_folders_parent_id ON folders(parent_id);
+
+-- Place drawings in folders; NULL keeps a drawing outside any folder
+-- Non-empty folders cannot be deleted until their drawings are moved or deleted
+ALTER TABLE drawings ADD COLUMN folder_id UUID REFERENCES folders(id);
+
+-- Speed up listing the drawings of a folder
+CREATE INDEX idx_drawings_folder_id ON drawings(folder_id);
@@ -0,0 +1,3 @@
+-- Record when the revisions of a drawing were last compacted, so the compaction worker only
+-- revisits drawings whose revisions have aged into a coarser retention bucket since then
+ALTER TABLE drawings ADD COLUMN revisions_compacted_at TIMESTAMP;
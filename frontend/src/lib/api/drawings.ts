@@ -1,19 +1,93 @@
 // API Types (matching backend)
 export interface DrawingDTO {
 	id: string
+	slug: string
 	name: string
 	data: Record<string, unknown>
+	version: number
+	// Null outside any folder
+	folder_id: string | null
+	created_at: string
+	updated_at: string
+}
+
+// Drawings in lists leave the scene out unless asked for with fields
+export interface DrawingSummaryDTO {
+	id: string
+	slug: string
+	name: string
+	version: number
+	element_count: number
+	byte_size: number
+	// Fetch with the Authorization header
+	thumbnail_url: string
+	thumbnail_version?: number
+	data?: Record<string, unknown>
+	folder_id: string | null
 	created_at: string
 	updated_at: string
 }
 
 export interface DrawingListResponse {
-	drawings: DrawingDTO[]
+	drawings: DrawingSummaryDTO[]
+	total: number
+	limit: number
+	offset: number
+	// Pass back as the cursor parameter to fetch the pages around; omitted at either end
+	next_cursor?: string
+	prev_cursor?: string
+}
+
+export interface SearchResultDTO {
+	id: string
+	slug: string
+	name: string
+	version: number
+	rank: number
+	// HTML with the matches wrapped in <mark>; the drawing's text is escaped
+	snippet: string
+	// Text elements and frames that match, in scene order
+	element_ids: string[]
+	updated_at: string
+}
+
+export interface SearchResponse {
+	results: SearchResultDTO[]
 	total: number
 	limit: number
 	offset: number
 }
 
+// Sorting and filtering of the drawing list; dates are RFC 3339 timestamps or YYYY-MM-DD
+export interface ListDrawingsParams {
+	limit?: number
+	offset?: number
+	sort?: 'name' | 'created_at' | 'updated_at' | 'size'
+	order?: 'asc' | 'desc'
+	name_prefix?: string
+	name_contains?: string
+	created_after?: string
+	created_before?: string
+	updated_after?: string
+	updated_before?: string
+	min_elements?: number
+	max_elements?: number
+	// A folder ID, or root for the drawings outside any folder
+	folder_id?: string
+}
+
+// Folders nest through parent_id, null at the top level
+export interface FolderDTO {
+	id: string
+	parent_id: string | null
+	name: string
+	created_at: string
+	updated_at: string
+}
+
+// restrict refuses non-empty folders, reparent moves the contents up and cascade deletes them
+export type FolderDeleteMode = 'restrict' | 'reparent' | 'cascade'
+
 export interface CreateDrawingRequest {
 	name: string
 	data: Record<string, unknown>
@@ -21,13 +95,24 @@ export interface CreateDrawingRequest {
 
 export interface UpdateDrawingRequest {
 	name?: string
+	slug?: string
 	data?: Record<string, unknown>
 }
 
+export type JSONPatchOperation =
+	| { op: 'add' | 'replace' | 'test'; path: string; value: unknown }
+	| { op: 'remove'; path: string }
+	| { op: 'move' | 'copy'; from: string; path: string }
+
 // API Client
 class DrawingsAPI {
 	private baseURL = '/api'
 
+	/**
+	 * Identifies this browser tab so the backend can coalesce autosave revisions
+	 */
+	private clientId = crypto.randomUUID()
+
 	/**
 	 * Centralized fetch wrapper with auth header injection and 401 handling
 	 */
@@ -59,16 +144,32 @@ class DrawingsAPI {
 		return response
 	}
 
-	async list(params?: { limit?: number; offset?: number }): Promise<DrawingListResponse> {
+	async list(params?: ListDrawingsParams): Promise<DrawingListResponse> {
 		const query = new URLSearchParams({
 			limit: String(params?.limit || 10),
 			offset: String(params?.offset || 0)
 		})
+		for (const [key, value] of Object.entries(params ?? {})) {
+			if (key !== 'limit' && key !== 'offset' && value !== undefined && value !== '') {
+				query.set(key, String(value))
+			}
+		}
 		const response = await this.fetchWithAuth(`${this.baseURL}/drawings?${query}`)
 		if (!response.ok) throw new Error('Failed to fetch drawings')
 		return response.json()
 	}
 
+	async search(q: string, params?: { limit?: number; offset?: number }): Promise<SearchResponse> {
+		const query = new URLSearchParams({
+			q,
+			limit: String(params?.limit || 10),
+			offset: String(params?.offset || 0)
+		})
+		const response = await this.fetchWithAuth(`${this.baseURL}/drawings/search?${query}`)
+		if (!response.ok) throw new Error('Failed to search drawings')
+		return response.json()
+	}
+
 	async get(id: string): Promise<DrawingDTO> {
 		const response = await this.fetchWithAuth(`${this.baseURL}/drawings/${id}`)
 		if (!response.ok) throw new Error('Failed to fetch drawing')
@@ -85,22 +186,119 @@ class DrawingsAPI {
 		return response.json()
 	}
 
-	async update(id: string, data: UpdateDrawingRequest): Promise<DrawingDTO> {
-		const response = await this.fetchWithAuth(`${this.baseURL}/drawings/${id}`, {
+	/**
+	 * Pass the version the drawing was loaded at to reject the save if another tab saved in between,
+	 * or merge to reconcile the scene with changes other tabs saved
+	 */
+	async update(
+		id: string,
+		data: UpdateDrawingRequest,
+		options: { version?: number; merge?: boolean } = {}
+	): Promise<DrawingDTO> {
+		const headers: Record<string, string> = { 'Content-Type': 'application/json', 'X-Client-ID': this.clientId }
+		if (options.version !== undefined) {
+			headers['If-Match'] = `"${options.version}"`
+		}
+		const query = options.merge ? '?merge=true' : ''
+		const response = await this.fetchWithAuth(`${this.baseURL}/drawings/${id}${query}`, {
 			method: 'PUT',
-			headers: { 'Content-Type': 'application/json' },
+			headers,
 			body: JSON.stringify(data)
 		})
+		if (response.status === 412) throw new Error('Drawing was modified in another tab')
 		if (!response.ok) throw new Error('Failed to update drawing')
 		return response.json()
 	}
 
+	/**
+	 * Send only the changed parts of the scene as an RFC 6902 JSON Patch
+	 */
+	async patch(id: string, operations: JSONPatchOperation[], version?: number): Promise<DrawingDTO> {
+		const headers: Record<string, string> = {
+			'Content-Type': 'application/json-patch+json',
+			'X-Client-ID': this.clientId
+		}
+		if (version !== undefined) {
+			headers['If-Match'] = `"${version}"`
+		}
+		const response = await this.fetchWithAuth(`${this.baseURL}/drawings/${id}`, {
+			method: 'PATCH',
+			headers,
+			body: JSON.stringify(operations)
+		})
+		if (response.status === 412) throw new Error('Drawing was modified in another tab')
+		if (!response.ok) throw new Error('Failed to patch drawing')
+		return response.json()
+	}
+
 	async delete(id: string): Promise<void> {
 		const response = await this.fetchWithAuth(`${this.baseURL}/drawings/${id}`, {
 			method: 'DELETE'
 		})
 		if (!response.ok) throw new Error('Failed to delete drawing')
 	}
+
+	/**
+	 * Move a drawing into a folder, or out of any folder with null
+	 */
+	async moveToFolder(id: string, folderId: string | null): Promise<void> {
+		const response = await this.fetchWithAuth(`${this.baseURL}/drawings/${id}/move`, {
+			method: 'POST',
+			headers: { 'Content-Type': 'application/json' },
+			body: JSON.stringify({ folder_id: folderId })
+		})
+		if (!response.ok) throw new Error('Failed to move drawing')
+	}
+
+	async listFolders(): Promise<FolderDTO[]> {
+		const response = await this.fetchWithAuth(`${this.baseURL}/folders`)
+		if (!response.ok) throw new Error('Failed to fetch folders')
+		const body: { folders: FolderDTO[] } = await response.json()
+		return body.folders
+	}
+
+	async createFolder(name: string, parentId: string | null = null): Promise<FolderDTO> {
+		const response = await this.fetchWithAuth(`${this.baseURL}/folders`, {
+			method: 'POST',
+			headers: { 'Content-Type': 'application/json' },
+			body: JSON.stringify({ name, parent_id: parentId })
+		})
+		if (response.status === 409) throw new Error('A folder with this name already exists')
+		if (!response.ok) throw new Error('Failed to create folder')
+		return response.json()
+	}
+
+	async renameFolder(id: string, name: string): Promise<FolderDTO> {
+		const response = await this.fetchWithAuth(`${this.baseURL}/folders/${id}`, {
+			method: 'PUT',
+			headers: { 'Content-Type': 'application/json' },
+			body: JSON.stringify({ name })
+		})
+		if (response.status === 409) throw new Error('A folder with this name already exists')
+		if (!response.ok) throw new Error('Failed to rename folder')
+		return response.json()
+	}
+
+	/**
+	 * Move a folder with its contents into another folder, or to the top level with null
+	 */
+	async moveFolder(id: string, parentId: string | null): Promise<FolderDTO> {
+		const response = await this.fetchWithAuth(`${this.baseURL}/folders/${id}/move`, {
+			method: 'POST',
+			headers: { 'Content-Type': 'application/json' },
+			body: JSON.stringify({ parent_id: parentId })
+		})
+		if (!response.ok) throw new Error('Failed to move folder')
+		return response.json()
+	}
+
+	async deleteFolder(id: string, mode: FolderDeleteMode = 'restrict'): Promise<void> {
+		const response = await this.fetchWithAuth(`${this.baseURL}/folders/${id}?mode=${mode}`, {
+			method: 'DELETE'
+		})
+		if (response.status === 409) throw new Error('Folder is not empty')
+		if (!response.ok) throw new Error('Failed to delete folder')
+	}
 }
 
 export const drawingsAPI = new DrawingsAPI()